@@ -8,6 +8,7 @@ import (
 	"github.com/ddx2x/oilmont/pkg/datasource/mongo"
 	"github.com/ddx2x/oilmont/pkg/log"
 	logruslogger "github.com/ddx2x/oilmont/pkg/log/logrus"
+	"github.com/ddx2x/oilmont/pkg/metrics"
 	"github.com/ddx2x/oilmont/pkg/thirdparty/signals"
 	"github.com/sirupsen/logrus"
 )
@@ -35,7 +36,7 @@ func main() {
 		panic(err)
 	}
 
-	server, err := cr.NewCustomResourceServer("cr", store)
+	server, err := cr.NewCustomResourceServer("cr", metrics.InstrumentStorage(store))
 	if err != nil {
 		panic(err)
 	}
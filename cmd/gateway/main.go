@@ -12,6 +12,7 @@ import (
 	"github.com/ddx2x/oilmont/pkg/datasource/mongo"
 	"github.com/ddx2x/oilmont/pkg/log"
 	logruslogger "github.com/ddx2x/oilmont/pkg/log/logrus"
+	"github.com/ddx2x/oilmont/pkg/metrics"
 	"github.com/ddx2x/oilmont/pkg/micro/gateway"
 	"github.com/sirupsen/logrus"
 )
@@ -37,10 +38,11 @@ func main() {
 		uri = DefaultStorageUrl
 	}
 
-	stage, err, errC := mongo.NewMongo(ctx, uri)
+	store, err, errC := mongo.NewMongo(ctx, uri)
 	if err != nil {
 		panic(fmt.Sprintf("init mongodb database connect error %s", err))
 	}
+	stage := metrics.InstrumentStorage(store)
 
 	// add gvr to local data storage
 	k8s.SetStorage(stage)
@@ -5,9 +5,14 @@ import (
 	"os"
 
 	"github.com/ddx2x/oilmont/pkg/controller/iamctrl"
+	"github.com/ddx2x/oilmont/pkg/controller/meterctrl"
+	"github.com/ddx2x/oilmont/pkg/controller/quotactrl"
+	"github.com/ddx2x/oilmont/pkg/controller/schedulectrl"
+	"github.com/ddx2x/oilmont/pkg/controller/stackctrl"
 	"github.com/ddx2x/oilmont/pkg/datasource/mongo"
 	"github.com/ddx2x/oilmont/pkg/log"
 	logruslogger "github.com/ddx2x/oilmont/pkg/log/logrus"
+	"github.com/ddx2x/oilmont/pkg/metrics"
 	"github.com/ddx2x/oilmont/pkg/thirdparty/signals"
 	"github.com/sirupsen/logrus"
 )
@@ -15,6 +20,9 @@ import (
 var uri string
 var DefaultStorageUrl = "mongodb://127.0.0.1:27017/admin"
 
+var metricsAddr string
+var DefaultMetricsAddr = ":9090"
+
 func main() {
 	stopCh := signals.SetupSignalHandler()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -36,8 +44,42 @@ func main() {
 		panic(err)
 	}
 
+	metricsAddr = os.Getenv("METRICS_ADDR")
+	if metricsAddr == "" {
+		metricsAddr = DefaultMetricsAddr
+	}
+	go func() {
+		if err := metrics.Serve(ctx, metricsAddr); err != nil {
+			errC <- err
+		}
+	}()
+
+	go func() {
+		if err := iamctrl.NewRBACController(metrics.InstrumentStorage(stage)).Run(); err != nil {
+			errC <- err
+		}
+	}()
+
+	go func() {
+		if err := quotactrl.NewQuotaController(metrics.InstrumentStorage(stage)).Run(); err != nil {
+			errC <- err
+		}
+	}()
+
+	go func() {
+		if err := meterctrl.NewMeterController(metrics.InstrumentStorage(stage)).Run(); err != nil {
+			errC <- err
+		}
+	}()
+
+	go func() {
+		if err := schedulectrl.NewScheduleController(metrics.InstrumentStorage(stage)).Run(); err != nil {
+			errC <- err
+		}
+	}()
+
 	go func() {
-		if err := iamctrl.NewRBACController(stage).Run(); err != nil {
+		if err := stackctrl.NewStackController(metrics.InstrumentStorage(stage)).Run(); err != nil {
 			errC <- err
 		}
 	}()
@@ -10,6 +10,7 @@ import (
 	"github.com/ddx2x/oilmont/pkg/k8s"
 	"github.com/ddx2x/oilmont/pkg/log"
 	logruslogger "github.com/ddx2x/oilmont/pkg/log/logrus"
+	"github.com/ddx2x/oilmont/pkg/metrics"
 	"github.com/ddx2x/oilmont/pkg/thirdparty/signals"
 	"github.com/sirupsen/logrus"
 )
@@ -35,10 +36,11 @@ func main() {
 		uri = DefaultStorageUrl
 	}
 
-	stage, err, errC := mongo.NewMongo(ctx, uri)
+	store, err, errC := mongo.NewMongo(ctx, uri)
 	if err != nil {
 		panic(fmt.Sprintf("init mongodb database connect error %s", err))
 	}
+	stage := metrics.InstrumentStorage(store)
 
 	// add gvr to local data storage
 	k8s.SetStorage(stage)
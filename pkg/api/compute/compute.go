@@ -0,0 +1,199 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/controller/vmctrl"
+	"github.com/ddx2x/oilmont/pkg/controller/vpcctrl"
+	"github.com/ddx2x/oilmont/pkg/controller/vswitchctrl"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/datasource/cache"
+	"github.com/ddx2x/oilmont/pkg/micro/webservice"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/service/compute"
+	"github.com/ddx2x/oilmont/pkg/service/networking"
+)
+
+type computeServer struct {
+	api.IAPIServer
+	webServer      webservice.Server
+	virtualMachine *compute.VirtualMachineService
+	storage        *compute.StorageService
+	image          *compute.ImageService
+
+	virtualMachineSnapshot *compute.VirtualMachineSnapshotService
+	virtualMachineRestore  *compute.VirtualMachineRestoreService
+
+	virtualPrivateCloud *networking.VirtualPrivateCloudService
+	vswitch             *networking.VSwitchService
+	elasticIP           *networking.ElasticIPService
+	loadBalancer        *networking.LoadBalancerService
+
+	// dryRun plan the changes of ?dryRun=true requests, validated by the services that persist nothing
+	dryRun                    *controller.DryRun
+	dryRunVirtualMachine      *compute.VirtualMachineService
+	dryRunVirtualPrivateCloud *networking.VirtualPrivateCloudService
+	dryRunVSwitch             *networking.VSwitchService
+}
+
+func (c *computeServer) Run() error {
+	return c.webServer.Run()
+}
+
+func NewComputeServer(serviceName string, storage datasource.IStorage) (*computeServer, error) {
+	c := cache.NewCache(15*time.Minute, 20*time.Minute)
+	baseService := service.NewBaseService(storage, c)
+	baseServer := api.NewBaseAPIServer(baseService)
+	dryRunService := service.DryRun(baseService)
+
+	server := &computeServer{
+		IAPIServer:     baseServer,
+		virtualMachine: compute.NewVirtualMachineService(baseService),
+		storage:        compute.NewStorageService(baseService),
+		image:          compute.NewImageService(baseService),
+
+		virtualMachineSnapshot: compute.NewVirtualMachineSnapshotService(baseService),
+		virtualMachineRestore:  compute.NewVirtualMachineRestoreService(baseService),
+
+		virtualPrivateCloud: networking.NewVirtualPrivateCloudService(baseService),
+		vswitch:             networking.NewVSwitchService(baseService),
+		elasticIP:           networking.NewElasticIPService(baseService),
+		loadBalancer:        networking.NewLoadBalancerService(baseService),
+
+		dryRun:                    controller.NewDryRun(storage),
+		dryRunVirtualMachine:      compute.NewVirtualMachineService(dryRunService),
+		dryRunVirtualPrivateCloud: networking.NewVirtualPrivateCloudService(dryRunService),
+		dryRunVSwitch:             networking.NewVSwitchService(dryRunService),
+	}
+
+	ctx := context.Background()
+	for table, handler := range map[string]controller.Handler{
+		common.VIRTUALMACHINE: vmctrl.NewVMCtrl(ctx),
+		common.VPC:            vpcctrl.NewVPCtrl(ctx),
+		common.VSWITCH:        vswitchctrl.NewVSwitchCtrl(ctx),
+	} {
+		if err := server.dryRun.Register(table, handler); err != nil {
+			return nil, err
+		}
+	}
+
+	webServer, err := webservice.NewWEBServer(serviceName, "", server.Server())
+	if err != nil {
+		return nil, err
+	}
+	server.webServer = webServer
+
+	group := server.Server().Group(fmt.Sprintf("/%s", serviceName))
+
+	// virtualmachine
+	{
+		api.GenerateURIV2(group, "compute.ddx2x.nip", "v1", "virtualmachine", true,
+			server.ListVirtualMachine,
+			server.ListVirtualMachine,
+			server.CreateVirtualMachine,
+			server.UpdateVirtualMachine,
+			server.DeleteVirtualMachine,
+		)
+		group.POST(api.GenerateURI("compute.ddx2x.nip", "v1", "virtualmachine/:name/op/:action", true), server.ActionVirtualMachine)
+		group.POST(api.GenerateURI("compute.ddx2x.nip", "v1", "virtualmachine/:name/op/:action", false), server.ActionVirtualMachine)
+	}
+
+	// storage
+	{
+		api.GenerateURIV2(group, "compute.ddx2x.nip", "v1", "storage", true,
+			server.ListStorage,
+			server.ListStorage,
+			nil,
+			nil,
+			nil,
+		)
+		group.POST(api.GenerateURI("compute.ddx2x.nip", "v1", "storage/:name/op/:action", true), server.ActionStorage)
+		group.POST(api.GenerateURI("compute.ddx2x.nip", "v1", "storage/:name/op/:action", false), server.ActionStorage)
+	}
+
+	// image
+	{
+		api.GenerateURIV2(group, "compute.ddx2x.nip", "v1", "image", true,
+			server.ListImage,
+			server.ListImage,
+			server.CreateImage,
+			nil,
+			server.DeleteImage,
+		)
+	}
+
+	// virtualmachinesnapshot
+	{
+		api.GenerateURIV2(group, "compute.ddx2x.nip", "v1", "virtualmachinesnapshot", true,
+			server.ListVirtualMachineSnapshot,
+			server.ListVirtualMachineSnapshot,
+			server.CreateVirtualMachineSnapshot,
+			nil,
+			server.DeleteVirtualMachineSnapshot,
+		)
+	}
+
+	// virtualmachinerestore
+	{
+		api.GenerateURIV2(group, "compute.ddx2x.nip", "v1", "virtualmachinerestore", true,
+			server.ListVirtualMachineRestore,
+			server.ListVirtualMachineRestore,
+			server.CreateVirtualMachineRestore,
+			nil,
+			server.DeleteVirtualMachineRestore,
+		)
+	}
+
+	// vpc
+	{
+		api.GenerateURIV2(group, "networking.ddx2x.nip", "v1", "vpc", true,
+			server.ListVirtualPrivateCloud,
+			server.ListVirtualPrivateCloud,
+			server.CreateVirtualPrivateCloud,
+			nil,
+			server.DeleteVirtualPrivateCloud,
+		)
+	}
+
+	// vswitch
+	{
+		api.GenerateURIV2(group, "networking.ddx2x.nip", "v1", "vswitch", true,
+			server.ListVSwitch,
+			server.ListVSwitch,
+			server.CreateVSwitch,
+			nil,
+			server.DeleteVSwitch,
+		)
+		group.GET(api.GenerateURI("networking.ddx2x.nip", "v1", "vswitch/:name/usage", true), server.UsageVSwitch)
+		group.GET(api.GenerateURI("networking.ddx2x.nip", "v1", "vswitch/:name/usage", false), server.UsageVSwitch)
+	}
+
+	// elastic ip
+	{
+		api.GenerateURIV2(group, "networking.ddx2x.nip", "v1", "elasticip", true,
+			server.ListElasticIP,
+			server.ListElasticIP,
+			server.CreateElasticIP,
+			server.UpdateElasticIP,
+			server.DeleteElasticIP,
+		)
+	}
+
+	// load balancer
+	{
+		api.GenerateURIV2(group, "networking.ddx2x.nip", "v1", "loadbalancer", true,
+			server.ListLoadBalancer,
+			server.ListLoadBalancer,
+			server.CreateLoadBalancer,
+			server.UpdateLoadBalancer,
+			server.DeleteLoadBalancer,
+		)
+	}
+
+	return server, nil
+}
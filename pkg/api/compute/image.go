@@ -0,0 +1,69 @@
+package compute
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *computeServer) ListImage(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	tenant := g.GetHeader(common.HttpRequestUserHeaderTENANT)
+
+	results, err := c.image.List(name, namespace, tenant)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+func (c *computeServer) CreateImage(g *gin.Context) {
+	request := &compute.Image{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+	if tenant := g.GetHeader(common.HttpRequestUserHeaderTENANT); tenant != "" {
+		request.Tenant = tenant
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := c.image.Create(request)
+	if err != nil {
+		c.RecordEvent(common.IMAGE, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.IMAGE, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (c *computeServer) DeleteImage(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := c.image.Delete(namespace, name)
+	if err != nil {
+		request := &compute.Image{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		c.RecordEvent(common.IMAGE, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.IMAGE, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
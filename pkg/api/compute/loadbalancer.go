@@ -0,0 +1,88 @@
+package compute
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *computeServer) ListLoadBalancer(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := c.loadBalancer.List(name, namespace)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+func (c *computeServer) CreateLoadBalancer(g *gin.Context) {
+	request := &networking.LoadBalancer{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := c.loadBalancer.Create(request)
+	if err != nil {
+		c.RecordEvent(common.LOADBALANCER, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.LOADBALANCER, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (c *computeServer) UpdateLoadBalancer(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	request := &networking.LoadBalancer{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	res, err := c.loadBalancer.Update(namespace, name, request)
+	if err != nil {
+		request.Metadata = core.Metadata{Name: name, Workspace: namespace}
+		c.RecordEvent(common.LOADBALANCER, core.MODIFIED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.LOADBALANCER, core.MODIFIED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (c *computeServer) DeleteLoadBalancer(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := c.loadBalancer.Delete(namespace, name)
+	if err != nil {
+		request := &networking.LoadBalancer{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		c.RecordEvent(common.LOADBALANCER, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.LOADBALANCER, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,65 @@
+package compute
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *computeServer) ListVirtualMachineRestore(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := c.virtualMachineRestore.List(name, namespace)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+func (c *computeServer) CreateVirtualMachineRestore(g *gin.Context) {
+	request := &compute.VirtualMachineRestore{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := c.virtualMachineRestore.Create(request)
+	if err != nil {
+		c.RecordEvent(common.VIRTUALMACHINERESTORE, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VIRTUALMACHINERESTORE, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (c *computeServer) DeleteVirtualMachineRestore(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := c.virtualMachineRestore.Delete(namespace, name)
+	if err != nil {
+		request := &compute.VirtualMachineRestore{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		c.RecordEvent(common.VIRTUALMACHINERESTORE, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VIRTUALMACHINERESTORE, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
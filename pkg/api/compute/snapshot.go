@@ -0,0 +1,65 @@
+package compute
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *computeServer) ListVirtualMachineSnapshot(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := c.virtualMachineSnapshot.List(name, namespace)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+func (c *computeServer) CreateVirtualMachineSnapshot(g *gin.Context) {
+	request := &compute.VirtualMachineSnapshot{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := c.virtualMachineSnapshot.Create(request)
+	if err != nil {
+		c.RecordEvent(common.VIRTUALMACHINESNAPSHOT, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VIRTUALMACHINESNAPSHOT, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (c *computeServer) DeleteVirtualMachineSnapshot(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := c.virtualMachineSnapshot.Delete(namespace, name)
+	if err != nil {
+		request := &compute.VirtualMachineSnapshot{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		c.RecordEvent(common.VIRTUALMACHINESNAPSHOT, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VIRTUALMACHINESNAPSHOT, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,50 @@
+package compute
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *computeServer) ListStorage(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := c.storage.List(name, namespace)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+// ActionStorage attach, detach or resize a storage, the body carries the target vm or the new size
+func (c *computeServer) ActionStorage(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	action := g.Param("action")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	request := &compute.Storage{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	res, err := c.storage.Action(namespace, name, action, request)
+	if err != nil {
+		request.Metadata = core.Metadata{Name: name, Workspace: namespace}
+		c.RecordEvent(common.STORAGE, core.MODIFIED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.STORAGE, core.MODIFIED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,129 @@
+package compute
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *computeServer) ListVirtualMachine(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := c.virtualMachine.List(name, namespace)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+// ActionVirtualMachine start, stop, restart, pause, unpause or migrate a vm
+func (c *computeServer) ActionVirtualMachine(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	action := g.Param("action")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := c.virtualMachine.Action(namespace, name, action)
+	if err != nil {
+		request := &compute.VirtualMachine{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		c.RecordEvent(common.VIRTUALMACHINE, core.MODIFIED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VIRTUALMACHINE, core.MODIFIED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (c *computeServer) CreateVirtualMachine(g *gin.Context) {
+	request := &compute.VirtualMachine{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+	if tenant := g.GetHeader(common.HttpRequestUserHeaderTENANT); tenant != "" {
+		request.Tenant = tenant
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	if api.IsDryRun(g) {
+		res, err := c.dryRunVirtualMachine.Create(request)
+		api.ResponsePlan(g, c.dryRun, common.VIRTUALMACHINE, core.ADDED, res, err)
+		return
+	}
+
+	res, err := c.virtualMachine.Create(request)
+	if err != nil {
+		c.RecordEvent(common.VIRTUALMACHINE, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VIRTUALMACHINE, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+// UpdateVirtualMachine resize a vm to another instance type
+func (c *computeServer) UpdateVirtualMachine(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	request := &compute.VirtualMachine{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	if api.IsDryRun(g) {
+		res, err := c.dryRunVirtualMachine.Update(namespace, name, request)
+		api.ResponsePlan(g, c.dryRun, common.VIRTUALMACHINE, core.MODIFIED, res, err)
+		return
+	}
+
+	res, err := c.virtualMachine.Update(namespace, name, request)
+	if err != nil {
+		request.Metadata = core.Metadata{Name: name, Workspace: namespace}
+		c.RecordEvent(common.VIRTUALMACHINE, core.MODIFIED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VIRTUALMACHINE, core.MODIFIED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (c *computeServer) DeleteVirtualMachine(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	if api.IsDryRun(g) {
+		res, err := c.dryRunVirtualMachine.Delete(namespace, name)
+		api.ResponsePlan(g, c.dryRun, common.VIRTUALMACHINE, core.DELETED, res, err)
+		return
+	}
+
+	res, err := c.virtualMachine.Delete(namespace, name)
+	if err != nil {
+		request := &compute.VirtualMachine{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		c.RecordEvent(common.VIRTUALMACHINE, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VIRTUALMACHINE, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,77 @@
+package compute
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *computeServer) ListVirtualPrivateCloud(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := c.virtualPrivateCloud.List(name, namespace)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+func (c *computeServer) CreateVirtualPrivateCloud(g *gin.Context) {
+	request := &networking.VirtualPrivateCloud{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	if api.IsDryRun(g) {
+		res, err := c.dryRunVirtualPrivateCloud.Create(request)
+		api.ResponsePlan(g, c.dryRun, common.VPC, core.ADDED, res, err)
+		return
+	}
+
+	res, err := c.virtualPrivateCloud.Create(request)
+	if err != nil {
+		c.RecordEvent(common.VPC, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VPC, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (c *computeServer) DeleteVirtualPrivateCloud(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	if api.IsDryRun(g) {
+		res, err := c.dryRunVirtualPrivateCloud.Delete(namespace, name)
+		api.ResponsePlan(g, c.dryRun, common.VPC, core.DELETED, res, err)
+		return
+	}
+
+	res, err := c.virtualPrivateCloud.Delete(namespace, name)
+	if err != nil {
+		request := &networking.VirtualPrivateCloud{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		c.RecordEvent(common.VPC, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VPC, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,91 @@
+package compute
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *computeServer) ListVSwitch(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := c.vswitch.List(name, namespace)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+func (c *computeServer) CreateVSwitch(g *gin.Context) {
+	request := &networking.Vswitch{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	if api.IsDryRun(g) {
+		res, err := c.dryRunVSwitch.Create(request)
+		api.ResponsePlan(g, c.dryRun, common.VSWITCH, core.ADDED, res, err)
+		return
+	}
+
+	res, err := c.vswitch.Create(request)
+	if err != nil {
+		c.RecordEvent(common.VSWITCH, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VSWITCH, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (c *computeServer) DeleteVSwitch(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	if api.IsDryRun(g) {
+		res, err := c.dryRunVSwitch.Delete(namespace, name)
+		api.ResponsePlan(g, c.dryRun, common.VSWITCH, core.DELETED, res, err)
+		return
+	}
+
+	res, err := c.vswitch.Delete(namespace, name)
+	if err != nil {
+		request := &networking.Vswitch{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		c.RecordEvent(common.VSWITCH, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	c.RecordEvent(common.VSWITCH, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+// UsageVSwitch the total, reserved and allocated private addresses of a vswitch
+func (c *computeServer) UsageVSwitch(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	usage, err := c.vswitch.Usage(namespace, name)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, usage)
+}
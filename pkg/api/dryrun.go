@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/gin-gonic/gin"
+)
+
+// IsDryRun the request only plans the change, ?dryRun=true
+func IsDryRun(g *gin.Context) bool {
+	dryRun, err := strconv.ParseBool(g.Query("dryRun"))
+	return err == nil && dryRun
+}
+
+// ResponsePlan respond the changes eventType of obj would make in the clusters, nothing is persisted or applied
+func ResponsePlan(g *gin.Context, dryRun *controller.DryRun, table string, eventType core.EventType, obj core.IObject, err error) {
+	if err != nil {
+		RequestParametersError(g, err)
+		return
+	}
+	plan, err := dryRun.Plan(g.Request.Context(), table, eventType, obj)
+	if err != nil {
+		RequestParametersError(g, err)
+		return
+	}
+	g.JSON(http.StatusOK, plan)
+}
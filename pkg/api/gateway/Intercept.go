@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/metrics"
 	"github.com/ddx2x/oilmont/pkg/micro/gateway"
 	"github.com/ddx2x/oilmont/pkg/utils/token"
 )
@@ -21,9 +22,17 @@ func LoginHandle(w http.ResponseWriter, r *http.Request) gateway.InterceptType {
 	if strings.HasPrefix(r.URL.String(), FeiShuLoginURL) {
 		return gateway.SelfHandle
 	}
+	if r.URL.Path == metrics.Path {
+		return gateway.SelfHandle
+	}
 	if strings.HasPrefix(r.URL.String(), SHELL) {
 		return gateway.Redirect
 	}
+	// vm consoles still go through the token and permission checks
+	if strings.HasPrefix(r.URL.Path, CONSOLE) && r.Header.Get(common.AuthorizationHeader) == "" {
+		r.Header.Set(common.AuthorizationHeader, r.URL.Query().Get(TokenQuery))
+		return gateway.Next
+	}
 	if strings.HasPrefix(r.URL.String(), WatchURL) {
 		authorizedHeader := r.Header.Get(common.AuthorizationHeader)
 		if authorizedHeader == "" {
@@ -25,7 +25,7 @@ type userLoginForm struct {
 type userCfgF func(tenant string, cfg *ucfg.Config) error
 
 func (gw *Gateway) injectCfg(tenant string, cfg *ucfg.Config) error {
-	for _, f := range []userCfgF{gw.allowedWorkspace, gw.allowedResourceOp, gw.allowedProviders, gw.allowedMenus, gw.allowedBiz} {
+	for _, f := range []userCfgF{gw.allowedWorkspace, gw.allowedResourceOp, gw.allowedProviders, gw.allowedMenus, gw.allowedBiz, gw.allowedQuotas} {
 		if err := f(tenant, cfg); err != nil {
 			return err
 		}
@@ -5,6 +5,7 @@ import (
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/resource/system"
 	ucfg "github.com/ddx2x/oilmont/pkg/resource/userconfig"
+	"github.com/ddx2x/oilmont/pkg/topology"
 )
 
 func (gw *Gateway) allowedProviders(tenant string, cfg *ucfg.Config) error {
@@ -12,7 +13,7 @@ func (gw *Gateway) allowedProviders(tenant string, cfg *ucfg.Config) error {
 		cfg.Providers = make(map[string]*ucfg.Provider)
 	}
 	for _, f := range []func(tenant string, cfg *ucfg.Config) error{
-		gw.provider, gw.region, gw.az, gw.cluster, gw.namespace} {
+		gw.provider, gw.region, gw.namespace} {
 		if err := f(tenant, cfg); err != nil {
 			return err
 		}
@@ -74,42 +75,28 @@ func (gw *Gateway) provider(tenant string, cfg *ucfg.Config) (err error) {
 	return
 }
 
-func (gw *Gateway) getRegion(regionLocalName, providerName string) (*system.Region, error) {
-	region := &system.Region{}
-	filter := map[string]interface{}{
-		"spec.local_name":    regionLocalName,
-		"metadata.namespace": providerName,
-	}
-	err := gw.stage.GetByFilter(common.DefaultDatabase, common.REGION, region, filter, true)
+// region fill the regions, azs and clusters of the providers from the topology, a tenant only gets the ones it is
+// allowed to
+func (gw *Gateway) region(tenant string, cfg *ucfg.Config) (err error) {
+	graph, err := topology.New(gw.stage).Graph()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return region, nil
-}
 
-func (gw *Gateway) region(tenant string, cfg *ucfg.Config) (err error) {
 	switch {
 	case cfg.IsAdmin():
-		for provider, _ := range cfg.Providers {
-			filter := map[string]interface{}{"metadata.namespace": provider}
-			var regions []system.Region
-			err = gw.stage.ListToObject(common.DefaultDatabase, common.REGION, filter, &regions, true)
-			if err != nil {
-				return
-			}
-			providerStruct := cfg.Providers[provider]
-			if providerStruct.Regions == nil {
-				providerStruct.Regions = make([]*ucfg.Region, 0)
+		for name, cfgProvider := range cfg.Providers {
+			provider := graph.Provider(name)
+			if provider == nil {
+				continue
 			}
-
-			for _, region := range regions {
-				providerStruct.Regions = append(
-					providerStruct.Regions,
-					&ucfg.Region{
-						Name:      region.Spec.ID,
-						LocalName: region.Spec.LocalName,
-					},
-				)
+			cfgProvider.Regions = make([]*ucfg.Region, 0)
+			for _, region := range provider.Regions {
+				cfgRegion := regionConfig(region)
+				for _, zone := range region.Zones {
+					cfgRegion.Azs = append(cfgRegion.Azs, azConfig(region, zone))
+				}
+				cfgProvider.Regions = append(cfgProvider.Regions, cfgRegion)
 			}
 		}
 	default:
@@ -118,28 +105,34 @@ func (gw *Gateway) region(tenant string, cfg *ucfg.Config) (err error) {
 			return err
 		}
 		for pName, region := range getTenant.Spec.Allowed {
-			regionMap, ok := region.(map[string]interface{})
+			allowedRegionMap, ok := region.(map[string]interface{})
 			if !ok {
 				continue
 			}
-
 			cfgProvider := cfg.Providers[pName]
-			if cfgProvider.Regions == nil {
-				cfgProvider.Regions = make([]*ucfg.Region, 0)
+			if cfgProvider == nil {
+				continue
 			}
-
-			for rLocalName, _ := range regionMap {
-				region, err := gw.getRegion(rLocalName, cfgProvider.Name)
-				if err != nil {
+			provider := graph.Provider(cfgProvider.Name)
+			if provider == nil {
+				continue
+			}
+			cfgProvider.Regions = make([]*ucfg.Region, 0)
+			for allowedRegionName, allowedAzsInterface := range allowedRegionMap {
+				region := provider.Region(allowedRegionName)
+				if region == nil {
 					continue
 				}
-				cfgProvider.Regions = append(
-					cfgProvider.Regions,
-					&ucfg.Region{
-						Name:      region.Spec.ID,
-						LocalName: region.Spec.LocalName,
-					},
-				)
+				cfgRegion := regionConfig(region)
+				var allowedAZs []string
+				if err := unmarshalList(&allowedAZs, allowedAzsInterface); err == nil {
+					for _, allowedAZ := range allowedAZs {
+						if zone := region.Zone(allowedAZ); zone != nil {
+							cfgRegion.Azs = append(cfgRegion.Azs, azConfig(region, zone))
+						}
+					}
+				}
+				cfgProvider.Regions = append(cfgProvider.Regions, cfgRegion)
 			}
 		}
 	}
@@ -147,18 +140,20 @@ func (gw *Gateway) region(tenant string, cfg *ucfg.Config) (err error) {
 	return
 }
 
-func (gw *Gateway) getAZ(pName, rName, aLocalName string) (*system.AvailableZone, error) {
-	az := &system.AvailableZone{}
-	filter := map[string]interface{}{
-		"spec.local_name":    aLocalName,
-		"metadata.namespace": pName,
-		"spec.region":        rName,
+func regionConfig(region *topology.Region) *ucfg.Region {
+	return &ucfg.Region{Name: region.ID, LocalName: region.LocalName, Azs: make([]*ucfg.Az, 0)}
+}
+
+// azConfig the az with the clusters serving it, the clusters of the whole region included
+func azConfig(region *topology.Region, zone *topology.Zone) *ucfg.Az {
+	clusters := make([]*ucfg.Cluster, 0, len(zone.Clusters)+len(region.Clusters))
+	for _, cluster := range zone.Clusters {
+		clusters = append(clusters, &ucfg.Cluster{Name: cluster.Name, Ns: cluster.Namespaces})
 	}
-	err := gw.stage.GetByFilter(common.DefaultDatabase, common.AVAILABLEZONE, az, filter, true)
-	if err != nil {
-		return nil, err
+	for _, cluster := range region.Clusters {
+		clusters = append(clusters, &ucfg.Cluster{Name: cluster.Name, Ns: cluster.Namespaces})
 	}
-	return az, nil
+	return &ucfg.Az{Name: zone.ID, LocalName: zone.LocalName, Clusters: clusters}
 }
 
 func unmarshalList(tag interface{}, data interface{}) error {
@@ -169,76 +164,6 @@ func unmarshalList(tag interface{}, data interface{}) error {
 	return json.Unmarshal(bs, tag)
 }
 
-func (gw *Gateway) az(tenant string, cfg *ucfg.Config) (err error) {
-	switch {
-	case cfg.IsAdmin():
-		for provider, _ := range cfg.Providers {
-			cfgProvider := cfg.Providers[provider]
-			for _, region := range cfgProvider.Regions {
-				filter := map[string]interface{}{
-					"spec.region":        region.Name,
-					"metadata.namespace": provider,
-				}
-				var azs []system.AvailableZone
-				err = gw.stage.ListToObject(common.DefaultDatabase, common.AVAILABLEZONE, filter, &azs, true)
-				if err != nil {
-					return
-				}
-				for _, az := range azs {
-					region.Azs = append(region.Azs,
-						&ucfg.Az{
-							Name:      az.Spec.ID,
-							LocalName: az.Spec.LocalName,
-						},
-					)
-				}
-			}
-		}
-		return
-	default:
-		getTenant, err := gw.getTenant(tenant)
-		if err != nil {
-			return err
-		}
-		for pName, region := range getTenant.Spec.Allowed {
-			allowedRegionMap, ok := region.(map[string]interface{})
-			if !ok {
-				continue
-			}
-			cfgProvider := cfg.Providers[pName]
-			for allowedRegionName, allowedAzsInterface := range allowedRegionMap {
-				cfgRegion := cfgProvider.GetRegion(allowedRegionName)
-				if cfgRegion == nil {
-					continue
-				}
-				var allowedAZs []string
-				if err := unmarshalList(&allowedAZs, allowedAzsInterface); err != nil {
-					continue
-				}
-				for _, allowedAZ := range allowedAZs {
-					az, err := gw.getAZ(cfgProvider.Name, cfgRegion.Name, allowedAZ)
-					if err != nil {
-						continue
-					}
-					cfgRegion.Azs = append(cfgRegion.Azs,
-						&ucfg.Az{
-							Name:      az.Spec.ID,
-							LocalName: az.Spec.LocalName,
-						},
-					)
-				}
-			}
-		}
-	}
-
-	return
-}
-
-func (gw *Gateway) cluster(tenant string, cfg *ucfg.Config) (err error) {
-	// TODO: 需要写入管理员授权可访问的集群（AZ->Cluster)
-	return
-}
-
 func (gw *Gateway) namespace(tenant string, cfg *ucfg.Config) (err error) {
 	// TODO: 用户自主开通区域的资源池(Namespace)
 	return
@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/iam"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	ucfg "github.com/ddx2x/oilmont/pkg/resource/userconfig"
+	"github.com/thoas/go-funk"
+)
+
+// allowedQuotas the quotas of the tenant of the user and of the workspaces allowed to the user, every quota for admin.
+// It runs after allowedWorkspace
+func (gw *Gateway) allowedQuotas(tenant string, cfg *ucfg.Config) error {
+	quotas := make([]system.ResourceQuota, 0)
+	if err := gw.stage.ListToObject(common.DefaultDatabase, common.RESOURCEQUOTA, map[string]interface{}{}, &quotas, true); err != nil {
+		return err
+	}
+
+	cfg.Quotas = make([]*ucfg.Quota, 0)
+	for _, quota := range quotas {
+		switch {
+		case cfg.RoleType == iam.AccountTypeAdmin:
+		case quota.GetWorkspace() == "" && quota.GetTenant() == cfg.Tenant:
+		case quota.GetWorkspace() != "" && funk.ContainsString(cfg.AllowedWorkspaces, quota.GetWorkspace()):
+		default:
+			continue
+		}
+		cfg.Quotas = append(cfg.Quotas, &ucfg.Quota{
+			Name:      quota.GetName(),
+			Workspace: quota.GetWorkspace(),
+			Hard:      quota.Spec.Hard,
+			Used:      quota.Status.Used,
+			Exceeded:  quota.Status.Exceeded,
+		})
+	}
+	return nil
+}
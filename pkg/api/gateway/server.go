@@ -21,6 +21,9 @@ const (
 	FeiShuLoginURL = "/feishu-user-login"
 	WatchURL       = "/watch"
 	SHELL          = "/kes/shell/pod"
+	CONSOLE        = "/kes/apis/subresources.kubevirt.io"
+	// TokenQuery carry the token of websocket requests, browsers can not set their headers
+	TokenQuery = "token"
 )
 
 type Gateway struct {
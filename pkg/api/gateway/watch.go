@@ -16,6 +16,7 @@ import (
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/k8s"
 	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/metrics"
 	"github.com/ddx2x/oilmont/pkg/utils/uri"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
@@ -87,7 +88,9 @@ func (gw *Gateway) watch(g *gin.Context) {
 
 	clientUniques := RandStringRunes(10)
 
+	sessionClosed := metrics.SessionOpened(metrics.SessionSSE)
 	defer func() {
+		sessionClosed()
 		flog.Warnf("-----END----- \r\n close long connection: %s \n  id: %s \r\n",
 			fullURL.String(), clientUniques,
 		)
@@ -2,11 +2,12 @@ package api
 
 import (
 	"fmt"
-	"github.com/gin-gonic/gin"
 	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/metrics"
 	"github.com/ddx2x/oilmont/pkg/resource/event"
 	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/gin-gonic/gin"
 	"math"
 	"net/http"
 	"os"
@@ -51,7 +52,8 @@ func (b BaseAPIServer) RecordEvent(source, action, operator string, obj core.IOb
 
 func NewBaseAPIServer(p service.IService) *BaseAPIServer {
 	engine := gin.New()
-	engine.Use([]gin.HandlerFunc{LoggerMiddleware(log.L), gin.Recovery()}...)
+	engine.Use([]gin.HandlerFunc{metrics.Middleware(), LoggerMiddleware(log.L, metrics.Path), gin.Recovery()}...)
+	metrics.Register(engine)
 	return &BaseAPIServer{
 		engine,
 		p,
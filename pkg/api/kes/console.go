@@ -0,0 +1,178 @@
+package kes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeVirtV1 "kubevirt.io/client-go/apis/core/v1"
+	"kubevirt.io/client-go/kubecli"
+)
+
+const (
+	// ConsoleVNC the graphical console of a vmi
+	ConsoleVNC = "vnc"
+	// ConsoleSerial the serial console of a vmi
+	ConsoleSerial = "console"
+
+	consoleConnectTimeout = 30 * time.Second
+	consoleBufferSize     = 32 * 1024
+)
+
+// consoleIdleTimeout a console without traffic in either direction for this long is closed
+var consoleIdleTimeout = 15 * time.Minute
+
+var errConsoleIdle = fmt.Errorf("console idle timeout")
+
+var consoleUpgrader = websocket.Upgrader{
+	ReadBufferSize:  consoleBufferSize,
+	WriteBufferSize: consoleBufferSize,
+	// noVNC asks for the binary sub protocol
+	Subprotocols: []string{"binary"},
+	// the gateway already authorized the request
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// vmConsole proxy the vnc or serial console subresource of a vmi over websocket.
+// The path follows the kubevirt subresource api so the gateway checks the op on virtualmachineinstances of the workspace
+func (k *KesServer) vmConsole(g *gin.Context) {
+	namespace, name, consoleType := g.Param("namespace"), g.Param("name"), g.Param("console")
+	if consoleType != ConsoleVNC && consoleType != ConsoleSerial {
+		api.RequestParametersError(g, fmt.Errorf("console type %s is not supported", consoleType))
+		return
+	}
+	cluster := g.Query("cluster")
+	if cluster == "" {
+		cluster = "default"
+	}
+	cli := k.multiCluster.Get(cluster)
+	if cli == nil {
+		api.RequestParametersError(g, fmt.Errorf("request cluster %s is not found", cluster))
+		return
+	}
+
+	vmi, err := cli.KubevirtClient.VirtualMachineInstance(namespace).Get(name, &metav1.GetOptions{})
+	if err != nil {
+		api.InternalServerError(g, err, err)
+		return
+	}
+	if vmi.Status.Phase != kubeVirtV1.Running {
+		api.RequestParametersError(g, fmt.Errorf("vm %s is %s, console requires a running vm", name, vmi.Status.Phase))
+		return
+	}
+
+	var stream kubecli.StreamInterface
+	switch consoleType {
+	case ConsoleVNC:
+		stream, err = cli.KubevirtClient.VirtualMachineInstance(namespace).VNC(name)
+	case ConsoleSerial:
+		stream, err = cli.KubevirtClient.VirtualMachineInstance(namespace).SerialConsole(name, &kubecli.SerialConsoleOptions{ConnectionTimeout: consoleConnectTimeout})
+	}
+	if err != nil {
+		api.InternalServerError(g, err, err)
+		return
+	}
+	conn := stream.AsConn()
+	defer conn.Close()
+
+	ws, err := consoleUpgrader.Upgrade(g.Writer, g.Request, nil)
+	if err != nil {
+		// the upgrader already replied to the client
+		return
+	}
+	defer ws.Close()
+
+	flog := log.G(context.TODO()).
+		WithField("user", g.GetHeader(common.HttpRequestUserHeaderKey)).
+		WithField("cluster", cluster).
+		WithField("vm", fmt.Sprintf("%s/%s", namespace, name)).
+		WithField("console", consoleType)
+	flog.Info("console session opened")
+
+	closed := metrics.SessionOpened(metrics.SessionConsole)
+	start := time.Now()
+	err = proxyConsole(ws, conn, consoleIdleTimeout)
+	closed()
+
+	reason := "closed"
+	if err == errConsoleIdle {
+		reason = err.Error()
+	}
+	_ = ws.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason),
+		time.Now().Add(time.Second),
+	)
+	flog.Infof("console session %s after %s: %v", reason, time.Since(start).Round(time.Second), err)
+}
+
+// proxyConsole copy the traffic between the websocket and the console until either side ends or nothing is
+// exchanged for idle. The caller closes both ends, which releases the copying goroutines
+func proxyConsole(ws *websocket.Conn, conn net.Conn, idle time.Duration) error {
+	done := make(chan error, 2)
+	activity := make(chan struct{}, 1)
+	touch := func() {
+		select {
+		case activity <- struct{}{}:
+		default:
+		}
+	}
+
+	go func() {
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+			touch()
+			if _, err := conn.Write(data); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	go func() {
+		buf := make([]byte, consoleBufferSize)
+		for {
+			n, err := conn.Read(buf)
+			if n > 0 {
+				touch()
+				if err := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+					done <- err
+					return
+				}
+			}
+			if err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-activity:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(idle)
+		case <-timer.C:
+			return errConsoleIdle
+		}
+	}
+}
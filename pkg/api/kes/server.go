@@ -122,6 +122,11 @@ func NewKesServer(serverName string, storage datasource.IStorage) (*KesServer, e
 		group.GET("/shell/namespace/:namespace/pod/:name/container/:container/:shelltype/:cluster", kes.podAttach)
 	}
 
+	// vm console
+	{
+		group.GET("/apis/subresources.kubevirt.io/v1/namespaces/:namespace/virtualmachineinstances/:name/:console", kes.vmConsole)
+	}
+
 	//metrics
 	{
 		group.POST("/metrics", kes.Metrics)
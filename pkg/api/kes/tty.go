@@ -14,6 +14,7 @@ import (
 
 	"github.com/ddx2x/oilmont/pkg/k8s"
 	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/metrics"
 	"github.com/igm/sockjs-go/v3/sockjs"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
@@ -84,6 +85,9 @@ func (m *manager) get(id string) (*sessionChannel, bool) {
 func (m *manager) set(id string, channel *sessionChannel) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
+	if _, exist := m.channels[id]; !exist {
+		metrics.AddSessions(metrics.SessionShell, 1)
+	}
 	m.channels[id] = channel
 }
 
@@ -99,6 +103,7 @@ func (m *manager) close(sessionID string, status uint32, reason string) {
 		return
 	}
 	delete(m.channels, sessionID)
+	metrics.AddSessions(metrics.SessionShell, -1)
 }
 
 // PtyHandler is what remotecommand expects from a pty
@@ -19,6 +19,15 @@ func (i *systemServer) ListCluster(g *gin.Context) {
 	g.JSON(http.StatusOK, results)
 }
 
+func (i *systemServer) GetClusterStatus(g *gin.Context) {
+	results, err := i.cluster.GetStatus(g.Param("name"))
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	g.JSON(http.StatusOK, results)
+}
+
 func (i *systemServer) CreateCluster(g *gin.Context) {
 	request := &system.Cluster{}
 	if err := g.ShouldBindJSON(request); err != nil {
@@ -28,7 +37,7 @@ func (i *systemServer) CreateCluster(g *gin.Context) {
 
 	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
 
-	res, err := i.cluster.Create(common.DefaultDatabase, common.CLUSTER, request)
+	res, err := i.cluster.Create(request)
 	if err != nil {
 		i.RecordEvent(common.CLUSTER, core.ADDED, reqUser, request, event.CloudEventFail)
 		api.RequestParametersError(g, err)
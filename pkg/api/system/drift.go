@@ -0,0 +1,24 @@
+package system
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/gin-gonic/gin"
+)
+
+// ListDrift the differences between the stage and the clusters, filtered by table and type
+func (i *systemServer) ListDrift(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	table := g.Query("table")
+	driftType := g.Query("type")
+
+	results, err := i.drift.List(name, namespace, table, driftType)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
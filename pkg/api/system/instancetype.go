@@ -1,9 +1,14 @@
 package system
 
 import (
-	"github.com/gin-gonic/gin"
-	"github.com/ddx2x/oilmont/pkg/api"
 	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/gin-gonic/gin"
 )
 
 func (i *systemServer) ListInstanceType(g *gin.Context) {
@@ -12,8 +17,9 @@ func (i *systemServer) ListInstanceType(g *gin.Context) {
 	provider := g.Query("provider")
 	region := g.Query("region")
 	zone := g.Query("zone")
+	tenant := g.GetHeader(common.HttpRequestUserHeaderTENANT)
 
-	results, err := i.instanceType.List(name, namespace, provider, region, zone)
+	results, err := i.instanceType.List(name, namespace, provider, region, zone, tenant)
 	if err != nil {
 		api.RequestParametersError(g, err)
 		return
@@ -21,3 +27,47 @@ func (i *systemServer) ListInstanceType(g *gin.Context) {
 
 	g.JSON(http.StatusOK, results)
 }
+
+// CreateInstanceType define a custom instance type for the kubeVirt vms of the tenant
+func (i *systemServer) CreateInstanceType(g *gin.Context) {
+	request := &system.InstanceType{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+	if tenant := g.GetHeader(common.HttpRequestUserHeaderTENANT); tenant != "" {
+		request.Tenant = tenant
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := i.instanceType.Create(request)
+	if err != nil {
+		i.RecordEvent(common.INSTANCETYPE, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.INSTANCETYPE, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (i *systemServer) DeleteInstanceType(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := i.instanceType.Delete(namespace, name)
+	if err != nil {
+		request := &system.InstanceType{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		i.RecordEvent(common.INSTANCETYPE, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.INSTANCETYPE, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
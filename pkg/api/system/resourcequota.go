@@ -0,0 +1,100 @@
+package system
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/gin-gonic/gin"
+)
+
+// ListResourceQuota the quotas of the workspace of the path, the ones of ?tenant= otherwise
+func (i *systemServer) ListResourceQuota(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := i.resourceQuota.List(name, namespace, g.Query("tenant"))
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+// GetResourceQuotaUsage the live usage of a quota, the status holds the one last reconciled
+func (i *systemServer) GetResourceQuotaUsage(g *gin.Context) {
+	results, err := i.resourceQuota.Usage(g.Param("namespace"), g.Param("name"))
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	g.JSON(http.StatusOK, results)
+}
+
+// CreateResourceQuota a quota created under a workspace path limits the workspace, otherwise the tenant of the request
+func (i *systemServer) CreateResourceQuota(g *gin.Context) {
+	request := &system.ResourceQuota{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := i.resourceQuota.Create(request)
+	if err != nil {
+		i.RecordEvent(common.RESOURCEQUOTA, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.RESOURCEQUOTA, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (i *systemServer) UpdateResourceQuota(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	request := &system.ResourceQuota{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, _, err := i.resourceQuota.Update(namespace, name, request)
+	if err != nil {
+		i.RecordEvent(common.RESOURCEQUOTA, core.MODIFIED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.RESOURCEQUOTA, core.MODIFIED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (i *systemServer) DeleteResourceQuota(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := i.resourceQuota.Delete(namespace, name)
+	if err != nil {
+		request := &system.ResourceQuota{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		i.RecordEvent(common.RESOURCEQUOTA, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.RESOURCEQUOTA, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
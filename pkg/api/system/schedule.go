@@ -0,0 +1,88 @@
+package system
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/gin-gonic/gin"
+)
+
+func (i *systemServer) ListSchedule(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := i.schedule.List(name, namespace)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+func (i *systemServer) CreateSchedule(g *gin.Context) {
+	request := &system.Schedule{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := i.schedule.Create(request)
+	if err != nil {
+		i.RecordEvent(common.SCHEDULE, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.SCHEDULE, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (i *systemServer) UpdateSchedule(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	request := &system.Schedule{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, _, err := i.schedule.Update(namespace, name, request)
+	if err != nil {
+		i.RecordEvent(common.SCHEDULE, core.MODIFIED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.SCHEDULE, core.MODIFIED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (i *systemServer) DeleteSchedule(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := i.schedule.Delete(namespace, name)
+	if err != nil {
+		request := &system.Schedule{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		i.RecordEvent(common.SCHEDULE, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.SCHEDULE, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
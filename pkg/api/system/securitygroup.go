@@ -0,0 +1,106 @@
+package system
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/gin-gonic/gin"
+)
+
+func (i *systemServer) ListSecurityGroup(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := i.securityGroup.List(name, namespace)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+func (i *systemServer) CreateSecurityGroup(g *gin.Context) {
+	request := &system.SecurityGroup{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	if api.IsDryRun(g) {
+		res, err := i.dryRunSecurityGroup.Create(request)
+		api.ResponsePlan(g, i.dryRun, common.SECURITYGROUP, core.ADDED, res, err)
+		return
+	}
+
+	res, err := i.securityGroup.Create(request)
+	if err != nil {
+		i.RecordEvent(common.SECURITYGROUP, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.SECURITYGROUP, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (i *systemServer) UpdateSecurityGroup(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	request := &system.SecurityGroup{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	if api.IsDryRun(g) {
+		res, _, err := i.dryRunSecurityGroup.Update(namespace, name, request)
+		api.ResponsePlan(g, i.dryRun, common.SECURITYGROUP, core.MODIFIED, res, err)
+		return
+	}
+
+	res, _, err := i.securityGroup.Update(namespace, name, request)
+	if err != nil {
+		i.RecordEvent(common.SECURITYGROUP, core.MODIFIED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.SECURITYGROUP, core.MODIFIED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (i *systemServer) DeleteSecurityGroup(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	if api.IsDryRun(g) {
+		res, err := i.dryRunSecurityGroup.Delete(namespace, name)
+		api.ResponsePlan(g, i.dryRun, common.SECURITYGROUP, core.DELETED, res, err)
+		return
+	}
+
+	res, err := i.securityGroup.Delete(namespace, name)
+	if err != nil {
+		request := &system.SecurityGroup{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		i.RecordEvent(common.SECURITYGROUP, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.SECURITYGROUP, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
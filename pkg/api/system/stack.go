@@ -0,0 +1,89 @@
+package system
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/gin-gonic/gin"
+)
+
+func (i *systemServer) ListStack(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	results, err := i.stack.List(name, namespace)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	g.JSON(http.StatusOK, results)
+}
+
+func (i *systemServer) CreateStack(g *gin.Context) {
+	request := &system.Stack{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+	if namespace := g.Param("namespace"); namespace != "" {
+		request.Workspace = namespace
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := i.stack.Create(request)
+	if err != nil {
+		i.RecordEvent(common.STACK, core.ADDED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.STACK, core.ADDED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+func (i *systemServer) UpdateStack(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+
+	request := &system.Stack{}
+	if err := g.ShouldBindJSON(request); err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, _, err := i.stack.Update(namespace, name, request)
+	if err != nil {
+		i.RecordEvent(common.STACK, core.MODIFIED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.STACK, core.MODIFIED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
+
+// DeleteStack the objects of the stack are deleted with it by stackctrl
+func (i *systemServer) DeleteStack(g *gin.Context) {
+	name := g.Param("name")
+	namespace := g.Param("namespace")
+	reqUser := g.GetHeader(common.HttpRequestUserHeaderKey)
+
+	res, err := i.stack.Delete(namespace, name)
+	if err != nil {
+		request := &system.Stack{Metadata: core.Metadata{Name: name, Workspace: namespace}}
+		i.RecordEvent(common.STACK, core.DELETED, reqUser, request, event.CloudEventFail)
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	i.RecordEvent(common.STACK, core.DELETED, reqUser, res, event.CloudEventSuccess)
+	g.JSON(http.StatusOK, res)
+}
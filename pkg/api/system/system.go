@@ -1,15 +1,20 @@
 package system
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/controller/securitygroupctrl"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/datasource/cache"
 	"github.com/ddx2x/oilmont/pkg/micro/webservice"
 	"github.com/ddx2x/oilmont/pkg/service"
 	"github.com/ddx2x/oilmont/pkg/service/system"
+	"github.com/ddx2x/oilmont/pkg/topology"
 )
 
 type systemServer struct {
@@ -27,6 +32,17 @@ type systemServer struct {
 	resourceService  *system.ResourceService
 	themeService     *system.ThemeService
 	tenant           *system.TenantService
+	securityGroup    *system.SecurityGroupService
+	drift            *system.DriftService
+	resourceQuota    *system.ResourceQuotaService
+	usage            *system.UsageService
+	schedule         *system.ScheduleService
+	stack            *system.StackService
+	topology         *topology.Topology
+
+	// dryRun plan the changes of ?dryRun=true requests
+	dryRun              *controller.DryRun
+	dryRunSecurityGroup *system.SecurityGroupService
 }
 
 func (i *systemServer) Run() error {
@@ -52,6 +68,19 @@ func NewSystemServer(serviceName string, storage datasource.IStorage) (*systemSe
 		resourceService:  system.NewResource(baseService),
 		themeService:     system.NewThemeService(baseService),
 		tenant:           system.NewTenant(baseService),
+		securityGroup:    system.NewSecurityGroupService(baseService),
+		drift:            system.NewDriftService(baseService),
+		resourceQuota:    system.NewResourceQuotaService(baseService),
+		usage:            system.NewUsageService(baseService),
+		schedule:         system.NewScheduleService(baseService),
+		stack:            system.NewStackService(baseService),
+		topology:         topology.New(baseService),
+
+		dryRun:              controller.NewDryRun(storage),
+		dryRunSecurityGroup: system.NewSecurityGroupService(service.DryRun(baseService)),
+	}
+	if err := server.dryRun.Register(common.SECURITYGROUP, securitygroupctrl.NewSecurityGroupCtrl(context.Background())); err != nil {
+		return nil, err
 	}
 
 	webServer, err := webservice.NewWEBServer(serviceName, "", server.Server())
@@ -72,6 +101,7 @@ func NewSystemServer(serviceName string, storage datasource.IStorage) (*systemSe
 			server.UpdateCluster,
 			server.DeleteCluster,
 		)
+		group.GET(api.GenerateURI("system.ddx2x.nip", "v1", "cluster/:name/status", false), server.GetClusterStatus)
 	}
 
 	// availableZone
@@ -118,6 +148,17 @@ func NewSystemServer(serviceName string, storage datasource.IStorage) (*systemSe
 		)
 	}
 
+	// securitygroup
+	{
+		api.GenerateURIV2(group, "system.ddx2x.nip", "v1", "securitygroup", true,
+			server.ListSecurityGroup,
+			server.ListSecurityGroup,
+			server.CreateSecurityGroup,
+			server.UpdateSecurityGroup,
+			server.DeleteSecurityGroup,
+		)
+	}
+
 	// provider
 	{
 		api.GenerateURIV2(group, "system.ddx2x.nip", "v1", "provider", false,
@@ -144,6 +185,10 @@ func NewSystemServer(serviceName string, storage datasource.IStorage) (*systemSe
 	{
 		group.GET(api.GenerateURI("system.ddx2x.nip", "v1", "instancetype", true), server.ListInstanceType)
 		group.GET(api.GenerateURI("system.ddx2x.nip", "v1", "instancetype", false), server.ListInstanceType)
+		group.POST(api.GenerateURI("system.ddx2x.nip", "v1", "instancetype", true), server.CreateInstanceType)
+		group.POST(api.GenerateURI("system.ddx2x.nip", "v1", "instancetype", false), server.CreateInstanceType)
+		group.DELETE(api.GenerateURI("system.ddx2x.nip", "v1", "instancetype/:name", true), server.DeleteInstanceType)
+		group.DELETE(api.GenerateURI("system.ddx2x.nip", "v1", "instancetype/:name", false), server.DeleteInstanceType)
 	}
 
 	// operation
@@ -190,5 +235,69 @@ func NewSystemServer(serviceName string, storage datasource.IStorage) (*systemSe
 		)
 	}
 
+	// drift
+	{
+		api.GenerateURIV2(group, "system.ddx2x.nip", "v1", "drift", true,
+			server.ListDrift,
+			server.ListDrift,
+			nil,
+			nil,
+			nil,
+		)
+	}
+
+	// resourcequota
+	{
+		api.GenerateURIV2(group, "system.ddx2x.nip", "v1", "resourcequota", true,
+			server.ListResourceQuota,
+			server.ListResourceQuota,
+			server.CreateResourceQuota,
+			server.UpdateResourceQuota,
+			server.DeleteResourceQuota,
+		)
+		group.GET(api.GenerateURI("system.ddx2x.nip", "v1", "resourcequota/:name/usage", true), server.GetResourceQuotaUsage)
+		group.GET(api.GenerateURI("system.ddx2x.nip", "v1", "resourcequota/:name/usage", false), server.GetResourceQuotaUsage)
+	}
+
+	// usage
+	{
+		api.GenerateURIV2(group, "system.ddx2x.nip", "v1", "usagerecord", true,
+			server.ListUsageRecord,
+			nil,
+			nil,
+			nil,
+			nil,
+		)
+		group.GET(api.GenerateURI("system.ddx2x.nip", "v1", "invoice", true), server.GetInvoice)
+		group.GET(api.GenerateURI("system.ddx2x.nip", "v1", "invoice", false), server.GetInvoice)
+	}
+
+	// schedule
+	{
+		api.GenerateURIV2(group, "system.ddx2x.nip", "v1", "schedule", true,
+			server.ListSchedule,
+			server.ListSchedule,
+			server.CreateSchedule,
+			server.UpdateSchedule,
+			server.DeleteSchedule,
+		)
+	}
+
+	// stack
+	{
+		api.GenerateURIV2(group, "system.ddx2x.nip", "v1", "stack", true,
+			server.ListStack,
+			server.ListStack,
+			server.CreateStack,
+			server.UpdateStack,
+			server.DeleteStack,
+		)
+	}
+
+	// topology
+	{
+		group.GET(api.GenerateURI("system.ddx2x.nip", "v1", "topology", false), server.GetTopology)
+	}
+
 	return server, nil
 }
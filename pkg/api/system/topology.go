@@ -0,0 +1,28 @@
+package system
+
+import (
+	"net/http"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/topology"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTopology the provider -> region -> az -> cluster graph, only the provider given by ?provider= if set
+func (i *systemServer) GetTopology(g *gin.Context) {
+	graph, err := i.topology.Graph()
+	if err != nil {
+		api.InternalServerError(g, err, err)
+		return
+	}
+
+	if name := g.Query("provider"); name != "" {
+		provider := graph.Provider(name)
+		graph.Providers = make([]*topology.Provider, 0)
+		if provider != nil {
+			graph.Providers = append(graph.Providers, provider)
+		}
+	}
+
+	g.JSON(http.StatusOK, graph)
+}
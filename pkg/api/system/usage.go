@@ -0,0 +1,97 @@
+package system
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/api"
+	"github.com/ddx2x/oilmont/pkg/metering"
+	"github.com/gin-gonic/gin"
+)
+
+const formatCSV = "csv"
+
+// ListUsageRecord the usage records of the workspace of the path or of ?tenant=, between ?from= and ?to=.
+// ?format=csv exports them
+func (i *systemServer) ListUsageRecord(g *gin.Context) {
+	from, to, err := usagePeriod(g)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	results, err := i.usage.List(g.Query("tenant"), g.Param("namespace"), from, to)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	if g.Query("format") == formatCSV {
+		g.Header("Content-Disposition", fmt.Sprintf("attachment; filename=usage-%d-%d.csv", from, to))
+		g.Header("Content-Type", "text/csv")
+		g.Status(http.StatusOK)
+		if err := metering.WriteRecordsCSV(g.Writer, results.Items); err != nil {
+			_ = g.Error(err)
+		}
+		return
+	}
+	g.JSON(http.StatusOK, results)
+}
+
+// GetInvoice the cost of the workspace of the path or of ?tenant= between ?from= and ?to=, ?format=csv exports it
+func (i *systemServer) GetInvoice(g *gin.Context) {
+	from, to, err := usagePeriod(g)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	invoice, err := i.usage.Invoice(g.Query("tenant"), g.Param("namespace"), from, to)
+	if err != nil {
+		api.RequestParametersError(g, err)
+		return
+	}
+
+	if g.Query("format") == formatCSV {
+		g.Header("Content-Disposition", fmt.Sprintf("attachment; filename=invoice-%d-%d.csv", invoice.From, invoice.To))
+		g.Header("Content-Type", "text/csv")
+		g.Status(http.StatusOK)
+		if err := invoice.WriteCSV(g.Writer); err != nil {
+			_ = g.Error(err)
+		}
+		return
+	}
+	g.JSON(http.StatusOK, invoice)
+}
+
+// usagePeriod ?from= and ?to= either in unix seconds or RFC3339, from the start of the month until now by default
+func usagePeriod(g *gin.Context) (int64, int64, error) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Unix()
+	to := now.Unix()
+
+	for _, item := range []struct {
+		name  string
+		value *int64
+	}{{"from", &from}, {"to", &to}} {
+		raw := g.Query(item.name)
+		if raw == "" {
+			continue
+		}
+		if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			*item.value = seconds
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return 0, 0, fmt.Errorf("%s must be unix seconds or RFC3339, got %s", item.name, raw)
+		}
+		*item.value = t.Unix()
+	}
+	if from >= to {
+		return 0, 0, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,149 @@
+// Package cloudinit render the cloud-config document a vm boots with
+package cloudinit
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const Header = "#cloud-config"
+
+type Config struct {
+	Hostname          string
+	SSHAuthorizedKeys []string
+	Password          string
+	// UserData a #cloud-config document supplied by the user, the generated keys are merged into it
+	UserData string
+}
+
+// Render the validated cloud-config document of config
+func Render(config Config) (string, error) {
+	document, err := parseUserData(config.UserData)
+	if err != nil {
+		return "", err
+	}
+
+	if _, exist := document["hostname"]; !exist && config.Hostname != "" {
+		document["hostname"] = config.Hostname
+	}
+
+	if len(config.SSHAuthorizedKeys) > 0 {
+		keys, _ := document["ssh_authorized_keys"].([]interface{})
+		for _, key := range config.SSHAuthorizedKeys {
+			if !contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+		document["ssh_authorized_keys"] = keys
+	}
+
+	if config.Password != "" {
+		document["password"] = config.Password
+		document["chpasswd"] = map[string]interface{}{"expire": false}
+		document["ssh_pwauth"] = true
+	}
+
+	if err := validate(document); err != nil {
+		return "", err
+	}
+
+	data, err := yaml.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s\n%s", Header, data), nil
+}
+
+// ValidateNetworkData network data must be a network-config version 1 or 2 document
+func ValidateNetworkData(networkData string) error {
+	if strings.TrimSpace(networkData) == "" {
+		return nil
+	}
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(networkData), &document); err != nil {
+		return fmt.Errorf("invalid network data %v", err)
+	}
+	if network, ok := document["network"].(map[string]interface{}); ok {
+		document = network
+	}
+	switch document["version"] {
+	case float64(1), float64(2):
+		return nil
+	}
+	return fmt.Errorf("network data version must be 1 or 2")
+}
+
+func parseUserData(userData string) (map[string]interface{}, error) {
+	document := map[string]interface{}{}
+	userData = strings.TrimSpace(userData)
+	if userData == "" {
+		return document, nil
+	}
+	if !strings.HasPrefix(userData, Header) {
+		return nil, fmt.Errorf("user data must be a %s document", Header)
+	}
+	if err := yaml.Unmarshal([]byte(userData), &document); err != nil {
+		return nil, fmt.Errorf("invalid user data %v", err)
+	}
+	if document == nil {
+		document = map[string]interface{}{}
+	}
+	return document, nil
+}
+
+func validate(document map[string]interface{}) error {
+	if hostname, exist := document["hostname"]; exist {
+		if _, ok := hostname.(string); !ok {
+			return fmt.Errorf("hostname must be a string")
+		}
+	}
+	for _, key := range []string{"ssh_authorized_keys", "packages"} {
+		value, exist := document[key]
+		if !exist {
+			continue
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be a list", key)
+		}
+		for _, item := range items {
+			if key == "ssh_authorized_keys" {
+				if _, ok := item.(string); !ok {
+					return fmt.Errorf("ssh_authorized_keys must be a list of string")
+				}
+			}
+		}
+	}
+	if value, exist := document["users"]; exist {
+		users, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("users must be a list")
+		}
+		for _, user := range users {
+			switch user := user.(type) {
+			case string:
+				if user != "default" {
+					return fmt.Errorf("user %s must be a mapping", user)
+				}
+			case map[string]interface{}:
+				if name, _ := user["name"].(string); name == "" {
+					return fmt.Errorf("user name is empty")
+				}
+			default:
+				return fmt.Errorf("user must be a mapping")
+			}
+		}
+	}
+	return nil
+}
+
+func contains(items []interface{}, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package cloudinit
+
+import (
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestRender(t *testing.T) {
+	userData := "#cloud-config\npackages: [nginx]\nssh_authorized_keys: [ssh-rsa AAA]\n"
+	data, err := Render(Config{Hostname: "vm1", SSHAuthorizedKeys: []string{"ssh-rsa AAA", "ssh-rsa BBB"}, UserData: userData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(data, Header+"\n") {
+		t.Fatalf("expected %s header, got %s", Header, data)
+	}
+
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(data), &document); err != nil {
+		t.Fatal(err)
+	}
+	if document["hostname"] != "vm1" {
+		t.Fatalf("expected hostname vm1, got %v", document["hostname"])
+	}
+	if keys := document["ssh_authorized_keys"].([]interface{}); len(keys) != 2 {
+		t.Fatalf("expected 2 distinct keys, got %v", keys)
+	}
+	if packages := document["packages"].([]interface{}); len(packages) != 1 {
+		t.Fatalf("expected user packages kept, got %v", packages)
+	}
+}
+
+func TestRenderInvalid(t *testing.T) {
+	for _, userData := range []string{
+		"#!/bin/sh\necho hello",
+		"#cloud-config\nhostname: [a, b]",
+		"#cloud-config\npackages: nginx",
+		"#cloud-config\nusers: [{shell: /bin/sh}]",
+	} {
+		if _, err := Render(Config{UserData: userData}); err == nil {
+			t.Fatalf("expected error for %q", userData)
+		}
+	}
+}
+
+func TestValidateNetworkData(t *testing.T) {
+	if err := ValidateNetworkData("network:\n  version: 2\n  ethernets: {}\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateNetworkData("version: 3"); err == nil {
+		t.Fatal("expected version error")
+	}
+}
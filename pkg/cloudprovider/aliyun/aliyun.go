@@ -0,0 +1,139 @@
+// Package aliyun drive ecs, vpc and slb for the "aliyun" provider
+package aliyun
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/errors"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/slb"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/common"
+)
+
+// pageSize the largest page the describe apis accept
+const pageSize = 100
+
+var _ cloudprovider.Interface = &Cloud{}
+
+type Cloud struct {
+	credentials cloudprovider.Credentials
+
+	mu         sync.Mutex
+	ecsClients map[string]*ecs.Client
+	vpcClients map[string]*vpc.Client
+	slbClients map[string]*slb.Client
+}
+
+func New(c cloudprovider.Credentials) (cloudprovider.Interface, error) {
+	if c.AccessKey == "" || c.AccessSecret == "" {
+		return nil, fmt.Errorf("aliyun provider requires an access key")
+	}
+	return &Cloud{
+		credentials: c,
+		ecsClients:  make(map[string]*ecs.Client),
+		vpcClients:  make(map[string]*vpc.Client),
+		slbClients:  make(map[string]*slb.Client),
+	}, nil
+}
+
+func (c *Cloud) Instances() cloudprovider.Instances                 { return &instances{c} }
+func (c *Cloud) Disks() cloudprovider.Disks                         { return &disks{c} }
+func (c *Cloud) VPCs() cloudprovider.VPCs                           { return &vpcs{c} }
+func (c *Cloud) Subnets() cloudprovider.Subnets                     { return &subnets{c} }
+func (c *Cloud) SecurityGroups() cloudprovider.SecurityGroups       { return &securityGroups{c} }
+func (c *Cloud) NetworkInterfaces() cloudprovider.NetworkInterfaces { return &networkInterfaces{c} }
+func (c *Cloud) Images() cloudprovider.Images                       { return &images{c} }
+func (c *Cloud) InstanceTypes() cloudprovider.InstanceTypes         { return &instanceTypes{c} }
+func (c *Cloud) Snapshots() cloudprovider.Snapshots                 { return &snapshots{c} }
+func (c *Cloud) ElasticIPs() cloudprovider.ElasticIPs               { return &elasticIPs{c} }
+func (c *Cloud) LoadBalancers() cloudprovider.LoadBalancers         { return &loadBalancers{c} }
+
+func (c *Cloud) region(region string) string {
+	if region == "" {
+		return c.credentials.Region
+	}
+	return region
+}
+
+// ecs client of the region, the endpoints of aliyun are regional so clients are cached per region
+func (c *Cloud) ecs(region string) (*ecs.Client, error) {
+	region = c.region(region)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, exist := c.ecsClients[region]; exist {
+		return client, nil
+	}
+	client, err := ecs.NewClientWithAccessKey(region, c.credentials.AccessKey, c.credentials.AccessSecret)
+	if err != nil {
+		return nil, err
+	}
+	c.ecsClients[region] = client
+	return client, nil
+}
+
+func (c *Cloud) vpc(region string) (*vpc.Client, error) {
+	region = c.region(region)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, exist := c.vpcClients[region]; exist {
+		return client, nil
+	}
+	client, err := vpc.NewClientWithAccessKey(region, c.credentials.AccessKey, c.credentials.AccessSecret)
+	if err != nil {
+		return nil, err
+	}
+	c.vpcClients[region] = client
+	return client, nil
+}
+
+func (c *Cloud) slb(region string) (*slb.Client, error) {
+	region = c.region(region)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, exist := c.slbClients[region]; exist {
+		return client, nil
+	}
+	client, err := slb.NewClientWithAccessKey(region, c.credentials.AccessKey, c.credentials.AccessSecret)
+	if err != nil {
+		return nil, err
+	}
+	c.slbClients[region] = client
+	return client, nil
+}
+
+// convertError map the "*.NotFound" error codes of aliyun to cloudprovider.NotFound
+func convertError(err error) error {
+	if serverErr, ok := err.(*errors.ServerError); ok && strings.HasSuffix(serverErr.ErrorCode(), ".NotFound") {
+		return cloudprovider.NotFound
+	}
+	return err
+}
+
+// jsonIds the ["a","b"] form the describe apis take their id filters in
+func jsonIds(ids ...string) string {
+	return fmt.Sprintf(`["%s"]`, strings.Join(ids, `","`))
+}
+
+// splitCidr split 10.0.0.0/16 into the ip and mask fields of the resources
+func splitCidr(cidr string) (string, string) {
+	parts := strings.SplitN(cidr, "/", 2)
+	if len(parts) != 2 {
+		return cidr, ""
+	}
+	return parts[0], parts[1]
+}
+
+func joinCidr(ip, mask string) string {
+	if mask == "" {
+		return ip
+	}
+	return fmt.Sprintf("%s/%s", ip, mask)
+}
+
+func init() {
+	cloudprovider.Register(common.ALIYUN, New)
+}
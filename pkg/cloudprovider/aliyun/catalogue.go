@@ -0,0 +1,76 @@
+package aliyun
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+type images struct{ *Cloud }
+
+func (c *images) List(_ context.Context, region string) ([]compute.Image, error) {
+	client, err := c.ecs(region)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]compute.Image, 0)
+	for page := 1; ; page++ {
+		request := ecs.CreateDescribeImagesRequest()
+		request.RegionId = c.region(region)
+		request.PageNumber = requests.NewInteger(page)
+		request.PageSize = requests.NewInteger(pageSize)
+		response, err := client.DescribeImages(request)
+		if err != nil {
+			return nil, convertError(err)
+		}
+		for _, image := range response.Images.Image {
+			result = append(result, compute.Image{
+				Spec: compute.ImageSpec{
+					Version: image.ImageVersion,
+					Os:      image.OSName,
+					Region:  c.region(region),
+					ID:      image.ImageId,
+				},
+			})
+		}
+		if page*pageSize >= response.TotalCount {
+			return result, nil
+		}
+	}
+}
+
+type instanceTypes struct{ *Cloud }
+
+func (c *instanceTypes) List(_ context.Context, region string) ([]system.InstanceType, error) {
+	client, err := c.ecs(region)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]system.InstanceType, 0)
+	request := ecs.CreateDescribeInstanceTypesRequest()
+	request.RegionId = c.region(region)
+	for {
+		response, err := client.DescribeInstanceTypes(request)
+		if err != nil {
+			return nil, convertError(err)
+		}
+		for _, instanceType := range response.InstanceTypes.InstanceType {
+			result = append(result, system.InstanceType{
+				Spec: system.InstanceTypeSpec{
+					Cores:  int64(instanceType.CpuCoreCount),
+					Memory: fmt.Sprintf("%gGi", instanceType.MemorySize),
+					Region: c.region(region),
+					ID:     instanceType.InstanceTypeId,
+				},
+			})
+		}
+		if response.NextToken == "" {
+			return result, nil
+		}
+		request.NextToken = response.NextToken
+	}
+}
@@ -0,0 +1,154 @@
+package aliyun
+
+import (
+	"context"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+type disks struct{ *Cloud }
+
+func (c *disks) Create(_ context.Context, disk *compute.Storage) error {
+	client, err := c.ecs(disk.Spec.Region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateCreateDiskRequest()
+	request.RegionId = c.region(disk.Spec.Region)
+	request.ZoneId = disk.Spec.Zone
+	request.DiskName = disk.Spec.LocalName
+	request.Description = disk.Spec.Description
+	request.DiskCategory = disk.Spec.CategoryType
+	request.Size = requests.NewInteger(disk.Spec.Size)
+	response, err := client.CreateDisk(request)
+	if err != nil {
+		return convertError(err)
+	}
+	disk.Spec.StorageId = response.DiskId
+	disk.Spec.State = "Creating"
+	return nil
+}
+
+func (c *disks) Get(_ context.Context, region, id string) (*compute.Storage, error) {
+	storages, err := c.describe(region, jsonIds(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(storages) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &storages[0], nil
+}
+
+func (c *disks) List(_ context.Context, region string) ([]compute.Storage, error) {
+	return c.describe(region, "")
+}
+
+func (c *disks) describe(region, ids string) ([]compute.Storage, error) {
+	client, err := c.ecs(region)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]compute.Storage, 0)
+	for page := 1; ; page++ {
+		request := ecs.CreateDescribeDisksRequest()
+		request.RegionId = c.region(region)
+		request.DiskIds = ids
+		request.PageNumber = requests.NewInteger(page)
+		request.PageSize = requests.NewInteger(pageSize)
+		response, err := client.DescribeDisks(request)
+		if err != nil {
+			return nil, convertError(err)
+		}
+		for _, disk := range response.Disks.Disk {
+			result = append(result, toStorage(disk))
+		}
+		if page*pageSize >= response.TotalCount {
+			return result, nil
+		}
+	}
+}
+
+func (c *disks) Delete(_ context.Context, region, id string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateDeleteDiskRequest()
+	request.RegionId = c.region(region)
+	request.DiskId = id
+	_, err = client.DeleteDisk(request)
+	return convertError(err)
+}
+
+func (c *disks) Attach(_ context.Context, region, id, instanceId string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateAttachDiskRequest()
+	request.RegionId = c.region(region)
+	request.DiskId = id
+	request.InstanceId = instanceId
+	_, err = client.AttachDisk(request)
+	return convertError(err)
+}
+
+func (c *disks) Detach(_ context.Context, region, id, instanceId string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateDetachDiskRequest()
+	request.RegionId = c.region(region)
+	request.DiskId = id
+	request.InstanceId = instanceId
+	_, err = client.DetachDisk(request)
+	return convertError(err)
+}
+
+// Resize online resize, the disk of a stopped instance takes effect on the next start
+func (c *disks) Resize(_ context.Context, region, id string, size int) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateResizeDiskRequest()
+	request.RegionId = c.region(region)
+	request.DiskId = id
+	request.NewSize = requests.NewInteger(size)
+	request.Type = "online"
+	_, err = client.ResizeDisk(request)
+	return convertError(err)
+}
+
+func toStorage(disk ecs.Disk) compute.Storage {
+	storage := compute.Storage{
+		Spec: compute.StorageSpec{
+			CategoryType:       disk.Category,
+			DeleteWithInstance: disk.DeleteWithInstance,
+			Description:        disk.Description,
+			DiskChargeType:     disk.DiskChargeType,
+			DiskType:           disk.Type,
+			IOPS:               disk.IOPS,
+			LocalName:          disk.DiskName,
+			Region:             disk.RegionId,
+			Zone:               disk.ZoneId,
+			Size:               disk.Size,
+			State:              disk.Status,
+			StorageId:          disk.DiskId,
+		},
+	}
+	for _, attachment := range disk.Attachments.Attachment {
+		storage.Spec.Attachments = append(storage.Spec.Attachments, compute.Attachment{
+			AttachedTime: attachment.AttachedTime,
+			Device:       attachment.Device,
+			InstanceId:   attachment.InstanceId,
+			State:        disk.Status,
+		})
+	}
+	return storage
+}
@@ -0,0 +1,185 @@
+package aliyun
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+type instances struct{ *Cloud }
+
+func (c *instances) Create(_ context.Context, vm *compute.VirtualMachine, bootstrap *cloudprovider.Bootstrap) error {
+	client, err := c.ecs(vm.Spec.RegionId)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateRunInstancesRequest()
+	request.RegionId = c.region(vm.Spec.RegionId)
+	request.ImageId = vm.Spec.ImageId
+	request.InstanceType = vm.Spec.InstanceType
+	request.InstanceName = vm.Spec.LocalName
+	request.ZoneId = vm.Spec.Az
+	request.VSwitchId = vm.Spec.VSwitchId
+	if len(vm.Spec.PrivateIpAddress) > 0 {
+		request.PrivateIpAddress = vm.Spec.PrivateIpAddress[0]
+	}
+	request.Amount = requests.NewInteger(1)
+	if len(vm.Spec.SecurityGroup) > 0 {
+		request.SecurityGroupIds = &vm.Spec.SecurityGroup
+	}
+	if bootstrap != nil {
+		if bootstrap.UserData != "" {
+			request.UserData = base64.StdEncoding.EncodeToString([]byte(bootstrap.UserData))
+		}
+		request.Password = bootstrap.Password
+	}
+
+	response, err := client.RunInstances(request)
+	if err != nil {
+		return convertError(err)
+	}
+	if len(response.InstanceIdSets.InstanceIdSet) == 0 {
+		return fmt.Errorf("run instances return no instance")
+	}
+	vm.Spec.InstanceId = response.InstanceIdSets.InstanceIdSet[0]
+	vm.Spec.State = compute.Starting
+	return nil
+}
+
+func (c *instances) Get(_ context.Context, region, id string) (*compute.VirtualMachine, error) {
+	vms, err := c.describe(region, jsonIds(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(vms) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &vms[0], nil
+}
+
+func (c *instances) List(_ context.Context, region string) ([]compute.VirtualMachine, error) {
+	return c.describe(region, "")
+}
+
+func (c *instances) describe(region, ids string) ([]compute.VirtualMachine, error) {
+	client, err := c.ecs(region)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]compute.VirtualMachine, 0)
+	for page := 1; ; page++ {
+		request := ecs.CreateDescribeInstancesRequest()
+		request.RegionId = c.region(region)
+		request.InstanceIds = ids
+		request.PageNumber = requests.NewInteger(page)
+		request.PageSize = requests.NewInteger(pageSize)
+		response, err := client.DescribeInstances(request)
+		if err != nil {
+			return nil, convertError(err)
+		}
+		for _, instance := range response.Instances.Instance {
+			result = append(result, toVirtualMachine(instance))
+		}
+		if page*pageSize >= response.TotalCount {
+			return result, nil
+		}
+	}
+}
+
+func (c *instances) Delete(_ context.Context, region, id string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateDeleteInstanceRequest()
+	request.RegionId = c.region(region)
+	request.InstanceId = id
+	request.Force = requests.NewBoolean(true)
+	_, err = client.DeleteInstance(request)
+	return convertError(err)
+}
+
+func (c *instances) Start(_ context.Context, region, id string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateStartInstanceRequest()
+	request.RegionId = c.region(region)
+	request.InstanceId = id
+	_, err = client.StartInstance(request)
+	return convertError(err)
+}
+
+func (c *instances) Stop(_ context.Context, region, id string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateStopInstanceRequest()
+	request.RegionId = c.region(region)
+	request.InstanceId = id
+	_, err = client.StopInstance(request)
+	return convertError(err)
+}
+
+func (c *instances) Reboot(_ context.Context, region, id string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateRebootInstanceRequest()
+	request.RegionId = c.region(region)
+	request.InstanceId = id
+	_, err = client.RebootInstance(request)
+	return convertError(err)
+}
+
+func instanceState(status string) compute.VirtualMachineStateType {
+	switch status {
+	case "Pending", "Starting":
+		return compute.Starting
+	case "Running":
+		return compute.Running
+	case "Stopping":
+		return compute.Stopping
+	case "Stopped":
+		return compute.Stopped
+	}
+	return compute.VirtualMachineStateType(strings.ToLower(status))
+}
+
+func toVirtualMachine(instance ecs.Instance) compute.VirtualMachine {
+	vm := compute.VirtualMachine{
+		Spec: compute.VirtualMachineSpec{
+			CPU:           fmt.Sprintf("%d", instance.Cpu),
+			Memory:        fmt.Sprintf("%dMi", instance.Memory),
+			LocalName:     instance.InstanceName,
+			RegionId:      instance.RegionId,
+			Az:            instance.ZoneId,
+			InstanceId:    instance.InstanceId,
+			InstanceType:  instance.InstanceType,
+			VPCId:         instance.VpcAttributes.VpcId,
+			VSwitchId:     instance.VpcAttributes.VSwitchId,
+			ImageId:       instance.ImageId,
+			SecurityGroup: instance.SecurityGroupIds.SecurityGroupId,
+			Vendor:        common.ALIYUN,
+			State:         instanceState(instance.Status),
+			CreateTime:    instance.CreationTime,
+			Os:            instance.OSName,
+		},
+	}
+	vm.Spec.PrivateIpAddress = append(vm.Spec.PrivateIpAddress, instance.VpcAttributes.PrivateIpAddress.IpAddress...)
+	vm.Spec.PublicIpAddress = append(vm.Spec.PublicIpAddress, instance.PublicIpAddress.IpAddress...)
+	if instance.EipAddress.IpAddress != "" {
+		vm.Spec.PublicIpAddress = append(vm.Spec.PublicIpAddress, instance.EipAddress.IpAddress)
+	}
+	return vm
+}
@@ -0,0 +1,410 @@
+package aliyun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/slb"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+const (
+	eipInstanceTypeEcs = "EcsInstance"
+	eipInstanceTypeSlb = "SlbInstance"
+)
+
+type elasticIPs struct{ *Cloud }
+
+// Allocate an eip, aliyun picks the address so Address is ignored
+func (c *elasticIPs) Allocate(_ context.Context, elasticIP *networking.ElasticIP) error {
+	client, err := c.vpc(elasticIP.Spec.Region)
+	if err != nil {
+		return err
+	}
+	request := vpc.CreateAllocateEipAddressRequest()
+	request.RegionId = c.region(elasticIP.Spec.Region)
+	request.Name = elasticIP.GetName()
+	if elasticIP.Spec.Bandwidth > 0 {
+		request.Bandwidth = strconv.Itoa(elasticIP.Spec.Bandwidth)
+	}
+	response, err := client.AllocateEipAddress(request)
+	if err != nil {
+		return convertError(err)
+	}
+	elasticIP.Spec.AllocationId = response.AllocationId
+	elasticIP.Spec.Address = response.EipAddress
+	elasticIP.Spec.State = networking.ElasticIPAvailable
+	return nil
+}
+
+func (c *elasticIPs) Get(_ context.Context, region, id string) (*networking.ElasticIP, error) {
+	address, err := c.describe(region, id)
+	if err != nil {
+		return nil, err
+	}
+	bandwidth, _ := strconv.Atoi(address.Bandwidth)
+	elasticIP := &networking.ElasticIP{
+		Spec: networking.ElasticIPSpec{
+			Region:       c.region(region),
+			Address:      address.IpAddress,
+			AllocationId: address.AllocationId,
+			Bandwidth:    bandwidth,
+			InstanceId:   address.InstanceId,
+			State:        networking.ElasticIPAvailable,
+		},
+	}
+	if address.Status == "InUse" {
+		elasticIP.Spec.State = networking.ElasticIPAssociated
+	}
+	return elasticIP, nil
+}
+
+func (c *elasticIPs) describe(region, id string) (*vpc.EipAddress, error) {
+	client, err := c.vpc(region)
+	if err != nil {
+		return nil, err
+	}
+	request := vpc.CreateDescribeEipAddressesRequest()
+	request.RegionId = c.region(region)
+	request.AllocationId = id
+	response, err := client.DescribeEipAddresses(request)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	if len(response.EipAddresses.EipAddress) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &response.EipAddresses.EipAddress[0], nil
+}
+
+func (c *elasticIPs) Release(_ context.Context, region, id string) error {
+	client, err := c.vpc(region)
+	if err != nil {
+		return err
+	}
+	request := vpc.CreateReleaseEipAddressRequest()
+	request.RegionId = c.region(region)
+	request.AllocationId = id
+	_, err = client.ReleaseEipAddress(request)
+	return convertError(err)
+}
+
+func (c *elasticIPs) Associate(_ context.Context, region, id, instanceId string) error {
+	return c.associate(region, id, instanceId, eipInstanceTypeEcs)
+}
+
+func (c *elasticIPs) associate(region, id, instanceId, instanceType string) error {
+	client, err := c.vpc(region)
+	if err != nil {
+		return err
+	}
+	request := vpc.CreateAssociateEipAddressRequest()
+	request.RegionId = c.region(region)
+	request.AllocationId = id
+	request.InstanceId = instanceId
+	request.InstanceType = instanceType
+	_, err = client.AssociateEipAddress(request)
+	return convertError(err)
+}
+
+func (c *elasticIPs) Disassociate(_ context.Context, region, id, instanceId string) error {
+	return c.disassociate(region, id, instanceId, eipInstanceTypeEcs)
+}
+
+func (c *elasticIPs) disassociate(region, id, instanceId, instanceType string) error {
+	client, err := c.vpc(region)
+	if err != nil {
+		return err
+	}
+	request := vpc.CreateUnassociateEipAddressRequest()
+	request.RegionId = c.region(region)
+	request.AllocationId = id
+	request.InstanceId = instanceId
+	request.InstanceType = instanceType
+	_, err = client.UnassociateEipAddress(request)
+	return convertError(err)
+}
+
+// loadBalancers classic slb instances. The backend port of a listener can not be modified so Update recreates
+// every listener, which briefly interrupts the traffic
+type loadBalancers struct{ *Cloud }
+
+// Create an intranet slb in the first subnet when internal or exposed on an eip, an internet one otherwise
+func (c *loadBalancers) Create(ctx context.Context, loadBalancer *networking.LoadBalancer, instanceIds []string, allocationId string) error {
+	spec := &loadBalancer.Spec
+	client, err := c.slb(spec.Region)
+	if err != nil {
+		return err
+	}
+	request := slb.CreateCreateLoadBalancerRequest()
+	request.RegionId = c.region(spec.Region)
+	request.LoadBalancerName = loadBalancer.GetName()
+	request.AddressType = "internet"
+	if spec.Internal || allocationId != "" {
+		request.AddressType = "intranet"
+		request.VpcId = spec.VPCId
+		if len(spec.Subnets) > 0 {
+			request.VSwitchId = spec.Subnets[0]
+		}
+	}
+	response, err := client.CreateLoadBalancer(request)
+	if err != nil {
+		return convertError(err)
+	}
+	spec.LoadBalancerId = response.LoadBalancerId
+	spec.Address = response.Address
+	spec.State = networking.LoadBalancerActive
+
+	if allocationId != "" {
+		elasticIPs := &elasticIPs{c.Cloud}
+		if err := elasticIPs.associate(spec.Region, allocationId, spec.LoadBalancerId, eipInstanceTypeSlb); err != nil {
+			return err
+		}
+		address, err := elasticIPs.describe(spec.Region, allocationId)
+		if err != nil {
+			return err
+		}
+		spec.Address = address.IpAddress
+	}
+	return c.Update(ctx, loadBalancer, instanceIds)
+}
+
+func (c *loadBalancers) Get(_ context.Context, region, id string) (*networking.LoadBalancer, error) {
+	response, err := c.describe(region, id)
+	if err != nil {
+		return nil, err
+	}
+	loadBalancer := &networking.LoadBalancer{
+		Spec: networking.LoadBalancerSpec{
+			Region:         c.region(region),
+			VPCId:          response.VpcId,
+			Internal:       response.AddressType == "intranet",
+			LoadBalancerId: response.LoadBalancerId,
+			Address:        response.Address,
+			State:          networking.LoadBalancerPending,
+		},
+	}
+	if response.VSwitchId != "" {
+		loadBalancer.Spec.Subnets = []string{response.VSwitchId}
+	}
+	if response.LoadBalancerStatus == "active" {
+		loadBalancer.Spec.State = networking.LoadBalancerActive
+	}
+	for _, listener := range response.ListenerPortsAndProtocol.ListenerPortAndProtocol {
+		loadBalancer.Spec.Listeners = append(loadBalancer.Spec.Listeners, networking.Listener{
+			Protocol: listener.ListenerProtocol,
+			Port:     listener.ListenerPort,
+		})
+	}
+	return loadBalancer, nil
+}
+
+func (c *loadBalancers) describe(region, id string) (*slb.DescribeLoadBalancerAttributeResponse, error) {
+	client, err := c.slb(region)
+	if err != nil {
+		return nil, err
+	}
+	request := slb.CreateDescribeLoadBalancerAttributeRequest()
+	request.RegionId = c.region(region)
+	request.LoadBalancerId = id
+	response, err := client.DescribeLoadBalancerAttribute(request)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return response, nil
+}
+
+// Update recreate the listeners and sync the backend servers
+func (c *loadBalancers) Update(_ context.Context, loadBalancer *networking.LoadBalancer, instanceIds []string) error {
+	spec := &loadBalancer.Spec
+	client, err := c.slb(spec.Region)
+	if err != nil {
+		return err
+	}
+	current, err := c.describe(spec.Region, spec.LoadBalancerId)
+	if err != nil {
+		return err
+	}
+
+	for _, listener := range current.ListenerPortsAndProtocol.ListenerPortAndProtocol {
+		request := slb.CreateDeleteLoadBalancerListenerRequest()
+		request.RegionId = c.region(spec.Region)
+		request.LoadBalancerId = spec.LoadBalancerId
+		request.ListenerPort = requests.NewInteger(listener.ListenerPort)
+		request.ListenerProtocol = listener.ListenerProtocol
+		if _, err := client.DeleteLoadBalancerListener(request); err != nil {
+			return convertError(err)
+		}
+	}
+	for _, listener := range spec.Listeners {
+		if err := c.createListener(client, spec, listener); err != nil {
+			return err
+		}
+	}
+
+	registered := make(map[string]bool)
+	for _, server := range current.BackendServers.BackendServer {
+		registered[server.ServerId] = true
+	}
+	add := make([]string, 0)
+	for _, instanceId := range instanceIds {
+		if !registered[instanceId] {
+			add = append(add, instanceId)
+		}
+		delete(registered, instanceId)
+	}
+	remove := make([]string, 0)
+	for instanceId := range registered {
+		remove = append(remove, instanceId)
+	}
+
+	if len(add) > 0 {
+		request := slb.CreateAddBackendServersRequest()
+		request.RegionId = c.region(spec.Region)
+		request.LoadBalancerId = spec.LoadBalancerId
+		if request.BackendServers, err = backendServers(add); err != nil {
+			return err
+		}
+		if _, err := client.AddBackendServers(request); err != nil {
+			return convertError(err)
+		}
+	}
+	if len(remove) > 0 {
+		request := slb.CreateRemoveBackendServersRequest()
+		request.RegionId = c.region(spec.Region)
+		request.LoadBalancerId = spec.LoadBalancerId
+		if request.BackendServers, err = backendServers(remove); err != nil {
+			return err
+		}
+		if _, err := client.RemoveBackendServers(request); err != nil {
+			return convertError(err)
+		}
+	}
+	return nil
+}
+
+func (c *loadBalancers) createListener(client *slb.Client, spec *networking.LoadBalancerSpec, listener networking.Listener) error {
+	check := spec.HealthCheck
+	checkType, checkURI := "tcp", ""
+	if check.Protocol == networking.ListenerHTTP {
+		checkType, checkURI = "http", check.Path
+	}
+
+	var err error
+	switch listener.Protocol {
+	case networking.ListenerHTTP:
+		request := slb.CreateCreateLoadBalancerHTTPListenerRequest()
+		request.RegionId = c.region(spec.Region)
+		request.LoadBalancerId = spec.LoadBalancerId
+		request.ListenerPort = requests.NewInteger(listener.Port)
+		request.BackendServerPort = requests.NewInteger(listener.BackendPort)
+		request.Bandwidth = requests.NewInteger(-1)
+		request.HealthCheck = "on"
+		request.HealthCheckURI = check.Path
+		request.HealthCheckConnectPort = requests.NewInteger(check.Port)
+		request.HealthCheckInterval = requests.NewInteger(check.Interval)
+		request.HealthCheckTimeout = requests.NewInteger(check.Timeout)
+		request.HealthyThreshold = requests.NewInteger(check.HealthyThreshold)
+		request.UnhealthyThreshold = requests.NewInteger(check.UnhealthyThreshold)
+		_, err = client.CreateLoadBalancerHTTPListener(request)
+	case networking.ListenerUDP:
+		request := slb.CreateCreateLoadBalancerUDPListenerRequest()
+		request.RegionId = c.region(spec.Region)
+		request.LoadBalancerId = spec.LoadBalancerId
+		request.ListenerPort = requests.NewInteger(listener.Port)
+		request.BackendServerPort = requests.NewInteger(listener.BackendPort)
+		request.Bandwidth = requests.NewInteger(-1)
+		request.HealthCheckConnectPort = requests.NewInteger(check.Port)
+		request.HealthCheckInterval = requests.NewInteger(check.Interval)
+		request.HealthCheckConnectTimeout = requests.NewInteger(check.Timeout)
+		request.HealthyThreshold = requests.NewInteger(check.HealthyThreshold)
+		request.UnhealthyThreshold = requests.NewInteger(check.UnhealthyThreshold)
+		_, err = client.CreateLoadBalancerUDPListener(request)
+	default:
+		request := slb.CreateCreateLoadBalancerTCPListenerRequest()
+		request.RegionId = c.region(spec.Region)
+		request.LoadBalancerId = spec.LoadBalancerId
+		request.ListenerPort = requests.NewInteger(listener.Port)
+		request.BackendServerPort = requests.NewInteger(listener.BackendPort)
+		request.Bandwidth = requests.NewInteger(-1)
+		request.HealthCheckType = checkType
+		request.HealthCheckURI = checkURI
+		request.HealthCheckConnectPort = requests.NewInteger(check.Port)
+		request.HealthCheckInterval = requests.NewInteger(check.Interval)
+		request.HealthCheckConnectTimeout = requests.NewInteger(check.Timeout)
+		request.HealthyThreshold = requests.NewInteger(check.HealthyThreshold)
+		request.UnhealthyThreshold = requests.NewInteger(check.UnhealthyThreshold)
+		_, err = client.CreateLoadBalancerTCPListener(request)
+	}
+	if err != nil {
+		return convertError(err)
+	}
+
+	request := slb.CreateStartLoadBalancerListenerRequest()
+	request.RegionId = c.region(spec.Region)
+	request.LoadBalancerId = spec.LoadBalancerId
+	request.ListenerPort = requests.NewInteger(listener.Port)
+	request.ListenerProtocol = slbProtocol(listener.Protocol)
+	_, err = client.StartLoadBalancerListener(request)
+	return convertError(err)
+}
+
+// Delete the slb, the eips it is exposed on are unassociated first and stay allocated
+func (c *loadBalancers) Delete(_ context.Context, region, id string) error {
+	vpcClient, err := c.vpc(region)
+	if err != nil {
+		return err
+	}
+	describe := vpc.CreateDescribeEipAddressesRequest()
+	describe.RegionId = c.region(region)
+	describe.AssociatedInstanceType = eipInstanceTypeSlb
+	describe.AssociatedInstanceId = id
+	response, err := vpcClient.DescribeEipAddresses(describe)
+	if err != nil {
+		return convertError(err)
+	}
+	elasticIPs := &elasticIPs{c.Cloud}
+	for _, address := range response.EipAddresses.EipAddress {
+		if err := elasticIPs.disassociate(region, address.AllocationId, id, eipInstanceTypeSlb); err != nil {
+			return err
+		}
+	}
+
+	client, err := c.slb(region)
+	if err != nil {
+		return err
+	}
+	request := slb.CreateDeleteLoadBalancerRequest()
+	request.RegionId = c.region(region)
+	request.LoadBalancerId = id
+	_, err = client.DeleteLoadBalancer(request)
+	return convertError(err)
+}
+
+func slbProtocol(protocol networking.ListenerProtocol) string {
+	switch protocol {
+	case networking.ListenerHTTP:
+		return "http"
+	case networking.ListenerUDP:
+		return "udp"
+	}
+	return "tcp"
+}
+
+// backendServers the [{"ServerId":"i-a","Weight":"100"}] form the backend server apis take
+func backendServers(instanceIds []string) (string, error) {
+	servers := make([]map[string]string, 0, len(instanceIds))
+	for _, instanceId := range instanceIds {
+		servers = append(servers, map[string]string{"ServerId": instanceId, "Weight": "100"})
+	}
+	data, err := json.Marshal(servers)
+	if err != nil {
+		return "", fmt.Errorf("marshal backend servers: %w", err)
+	}
+	return string(data), nil
+}
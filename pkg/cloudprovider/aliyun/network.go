@@ -0,0 +1,539 @@
+package aliyun
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/vpc"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+type vpcs struct{ *Cloud }
+
+func (c *vpcs) Create(_ context.Context, virtualPrivateCloud *networking.VirtualPrivateCloud) error {
+	client, err := c.vpc(virtualPrivateCloud.Spec.Region)
+	if err != nil {
+		return err
+	}
+	request := vpc.CreateCreateVpcRequest()
+	request.RegionId = c.region(virtualPrivateCloud.Spec.Region)
+	request.VpcName = virtualPrivateCloud.Spec.LocalName
+	request.CidrBlock = joinCidr(virtualPrivateCloud.Spec.IP, virtualPrivateCloud.Spec.Mask)
+	response, err := client.CreateVpc(request)
+	if err != nil {
+		return convertError(err)
+	}
+	virtualPrivateCloud.Spec.ID = response.VpcId
+	return nil
+}
+
+func (c *vpcs) Get(_ context.Context, region, id string) (*networking.VirtualPrivateCloud, error) {
+	result, err := c.describe(region, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &result[0], nil
+}
+
+func (c *vpcs) List(_ context.Context, region string) ([]networking.VirtualPrivateCloud, error) {
+	return c.describe(region, "")
+}
+
+func (c *vpcs) describe(region, id string) ([]networking.VirtualPrivateCloud, error) {
+	client, err := c.vpc(region)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]networking.VirtualPrivateCloud, 0)
+	for page := 1; ; page++ {
+		request := vpc.CreateDescribeVpcsRequest()
+		request.RegionId = c.region(region)
+		request.VpcId = id
+		request.PageNumber = requests.NewInteger(page)
+		request.PageSize = requests.NewInteger(pageSize)
+		response, err := client.DescribeVpcs(request)
+		if err != nil {
+			return nil, convertError(err)
+		}
+		for _, item := range response.Vpcs.Vpc {
+			ip, mask := splitCidr(item.CidrBlock)
+			result = append(result, networking.VirtualPrivateCloud{
+				Spec: networking.VirtualPrivateCloudSpec{
+					LocalName: item.VpcName,
+					IP:        ip,
+					Mask:      mask,
+					Region:    item.RegionId,
+					ID:        item.VpcId,
+				},
+			})
+		}
+		if page*pageSize >= response.TotalCount {
+			return result, nil
+		}
+	}
+}
+
+func (c *vpcs) Delete(_ context.Context, region, id string) error {
+	client, err := c.vpc(region)
+	if err != nil {
+		return err
+	}
+	request := vpc.CreateDeleteVpcRequest()
+	request.RegionId = c.region(region)
+	request.VpcId = id
+	_, err = client.DeleteVpc(request)
+	return convertError(err)
+}
+
+type subnets struct{ *Cloud }
+
+func (c *subnets) Create(_ context.Context, subnet *networking.Vswitch) error {
+	client, err := c.vpc(subnet.Spec.Region)
+	if err != nil {
+		return err
+	}
+	request := vpc.CreateCreateVSwitchRequest()
+	request.RegionId = c.region(subnet.Spec.Region)
+	request.VpcId = subnet.Spec.VpcId
+	request.ZoneId = subnet.Spec.Zone
+	request.VSwitchName = subnet.Spec.LocalName
+	request.Description = subnet.Spec.Describe
+	request.CidrBlock = joinCidr(subnet.Spec.IP, subnet.Spec.Mask)
+	response, err := client.CreateVSwitch(request)
+	if err != nil {
+		return convertError(err)
+	}
+	subnet.Spec.Id = response.VSwitchId
+	return nil
+}
+
+func (c *subnets) Get(_ context.Context, region, id string) (*networking.Vswitch, error) {
+	result, err := c.describe(region, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &result[0], nil
+}
+
+func (c *subnets) List(_ context.Context, region string) ([]networking.Vswitch, error) {
+	return c.describe(region, "")
+}
+
+func (c *subnets) describe(region, id string) ([]networking.Vswitch, error) {
+	client, err := c.vpc(region)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]networking.Vswitch, 0)
+	for page := 1; ; page++ {
+		request := vpc.CreateDescribeVSwitchesRequest()
+		request.RegionId = c.region(region)
+		request.VSwitchId = id
+		request.PageNumber = requests.NewInteger(page)
+		request.PageSize = requests.NewInteger(pageSize)
+		response, err := client.DescribeVSwitches(request)
+		if err != nil {
+			return nil, convertError(err)
+		}
+		for _, item := range response.VSwitches.VSwitch {
+			ip, mask := splitCidr(item.CidrBlock)
+			result = append(result, networking.Vswitch{
+				Spec: networking.VSwitchSpec{
+					LocalName: item.VSwitchName,
+					IP:        ip,
+					Mask:      mask,
+					Region:    c.region(region),
+					Zone:      item.ZoneId,
+					Id:        item.VSwitchId,
+					VpcId:     item.VpcId,
+					Describe:  item.Description,
+				},
+			})
+		}
+		if page*pageSize >= response.TotalCount {
+			return result, nil
+		}
+	}
+}
+
+func (c *subnets) Delete(_ context.Context, region, id string) error {
+	client, err := c.vpc(region)
+	if err != nil {
+		return err
+	}
+	request := vpc.CreateDeleteVSwitchRequest()
+	request.RegionId = c.region(region)
+	request.VSwitchId = id
+	_, err = client.DeleteVSwitch(request)
+	return convertError(err)
+}
+
+type securityGroups struct{ *Cloud }
+
+func (c *securityGroups) Create(ctx context.Context, securityGroup *system.SecurityGroup) error {
+	client, err := c.ecs(securityGroup.Spec.RegionId)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateCreateSecurityGroupRequest()
+	request.RegionId = c.region(securityGroup.Spec.RegionId)
+	request.VpcId = securityGroup.Spec.VpcId
+	request.SecurityGroupName = securityGroup.Spec.LocalName
+	response, err := client.CreateSecurityGroup(request)
+	if err != nil {
+		return convertError(err)
+	}
+	securityGroup.Spec.ID = response.SecurityGroupId
+	return c.Update(ctx, securityGroup)
+}
+
+func (c *securityGroups) Get(_ context.Context, region, id string) (*system.SecurityGroup, error) {
+	result, err := c.describe(region, jsonIds(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &result[0], nil
+}
+
+func (c *securityGroups) List(_ context.Context, region string) ([]system.SecurityGroup, error) {
+	return c.describe(region, "")
+}
+
+func (c *securityGroups) describe(region, ids string) ([]system.SecurityGroup, error) {
+	client, err := c.ecs(region)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]system.SecurityGroup, 0)
+	for page := 1; ; page++ {
+		request := ecs.CreateDescribeSecurityGroupsRequest()
+		request.RegionId = c.region(region)
+		request.SecurityGroupIds = ids
+		request.PageNumber = requests.NewInteger(page)
+		request.PageSize = requests.NewInteger(pageSize)
+		response, err := client.DescribeSecurityGroups(request)
+		if err != nil {
+			return nil, convertError(err)
+		}
+		for _, item := range response.SecurityGroups.SecurityGroup {
+			permissions, err := c.permissions(region, item.SecurityGroupId)
+			if err != nil {
+				return nil, err
+			}
+			securityGroup := system.SecurityGroup{
+				Spec: system.SecurityGroupSpec{
+					LocalName: item.SecurityGroupName,
+					RegionId:  c.region(region),
+					VpcId:     item.VpcId,
+					ID:        item.SecurityGroupId,
+				},
+			}
+			for _, permission := range permissions {
+				switch permission.Direction {
+				case "ingress":
+					securityGroup.Spec.Ingress = append(securityGroup.Spec.Ingress, toRule(permission))
+				case "egress":
+					securityGroup.Spec.Egress = append(securityGroup.Spec.Egress, toRule(permission))
+				}
+			}
+			result = append(result, securityGroup)
+		}
+		if page*pageSize >= response.TotalCount {
+			return result, nil
+		}
+	}
+}
+
+func (c *securityGroups) permissions(region, id string) ([]ecs.Permission, error) {
+	client, err := c.ecs(region)
+	if err != nil {
+		return nil, err
+	}
+	request := ecs.CreateDescribeSecurityGroupAttributeRequest()
+	request.RegionId = c.region(region)
+	request.SecurityGroupId = id
+	request.Direction = "all"
+	response, err := client.DescribeSecurityGroupAttribute(request)
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return response.Permissions.Permission, nil
+}
+
+// Update authorize the rules of the spec missing from the group and revoke the ones no longer in it
+func (c *securityGroups) Update(_ context.Context, securityGroup *system.SecurityGroup) error {
+	region, id := c.region(securityGroup.Spec.RegionId), securityGroup.Spec.ID
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	permissions, err := c.permissions(region, id)
+	if err != nil {
+		return err
+	}
+	current := make([]system.SecurityGroupRole, 0, len(permissions))
+	byKey := make(map[string]ecs.Permission)
+	for _, permission := range permissions {
+		rule := toRule(permission)
+		current = append(current, rule)
+		byKey[rule.Key()] = permission
+	}
+
+	securityGroup.Spec.Normalize()
+	added, removed := system.DiffRules(current, securityGroup.Spec.Rules())
+	for _, rule := range removed {
+		permission := byKey[rule.Key()]
+		switch rule.Direction {
+		case system.Ingress:
+			request := ecs.CreateRevokeSecurityGroupRequest()
+			request.RegionId, request.SecurityGroupId = region, id
+			request.IpProtocol, request.PortRange, request.SourceCidrIp = permission.IpProtocol, permission.PortRange, permission.SourceCidrIp
+			request.SourceGroupId, request.Policy, request.NicType = permission.SourceGroupId, permission.Policy, permission.NicType
+			request.Priority = permission.Priority
+			if _, err := client.RevokeSecurityGroup(request); err != nil {
+				return convertError(err)
+			}
+		case system.Egress:
+			request := ecs.CreateRevokeSecurityGroupEgressRequest()
+			request.RegionId, request.SecurityGroupId = region, id
+			request.IpProtocol, request.PortRange, request.DestCidrIp = permission.IpProtocol, permission.PortRange, permission.DestCidrIp
+			request.DestGroupId, request.Policy, request.NicType = permission.DestGroupId, permission.Policy, permission.NicType
+			request.Priority = permission.Priority
+			if _, err := client.RevokeSecurityGroupEgress(request); err != nil {
+				return convertError(err)
+			}
+		}
+	}
+
+	for _, rule := range added {
+		switch rule.Direction {
+		case system.Ingress:
+			request := ecs.CreateAuthorizeSecurityGroupRequest()
+			request.RegionId, request.SecurityGroupId = region, id
+			request.IpProtocol, request.PortRange, request.SourceCidrIp = toProtocol(rule.IpProtocol), rule.PortRange, rule.SourceCidrIp
+			request.SourceGroupId, request.Policy, request.Priority = rule.PeerGroup, toPolicy(rule.Action), strconv.Itoa(rule.Priority)
+			request.Description = rule.Description
+			if _, err := client.AuthorizeSecurityGroup(request); err != nil {
+				return convertError(err)
+			}
+		case system.Egress:
+			request := ecs.CreateAuthorizeSecurityGroupEgressRequest()
+			request.RegionId, request.SecurityGroupId = region, id
+			request.IpProtocol, request.PortRange, request.DestCidrIp = toProtocol(rule.IpProtocol), rule.PortRange, rule.SourceCidrIp
+			request.DestGroupId, request.Policy, request.Priority = rule.PeerGroup, toPolicy(rule.Action), strconv.Itoa(rule.Priority)
+			request.Description = rule.Description
+			if _, err := client.AuthorizeSecurityGroupEgress(request); err != nil {
+				return convertError(err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *securityGroups) Delete(_ context.Context, region, id string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateDeleteSecurityGroupRequest()
+	request.RegionId = c.region(region)
+	request.SecurityGroupId = id
+	_, err = client.DeleteSecurityGroup(request)
+	return convertError(err)
+}
+
+// toProtocol aliyun take the protocols of system.SecurityGroupRole in lower case, "all" included
+func toProtocol(protocol system.IpProtocolType) string {
+	return strings.ToLower(string(protocol))
+}
+
+// toPolicy aliyun accept or drop the traffic matching a rule
+func toPolicy(action system.RuleAction) string {
+	if action == system.Deny {
+		return "drop"
+	}
+	return "accept"
+}
+
+func toRule(permission ecs.Permission) system.SecurityGroupRole {
+	rule := system.SecurityGroupRole{
+		Direction:    system.RuleDirection(permission.Direction),
+		Action:       system.Allow,
+		Priority:     system.MinRulePriority,
+		PortRange:    permission.PortRange,
+		IpProtocol:   system.IpProtocolType(strings.ToUpper(permission.IpProtocol)),
+		SourceCidrIp: permission.SourceCidrIp,
+		PeerGroup:    permission.SourceGroupId,
+		Description:  permission.Description,
+	}
+	if rule.Direction == system.Egress {
+		rule.SourceCidrIp, rule.PeerGroup = permission.DestCidrIp, permission.DestGroupId
+	}
+	if strings.EqualFold(permission.Policy, "drop") {
+		rule.Action = system.Deny
+	}
+	if priority, err := strconv.Atoi(permission.Priority); err == nil {
+		rule.Priority = priority
+	}
+	return rule
+}
+
+type networkInterfaces struct{ *Cloud }
+
+func (c *networkInterfaces) Create(_ context.Context, networkInterface *networking.NetworkInterface) error {
+	client, err := c.ecs(networkInterface.Spec.Region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateCreateNetworkInterfaceRequest()
+	request.RegionId = c.region(networkInterface.Spec.Region)
+	request.VSwitchId = networkInterface.Spec.SubnetId
+	request.NetworkInterfaceName = networkInterface.Spec.LocalName
+	request.Description = networkInterface.Spec.Description
+	request.PrimaryIpAddress = networkInterface.Spec.PrivateIpAddress
+	if len(networkInterface.Spec.SecurityGroupIds) > 0 {
+		request.SecurityGroupIds = &networkInterface.Spec.SecurityGroupIds
+	}
+	response, err := client.CreateNetworkInterface(request)
+	if err != nil {
+		return convertError(err)
+	}
+	networkInterface.Spec.ID = response.NetworkInterfaceId
+	networkInterface.Spec.MacAddress = response.MacAddress
+	networkInterface.Spec.PrivateIpAddress = response.PrivateIpAddress
+	networkInterface.Spec.VPCId = response.VpcId
+	networkInterface.Spec.Zone = response.ZoneId
+	networkInterface.Spec.State = response.Status
+	return nil
+}
+
+func (c *networkInterfaces) Get(_ context.Context, region, id string) (*networking.NetworkInterface, error) {
+	result, err := c.describe(region, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &result[0], nil
+}
+
+func (c *networkInterfaces) List(_ context.Context, region string) ([]networking.NetworkInterface, error) {
+	return c.describe(region, nil)
+}
+
+func (c *networkInterfaces) describe(region string, ids []string) ([]networking.NetworkInterface, error) {
+	client, err := c.ecs(region)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]networking.NetworkInterface, 0)
+	for page := 1; ; page++ {
+		request := ecs.CreateDescribeNetworkInterfacesRequest()
+		request.RegionId = c.region(region)
+		if len(ids) > 0 {
+			request.NetworkInterfaceId = &ids
+		}
+		request.PageNumber = requests.NewInteger(page)
+		request.PageSize = requests.NewInteger(pageSize)
+		response, err := client.DescribeNetworkInterfaces(request)
+		if err != nil {
+			return nil, convertError(err)
+		}
+		for _, item := range response.NetworkInterfaceSets.NetworkInterfaceSet {
+			result = append(result, toNetworkInterface(c.region(region), item))
+		}
+		if page*pageSize >= response.TotalCount {
+			return result, nil
+		}
+	}
+}
+
+func (c *networkInterfaces) Delete(_ context.Context, region, id string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateDeleteNetworkInterfaceRequest()
+	request.RegionId = c.region(region)
+	request.NetworkInterfaceId = id
+	_, err = client.DeleteNetworkInterface(request)
+	return convertError(err)
+}
+
+func (c *networkInterfaces) Attach(_ context.Context, region, id, instanceId string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateAttachNetworkInterfaceRequest()
+	request.RegionId = c.region(region)
+	request.NetworkInterfaceId = id
+	request.InstanceId = instanceId
+	_, err = client.AttachNetworkInterface(request)
+	return convertError(err)
+}
+
+func (c *networkInterfaces) Detach(_ context.Context, region, id, instanceId string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateDetachNetworkInterfaceRequest()
+	request.RegionId = c.region(region)
+	request.NetworkInterfaceId = id
+	request.InstanceId = instanceId
+	_, err = client.DetachNetworkInterface(request)
+	return convertError(err)
+}
+
+func toNetworkInterface(region string, item ecs.NetworkInterfaceSet) networking.NetworkInterface {
+	networkInterface := networking.NetworkInterface{
+		Spec: networking.NetworkInterfaceSpec{
+			Attachment: networking.ENIAttachment{
+				InstanceId: item.InstanceId,
+			},
+			Description:      item.Description,
+			MacAddress:       item.MacAddress,
+			PrivateIpAddress: item.PrivateIpAddress,
+			PublicIpAddress:  item.AssociatedPublicIp.PublicIpAddress,
+			SecurityGroupIds: item.SecurityGroupIds.SecurityGroupId,
+			SubnetId:         item.VSwitchId,
+			VPCId:            item.VpcId,
+			Type:             item.Type,
+			ID:               item.NetworkInterfaceId,
+			LocalName:        item.NetworkInterfaceName,
+			Region:           region,
+			Zone:             item.ZoneId,
+			State:            item.Status,
+		},
+	}
+	if item.InstanceId != "" {
+		networkInterface.Spec.Attachment.Status = "attached"
+	}
+	for _, set := range item.PrivateIpSets.PrivateIpSet {
+		networkInterface.Spec.PrivateIpSets = append(networkInterface.Spec.PrivateIpSets, networking.ENIPrivateIpSet{
+			Primary:          set.Primary,
+			PrivateIpAddress: set.PrivateIpAddress,
+			PublicIpAddress:  set.AssociatedPublicIp.PublicIpAddress,
+		})
+	}
+	for _, set := range item.Ipv6Sets.Ipv6Set {
+		networkInterface.Spec.Ipv6 = append(networkInterface.Spec.Ipv6, set.Ipv6Address)
+	}
+	return networkInterface
+}
@@ -0,0 +1,76 @@
+package aliyun
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+)
+
+type snapshots struct{ *Cloud }
+
+func (c *snapshots) Create(_ context.Context, region, diskId, name string) (string, error) {
+	client, err := c.ecs(region)
+	if err != nil {
+		return "", err
+	}
+	request := ecs.CreateCreateSnapshotRequest()
+	request.RegionId = c.region(region)
+	request.DiskId = diskId
+	request.SnapshotName = name
+	response, err := client.CreateSnapshot(request)
+	if err != nil {
+		return "", convertError(err)
+	}
+	return response.SnapshotId, nil
+}
+
+func (c *snapshots) Ready(_ context.Context, region, id string) (bool, error) {
+	client, err := c.ecs(region)
+	if err != nil {
+		return false, err
+	}
+	request := ecs.CreateDescribeSnapshotsRequest()
+	request.RegionId = c.region(region)
+	request.SnapshotIds = jsonIds(id)
+	response, err := client.DescribeSnapshots(request)
+	if err != nil {
+		return false, convertError(err)
+	}
+	if len(response.Snapshots.Snapshot) == 0 {
+		return false, cloudprovider.NotFound
+	}
+	switch response.Snapshots.Snapshot[0].Status {
+	case "accomplished":
+		return true, nil
+	case "failed":
+		return false, fmt.Errorf("snapshot %s failed", id)
+	}
+	return false, nil
+}
+
+func (c *snapshots) Delete(_ context.Context, region, id string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateDeleteSnapshotRequest()
+	request.RegionId = c.region(region)
+	request.SnapshotId = id
+	_, err = client.DeleteSnapshot(request)
+	return convertError(err)
+}
+
+func (c *snapshots) Restore(_ context.Context, region, diskId, snapshotId string) error {
+	client, err := c.ecs(region)
+	if err != nil {
+		return err
+	}
+	request := ecs.CreateResetDiskRequest()
+	request.RegionId = c.region(region)
+	request.DiskId = diskId
+	request.SnapshotId = snapshotId
+	_, err = client.ResetDisk(request)
+	return convertError(err)
+}
@@ -0,0 +1,108 @@
+// Package aws drive ec2 and elbv2 for the "aws" provider
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/common"
+)
+
+var _ cloudprovider.Interface = &Cloud{}
+
+type Cloud struct {
+	session *session.Session
+	region  string
+}
+
+func New(c cloudprovider.Credentials) (cloudprovider.Interface, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(c.Region),
+		Credentials: credentials.NewStaticCredentials(c.AccessKey, c.AccessSecret, ""),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Cloud{session: sess, region: c.Region}, nil
+}
+
+func (c *Cloud) Instances() cloudprovider.Instances                 { return &instances{c} }
+func (c *Cloud) Disks() cloudprovider.Disks                         { return &disks{c} }
+func (c *Cloud) VPCs() cloudprovider.VPCs                           { return &vpcs{c} }
+func (c *Cloud) Subnets() cloudprovider.Subnets                     { return &subnets{c} }
+func (c *Cloud) SecurityGroups() cloudprovider.SecurityGroups       { return &securityGroups{c} }
+func (c *Cloud) NetworkInterfaces() cloudprovider.NetworkInterfaces { return &networkInterfaces{c} }
+func (c *Cloud) Images() cloudprovider.Images                       { return &images{c} }
+func (c *Cloud) InstanceTypes() cloudprovider.InstanceTypes         { return &instanceTypes{c} }
+func (c *Cloud) Snapshots() cloudprovider.Snapshots                 { return &snapshots{c} }
+func (c *Cloud) ElasticIPs() cloudprovider.ElasticIPs               { return &elasticIPs{c} }
+func (c *Cloud) LoadBalancers() cloudprovider.LoadBalancers         { return &loadBalancers{c} }
+
+// ec2 client of the region, fallback to the region of the credentials
+func (c *Cloud) ec2(region string) *ec2.EC2 {
+	if region == "" {
+		region = c.region
+	}
+	return ec2.New(c.session, aws.NewConfig().WithRegion(region))
+}
+
+func (c *Cloud) elbv2(region string) *elbv2.ELBV2 {
+	if region == "" {
+		region = c.region
+	}
+	return elbv2.New(c.session, aws.NewConfig().WithRegion(region))
+}
+
+// convertError map the "*.NotFound" error codes of ec2 to cloudprovider.NotFound
+func convertError(err error) error {
+	if awsErr, ok := err.(awserr.Error); ok && strings.HasSuffix(awsErr.Code(), ".NotFound") {
+		return cloudprovider.NotFound
+	}
+	return err
+}
+
+func nameTag(resourceType, name string) []*ec2.TagSpecification {
+	if name == "" {
+		return nil
+	}
+	return []*ec2.TagSpecification{{
+		ResourceType: aws.String(resourceType),
+		Tags:         []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(name)}},
+	}}
+}
+
+func tagValue(tags []*ec2.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == key {
+			return aws.StringValue(tag.Value)
+		}
+	}
+	return ""
+}
+
+// splitCidr split 10.0.0.0/16 into the ip and mask fields of the resources
+func splitCidr(cidr string) (string, string) {
+	parts := strings.SplitN(cidr, "/", 2)
+	if len(parts) != 2 {
+		return cidr, ""
+	}
+	return parts[0], parts[1]
+}
+
+func joinCidr(ip, mask string) string {
+	if mask == "" {
+		return ip
+	}
+	return fmt.Sprintf("%s/%s", ip, mask)
+}
+
+func init() {
+	cloudprovider.Register(common.AWS, New)
+}
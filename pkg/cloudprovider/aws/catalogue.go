@@ -0,0 +1,60 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+type images struct{ *Cloud }
+
+// List the images owned by the account, the public amazon catalogue is too large to mirror
+func (c *images) List(ctx context.Context, region string) ([]compute.Image, error) {
+	output, err := c.ec2(region).DescribeImagesWithContext(ctx, &ec2.DescribeImagesInput{Owners: aws.StringSlice([]string{"self"})})
+	if err != nil {
+		return nil, convertError(err)
+	}
+	result := make([]compute.Image, 0, len(output.Images))
+	for _, image := range output.Images {
+		result = append(result, compute.Image{
+			Spec: compute.ImageSpec{
+				Version: aws.StringValue(image.CreationDate),
+				Os:      aws.StringValue(image.Name),
+				Region:  region,
+				ID:      aws.StringValue(image.ImageId),
+			},
+		})
+	}
+	return result, nil
+}
+
+type instanceTypes struct{ *Cloud }
+
+func (c *instanceTypes) List(ctx context.Context, region string) ([]system.InstanceType, error) {
+	result := make([]system.InstanceType, 0)
+	err := c.ec2(region).DescribeInstanceTypesPagesWithContext(ctx, &ec2.DescribeInstanceTypesInput{},
+		func(output *ec2.DescribeInstanceTypesOutput, _ bool) bool {
+			for _, instanceType := range output.InstanceTypes {
+				spec := system.InstanceTypeSpec{
+					Region: region,
+					ID:     aws.StringValue(instanceType.InstanceType),
+				}
+				if instanceType.VCpuInfo != nil {
+					spec.Cores = aws.Int64Value(instanceType.VCpuInfo.DefaultVCpus)
+				}
+				if instanceType.MemoryInfo != nil {
+					spec.Memory = fmt.Sprintf("%dMi", aws.Int64Value(instanceType.MemoryInfo.SizeInMiB))
+				}
+				result = append(result, system.InstanceType{Spec: spec})
+			}
+			return true
+		})
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return result, nil
+}
@@ -0,0 +1,150 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+type disks struct{ *Cloud }
+
+func (c *disks) Create(ctx context.Context, disk *compute.Storage) error {
+	input := &ec2.CreateVolumeInput{
+		AvailabilityZone:  aws.String(disk.Spec.Zone),
+		Size:              aws.Int64(int64(disk.Spec.Size)),
+		TagSpecifications: nameTag(ec2.ResourceTypeVolume, disk.Spec.LocalName),
+	}
+	if disk.Spec.CategoryType != "" {
+		input.VolumeType = aws.String(disk.Spec.CategoryType)
+	}
+	if disk.Spec.IOPS > 0 {
+		input.Iops = aws.Int64(int64(disk.Spec.IOPS))
+	}
+	if disk.Spec.Throughput > 0 {
+		input.Throughput = aws.Int64(int64(disk.Spec.Throughput))
+	}
+	volume, err := c.ec2(disk.Spec.Region).CreateVolumeWithContext(ctx, input)
+	if err != nil {
+		return convertError(err)
+	}
+	disk.Spec.StorageId = aws.StringValue(volume.VolumeId)
+	disk.Spec.State = aws.StringValue(volume.State)
+	return nil
+}
+
+func (c *disks) Get(ctx context.Context, region, id string) (*compute.Storage, error) {
+	storages, err := c.describe(ctx, region, &ec2.DescribeVolumesInput{VolumeIds: aws.StringSlice([]string{id})})
+	if err != nil {
+		return nil, err
+	}
+	if len(storages) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &storages[0], nil
+}
+
+func (c *disks) List(ctx context.Context, region string) ([]compute.Storage, error) {
+	return c.describe(ctx, region, &ec2.DescribeVolumesInput{})
+}
+
+func (c *disks) describe(ctx context.Context, region string, input *ec2.DescribeVolumesInput) ([]compute.Storage, error) {
+	result := make([]compute.Storage, 0)
+	err := c.ec2(region).DescribeVolumesPagesWithContext(ctx, input,
+		func(output *ec2.DescribeVolumesOutput, _ bool) bool {
+			for _, volume := range output.Volumes {
+				result = append(result, toStorage(region, volume))
+			}
+			return true
+		})
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return result, nil
+}
+
+func (c *disks) Delete(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).DeleteVolumeWithContext(ctx, &ec2.DeleteVolumeInput{VolumeId: aws.String(id)})
+	return convertError(err)
+}
+
+func (c *disks) Attach(ctx context.Context, region, id, instanceId string) error {
+	device, err := c.freeDevice(ctx, region, instanceId)
+	if err != nil {
+		return err
+	}
+	_, err = c.ec2(region).AttachVolumeWithContext(ctx, &ec2.AttachVolumeInput{
+		VolumeId:   aws.String(id),
+		InstanceId: aws.String(instanceId),
+		Device:     aws.String(device),
+	})
+	return convertError(err)
+}
+
+// freeDevice first of /dev/sdf-/dev/sdp not mapped on the instance, the range aws recommend for ebs volumes
+func (c *disks) freeDevice(ctx context.Context, region, instanceId string) (string, error) {
+	output, err := c.ec2(region).DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice([]string{instanceId})})
+	if err != nil {
+		return "", convertError(err)
+	}
+	used := make(map[string]bool)
+	for _, reservation := range output.Reservations {
+		for _, instance := range reservation.Instances {
+			for _, mapping := range instance.BlockDeviceMappings {
+				used[aws.StringValue(mapping.DeviceName)] = true
+			}
+		}
+	}
+	for letter := 'f'; letter <= 'p'; letter++ {
+		if device := fmt.Sprintf("/dev/sd%c", letter); !used[device] {
+			return device, nil
+		}
+	}
+	return "", fmt.Errorf("instance %s has no free device", instanceId)
+}
+
+func (c *disks) Detach(ctx context.Context, region, id, instanceId string) error {
+	_, err := c.ec2(region).DetachVolumeWithContext(ctx, &ec2.DetachVolumeInput{
+		VolumeId:   aws.String(id),
+		InstanceId: aws.String(instanceId),
+	})
+	return convertError(err)
+}
+
+func (c *disks) Resize(ctx context.Context, region, id string, size int) error {
+	_, err := c.ec2(region).ModifyVolumeWithContext(ctx, &ec2.ModifyVolumeInput{
+		VolumeId: aws.String(id),
+		Size:     aws.Int64(int64(size)),
+	})
+	return convertError(err)
+}
+
+func toStorage(region string, volume *ec2.Volume) compute.Storage {
+	storage := compute.Storage{
+		Spec: compute.StorageSpec{
+			LocalName:    tagValue(volume.Tags, "Name"),
+			CategoryType: aws.StringValue(volume.VolumeType),
+			IOPS:         int(aws.Int64Value(volume.Iops)),
+			Throughput:   int(aws.Int64Value(volume.Throughput)),
+			Size:         int(aws.Int64Value(volume.Size)),
+			Region:       region,
+			Zone:         aws.StringValue(volume.AvailabilityZone),
+			StorageId:    aws.StringValue(volume.VolumeId),
+			State:        aws.StringValue(volume.State),
+		},
+	}
+	for _, attachment := range volume.Attachments {
+		storage.Spec.DeleteWithInstance = aws.BoolValue(attachment.DeleteOnTermination)
+		storage.Spec.Attachments = append(storage.Spec.Attachments, compute.Attachment{
+			AttachedTime: aws.TimeValue(attachment.AttachTime).UTC().Format(time.RFC3339),
+			Device:       aws.StringValue(attachment.Device),
+			InstanceId:   aws.StringValue(attachment.InstanceId),
+			State:        aws.StringValue(attachment.State),
+		})
+	}
+	return storage
+}
@@ -0,0 +1,164 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+type instances struct{ *Cloud }
+
+// Create ec2 has no password injection, the password reaches the guest through the cloud-config user data
+func (c *instances) Create(ctx context.Context, vm *compute.VirtualMachine, bootstrap *cloudprovider.Bootstrap) error {
+	input := &ec2.RunInstancesInput{
+		ImageId:           aws.String(vm.Spec.ImageId),
+		InstanceType:      aws.String(vm.Spec.InstanceType),
+		MinCount:          aws.Int64(1),
+		MaxCount:          aws.Int64(1),
+		TagSpecifications: nameTag(ec2.ResourceTypeInstance, vm.Spec.LocalName),
+	}
+	if vm.Spec.VSwitchId != "" {
+		input.SubnetId = aws.String(vm.Spec.VSwitchId)
+	}
+	if len(vm.Spec.PrivateIpAddress) > 0 {
+		input.PrivateIpAddress = aws.String(vm.Spec.PrivateIpAddress[0])
+	}
+	if len(vm.Spec.SecurityGroup) > 0 {
+		input.SecurityGroupIds = aws.StringSlice(vm.Spec.SecurityGroup)
+	}
+	if vm.Spec.Az != "" {
+		input.Placement = &ec2.Placement{AvailabilityZone: aws.String(vm.Spec.Az)}
+	}
+	if bootstrap != nil && bootstrap.UserData != "" {
+		input.UserData = aws.String(base64.StdEncoding.EncodeToString([]byte(bootstrap.UserData)))
+	}
+
+	reservation, err := c.ec2(vm.Spec.RegionId).RunInstancesWithContext(ctx, input)
+	if err != nil {
+		return convertError(err)
+	}
+	if len(reservation.Instances) == 0 {
+		return fmt.Errorf("run instances return no instance")
+	}
+	instance := reservation.Instances[0]
+	vm.Spec.InstanceId = aws.StringValue(instance.InstanceId)
+	vm.Spec.State = instanceState(instance.State)
+	vm.Spec.CreateTime = aws.TimeValue(instance.LaunchTime).UTC().Format(time.RFC3339)
+	return nil
+}
+
+func (c *instances) Get(ctx context.Context, region, id string) (*compute.VirtualMachine, error) {
+	vms, err := c.describe(ctx, region, &ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice([]string{id})})
+	if err != nil {
+		return nil, err
+	}
+	if len(vms) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &vms[0], nil
+}
+
+func (c *instances) List(ctx context.Context, region string) ([]compute.VirtualMachine, error) {
+	return c.describe(ctx, region, &ec2.DescribeInstancesInput{})
+}
+
+func (c *instances) describe(ctx context.Context, region string, input *ec2.DescribeInstancesInput) ([]compute.VirtualMachine, error) {
+	result := make([]compute.VirtualMachine, 0)
+	err := c.ec2(region).DescribeInstancesPagesWithContext(ctx, input,
+		func(output *ec2.DescribeInstancesOutput, _ bool) bool {
+			for _, reservation := range output.Reservations {
+				for _, instance := range reservation.Instances {
+					if instanceState(instance.State) == "terminated" {
+						continue
+					}
+					result = append(result, toVirtualMachine(region, instance))
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return result, nil
+}
+
+func (c *instances) Delete(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{InstanceIds: aws.StringSlice([]string{id})})
+	return convertError(err)
+}
+
+func (c *instances) Start(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).StartInstancesWithContext(ctx, &ec2.StartInstancesInput{InstanceIds: aws.StringSlice([]string{id})})
+	return convertError(err)
+}
+
+func (c *instances) Stop(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).StopInstancesWithContext(ctx, &ec2.StopInstancesInput{InstanceIds: aws.StringSlice([]string{id})})
+	return convertError(err)
+}
+
+func (c *instances) Reboot(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).RebootInstancesWithContext(ctx, &ec2.RebootInstancesInput{InstanceIds: aws.StringSlice([]string{id})})
+	return convertError(err)
+}
+
+func instanceState(state *ec2.InstanceState) compute.VirtualMachineStateType {
+	if state == nil {
+		return compute.Unknown
+	}
+	switch aws.StringValue(state.Name) {
+	case ec2.InstanceStateNamePending:
+		return compute.Starting
+	case ec2.InstanceStateNameRunning:
+		return compute.Running
+	case ec2.InstanceStateNameStopping:
+		return compute.Stopping
+	case ec2.InstanceStateNameStopped:
+		return compute.Stopped
+	case ec2.InstanceStateNameShuttingDown:
+		return compute.Deleting
+	}
+	return compute.VirtualMachineStateType(aws.StringValue(state.Name))
+}
+
+func toVirtualMachine(region string, instance *ec2.Instance) compute.VirtualMachine {
+	vm := compute.VirtualMachine{
+		Spec: compute.VirtualMachineSpec{
+			LocalName:    tagValue(instance.Tags, "Name"),
+			RegionId:     region,
+			InstanceId:   aws.StringValue(instance.InstanceId),
+			InstanceType: aws.StringValue(instance.InstanceType),
+			VPCId:        aws.StringValue(instance.VpcId),
+			VSwitchId:    aws.StringValue(instance.SubnetId),
+			ImageId:      aws.StringValue(instance.ImageId),
+			Vendor:       common.AWS,
+			RootDevice:   aws.StringValue(instance.RootDeviceName),
+			State:        instanceState(instance.State),
+			CreateTime:   aws.TimeValue(instance.LaunchTime).UTC().Format(time.RFC3339),
+			Os:           aws.StringValue(instance.Platform),
+		},
+	}
+	if instance.Placement != nil {
+		vm.Spec.Az = aws.StringValue(instance.Placement.AvailabilityZone)
+	}
+	if instance.CpuOptions != nil {
+		vm.Spec.CPU = fmt.Sprintf("%d", aws.Int64Value(instance.CpuOptions.CoreCount)*aws.Int64Value(instance.CpuOptions.ThreadsPerCore))
+	}
+	for _, group := range instance.SecurityGroups {
+		vm.Spec.SecurityGroup = append(vm.Spec.SecurityGroup, aws.StringValue(group.GroupId))
+	}
+	if ip := aws.StringValue(instance.PrivateIpAddress); ip != "" {
+		vm.Spec.PrivateIpAddress = append(vm.Spec.PrivateIpAddress, ip)
+	}
+	if ip := aws.StringValue(instance.PublicIpAddress); ip != "" {
+		vm.Spec.PublicIpAddress = append(vm.Spec.PublicIpAddress, ip)
+	}
+	return vm
+}
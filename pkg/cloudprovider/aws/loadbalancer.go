@@ -0,0 +1,411 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+type elasticIPs struct{ *Cloud }
+
+// Allocate a vpc address, Address recovers a released address when set. Bandwidth does not apply to aws
+func (c *elasticIPs) Allocate(ctx context.Context, elasticIP *networking.ElasticIP) error {
+	input := &ec2.AllocateAddressInput{
+		Domain:            aws.String(ec2.DomainTypeVpc),
+		TagSpecifications: nameTag(ec2.ResourceTypeElasticIp, elasticIP.GetName()),
+	}
+	if elasticIP.Spec.Address != "" {
+		input.Address = aws.String(elasticIP.Spec.Address)
+	}
+	output, err := c.ec2(elasticIP.Spec.Region).AllocateAddressWithContext(ctx, input)
+	if err != nil {
+		return convertError(err)
+	}
+	elasticIP.Spec.AllocationId = aws.StringValue(output.AllocationId)
+	elasticIP.Spec.Address = aws.StringValue(output.PublicIp)
+	elasticIP.Spec.State = networking.ElasticIPAvailable
+	return nil
+}
+
+func (c *elasticIPs) Get(ctx context.Context, region, id string) (*networking.ElasticIP, error) {
+	address, err := c.describe(ctx, region, id)
+	if err != nil {
+		return nil, err
+	}
+	elasticIP := &networking.ElasticIP{
+		Spec: networking.ElasticIPSpec{
+			Region:       region,
+			Address:      aws.StringValue(address.PublicIp),
+			AllocationId: aws.StringValue(address.AllocationId),
+			InstanceId:   aws.StringValue(address.InstanceId),
+			State:        networking.ElasticIPAvailable,
+		},
+	}
+	if address.AssociationId != nil {
+		elasticIP.Spec.State = networking.ElasticIPAssociated
+	}
+	return elasticIP, nil
+}
+
+func (c *elasticIPs) describe(ctx context.Context, region, id string) (*ec2.Address, error) {
+	output, err := c.ec2(region).DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{AllocationIds: aws.StringSlice([]string{id})})
+	if err != nil {
+		return nil, convertError(err)
+	}
+	if len(output.Addresses) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return output.Addresses[0], nil
+}
+
+func (c *elasticIPs) Release(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).ReleaseAddressWithContext(ctx, &ec2.ReleaseAddressInput{AllocationId: aws.String(id)})
+	return convertError(err)
+}
+
+func (c *elasticIPs) Associate(ctx context.Context, region, id, instanceId string) error {
+	_, err := c.ec2(region).AssociateAddressWithContext(ctx, &ec2.AssociateAddressInput{
+		AllocationId: aws.String(id),
+		InstanceId:   aws.String(instanceId),
+	})
+	return convertError(err)
+}
+
+func (c *elasticIPs) Disassociate(ctx context.Context, region, id, instanceId string) error {
+	address, err := c.describe(ctx, region, id)
+	if err != nil {
+		return err
+	}
+	if aws.StringValue(address.InstanceId) != instanceId {
+		return fmt.Errorf("elastic ip %s is not associated with %s", id, instanceId)
+	}
+	_, err = c.ec2(region).DisassociateAddressWithContext(ctx, &ec2.DisassociateAddressInput{AssociationId: address.AssociationId})
+	return convertError(err)
+}
+
+// loadBalancers network load balancers, every listener forwards to a target group of the backend instances.
+// http listeners are forwarded as tcp, the health check still probes http
+type loadBalancers struct{ *Cloud }
+
+func (c *loadBalancers) Create(ctx context.Context, loadBalancer *networking.LoadBalancer, instanceIds []string, allocationId string) error {
+	spec := &loadBalancer.Spec
+	input := &elbv2.CreateLoadBalancerInput{
+		Name:   aws.String(elbName(loadBalancer.GetName())),
+		Type:   aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+		Scheme: aws.String(elbv2.LoadBalancerSchemeEnumInternetFacing),
+	}
+	if spec.Internal {
+		input.Scheme = aws.String(elbv2.LoadBalancerSchemeEnumInternal)
+	}
+	for index, subnet := range spec.Subnets {
+		mapping := &elbv2.SubnetMapping{SubnetId: aws.String(subnet)}
+		// the elastic ip fronts the load balancer in its first subnet
+		if index == 0 && allocationId != "" {
+			mapping.AllocationId = aws.String(allocationId)
+		}
+		input.SubnetMappings = append(input.SubnetMappings, mapping)
+	}
+	output, err := c.elbv2(spec.Region).CreateLoadBalancerWithContext(ctx, input)
+	if err != nil {
+		return convertELBError(err)
+	}
+	if len(output.LoadBalancers) == 0 {
+		return fmt.Errorf("load balancer %s not created", loadBalancer.GetName())
+	}
+	created := toLoadBalancer(spec.Region, output.LoadBalancers[0])
+	spec.LoadBalancerId = created.Spec.LoadBalancerId
+	spec.Address = created.Spec.Address
+	spec.State = created.Spec.State
+	if spec.VPCId == "" {
+		spec.VPCId = created.Spec.VPCId
+	}
+
+	return c.Update(ctx, loadBalancer, instanceIds)
+}
+
+func (c *loadBalancers) Get(ctx context.Context, region, id string) (*networking.LoadBalancer, error) {
+	output, err := c.elbv2(region).DescribeLoadBalancersWithContext(ctx, &elbv2.DescribeLoadBalancersInput{LoadBalancerArns: aws.StringSlice([]string{id})})
+	if err != nil {
+		return nil, convertELBError(err)
+	}
+	if len(output.LoadBalancers) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	loadBalancer := toLoadBalancer(region, output.LoadBalancers[0])
+	return &loadBalancer, nil
+}
+
+// Update keep the listener of every listened port, its target group is replaced when the protocol or the
+// backend port changed, otherwise its health check is modified and its targets synced
+func (c *loadBalancers) Update(ctx context.Context, loadBalancer *networking.LoadBalancer, instanceIds []string) error {
+	spec := &loadBalancer.Spec
+	client := c.elbv2(spec.Region)
+
+	current := make(map[int64]*elbv2.Listener)
+	err := client.DescribeListenersPagesWithContext(ctx, &elbv2.DescribeListenersInput{LoadBalancerArn: aws.String(spec.LoadBalancerId)},
+		func(output *elbv2.DescribeListenersOutput, _ bool) bool {
+			for _, listener := range output.Listeners {
+				current[aws.Int64Value(listener.Port)] = listener
+			}
+			return true
+		})
+	if err != nil {
+		return convertELBError(err)
+	}
+
+	for _, listener := range spec.Listeners {
+		existing, exist := current[int64(listener.Port)]
+		delete(current, int64(listener.Port))
+
+		var targetGroup *elbv2.TargetGroup
+		if exist {
+			if targetGroup, err = c.targetGroupOf(ctx, spec.Region, existing); err != nil {
+				return err
+			}
+		}
+		if targetGroup != nil && aws.StringValue(targetGroup.Protocol) == elbProtocol(listener.Protocol) &&
+			aws.Int64Value(targetGroup.Port) == int64(listener.BackendPort) {
+			if _, err := client.ModifyTargetGroupWithContext(ctx, healthCheckOf(targetGroup.TargetGroupArn, spec.HealthCheck)); err != nil {
+				return convertELBError(err)
+			}
+			if err := c.syncTargets(ctx, spec.Region, targetGroup.TargetGroupArn, listener.BackendPort, instanceIds); err != nil {
+				return err
+			}
+			continue
+		}
+
+		created, err := c.createTargetGroup(ctx, loadBalancer, listener, instanceIds)
+		if err != nil {
+			return err
+		}
+		actions := []*elbv2.Action{{Type: aws.String(elbv2.ActionTypeEnumForward), TargetGroupArn: created.TargetGroupArn}}
+		if exist {
+			_, err = client.ModifyListenerWithContext(ctx, &elbv2.ModifyListenerInput{
+				ListenerArn:    existing.ListenerArn,
+				Protocol:       aws.String(elbProtocol(listener.Protocol)),
+				DefaultActions: actions,
+			})
+		} else {
+			_, err = client.CreateListenerWithContext(ctx, &elbv2.CreateListenerInput{
+				LoadBalancerArn: aws.String(spec.LoadBalancerId),
+				Protocol:        aws.String(elbProtocol(listener.Protocol)),
+				Port:            aws.Int64(int64(listener.Port)),
+				DefaultActions:  actions,
+			})
+		}
+		if err != nil {
+			return convertELBError(err)
+		}
+		if targetGroup != nil {
+			if _, err := client.DeleteTargetGroupWithContext(ctx, &elbv2.DeleteTargetGroupInput{TargetGroupArn: targetGroup.TargetGroupArn}); err != nil {
+				return convertELBError(err)
+			}
+		}
+	}
+
+	for _, listener := range current {
+		targetGroup, err := c.targetGroupOf(ctx, spec.Region, listener)
+		if err != nil {
+			return err
+		}
+		if _, err := client.DeleteListenerWithContext(ctx, &elbv2.DeleteListenerInput{ListenerArn: listener.ListenerArn}); err != nil {
+			return convertELBError(err)
+		}
+		if targetGroup != nil {
+			if _, err := client.DeleteTargetGroupWithContext(ctx, &elbv2.DeleteTargetGroupInput{TargetGroupArn: targetGroup.TargetGroupArn}); err != nil {
+				return convertELBError(err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *loadBalancers) createTargetGroup(ctx context.Context, loadBalancer *networking.LoadBalancer, listener networking.Listener, instanceIds []string) (*elbv2.TargetGroup, error) {
+	spec := &loadBalancer.Spec
+	check := healthCheckOf(nil, spec.HealthCheck)
+	output, err := c.elbv2(spec.Region).CreateTargetGroupWithContext(ctx, &elbv2.CreateTargetGroupInput{
+		Name:                       aws.String(elbName(loadBalancer.GetName(), strconv.Itoa(listener.Port), strconv.Itoa(listener.BackendPort))),
+		Protocol:                   aws.String(elbProtocol(listener.Protocol)),
+		Port:                       aws.Int64(int64(listener.BackendPort)),
+		VpcId:                      aws.String(spec.VPCId),
+		TargetType:                 aws.String(elbv2.TargetTypeEnumInstance),
+		HealthCheckProtocol:        check.HealthCheckProtocol,
+		HealthCheckPort:            check.HealthCheckPort,
+		HealthCheckPath:            check.HealthCheckPath,
+		HealthCheckIntervalSeconds: check.HealthCheckIntervalSeconds,
+		HealthyThresholdCount:      check.HealthyThresholdCount,
+		UnhealthyThresholdCount:    check.UnhealthyThresholdCount,
+	})
+	if err != nil {
+		return nil, convertELBError(err)
+	}
+	if len(output.TargetGroups) == 0 {
+		return nil, fmt.Errorf("target group of listener %d not created", listener.Port)
+	}
+	targetGroup := output.TargetGroups[0]
+	if err := c.syncTargets(ctx, spec.Region, targetGroup.TargetGroupArn, listener.BackendPort, instanceIds); err != nil {
+		return nil, err
+	}
+	return targetGroup, nil
+}
+
+// targetGroupOf the target group the listener forwards to, nil when it forwards elsewhere
+func (c *loadBalancers) targetGroupOf(ctx context.Context, region string, listener *elbv2.Listener) (*elbv2.TargetGroup, error) {
+	for _, action := range listener.DefaultActions {
+		if action.TargetGroupArn == nil {
+			continue
+		}
+		output, err := c.elbv2(region).DescribeTargetGroupsWithContext(ctx, &elbv2.DescribeTargetGroupsInput{
+			TargetGroupArns: []*string{action.TargetGroupArn},
+		})
+		if err != nil {
+			return nil, convertELBError(err)
+		}
+		if len(output.TargetGroups) > 0 {
+			return output.TargetGroups[0], nil
+		}
+	}
+	return nil, nil
+}
+
+// syncTargets register the missing instances in the target group and deregister the others
+func (c *loadBalancers) syncTargets(ctx context.Context, region string, targetGroupArn *string, port int, instanceIds []string) error {
+	client := c.elbv2(region)
+	output, err := client.DescribeTargetHealthWithContext(ctx, &elbv2.DescribeTargetHealthInput{TargetGroupArn: targetGroupArn})
+	if err != nil {
+		return convertELBError(err)
+	}
+	registered := make(map[string]bool)
+	for _, description := range output.TargetHealthDescriptions {
+		registered[aws.StringValue(description.Target.Id)] = true
+	}
+
+	register := make([]*elbv2.TargetDescription, 0)
+	for _, instanceId := range instanceIds {
+		if !registered[instanceId] {
+			register = append(register, &elbv2.TargetDescription{Id: aws.String(instanceId), Port: aws.Int64(int64(port))})
+		}
+		delete(registered, instanceId)
+	}
+	if len(register) > 0 {
+		if _, err := client.RegisterTargetsWithContext(ctx, &elbv2.RegisterTargetsInput{TargetGroupArn: targetGroupArn, Targets: register}); err != nil {
+			return convertELBError(err)
+		}
+	}
+
+	deregister := make([]*elbv2.TargetDescription, 0)
+	for instanceId := range registered {
+		deregister = append(deregister, &elbv2.TargetDescription{Id: aws.String(instanceId)})
+	}
+	if len(deregister) > 0 {
+		if _, err := client.DeregisterTargetsWithContext(ctx, &elbv2.DeregisterTargetsInput{TargetGroupArn: targetGroupArn, Targets: deregister}); err != nil {
+			return convertELBError(err)
+		}
+	}
+	return nil
+}
+
+// Delete the load balancer, then the target groups its listeners forwarded to
+func (c *loadBalancers) Delete(ctx context.Context, region, id string) error {
+	client := c.elbv2(region)
+	targetGroups := make([]*string, 0)
+	err := client.DescribeListenersPagesWithContext(ctx, &elbv2.DescribeListenersInput{LoadBalancerArn: aws.String(id)},
+		func(output *elbv2.DescribeListenersOutput, _ bool) bool {
+			for _, listener := range output.Listeners {
+				for _, action := range listener.DefaultActions {
+					if action.TargetGroupArn != nil {
+						targetGroups = append(targetGroups, action.TargetGroupArn)
+					}
+				}
+			}
+			return true
+		})
+	if err != nil {
+		return convertELBError(err)
+	}
+	if _, err := client.DeleteLoadBalancerWithContext(ctx, &elbv2.DeleteLoadBalancerInput{LoadBalancerArn: aws.String(id)}); err != nil {
+		return convertELBError(err)
+	}
+	for _, targetGroup := range targetGroups {
+		if _, err := client.DeleteTargetGroupWithContext(ctx, &elbv2.DeleteTargetGroupInput{TargetGroupArn: targetGroup}); err != nil {
+			return convertELBError(err)
+		}
+	}
+	return nil
+}
+
+func healthCheckOf(targetGroupArn *string, check networking.HealthCheck) *elbv2.ModifyTargetGroupInput {
+	input := &elbv2.ModifyTargetGroupInput{
+		TargetGroupArn:             targetGroupArn,
+		HealthCheckProtocol:        aws.String(check.Protocol),
+		HealthCheckPort:            aws.String(strconv.Itoa(check.Port)),
+		HealthCheckIntervalSeconds: aws.Int64(int64(check.Interval)),
+		HealthyThresholdCount:      aws.Int64(int64(check.HealthyThreshold)),
+		UnhealthyThresholdCount:    aws.Int64(int64(check.UnhealthyThreshold)),
+	}
+	if check.Protocol == networking.ListenerHTTP {
+		input.HealthCheckPath = aws.String(check.Path)
+	}
+	return input
+}
+
+// elbProtocol the protocol of a network load balancer listener
+func elbProtocol(protocol networking.ListenerProtocol) string {
+	if protocol == networking.ListenerUDP {
+		return elbv2.ProtocolEnumUdp
+	}
+	return elbv2.ProtocolEnumTcp
+}
+
+var elbNameInvalid = regexp.MustCompile("[^a-zA-Z0-9-]+")
+
+// elbName the names of load balancers and target groups are limited to 32 alphanumerics and hyphens
+func elbName(parts ...string) string {
+	name := strings.Trim(elbNameInvalid.ReplaceAllString(strings.Join(parts, "-"), "-"), "-")
+	if len(name) > 32 {
+		name = strings.TrimRight(name[:32], "-")
+	}
+	return name
+}
+
+func toLoadBalancer(region string, lb *elbv2.LoadBalancer) networking.LoadBalancer {
+	loadBalancer := networking.LoadBalancer{
+		Spec: networking.LoadBalancerSpec{
+			Region:         region,
+			VPCId:          aws.StringValue(lb.VpcId),
+			Internal:       aws.StringValue(lb.Scheme) == elbv2.LoadBalancerSchemeEnumInternal,
+			LoadBalancerId: aws.StringValue(lb.LoadBalancerArn),
+			Address:        aws.StringValue(lb.DNSName),
+			State:          networking.LoadBalancerPending,
+		},
+	}
+	for _, zone := range lb.AvailabilityZones {
+		loadBalancer.Spec.Subnets = append(loadBalancer.Spec.Subnets, aws.StringValue(zone.SubnetId))
+	}
+	if lb.State != nil {
+		switch aws.StringValue(lb.State.Code) {
+		case elbv2.LoadBalancerStateEnumActive:
+			loadBalancer.Spec.State = networking.LoadBalancerActive
+		case elbv2.LoadBalancerStateEnumFailed:
+			loadBalancer.Spec.State = networking.LoadBalancerFailed
+		}
+	}
+	return loadBalancer
+}
+
+// convertELBError map the "*NotFound" error codes of elbv2 to cloudprovider.NotFound
+func convertELBError(err error) error {
+	if awsErr, ok := err.(awserr.Error); ok && strings.HasSuffix(awsErr.Code(), "NotFound") {
+		return cloudprovider.NotFound
+	}
+	return err
+}
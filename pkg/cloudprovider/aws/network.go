@@ -0,0 +1,485 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+type vpcs struct{ *Cloud }
+
+func (c *vpcs) Create(ctx context.Context, vpc *networking.VirtualPrivateCloud) error {
+	output, err := c.ec2(vpc.Spec.Region).CreateVpcWithContext(ctx, &ec2.CreateVpcInput{
+		CidrBlock:         aws.String(joinCidr(vpc.Spec.IP, vpc.Spec.Mask)),
+		TagSpecifications: nameTag(ec2.ResourceTypeVpc, vpc.Spec.LocalName),
+	})
+	if err != nil {
+		return convertError(err)
+	}
+	vpc.Spec.ID = aws.StringValue(output.Vpc.VpcId)
+	return nil
+}
+
+func (c *vpcs) Get(ctx context.Context, region, id string) (*networking.VirtualPrivateCloud, error) {
+	result, err := c.describe(ctx, region, &ec2.DescribeVpcsInput{VpcIds: aws.StringSlice([]string{id})})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &result[0], nil
+}
+
+func (c *vpcs) List(ctx context.Context, region string) ([]networking.VirtualPrivateCloud, error) {
+	return c.describe(ctx, region, &ec2.DescribeVpcsInput{})
+}
+
+func (c *vpcs) describe(ctx context.Context, region string, input *ec2.DescribeVpcsInput) ([]networking.VirtualPrivateCloud, error) {
+	result := make([]networking.VirtualPrivateCloud, 0)
+	err := c.ec2(region).DescribeVpcsPagesWithContext(ctx, input,
+		func(output *ec2.DescribeVpcsOutput, _ bool) bool {
+			for _, vpc := range output.Vpcs {
+				ip, mask := splitCidr(aws.StringValue(vpc.CidrBlock))
+				result = append(result, networking.VirtualPrivateCloud{
+					Spec: networking.VirtualPrivateCloudSpec{
+						LocalName: tagValue(vpc.Tags, "Name"),
+						IP:        ip,
+						Mask:      mask,
+						Region:    region,
+						ID:        aws.StringValue(vpc.VpcId),
+					},
+				})
+			}
+			return true
+		})
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return result, nil
+}
+
+func (c *vpcs) Delete(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).DeleteVpcWithContext(ctx, &ec2.DeleteVpcInput{VpcId: aws.String(id)})
+	return convertError(err)
+}
+
+type subnets struct{ *Cloud }
+
+func (c *subnets) Create(ctx context.Context, subnet *networking.Vswitch) error {
+	output, err := c.ec2(subnet.Spec.Region).CreateSubnetWithContext(ctx, &ec2.CreateSubnetInput{
+		VpcId:             aws.String(subnet.Spec.VpcId),
+		CidrBlock:         aws.String(joinCidr(subnet.Spec.IP, subnet.Spec.Mask)),
+		AvailabilityZone:  aws.String(subnet.Spec.Zone),
+		TagSpecifications: nameTag(ec2.ResourceTypeSubnet, subnet.Spec.LocalName),
+	})
+	if err != nil {
+		return convertError(err)
+	}
+	subnet.Spec.Id = aws.StringValue(output.Subnet.SubnetId)
+	return nil
+}
+
+func (c *subnets) Get(ctx context.Context, region, id string) (*networking.Vswitch, error) {
+	result, err := c.describe(ctx, region, &ec2.DescribeSubnetsInput{SubnetIds: aws.StringSlice([]string{id})})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &result[0], nil
+}
+
+func (c *subnets) List(ctx context.Context, region string) ([]networking.Vswitch, error) {
+	return c.describe(ctx, region, &ec2.DescribeSubnetsInput{})
+}
+
+func (c *subnets) describe(ctx context.Context, region string, input *ec2.DescribeSubnetsInput) ([]networking.Vswitch, error) {
+	result := make([]networking.Vswitch, 0)
+	err := c.ec2(region).DescribeSubnetsPagesWithContext(ctx, input,
+		func(output *ec2.DescribeSubnetsOutput, _ bool) bool {
+			for _, subnet := range output.Subnets {
+				ip, mask := splitCidr(aws.StringValue(subnet.CidrBlock))
+				result = append(result, networking.Vswitch{
+					Spec: networking.VSwitchSpec{
+						LocalName: tagValue(subnet.Tags, "Name"),
+						IP:        ip,
+						Mask:      mask,
+						Region:    region,
+						Zone:      aws.StringValue(subnet.AvailabilityZone),
+						Id:        aws.StringValue(subnet.SubnetId),
+						VpcId:     aws.StringValue(subnet.VpcId),
+					},
+				})
+			}
+			return true
+		})
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return result, nil
+}
+
+func (c *subnets) Delete(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).DeleteSubnetWithContext(ctx, &ec2.DeleteSubnetInput{SubnetId: aws.String(id)})
+	return convertError(err)
+}
+
+type securityGroups struct{ *Cloud }
+
+func (c *securityGroups) Create(ctx context.Context, securityGroup *system.SecurityGroup) error {
+	name := securityGroup.Spec.LocalName
+	if name == "" {
+		name = securityGroup.GetName()
+	}
+	output, err := c.ec2(securityGroup.Spec.RegionId).CreateSecurityGroupWithContext(ctx, &ec2.CreateSecurityGroupInput{
+		GroupName:   aws.String(name),
+		Description: aws.String(name),
+		VpcId:       aws.String(securityGroup.Spec.VpcId),
+	})
+	if err != nil {
+		return convertError(err)
+	}
+	securityGroup.Spec.ID = aws.StringValue(output.GroupId)
+	return c.Update(ctx, securityGroup)
+}
+
+func (c *securityGroups) Get(ctx context.Context, region, id string) (*system.SecurityGroup, error) {
+	result, err := c.describe(ctx, region, &ec2.DescribeSecurityGroupsInput{GroupIds: aws.StringSlice([]string{id})})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &result[0], nil
+}
+
+func (c *securityGroups) List(ctx context.Context, region string) ([]system.SecurityGroup, error) {
+	return c.describe(ctx, region, &ec2.DescribeSecurityGroupsInput{})
+}
+
+func (c *securityGroups) describe(ctx context.Context, region string, input *ec2.DescribeSecurityGroupsInput) ([]system.SecurityGroup, error) {
+	result := make([]system.SecurityGroup, 0)
+	err := c.ec2(region).DescribeSecurityGroupsPagesWithContext(ctx, input,
+		func(output *ec2.DescribeSecurityGroupsOutput, _ bool) bool {
+			for _, group := range output.SecurityGroups {
+				result = append(result, system.SecurityGroup{
+					Spec: system.SecurityGroupSpec{
+						LocalName: aws.StringValue(group.GroupName),
+						RegionId:  region,
+						VpcId:     aws.StringValue(group.VpcId),
+						ID:        aws.StringValue(group.GroupId),
+						Ingress:   toRules(group.IpPermissions, system.Ingress),
+						Egress:    toRules(group.IpPermissionsEgress, system.Egress),
+					},
+				})
+			}
+			return true
+		})
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return result, nil
+}
+
+// Update authorize the rules of the spec missing from the group and revoke the ones no longer in it,
+// security groups of ec2 only allow traffic and have no rule priority
+func (c *securityGroups) Update(ctx context.Context, securityGroup *system.SecurityGroup) error {
+	region, id := securityGroup.Spec.RegionId, securityGroup.Spec.ID
+	output, err := c.ec2(region).DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{GroupIds: aws.StringSlice([]string{id})})
+	if err != nil {
+		return convertError(err)
+	}
+	if len(output.SecurityGroups) == 0 {
+		return cloudprovider.NotFound
+	}
+	current := output.SecurityGroups[0]
+
+	securityGroup.Spec.Normalize()
+	if err := c.sync(ctx, region, id, system.Ingress, toRules(current.IpPermissions, system.Ingress), securityGroup.Spec.Ingress); err != nil {
+		return err
+	}
+	return c.sync(ctx, region, id, system.Egress, toRules(current.IpPermissionsEgress, system.Egress), securityGroup.Spec.Egress)
+}
+
+func (c *securityGroups) sync(ctx context.Context, region, id string, direction system.RuleDirection, current, rules []system.SecurityGroupRole) error {
+	desired := make([]system.SecurityGroupRole, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Action == system.Deny {
+			return fmt.Errorf("rule %s: ec2 security groups can not deny traffic", rule)
+		}
+		rule.Priority = system.MinRulePriority
+		desired = append(desired, rule)
+	}
+	added, removed := system.DiffRules(current, desired)
+	if err := c.revoke(ctx, region, id, direction, removed); err != nil {
+		return err
+	}
+	return c.authorize(ctx, region, id, direction, added)
+}
+
+func (c *securityGroups) revoke(ctx context.Context, region, id string, direction system.RuleDirection, rules []system.SecurityGroupRole) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	permissions, err := toPermissions(rules)
+	if err != nil {
+		return err
+	}
+	if direction == system.Ingress {
+		_, err = c.ec2(region).RevokeSecurityGroupIngressWithContext(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId: aws.String(id), IpPermissions: permissions})
+	} else {
+		_, err = c.ec2(region).RevokeSecurityGroupEgressWithContext(ctx, &ec2.RevokeSecurityGroupEgressInput{
+			GroupId: aws.String(id), IpPermissions: permissions})
+	}
+	return convertError(err)
+}
+
+func (c *securityGroups) authorize(ctx context.Context, region, id string, direction system.RuleDirection, rules []system.SecurityGroupRole) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	permissions, err := toPermissions(rules)
+	if err != nil {
+		return err
+	}
+	if direction == system.Ingress {
+		_, err = c.ec2(region).AuthorizeSecurityGroupIngressWithContext(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId: aws.String(id), IpPermissions: permissions})
+	} else {
+		_, err = c.ec2(region).AuthorizeSecurityGroupEgressWithContext(ctx, &ec2.AuthorizeSecurityGroupEgressInput{
+			GroupId: aws.String(id), IpPermissions: permissions})
+	}
+	return convertError(err)
+}
+
+func (c *securityGroups) Delete(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(id)})
+	return convertError(err)
+}
+
+var protocols = map[system.IpProtocolType]string{
+	system.TCP:  "tcp",
+	system.UDP:  "udp",
+	system.ICMP: "icmp",
+	system.GRE:  "47",
+	system.ALL:  "-1",
+}
+
+func toPermissions(rules []system.SecurityGroupRole) ([]*ec2.IpPermission, error) {
+	permissions := make([]*ec2.IpPermission, 0, len(rules))
+	for _, rule := range rules {
+		protocol, exist := protocols[rule.IpProtocol]
+		if !exist {
+			return nil, fmt.Errorf("unsupported ip protocol %s", rule.IpProtocol)
+		}
+		from, to, err := system.ParsePortRange(rule.PortRange)
+		if err != nil {
+			return nil, err
+		}
+		permission := &ec2.IpPermission{
+			IpProtocol: aws.String(protocol),
+			FromPort:   aws.Int64(int64(from)),
+			ToPort:     aws.Int64(int64(to)),
+		}
+		if rule.PeerGroup != "" {
+			permission.UserIdGroupPairs = []*ec2.UserIdGroupPair{{GroupId: aws.String(rule.PeerGroup), Description: description(rule)}}
+		} else {
+			permission.IpRanges = []*ec2.IpRange{{CidrIp: aws.String(rule.SourceCidrIp), Description: description(rule)}}
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions, nil
+}
+
+func description(rule system.SecurityGroupRole) *string {
+	if rule.Description == "" {
+		return nil
+	}
+	return aws.String(rule.Description)
+}
+
+func toRules(permissions []*ec2.IpPermission, direction system.RuleDirection) []system.SecurityGroupRole {
+	rules := make([]system.SecurityGroupRole, 0)
+	for _, permission := range permissions {
+		protocol := system.IpProtocolType(strings.ToUpper(aws.StringValue(permission.IpProtocol)))
+		for key, value := range protocols {
+			if value == aws.StringValue(permission.IpProtocol) {
+				protocol = key
+			}
+		}
+		portRange := fmt.Sprintf("%d/%d", aws.Int64Value(permission.FromPort), aws.Int64Value(permission.ToPort))
+		if permission.FromPort == nil {
+			portRange = system.AllPorts
+		}
+		rule := system.SecurityGroupRole{
+			Direction:  direction,
+			Action:     system.Allow,
+			Priority:   system.MinRulePriority,
+			PortRange:  portRange,
+			IpProtocol: protocol,
+		}
+		for _, ipRange := range permission.IpRanges {
+			rule.SourceCidrIp, rule.PeerGroup = aws.StringValue(ipRange.CidrIp), ""
+			rule.Description = aws.StringValue(ipRange.Description)
+			rules = append(rules, rule)
+		}
+		for _, pair := range permission.UserIdGroupPairs {
+			rule.SourceCidrIp, rule.PeerGroup = "", aws.StringValue(pair.GroupId)
+			rule.Description = aws.StringValue(pair.Description)
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+type networkInterfaces struct{ *Cloud }
+
+func (c *networkInterfaces) Create(ctx context.Context, networkInterface *networking.NetworkInterface) error {
+	input := &ec2.CreateNetworkInterfaceInput{
+		SubnetId:    aws.String(networkInterface.Spec.SubnetId),
+		Description: aws.String(networkInterface.Spec.Description),
+	}
+	if len(networkInterface.Spec.SecurityGroupIds) > 0 {
+		input.Groups = aws.StringSlice(networkInterface.Spec.SecurityGroupIds)
+	}
+	if networkInterface.Spec.PrivateIpAddress != "" {
+		input.PrivateIpAddress = aws.String(networkInterface.Spec.PrivateIpAddress)
+	}
+	output, err := c.ec2(networkInterface.Spec.Region).CreateNetworkInterfaceWithContext(ctx, input)
+	if err != nil {
+		return convertError(err)
+	}
+	created := toNetworkInterface(networkInterface.Spec.Region, output.NetworkInterface)
+	networkInterface.Spec.ID = created.Spec.ID
+	networkInterface.Spec.MacAddress = created.Spec.MacAddress
+	networkInterface.Spec.PrivateIpAddress = created.Spec.PrivateIpAddress
+	networkInterface.Spec.PrivateIpSets = created.Spec.PrivateIpSets
+	networkInterface.Spec.State = created.Spec.State
+	return nil
+}
+
+func (c *networkInterfaces) Get(ctx context.Context, region, id string) (*networking.NetworkInterface, error) {
+	result, err := c.describe(ctx, region, &ec2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: aws.StringSlice([]string{id})})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, cloudprovider.NotFound
+	}
+	return &result[0], nil
+}
+
+func (c *networkInterfaces) List(ctx context.Context, region string) ([]networking.NetworkInterface, error) {
+	return c.describe(ctx, region, &ec2.DescribeNetworkInterfacesInput{})
+}
+
+func (c *networkInterfaces) describe(ctx context.Context, region string, input *ec2.DescribeNetworkInterfacesInput) ([]networking.NetworkInterface, error) {
+	result := make([]networking.NetworkInterface, 0)
+	err := c.ec2(region).DescribeNetworkInterfacesPagesWithContext(ctx, input,
+		func(output *ec2.DescribeNetworkInterfacesOutput, _ bool) bool {
+			for _, networkInterface := range output.NetworkInterfaces {
+				result = append(result, toNetworkInterface(region, networkInterface))
+			}
+			return true
+		})
+	if err != nil {
+		return nil, convertError(err)
+	}
+	return result, nil
+}
+
+func (c *networkInterfaces) Delete(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).DeleteNetworkInterfaceWithContext(ctx, &ec2.DeleteNetworkInterfaceInput{NetworkInterfaceId: aws.String(id)})
+	return convertError(err)
+}
+
+func (c *networkInterfaces) Attach(ctx context.Context, region, id, instanceId string) error {
+	output, err := c.ec2(region).DescribeNetworkInterfacesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("attachment.instance-id"), Values: aws.StringSlice([]string{instanceId})}},
+	})
+	if err != nil {
+		return convertError(err)
+	}
+	_, err = c.ec2(region).AttachNetworkInterfaceWithContext(ctx, &ec2.AttachNetworkInterfaceInput{
+		NetworkInterfaceId: aws.String(id),
+		InstanceId:         aws.String(instanceId),
+		DeviceIndex:        aws.Int64(int64(len(output.NetworkInterfaces))),
+	})
+	return convertError(err)
+}
+
+func (c *networkInterfaces) Detach(ctx context.Context, region, id, instanceId string) error {
+	networkInterface, err := c.ec2(region).DescribeNetworkInterfacesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{
+		NetworkInterfaceIds: aws.StringSlice([]string{id}),
+	})
+	if err != nil {
+		return convertError(err)
+	}
+	if len(networkInterface.NetworkInterfaces) == 0 {
+		return cloudprovider.NotFound
+	}
+	attachment := networkInterface.NetworkInterfaces[0].Attachment
+	if attachment == nil || aws.StringValue(attachment.InstanceId) != instanceId {
+		return fmt.Errorf("network interface %s is not attached to %s", id, instanceId)
+	}
+	_, err = c.ec2(region).DetachNetworkInterfaceWithContext(ctx, &ec2.DetachNetworkInterfaceInput{AttachmentId: attachment.AttachmentId})
+	return convertError(err)
+}
+
+func toNetworkInterface(region string, eni *ec2.NetworkInterface) networking.NetworkInterface {
+	networkInterface := networking.NetworkInterface{
+		Spec: networking.NetworkInterfaceSpec{
+			ID:               aws.StringValue(eni.NetworkInterfaceId),
+			Description:      aws.StringValue(eni.Description),
+			MacAddress:       aws.StringValue(eni.MacAddress),
+			PrivateDnsName:   aws.StringValue(eni.PrivateDnsName),
+			PrivateIpAddress: aws.StringValue(eni.PrivateIpAddress),
+			SubnetId:         aws.StringValue(eni.SubnetId),
+			VPCId:            aws.StringValue(eni.VpcId),
+			Type:             aws.StringValue(eni.InterfaceType),
+			Region:           region,
+			Zone:             aws.StringValue(eni.AvailabilityZone),
+			State:            aws.StringValue(eni.Status),
+		},
+	}
+	if eni.Association != nil {
+		networkInterface.Spec.PublicDnsName = aws.StringValue(eni.Association.PublicDnsName)
+		networkInterface.Spec.PublicIpAddress = aws.StringValue(eni.Association.PublicIp)
+	}
+	if eni.Attachment != nil {
+		networkInterface.Spec.Attachment = networking.ENIAttachment{
+			AttachTime: aws.TimeValue(eni.Attachment.AttachTime).UTC().Format(time.RFC3339),
+			InstanceId: aws.StringValue(eni.Attachment.InstanceId),
+			Status:     aws.StringValue(eni.Attachment.Status),
+		}
+	}
+	for _, group := range eni.Groups {
+		networkInterface.Spec.SecurityGroupIds = append(networkInterface.Spec.SecurityGroupIds, aws.StringValue(group.GroupId))
+	}
+	for _, address := range eni.PrivateIpAddresses {
+		set := networking.ENIPrivateIpSet{
+			Primary:          aws.BoolValue(address.Primary),
+			PrivateDnsName:   aws.StringValue(address.PrivateDnsName),
+			PrivateIpAddress: aws.StringValue(address.PrivateIpAddress),
+		}
+		if address.Association != nil {
+			set.PublicIpAddress = aws.StringValue(address.Association.PublicIp)
+		}
+		networkInterface.Spec.PrivateIpSets = append(networkInterface.Spec.PrivateIpSets, set)
+	}
+	for _, address := range eni.Ipv6Addresses {
+		networkInterface.Spec.Ipv6 = append(networkInterface.Spec.Ipv6, aws.StringValue(address.Ipv6Address))
+	}
+	return networkInterface
+}
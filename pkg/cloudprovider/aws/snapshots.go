@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+)
+
+type snapshots struct{ *Cloud }
+
+func (c *snapshots) Create(ctx context.Context, region, diskId, name string) (string, error) {
+	snapshot, err := c.ec2(region).CreateSnapshotWithContext(ctx, &ec2.CreateSnapshotInput{
+		VolumeId:          aws.String(diskId),
+		Description:       aws.String(name),
+		TagSpecifications: nameTag(ec2.ResourceTypeSnapshot, name),
+	})
+	if err != nil {
+		return "", convertError(err)
+	}
+	return aws.StringValue(snapshot.SnapshotId), nil
+}
+
+func (c *snapshots) Ready(ctx context.Context, region, id string) (bool, error) {
+	output, err := c.ec2(region).DescribeSnapshotsWithContext(ctx, &ec2.DescribeSnapshotsInput{SnapshotIds: aws.StringSlice([]string{id})})
+	if err != nil {
+		return false, convertError(err)
+	}
+	if len(output.Snapshots) == 0 {
+		return false, cloudprovider.NotFound
+	}
+	switch snapshot := output.Snapshots[0]; aws.StringValue(snapshot.State) {
+	case ec2.SnapshotStateCompleted:
+		return true, nil
+	case ec2.SnapshotStateError:
+		return false, fmt.Errorf("snapshot %s error %s", id, aws.StringValue(snapshot.StateMessage))
+	}
+	return false, nil
+}
+
+func (c *snapshots) Delete(ctx context.Context, region, id string) error {
+	_, err := c.ec2(region).DeleteSnapshotWithContext(ctx, &ec2.DeleteSnapshotInput{SnapshotId: aws.String(id)})
+	return convertError(err)
+}
+
+// Restore ec2 only replaces root volumes in place, a data volume would have to be recreated from the snapshot
+func (c *snapshots) Restore(ctx context.Context, region, diskId, snapshotId string) error {
+	client := c.ec2(region)
+	volumes, err := client.DescribeVolumesWithContext(ctx, &ec2.DescribeVolumesInput{VolumeIds: aws.StringSlice([]string{diskId})})
+	if err != nil {
+		return convertError(err)
+	}
+	if len(volumes.Volumes) == 0 || len(volumes.Volumes[0].Attachments) == 0 {
+		return fmt.Errorf("volume %s is not attached to an instance", diskId)
+	}
+	attachment := volumes.Volumes[0].Attachments[0]
+
+	reservations, err := client.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{InstanceIds: []*string{attachment.InstanceId}})
+	if err != nil {
+		return convertError(err)
+	}
+	if len(reservations.Reservations) == 0 || len(reservations.Reservations[0].Instances) == 0 {
+		return cloudprovider.NotFound
+	}
+	instance := reservations.Reservations[0].Instances[0]
+	if aws.StringValue(instance.RootDeviceName) != aws.StringValue(attachment.Device) {
+		return cloudprovider.NotImplemented
+	}
+
+	_, err = client.CreateReplaceRootVolumeTaskWithContext(ctx, &ec2.CreateReplaceRootVolumeTaskInput{
+		InstanceId: attachment.InstanceId,
+		SnapshotId: aws.String(snapshotId),
+	})
+	return convertError(err)
+}
@@ -0,0 +1,137 @@
+// Package cloudprovider abstracts the public cloud vendors the infra controllers
+// drive directly, each vendor registers an implementation under its provider name.
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+var (
+	NotFound       = fmt.Errorf("cloud resource not found")
+	NotImplemented = fmt.Errorf("not implemented by the cloud provider")
+)
+
+// Credentials access key of a system.Provider, Region is the default region of the client
+type Credentials struct {
+	AccessKey    string
+	AccessSecret string
+	Region       string
+}
+
+type Interface interface {
+	Instances() Instances
+	Disks() Disks
+	VPCs() VPCs
+	Subnets() Subnets
+	SecurityGroups() SecurityGroups
+	NetworkInterfaces() NetworkInterfaces
+	Images() Images
+	InstanceTypes() InstanceTypes
+	Snapshots() Snapshots
+	ElasticIPs() ElasticIPs
+	LoadBalancers() LoadBalancers
+}
+
+// Bootstrap the guest initialisation material of an instance, rendered from the vm license and user data
+type Bootstrap struct {
+	UserData          string
+	NetworkData       string
+	SSHAuthorizedKeys []string
+	Password          string
+}
+
+// Instances Create fill the vendor instance id and state back into the vm spec,
+// Get and List only fill the spec
+type Instances interface {
+	Create(ctx context.Context, vm *compute.VirtualMachine, bootstrap *Bootstrap) error
+	Get(ctx context.Context, region, id string) (*compute.VirtualMachine, error)
+	List(ctx context.Context, region string) ([]compute.VirtualMachine, error)
+	Delete(ctx context.Context, region, id string) error
+	Start(ctx context.Context, region, id string) error
+	Stop(ctx context.Context, region, id string) error
+	Reboot(ctx context.Context, region, id string) error
+}
+
+type Disks interface {
+	Create(ctx context.Context, disk *compute.Storage) error
+	Get(ctx context.Context, region, id string) (*compute.Storage, error)
+	List(ctx context.Context, region string) ([]compute.Storage, error)
+	Delete(ctx context.Context, region, id string) error
+	Attach(ctx context.Context, region, id, instanceId string) error
+	Detach(ctx context.Context, region, id, instanceId string) error
+	Resize(ctx context.Context, region, id string, size int) error
+}
+
+type VPCs interface {
+	Create(ctx context.Context, vpc *networking.VirtualPrivateCloud) error
+	Get(ctx context.Context, region, id string) (*networking.VirtualPrivateCloud, error)
+	List(ctx context.Context, region string) ([]networking.VirtualPrivateCloud, error)
+	Delete(ctx context.Context, region, id string) error
+}
+
+type Subnets interface {
+	Create(ctx context.Context, subnet *networking.Vswitch) error
+	Get(ctx context.Context, region, id string) (*networking.Vswitch, error)
+	List(ctx context.Context, region string) ([]networking.Vswitch, error)
+	Delete(ctx context.Context, region, id string) error
+}
+
+// SecurityGroups Update replace the vendor rules with the ingress and egress of the spec
+type SecurityGroups interface {
+	Create(ctx context.Context, securityGroup *system.SecurityGroup) error
+	Get(ctx context.Context, region, id string) (*system.SecurityGroup, error)
+	List(ctx context.Context, region string) ([]system.SecurityGroup, error)
+	Update(ctx context.Context, securityGroup *system.SecurityGroup) error
+	Delete(ctx context.Context, region, id string) error
+}
+
+type NetworkInterfaces interface {
+	Create(ctx context.Context, networkInterface *networking.NetworkInterface) error
+	Get(ctx context.Context, region, id string) (*networking.NetworkInterface, error)
+	List(ctx context.Context, region string) ([]networking.NetworkInterface, error)
+	Delete(ctx context.Context, region, id string) error
+	Attach(ctx context.Context, region, id, instanceId string) error
+	Detach(ctx context.Context, region, id, instanceId string) error
+}
+
+type Images interface {
+	List(ctx context.Context, region string) ([]compute.Image, error)
+}
+
+type InstanceTypes interface {
+	List(ctx context.Context, region string) ([]system.InstanceType, error)
+}
+
+// Snapshots Create return the snapshot id before the snapshot is ready, poll Ready until it is
+type Snapshots interface {
+	Create(ctx context.Context, region, diskId, name string) (string, error)
+	// Ready an error is returned once the vendor failed the snapshot
+	Ready(ctx context.Context, region, id string) (bool, error)
+	Delete(ctx context.Context, region, id string) error
+	// Restore roll the disk back to the snapshot, the instance of the disk must be stopped
+	Restore(ctx context.Context, region, diskId, snapshotId string) error
+}
+
+// ElasticIPs Allocate fill the allocation id and the address back into the spec
+type ElasticIPs interface {
+	Allocate(ctx context.Context, elasticIP *networking.ElasticIP) error
+	Get(ctx context.Context, region, id string) (*networking.ElasticIP, error)
+	Release(ctx context.Context, region, id string) error
+	Associate(ctx context.Context, region, id, instanceId string) error
+	Disassociate(ctx context.Context, region, id, instanceId string) error
+}
+
+// LoadBalancers Create fill the load balancer id and the address back into the spec, the load balancer is exposed
+// on the elastic ip of allocationId when set. Update bring the listeners, the health check and the backend
+// instances of the load balancer to the spec
+type LoadBalancers interface {
+	Create(ctx context.Context, loadBalancer *networking.LoadBalancer, instanceIds []string, allocationId string) error
+	Get(ctx context.Context, region, id string) (*networking.LoadBalancer, error)
+	Update(ctx context.Context, loadBalancer *networking.LoadBalancer, instanceIds []string) error
+	Delete(ctx context.Context, region, id string) error
+}
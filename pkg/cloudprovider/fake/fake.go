@@ -0,0 +1,929 @@
+// Package fake an in-memory cloud registered as the "fake" provider, used by the controller tests
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+const Name = "fake"
+
+var (
+	cloudsMutex sync.Mutex
+	clouds      = make(map[string]*Cloud)
+)
+
+// CloudOf the cloud shared by every client built with the access key
+func CloudOf(accessKey string) *Cloud {
+	cloudsMutex.Lock()
+	defer cloudsMutex.Unlock()
+	cloud, exist := clouds[accessKey]
+	if !exist {
+		cloud = NewCloud()
+		clouds[accessKey] = cloud
+	}
+	return cloud
+}
+
+var _ cloudprovider.Interface = &Cloud{}
+
+type Cloud struct {
+	mu    sync.Mutex
+	seq   int
+	err   error
+	delay time.Duration
+
+	instances         map[string]compute.VirtualMachine
+	transitions       map[string]transition // instance id -> the state the instance settles in
+	bootstraps        map[string]cloudprovider.Bootstrap
+	disks             map[string]compute.Storage
+	vpcs              map[string]networking.VirtualPrivateCloud
+	subnets           map[string]networking.Vswitch
+	securityGroups    map[string]system.SecurityGroup
+	networkInterfaces map[string]networking.NetworkInterface
+	snapshots         map[string]string // snapshot id -> disk id
+	restores          map[string]string // disk id -> snapshot id
+	elasticIPs        map[string]networking.ElasticIP
+	loadBalancers     map[string]networking.LoadBalancer
+	targets           map[string][]string // load balancer id -> instance ids
+	images            []compute.Image
+	instanceTypes     []system.InstanceType
+}
+
+func NewCloud() *Cloud {
+	return &Cloud{
+		instances:         make(map[string]compute.VirtualMachine),
+		transitions:       make(map[string]transition),
+		bootstraps:        make(map[string]cloudprovider.Bootstrap),
+		disks:             make(map[string]compute.Storage),
+		vpcs:              make(map[string]networking.VirtualPrivateCloud),
+		subnets:           make(map[string]networking.Vswitch),
+		securityGroups:    make(map[string]system.SecurityGroup),
+		networkInterfaces: make(map[string]networking.NetworkInterface),
+		snapshots:         make(map[string]string),
+		restores:          make(map[string]string),
+		elasticIPs:        make(map[string]networking.ElasticIP),
+		loadBalancers:     make(map[string]networking.LoadBalancer),
+		targets:           make(map[string][]string),
+	}
+}
+
+// SetError make every following call fail with err until it is reset with nil
+func (c *Cloud) SetError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// SetDelay make the instances settle delay after they were created, started, stopped or rebooted.
+// Until then they report the transitional state, by default they settle on the next call
+func (c *Cloud) SetDelay(delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delay = delay
+}
+
+func (c *Cloud) AddImage(image compute.Image) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.images = append(c.images, image)
+}
+
+func (c *Cloud) AddInstanceType(instanceType system.InstanceType) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instanceTypes = append(c.instanceTypes, instanceType)
+}
+
+func (c *Cloud) Instances() cloudprovider.Instances                 { return &instances{c} }
+func (c *Cloud) Disks() cloudprovider.Disks                         { return &disks{c} }
+func (c *Cloud) VPCs() cloudprovider.VPCs                           { return &vpcs{c} }
+func (c *Cloud) Subnets() cloudprovider.Subnets                     { return &subnets{c} }
+func (c *Cloud) SecurityGroups() cloudprovider.SecurityGroups       { return &securityGroups{c} }
+func (c *Cloud) NetworkInterfaces() cloudprovider.NetworkInterfaces { return &networkInterfaces{c} }
+func (c *Cloud) Images() cloudprovider.Images                       { return &images{c} }
+func (c *Cloud) InstanceTypes() cloudprovider.InstanceTypes         { return &instanceTypes{c} }
+func (c *Cloud) Snapshots() cloudprovider.Snapshots                 { return &snapshots{c} }
+func (c *Cloud) ElasticIPs() cloudprovider.ElasticIPs               { return &elasticIPs{c} }
+func (c *Cloud) LoadBalancers() cloudprovider.LoadBalancers         { return &loadBalancers{c} }
+
+// lock hold the cloud lock and return the injected error if any, the caller must unlock.
+// The instance transitions which are due settle first
+func (c *Cloud) lock() error {
+	c.mu.Lock()
+	c.settle()
+	return c.err
+}
+
+func (c *Cloud) nextId(prefix string) string {
+	c.seq++
+	return fmt.Sprintf("%s-%08d", prefix, c.seq)
+}
+
+type instances struct{ *Cloud }
+
+// transition an instance change the vendor carries out asynchronously
+type transition struct {
+	state compute.VirtualMachineStateType
+	at    time.Time
+}
+
+// transit put the instance in the transitional state until it settles in state, the lock is held
+func (c *Cloud) transit(id string, transitional, state compute.VirtualMachineStateType) {
+	vm := c.instances[id]
+	vm.Spec.State = transitional
+	c.instances[id] = vm
+	c.transitions[id] = transition{state: state, at: time.Now().Add(c.delay)}
+}
+
+// settle the transitions of the instances which are due, the lock is held
+func (c *Cloud) settle() {
+	now := time.Now()
+	for id, transition := range c.transitions {
+		if now.Before(transition.at) {
+			continue
+		}
+		if vm, exist := c.instances[id]; exist {
+			vm.Spec.State = transition.state
+			c.instances[id] = vm
+		}
+		delete(c.transitions, id)
+	}
+}
+
+// Bootstrap the bootstrap an instance was created with
+func (c *Cloud) Bootstrap(id string) (cloudprovider.Bootstrap, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	bootstrap, exist := c.bootstraps[id]
+	return bootstrap, exist
+}
+
+func (c *instances) Create(_ context.Context, vm *compute.VirtualMachine, bootstrap *cloudprovider.Bootstrap) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if vm.Spec.InstanceId == "" {
+		vm.Spec.InstanceId = c.nextId("i")
+	}
+	vm.Spec.State = compute.Starting
+	vm.Spec.CreateTime = time.Now().UTC().Format(time.RFC3339)
+	c.instances[vm.Spec.InstanceId] = compute.VirtualMachine{Spec: vm.Spec}
+	c.transit(vm.Spec.InstanceId, compute.Starting, compute.Running)
+	if bootstrap != nil {
+		c.bootstraps[vm.Spec.InstanceId] = *bootstrap
+	}
+	return nil
+}
+
+func (c *instances) Get(_ context.Context, _, id string) (*compute.VirtualMachine, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	vm, exist := c.instances[id]
+	if !exist {
+		return nil, cloudprovider.NotFound
+	}
+	return &vm, nil
+}
+
+func (c *instances) List(_ context.Context, region string) ([]compute.VirtualMachine, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	result := make([]compute.VirtualMachine, 0)
+	for _, vm := range c.instances {
+		if region == "" || vm.Spec.RegionId == region {
+			result = append(result, vm)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Spec.InstanceId < result[j].Spec.InstanceId })
+	return result, nil
+}
+
+func (c *instances) Delete(_ context.Context, _, id string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if _, exist := c.instances[id]; !exist {
+		return cloudprovider.NotFound
+	}
+	delete(c.instances, id)
+	return nil
+}
+
+func (c *instances) setState(id string, transitional, state compute.VirtualMachineStateType) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if _, exist := c.instances[id]; !exist {
+		return cloudprovider.NotFound
+	}
+	c.transit(id, transitional, state)
+	return nil
+}
+
+func (c *instances) Start(_ context.Context, _, id string) error {
+	return c.setState(id, compute.Starting, compute.Running)
+}
+
+func (c *instances) Stop(_ context.Context, _, id string) error {
+	return c.setState(id, compute.Stopping, compute.Stopped)
+}
+
+func (c *instances) Reboot(_ context.Context, _, id string) error {
+	return c.setState(id, compute.Restarting, compute.Running)
+}
+
+type disks struct{ *Cloud }
+
+func (c *disks) Create(_ context.Context, disk *compute.Storage) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if disk.Spec.StorageId == "" {
+		disk.Spec.StorageId = c.nextId("vol")
+	}
+	disk.Spec.State = "available"
+	c.disks[disk.Spec.StorageId] = compute.Storage{Spec: disk.Spec}
+	return nil
+}
+
+func (c *disks) Get(_ context.Context, _, id string) (*compute.Storage, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	disk, exist := c.disks[id]
+	if !exist {
+		return nil, cloudprovider.NotFound
+	}
+	return &disk, nil
+}
+
+func (c *disks) List(_ context.Context, region string) ([]compute.Storage, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	result := make([]compute.Storage, 0)
+	for _, disk := range c.disks {
+		if region == "" || disk.Spec.Region == region {
+			result = append(result, disk)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Spec.StorageId < result[j].Spec.StorageId })
+	return result, nil
+}
+
+func (c *disks) Delete(_ context.Context, _, id string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	disk, exist := c.disks[id]
+	if !exist {
+		return cloudprovider.NotFound
+	}
+	if len(disk.Spec.Attachments) > 0 {
+		return fmt.Errorf("disk %s is in use", id)
+	}
+	delete(c.disks, id)
+	return nil
+}
+
+func (c *disks) Attach(_ context.Context, _, id, instanceId string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	disk, exist := c.disks[id]
+	if !exist {
+		return cloudprovider.NotFound
+	}
+	if _, exist := c.instances[instanceId]; !exist {
+		return cloudprovider.NotFound
+	}
+	if len(disk.Spec.Attachments) > 0 {
+		return fmt.Errorf("disk %s is in use", id)
+	}
+	disk.Spec.Attachments = []compute.Attachment{{
+		AttachedTime: time.Now().UTC().Format(time.RFC3339),
+		Device:       fmt.Sprintf("/dev/vd%c", 'b'+len(c.attachedTo(instanceId))),
+		InstanceId:   instanceId,
+		State:        "attached",
+	}}
+	disk.Spec.State = "in-use"
+	c.disks[id] = disk
+	return nil
+}
+
+func (c *disks) attachedTo(instanceId string) []string {
+	ids := make([]string, 0)
+	for id, disk := range c.disks {
+		for _, attachment := range disk.Spec.Attachments {
+			if attachment.InstanceId == instanceId {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func (c *disks) Detach(_ context.Context, _, id, instanceId string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	disk, exist := c.disks[id]
+	if !exist {
+		return cloudprovider.NotFound
+	}
+	if len(disk.Spec.Attachments) == 0 || disk.Spec.Attachments[0].InstanceId != instanceId {
+		return fmt.Errorf("disk %s is not attached to %s", id, instanceId)
+	}
+	disk.Spec.Attachments = nil
+	disk.Spec.State = "available"
+	c.disks[id] = disk
+	return nil
+}
+
+func (c *disks) Resize(_ context.Context, _, id string, size int) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	disk, exist := c.disks[id]
+	if !exist {
+		return cloudprovider.NotFound
+	}
+	if size < disk.Spec.Size {
+		return fmt.Errorf("disk %s can not shrink from %d to %d", id, disk.Spec.Size, size)
+	}
+	disk.Spec.Size = size
+	c.disks[id] = disk
+	return nil
+}
+
+type vpcs struct{ *Cloud }
+
+func (c *vpcs) Create(_ context.Context, vpc *networking.VirtualPrivateCloud) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if vpc.Spec.ID == "" {
+		vpc.Spec.ID = c.nextId("vpc")
+	}
+	c.vpcs[vpc.Spec.ID] = networking.VirtualPrivateCloud{Spec: vpc.Spec}
+	return nil
+}
+
+func (c *vpcs) Get(_ context.Context, _, id string) (*networking.VirtualPrivateCloud, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	vpc, exist := c.vpcs[id]
+	if !exist {
+		return nil, cloudprovider.NotFound
+	}
+	return &vpc, nil
+}
+
+func (c *vpcs) List(_ context.Context, region string) ([]networking.VirtualPrivateCloud, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	result := make([]networking.VirtualPrivateCloud, 0)
+	for _, vpc := range c.vpcs {
+		if region == "" || vpc.Spec.Region == region {
+			result = append(result, vpc)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Spec.ID < result[j].Spec.ID })
+	return result, nil
+}
+
+func (c *vpcs) Delete(_ context.Context, _, id string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if _, exist := c.vpcs[id]; !exist {
+		return cloudprovider.NotFound
+	}
+	for _, subnet := range c.subnets {
+		if subnet.Spec.VpcId == id {
+			return fmt.Errorf("vpc %s has dependent subnet %s", id, subnet.Spec.Id)
+		}
+	}
+	delete(c.vpcs, id)
+	return nil
+}
+
+type subnets struct{ *Cloud }
+
+func (c *subnets) Create(_ context.Context, subnet *networking.Vswitch) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if _, exist := c.vpcs[subnet.Spec.VpcId]; !exist {
+		return fmt.Errorf("vpc %s: %w", subnet.Spec.VpcId, cloudprovider.NotFound)
+	}
+	if subnet.Spec.Id == "" {
+		subnet.Spec.Id = c.nextId("vsw")
+	}
+	c.subnets[subnet.Spec.Id] = networking.Vswitch{Spec: subnet.Spec}
+	return nil
+}
+
+func (c *subnets) Get(_ context.Context, _, id string) (*networking.Vswitch, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	subnet, exist := c.subnets[id]
+	if !exist {
+		return nil, cloudprovider.NotFound
+	}
+	return &subnet, nil
+}
+
+func (c *subnets) List(_ context.Context, region string) ([]networking.Vswitch, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	result := make([]networking.Vswitch, 0)
+	for _, subnet := range c.subnets {
+		if region == "" || subnet.Spec.Region == region {
+			result = append(result, subnet)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Spec.Id < result[j].Spec.Id })
+	return result, nil
+}
+
+func (c *subnets) Delete(_ context.Context, _, id string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if _, exist := c.subnets[id]; !exist {
+		return cloudprovider.NotFound
+	}
+	delete(c.subnets, id)
+	return nil
+}
+
+type securityGroups struct{ *Cloud }
+
+func (c *securityGroups) Create(_ context.Context, securityGroup *system.SecurityGroup) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if securityGroup.Spec.ID == "" {
+		securityGroup.Spec.ID = c.nextId("sg")
+	}
+	c.securityGroups[securityGroup.Spec.ID] = system.SecurityGroup{Spec: securityGroup.Spec}
+	return nil
+}
+
+func (c *securityGroups) Get(_ context.Context, _, id string) (*system.SecurityGroup, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	securityGroup, exist := c.securityGroups[id]
+	if !exist {
+		return nil, cloudprovider.NotFound
+	}
+	return &securityGroup, nil
+}
+
+func (c *securityGroups) List(_ context.Context, region string) ([]system.SecurityGroup, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	result := make([]system.SecurityGroup, 0)
+	for _, securityGroup := range c.securityGroups {
+		if region == "" || securityGroup.Spec.RegionId == region {
+			result = append(result, securityGroup)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Spec.ID < result[j].Spec.ID })
+	return result, nil
+}
+
+func (c *securityGroups) Update(_ context.Context, securityGroup *system.SecurityGroup) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if _, exist := c.securityGroups[securityGroup.Spec.ID]; !exist {
+		return cloudprovider.NotFound
+	}
+	c.securityGroups[securityGroup.Spec.ID] = system.SecurityGroup{Spec: securityGroup.Spec}
+	return nil
+}
+
+func (c *securityGroups) Delete(_ context.Context, _, id string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if _, exist := c.securityGroups[id]; !exist {
+		return cloudprovider.NotFound
+	}
+	delete(c.securityGroups, id)
+	return nil
+}
+
+type networkInterfaces struct{ *Cloud }
+
+func (c *networkInterfaces) Create(_ context.Context, networkInterface *networking.NetworkInterface) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if networkInterface.Spec.ID == "" {
+		networkInterface.Spec.ID = c.nextId("eni")
+	}
+	networkInterface.Spec.MacAddress = fmt.Sprintf("00:16:3e:00:%02x:%02x", c.seq/256%256, c.seq%256)
+	networkInterface.Spec.State = "Available"
+	c.networkInterfaces[networkInterface.Spec.ID] = networking.NetworkInterface{Spec: networkInterface.Spec}
+	return nil
+}
+
+func (c *networkInterfaces) Get(_ context.Context, _, id string) (*networking.NetworkInterface, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	networkInterface, exist := c.networkInterfaces[id]
+	if !exist {
+		return nil, cloudprovider.NotFound
+	}
+	return &networkInterface, nil
+}
+
+func (c *networkInterfaces) List(_ context.Context, region string) ([]networking.NetworkInterface, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	result := make([]networking.NetworkInterface, 0)
+	for _, networkInterface := range c.networkInterfaces {
+		if region == "" || networkInterface.Spec.Region == region {
+			result = append(result, networkInterface)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Spec.ID < result[j].Spec.ID })
+	return result, nil
+}
+
+func (c *networkInterfaces) Delete(_ context.Context, _, id string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if _, exist := c.networkInterfaces[id]; !exist {
+		return cloudprovider.NotFound
+	}
+	delete(c.networkInterfaces, id)
+	return nil
+}
+
+func (c *networkInterfaces) Attach(_ context.Context, _, id, instanceId string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	networkInterface, exist := c.networkInterfaces[id]
+	if !exist {
+		return cloudprovider.NotFound
+	}
+	if _, exist := c.instances[instanceId]; !exist {
+		return cloudprovider.NotFound
+	}
+	networkInterface.Spec.Attachment = networking.ENIAttachment{
+		AttachTime: time.Now().UTC().Format(time.RFC3339),
+		InstanceId: instanceId,
+		Status:     "attached",
+	}
+	networkInterface.Spec.State = "InUse"
+	c.networkInterfaces[id] = networkInterface
+	return nil
+}
+
+func (c *networkInterfaces) Detach(_ context.Context, _, id, instanceId string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	networkInterface, exist := c.networkInterfaces[id]
+	if !exist {
+		return cloudprovider.NotFound
+	}
+	if networkInterface.Spec.Attachment.InstanceId != instanceId {
+		return fmt.Errorf("network interface %s is not attached to %s", id, instanceId)
+	}
+	networkInterface.Spec.Attachment = networking.ENIAttachment{}
+	networkInterface.Spec.State = "Available"
+	c.networkInterfaces[id] = networkInterface
+	return nil
+}
+
+type images struct{ *Cloud }
+
+func (c *images) List(_ context.Context, region string) ([]compute.Image, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	result := make([]compute.Image, 0)
+	for _, image := range c.images {
+		if region == "" || image.Spec.Region == region {
+			result = append(result, image)
+		}
+	}
+	return result, nil
+}
+
+type instanceTypes struct{ *Cloud }
+
+func (c *instanceTypes) List(_ context.Context, region string) ([]system.InstanceType, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	result := make([]system.InstanceType, 0)
+	for _, instanceType := range c.instanceTypes {
+		if region == "" || instanceType.Spec.Region == region {
+			result = append(result, instanceType)
+		}
+	}
+	return result, nil
+}
+
+func init() {
+	cloudprovider.Register(Name, func(credentials cloudprovider.Credentials) (cloudprovider.Interface, error) {
+		return CloudOf(credentials.AccessKey), nil
+	})
+}
+
+// Restored the snapshot the disk was last restored from
+func (c *Cloud) Restored(diskId string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.restores[diskId]
+}
+
+type snapshots struct{ *Cloud }
+
+func (c *snapshots) Create(_ context.Context, _, diskId, _ string) (string, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return "", err
+	}
+	if _, exist := c.disks[diskId]; !exist {
+		return "", cloudprovider.NotFound
+	}
+	id := c.nextId("snap")
+	c.snapshots[id] = diskId
+	return id, nil
+}
+
+func (c *snapshots) Ready(_ context.Context, _, id string) (bool, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return false, err
+	}
+	if _, exist := c.snapshots[id]; !exist {
+		return false, cloudprovider.NotFound
+	}
+	return true, nil
+}
+
+func (c *snapshots) Delete(_ context.Context, _, id string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if _, exist := c.snapshots[id]; !exist {
+		return cloudprovider.NotFound
+	}
+	delete(c.snapshots, id)
+	return nil
+}
+
+func (c *snapshots) Restore(_ context.Context, _, diskId, snapshotId string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if c.snapshots[snapshotId] != diskId {
+		return fmt.Errorf("snapshot %s is not taken from disk %s", snapshotId, diskId)
+	}
+	for _, attachment := range c.disks[diskId].Spec.Attachments {
+		if instance, exist := c.instances[attachment.InstanceId]; exist && instance.Spec.State != compute.Stopped {
+			return fmt.Errorf("instance %s of disk %s is not stopped", attachment.InstanceId, diskId)
+		}
+	}
+	c.restores[diskId] = snapshotId
+	return nil
+}
+
+type elasticIPs struct{ *Cloud }
+
+func (c *elasticIPs) Allocate(_ context.Context, elasticIP *networking.ElasticIP) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	elasticIP.Spec.AllocationId = c.nextId("eip")
+	if elasticIP.Spec.Address == "" {
+		elasticIP.Spec.Address = fmt.Sprintf("203.0.%d.%d", c.seq/256%256, c.seq%256)
+	}
+	elasticIP.Spec.State = networking.ElasticIPAvailable
+	c.elasticIPs[elasticIP.Spec.AllocationId] = networking.ElasticIP{Spec: elasticIP.Spec}
+	return nil
+}
+
+func (c *elasticIPs) Get(_ context.Context, _, id string) (*networking.ElasticIP, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	elasticIP, exist := c.elasticIPs[id]
+	if !exist {
+		return nil, cloudprovider.NotFound
+	}
+	return &elasticIP, nil
+}
+
+func (c *elasticIPs) Release(_ context.Context, _, id string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	elasticIP, exist := c.elasticIPs[id]
+	if !exist {
+		return cloudprovider.NotFound
+	}
+	if elasticIP.Spec.InstanceId != "" {
+		return fmt.Errorf("elastic ip %s is associated with %s", id, elasticIP.Spec.InstanceId)
+	}
+	delete(c.elasticIPs, id)
+	return nil
+}
+
+func (c *elasticIPs) Associate(_ context.Context, _, id, instanceId string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	elasticIP, exist := c.elasticIPs[id]
+	if !exist {
+		return cloudprovider.NotFound
+	}
+	if _, exist := c.instances[instanceId]; !exist {
+		return cloudprovider.NotFound
+	}
+	elasticIP.Spec.InstanceId = instanceId
+	elasticIP.Spec.State = networking.ElasticIPAssociated
+	c.elasticIPs[id] = elasticIP
+	return nil
+}
+
+func (c *elasticIPs) Disassociate(_ context.Context, _, id, instanceId string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	elasticIP, exist := c.elasticIPs[id]
+	if !exist {
+		return cloudprovider.NotFound
+	}
+	if elasticIP.Spec.InstanceId != instanceId {
+		return fmt.Errorf("elastic ip %s is not associated with %s", id, instanceId)
+	}
+	elasticIP.Spec.InstanceId = ""
+	elasticIP.Spec.State = networking.ElasticIPAvailable
+	c.elasticIPs[id] = elasticIP
+	return nil
+}
+
+// Targets the instances the load balancer forwards to
+func (c *Cloud) Targets(id string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.targets[id]...)
+}
+
+type loadBalancers struct{ *Cloud }
+
+func (c *loadBalancers) Create(_ context.Context, loadBalancer *networking.LoadBalancer, instanceIds []string, allocationId string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if err := c.checkInstances(instanceIds); err != nil {
+		return err
+	}
+	loadBalancer.Spec.LoadBalancerId = c.nextId("lb")
+	loadBalancer.Spec.Address = fmt.Sprintf("10.255.%d.%d", c.seq/256%256, c.seq%256)
+	if allocationId != "" {
+		elasticIP, exist := c.elasticIPs[allocationId]
+		if !exist {
+			return cloudprovider.NotFound
+		}
+		elasticIP.Spec.InstanceId = loadBalancer.Spec.LoadBalancerId
+		elasticIP.Spec.State = networking.ElasticIPAssociated
+		c.elasticIPs[allocationId] = elasticIP
+		loadBalancer.Spec.Address = elasticIP.Spec.Address
+	}
+	loadBalancer.Spec.State = networking.LoadBalancerActive
+	c.loadBalancers[loadBalancer.Spec.LoadBalancerId] = networking.LoadBalancer{Spec: loadBalancer.Spec}
+	c.targets[loadBalancer.Spec.LoadBalancerId] = append([]string{}, instanceIds...)
+	return nil
+}
+
+func (c *loadBalancers) checkInstances(instanceIds []string) error {
+	for _, instanceId := range instanceIds {
+		if _, exist := c.instances[instanceId]; !exist {
+			return fmt.Errorf("instance %s: %v", instanceId, cloudprovider.NotFound)
+		}
+	}
+	return nil
+}
+
+func (c *loadBalancers) Get(_ context.Context, _, id string) (*networking.LoadBalancer, error) {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return nil, err
+	}
+	loadBalancer, exist := c.loadBalancers[id]
+	if !exist {
+		return nil, cloudprovider.NotFound
+	}
+	return &loadBalancer, nil
+}
+
+func (c *loadBalancers) Update(_ context.Context, loadBalancer *networking.LoadBalancer, instanceIds []string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	current, exist := c.loadBalancers[loadBalancer.Spec.LoadBalancerId]
+	if !exist {
+		return cloudprovider.NotFound
+	}
+	if err := c.checkInstances(instanceIds); err != nil {
+		return err
+	}
+	current.Spec.Listeners = loadBalancer.Spec.Listeners
+	current.Spec.HealthCheck = loadBalancer.Spec.HealthCheck
+	c.loadBalancers[loadBalancer.Spec.LoadBalancerId] = current
+	c.targets[loadBalancer.Spec.LoadBalancerId] = append([]string{}, instanceIds...)
+	return nil
+}
+
+func (c *loadBalancers) Delete(_ context.Context, _, id string) error {
+	defer c.mu.Unlock()
+	if err := c.lock(); err != nil {
+		return err
+	}
+	if _, exist := c.loadBalancers[id]; !exist {
+		return cloudprovider.NotFound
+	}
+	for allocationId, elasticIP := range c.elasticIPs {
+		if elasticIP.Spec.InstanceId == id {
+			elasticIP.Spec.InstanceId = ""
+			elasticIP.Spec.State = networking.ElasticIPAvailable
+			c.elasticIPs[allocationId] = elasticIP
+		}
+	}
+	delete(c.loadBalancers, id)
+	delete(c.targets, id)
+	return nil
+}
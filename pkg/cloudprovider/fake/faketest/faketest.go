@@ -0,0 +1,58 @@
+// Package faketest the stage and vm fixtures of the tests driving the fake provider
+package faketest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+const (
+	// Region the region of the resources seeded by the helpers
+	Region = "region-1"
+	// Workspace the workspace of the resources seeded by the helpers
+	Workspace = "ws"
+)
+
+// NewStage a memory stage holding the fake provider, keyed by the test name so that every test drives a cloud of its own
+func NewStage(t testing.TB) (*memory.Memory, *fake.Cloud) {
+	stage := memory.NewMemory()
+	provider := &system.Provider{Metadata: core.Metadata{Name: fake.Name}}
+	provider.Spec.AccessKey = t.Name()
+	if _, err := stage.Create(common.DefaultDatabase, common.PROVIDER, provider); err != nil {
+		t.Fatal(err)
+	}
+	return stage, fake.CloudOf(t.Name())
+}
+
+// NewVM a vm of the fake provider with the status, not stored
+func NewVM(name, status string) *compute.VirtualMachine {
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: name, Namespace: fake.Name, Workspace: Workspace}}
+	vm.Spec = compute.VirtualMachineSpec{Vendor: fake.Name, RegionId: Region, Status: status}
+	return vm
+}
+
+// SeedVM store a running vm along with its instance in the cloud, settled unless the cloud has a delay
+func SeedVM(t testing.TB, stage datasource.IStorage, cloud *fake.Cloud, name string) *compute.VirtualMachine {
+	ctx := context.Background()
+	vm := NewVM(name, common.RUNNING)
+	if err := cloud.Instances().Create(ctx, vm, nil); err != nil {
+		t.Fatal(err)
+	}
+	instance, err := cloud.Instances().Get(ctx, Region, vm.Spec.InstanceId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.Spec.State = instance.Spec.State
+	if _, err := stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+		t.Fatal(err)
+	}
+	return vm
+}
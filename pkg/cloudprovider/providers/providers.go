@@ -0,0 +1,7 @@
+// Package providers link the vendor implementations into the binaries driving the clouds
+package providers
+
+import (
+	_ "github.com/ddx2x/oilmont/pkg/cloudprovider/aliyun"
+	_ "github.com/ddx2x/oilmont/pkg/cloudprovider/aws"
+)
@@ -0,0 +1,72 @@
+package cloudprovider
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+type Factory func(credentials Credentials) (Interface, error)
+
+var (
+	factoriesMutex sync.RWMutex
+	factories      = make(map[string]Factory)
+)
+
+// Register make a vendor implementation available under the provider name, called from init
+func Register(name string, factory Factory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	if _, exist := factories[name]; exist {
+		panic(fmt.Sprintf("cloud provider %s registered twice", name))
+	}
+	factories[name] = factory
+}
+
+func IsRegistered(name string) bool {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+	_, exist := factories[name]
+	return exist
+}
+
+func Registered() []string {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func New(name string, credentials Credentials) (Interface, error) {
+	factoriesMutex.RLock()
+	factory, exist := factories[name]
+	factoriesMutex.RUnlock()
+	if !exist {
+		return nil, fmt.Errorf("unknown cloud provider %s", name)
+	}
+	return factory(credentials)
+}
+
+// ForProvider build the implementation registered for the provider with the credentials stored in its system.Provider
+func ForProvider(stage datasource.IStorage, name, region string) (Interface, error) {
+	if !IsRegistered(name) {
+		return nil, fmt.Errorf("unknown cloud provider %s", name)
+	}
+	provider := &system.Provider{}
+	if err := stage.Get(common.DefaultDatabase, common.PROVIDER, name, provider, true); err != nil {
+		return nil, fmt.Errorf("get provider %s error %v", name, err)
+	}
+	return New(name, Credentials{
+		AccessKey:    provider.Spec.AccessKey,
+		AccessSecret: provider.Spec.AccessSecret,
+		Region:       region,
+	})
+}
@@ -38,6 +38,10 @@ const (
 	TABLERESOURCE = "tableresource"
 	// GVRRESOURCE
 	GVRRESOURCE = "gvrresource"
+	// SCHEDULERUN the runs of the schedules claimed by a scheduler replica
+	SCHEDULERUN = "schedulerun"
+	// USAGEWINDOW the usage windows claimed by a meter replica
+	USAGEWINDOW = "usagewindow"
 
 	CLOUDEVENT = "cloudevent"
 
@@ -52,6 +56,8 @@ const (
 	DELETE  string = "delete"
 	SYNC    string = "sync"
 	FAIL    string = "fail"
+	// PENDING applied to the vendor, which has not settled the resource yet
+	PENDING string = "pending"
 
 	// IAM 基础资源
 	ACCOUNT        TableNameType = "account"
@@ -81,8 +87,14 @@ const (
 	VSWITCH        TableNameType = "vswitch"
 	STORAGE        TableNameType = "storage"
 
+	VIRTUALMACHINESNAPSHOT TableNameType = "virtualmachinesnapshot"
+	VIRTUALMACHINERESTORE  TableNameType = "virtualmachinerestore"
+
 	// Networking
 	NETWORKINTERFACE TableNameType = "networkinterface"
+	IPALLOCATION     TableNameType = "ipallocation"
+	ELASTICIP        TableNameType = "elasticip"
+	LOADBALANCER     TableNameType = "loadbalancer"
 
 	// system 配置
 	CLUSTER       TableNameType = "cluster"
@@ -94,8 +106,17 @@ const (
 	Menu          TableNameType = "menu"
 	INSTANCETYPE  TableNameType = "instancetype"
 	SECURITYGROUP TableNameType = "securitygroup"
+	DRIFT         TableNameType = "drift"
 	THEME         TableNameType = "theme"
 	RELATION      TableNameType = "relation"
+	RESOURCEQUOTA TableNameType = "resourcequota"
+	USAGERECORD   TableNameType = "usagerecord"
+	SCHEDULE      TableNameType = "schedule"
+	STACK         TableNameType = "stack"
+	// QUOTARESERVATION the admissions in progress against the quotas
+	QUOTARESERVATION TableNameType = "quotareservation"
+	// USAGEMETER how far each usage is metered
+	USAGEMETER TableNameType = "usagemeter"
 
 	// customresource 配置
 	CUSTOMRESOURCE TableNameType = "customresource"
@@ -134,6 +155,17 @@ var resourceTableMap = map[string]TableNameType{
 	"relation":          RELATION,
 	"storage":           STORAGE,
 	"networkinterface":  NETWORKINTERFACE,
+	"ipallocation":      IPALLOCATION,
+	"elasticip":         ELASTICIP,
+	"loadbalancer":      LOADBALANCER,
+	"drift":             DRIFT,
+	"resourcequota":     RESOURCEQUOTA,
+	"usagerecord":       USAGERECORD,
+	"schedule":          SCHEDULE,
+	"stack":             STACK,
+
+	"virtualmachinesnapshot": VIRTUALMACHINESNAPSHOT,
+	"virtualmachinerestore":  VIRTUALMACHINERESTORE,
 }
 
 func GetResourceTable(ctx context.Context, stage datasource.IStorage, tenant, resource string) (DBNameType, TableNameType) {
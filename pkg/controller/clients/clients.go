@@ -31,6 +31,9 @@ type KubeClient struct {
 type Clients struct {
 	mutex       sync.Mutex
 	kubeClients map[string]*KubeClient
+	configs     map[string][]byte
+	states      map[string]*ClusterState
+	subscribers []*subscriber
 }
 
 func (kc *KubeClient) Apply(ctx context.Context, namespace string, gvr schema.GroupVersionResource, name string, unstructured *unstructured.Unstructured, forceUpdate bool) (newUnstructured *unstructured.Unstructured, isUpdate bool, err error) {
@@ -135,6 +138,38 @@ func (kc *KubeClient) ApplyVMI( vmi *kubeVirtV1.VirtualMachineInstance, forceUpd
 	return
 }
 
+func (kc *KubeClient) ApplyVirtualMachine(vm *kubeVirtV1.VirtualMachine, forceUpdate bool) (newObj *kubeVirtV1.VirtualMachine, isUpdate bool, err error) {
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		getObj, getErr := kc.
+			KubevirtCli.
+			VirtualMachine(vm.GetNamespace()).
+			Get(vm.GetName(), &metav1.GetOptions{})
+
+		if errors.IsNotFound(getErr) {
+			var createErr error
+			newObj, createErr = kc.KubevirtCli.
+				VirtualMachine(vm.GetNamespace()).
+				Create(vm)
+			return createErr
+		}
+		if getErr != nil {
+			return getErr
+		}
+
+		kc.compareVirtualMachine(vm, getObj, forceUpdate)
+		var updateErr error
+		newObj, updateErr = kc.
+			KubevirtCli.
+			VirtualMachine(vm.GetNamespace()).
+			Update(vm)
+
+		isUpdate = true
+		return updateErr
+	})
+
+	return
+}
+
 func (kc *KubeClient) compareDataVolume(obj *v1beta1.DataVolume, getObj *v1beta1.DataVolume, forceUpdate bool) {
 	if !reflect.DeepEqual(obj.TypeMeta, getObj.TypeMeta) {
 		obj.TypeMeta = getObj.TypeMeta
@@ -161,6 +196,25 @@ func (kc *KubeClient) compareVMI(obj *kubeVirtV1.VirtualMachineInstance, getObj
 	}
 }
 
+// compareVirtualMachine the run strategy is driven by the start/stop subresources, keep the one in the cluster
+func (kc *KubeClient) compareVirtualMachine(obj *kubeVirtV1.VirtualMachine, getObj *kubeVirtV1.VirtualMachine, forceUpdate bool) {
+	if !reflect.DeepEqual(obj.TypeMeta, getObj.TypeMeta) {
+		obj.TypeMeta = getObj.TypeMeta
+	}
+	if !reflect.DeepEqual(obj.ObjectMeta, getObj.ObjectMeta) {
+		obj.ObjectMeta = getObj.ObjectMeta
+	}
+	obj.Spec.Running = getObj.Spec.Running
+	obj.Spec.RunStrategy = getObj.Spec.RunStrategy
+
+	if forceUpdate {
+		if obj.Annotations == nil {
+			obj.Annotations = map[string]string{}
+		}
+		obj.Annotations["forceUpdate"] = fmt.Sprintf("%d", time.Now().Unix())
+	}
+}
+
 func (kc *KubeClient) compareObject(getObj, obj *unstructured.Unstructured, forceUpdate bool) {
 	if !reflect.DeepEqual(getObj.Object["metadata"], obj.Object["metadata"]) {
 		getObj.Object["metadata"] = kc.compareMetadataLabelsOrAnnotation(
@@ -254,6 +308,8 @@ func NewClients() *Clients {
 	return &Clients{
 		mutex:       sync.Mutex{},
 		kubeClients: make(map[string]*KubeClient),
+		configs:     make(map[string][]byte),
+		states:      make(map[string]*ClusterState),
 	}
 }
 
@@ -265,8 +321,15 @@ func (cs *Clients) AddKubeClient(name string, c *KubeClient) {
 
 func (cs *Clients) RemoveClient(c string) {
 	cs.mutex.Lock()
-	defer cs.mutex.Unlock()
+	_, exist := cs.kubeClients[c]
 	delete(cs.kubeClients, c)
+	delete(cs.configs, c)
+	delete(cs.states, c)
+	cs.mutex.Unlock()
+
+	if exist {
+		cs.publish(ClusterEvent{Name: c, Type: ClusterRemoved})
+	}
 }
 
 func (cs *Clients) GetClient(name string) (*KubeClient, error) {
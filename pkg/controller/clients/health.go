@@ -0,0 +1,378 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/version"
+)
+
+const (
+	// DefaultProbeInterval interval between two health probes of a reachable cluster
+	DefaultProbeInterval = 30 * time.Second
+
+	probeTimeout = 10 * time.Second
+	maxBackoff   = 5 * time.Minute
+)
+
+type ClusterEventType string
+
+const (
+	ClusterAdded   ClusterEventType = "added"
+	ClusterUpdated ClusterEventType = "updated"
+	ClusterRemoved ClusterEventType = "removed"
+	ClusterUp      ClusterEventType = "up"
+	ClusterDown    ClusterEventType = "down"
+)
+
+// ClusterEvent registration change or reachability transition of a cluster
+type ClusterEvent struct {
+	Name string
+	Type ClusterEventType
+}
+
+// ClusterState result of the latest health probe of a cluster
+type ClusterState struct {
+	Name          string        `json:"name"`
+	Reachable     bool          `json:"reachable"`
+	Version       string        `json:"version"`
+	LastError     string        `json:"last_error"`
+	Latency       time.Duration `json:"latency"`
+	LastProbeTime time.Time     `json:"last_probe_time"`
+
+	probed    bool
+	failures  int
+	nextProbe time.Time
+}
+
+// SetClusterConfig register the kubeconfig of a cluster, the client is only
+// rebuilt when the config differs from the one already registered
+func (cs *Clients) SetClusterConfig(name string, config []byte) error {
+	cs.mutex.Lock()
+	_, exist := cs.kubeClients[name]
+	if exist && bytes.Equal(cs.configs[name], config) {
+		cs.mutex.Unlock()
+		return nil
+	}
+	cs.mutex.Unlock()
+
+	kubeClient, err := buildClientFromJSON(name, config)
+	if err != nil {
+		return err
+	}
+
+	cs.mutex.Lock()
+	_, exist = cs.kubeClients[name]
+	cs.kubeClients[name] = kubeClient
+	cs.configs[name] = config
+	// probe the new client as soon as possible
+	if state, exist := cs.states[name]; exist {
+		state.failures, state.nextProbe = 0, time.Time{}
+	}
+	cs.mutex.Unlock()
+
+	if exist {
+		cs.publish(ClusterEvent{Name: name, Type: ClusterUpdated})
+	} else {
+		cs.publish(ClusterEvent{Name: name, Type: ClusterAdded})
+	}
+	return nil
+}
+
+// Subscribe receive cluster registration changes and up/down transitions until ctx is done, the channel is closed
+// then. A slow subscriber does not hold the prober back, the events of a cluster it has not received yet are
+// coalesced into one
+func (cs *Clients) Subscribe(ctx context.Context) <-chan ClusterEvent {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	s := newSubscriber()
+	cs.subscribers = append(cs.subscribers, s)
+	go func() {
+		s.run(ctx)
+		cs.unsubscribe(s)
+		close(s.ch)
+	}()
+	return s.ch
+}
+
+func (cs *Clients) unsubscribe(s *subscriber) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	for index := range cs.subscribers {
+		if cs.subscribers[index] == s {
+			cs.subscribers = append(cs.subscribers[:index], cs.subscribers[index+1:]...)
+			return
+		}
+	}
+}
+
+// Names registered cluster names
+func (cs *Clients) Names() []string {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	names := make([]string, 0, len(cs.kubeClients))
+	for name := range cs.kubeClients {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Reachable report whether the cluster answered its last probe,
+// a cluster that has not been probed yet is treated as reachable
+func (cs *Clients) Reachable(name string) bool {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	if _, exist := cs.kubeClients[name]; !exist {
+		return false
+	}
+	state, exist := cs.states[name]
+	if !exist || !state.probed {
+		return true
+	}
+	return state.Reachable
+}
+
+func (cs *Clients) ClusterState(name string) (ClusterState, bool) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	state, exist := cs.states[name]
+	if !exist {
+		return ClusterState{}, false
+	}
+	return *state, true
+}
+
+func (cs *Clients) ClusterStates() []ClusterState {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	states := make([]ClusterState, 0, len(cs.states))
+	for _, state := range cs.states {
+		states = append(states, *state)
+	}
+	return states
+}
+
+// StartHealthCheck probe every registered cluster each interval, clusters failing
+// the probe are retried with exponential backoff and get their client rebuilt.
+// report is called with the state after every probe.
+func (cs *Clients) StartHealthCheck(ctx context.Context, interval time.Duration, report func(state ClusterState)) {
+	if interval <= 0 {
+		interval = DefaultProbeInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			cs.probeAll(ctx, interval, report)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (cs *Clients) probeAll(ctx context.Context, interval time.Duration, report func(state ClusterState)) {
+	now := time.Now()
+	for _, name := range cs.Names() {
+		cs.mutex.Lock()
+		state, exist := cs.states[name]
+		if !exist {
+			state = &ClusterState{Name: name}
+			cs.states[name] = state
+		}
+		due := !now.Before(state.nextProbe)
+		cs.mutex.Unlock()
+
+		if !due {
+			continue
+		}
+		if result, ok := cs.probe(ctx, name, interval); ok && report != nil {
+			report(result)
+		}
+	}
+}
+
+func (cs *Clients) probe(ctx context.Context, name string, interval time.Duration) (ClusterState, bool) {
+	kubeClient, err := cs.GetClient(name)
+	if err != nil {
+		return ClusterState{}, false
+	}
+
+	start := time.Now()
+	serverVersion, probeErr := serverVersion(ctx, kubeClient)
+	latency := time.Since(start)
+
+	cs.mutex.Lock()
+	state, exist := cs.states[name]
+	if !exist {
+		// removed while probing
+		cs.mutex.Unlock()
+		return ClusterState{}, false
+	}
+	wasReachable, wasProbed := state.Reachable, state.probed
+
+	state.probed = true
+	state.Latency = latency
+	state.LastProbeTime = start
+	if probeErr != nil {
+		state.Reachable = false
+		state.LastError = probeErr.Error()
+		state.failures++
+		state.nextProbe = start.Add(backoff(interval, state.failures))
+	} else {
+		state.Reachable = true
+		state.Version = serverVersion
+		state.LastError = ""
+		state.failures = 0
+		state.nextProbe = time.Time{}
+	}
+	result := *state
+	config := cs.configs[name]
+	cs.mutex.Unlock()
+
+	// reconnect with a fresh client, the old transport may hold broken connections
+	if probeErr != nil && config != nil {
+		if kubeClient, err := buildClientFromJSON(name, config); err == nil {
+			cs.mutex.Lock()
+			if _, exist := cs.kubeClients[name]; exist {
+				cs.kubeClients[name] = kubeClient
+			}
+			cs.mutex.Unlock()
+		}
+	}
+
+	switch {
+	case result.Reachable && (!wasProbed || !wasReachable):
+		cs.publish(ClusterEvent{Name: name, Type: ClusterUp})
+	case !result.Reachable && (!wasProbed || wasReachable):
+		cs.publish(ClusterEvent{Name: name, Type: ClusterDown})
+	}
+
+	return result, true
+}
+
+// publish queue event to every subscriber, it never waits for them
+func (cs *Clients) publish(event ClusterEvent) {
+	cs.mutex.Lock()
+	subscribers := make([]*subscriber, len(cs.subscribers))
+	copy(subscribers, cs.subscribers)
+	cs.mutex.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber.push(event)
+	}
+}
+
+// subscriber the events waiting for a subscriber, at most one per cluster. They are handed over in order by a
+// goroutine of its own, so that pushing an event never blocks
+type subscriber struct {
+	ch     chan ClusterEvent
+	signal chan struct{}
+
+	mutex   sync.Mutex
+	pending []ClusterEvent
+}
+
+func newSubscriber() *subscriber {
+	return &subscriber{ch: make(chan ClusterEvent), signal: make(chan struct{}, 1)}
+}
+
+func (s *subscriber) push(event ClusterEvent) {
+	s.mutex.Lock()
+	coalesced := false
+	for index := range s.pending {
+		if s.pending[index].Name == event.Name {
+			s.pending[index] = coalesce(s.pending[index], event)
+			coalesced = true
+			break
+		}
+	}
+	if !coalesced {
+		s.pending = append(s.pending, event)
+	}
+	s.mutex.Unlock()
+
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+// run hand the pending events over until ctx is done
+func (s *subscriber) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.signal:
+		}
+		for {
+			s.mutex.Lock()
+			if len(s.pending) == 0 {
+				s.mutex.Unlock()
+				break
+			}
+			event := s.pending[0]
+			s.pending = s.pending[1:]
+			s.mutex.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case s.ch <- event:
+			}
+		}
+	}
+}
+
+// coalesce the event received in place of pending and next of the same cluster, the latest one except that the
+// watches through a client rebuilt or removed meanwhile must still be started again, which an update does
+func coalesce(pending, next ClusterEvent) ClusterEvent {
+	if (pending.Type == ClusterUpdated || pending.Type == ClusterRemoved) && (next.Type == ClusterUp || next.Type == ClusterAdded) {
+		return ClusterEvent{Name: next.Name, Type: ClusterUpdated}
+	}
+	return next
+}
+
+func backoff(interval time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 1; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+func serverVersion(ctx context.Context, kubeClient *KubeClient) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	body, err := kubeClient.ClientSet.Discovery().RESTClient().
+		Get().
+		AbsPath("/version").
+		Do(ctx).
+		Raw()
+	if err != nil {
+		return "", err
+	}
+
+	info := &version.Info{}
+	if err := json.Unmarshal(body, info); err != nil {
+		return "", err
+	}
+	return info.GitVersion, nil
+}
+
+func buildClientFromJSON(name string, config []byte) (*KubeClient, error) {
+	cfg, err := JSONToConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return BuildClient(name, *cfg)
+}
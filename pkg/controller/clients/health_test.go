@@ -0,0 +1,74 @@
+package clients
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPublishCoalesce(t *testing.T) {
+	cs := NewClients()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := cs.Subscribe(ctx)
+
+	// nobody receives meanwhile, publishing must not wait
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			cs.publish(ClusterEvent{Name: "a", Type: ClusterUp})
+			cs.publish(ClusterEvent{Name: "a", Type: ClusterDown})
+		}
+		cs.publish(ClusterEvent{Name: "b", Type: ClusterUpdated})
+		cs.publish(ClusterEvent{Name: "b", Type: ClusterUp})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected publish not to block on a slow subscriber")
+	}
+
+	received := make(map[string]ClusterEventType)
+	count := 0
+	for {
+		select {
+		case event := <-events:
+			received[event.Name] = event.Type
+			count++
+			continue
+		case <-time.After(100 * time.Millisecond):
+		}
+		break
+	}
+	// the first event may already be on its way, the others are coalesced per cluster
+	if count > 3 || received["a"] != ClusterDown || received["b"] != ClusterUpdated {
+		t.Fatalf("expected the events coalesced per cluster, got %d events %v", count, received)
+	}
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	cs := NewClients()
+	ctx, cancel := context.WithCancel(context.Background())
+	events := cs.Subscribe(ctx)
+	cs.publish(ClusterEvent{Name: "a", Type: ClusterUp})
+	cancel()
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if ok {
+				continue
+			}
+		case <-deadline:
+			t.Fatal("expected the events closed once the subscriber is cancelled")
+		}
+		break
+	}
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	if len(cs.subscribers) != 0 {
+		t.Fatalf("expected the subscriber removed, got %d", len(cs.subscribers))
+	}
+}
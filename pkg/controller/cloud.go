@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+)
+
+// CloudId the vendor id of the object of table named ref in the workspace. The cloud resources reference
+// each other by stage name or by vendor id, ref is returned unchanged when it names no object with an id
+func CloudId(stage datasource.IStorage, table, workspace, ref string) string {
+	if ref == "" {
+		return ref
+	}
+	filter := map[string]interface{}{common.FilterName: ref}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	object := &struct {
+		Spec struct {
+			ID string `json:"id" bson:"id"`
+		} `json:"spec" bson:"spec"`
+	}{}
+	if err := stage.GetByFilter(common.DefaultDatabase, table, object, filter, true); err != nil || object.Spec.ID == "" {
+		return ref
+	}
+	return object.Spec.ID
+}
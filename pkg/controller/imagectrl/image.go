@@ -13,9 +13,11 @@ import (
 var _ controller.Handler = &ImageCtrl{}
 
 var (
-	imageGvr = schema.GroupVersionResource{Group: "github.com/ddx2x", Version: "v1", Resource: "images"}
+	imageGvr      = schema.GroupVersionResource{Group: "github.com/ddx2x", Version: "v1", Resource: "images"}
+	dataVolumeGvr = schema.GroupVersionResource{Group: "cdi.kubevirt.io", Version: "v1beta1", Resource: "datavolumes"}
 )
 
+// ImageCtrl mirror the images of the cloud providers and import the catalogue images into golden volumes of every cluster
 type ImageCtrl struct {
 	stage datasource.IStorage
 	cs    *clients.Clients
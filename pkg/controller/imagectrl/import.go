@@ -0,0 +1,256 @@
+package imagectrl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+// toDataVolume the golden volume importing the image into compute.ImageNamespace.
+// CDI of this version does not verify checksums, the checksum is recorded on the volume
+// so the import knows which content it holds and is redone when the image checksum changes
+func toDataVolume(image *compute.Image) (*v1beta1.DataVolume, error) {
+	size, err := resource.ParseQuantity(image.Spec.Size)
+	if err != nil {
+		return nil, fmt.Errorf("image %s size %q error %v", image.GetName(), image.Spec.Size, err)
+	}
+
+	source := &v1beta1.DataVolumeSource{}
+	switch image.Spec.SourceType {
+	case compute.ImageSourceRegistry:
+		source.Registry = &v1beta1.DataVolumeSourceRegistry{URL: image.Spec.Source}
+	case compute.ImageSourceHTTP:
+		source.HTTP = &v1beta1.DataVolumeSourceHTTP{URL: image.Spec.Source}
+	default:
+		return nil, fmt.Errorf("image %s has unknown source type %q", image.GetName(), image.Spec.SourceType)
+	}
+
+	return &v1beta1.DataVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        image.VolumeName(),
+			Namespace:   compute.ImageNamespace,
+			Labels:      map[string]string{compute.ImageNameLabel: image.GetName(), compute.ImageWorkspaceLabel: image.GetWorkspace()},
+			Annotations: map[string]string{compute.ImageChecksumAnnotation: image.Spec.Checksum},
+		},
+		Spec: v1beta1.DataVolumeSpec{
+			Source: source,
+			PVC: &corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+				},
+			},
+		},
+	}, nil
+}
+
+// importInto create the golden volume of the image in cluster, the south watch of the volume reports its progress
+func (V *ImageCtrl) importInto(cluster string, image *compute.Image) {
+	flog := V.flog.WithField("func", "importInto").WithField("cluster", cluster)
+
+	dataVolume, err := toDataVolume(image)
+	if err == nil {
+		err = V.applyDataVolume(cluster, dataVolume)
+	}
+	if err != nil {
+		flog.Warnf("import image %s error %v", image.GetName(), err)
+		V.changeImport(image, compute.ImageImport{Cluster: cluster, Phase: string(v1beta1.Failed), Checksum: image.Spec.Checksum, Message: err.Error()}, err)
+		return
+	}
+
+	flog.Infof("import image %s into %s/%s", image.GetName(), dataVolume.Namespace, dataVolume.Name)
+	V.changeImport(image, compute.ImageImport{Cluster: cluster, Phase: string(v1beta1.Pending), Checksum: image.Spec.Checksum}, nil)
+}
+
+func (V *ImageCtrl) applyDataVolume(cluster string, dataVolume *v1beta1.DataVolume) error {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
+		return err
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: dataVolume.Namespace}}
+	_, err = client.KubevirtCli.CoreV1().Namespaces().Create(context.Background(), namespace, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("create namespace %s error %v", dataVolume.Namespace, err)
+	}
+	if _, _, err = client.ApplyDataVolume(context.Background(), dataVolume.Namespace, dataVolume.Name, dataVolume, false); err != nil {
+		return fmt.Errorf("apply dataVolume %s error %v", dataVolume.Name, err)
+	}
+	return nil
+}
+
+// reimport replace the golden volume of cluster, the source of a dataVolume can not be changed in place
+func (V *ImageCtrl) reimport(cluster string, image *compute.Image) {
+	if err := V.deleteDataVolume(cluster, image); err != nil {
+		V.flog.WithField("func", "reimport").Warnf("delete dataVolume of image %s in %s error %v", image.GetName(), cluster, err)
+		return
+	}
+	V.importInto(cluster, image)
+}
+
+func (V *ImageCtrl) deleteFrom(cluster string, image *compute.Image) {
+	flog := V.flog.WithField("func", "deleteFrom").WithField("cluster", cluster)
+	if err := V.deleteDataVolume(cluster, image); err != nil {
+		flog.Warnf("delete dataVolume of image %s error %v", image.GetName(), err)
+		return
+	}
+	flog.Infof("delete golden volume of image %s", image.GetName())
+}
+
+func (V *ImageCtrl) deleteDataVolume(cluster string, image *compute.Image) error {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
+		return err
+	}
+	err = client.KubevirtCli.CdiClient().CdiV1beta1().DataVolumes(compute.ImageNamespace).
+		Delete(context.Background(), image.VolumeName(), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// importPending import into cluster the catalogue images it has no current golden volume of,
+// run whenever the cluster is watched so a cluster joining later receives the catalogue too
+func (V *ImageCtrl) importPending(cluster string) {
+	flog := V.flog.WithField("func", "importPending").WithField("cluster", cluster)
+
+	images := make([]compute.Image, 0)
+	if err := V.stage.ListToObject(common.DefaultDatabase, common.IMAGE, map[string]interface{}{}, &images, true); err != nil {
+		flog.Warnf("list image error %v", err)
+		return
+	}
+	for index := range images {
+		image := &images[index]
+		if !image.IsCatalogue() {
+			continue
+		}
+		imported, exist := image.ImportOf(cluster)
+		switch {
+		case !exist:
+			V.importInto(cluster, image)
+		case imported.Checksum != image.Spec.Checksum:
+			V.reimport(cluster, image)
+		}
+	}
+}
+
+// dataVolumeToStage record the phase and progress of a golden volume on the import of its image
+func (V *ImageCtrl) dataVolumeToStage(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "dataVolumeToStage").WithField("cluster", cluster)
+
+	dataVolume := &v1beta1.DataVolume{}
+	if err := fromObject(obj, dataVolume); err != nil {
+		flog.Warnf("unmarshal dataVolume error %v", err)
+		return
+	}
+	image, err := getImage(V.stage, dataVolume.Labels[compute.ImageWorkspaceLabel], dataVolume.Labels[compute.ImageNameLabel])
+	if err != nil {
+		return
+	}
+
+	imported := compute.ImageImport{
+		Cluster:  cluster,
+		Phase:    string(dataVolume.Status.Phase),
+		Progress: string(dataVolume.Status.Progress),
+		Checksum: dataVolume.Annotations[compute.ImageChecksumAnnotation],
+	}
+	var importErr error
+	if dataVolume.Status.Phase == v1beta1.Failed {
+		importErr = fmt.Errorf("import into %s failed", cluster)
+		for _, condition := range dataVolume.Status.Conditions {
+			if condition.Message != "" {
+				importErr = fmt.Errorf("import into %s failed: %s", cluster, condition.Message)
+			}
+		}
+		imported.Message = importErr.Error()
+	}
+
+	if current, exist := image.ImportOf(cluster); exist && *current == imported {
+		return
+	}
+	V.changeImport(image, imported, importErr)
+}
+
+// imagePaths the spec fields of an image the controller writes back with the progress, they bump no generation
+var imagePaths = append([]string{"spec.imports", "spec.state"}, datasource.ProgressPaths...)
+
+// changeImport record the import of the image into a cluster and the state of the image over every cluster
+func (V *ImageCtrl) changeImport(image *compute.Image, imported compute.ImageImport, err error) {
+	flog := V.flog.WithField("func", "changeImport")
+
+	if current, exist := image.ImportOf(imported.Cluster); exist {
+		*current = imported
+	} else {
+		image.Spec.Imports = append(image.Spec.Imports, imported)
+	}
+	image.Spec.State = imageState(image)
+	switch image.Spec.State {
+	case compute.ImageStateReady:
+		image.Spec.Status, image.Spec.Message = common.RUNNING, "success"
+	case compute.ImageStateFailed:
+		image.Spec.Status, image.Spec.Message = common.FAIL, imported.Message
+	default:
+		image.Spec.Status, image.Spec.Message = common.INIT, ""
+	}
+
+	if _, applyErr := V.stage.ApplyStatus(common.DefaultDatabase, common.IMAGE, image.GetName(), image, imagePaths...); applyErr != nil {
+		flog.Warnf("change image %s import error %v", image.GetName(), applyErr)
+		return
+	}
+	if reportErr := controller.ReportObserved(V.stage, common.IMAGE, image, controller.SyncedCondition(err)); reportErr != nil {
+		flog.Warnf("report image %s synced condition error %v", image.GetName(), reportErr)
+	}
+	if reportErr := controller.ReportCondition(V.stage, common.IMAGE, image, imageReadyCondition(image)); reportErr != nil {
+		flog.Warnf("report image %s ready condition error %v", image.GetName(), reportErr)
+	}
+}
+
+// imageState failed when an import failed, ready once every import succeeded
+func imageState(image *compute.Image) string {
+	if len(image.Spec.Imports) == 0 {
+		return compute.ImageStateImporting
+	}
+	state := compute.ImageStateReady
+	for _, imported := range image.Spec.Imports {
+		switch {
+		case imported.Phase == string(v1beta1.Failed):
+			return compute.ImageStateFailed
+		case imported.Phase != string(v1beta1.Succeeded) || imported.Checksum != image.Spec.Checksum:
+			state = compute.ImageStateImporting
+		}
+	}
+	return state
+}
+
+func imageReadyCondition(image *compute.Image) core.Condition {
+	switch image.Spec.State {
+	case compute.ImageStateReady:
+		return core.NewCondition(core.ConditionReady, core.ConditionTrue, controller.ReasonRunning, image.Spec.Message)
+	case compute.ImageStateFailed:
+		return core.NewCondition(core.ConditionReady, core.ConditionFalse, controller.ReasonFailed, image.Spec.Message)
+	}
+	return core.NewCondition(core.ConditionReady, core.ConditionUnknown, controller.ReasonPending, image.Spec.Message)
+}
+
+func getImage(stage datasource.IStorage, workspace, name string) (*compute.Image, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	image := &compute.Image{}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.IMAGE, image, filter, true); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
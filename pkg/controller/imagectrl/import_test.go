@@ -0,0 +1,110 @@
+package imagectrl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+func newImage() *compute.Image {
+	image := &compute.Image{Metadata: core.Metadata{Name: "fedora", Workspace: "ws"}}
+	image.Spec = compute.ImageSpec{SourceType: compute.ImageSourceRegistry, Source: "docker://fedora:cloud", Size: "5Gi", Checksum: "sha256:ab"}
+	return image
+}
+
+func TestToDataVolume(t *testing.T) {
+	image := newImage()
+	dataVolume, err := toDataVolume(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dataVolume.Namespace != compute.ImageNamespace || dataVolume.Name != "image-ws-fedora" {
+		t.Fatalf("unexpected golden volume %s/%s", dataVolume.Namespace, dataVolume.Name)
+	}
+	if dataVolume.Spec.Source.Registry == nil || dataVolume.Spec.Source.Registry.URL != image.Spec.Source {
+		t.Fatalf("expected registry source, got %+v", dataVolume.Spec.Source)
+	}
+	if dataVolume.Labels[compute.ImageNameLabel] != "fedora" || dataVolume.Annotations[compute.ImageChecksumAnnotation] != "sha256:ab" {
+		t.Fatalf("unexpected labels %v annotations %v", dataVolume.Labels, dataVolume.Annotations)
+	}
+
+	image.Spec.SourceType, image.Spec.Source = compute.ImageSourceHTTP, "https://example.com/fedora.qcow2"
+	if dataVolume, _ = toDataVolume(image); dataVolume.Spec.Source.HTTP == nil {
+		t.Fatalf("expected http source, got %+v", dataVolume.Spec.Source)
+	}
+
+	image.Spec.Size = "five"
+	if _, err := toDataVolume(image); err == nil {
+		t.Fatal("expected invalid size error")
+	}
+}
+
+func TestImageState(t *testing.T) {
+	image := newImage()
+	if state := imageState(image); state != compute.ImageStateImporting {
+		t.Fatalf("expected importing without import, got %s", state)
+	}
+	image.Spec.Imports = []compute.ImageImport{
+		{Cluster: "a", Phase: string(v1beta1.Succeeded), Checksum: "sha256:ab"},
+		{Cluster: "b", Phase: string(v1beta1.ImportInProgress), Checksum: "sha256:ab"},
+	}
+	if state := imageState(image); state != compute.ImageStateImporting {
+		t.Fatalf("expected importing, got %s", state)
+	}
+	image.Spec.Imports[1].Phase = string(v1beta1.Succeeded)
+	if state := imageState(image); state != compute.ImageStateReady {
+		t.Fatalf("expected ready, got %s", state)
+	}
+	image.Spec.Checksum = "sha256:cd"
+	if state := imageState(image); state != compute.ImageStateImporting {
+		t.Fatalf("expected importing after checksum change, got %s", state)
+	}
+	image.Spec.Imports[0].Phase = string(v1beta1.Failed)
+	if state := imageState(image); state != compute.ImageStateFailed {
+		t.Fatalf("expected failed, got %s", state)
+	}
+}
+
+func TestDataVolumeToStage(t *testing.T) {
+	stage := memory.NewMemory()
+	V := NewImageCtrl(context.Background()).(*ImageCtrl)
+	V.Set(nil, stage)
+
+	image := newImage()
+	image.Spec.Status = common.INIT
+	if _, err := stage.Create(common.DefaultDatabase, common.IMAGE, image); err != nil {
+		t.Fatal(err)
+	}
+
+	dataVolume, _ := toDataVolume(image)
+	dataVolume.TypeMeta = metav1.TypeMeta{Kind: "DataVolume", APIVersion: "cdi.kubevirt.io/v1beta1"}
+	dataVolume.Status = v1beta1.DataVolumeStatus{Phase: v1beta1.ImportInProgress, Progress: "42.0%"}
+	if !isDataVolume(dataVolume) {
+		t.Fatal("expected a dataVolume")
+	}
+	V.SouthOnUpdate("cluster-a", dataVolume)
+
+	current, err := getImage(stage, "ws", "fedora")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, exist := current.ImportOf("cluster-a")
+	if !exist || imported.Progress != "42.0%" || imported.Checksum != "sha256:ab" || current.Spec.State != compute.ImageStateImporting {
+		t.Fatalf("unexpected import %+v state %s", imported, current.Spec.State)
+	}
+
+	dataVolume.Status = v1beta1.DataVolumeStatus{Phase: v1beta1.Succeeded, Progress: "100.0%"}
+	V.SouthOnUpdate("cluster-a", dataVolume)
+	if current, _ = getImage(stage, "ws", "fedora"); current.Spec.State != compute.ImageStateReady || current.Spec.Status != common.RUNNING {
+		t.Fatalf("expected ready image, got state %s status %s", current.Spec.State, current.Spec.Status)
+	}
+	if len(current.Spec.Imports) != 1 {
+		t.Fatalf("expected a single import, got %+v", current.Spec.Imports)
+	}
+}
@@ -2,21 +2,62 @@ package imagectrl
 
 import (
 	"context"
+
+	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
 )
 
-func (V ImageCtrl) NorthOnAdd(obj core.IObject) {
-	panic("implement me")
+// NorthOnAdd import a catalogue image into every cluster, the images of the cloud providers are left alone
+func (V *ImageCtrl) NorthOnAdd(obj core.IObject) {
+	flog := V.flog.WithField("func", "NorthOnAdd")
+
+	image := &compute.Image{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, image); err != nil {
+		flog.Warnf("unstructured obj error %v", err)
+		return
+	}
+	if !image.IsCatalogue() || image.Spec.Status != common.INIT {
+		return
+	}
+	for _, cluster := range V.cs.Names() {
+		V.importInto(cluster, image)
+	}
 }
 
-func (V ImageCtrl) NorthOnUpdate(obj core.IObject) {
-	panic("implement me")
+// NorthOnUpdate import the image again into the clusters whose golden volume was made from another checksum
+func (V *ImageCtrl) NorthOnUpdate(obj core.IObject) {
+	flog := V.flog.WithField("func", "NorthOnUpdate")
+
+	image := &compute.Image{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, image); err != nil {
+		flog.Warnf("unstructured obj error %v", err)
+		return
+	}
+	if !image.IsCatalogue() {
+		return
+	}
+	for _, imported := range image.Spec.Imports {
+		if imported.Checksum != image.Spec.Checksum {
+			V.reimport(imported.Cluster, image)
+		}
+	}
 }
 
-func (V ImageCtrl) NorthOnDelete(obj core.IObject) {
-	panic("implement me")
+func (V *ImageCtrl) NorthOnDelete(obj core.IObject) {
+	image := &compute.Image{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, image); err != nil {
+		return
+	}
+	if !image.IsCatalogue() {
+		return
+	}
+	for _, imported := range image.Spec.Imports {
+		V.deleteFrom(imported.Cluster, image)
+	}
 }
 
-func (V ImageCtrl) NorthEventCh(ctx context.Context) (<-chan core.Event, error) {
-	return nil, nil
+func (V *ImageCtrl) NorthEventCh(ctx context.Context) (<-chan core.Event, error) {
+	return V.stage.WatchEvent(ctx, common.DefaultDatabase, common.IMAGE, "0")
 }
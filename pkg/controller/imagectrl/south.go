@@ -6,7 +6,6 @@ import (
 	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/resource/compute"
-	"github.com/ddx2x/oilmont/pkg/resource/system"
 	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -14,46 +13,72 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 )
 
-func (V *ImageCtrl) SouthOnAdd(obj runtime.Object) {
+func (V *ImageCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
+	if isDataVolume(obj) {
+		V.dataVolumeToStage(cluster, obj)
+		return
+	}
 	V.applyImageToStage(obj)
 
 }
 
-func (V *ImageCtrl) SouthOnUpdate(obj runtime.Object) {
+func (V *ImageCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
+	if isDataVolume(obj) {
+		V.dataVolumeToStage(cluster, obj)
+		return
+	}
 	V.applyImageToStage(obj)
 
 }
 
-func (V *ImageCtrl) SouthOnDelete(obj runtime.Object) {
+// SouthOnDelete a golden volume removed from the cluster is imported again on the next watch of the cluster
+func (V *ImageCtrl) SouthOnDelete(cluster string, obj runtime.Object) {
+	if isDataVolume(obj) {
+		return
+	}
 	V.deleteImageToStage(obj)
 }
 
-func (V *ImageCtrl) SouthEventChs(ctx context.Context) ([]<-chan watch.Event, error) {
+func (V *ImageCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
 	flog := V.flog.WithField("func", "SouthEventChs")
 	flog.Info("region start watch South Event")
 
-	clusters := make([]system.Cluster, 0)
-	if err := V.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
 		return nil, err
 	}
 
 	channels := make([]<-chan watch.Event, 0)
-	for _, cluster := range clusters {
-		client, err := V.cs.GetClient(cluster.GetName())
-		if err != nil {
-			return nil, err
-		}
+	watchInterface, err := client.Interface.Resource(imageGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	channels = append(channels, watchInterface.ResultChan())
 
-		watchInterface, err := client.Interface.Resource(imageGvr).Watch(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		channels = append(channels, watchInterface.ResultChan())
+	dataVolumeWatch, err := client.Interface.Resource(dataVolumeGvr).Namespace(compute.ImageNamespace).
+		Watch(ctx, metav1.ListOptions{LabelSelector: compute.ImageNameLabel})
+	if err != nil {
+		return nil, err
 	}
+	channels = append(channels, dataVolumeWatch.ResultChan())
+
+	V.importPending(cluster)
 
 	return channels, nil
 }
 
+func isDataVolume(obj runtime.Object) bool {
+	return obj.GetObjectKind().GroupVersionKind().Kind == "DataVolume"
+}
+
+func fromObject(obj runtime.Object, result interface{}) error {
+	object := &unstructured.Unstructured{}
+	if err := utilsObj.Unmarshal(object, obj); err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, result)
+}
+
 func (V *ImageCtrl) applyImageToStage(obj runtime.Object) {
 	var os string
 	flog := V.flog.WithField("func", "applyImageToStage")
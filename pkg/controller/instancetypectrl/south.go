@@ -14,9 +14,9 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 )
 
-func (V *InstanceTypeCtrl) SouthOnAdd(obj runtime.Object) {
+func (V *InstanceTypeCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
 	var name string
-	flog := V.flog.WithField("func", "SouthOnAdd")
+	flog := V.flog.WithField("func", "SouthOnAdd").WithField("cluster", cluster)
 
 	instanceType := &unstructured.Unstructured{}
 	err := utilsObj.Unmarshal(instanceType, obj)
@@ -86,8 +86,8 @@ func (V *InstanceTypeCtrl) SouthOnAdd(obj runtime.Object) {
 	}
 }
 
-func (V *InstanceTypeCtrl) SouthOnUpdate(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnUpdate")
+func (V *InstanceTypeCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnUpdate").WithField("cluster", cluster)
 
 	instanceType := &unstructured.Unstructured{}
 	err := utilsObj.Unmarshal(instanceType, obj)
@@ -132,7 +132,7 @@ func (V *InstanceTypeCtrl) SouthOnUpdate(obj runtime.Object) {
 	flog.Infof("update new instanceType %s", instanceTypeObj.GetName())
 }
 
-func (V *InstanceTypeCtrl) SouthOnDelete(obj runtime.Object) {
+func (V *InstanceTypeCtrl) SouthOnDelete(cluster string, obj runtime.Object) {
 	var name string
 
 	flog := V.flog.WithField("func", "deleteImageToStage")
@@ -162,28 +162,21 @@ func (V *InstanceTypeCtrl) SouthOnDelete(obj runtime.Object) {
 	flog.Infof("delete a instanceType %s from stage", instanceType.GetName())
 }
 
-func (V *InstanceTypeCtrl) SouthEventChs(ctx context.Context) ([]<-chan watch.Event, error) {
+func (V *InstanceTypeCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
 	flog := V.flog.WithField("func", "SouthEventChs")
 	flog.Info("instanceType ctrl start watch South Event")
 
-	clusters := make([]system.Cluster, 0)
-	if err := V.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
 		return nil, err
 	}
 
 	channels := make([]<-chan watch.Event, 0)
-	for _, cluster := range clusters {
-		client, err := V.cs.GetClient(cluster.GetName())
-		if err != nil {
-			return nil, err
-		}
-
-		watchInterface, err := client.Interface.Resource(instanceTypeGvr).Watch(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		channels = append(channels, watchInterface.ResultChan())
+	watchInterface, err := client.Interface.Resource(instanceTypeGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	channels = append(channels, watchInterface.ResultChan())
 
 	return channels, nil
 }
@@ -3,15 +3,20 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/controller/clients"
 	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/metrics"
 	"github.com/ddx2x/oilmont/pkg/resource/system"
 	objUtils "github.com/ddx2x/oilmont/pkg/utils/obj"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Controller interface {
@@ -25,11 +30,13 @@ type NorthHandler interface {
 	NorthEventCh(ctx context.Context) (<-chan core.Event, error)
 }
 
+// SouthHandler cluster is the name of the cluster the south object lives in
 type SouthHandler interface {
-	SouthOnAdd(obj runtime.Object)
-	SouthOnUpdate(obj runtime.Object)
-	SouthOnDelete(obj runtime.Object)
-	SouthEventChs(ctx context.Context) ([]<-chan watch.Event, error)
+	SouthOnAdd(cluster string, obj runtime.Object)
+	SouthOnUpdate(cluster string, obj runtime.Object)
+	SouthOnDelete(cluster string, obj runtime.Object)
+	// SouthEventChs watch the south resources of a single cluster, the watches end with ctx
+	SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error)
 }
 
 type InjectClient interface {
@@ -46,6 +53,7 @@ type Controllers struct {
 	stage              datasource.IStorage
 	clients            *clients.Clients
 	backendControllers []*BackendController
+	resync             ResyncConfig
 }
 
 func NewControllers(stage datasource.IStorage) *Controllers {
@@ -54,6 +62,7 @@ func NewControllers(stage datasource.IStorage) *Controllers {
 		stage:              stage,
 		clients:            cs,
 		backendControllers: make([]*BackendController, 0),
+		resync:             ResyncConfig{Interval: DefaultResyncInterval},
 	}
 }
 
@@ -85,45 +94,73 @@ func (c *Controllers) Run(ctx context.Context) chan error {
 
 	for _, controller := range c.backendControllers {
 		//controller.Set(c.clients, c.stage)
+		controller.resync = c.resync
 		go controller.Start(ctx, errChan)
 	}
 	return errChan
 }
 
+// queueSize events buffered between the watches and the handler
+const queueSize = 1024
+
 type BackendController struct {
 	stage   datasource.IStorage
 	clients *clients.Clients
 	Handler
+
+	name       string
+	northQueue chan core.Event
+	southQueue chan southEvent
+
+	mutex        sync.Mutex
+	southWatches map[string]*southWatch
+
+	resync ResyncConfig
+}
+
+type southEvent struct {
+	cluster string
+	event   watch.Event
 }
 
 func NewBackendController(stage datasource.IStorage, cs *clients.Clients, h Handler) (*BackendController, error) {
 	//cs := clients.NewClients()
 	h.Set(cs, stage)
 	return &BackendController{
-		stage:   stage,
-		clients: cs,
-		Handler: h,
+		stage:        stage,
+		clients:      cs,
+		Handler:      h,
+		name:         strings.TrimPrefix(fmt.Sprintf("%T", h), "*"),
+		northQueue:   make(chan core.Event, queueSize),
+		southQueue:   make(chan southEvent, queueSize),
+		southWatches: make(map[string]*southWatch),
+		resync:       ResyncConfig{Interval: DefaultResyncInterval},
 	}, nil
 }
 
 func (bc *BackendController) northHandle(event core.Event) {
+	defer metrics.ObserveReconcile(bc.name, metrics.North, string(event.Type), time.Now())
 	switch event.Type {
 	case core.ADDED:
 		bc.NorthOnAdd(event.Object)
 	case core.MODIFIED:
+		if Observed(event.Object) {
+			return
+		}
 		bc.NorthOnUpdate(event.Object)
 	case core.DELETED:
 		bc.NorthOnDelete(event.Object)
 	}
 }
-func (bc *BackendController) southHandle(event watch.Event) {
+func (bc *BackendController) southHandle(cluster string, event watch.Event) {
+	defer metrics.ObserveReconcile(bc.name, metrics.South, string(event.Type), time.Now())
 	switch event.Type {
 	case watch.Added:
-		bc.SouthOnAdd(event.Object)
+		bc.SouthOnAdd(cluster, event.Object)
 	case watch.Modified:
-		bc.SouthOnUpdate(event.Object)
+		bc.SouthOnUpdate(cluster, event.Object)
 	case watch.Deleted:
-		bc.SouthOnDelete(event.Object)
+		bc.SouthOnDelete(cluster, event.Object)
 	}
 }
 
@@ -140,22 +177,7 @@ func (c *Controllers) SetupCluster(ctx context.Context, ec <-chan core.Event) er
 	}
 
 	for _, cluster := range clusters {
-		bs, err := json.Marshal(cluster.Spec.Config)
-		if err != nil {
-			log.G(ctx).Warnf("backend controller read cluster config marshal error:%s err")
-		}
-
-		cfg, err := clients.JSONToConfig(bs)
-		if err != nil {
-			log.G(ctx).Warnf("backend controller create kubeclient error:%s err")
-			continue
-		}
-		kubecli, err := clients.BuildClient(cluster.GetName(), *cfg)
-		if err != nil {
-			log.G(ctx).Warnf("backend controller create build client error:%s err")
-			continue
-		}
-		c.clients.AddKubeClient(cluster.GetName(), kubecli)
+		c.setClusterConfig(ctx, cluster.GetName(), cluster.Spec.Config)
 	}
 	go func() {
 		for {
@@ -167,29 +189,12 @@ func (c *Controllers) SetupCluster(ctx context.Context, ec <-chan core.Event) er
 				cluster := e.Object.(*core.DefaultObject)
 				switch e.Type {
 				case core.ADDED, core.MODIFIED:
-					bs, err := json.Marshal(cluster.Spec)
-					if err != nil {
-						log.G(ctx).Warnf("backend controller read cluster config marshal error:%s err")
-					}
-
-					data := map[string]interface{}{}
-					err = json.Unmarshal(bs, &data)
-					if err != nil {
-						log.G(ctx).Warnf("backend controller read cluster config marshal error:%s err")
-					}
-					bs, err = json.Marshal(data["config"])
-
-					cfg, err := clients.JSONToConfig(bs)
-					if err != nil {
-						log.G(ctx).Warnf("backend controller create kubeclient error:%s err")
-						continue
-					}
-					kubecli, err := clients.BuildClient(cluster.GetName(), *cfg)
-					if err != nil {
-						log.G(ctx).Warnf("backend controller create build client error:%s err")
+					spec := system.ClusterSpec{}
+					if err := objUtils.UnstructuredObjectToInstanceObj(cluster.Spec, &spec); err != nil {
+						log.G(ctx).Warnf("backend controller read cluster %s spec error: %v", cluster.GetName(), err)
 						continue
 					}
-					c.clients.AddKubeClient(cluster.GetName(), kubecli)
+					c.setClusterConfig(ctx, cluster.GetName(), spec.Config)
 				case core.DELETED:
 					c.clients.RemoveClient(cluster.GetName())
 				}
@@ -197,65 +202,208 @@ func (c *Controllers) SetupCluster(ctx context.Context, ec <-chan core.Event) er
 		}
 	}()
 
+	c.clients.StartHealthCheck(ctx, clients.DefaultProbeInterval, func(state clients.ClusterState) {
+		c.reportClusterState(ctx, state)
+	})
+
 	return nil
 }
 
-func (bc *BackendController) Start(ctx context.Context, errChan chan error) {
-
-	northEvent, err := bc.NorthEventCh(ctx)
+// setClusterConfig (re)build the client of cluster, it is a no-op when the kubeconfig did not change
+func (c *Controllers) setClusterConfig(ctx context.Context, name string, config interface{}) {
+	bs, err := json.Marshal(config)
 	if err != nil {
-		errChan <- err
+		log.G(ctx).Warnf("backend controller read cluster %s config marshal error: %v", name, err)
 		return
 	}
-	southEvents, err := bc.SouthEventChs(ctx)
+	if err := c.clients.SetClusterConfig(name, bs); err != nil {
+		log.G(ctx).Warnf("backend controller build cluster %s client error: %v", name, err)
+	}
+}
+
+func (c *Controllers) reportClusterState(ctx context.Context, state clients.ClusterState) {
+	cluster := &system.Cluster{}
+	if err := c.stage.Get(common.DefaultDatabase, common.CLUSTER, state.Name, cluster, false); err != nil {
+		log.G(ctx).Warnf("backend controller get cluster %s error: %v", state.Name, err)
+		return
+	}
+
+	cluster.Status.Reachable = state.Reachable
+	cluster.Status.Version = state.Version
+	cluster.Status.LastError = state.LastError
+	cluster.Status.Latency = state.Latency.Milliseconds()
+	cluster.Status.LastProbeTime = state.LastProbeTime.Format(time.RFC3339)
+	if state.Reachable {
+		cluster.Status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionTrue, ReasonReachable, ""))
+	} else {
+		cluster.Status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionFalse, ReasonUnreachable, state.LastError))
+	}
+
+	if _, err := c.stage.ApplyStatus(common.DefaultDatabase, common.CLUSTER, cluster.GetName(), cluster); err != nil {
+		log.G(ctx).Warnf("backend controller report cluster %s status error: %v", state.Name, err)
+	}
+}
+
+func (bc *BackendController) Start(ctx context.Context, errChan chan error) {
+
+	northEvent, err := bc.NorthEventCh(ctx)
 	if err != nil {
 		errChan <- err
 		return
 	}
 
-	channels := len(southEvents) + 1
-	stopCh := make(chan struct{}, channels)
-
 	go func() {
 		for {
 			select {
-			case <-stopCh:
+			case <-ctx.Done():
 				return
 			case event, ok := <-northEvent:
 				if !ok {
 					return
 				}
+				select {
+				case bc.northQueue <- event:
+				case <-ctx.Done():
+					return
+				}
+				metrics.SetQueueDepth(bc.name, metrics.North, len(bc.northQueue))
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-bc.northQueue:
+				metrics.SetQueueDepth(bc.name, metrics.North, len(bc.northQueue))
 				bc.northHandle(event)
+			case event := <-bc.southQueue:
+				metrics.SetQueueDepth(bc.name, metrics.South, len(bc.southQueue))
+				bc.southHandle(event.cluster, event.event)
 			}
 		}
 	}()
 
+	// subscribe before listing so no transition is lost in between
+	clusterEvents := bc.clients.Subscribe(ctx)
+	for _, name := range bc.clients.Names() {
+		if bc.clients.Reachable(name) {
+			bc.startSouth(ctx, name)
+		}
+	}
+
 	go func() {
-		for _, southEvent := range southEvents {
-			go func(southEvent <-chan watch.Event) {
-				for {
-					select {
-					case <-stopCh:
-						return
-					case event, ok := <-southEvent:
-						if !ok {
-							return
-						}
-						bc.southHandle(event)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-clusterEvents:
+				if !ok {
+					return
+				}
+				switch event.Type {
+				case clients.ClusterAdded, clients.ClusterUp:
+					if bc.clients.Reachable(event.Name) {
+						bc.startSouth(ctx, event.Name)
 					}
+				case clients.ClusterUpdated:
+					// the client has been rebuilt, watch again through it
+					bc.stopSouth(ctx, event.Name)
+					if bc.clients.Reachable(event.Name) {
+						bc.startSouth(ctx, event.Name)
+					}
+				case clients.ClusterDown, clients.ClusterRemoved:
+					bc.stopSouth(ctx, event.Name)
 				}
-			}(southEvent)
+			}
 		}
 	}()
 
+	if resyncer, ok := bc.Handler.(Resyncer); ok && bc.resync.Interval > 0 {
+		go bc.runResync(ctx, resyncer)
+	}
+
+	return
+}
+
+// southRewatchDelay wait before watching a cluster again once all its watches ended
+const southRewatchDelay = 5 * time.Second
+
+type southWatch struct {
+	cancel context.CancelFunc
+}
+
+// startSouth watch the south events of cluster until stopSouth, the watches are
+// established again whenever the api server closes them. No-op when already watching.
+func (bc *BackendController) startSouth(ctx context.Context, cluster string) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	if _, exist := bc.southWatches[cluster]; exist {
+		return
+	}
+
+	southCtx, cancel := context.WithCancel(ctx)
+	bc.southWatches[cluster] = &southWatch{cancel: cancel}
+	log.G(ctx).Infof("backend controller start cluster %s south event", cluster)
+
 	go func() {
-		<-ctx.Done()
-		for range iter(channels) {
-			stopCh <- struct{}{}
+		for {
+			bc.watchSouth(southCtx, cluster)
+			select {
+			case <-southCtx.Done():
+				return
+			case <-time.After(southRewatchDelay):
+				metrics.IncWatchRetry(bc.name, cluster)
+			}
 		}
 	}()
+}
 
-	return
+// watchSouth dispatch the south events of cluster until every watch channel is closed
+func (bc *BackendController) watchSouth(ctx context.Context, cluster string) {
+	southEvents, err := bc.SouthEventChs(ctx, cluster)
+	if err != nil {
+		log.G(ctx).Warnf("backend controller watch cluster %s south event error: %v", cluster, err)
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for _, southCh := range southEvents {
+		wg.Add(1)
+		go func(southCh <-chan watch.Event) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event, ok := <-southCh:
+					if !ok {
+						return
+					}
+					select {
+					case bc.southQueue <- southEvent{cluster: cluster, event: event}:
+					case <-ctx.Done():
+						return
+					}
+					metrics.SetQueueDepth(bc.name, metrics.South, len(bc.southQueue))
+				}
+			}
+		}(southCh)
+	}
+	wg.Wait()
 }
 
-func iter(n int) []struct{} { return make([]struct{}, n) }
+// stopSouth stop the south events of cluster until it is started again
+func (bc *BackendController) stopSouth(ctx context.Context, cluster string) {
+	bc.mutex.Lock()
+	defer bc.mutex.Unlock()
+	current, exist := bc.southWatches[cluster]
+	if !exist {
+		return
+	}
+	current.cancel()
+	delete(bc.southWatches, cluster)
+	log.G(ctx).Warnf("backend controller stop cluster %s south event", cluster)
+}
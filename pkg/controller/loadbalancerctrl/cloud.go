@@ -0,0 +1,220 @@
+package loadbalancerctrl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+// allocateCloudElasticIP allocate the elastic ip and associate it with the instance of its vm if any
+func (E *ElasticIPCtrl) allocateCloudElasticIP(elasticIP *networking.ElasticIP) {
+	flog := E.flog.WithField("func", "allocateCloudElasticIP")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(E.stage, elasticIP.Spec.Vendor, elasticIP.Spec.Region)
+	if err != nil {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, err)
+		return
+	}
+	if err := provider.ElasticIPs().Allocate(ctx, elasticIP); err != nil {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, fmt.Errorf("allocate elastic ip error %v", err))
+		return
+	}
+	flog.Infof("allocate elastic ip %s address %s", elasticIP.GetName(), elasticIP.Spec.Address)
+
+	if elasticIP.Spec.VirtualMachine == "" {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPAvailable, nil)
+		return
+	}
+	E.associateCloudElasticIP(ctx, provider, elasticIP)
+}
+
+// bindCloudElasticIP move the elastic ip from the instance it is associated with to the instance of its vm
+func (E *ElasticIPCtrl) bindCloudElasticIP(elasticIP *networking.ElasticIP) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if elasticIP.Spec.AllocationId == "" {
+		E.allocateCloudElasticIP(elasticIP)
+		return
+	}
+	provider, err := cloudprovider.ForProvider(E.stage, elasticIP.Spec.Vendor, elasticIP.Spec.Region)
+	if err != nil {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, err)
+		return
+	}
+
+	if elasticIP.Spec.InstanceId != "" {
+		if elasticIP.Spec.VirtualMachine != "" {
+			vm, err := vmOf(E.stage, elasticIP.GetWorkspace(), elasticIP.Spec.VirtualMachine)
+			if err == nil && vm.Spec.InstanceId == elasticIP.Spec.InstanceId {
+				E.changeElasticIPState(elasticIP, networking.ElasticIPAssociated, nil)
+				return
+			}
+		}
+		err := provider.ElasticIPs().Disassociate(ctx, elasticIP.Spec.Region, elasticIP.Spec.AllocationId, elasticIP.Spec.InstanceId)
+		if err != nil && err != cloudprovider.NotFound {
+			E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, fmt.Errorf("disassociate elastic ip from %s error %v", elasticIP.Spec.InstanceId, err))
+			return
+		}
+		elasticIP.Spec.InstanceId = ""
+	}
+
+	if elasticIP.Spec.VirtualMachine == "" {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPAvailable, nil)
+		return
+	}
+	E.associateCloudElasticIP(ctx, provider, elasticIP)
+}
+
+func (E *ElasticIPCtrl) associateCloudElasticIP(ctx context.Context, provider cloudprovider.Interface, elasticIP *networking.ElasticIP) {
+	flog := E.flog.WithField("func", "associateCloudElasticIP")
+
+	vm, err := vmOf(E.stage, elasticIP.GetWorkspace(), elasticIP.Spec.VirtualMachine)
+	if err != nil {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, fmt.Errorf("get vm %s error %v", elasticIP.Spec.VirtualMachine, err))
+		return
+	}
+	if vm.Spec.InstanceId == "" {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, fmt.Errorf("vm %s has no instance", vm.GetName()))
+		return
+	}
+	if err := provider.ElasticIPs().Associate(ctx, elasticIP.Spec.Region, elasticIP.Spec.AllocationId, vm.Spec.InstanceId); err != nil {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, fmt.Errorf("associate elastic ip with %s error %v", vm.Spec.InstanceId, err))
+		return
+	}
+	elasticIP.Spec.InstanceId = vm.Spec.InstanceId
+	flog.Infof("associate elastic ip %s with vm %s", elasticIP.GetName(), vm.GetName())
+	E.changeElasticIPState(elasticIP, networking.ElasticIPAssociated, nil)
+}
+
+func (E *ElasticIPCtrl) releaseCloudElasticIP(elasticIP *networking.ElasticIP) {
+	flog := E.flog.WithField("func", "releaseCloudElasticIP")
+	if elasticIP.Spec.AllocationId == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(E.stage, elasticIP.Spec.Vendor, elasticIP.Spec.Region)
+	if err != nil {
+		flog.Warnf("get cloud provider error %v", err)
+		return
+	}
+	if elasticIP.Spec.InstanceId != "" {
+		err := provider.ElasticIPs().Disassociate(ctx, elasticIP.Spec.Region, elasticIP.Spec.AllocationId, elasticIP.Spec.InstanceId)
+		if err != nil && err != cloudprovider.NotFound {
+			flog.Warnf("disassociate elastic ip %s error %v", elasticIP.GetName(), err)
+			return
+		}
+	}
+	if err := provider.ElasticIPs().Release(ctx, elasticIP.Spec.Region, elasticIP.Spec.AllocationId); err != nil && err != cloudprovider.NotFound {
+		flog.Warnf("release elastic ip %s error %v", elasticIP.GetName(), err)
+		return
+	}
+	flog.Infof("release elastic ip %s", elasticIP.GetName())
+}
+
+func (L *LoadBalancerCtrl) createCloudLoadBalancer(loadBalancer *networking.LoadBalancer) {
+	flog := L.flog.WithField("func", "createCloudLoadBalancer")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(L.stage, loadBalancer.Spec.Vendor, loadBalancer.Spec.Region)
+	if err != nil {
+		L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, err)
+		return
+	}
+	instanceIds, err := L.instanceIdsOf(loadBalancer)
+	if err != nil {
+		L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, err)
+		return
+	}
+	allocationId := ""
+	if loadBalancer.Spec.ElasticIP != "" {
+		elasticIP, err := getElasticIP(L.stage, loadBalancer.GetWorkspace(), loadBalancer.Spec.ElasticIP)
+		if err != nil {
+			L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, fmt.Errorf("get elastic ip %s error %v", loadBalancer.Spec.ElasticIP, err))
+			return
+		}
+		if elasticIP.Spec.AllocationId == "" {
+			L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, fmt.Errorf("elastic ip %s is not allocated", elasticIP.GetName()))
+			return
+		}
+		allocationId = elasticIP.Spec.AllocationId
+	}
+
+	if err := provider.LoadBalancers().Create(ctx, loadBalancer, instanceIds, allocationId); err != nil {
+		L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, fmt.Errorf("create load balancer error %v", err))
+		return
+	}
+	flog.Infof("create load balancer %s id %s", loadBalancer.GetName(), loadBalancer.Spec.LoadBalancerId)
+	L.changeLoadBalancerState(loadBalancer, loadBalancer.Spec.State, nil)
+}
+
+func (L *LoadBalancerCtrl) updateCloudLoadBalancer(loadBalancer *networking.LoadBalancer) {
+	flog := L.flog.WithField("func", "updateCloudLoadBalancer")
+	if loadBalancer.Spec.LoadBalancerId == "" {
+		L.createCloudLoadBalancer(loadBalancer)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(L.stage, loadBalancer.Spec.Vendor, loadBalancer.Spec.Region)
+	if err != nil {
+		L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, err)
+		return
+	}
+	instanceIds, err := L.instanceIdsOf(loadBalancer)
+	if err != nil {
+		L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, err)
+		return
+	}
+	if err := provider.LoadBalancers().Update(ctx, loadBalancer, instanceIds); err != nil {
+		L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, fmt.Errorf("update load balancer error %v", err))
+		return
+	}
+	flog.Infof("update load balancer %s backends %v", loadBalancer.GetName(), instanceIds)
+	L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerActive, nil)
+}
+
+func (L *LoadBalancerCtrl) deleteCloudLoadBalancer(loadBalancer *networking.LoadBalancer) {
+	flog := L.flog.WithField("func", "deleteCloudLoadBalancer")
+	if loadBalancer.Spec.LoadBalancerId == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(L.stage, loadBalancer.Spec.Vendor, loadBalancer.Spec.Region)
+	if err != nil {
+		flog.Warnf("get cloud provider error %v", err)
+		return
+	}
+	err = provider.LoadBalancers().Delete(ctx, loadBalancer.Spec.Region, loadBalancer.Spec.LoadBalancerId)
+	if err != nil && err != cloudprovider.NotFound {
+		flog.Warnf("delete load balancer %s error %v", loadBalancer.GetName(), err)
+		return
+	}
+	flog.Infof("delete load balancer %s", loadBalancer.GetName())
+}
+
+// instanceIdsOf the instances of the backend vms
+func (L *LoadBalancerCtrl) instanceIdsOf(loadBalancer *networking.LoadBalancer) ([]string, error) {
+	instanceIds := make([]string, 0, len(loadBalancer.Spec.Backends))
+	for _, backend := range loadBalancer.Spec.Backends {
+		vm, err := vmOf(L.stage, loadBalancer.GetWorkspace(), backend)
+		if err != nil {
+			return nil, fmt.Errorf("get backend vm %s error %v", backend, err)
+		}
+		if vm.Spec.InstanceId == "" {
+			return nil, fmt.Errorf("backend vm %s has no instance", backend)
+		}
+		instanceIds = append(instanceIds, vm.Spec.InstanceId)
+	}
+	return instanceIds, nil
+}
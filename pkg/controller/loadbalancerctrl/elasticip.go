@@ -0,0 +1,184 @@
+package loadbalancerctrl
+
+import (
+	"context"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	_ "github.com/ddx2x/oilmont/pkg/cloudprovider/providers"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/controller/clients"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var _ controller.Handler = &ElasticIPCtrl{}
+
+// ElasticIPCtrl allocate the elastic ips of the providers and bind them to the instances of the vms, the local ones
+// become a MetalLB pool of the single address and a LoadBalancer Service selecting the vm
+type ElasticIPCtrl struct {
+	stage     datasource.IStorage
+	cs        *clients.Clients
+	placement *controller.PlacementResolver
+	flog      log.Logger
+}
+
+func (E *ElasticIPCtrl) Set(cs *clients.Clients, stage datasource.IStorage) {
+	E.cs, E.stage = cs, stage
+	E.placement = controller.NewPlacementResolver(stage, cs)
+}
+
+func NewElasticIPCtrl(ctx context.Context) controller.Handler {
+	flog := log.GetLogger(ctx).WithField("controller", "elasticipctrl")
+	return &ElasticIPCtrl{flog: flog}
+}
+
+func (E *ElasticIPCtrl) NorthOnAdd(obj core.IObject) {
+	flog := E.flog.WithField("func", "NorthOnAdd")
+
+	elasticIP := &networking.ElasticIP{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, elasticIP); err != nil {
+		flog.Warnf("unstructured obj error %v", err)
+		return
+	}
+	if elasticIP.Spec.Status != common.INIT {
+		return
+	}
+
+	if cloudprovider.IsRegistered(elasticIP.Spec.Vendor) {
+		E.allocateCloudElasticIP(elasticIP)
+		return
+	}
+	E.applyLocalElasticIP(elasticIP)
+}
+
+// NorthOnUpdate bind the elastic ip to the vm it names now, or unbind it
+func (E *ElasticIPCtrl) NorthOnUpdate(obj core.IObject) {
+	flog := E.flog.WithField("func", "NorthOnUpdate")
+
+	elasticIP := &networking.ElasticIP{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, elasticIP); err != nil {
+		flog.Warnf("unstructured obj error %v", err)
+		return
+	}
+	if elasticIP.Spec.Status != common.UPDATE {
+		return
+	}
+
+	if cloudprovider.IsRegistered(elasticIP.Spec.Vendor) {
+		E.bindCloudElasticIP(elasticIP)
+		return
+	}
+	E.applyLocalElasticIP(elasticIP)
+}
+
+func (E *ElasticIPCtrl) NorthOnDelete(obj core.IObject) {
+	elasticIP := &networking.ElasticIP{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, elasticIP); err != nil {
+		return
+	}
+
+	if cloudprovider.IsRegistered(elasticIP.Spec.Vendor) {
+		E.releaseCloudElasticIP(elasticIP)
+		return
+	}
+	E.deleteLocalElasticIP(elasticIP)
+}
+
+func (E *ElasticIPCtrl) NorthEventCh(ctx context.Context) (<-chan core.Event, error) {
+	return E.stage.WatchEvent(ctx, common.DefaultDatabase, common.ELASTICIP, "0")
+}
+
+func (E *ElasticIPCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
+	E.serviceToElasticIP(cluster, obj)
+}
+
+func (E *ElasticIPCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
+	E.serviceToElasticIP(cluster, obj)
+}
+
+// SouthOnDelete the stage owns the elastic ip, the Service is recreated on its next update
+func (E *ElasticIPCtrl) SouthOnDelete(string, runtime.Object) {}
+
+// SouthEventChs watch the Services of the local elastic ips, MetalLB announces the address once it is assigned
+func (E *ElasticIPCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
+	client, err := E.cs.GetClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+	watchInterface, err := client.Interface.Resource(serviceGvr).Watch(ctx, metav1.ListOptions{LabelSelector: elasticIPLabel})
+	if err != nil {
+		return nil, err
+	}
+	return []<-chan watch.Event{watchInterface.ResultChan()}, nil
+}
+
+// serviceToElasticIP mark the elastic ip associated once its address is assigned to the Service
+func (E *ElasticIPCtrl) serviceToElasticIP(cluster string, obj runtime.Object) {
+	flog := E.flog.WithField("func", "serviceToElasticIP").WithField("cluster", cluster)
+
+	service := &corev1.Service{}
+	if err := fromObject(obj, service); err != nil {
+		flog.Warnf("unmarshal service error %v", err)
+		return
+	}
+	elasticIP, err := getElasticIP(E.stage, service.GetNamespace(), service.Labels[elasticIPLabel])
+	if err != nil || elasticIP.Spec.State == networking.ElasticIPAssociated || elasticIP.Spec.VirtualMachine == "" {
+		return
+	}
+	if ingressOf(service) != elasticIP.Spec.Address {
+		return
+	}
+	E.changeElasticIPState(elasticIP, networking.ElasticIPAssociated, nil)
+}
+
+// elasticIPPaths the spec fields of an elastic ip the controller writes back with the progress, they bump no generation
+var elasticIPPaths = append([]string{"spec.allocation_id", "spec.address", "spec.instance_id", "spec.state"}, datasource.ProgressPaths...)
+
+// changeElasticIPState record the state reached by the elastic ip
+func (E *ElasticIPCtrl) changeElasticIPState(elasticIP *networking.ElasticIP, state string, err error) {
+	flog := E.flog.WithField("func", "changeElasticIPState")
+
+	elasticIP.Spec.State = state
+	switch {
+	case err != nil:
+		flog.Warnf("elastic ip %s error %v", elasticIP.GetName(), err)
+		elasticIP.Spec.State = networking.ElasticIPFailed
+		elasticIP.Spec.Status = common.FAIL
+		elasticIP.Spec.Message = err.Error()
+	default:
+		elasticIP.Spec.Status = common.RUNNING
+		elasticIP.Spec.Message = "success"
+	}
+
+	if _, applyErr := E.stage.ApplyStatus(common.DefaultDatabase, common.ELASTICIP, elasticIP.GetName(), elasticIP, elasticIPPaths...); applyErr != nil {
+		flog.Warnf("change elastic ip %s state error %v", elasticIP.GetName(), applyErr)
+		return
+	}
+	if reportErr := controller.ReportObserved(E.stage, common.ELASTICIP, elasticIP, controller.SyncedCondition(err)); reportErr != nil {
+		flog.Warnf("report elastic ip %s synced condition error %v", elasticIP.GetName(), reportErr)
+	}
+	condition := controller.ReadyCondition(elasticIP.Spec.Status, elasticIP.Spec.Message)
+	if reportErr := controller.ReportCondition(E.stage, common.ELASTICIP, elasticIP, condition); reportErr != nil {
+		flog.Warnf("report elastic ip %s ready condition error %v", elasticIP.GetName(), reportErr)
+	}
+}
+
+func getElasticIP(stage datasource.IStorage, workspace, name string) (*networking.ElasticIP, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	elasticIP := &networking.ElasticIP{}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.ELASTICIP, elasticIP, filter, true); err != nil {
+		return nil, err
+	}
+	return elasticIP, nil
+}
@@ -0,0 +1,437 @@
+package loadbalancerctrl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/controller/clients"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubeVirtV1 "kubevirt.io/client-go/apis/core/v1"
+)
+
+var (
+	serviceGvr     = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	kubeVirtVMIGvr = schema.GroupVersionResource{Group: "kubevirt.io", Version: "v1", Resource: "virtualmachineinstances"}
+	metalLBPoolGvr = schema.GroupVersionResource{Group: "metallb.io", Version: "v1beta1", Resource: "ipaddresspools"}
+)
+
+const (
+	// elasticIPLabel label of the Services of the local elastic ips, the value is the elastic ip name
+	elasticIPLabel = "cloud.ddx2x.nip/elastic-ip"
+	// loadBalancerLabel label of the Services of the local load balancers, the value is the load balancer name
+	loadBalancerLabel = "cloud.ddx2x.nip/load-balancer"
+
+	metalLBNamespace             = "metallb-system"
+	metalLBAddressPoolAnnotation = "metallb.universe.tf/address-pool"
+	metalLBIPsAnnotation         = "metallb.universe.tf/loadBalancerIPs"
+)
+
+func poolName(elasticIP *networking.ElasticIP) string {
+	return fmt.Sprintf("eip-%s-%s", elasticIP.GetWorkspace(), elasticIP.GetName())
+}
+
+func elasticIPServiceName(elasticIP *networking.ElasticIP) string {
+	return fmt.Sprintf("eip-%s", elasticIP.GetName())
+}
+
+func loadBalancerServiceName(loadBalancer *networking.LoadBalancer) string {
+	return fmt.Sprintf("lb-%s", loadBalancer.GetName())
+}
+
+// toAddressPool the MetalLB pool of the single address of elasticIP, only Services asking for it get the address
+func toAddressPool(elasticIP *networking.ElasticIP) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "metallb.io/v1beta1",
+		"kind":       "IPAddressPool",
+		"metadata": map[string]interface{}{
+			"name":      poolName(elasticIP),
+			"namespace": metalLBNamespace,
+			"labels": map[string]interface{}{
+				"workspace":    elasticIP.GetWorkspace(),
+				elasticIPLabel: elasticIP.GetName(),
+			},
+		},
+		"spec": map[string]interface{}{
+			"addresses":  []interface{}{fmt.Sprintf("%s/32", elasticIP.Spec.Address)},
+			"autoAssign": false,
+		},
+	}}
+}
+
+// elasticIPAnnotations pin the Service to the address of the pool of elasticIP
+func elasticIPAnnotations(elasticIP *networking.ElasticIP) map[string]string {
+	return map[string]string{
+		metalLBAddressPoolAnnotation: poolName(elasticIP),
+		metalLBIPsAnnotation:         elasticIP.Spec.Address,
+	}
+}
+
+// toElasticIPService the LoadBalancer Service exposing Ports of the vm elasticIP is bound to on its address
+func toElasticIPService(elasticIP *networking.ElasticIP) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        elasticIPServiceName(elasticIP),
+			Namespace:   elasticIP.GetWorkspace(),
+			Labels:      map[string]string{"workspace": elasticIP.GetWorkspace(), elasticIPLabel: elasticIP.GetName()},
+			Annotations: elasticIPAnnotations(elasticIP),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:           corev1.ServiceTypeLoadBalancer,
+			Selector:       map[string]string{"kubevirt.io/vm": elasticIP.Spec.VirtualMachine},
+			LoadBalancerIP: elasticIP.Spec.Address,
+		},
+	}
+	for _, port := range elasticIP.Spec.Ports {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       fmt.Sprintf("tcp-%d", port),
+			Protocol:   corev1.ProtocolTCP,
+			Port:       int32(port),
+			TargetPort: intstr.FromInt(port),
+		})
+	}
+	return service
+}
+
+// toLoadBalancerService the Service without selector of loadBalancer, a ClusterIP one when internal.
+// elasticIP is the elastic ip it is exposed on if any
+func toLoadBalancerService(loadBalancer *networking.LoadBalancer, elasticIP *networking.ElasticIP) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      loadBalancerServiceName(loadBalancer),
+			Namespace: loadBalancer.GetWorkspace(),
+			Labels:    map[string]string{"workspace": loadBalancer.GetWorkspace(), loadBalancerLabel: loadBalancer.GetName()},
+		},
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	if loadBalancer.Spec.Internal {
+		service.Spec.Type = corev1.ServiceTypeClusterIP
+	} else if elasticIP != nil {
+		service.Annotations = elasticIPAnnotations(elasticIP)
+		service.Spec.LoadBalancerIP = elasticIP.Spec.Address
+	}
+	for _, listener := range loadBalancer.Spec.Listeners {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       listenerPortName(listener),
+			Protocol:   serviceProtocol(listener.Protocol),
+			Port:       int32(listener.Port),
+			TargetPort: intstr.FromInt(listener.BackendPort),
+		})
+	}
+	return service
+}
+
+// toEndpoints the endpoints of the Service of loadBalancer, the vmis kubeVirt does not report ready are not forwarded to
+func toEndpoints(loadBalancer *networking.LoadBalancer, vmis []kubeVirtV1.VirtualMachineInstance) *corev1.Endpoints {
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      loadBalancerServiceName(loadBalancer),
+			Namespace: loadBalancer.GetWorkspace(),
+			Labels:    map[string]string{"workspace": loadBalancer.GetWorkspace(), loadBalancerLabel: loadBalancer.GetName()},
+		},
+	}
+	subset := corev1.EndpointSubset{}
+	for _, vmi := range vmis {
+		if len(vmi.Status.Interfaces) == 0 || vmi.Status.Interfaces[0].IP == "" {
+			continue
+		}
+		address := corev1.EndpointAddress{
+			IP:        vmi.Status.Interfaces[0].IP,
+			NodeName:  nodeNameOf(&vmi),
+			TargetRef: &corev1.ObjectReference{Kind: "VirtualMachineInstance", Namespace: vmi.GetNamespace(), Name: vmi.GetName(), UID: vmi.GetUID()},
+		}
+		if vmiReady(&vmi) {
+			subset.Addresses = append(subset.Addresses, address)
+		} else {
+			subset.NotReadyAddresses = append(subset.NotReadyAddresses, address)
+		}
+	}
+	if len(subset.Addresses) == 0 && len(subset.NotReadyAddresses) == 0 {
+		return endpoints
+	}
+	for _, listener := range loadBalancer.Spec.Listeners {
+		subset.Ports = append(subset.Ports, corev1.EndpointPort{
+			Name:     listenerPortName(listener),
+			Protocol: serviceProtocol(listener.Protocol),
+			Port:     int32(listener.BackendPort),
+		})
+	}
+	endpoints.Subsets = []corev1.EndpointSubset{subset}
+	return endpoints
+}
+
+func listenerPortName(listener networking.Listener) string {
+	return fmt.Sprintf("%s-%d", strings.ToLower(listener.Protocol), listener.Port)
+}
+
+// serviceProtocol http listeners are plain tcp to a Service
+func serviceProtocol(protocol networking.ListenerProtocol) corev1.Protocol {
+	if protocol == networking.ListenerUDP {
+		return corev1.ProtocolUDP
+	}
+	return corev1.ProtocolTCP
+}
+
+func vmiReady(vmi *kubeVirtV1.VirtualMachineInstance) bool {
+	if vmi.Status.Phase != kubeVirtV1.Running {
+		return false
+	}
+	for _, condition := range vmi.Status.Conditions {
+		if condition.Type == kubeVirtV1.VirtualMachineInstanceReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func nodeNameOf(vmi *kubeVirtV1.VirtualMachineInstance) *string {
+	if vmi.Status.NodeName == "" {
+		return nil
+	}
+	nodeName := vmi.Status.NodeName
+	return &nodeName
+}
+
+// ingressOf the address the Service is announced on, empty until MetalLB assigned it
+func ingressOf(service *corev1.Service) string {
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP
+		}
+	}
+	return ""
+}
+
+// applyLocalElasticIP apply the pool of the address, then the Service of the vm it is bound to or delete it when unbound
+func (E *ElasticIPCtrl) applyLocalElasticIP(elasticIP *networking.ElasticIP) {
+	flog := E.flog.WithField("func", "applyLocalElasticIP")
+
+	if elasticIP.Spec.Address == "" {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, fmt.Errorf("local elastic ip requires an address"))
+		return
+	}
+	client, err := E.clientOf(elasticIP)
+	if err != nil {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, err)
+		return
+	}
+	if err := applyAddressPool(client, toAddressPool(elasticIP)); err != nil {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, fmt.Errorf("apply address pool error %v", err))
+		return
+	}
+
+	if elasticIP.Spec.VirtualMachine == "" {
+		if err := deleteService(client, elasticIP.GetWorkspace(), elasticIPServiceName(elasticIP)); err != nil {
+			E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, fmt.Errorf("delete service error %v", err))
+			return
+		}
+		E.changeElasticIPState(elasticIP, networking.ElasticIPAvailable, nil)
+		return
+	}
+
+	service, err := applyService(client, toElasticIPService(elasticIP))
+	if err != nil {
+		E.changeElasticIPState(elasticIP, networking.ElasticIPFailed, fmt.Errorf("apply service error %v", err))
+		return
+	}
+	flog.Infof("bind elastic ip %s to vm %s", elasticIP.GetName(), elasticIP.Spec.VirtualMachine)
+	// associated once MetalLB announces the address, see serviceToElasticIP
+	state := networking.ElasticIPAvailable
+	if ingressOf(service) == elasticIP.Spec.Address {
+		state = networking.ElasticIPAssociated
+	}
+	E.changeElasticIPState(elasticIP, state, nil)
+}
+
+func (E *ElasticIPCtrl) deleteLocalElasticIP(elasticIP *networking.ElasticIP) {
+	flog := E.flog.WithField("func", "deleteLocalElasticIP")
+
+	client, err := E.clientOf(elasticIP)
+	if err != nil {
+		flog.Warnf("get client error %v", err)
+		return
+	}
+	if err := deleteService(client, elasticIP.GetWorkspace(), elasticIPServiceName(elasticIP)); err != nil {
+		flog.Warnf("delete service of elastic ip %s error %v", elasticIP.GetName(), err)
+		return
+	}
+	err = client.Interface.Resource(metalLBPoolGvr).Namespace(metalLBNamespace).Delete(context.Background(), poolName(elasticIP), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		flog.Warnf("delete address pool of elastic ip %s error %v", elasticIP.GetName(), err)
+		return
+	}
+	flog.Infof("delete elastic ip %s", elasticIP.GetName())
+}
+
+func (E *ElasticIPCtrl) clientOf(elasticIP *networking.ElasticIP) (*clients.KubeClient, error) {
+	return E.placement.Client(controller.Placement{
+		Provider:  elasticIP.Spec.Vendor,
+		Region:    elasticIP.Spec.Region,
+		Az:        elasticIP.Spec.Az,
+		Workspace: elasticIP.GetWorkspace(),
+	})
+}
+
+// applyLocalLoadBalancer apply the Service of the load balancer and its endpoints
+func (L *LoadBalancerCtrl) applyLocalLoadBalancer(loadBalancer *networking.LoadBalancer) {
+	flog := L.flog.WithField("func", "applyLocalLoadBalancer")
+
+	var elasticIP *networking.ElasticIP
+	if loadBalancer.Spec.ElasticIP != "" {
+		var err error
+		if elasticIP, err = getElasticIP(L.stage, loadBalancer.GetWorkspace(), loadBalancer.Spec.ElasticIP); err != nil {
+			L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, fmt.Errorf("get elastic ip %s error %v", loadBalancer.Spec.ElasticIP, err))
+			return
+		}
+	}
+	client, err := L.clientOf(loadBalancer)
+	if err != nil {
+		L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, err)
+		return
+	}
+	service, err := applyService(client, toLoadBalancerService(loadBalancer, elasticIP))
+	if err != nil {
+		L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, fmt.Errorf("apply service error %v", err))
+		return
+	}
+	if err := L.applyEndpoints(loadBalancer); err != nil {
+		L.changeLoadBalancerState(loadBalancer, networking.LoadBalancerFailed, fmt.Errorf("apply endpoints error %v", err))
+		return
+	}
+	flog.Infof("apply load balancer %s backends %v", loadBalancer.GetName(), loadBalancer.Spec.Backends)
+
+	// active once MetalLB announces the address, see serviceToLoadBalancer
+	loadBalancer.Spec.Address = ingressOf(service)
+	if loadBalancer.Spec.Internal {
+		loadBalancer.Spec.Address = service.Spec.ClusterIP
+	}
+	state := networking.LoadBalancerPending
+	if loadBalancer.Spec.Address != "" {
+		state = networking.LoadBalancerActive
+	}
+	L.changeLoadBalancerState(loadBalancer, state, nil)
+}
+
+// applyEndpoints point the Service of the load balancer at the running vmis of its backends
+func (L *LoadBalancerCtrl) applyEndpoints(loadBalancer *networking.LoadBalancer) error {
+	client, err := L.clientOf(loadBalancer)
+	if err != nil {
+		return err
+	}
+	vmis := make([]kubeVirtV1.VirtualMachineInstance, 0, len(loadBalancer.Spec.Backends))
+	for _, backend := range loadBalancer.Spec.Backends {
+		vmi, err := client.KubevirtCli.VirtualMachineInstance(loadBalancer.GetWorkspace()).Get(backend, &metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		vmis = append(vmis, *vmi)
+	}
+
+	desired := toEndpoints(loadBalancer, vmis)
+	endpoints := client.KubevirtCli.CoreV1().Endpoints(desired.Namespace)
+	current, err := endpoints.Get(context.Background(), desired.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = endpoints.Create(context.Background(), desired, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	current.Labels = desired.Labels
+	current.Subsets = desired.Subsets
+	_, err = endpoints.Update(context.Background(), current, metav1.UpdateOptions{})
+	return err
+}
+
+func (L *LoadBalancerCtrl) deleteLocalLoadBalancer(loadBalancer *networking.LoadBalancer) {
+	flog := L.flog.WithField("func", "deleteLocalLoadBalancer")
+
+	client, err := L.clientOf(loadBalancer)
+	if err != nil {
+		flog.Warnf("get client error %v", err)
+		return
+	}
+	name := loadBalancerServiceName(loadBalancer)
+	if err := deleteService(client, loadBalancer.GetWorkspace(), name); err != nil {
+		flog.Warnf("delete service of load balancer %s error %v", loadBalancer.GetName(), err)
+		return
+	}
+	err = client.KubevirtCli.CoreV1().Endpoints(loadBalancer.GetWorkspace()).Delete(context.Background(), name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		flog.Warnf("delete endpoints of load balancer %s error %v", loadBalancer.GetName(), err)
+		return
+	}
+	flog.Infof("delete load balancer %s", loadBalancer.GetName())
+}
+
+func (L *LoadBalancerCtrl) clientOf(loadBalancer *networking.LoadBalancer) (*clients.KubeClient, error) {
+	return L.placement.Client(controller.Placement{
+		Provider:  loadBalancer.Spec.Vendor,
+		Region:    loadBalancer.Spec.Region,
+		Az:        loadBalancer.Spec.Az,
+		Workspace: loadBalancer.GetWorkspace(),
+	})
+}
+
+// applyService create or replace the labels, annotations and spec of the Service, the allocated cluster ip is kept
+func applyService(client *clients.KubeClient, service *corev1.Service) (*corev1.Service, error) {
+	services := client.KubevirtCli.CoreV1().Services(service.Namespace)
+	current, err := services.Get(context.Background(), service.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return services.Create(context.Background(), service, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	current.Labels = service.Labels
+	current.Annotations = service.Annotations
+	current.Spec.Type = service.Spec.Type
+	current.Spec.Selector = service.Spec.Selector
+	current.Spec.Ports = service.Spec.Ports
+	current.Spec.LoadBalancerIP = service.Spec.LoadBalancerIP
+	return services.Update(context.Background(), current, metav1.UpdateOptions{})
+}
+
+func deleteService(client *clients.KubeClient, namespace, name string) error {
+	err := client.KubevirtCli.CoreV1().Services(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func applyAddressPool(client *clients.KubeClient, pool *unstructured.Unstructured) error {
+	pools := client.Interface.Resource(metalLBPoolGvr).Namespace(pool.GetNamespace())
+	current, err := pools.Get(context.Background(), pool.GetName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = pools.Create(context.Background(), pool, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	current.SetLabels(pool.GetLabels())
+	current.Object["spec"] = pool.Object["spec"]
+	_, err = pools.Update(context.Background(), current, metav1.UpdateOptions{})
+	return err
+}
+
+func fromObject(obj runtime.Object, result interface{}) error {
+	object := &unstructured.Unstructured{}
+	if err := utilsObj.Unmarshal(object, obj); err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, result)
+}
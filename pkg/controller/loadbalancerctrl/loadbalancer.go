@@ -0,0 +1,265 @@
+package loadbalancerctrl
+
+import (
+	"context"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/controller/clients"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var _ controller.Handler = &LoadBalancerCtrl{}
+
+// LoadBalancerCtrl realise the load balancers through the provider, the local ones become a Service without selector
+// whose Endpoints are the addresses of the backend vmis
+type LoadBalancerCtrl struct {
+	stage     datasource.IStorage
+	cs        *clients.Clients
+	placement *controller.PlacementResolver
+	flog      log.Logger
+}
+
+func (L *LoadBalancerCtrl) Set(cs *clients.Clients, stage datasource.IStorage) {
+	L.cs, L.stage = cs, stage
+	L.placement = controller.NewPlacementResolver(stage, cs)
+}
+
+func NewLoadBalancerCtrl(ctx context.Context) controller.Handler {
+	flog := log.GetLogger(ctx).WithField("controller", "loadbalancerctrl")
+	return &LoadBalancerCtrl{flog: flog}
+}
+
+func (L *LoadBalancerCtrl) NorthOnAdd(obj core.IObject) {
+	flog := L.flog.WithField("func", "NorthOnAdd")
+
+	loadBalancer := &networking.LoadBalancer{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, loadBalancer); err != nil {
+		flog.Warnf("unstructured obj error %v", err)
+		return
+	}
+	if loadBalancer.Spec.Status != common.INIT {
+		return
+	}
+
+	if cloudprovider.IsRegistered(loadBalancer.Spec.Vendor) {
+		L.createCloudLoadBalancer(loadBalancer)
+		return
+	}
+	L.applyLocalLoadBalancer(loadBalancer)
+}
+
+// NorthOnUpdate apply the listeners, the backends and the health check
+func (L *LoadBalancerCtrl) NorthOnUpdate(obj core.IObject) {
+	flog := L.flog.WithField("func", "NorthOnUpdate")
+
+	loadBalancer := &networking.LoadBalancer{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, loadBalancer); err != nil {
+		flog.Warnf("unstructured obj error %v", err)
+		return
+	}
+	if loadBalancer.Spec.Status != common.UPDATE {
+		return
+	}
+
+	if cloudprovider.IsRegistered(loadBalancer.Spec.Vendor) {
+		L.updateCloudLoadBalancer(loadBalancer)
+		return
+	}
+	L.applyLocalLoadBalancer(loadBalancer)
+}
+
+func (L *LoadBalancerCtrl) NorthOnDelete(obj core.IObject) {
+	loadBalancer := &networking.LoadBalancer{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, loadBalancer); err != nil {
+		return
+	}
+
+	if cloudprovider.IsRegistered(loadBalancer.Spec.Vendor) {
+		L.deleteCloudLoadBalancer(loadBalancer)
+		return
+	}
+	L.deleteLocalLoadBalancer(loadBalancer)
+}
+
+func (L *LoadBalancerCtrl) NorthEventCh(ctx context.Context) (<-chan core.Event, error) {
+	return L.stage.WatchEvent(ctx, common.DefaultDatabase, common.LOADBALANCER, "0")
+}
+
+func (L *LoadBalancerCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
+	L.southToLoadBalancer(cluster, obj)
+}
+
+func (L *LoadBalancerCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
+	L.southToLoadBalancer(cluster, obj)
+}
+
+// SouthOnDelete a stopped vmi takes its address out of the endpoints
+func (L *LoadBalancerCtrl) SouthOnDelete(cluster string, obj runtime.Object) {
+	L.southToLoadBalancer(cluster, obj)
+}
+
+// SouthEventChs watch the Services of the local load balancers for their address and the vmis for the endpoints
+func (L *LoadBalancerCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
+	client, err := L.cs.GetClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+	services, err := client.Interface.Resource(serviceGvr).Watch(ctx, metav1.ListOptions{LabelSelector: loadBalancerLabel})
+	if err != nil {
+		return nil, err
+	}
+	vmis, err := client.Interface.Resource(kubeVirtVMIGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		services.Stop()
+		return nil, err
+	}
+	return []<-chan watch.Event{services.ResultChan(), vmis.ResultChan()}, nil
+}
+
+func (L *LoadBalancerCtrl) southToLoadBalancer(cluster string, obj runtime.Object) {
+	flog := L.flog.WithField("func", "southToLoadBalancer").WithField("cluster", cluster)
+
+	object := &unstructured.Unstructured{}
+	if err := utilsObj.Unmarshal(object, obj); err != nil {
+		flog.Warnf("unmarshal south object error %v", err)
+		return
+	}
+	if object.GetKind() == "Service" {
+		service := &corev1.Service{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, service); err != nil {
+			flog.Warnf("unmarshal service error %v", err)
+			return
+		}
+		L.serviceToLoadBalancer(service)
+		return
+	}
+	L.refreshEndpoints(object.GetNamespace(), object.GetName())
+}
+
+// serviceToLoadBalancer record the address MetalLB assigned to the Service
+func (L *LoadBalancerCtrl) serviceToLoadBalancer(service *corev1.Service) {
+	loadBalancer, err := getLoadBalancer(L.stage, service.GetNamespace(), service.Labels[loadBalancerLabel])
+	if err != nil || loadBalancer.Spec.Status != common.RUNNING {
+		return
+	}
+	address := ingressOf(service)
+	if address == "" {
+		address = service.Spec.ClusterIP
+	}
+	if address == loadBalancer.Spec.Address && loadBalancer.Spec.State == networking.LoadBalancerActive {
+		return
+	}
+	loadBalancer.Spec.Address = address
+	state := networking.LoadBalancerPending
+	if address != "" {
+		state = networking.LoadBalancerActive
+	}
+	L.changeLoadBalancerState(loadBalancer, state, nil)
+}
+
+// refreshEndpoints update the endpoints of the local load balancers of the workspace forwarding to vm
+func (L *LoadBalancerCtrl) refreshEndpoints(workspace, vm string) {
+	flog := L.flog.WithField("func", "refreshEndpoints")
+
+	loadBalancers := make([]networking.LoadBalancer, 0)
+	if err := L.stage.ListToObject(common.DefaultDatabase, common.LOADBALANCER, map[string]interface{}{common.FilterWorkspace: workspace}, &loadBalancers, true); err != nil {
+		flog.Warnf("list load balancers of workspace %s error %v", workspace, err)
+		return
+	}
+	for index := range loadBalancers {
+		loadBalancer := &loadBalancers[index]
+		if cloudprovider.IsRegistered(loadBalancer.Spec.Vendor) || loadBalancer.Spec.Status != common.RUNNING || !contains(loadBalancer.Spec.Backends, vm) {
+			continue
+		}
+		if err := L.applyEndpoints(loadBalancer); err != nil {
+			flog.Warnf("refresh endpoints of load balancer %s error %v", loadBalancer.GetName(), err)
+		}
+	}
+}
+
+// loadBalancerPaths the spec fields of a load balancer the controller writes back with the progress, they bump no
+// generation
+var loadBalancerPaths = append([]string{"spec.load_balancer_id", "spec.address", "spec.state"}, datasource.ProgressPaths...)
+
+// changeLoadBalancerState record the state reached by the load balancer
+func (L *LoadBalancerCtrl) changeLoadBalancerState(loadBalancer *networking.LoadBalancer, state string, err error) {
+	flog := L.flog.WithField("func", "changeLoadBalancerState")
+
+	loadBalancer.Spec.State = state
+	switch {
+	case err != nil:
+		flog.Warnf("load balancer %s error %v", loadBalancer.GetName(), err)
+		loadBalancer.Spec.State = networking.LoadBalancerFailed
+		loadBalancer.Spec.Status = common.FAIL
+		loadBalancer.Spec.Message = err.Error()
+	default:
+		loadBalancer.Spec.Status = common.RUNNING
+		loadBalancer.Spec.Message = "success"
+	}
+
+	if _, applyErr := L.stage.ApplyStatus(common.DefaultDatabase, common.LOADBALANCER, loadBalancer.GetName(), loadBalancer, loadBalancerPaths...); applyErr != nil {
+		flog.Warnf("change load balancer %s state error %v", loadBalancer.GetName(), applyErr)
+		return
+	}
+	if reportErr := controller.ReportObserved(L.stage, common.LOADBALANCER, loadBalancer, controller.SyncedCondition(err)); reportErr != nil {
+		flog.Warnf("report load balancer %s synced condition error %v", loadBalancer.GetName(), reportErr)
+	}
+	if reportErr := controller.ReportCondition(L.stage, common.LOADBALANCER, loadBalancer, loadBalancerReadyCondition(loadBalancer)); reportErr != nil {
+		flog.Warnf("report load balancer %s ready condition error %v", loadBalancer.GetName(), reportErr)
+	}
+}
+
+func loadBalancerReadyCondition(loadBalancer *networking.LoadBalancer) core.Condition {
+	switch loadBalancer.Spec.State {
+	case networking.LoadBalancerActive:
+		return core.NewCondition(core.ConditionReady, core.ConditionTrue, controller.ReasonRunning, loadBalancer.Spec.Message)
+	case networking.LoadBalancerFailed:
+		return core.NewCondition(core.ConditionReady, core.ConditionFalse, controller.ReasonFailed, loadBalancer.Spec.Message)
+	}
+	return core.NewCondition(core.ConditionReady, core.ConditionUnknown, controller.ReasonPending, loadBalancer.Spec.Message)
+}
+
+func getLoadBalancer(stage datasource.IStorage, workspace, name string) (*networking.LoadBalancer, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	loadBalancer := &networking.LoadBalancer{}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.LOADBALANCER, loadBalancer, filter, true); err != nil {
+		return nil, err
+	}
+	return loadBalancer, nil
+}
+
+func vmOf(stage datasource.IStorage, workspace, name string) (*compute.VirtualMachine, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	vm := &compute.VirtualMachine{}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, vm, filter, true); err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, current := range items {
+		if current == item {
+			return true
+		}
+	}
+	return false
+}
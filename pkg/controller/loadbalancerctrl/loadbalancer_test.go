@@ -0,0 +1,222 @@
+package loadbalancerctrl
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake/faketest"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kubeVirtV1 "kubevirt.io/client-go/apis/core/v1"
+)
+
+func newFakeCloudCtrls(t *testing.T) (*ElasticIPCtrl, *LoadBalancerCtrl, *fake.Cloud) {
+	stage, cloud := faketest.NewStage(t)
+	E := NewElasticIPCtrl(context.Background()).(*ElasticIPCtrl)
+	E.Set(nil, stage)
+	L := NewLoadBalancerCtrl(context.Background()).(*LoadBalancerCtrl)
+	L.Set(nil, stage)
+	return E, L, cloud
+}
+
+func newElasticIP(t *testing.T, stage datasource.IStorage, name, vm string) *networking.ElasticIP {
+	elasticIP := &networking.ElasticIP{Metadata: core.Metadata{Name: name, Namespace: fake.Name, Workspace: "ws"}}
+	elasticIP.Spec = networking.ElasticIPSpec{Vendor: fake.Name, Region: "region-1", VirtualMachine: vm, Status: common.INIT}
+	if _, err := stage.Create(common.DefaultDatabase, common.ELASTICIP, elasticIP); err != nil {
+		t.Fatal(err)
+	}
+	return elasticIP
+}
+
+func TestCloudElasticIP(t *testing.T) {
+	E, _, cloud := newFakeCloudCtrls(t)
+	ctx := context.Background()
+	vm := faketest.SeedVM(t, E.stage, cloud, "vm1")
+	E.NorthOnAdd(newElasticIP(t, E.stage, "eip1", "vm1"))
+
+	elasticIP, err := getElasticIP(E.stage, "ws", "eip1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elasticIP.Spec.State != networking.ElasticIPAssociated || elasticIP.Spec.InstanceId != vm.Spec.InstanceId || elasticIP.Spec.Address == "" {
+		t.Fatalf("expected elastic ip associated with %s, got %+v", vm.Spec.InstanceId, elasticIP.Spec)
+	}
+	allocated, err := cloud.ElasticIPs().Get(ctx, "region-1", elasticIP.Spec.AllocationId)
+	if err != nil || allocated.Spec.InstanceId != vm.Spec.InstanceId {
+		t.Fatalf("expected provider elastic ip associated with %s, got %+v %v", vm.Spec.InstanceId, allocated, err)
+	}
+
+	// unbind
+	elasticIP.Spec.VirtualMachine = ""
+	elasticIP.Spec.Status = common.UPDATE
+	E.NorthOnUpdate(elasticIP)
+	if elasticIP, err = getElasticIP(E.stage, "ws", "eip1"); err != nil {
+		t.Fatal(err)
+	}
+	if elasticIP.Spec.State != networking.ElasticIPAvailable || elasticIP.Spec.InstanceId != "" {
+		t.Fatalf("expected elastic ip available, got %+v", elasticIP.Spec)
+	}
+
+	// bound again then released, the provider disassociates it first
+	elasticIP.Spec.VirtualMachine = "vm1"
+	elasticIP.Spec.Status = common.UPDATE
+	E.NorthOnUpdate(elasticIP)
+	if elasticIP, err = getElasticIP(E.stage, "ws", "eip1"); err != nil || elasticIP.Spec.State != networking.ElasticIPAssociated {
+		t.Fatalf("expected elastic ip associated again, got %+v %v", elasticIP, err)
+	}
+	E.NorthOnDelete(elasticIP)
+	if _, err := cloud.ElasticIPs().Get(ctx, "region-1", elasticIP.Spec.AllocationId); err != cloudprovider.NotFound {
+		t.Fatalf("expected elastic ip released, got %v", err)
+	}
+}
+
+func TestCloudElasticIPOfVMWithoutInstance(t *testing.T) {
+	E, _, _ := newFakeCloudCtrls(t)
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Workspace: "ws"}}
+	vm.Spec = compute.VirtualMachineSpec{Vendor: fake.Name, RegionId: "region-1"}
+	if _, err := E.stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+		t.Fatal(err)
+	}
+	E.NorthOnAdd(newElasticIP(t, E.stage, "eip1", "vm1"))
+
+	elasticIP, err := getElasticIP(E.stage, "ws", "eip1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elasticIP.Spec.State != networking.ElasticIPFailed || elasticIP.Spec.Status != common.FAIL {
+		t.Fatalf("expected failed elastic ip, got %+v", elasticIP.Spec)
+	}
+}
+
+func TestCloudLoadBalancer(t *testing.T) {
+	E, L, cloud := newFakeCloudCtrls(t)
+	ctx := context.Background()
+	vm1 := faketest.SeedVM(t, L.stage, cloud, "vm1")
+	vm2 := faketest.SeedVM(t, L.stage, cloud, "vm2")
+	E.NorthOnAdd(newElasticIP(t, E.stage, "eip1", ""))
+	elasticIP, err := getElasticIP(E.stage, "ws", "eip1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadBalancer := &networking.LoadBalancer{Metadata: core.Metadata{Name: "lb1", Namespace: fake.Name, Workspace: "ws"}}
+	loadBalancer.Spec = networking.LoadBalancerSpec{
+		Vendor:    fake.Name,
+		Region:    "region-1",
+		Subnets:   []string{"subnet-1"},
+		Listeners: []networking.Listener{{Port: 80, BackendPort: 8080}},
+		Backends:  []string{"vm1"},
+		ElasticIP: "eip1",
+		Status:    common.INIT,
+	}
+	loadBalancer.Spec.Normalize()
+	if _, err := L.stage.Create(common.DefaultDatabase, common.LOADBALANCER, loadBalancer); err != nil {
+		t.Fatal(err)
+	}
+	L.NorthOnAdd(loadBalancer)
+
+	if loadBalancer, err = getLoadBalancer(L.stage, "ws", "lb1"); err != nil {
+		t.Fatal(err)
+	}
+	if loadBalancer.Spec.State != networking.LoadBalancerActive || loadBalancer.Spec.Address != elasticIP.Spec.Address {
+		t.Fatalf("expected active load balancer on %s, got %+v", elasticIP.Spec.Address, loadBalancer.Spec)
+	}
+	if targets := cloud.Targets(loadBalancer.Spec.LoadBalancerId); !reflect.DeepEqual(targets, []string{vm1.Spec.InstanceId}) {
+		t.Fatalf("expected targets %s, got %v", vm1.Spec.InstanceId, targets)
+	}
+
+	loadBalancer.Spec.Backends = []string{"vm1", "vm2"}
+	loadBalancer.Spec.Status = common.UPDATE
+	L.NorthOnUpdate(loadBalancer)
+	expected := []string{vm1.Spec.InstanceId, vm2.Spec.InstanceId}
+	if targets := cloud.Targets(loadBalancer.Spec.LoadBalancerId); !reflect.DeepEqual(targets, expected) {
+		t.Fatalf("expected targets %v, got %v", expected, targets)
+	}
+
+	L.NorthOnDelete(loadBalancer)
+	if _, err := cloud.LoadBalancers().Get(ctx, "region-1", loadBalancer.Spec.LoadBalancerId); err != cloudprovider.NotFound {
+		t.Fatalf("expected load balancer deleted, got %v", err)
+	}
+	released, err := cloud.ElasticIPs().Get(ctx, "region-1", elasticIP.Spec.AllocationId)
+	if err != nil || released.Spec.InstanceId != "" {
+		t.Fatalf("expected elastic ip freed, got %+v %v", released, err)
+	}
+}
+
+func TestLocalElasticIPService(t *testing.T) {
+	elasticIP := &networking.ElasticIP{Metadata: core.Metadata{Name: "eip1", Workspace: "ws"}}
+	elasticIP.Spec = networking.ElasticIPSpec{Address: "192.168.10.5", VirtualMachine: "vm1", Ports: []int{22, 443}}
+
+	pool := toAddressPool(elasticIP)
+	addresses, _, _ := unstructured.NestedSlice(pool.Object, "spec", "addresses")
+	if pool.GetNamespace() != metalLBNamespace || !reflect.DeepEqual(addresses, []interface{}{"192.168.10.5/32"}) {
+		t.Fatalf("unexpected address pool %v", pool.Object)
+	}
+
+	service := toElasticIPService(elasticIP)
+	if service.Namespace != "ws" || service.Spec.Type != corev1.ServiceTypeLoadBalancer || service.Spec.Selector["kubevirt.io/vm"] != "vm1" {
+		t.Fatalf("unexpected service %+v", service)
+	}
+	if service.Annotations[metalLBAddressPoolAnnotation] != pool.GetName() || service.Annotations[metalLBIPsAnnotation] != "192.168.10.5" {
+		t.Fatalf("service not pinned to the pool %v", service.Annotations)
+	}
+	if len(service.Spec.Ports) != 2 || service.Spec.Ports[1].Port != 443 || service.Spec.Ports[1].TargetPort.IntValue() != 443 {
+		t.Fatalf("unexpected ports %+v", service.Spec.Ports)
+	}
+}
+
+func TestLocalLoadBalancerEndpoints(t *testing.T) {
+	loadBalancer := &networking.LoadBalancer{Metadata: core.Metadata{Name: "lb1", Workspace: "ws"}}
+	loadBalancer.Spec = networking.LoadBalancerSpec{
+		Listeners: []networking.Listener{{Protocol: "udp", Port: 53}, {Protocol: "http", Port: 80, BackendPort: 8080}},
+		Backends:  []string{"vm1", "vm2", "vm3"},
+	}
+	loadBalancer.Spec.Normalize()
+
+	service := toLoadBalancerService(loadBalancer, nil)
+	if service.Spec.Selector != nil || len(service.Spec.Ports) != 2 ||
+		service.Spec.Ports[0].Protocol != corev1.ProtocolUDP || service.Spec.Ports[1].Protocol != corev1.ProtocolTCP ||
+		service.Spec.Ports[1].TargetPort.IntValue() != 8080 {
+		t.Fatalf("unexpected service %+v", service.Spec)
+	}
+
+	ready := kubeVirtV1.VirtualMachineInstance{}
+	ready.Name = "vm1"
+	ready.Status.Phase = kubeVirtV1.Running
+	ready.Status.Interfaces = []kubeVirtV1.VirtualMachineInstanceNetworkInterface{{IP: "10.0.0.1"}}
+	ready.Status.Conditions = []kubeVirtV1.VirtualMachineInstanceCondition{{Type: kubeVirtV1.VirtualMachineInstanceReady, Status: corev1.ConditionTrue}}
+	booting := kubeVirtV1.VirtualMachineInstance{}
+	booting.Name = "vm2"
+	booting.Status.Phase = kubeVirtV1.Running
+	booting.Status.Interfaces = []kubeVirtV1.VirtualMachineInstanceNetworkInterface{{IP: "10.0.0.2"}}
+	scheduling := kubeVirtV1.VirtualMachineInstance{}
+	scheduling.Name = "vm3"
+	scheduling.Status.Phase = kubeVirtV1.Scheduling
+
+	endpoints := toEndpoints(loadBalancer, []kubeVirtV1.VirtualMachineInstance{ready, booting, scheduling})
+	if endpoints.Name != service.Name || len(endpoints.Subsets) != 1 {
+		t.Fatalf("unexpected endpoints %+v", endpoints)
+	}
+	subset := endpoints.Subsets[0]
+	if len(subset.Addresses) != 1 || subset.Addresses[0].IP != "10.0.0.1" ||
+		len(subset.NotReadyAddresses) != 1 || subset.NotReadyAddresses[0].IP != "10.0.0.2" {
+		t.Fatalf("unexpected addresses %+v", subset)
+	}
+	for index, port := range subset.Ports {
+		if port.Name != service.Spec.Ports[index].Name || port.Port != int32(service.Spec.Ports[index].TargetPort.IntValue()) {
+			t.Fatalf("endpoint port %+v does not match service port %+v", port, service.Spec.Ports[index])
+		}
+	}
+
+	if endpoints := toEndpoints(loadBalancer, nil); len(endpoints.Subsets) != 0 {
+		t.Fatalf("expected no subset without running backends, got %+v", endpoints.Subsets)
+	}
+}
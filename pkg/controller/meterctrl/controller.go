@@ -0,0 +1,275 @@
+package meterctrl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/metering"
+	"github.com/ddx2x/oilmont/pkg/proc"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/utils/obj"
+	"github.com/ddx2x/oilmont/pkg/utils/uuid"
+)
+
+// DefaultSampleInterval how often the usage of the billed objects is recorded
+const DefaultSampleInterval = 15 * time.Minute
+
+var _ controller.Controller = &MeterController{}
+
+// MeterController record the usage of the objects of the workspaces. Every interval each billed object is sampled
+// from the stage, the run time of a vm is closed by the watch as soon as the vm stops. How far each usage is metered
+// is kept in the usagemeter table and a window is recorded by the replica which claimed it first in the usagewindow
+// table, so the replicas neither bill a window twice nor lose the windows across restarts
+type MeterController struct {
+	datasource.IStorage
+	proc     *proc.Proc
+	interval time.Duration
+	flog     log.Logger
+	// replica the id a window is claimed with
+	replica string
+	// started an object first seen before any sample was recorded is billed from it
+	started int64
+}
+
+// sampleMeter the meter of the samples, an object first seen is billed from the last one. The usage keys hold slashes
+const sampleMeter = "sample"
+
+func NewMeterController(store datasource.IStorage) *MeterController {
+	hostname, _ := os.Hostname()
+	return &MeterController{
+		IStorage: store,
+		proc:     proc.NewProc(),
+		interval: DefaultSampleInterval,
+		flog:     log.GetLogger(context.Background()).WithField("controller", "meterctrl"),
+		replica:  fmt.Sprintf("%s-%s", hostname, uuid.NewSUID().String()),
+		started:  time.Now().Unix(),
+	}
+}
+
+func (m *MeterController) Run() error {
+	m.proc.Add(m.WatchVirtualMachine, m.SampleLoop)
+	return <-m.proc.Start()
+}
+
+// SampleLoop record the usage of the billed objects each interval
+func (m *MeterController) SampleLoop(errC chan<- error) {
+	flog := m.flog.WithField("thread", "sample")
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if err := m.Sample(now); err != nil {
+			flog.Infof("sample usage error %s\n", err)
+		}
+	}
+}
+
+// Sample record the usage of every billed object from when it was last metered until now
+func (m *MeterController) Sample(now time.Time) error {
+	meter := metering.New(m.IStorage)
+	usages, err := meter.Sample()
+	if err != nil {
+		return err
+	}
+	prices, err := meter.Prices()
+	if err != nil {
+		return err
+	}
+	meters, err := m.meters()
+	if err != nil {
+		return err
+	}
+
+	last := m.started
+	if sample, exist := meters[sampleMeter]; exist {
+		last = sample.Spec.End
+	}
+	end := now.Unix()
+	seen := map[string]bool{sampleMeter: true}
+	for _, usage := range usages {
+		key := usage.Key()
+		seen[key] = true
+		start := last
+		if usageMeter, exist := meters[key]; exist {
+			start = usageMeter.Spec.End
+		}
+		if start >= end {
+			continue
+		}
+		claimed, err := m.claim(key, start)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		record := meter.Record(usage, prices, start, end, system.UsageSourceSample)
+		if _, err := m.Create(common.DefaultDatabase, common.USAGERECORD, record); err != nil {
+			return err
+		}
+		if err := m.advance(key, end); err != nil {
+			return err
+		}
+	}
+	// an object no longer billed is billed from the sample it comes back at
+	for key, usageMeter := range meters {
+		if !seen[key] {
+			if err := m.DeleteByUUID(common.DefaultDatabase, common.USAGEMETER, usageMeter.GetUUID()); err != nil {
+				return err
+			}
+		}
+	}
+	if last < end {
+		return m.advance(sampleMeter, end)
+	}
+	return nil
+}
+
+// Observe close the run time of a vm which stopped, a vm which started is billed from now on
+func (m *MeterController) Observe(vm *compute.VirtualMachine, now time.Time) error {
+	usage := metering.RunUsage(vm)
+	key := usage.Key()
+
+	usageMeter, err := m.meter(key)
+	if err != nil {
+		return err
+	}
+	running := metering.Running(vm)
+	switch {
+	case running && usageMeter == nil:
+		return m.advance(key, now.Unix())
+	case !running && usageMeter != nil:
+		start := usageMeter.Spec.End
+		if start < now.Unix() {
+			claimed, err := m.claim(key, start)
+			if err != nil || !claimed {
+				return err
+			}
+			meter := metering.New(m.IStorage)
+			prices, err := meter.Prices()
+			if err != nil {
+				return err
+			}
+			record := meter.Record(usage, prices, start, now.Unix(), system.UsageSourceEvent)
+			if _, err := m.Create(common.DefaultDatabase, common.USAGERECORD, record); err != nil {
+				return err
+			}
+		}
+		return m.DeleteByUUID(common.DefaultDatabase, common.USAGEMETER, usageMeter.GetUUID())
+	}
+	return nil
+}
+
+type usageWindow struct {
+	Id   string `json:"_id" bson:"_id"`
+	Data string `json:"data" bson:"data"`
+}
+
+// claim whether this replica is the one recording the window of the usage of key starting at start. The first
+// replica to insert the window keeps it, the insert of the others is ignored
+func (m *MeterController) claim(key string, start int64) (bool, error) {
+	id := fmt.Sprintf("%s/%d", key, start)
+	if err := m.InsertUnique(common.DefaultDatabase, common.USAGEWINDOW, id, m.replica); err != nil {
+		return false, err
+	}
+	window := &usageWindow{}
+	if err := m.GetById(common.DefaultDatabase, common.USAGEWINDOW, id, window); err != nil {
+		return false, err
+	}
+	return window.Data == m.replica, nil
+}
+
+// meters the stored meters by usage key
+func (m *MeterController) meters() (map[string]*system.UsageMeter, error) {
+	meters := make([]system.UsageMeter, 0)
+	if err := m.ListToObject(common.DefaultDatabase, common.USAGEMETER, map[string]interface{}{}, &meters, true); err != nil {
+		return nil, err
+	}
+	result := make(map[string]*system.UsageMeter, len(meters))
+	for index := range meters {
+		result[meters[index].GetName()] = &meters[index]
+	}
+	return result, nil
+}
+
+// meter the stored meter of key, nil when the usage is not metered
+func (m *MeterController) meter(key string) (*system.UsageMeter, error) {
+	usageMeter := &system.UsageMeter{}
+	err := m.GetByFilter(common.DefaultDatabase, common.USAGEMETER, usageMeter, map[string]interface{}{common.FilterName: key}, true)
+	if err == datasource.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return usageMeter, nil
+}
+
+// advance store that the usage of key is metered until end
+func (m *MeterController) advance(key string, end int64) error {
+	usageMeter := &system.UsageMeter{Metadata: core.Metadata{Name: key}}
+	usageMeter.Spec.End = end
+	_, _, err := m.Apply(common.DefaultDatabase, common.USAGEMETER, key, usageMeter, false)
+	return err
+}
+
+// WatchVirtualMachine follow the state of the vms, the ones running at start are billed from the start
+func (m *MeterController) WatchVirtualMachine(errC chan<- error) {
+	m.flog.Info("MeterController start watch virtualmachine")
+	flog := m.flog.WithField("thread", "virtualmachine")
+
+	vmCoder := datasource.GetCoder(string(compute.VirtualMachineKind))
+	if vmCoder == nil {
+		errC <- fmt.Errorf("(%s) %s", compute.VirtualMachineKind, "coder not exist")
+		return
+	}
+	vmWatchChan := datasource.NewWatch(vmCoder)
+
+	vms := make([]compute.VirtualMachine, 0)
+	if err := m.ListToObject(common.DefaultDatabase, common.VIRTUALMACHINE, map[string]interface{}{}, &vms, true); err != nil {
+		errC <- err
+		return
+	}
+	var version = "0"
+	for index := range vms {
+		if vms[index].GetResourceVersion() > version {
+			version = vms[index].GetResourceVersion()
+		}
+		// a running vm metered before the restart keeps its meter, the others are billed from the start
+		if !metering.Running(&vms[index]) {
+			continue
+		}
+		if err := m.Observe(&vms[index], time.Unix(m.started, 0)); err != nil {
+			flog.Infof("observe virtualmachine %s error %s\n", vms[index].GetName(), err)
+		}
+	}
+
+	m.Watch(common.DefaultDatabase, common.VIRTUALMACHINE, version, vmWatchChan)
+
+	for {
+		select {
+		case item, ok := <-vmWatchChan.ResultChan():
+			if !ok {
+				errC <- fmt.Errorf("virtualmachine watch closed")
+				return
+			}
+
+			vm := &compute.VirtualMachine{}
+			if err := obj.UnstructuredObjectToInstanceObj(&item, vm); err != nil {
+				flog.Infof("watch virtualmachine unstruct error %s\n", err)
+				continue
+			}
+			if err := m.Observe(vm, time.Now()); err != nil {
+				flog.Infof("observe virtualmachine %s error %s\n", vm.GetName(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package meterctrl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+func TestSampleAndObserve(t *testing.T) {
+	stage := memory.NewMemory()
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Workspace: "ws1"}}
+	vm.Spec.CPU, vm.Spec.State = "2", compute.Running
+	if _, err := stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := NewMeterController(stage)
+	start := time.Unix(ctrl.started, 0)
+	if err := ctrl.Sample(start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the vm stops half an hour after the sample, the event closes its run time
+	vm.Spec.State = compute.Stopped
+	if err := ctrl.Observe(vm, start.Add(90*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := stage.Apply(common.DefaultDatabase, common.VIRTUALMACHINE, vm.GetName(), vm, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.Sample(start.Add(2 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	records := make([]system.UsageRecord, 0)
+	if err := stage.ListToObject(common.DefaultDatabase, common.USAGERECORD, map[string]interface{}{}, &records, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected a sampled and an event record, got %d", len(records))
+	}
+	var hours float64
+	for _, record := range records {
+		hours += record.Spec.Hours()
+		if record.Spec.Source == system.UsageSourceEvent && record.Spec.Hours() != 0.5 {
+			t.Fatalf("expected the event record to cover half an hour, got %v", record.Spec.Hours())
+		}
+	}
+	if hours != 1.5 {
+		t.Fatalf("expected the vm to be billed 1.5 hours, got %v", hours)
+	}
+}
+
+// TestSampleReplicas the replicas sampling at once bill a window once, a restarted replica goes on from the stored meter
+func TestSampleReplicas(t *testing.T) {
+	stage := memory.NewMemory()
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Workspace: "ws1"}}
+	vm.Spec.CPU, vm.Spec.State = "2", compute.Running
+	if _, err := stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := NewMeterController(stage), NewMeterController(stage)
+	start := time.Unix(first.started, 0)
+	for _, ctrl := range []*MeterController{first, second} {
+		if err := ctrl.Sample(start.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restarted := NewMeterController(stage)
+	restarted.started = start.Add(90 * time.Minute).Unix()
+	if err := restarted.Sample(start.Add(2 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	records := make([]system.UsageRecord, 0)
+	if err := stage.ListToObject(common.DefaultDatabase, common.USAGERECORD, map[string]interface{}{}, &records, true); err != nil {
+		t.Fatal(err)
+	}
+	var hours float64
+	for _, record := range records {
+		hours += record.Spec.Hours()
+	}
+	if len(records) != 2 || hours != 2 {
+		t.Fatalf("expected two records billing 2 hours, got %d billing %v", len(records), hours)
+	}
+}
@@ -0,0 +1,54 @@
+package networkinterfacectrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/ipam"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+// allocateAddress take the primary private address of the networkInterface from its subnet,
+// a subnet unknown to the stage is left to the provider
+func (V *NetworkInterfaceCtrl) allocateAddress(networkInterface *networking.NetworkInterface) error {
+	flog := V.flog.WithField("func", "allocateAddress")
+	if networkInterface.Spec.SubnetId == "" {
+		return nil
+	}
+
+	addresses := ipam.New(V.stage)
+	vswitch, err := addresses.VSwitchOf(networkInterface.GetWorkspace(), networkInterface.Spec.SubnetId)
+	if err != nil {
+		flog.Infof("networkInterface %s %v, address left to the provider", networkInterface.GetName(), err)
+		return nil
+	}
+
+	address, err := addresses.Allocate(vswitch, string(networking.NetworkInterfaceKind), networkInterface.GetName(), networkInterface.Spec.PrivateIpAddress)
+	if err != nil {
+		return err
+	}
+	if address == networkInterface.Spec.PrivateIpAddress {
+		return nil
+	}
+	networkInterface.Spec.PrivateIpAddress = address
+	_, err = V.stage.ApplyStatus(common.DefaultDatabase, common.NETWORKINTERFACE, networkInterface.GetName(), networkInterface, "spec.private_ip_address")
+	return err
+}
+
+func (V *NetworkInterfaceCtrl) releaseAddress(networkInterface *networking.NetworkInterface) {
+	flog := V.flog.WithField("func", "releaseAddress")
+	err := ipam.New(V.stage).ReleaseOwner(networkInterface.GetWorkspace(), string(networking.NetworkInterfaceKind), networkInterface.GetName())
+	if err != nil {
+		flog.Warnf("release address of networkInterface %s error %v", networkInterface.GetName(), err)
+	}
+}
+
+// ownedPaths the spec fields of a networkInterface the controller writes back with the progress: what the vendor
+// assigned. They bump no generation
+var ownedPaths = append([]string{"spec.id", "spec.mac_address", "spec.private_ip_address", "spec.private_ip_sets", "spec.state"}, datasource.ProgressPaths...)
+
+func (V *NetworkInterfaceCtrl) changeStatus(networkInterface *networking.NetworkInterface, status string, message string) error {
+	networkInterface.Spec.Status = status
+	networkInterface.Spec.Message = message
+	_, err := V.stage.ApplyStatus(common.DefaultDatabase, common.NETWORKINTERFACE, networkInterface.GetName(), networkInterface, ownedPaths...)
+	return err
+}
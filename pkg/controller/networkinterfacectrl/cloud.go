@@ -0,0 +1,88 @@
+package networkinterfacectrl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	_ "github.com/ddx2x/oilmont/pkg/cloudprovider/providers"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+func isCloud(networkInterface *networking.NetworkInterface) bool {
+	return cloudprovider.IsRegistered(networkInterface.GetNamespace())
+}
+
+// cloudFail record err on the networkInterface, a cloud networkInterface has no custom resource to report it back
+func (V *NetworkInterfaceCtrl) cloudFail(networkInterface *networking.NetworkInterface, err error) {
+	flog := V.flog.WithField("func", "cloudFail")
+	flog.Warnf("networkInterface %s error %v", networkInterface.GetName(), err)
+	if applyErr := V.changeStatus(networkInterface, common.FAIL, err.Error()); applyErr != nil {
+		flog.Warnf("change networkInterface status error %v", applyErr)
+	}
+	V.reportSynced(networkInterface, err)
+	V.reportReady(networkInterface)
+}
+
+// createCloudNetworkInterface create the vendor network interface in the subnet and security groups it
+// references and store what the vendor assigned
+func (V *NetworkInterfaceCtrl) createCloudNetworkInterface(networkInterface *networking.NetworkInterface) {
+	flog := V.flog.WithField("func", "createCloudNetworkInterface")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if networkInterface.Spec.ID == "" {
+		provider, err := cloudprovider.ForProvider(V.stage, networkInterface.GetNamespace(), networkInterface.Spec.Region)
+		if err != nil {
+			V.cloudFail(networkInterface, err)
+			return
+		}
+		workspace := networkInterface.GetWorkspace()
+		vendor := *networkInterface
+		vendor.Spec.SubnetId = controller.CloudId(V.stage, common.VSWITCH, workspace, networkInterface.Spec.SubnetId)
+		vendor.Spec.VPCId = controller.CloudId(V.stage, common.VPC, workspace, networkInterface.Spec.VPCId)
+		vendor.Spec.SecurityGroupIds = make([]string, 0, len(networkInterface.Spec.SecurityGroupIds))
+		for _, id := range networkInterface.Spec.SecurityGroupIds {
+			vendor.Spec.SecurityGroupIds = append(vendor.Spec.SecurityGroupIds, controller.CloudId(V.stage, common.SECURITYGROUP, workspace, id))
+		}
+		if err := provider.NetworkInterfaces().Create(ctx, &vendor); err != nil {
+			V.cloudFail(networkInterface, fmt.Errorf("create network interface error %v", err))
+			return
+		}
+		networkInterface.Spec.ID = vendor.Spec.ID
+		networkInterface.Spec.MacAddress = vendor.Spec.MacAddress
+		networkInterface.Spec.PrivateIpAddress = vendor.Spec.PrivateIpAddress
+		networkInterface.Spec.PrivateIpSets = vendor.Spec.PrivateIpSets
+		networkInterface.Spec.State = vendor.Spec.State
+		flog.Infof("create networkInterface %s id %s", networkInterface.GetName(), networkInterface.Spec.ID)
+	}
+
+	if applyErr := V.changeStatus(networkInterface, common.RUNNING, "success"); applyErr != nil {
+		flog.Warnf("change networkInterface status error %v", applyErr)
+	}
+	V.reportSynced(networkInterface, nil)
+	V.reportReady(networkInterface)
+}
+
+func (V *NetworkInterfaceCtrl) deleteCloudNetworkInterface(networkInterface *networking.NetworkInterface) {
+	flog := V.flog.WithField("func", "deleteCloudNetworkInterface")
+	if networkInterface.Spec.ID == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(V.stage, networkInterface.GetNamespace(), networkInterface.Spec.Region)
+	if err != nil {
+		flog.Warnf("get cloud provider error %v", err)
+		return
+	}
+	err = provider.NetworkInterfaces().Delete(ctx, networkInterface.Spec.Region, networkInterface.Spec.ID)
+	if err != nil && err != cloudprovider.NotFound {
+		flog.Warnf("delete networkInterface %s id %s error %v", networkInterface.GetName(), networkInterface.Spec.ID, err)
+		return
+	}
+	flog.Infof("delete networkInterface %s id %s", networkInterface.GetName(), networkInterface.Spec.ID)
+}
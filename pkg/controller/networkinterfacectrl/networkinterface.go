@@ -3,10 +3,12 @@ package networkinterfacectrl
 import (
 	"context"
 
+	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/controller"
 	"github.com/ddx2x/oilmont/pkg/controller/clients"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -17,16 +19,36 @@ var (
 var _ controller.Handler = &NetworkInterfaceCtrl{}
 
 type NetworkInterfaceCtrl struct {
-	stage datasource.IStorage
-	cs    *clients.Clients
-	flog  log.Logger
+	stage     datasource.IStorage
+	cs        *clients.Clients
+	placement *controller.PlacementResolver
+	flog      log.Logger
 }
 
 func (V *NetworkInterfaceCtrl) Set(cs *clients.Clients, stage datasource.IStorage) {
 	V.cs, V.stage = cs, stage
+	V.placement = controller.NewPlacementResolver(stage, cs)
 }
 
 func NewNetworkInterfaceCtrl(ctx context.Context) controller.Handler {
 	flog := log.GetLogger(ctx).WithField("controller", "networkInterfaceCtrl")
 	return &NetworkInterfaceCtrl{flog: flog}
 }
+
+func (V *NetworkInterfaceCtrl) reportSynced(networkInterface *networking.NetworkInterface, err error) {
+	if reportErr := controller.ReportObserved(V.stage, common.NETWORKINTERFACE, networkInterface, controller.SyncedCondition(err)); reportErr != nil {
+		V.flog.Warnf("report networkInterface %s synced condition error %v", networkInterface.GetName(), reportErr)
+	}
+}
+
+func (V *NetworkInterfaceCtrl) reportReady(networkInterface *networking.NetworkInterface) {
+	condition := controller.ReadyCondition(networkInterface.Spec.Status, networkInterface.Spec.Message)
+	if reportErr := controller.ReportCondition(V.stage, common.NETWORKINTERFACE, networkInterface, condition); reportErr != nil {
+		V.flog.Warnf("report networkInterface %s ready condition error %v", networkInterface.GetName(), reportErr)
+	}
+}
+
+// clientOf client of the cluster owning the networkInterface
+func (V *NetworkInterfaceCtrl) clientOf(networkInterface *networking.NetworkInterface) (*clients.KubeClient, error) {
+	return V.placement.Client(controller.Placement{Provider: networkInterface.GetNamespace(), Region: networkInterface.Spec.Region, Az: networkInterface.Spec.Zone, Workspace: networkInterface.GetWorkspace()})
+}
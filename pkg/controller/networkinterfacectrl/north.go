@@ -2,8 +2,12 @@ package networkinterfacectrl
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
 	"github.com/ddx2x/oilmont/pkg/resource/networking"
 	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,18 +17,35 @@ import (
 func (V *NetworkInterfaceCtrl) NorthOnAdd(obj core.IObject) {
 	flog := V.flog.WithField("func", "NorthOnAdd")
 
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
-	if err != nil {
-		flog.Infof("get client error %v", err)
-		return
-	}
-
 	networkInterface := &networking.NetworkInterface{}
 	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, networkInterface); err != nil {
 		flog.Warnf("unstructured obj error %v", err)
 		return
 	}
 
+	if networkInterface.Spec.Status != common.INIT {
+		return
+	}
+	if err := V.allocateAddress(networkInterface); err != nil {
+		flog.Warnf("allocate address of networkInterface %s error %v", networkInterface.GetName(), err)
+		if applyErr := V.changeStatus(networkInterface, common.FAIL, err.Error()); applyErr != nil {
+			flog.Warnf("change networkInterface status error %v", applyErr)
+		}
+		V.reportSynced(networkInterface, err)
+		V.reportReady(networkInterface)
+		return
+	}
+	if isCloud(networkInterface) {
+		V.createCloudNetworkInterface(networkInterface)
+		return
+	}
+
+	client, err := V.clientOf(networkInterface)
+	if err != nil {
+		flog.Infof("get client error %v", err)
+		return
+	}
+
 	unstructuredENI, ok, err := V.checkObjStatusAndGetUnstructuredObj(networkInterface, common.INIT)
 	if !ok {
 		if err != nil {
@@ -35,6 +56,7 @@ func (V *NetworkInterfaceCtrl) NorthOnAdd(obj core.IObject) {
 
 	_, err = client.Interface.Resource(NetworkInterfaceGvr).Namespace(unstructuredENI.GetNamespace()).Create(
 		context.Background(), unstructuredENI, metav1.CreateOptions{})
+	V.reportSynced(networkInterface, err)
 	if err != nil {
 		flog.Infof("create networkInterface error %v", err)
 		return
@@ -47,17 +69,22 @@ func (V *NetworkInterfaceCtrl) NorthOnAdd(obj core.IObject) {
 func (V *NetworkInterfaceCtrl) NorthOnUpdate(obj core.IObject) {
 	flog := V.flog.WithField("func", "NorthOnUpdate")
 
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
-	if err != nil {
-		flog.Infof("get client error %v", err)
-		return
-	}
-
 	networkInterface := &networking.NetworkInterface{}
 	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, networkInterface); err != nil {
 		flog.Warnf("unstructured obj error %v", err)
 		return
 	}
+	if isCloud(networkInterface) && networkInterface.Spec.Status == common.UPDATE {
+		// a vendor network interface is not changed once created
+		V.cloudFail(networkInterface, fmt.Errorf("update networkInterface %s error %v", networkInterface.GetName(), cloudprovider.NotImplemented))
+		return
+	}
+
+	client, err := V.clientOf(networkInterface)
+	if err != nil {
+		flog.Infof("get client error %v", err)
+		return
+	}
 
 	unstructuredENI, ok, err := V.checkObjStatusAndGetUnstructuredObj(networkInterface, common.UPDATE)
 	if !ok {
@@ -69,7 +96,7 @@ func (V *NetworkInterfaceCtrl) NorthOnUpdate(obj core.IObject) {
 
 	_, err = client.Interface.Resource(NetworkInterfaceGvr).Namespace(unstructuredENI.GetNamespace()).Update(
 		context.Background(), unstructuredENI, metav1.UpdateOptions{})
-
+	V.reportSynced(networkInterface, err)
 	if err != nil {
 		flog.Infof("update networkInterface obj error %v", err)
 		return
@@ -80,6 +107,16 @@ func (V *NetworkInterfaceCtrl) NorthOnUpdate(obj core.IObject) {
 }
 
 func (V *NetworkInterfaceCtrl) NorthOnDelete(obj core.IObject) {
+	networkInterface := &networking.NetworkInterface{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, networkInterface); err != nil {
+		V.flog.WithField("func", "NorthOnDelete").Warnf("unstructured obj error %v", err)
+		return
+	}
+	if isCloud(networkInterface) {
+		V.deleteCloudNetworkInterface(networkInterface)
+	}
+	V.releaseAddress(networkInterface)
+
 	//flog := V.flog.WithField("func", "NorthOnDelete")
 	//
 	//client, err := V.cs.GetClient(common.DefaultKubernetes)
@@ -124,24 +161,9 @@ func (V *NetworkInterfaceCtrl) checkObjStatusAndGetUnstructuredObj(networkInterf
 		return nil, false, nil
 	}
 
-	labels := make([]eniLabel, 0)
-	for k, v := range networkInterface.Labels {
-		label := eniLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
-	}
-
-	networkInterfaceParams := &NetworkInterfaceParams{
-		Name:      networkInterface.Name,
-		Namespace: networkInterface.Namespace,
-		Labels:    labels,
-	}
-
-	unstructuredStorage, err := utilsObj.Render(networkInterfaceParams, networkInterfaceTpl)
+	unstructuredENI, err := ddx2xv1.ToUnstructured(ddx2xv1.ToNetworkInterface(networkInterface))
 	if err != nil {
 		return nil, false, err
 	}
-	return unstructuredStorage, true, nil
+	return unstructuredENI, true, nil
 }
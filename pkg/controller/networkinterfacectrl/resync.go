@@ -0,0 +1,30 @@
+package networkinterfacectrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ controller.Resyncer = &NetworkInterfaceCtrl{}
+
+func (V *NetworkInterfaceCtrl) ResyncTable() string { return common.NETWORKINTERFACE }
+
+func (V *NetworkInterfaceCtrl) ResyncGvr() schema.GroupVersionResource { return NetworkInterfaceGvr }
+
+// ToSouth the network interface custom resource the stage object is pushed as, none for a cloud network interface
+func (V *NetworkInterfaceCtrl) ToSouth(obj core.IObject) (*unstructured.Unstructured, error) {
+	object := &networking.NetworkInterface{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, object); err != nil {
+		return nil, err
+	}
+	if isCloud(object) {
+		return nil, nil
+	}
+	return ddx2xv1.ToUnstructured(ddx2xv1.ToNetworkInterface(object))
+}
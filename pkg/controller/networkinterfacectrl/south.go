@@ -3,10 +3,9 @@ package networkinterfacectrl
 import (
 	"context"
 	"github.com/ddx2x/oilmont/pkg/common"
-	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/datasource"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
 	"github.com/ddx2x/oilmont/pkg/resource/networking"
-	"github.com/ddx2x/oilmont/pkg/resource/system"
 	objUtils "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -14,17 +13,13 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 )
 
-func (V *NetworkInterfaceCtrl) SouthOnAdd(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnAdd")
-	unstructuredENI := &unstructured.Unstructured{}
-
-	err := objUtils.Unmarshal(unstructuredENI, obj)
-	if err != nil {
-		flog.Warnf("unmarshal networkInterface data error: %v", obj)
-	}
-
-	networkInterface, ok := V.checkObjStatusAndGetObj(unstructuredENI, common.INIT)
+func (V *NetworkInterfaceCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnAdd").WithField("cluster", cluster)
+	networkInterface, ok, err := V.checkObjStatusAndGetObj(obj, common.INIT)
 	if !ok {
+		if err != nil {
+			flog.Warnf("unmarshal networkInterface data error: %v", err)
+		}
 		return
 	}
 
@@ -36,6 +31,7 @@ func (V *NetworkInterfaceCtrl) SouthOnAdd(obj runtime.Object) {
 			return
 		}
 		flog.Infof("create networkInterface %s,namespace: %s, workspace: %s", networkInterface.GetName(), networkInterface.GetNamespace(), networkInterface.GetWorkspace())
+		V.reportReady(networkInterface)
 		return
 	}
 	if err != nil {
@@ -51,20 +47,18 @@ func (V *NetworkInterfaceCtrl) SouthOnAdd(obj runtime.Object) {
 	if update {
 		flog.Infof("update networkInterface %s, workspace: %s, namespace: %s", networkInterface.GetName(), networkInterface.GetWorkspace(), networkInterface.GetNamespace())
 	}
+	V.reportReady(networkInterface)
 	return
 }
 
-func (V *NetworkInterfaceCtrl) SouthOnUpdate(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnUpdate")
+func (V *NetworkInterfaceCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnUpdate").WithField("cluster", cluster)
 	var force = false
-	unstructuredENI := &unstructured.Unstructured{}
-	err := objUtils.Unmarshal(unstructuredENI, obj)
-	if err != nil {
-		flog.Warnf("unmarshal networkInterface data error: %v", obj)
-	}
-
-	networkInterface, ok := V.checkObjStatusAndGetObj(unstructuredENI, common.DELETE, common.UPDATE)
+	networkInterface, ok, err := V.checkObjStatusAndGetObj(obj, common.DELETE, common.UPDATE)
 	if !ok {
+		if err != nil {
+			flog.Warnf("unmarshal networkInterface data error: %v", err)
+		}
 		return
 	}
 
@@ -78,13 +72,14 @@ func (V *NetworkInterfaceCtrl) SouthOnUpdate(obj runtime.Object) {
 		flog.Warnf("update networkInterface error: %v", obj)
 		return
 	}
+	V.reportReady(networkInterface)
 
 	flog.Infof("update a networkInterface %s to stage", networkInterface.GetName())
 	return
 }
 
-func (V *NetworkInterfaceCtrl) SouthOnDelete(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnDelete")
+func (V *NetworkInterfaceCtrl) SouthOnDelete(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnDelete").WithField("cluster", cluster)
 	unstructuredENI := &unstructured.Unstructured{}
 
 	err := objUtils.Unmarshal(unstructuredENI, obj)
@@ -104,144 +99,31 @@ func (V *NetworkInterfaceCtrl) SouthOnDelete(obj runtime.Object) {
 	return
 }
 
-func (V *NetworkInterfaceCtrl) SouthEventChs(ctx context.Context) ([]<-chan watch.Event, error) {
-	clusters := make([]system.Cluster, 0)
-	if err := V.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+func (V *NetworkInterfaceCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
 		return nil, err
 	}
 
 	channels := make([]<-chan watch.Event, 0)
-	for _, cluster := range clusters {
-		client, err := V.cs.GetClient(cluster.GetName())
-		if err != nil {
-			return nil, err
-		}
-
-		watchInterface, err := client.Interface.Resource(NetworkInterfaceGvr).Watch(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		channels = append(channels, watchInterface.ResultChan())
+	watchInterface, err := client.Interface.Resource(NetworkInterfaceGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	channels = append(channels, watchInterface.ResultChan())
 
 	return channels, nil
 }
 
-func (V *NetworkInterfaceCtrl) checkObjStatusAndGetObj(unstructuredENI *unstructured.Unstructured, checkStatus ...string) (*networking.NetworkInterface, bool) {
-	status := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "status")
-	for _, s := range checkStatus {
-		if status == s {
-			return nil, false
-		}
-	}
-
-	description := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "description")
-	localName := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "localName")
-	macAddress := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "macAddress")
-	message := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "message")
-	networkInterfaceId := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "networkInterfaceId")
-	privateDnsName := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "privateDnsName")
-	privateIpAddress := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "privateIpAddress")
-	publicDnsName := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "publicDnsName")
-	publicIpAddress := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "publicIpAddress")
-	region := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "region")
-	state := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "state")
-	subnetId := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "subnetId")
-	eniType := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "type")
-	vpcId := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "vpcId")
-	zone := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "zone")
-	attachTime := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "attachment", "attachTime")
-	instanceId := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "attachment", "instanceId")
-	attachStatus := objUtils.GetNestedString(unstructuredENI.Object,
-		"spec", "attachment", "status")
-
-	spec := objUtils.GetNestedMap(unstructuredENI.Object,
-		"spec")
-
-	labels := map[string]interface{}{}
-	getLabels := unstructuredENI.GetLabels()
-	for k, v := range getLabels {
-		labels[k] = v
+func (V *NetworkInterfaceCtrl) checkObjStatusAndGetObj(obj runtime.Object, checkStatus ...string) (*networking.NetworkInterface, bool, error) {
+	unstructuredENI := &ddx2xv1.NetworkInterface{}
+	if err := ddx2xv1.FromUnstructured(obj, unstructuredENI); err != nil {
+		return nil, false, err
 	}
-
-	networkInterface := &networking.NetworkInterface{
-		Metadata: core.Metadata{
-			Name:      unstructuredENI.GetName(),
-			Namespace: unstructuredENI.GetNamespace(),
-			Workspace: getLabels["workspace"],
-			Kind:      core.Kind(unstructuredENI.GetKind()),
-			Labels:    labels,
-		},
-		Spec: networking.NetworkInterfaceSpec{
-			Description:      description,
-			PrivateDnsName:   privateDnsName,
-			PrivateIpAddress: privateIpAddress,
-			PublicDnsName:    publicDnsName,
-			PublicIpAddress:  publicIpAddress,
-			SubnetId:         subnetId,
-			VPCId:            vpcId,
-			MacAddress:       macAddress,
-			Type:             eniType,
-			ID:               networkInterfaceId,
-			LocalName:        localName,
-			Region:           region,
-			Zone:             zone,
-			Status:           status,
-			State:            state,
-			Message:          message,
-			Attachment: networking.ENIAttachment{
-				AttachTime: attachTime,
-				InstanceId: instanceId,
-				Status:     attachStatus,
-			},
-		},
-	}
-	privateIpSets := spec["privateIpSets"]
-	if privateIpSets != nil {
-		for _, obj := range privateIpSets.([]interface{}) {
-			_privateIpSet := obj.(map[string]interface{})
-			ipset := networking.ENIPrivateIpSet{
-				Primary:          _privateIpSet["primary"].(bool),
-				PrivateDnsName:   _privateIpSet["privateDnsName"].(string),
-				PrivateIpAddress: _privateIpSet["privateIpAddress"].(string),
-				PublicIpAddress:  _privateIpSet["publicIpAddress"].(string),
-			}
-			networkInterface.Spec.PrivateIpSets = append(networkInterface.Spec.PrivateIpSets, ipset)
-		}
-	}
-	securityGroupIds := spec["securityGroupIds"]
-	if securityGroupIds != nil {
-		for _, _obj := range securityGroupIds.([]interface{}) {
-			networkInterface.Spec.SecurityGroupIds = append(networkInterface.Spec.SecurityGroupIds, _obj.(string))
-		}
-	}
-	ipv6 := spec["ipv6"]
-	if ipv6 != nil {
-		for _, _obj := range ipv6.([]interface{}) {
-			networkInterface.Spec.Ipv6 = append(networkInterface.Spec.Ipv6, _obj.(string))
+	for _, s := range checkStatus {
+		if unstructuredENI.Spec.Status == s {
+			return nil, false, nil
 		}
 	}
-
-	if networkInterface.Workspace == "" {
-		networkInterface.Workspace = common.DefaultWorkspace
-	}
-	return networkInterface, true
+	return unstructuredENI.ToResource(), true, nil
 }
@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller/clients"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+var NoClusterForPlacement = fmt.Errorf("no cluster match placement")
+
+// Placement where a resource should live, region and az accept either the name or the provider id
+type Placement struct {
+	Provider  string
+	Region    string
+	Az        string
+	Workspace string
+}
+
+func (p Placement) String() string {
+	return fmt.Sprintf("provider=%s,region=%s,az=%s,workspace=%s", p.Provider, p.Region, p.Az, p.Workspace)
+}
+
+// PlacementResolver map a placement to the cluster owning it through the cluster, region and availablezone tables
+type PlacementResolver struct {
+	stage datasource.IStorage
+	cs    *clients.Clients
+}
+
+func NewPlacementResolver(stage datasource.IStorage, cs *clients.Clients) *PlacementResolver {
+	return &PlacementResolver{stage: stage, cs: cs}
+}
+
+// Resolve return the name of the cluster owning placement.
+// A placement without region keeps the legacy behavior and goes to the default cluster.
+func (p *PlacementResolver) Resolve(placement Placement) (string, error) {
+	regions, err := p.regionAliases(placement.Region)
+	if err != nil {
+		return "", err
+	}
+	zones, zoneRegion, err := p.zoneAliases(placement.Az)
+	if err != nil {
+		return "", err
+	}
+	if len(regions) == 0 && zoneRegion != "" {
+		if regions, err = p.regionAliases(zoneRegion); err != nil {
+			return "", err
+		}
+	}
+
+	if len(regions) == 0 && len(zones) == 0 {
+		return common.DefaultKubernetes, nil
+	}
+
+	clusters, err := p.clustersOf(regions, zones)
+	if err != nil {
+		return "", err
+	}
+
+	candidates := make([]candidate, 0)
+	for _, cluster := range clusters {
+		spec := cluster.Spec
+		if placement.Provider != "" && spec.Provider != "" && spec.Provider != placement.Provider {
+			continue
+		}
+		if len(regions) > 0 && !regions[spec.Region] {
+			continue
+		}
+		// a cluster without az serves every az of its region
+		if len(zones) > 0 && spec.Az != "" && !zones[spec.Az] {
+			continue
+		}
+		// a cluster restricted to namespaces only serves the matching workspaces
+		if placement.Workspace != "" && len(spec.Namespaces) > 0 && !contains(spec.Namespaces, placement.Workspace) {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			name:      cluster.GetName(),
+			exactAz:   len(zones) > 0 && spec.Az != "",
+			reachable: p.cs.Reachable(cluster.GetName()),
+		})
+	}
+
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%w: %s", NoClusterForPlacement, placement)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].reachable != candidates[j].reachable {
+			return candidates[i].reachable
+		}
+		if candidates[i].exactAz != candidates[j].exactAz {
+			return candidates[i].exactAz
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	return candidates[0].name, nil
+}
+
+// Client resolve placement and return the client of the owning cluster
+func (p *PlacementResolver) Client(placement Placement) (*clients.KubeClient, error) {
+	cluster, err := p.Resolve(placement)
+	if err != nil {
+		return nil, err
+	}
+	return p.cs.GetClient(cluster)
+}
+
+type candidate struct {
+	name      string
+	exactAz   bool
+	reachable bool
+}
+
+// clustersOf the clusters of the regions, or of the zones when the region is unknown. Only the clusters of the
+// placement are read, the placement is resolved on every north event
+func (p *PlacementResolver) clustersOf(regions, zones map[string]bool) ([]system.Cluster, error) {
+	key, values := "spec.region", regions
+	if len(regions) == 0 {
+		key, values = "spec.az", zones
+	}
+	clusters := make([]system.Cluster, 0)
+	seen := make(map[string]bool)
+	for value := range values {
+		items := make([]system.Cluster, 0)
+		if err := p.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{key: value}, &items, true); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if !seen[item.GetName()] {
+				seen[item.GetName()] = true
+				clusters = append(clusters, item)
+			}
+		}
+	}
+	return clusters, nil
+}
+
+// aliasFilters the filters finding an object referred to by name, either its name or its provider id
+func aliasFilters(name string) []map[string]interface{} {
+	return []map[string]interface{}{{common.FilterName: name}, {"spec.id": name}}
+}
+
+func (p *PlacementResolver) regionAliases(region string) (map[string]bool, error) {
+	if region == "" {
+		return nil, nil
+	}
+	aliases := map[string]bool{region: true}
+
+	for _, filter := range aliasFilters(region) {
+		regions := make([]system.Region, 0)
+		if err := p.stage.ListToObject(common.DefaultDatabase, common.REGION, filter, &regions, true); err != nil {
+			return nil, err
+		}
+		for _, item := range regions {
+			aliases[item.GetName()] = true
+			if item.Spec.ID != "" {
+				aliases[item.Spec.ID] = true
+			}
+		}
+	}
+	return aliases, nil
+}
+
+func (p *PlacementResolver) zoneAliases(az string) (map[string]bool, string, error) {
+	if az == "" {
+		return nil, "", nil
+	}
+	aliases := map[string]bool{az: true}
+	region := ""
+
+	for _, filter := range aliasFilters(az) {
+		zones := make([]system.AvailableZone, 0)
+		if err := p.stage.ListToObject(common.DefaultDatabase, common.AVAILABLEZONE, filter, &zones, true); err != nil {
+			return nil, "", err
+		}
+		for _, item := range zones {
+			aliases[item.GetName()] = true
+			if item.Spec.ID != "" {
+				aliases[item.Spec.ID] = true
+			}
+			region = item.Spec.Region
+		}
+	}
+	return aliases, region, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
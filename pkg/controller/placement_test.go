@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller/clients"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+func TestPlacementResolve(t *testing.T) {
+	stage := memory.NewMemory()
+	region := &system.Region{Metadata: core.Metadata{Name: "r1"}, Spec: system.RegionSpec{ID: "cn-r1"}}
+	if _, err := stage.Create(common.DefaultDatabase, common.REGION, region); err != nil {
+		t.Fatal(err)
+	}
+	for _, cluster := range []*system.Cluster{
+		{Metadata: core.Metadata{Name: "aws-r1"}, Spec: system.ClusterSpec{Provider: common.AWS, Region: "r1"}},
+		{Metadata: core.Metadata{Name: "local-r1"}, Spec: system.ClusterSpec{Provider: "local", Region: "r1"}},
+		{Metadata: core.Metadata{Name: "local-r2"}, Spec: system.ClusterSpec{Provider: "local", Region: "r2"}},
+	} {
+		if _, err := stage.Create(common.DefaultDatabase, common.CLUSTER, cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolver := NewPlacementResolver(stage, clients.NewClients())
+	for provider, expected := range map[string]string{"local": "local-r1", common.AWS: "aws-r1"} {
+		// the region is referred to by its provider id
+		cluster, err := resolver.Resolve(Placement{Provider: provider, Region: "cn-r1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cluster != expected {
+			t.Fatalf("expected provider %s placed on %s, got %s", provider, expected, cluster)
+		}
+	}
+	if _, err := resolver.Resolve(Placement{Provider: common.ALIYUN, Region: "r1"}); !errors.Is(err, NoClusterForPlacement) {
+		t.Fatalf("expected no cluster for another provider, got %v", err)
+	}
+}
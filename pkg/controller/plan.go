@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller/clients"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type PlanAction = string
+
+const (
+	PlanCreate    PlanAction = "create"
+	PlanUpdate    PlanAction = "update"
+	PlanDelete    PlanAction = "delete"
+	PlanUnchanged PlanAction = "unchanged"
+	// PlanApply the current object could not be read, it is created or updated
+	PlanApply PlanAction = "apply"
+)
+
+// Rendered an object a handler would apply to a cluster, or delete from it
+type Rendered struct {
+	Cluster string
+	Gvr     schema.GroupVersionResource
+	Object  *unstructured.Unstructured
+	Delete  bool
+	// Redact the object holds credentials, only the fields changed are planned
+	Redact bool
+}
+
+// Planner a handler able to render the objects a north event of obj would apply, without applying them
+type Planner interface {
+	Plan(eventType core.EventType, obj core.IObject) ([]Rendered, error)
+}
+
+// Change an object of a cluster the change of a stage object would create, update or delete
+type Change struct {
+	Cluster   string     `json:"cluster"`
+	Action    PlanAction `json:"action"`
+	Resource  string     `json:"resource"`
+	Namespace string     `json:"namespace"`
+	Name      string     `json:"name"`
+	// Fields the top level fields and spec fields the update changes
+	Fields  []string               `json:"fields,omitempty"`
+	Current map[string]interface{} `json:"current,omitempty"`
+	Desired map[string]interface{} `json:"desired,omitempty"`
+	Message string                 `json:"message,omitempty"`
+}
+
+// Plan the dry run of a change of a stage object
+type Plan struct {
+	Action  core.EventType `json:"action"`
+	Object  core.IObject   `json:"object"`
+	Changes []Change       `json:"changes"`
+}
+
+// DryRun render the change of a stage object through the planners of the handlers and diff it
+// against the clusters, without persisting or applying anything. Used by the api servers.
+type DryRun struct {
+	stage    datasource.IStorage
+	clients  *clients.Clients
+	planners map[string]Planner
+}
+
+func NewDryRun(stage datasource.IStorage) *DryRun {
+	return &DryRun{stage: stage, clients: clients.NewClients(), planners: make(map[string]Planner)}
+}
+
+// Register plan the changes of table through handler
+func (d *DryRun) Register(table string, handler Handler) error {
+	planner, ok := handler.(Planner)
+	if !ok {
+		return fmt.Errorf("handler %T can not plan", handler)
+	}
+	handler.Set(d.clients, d.stage)
+	d.planners[table] = planner
+	return nil
+}
+
+// Plan the changes eventType of obj would make in the clusters
+func (d *DryRun) Plan(ctx context.Context, table string, eventType core.EventType, obj core.IObject) (*Plan, error) {
+	planner, exist := d.planners[table]
+	if !exist {
+		return nil, fmt.Errorf("%s has no dry run", table)
+	}
+	if err := d.loadClusters(); err != nil {
+		return nil, err
+	}
+
+	rendered, err := planner.Plan(eventType, obj)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{Action: eventType, Object: obj, Changes: d.diff(ctx, rendered)}, nil
+}
+
+// loadClusters register the clusters of the stage, clients are only rebuilt when their config changed
+func (d *DryRun) loadClusters() error {
+	clusters := make([]system.Cluster, 0)
+	if err := d.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		bs, err := json.Marshal(cluster.Spec.Config)
+		if err != nil {
+			return err
+		}
+		if err := d.clients.SetClusterConfig(cluster.GetName(), bs); err != nil {
+			return fmt.Errorf("cluster %s client error %v", cluster.GetName(), err)
+		}
+	}
+	return nil
+}
+
+func (d *DryRun) diff(ctx context.Context, rendered []Rendered) []Change {
+	changes := make([]Change, 0, len(rendered))
+	for _, item := range rendered {
+		var current *unstructured.Unstructured
+		client, err := d.clients.GetClient(item.Cluster)
+		if err == nil {
+			current, err = client.Interface.Resource(item.Gvr).Namespace(item.Object.GetNamespace()).
+				Get(ctx, item.Object.GetName(), metav1.GetOptions{})
+			if errors.IsNotFound(err) {
+				current, err = nil, nil
+			}
+		}
+		changes = append(changes, planChange(item, current, err))
+	}
+	return changes
+}
+
+// planChange the change turning current into the rendered object, current is nil when the object does not exist
+// and err is set when it could not be read
+func planChange(item Rendered, current *unstructured.Unstructured, err error) Change {
+	change := Change{
+		Cluster:   item.Cluster,
+		Resource:  item.Gvr.Resource,
+		Namespace: item.Object.GetNamespace(),
+		Name:      item.Object.GetName(),
+	}
+	if !item.Delete && !item.Redact {
+		change.Desired = item.Object.Object
+	}
+
+	switch {
+	case err != nil:
+		change.Action, change.Message = PlanApply, err.Error()
+		if item.Delete {
+			change.Action = PlanDelete
+		}
+	case item.Delete && current == nil:
+		change.Action = PlanUnchanged
+	case item.Delete:
+		change.Action = PlanDelete
+	case current == nil:
+		change.Action = PlanCreate
+	default:
+		change.Fields = diffObject(item.Object, current)
+		change.Action = PlanUpdate
+		if len(change.Fields) == 0 {
+			change.Action = PlanUnchanged
+		}
+	}
+	if current != nil && !item.Redact {
+		change.Current = current.Object
+	}
+	return change
+}
+
+// diffObject the fields desired sets that current differs in, spec fields but the sync status are compared one by one
+func diffObject(desired, current *unstructured.Unstructured) []string {
+	fields := make([]string, 0)
+	for field, value := range desired.Object {
+		switch field {
+		case "apiVersion", "kind", "metadata", "status":
+			continue
+		case "spec":
+			for _, specField := range diffSpec(desired, current) {
+				fields = append(fields, "spec."+specField)
+			}
+			continue
+		}
+		if !equalValue(value, current.Object[field]) {
+			fields = append(fields, field)
+		}
+	}
+	// labels set by others on current are left alone
+	currentLabels := current.GetLabels()
+	for key, value := range desired.GetLabels() {
+		if currentLabels[key] != value {
+			fields = append(fields, "metadata.labels")
+			break
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
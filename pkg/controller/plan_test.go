@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPlanChange(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "ddx2x.nip", Version: "v1", Resource: "vpcs"}
+	desired := newSouth("vpc", "ws", map[string]interface{}{"ip": "10.0.0.0", "mask": int64(16), "status": "init"})
+	item := Rendered{Cluster: "a", Gvr: gvr, Object: desired}
+
+	if change := planChange(item, nil, nil); change.Action != PlanCreate || change.Desired == nil || change.Current != nil {
+		t.Fatalf("unexpected create change %+v", change)
+	}
+
+	same := newSouth("vpc", "ws", map[string]interface{}{"ip": "10.0.0.0", "mask": int64(16), "status": "running"})
+	if change := planChange(item, same, nil); change.Action != PlanUnchanged {
+		t.Fatalf("unexpected unchanged change %+v", change)
+	}
+
+	changed := newSouth("vpc", "other", map[string]interface{}{"ip": "10.0.0.0", "mask": int64(24)})
+	change := planChange(item, changed, nil)
+	if change.Action != PlanUpdate || len(change.Fields) != 2 ||
+		change.Fields[0] != "metadata.labels" || change.Fields[1] != "spec.mask" {
+		t.Fatalf("unexpected update change %+v", change)
+	}
+
+	deleted := Rendered{Cluster: "a", Gvr: gvr, Object: desired, Delete: true}
+	if change := planChange(deleted, same, nil); change.Action != PlanDelete || change.Desired != nil {
+		t.Fatalf("unexpected delete change %+v", change)
+	}
+	if change := planChange(deleted, nil, nil); change.Action != PlanUnchanged {
+		t.Fatalf("unexpected delete of a missing object %+v", change)
+	}
+
+	// credentials are never planned, only the fields changed
+	redacted := Rendered{Cluster: "a", Gvr: gvr, Object: desired, Redact: true}
+	if change := planChange(redacted, changed, nil); change.Desired != nil || change.Current != nil || len(change.Fields) == 0 {
+		t.Fatalf("unexpected redacted change %+v", change)
+	}
+
+	if change := planChange(item, nil, fmt.Errorf("cluster a unreachable")); change.Action != PlanApply || change.Message == "" {
+		t.Fatalf("unexpected unreadable change %+v", change)
+	}
+}
@@ -0,0 +1,167 @@
+package quotactrl
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/proc"
+	"github.com/ddx2x/oilmont/pkg/quota"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/utils/obj"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	ReasonWithinLimits = "WithinLimits"
+	ReasonExceeded     = "Exceeded"
+)
+
+// DefaultResyncInterval how often the usage of every quota is recomputed, the objects counted are not watched
+const DefaultResyncInterval = time.Minute
+
+var _ controller.Controller = &QuotaController{}
+
+// QuotaController keep the status of the resource quotas up to date with the usage of their scope.
+// Admission does not depend on it, the services compute the usage they admit against
+type QuotaController struct {
+	datasource.IStorage
+	proc     *proc.Proc
+	interval time.Duration
+	flog     log.Logger
+}
+
+func NewQuotaController(store datasource.IStorage) *QuotaController {
+	return &QuotaController{
+		IStorage: store,
+		proc:     proc.NewProc(),
+		interval: DefaultResyncInterval,
+		flog:     log.GetLogger(context.Background()).WithField("controller", "quotactrl"),
+	}
+}
+
+func (q *QuotaController) Run() error {
+	q.proc.Add(q.WatchQuota, q.Resync)
+	return <-q.proc.Start()
+}
+
+// WatchQuota reconcile a quota as soon as it is created or its limits change
+func (q *QuotaController) WatchQuota(errC chan<- error) {
+	q.flog.Info("QuotaController start watch resource quota")
+	flog := q.flog.WithField("thread", "resourcequota")
+
+	quotaCoder := datasource.GetCoder(string(system.ResourceQuotaKind))
+	if quotaCoder == nil {
+		errC <- fmt.Errorf("(%s) %s", system.ResourceQuotaKind, "coder not exist")
+		return
+	}
+	quotaWatchChan := datasource.NewWatch(quotaCoder)
+
+	quotas := make([]system.ResourceQuota, 0)
+	if err := q.ListToObject(common.DefaultDatabase, common.RESOURCEQUOTA, map[string]interface{}{}, &quotas, true); err != nil {
+		errC <- err
+		return
+	}
+	var version = "0"
+	for index := range quotas {
+		if quotas[index].GetResourceVersion() > version {
+			version = quotas[index].GetResourceVersion()
+		}
+		if err := q.Reconcile(&quotas[index], true); err != nil {
+			flog.Infof("reconcile resource quota %s error %s\n", quotas[index].GetName(), err)
+		}
+	}
+
+	q.Watch(common.DefaultDatabase, common.RESOURCEQUOTA, version, quotaWatchChan)
+
+	for {
+		select {
+		case item, ok := <-quotaWatchChan.ResultChan():
+			if !ok {
+				errC <- fmt.Errorf("resource quota watch closed")
+				return
+			}
+
+			resourceQuota := &system.ResourceQuota{}
+			if err := obj.UnstructuredObjectToInstanceObj(&item, resourceQuota); err != nil {
+				flog.Infof("watch resource quota unstruct error %s\n", err)
+				continue
+			}
+			if resourceQuota.IsDelete {
+				continue
+			}
+			// the status written back comes again through the watch, it is only written when the usage moved
+			if err := q.Reconcile(resourceQuota, false); err != nil {
+				flog.Infof("watch resource quota %s reconcile error %s\n", resourceQuota.GetName(), err)
+			}
+		}
+	}
+}
+
+// Resync reconcile every quota each interval, this is what follows the objects created and deleted in the scopes
+func (q *QuotaController) Resync(errC chan<- error) {
+	flog := q.flog.WithField("thread", "resync")
+	ticker := time.NewTicker(q.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		quotas := make([]system.ResourceQuota, 0)
+		if err := q.ListToObject(common.DefaultDatabase, common.RESOURCEQUOTA, map[string]interface{}{}, &quotas, true); err != nil {
+			flog.Infof("list resource quota error %s\n", err)
+			continue
+		}
+		for index := range quotas {
+			if err := q.Reconcile(&quotas[index], true); err != nil {
+				flog.Infof("resync resource quota %s error %s\n", quotas[index].GetName(), err)
+			}
+		}
+	}
+}
+
+// Reconcile record the usage of the scope of resourceQuota and the limits it is above. Without force the status is
+// left alone when neither the usage nor the exceeded limits changed
+func (q *QuotaController) Reconcile(resourceQuota *system.ResourceQuota, force bool) error {
+	usage, err := quota.New(q.IStorage).Usage(resourceQuota, nil)
+	if err != nil {
+		return err
+	}
+	used := usage.Strings()
+
+	exceeded := make([]string, 0)
+	for name, limit := range resourceQuota.Spec.Hard {
+		hard, err := resource.ParseQuantity(limit)
+		if err != nil {
+			continue
+		}
+		quantity := usage[name]
+		if quantity.Cmp(hard) > 0 {
+			exceeded = append(exceeded, name)
+		}
+	}
+	sort.Strings(exceeded)
+
+	status := &resourceQuota.Status
+	if !force && reflect.DeepEqual(status.Used, used) && reflect.DeepEqual(status.Exceeded, exceeded) {
+		return nil
+	}
+	status.Used = used
+	status.Exceeded = exceeded
+	status.ReconcileTime = time.Now().Unix()
+	if len(exceeded) > 0 {
+		status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionFalse, ReasonExceeded,
+			fmt.Sprintf("usage above the limits of %s", strings.Join(exceeded, ", "))))
+	} else {
+		status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionTrue, ReasonWithinLimits, ""))
+	}
+
+	_, err = q.ApplyStatus(common.DefaultDatabase, common.RESOURCEQUOTA, resourceQuota.GetName(), resourceQuota)
+	return err
+}
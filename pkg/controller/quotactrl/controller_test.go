@@ -0,0 +1,61 @@
+package quotactrl
+
+import (
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+func TestReconcile(t *testing.T) {
+	stage := memory.NewMemory()
+	resourceQuota := &system.ResourceQuota{Metadata: core.Metadata{Name: "ws1-quota", Workspace: "ws1"}}
+	resourceQuota.Spec.Hard = map[string]string{system.QuotaCount(common.VIRTUALMACHINE): "1", system.QuotaMemory: "8Gi"}
+	if _, err := stage.Create(common.DefaultDatabase, common.RESOURCEQUOTA, resourceQuota); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"vm1", "vm2"} {
+		vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: name, Workspace: "ws1"}}
+		vm.Spec.CPU, vm.Spec.Memory = "1", "2Gi"
+		if _, err := stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctrl := NewQuotaController(stage)
+	if err := ctrl.Reconcile(resourceQuota, false); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := &system.ResourceQuota{}
+	filter := map[string]interface{}{common.FilterName: "ws1-quota"}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.RESOURCEQUOTA, stored, filter, true); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status.Used[system.QuotaMemory] != "4Gi" || stored.Status.Used[system.QuotaCount(common.VIRTUALMACHINE)] != "2" {
+		t.Fatalf("unexpected usage %v", stored.Status.Used)
+	}
+	if len(stored.Status.Exceeded) != 1 || stored.Status.Exceeded[0] != system.QuotaCount(common.VIRTUALMACHINE) {
+		t.Fatalf("expected the vm count to be exceeded, got %v", stored.Status.Exceeded)
+	}
+	if stored.Status.IsTrue(core.ConditionReady) || stored.Status.ReconcileTime == 0 {
+		t.Fatalf("expected an exceeded quota not to be ready, got %v", stored.Status)
+	}
+
+	stored.Status.ReconcileTime = 1
+	if _, err := stage.ApplyStatus(common.DefaultDatabase, common.RESOURCEQUOTA, stored.GetName(), stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.Reconcile(stored, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.RESOURCEQUOTA, stored, filter, true); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status.ReconcileTime != 1 {
+		t.Fatal("expected an unchanged usage not to be written again")
+	}
+}
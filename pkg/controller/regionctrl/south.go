@@ -14,42 +14,35 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 )
 
-func (V *RegionCtrl) SouthOnAdd(obj runtime.Object) {
+func (V *RegionCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
 	V.applyRegionToStage(obj)
 
 }
 
-func (V *RegionCtrl) SouthOnUpdate(obj runtime.Object) {
+func (V *RegionCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
 	V.applyRegionToStage(obj)
 
 }
 
-func (V *RegionCtrl) SouthOnDelete(obj runtime.Object) {
+func (V *RegionCtrl) SouthOnDelete(cluster string, obj runtime.Object) {
 	panic("implement me")
 }
 
-func (V *RegionCtrl) SouthEventChs(ctx context.Context) ([]<-chan watch.Event, error) {
+func (V *RegionCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
 	flog := V.flog.WithField("func", "SouthEventChs")
 	flog.Info("region start watch South Event")
 
-	clusters := make([]system.Cluster, 0)
-	if err := V.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
 		return nil, err
 	}
 
 	channels := make([]<-chan watch.Event, 0)
-	for _, cluster := range clusters {
-		client, err := V.cs.GetClient(cluster.GetName())
-		if err != nil {
-			return nil, err
-		}
-
-		watchInterface, err := client.Interface.Resource(regionGvr).Watch(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		channels = append(channels, watchInterface.ResultChan())
+	watchInterface, err := client.Interface.Resource(regionGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	channels = append(channels, watchInterface.ResultChan())
 
 	return channels, nil
 }
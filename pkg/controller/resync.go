@@ -0,0 +1,469 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	objUtils "github.com/ddx2x/oilmont/pkg/utils/obj"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// SourceOfTruth the side the drift of a table is repaired from
+type SourceOfTruth = string
+
+const (
+	// ReportOnly the drift is reported and left alone
+	ReportOnly SourceOfTruth = ""
+	// StageTruth the stage object is pushed to the clusters again
+	StageTruth SourceOfTruth = "stage"
+	// ClusterTruth the custom resource of the cluster is copied into the stage
+	ClusterTruth SourceOfTruth = "cluster"
+)
+
+// DefaultResyncInterval how often the stage and the clusters are compared unless configured otherwise
+const DefaultResyncInterval = 10 * time.Minute
+
+// ResyncConfig the interval of the resync and the source of truth of each table, keyed by table,
+// a table without policy is reported only
+type ResyncConfig struct {
+	Interval time.Duration
+	Policies map[string]SourceOfTruth
+}
+
+// Resyncer a handler whose stage table is mirrored by a custom resource of the clusters,
+// the backend controller compares both sides periodically to report and repair their drift
+type Resyncer interface {
+	ResyncTable() string
+	ResyncGvr() schema.GroupVersionResource
+	// ToSouth the custom resource the stage object is pushed to the clusters as, nil when it is not pushed
+	ToSouth(obj core.IObject) (*unstructured.Unstructured, error)
+}
+
+// SetResync configure the resync of the backend controllers, before Run
+func (c *Controllers) SetResync(config ResyncConfig) {
+	c.resync = config
+}
+
+// ignoredFields the runtime state reported back by the clusters, never a drift
+var ignoredFields = map[string]bool{"status": true, "message": true}
+
+type stageObject struct {
+	object *core.DefaultObject
+	south  *unstructured.Unstructured
+}
+
+type southObject struct {
+	cluster string
+	object  *unstructured.Unstructured
+}
+
+type drift struct {
+	kind      system.DriftType
+	workspace string
+	name      string
+	cluster   string
+	fields    []string
+	stage     *stageObject
+	south     *southObject
+
+	repaired bool
+	message  string
+}
+
+// recordName orphaned and differing custom resources are recorded once per cluster
+func (d *drift) recordName(table string) string {
+	if d.cluster == "" {
+		return fmt.Sprintf("%s-%s", table, d.name)
+	}
+	return fmt.Sprintf("%s-%s-%s", table, d.cluster, d.name)
+}
+
+func driftKey(workspace, name string) string {
+	if workspace == "" {
+		workspace = common.DefaultWorkspace
+	}
+	return fmt.Sprintf("%s/%s", workspace, name)
+}
+
+func specStatus(obj *unstructured.Unstructured) string {
+	status, _, _ := unstructured.NestedString(obj.Object, "spec", "status")
+	return status
+}
+
+// runResync compare the stage and the clusters every interval until ctx is done
+func (bc *BackendController) runResync(ctx context.Context, resyncer Resyncer) {
+	ticker := time.NewTicker(bc.resync.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := bc.resyncOnce(ctx, resyncer); err != nil {
+				log.G(ctx).Warnf("backend controller %s resync %s error: %v", bc.name, resyncer.ResyncTable(), err)
+			}
+		}
+	}
+}
+
+func (bc *BackendController) resyncOnce(ctx context.Context, resyncer Resyncer) error {
+	stage, err := bc.listStage(resyncer)
+	if err != nil {
+		return err
+	}
+	south, complete := bc.listSouth(ctx, resyncer)
+	drifts := detectDrift(stage, south, complete)
+
+	policy := bc.resync.Policies[resyncer.ResyncTable()]
+	if policy != ReportOnly {
+		for index := range drifts {
+			if err := bc.repair(ctx, resyncer, policy, &drifts[index]); err != nil {
+				drifts[index].message = err.Error()
+				continue
+			}
+			drifts[index].repaired = true
+		}
+	}
+	return bc.recordDrift(resyncer.ResyncTable(), policy, drifts)
+}
+
+// listStage the stage objects of the table keyed by workspace and name, along with the custom resource each is pushed as
+func (bc *BackendController) listStage(resyncer Resyncer) (map[string]*stageObject, error) {
+	data, err := bc.stage.List(common.DefaultDatabase, resyncer.ResyncTable(), "", true)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*stageObject, len(data))
+	for _, item := range data {
+		object := &core.DefaultObject{}
+		if err := objUtils.UnstructuredObjectToInstanceObj(item, object); err != nil {
+			return nil, err
+		}
+		south, err := resyncer.ToSouth(object)
+		if err != nil {
+			return nil, fmt.Errorf("convert %s %s error: %v", resyncer.ResyncTable(), object.GetName(), err)
+		}
+		if south == nil {
+			continue
+		}
+		result[driftKey(object.GetWorkspace(), object.GetName())] = &stageObject{object: object, south: south}
+	}
+	return result, nil
+}
+
+// listSouth the custom resources of every cluster keyed by workspace and name,
+// complete is false when a cluster could not be listed
+func (bc *BackendController) listSouth(ctx context.Context, resyncer Resyncer) (map[string][]southObject, bool) {
+	result := make(map[string][]southObject)
+	complete := true
+	for _, cluster := range bc.clients.Names() {
+		if !bc.clients.Reachable(cluster) {
+			complete = false
+			continue
+		}
+		client, err := bc.clients.GetClient(cluster)
+		if err != nil {
+			complete = false
+			continue
+		}
+		list, err := client.Interface.Resource(resyncer.ResyncGvr()).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.G(ctx).Warnf("backend controller %s list cluster %s error: %v", bc.name, cluster, err)
+			complete = false
+			continue
+		}
+		for index := range list.Items {
+			item := &list.Items[index]
+			if specStatus(item) == common.DELETE {
+				continue
+			}
+			key := driftKey(item.GetLabels()["workspace"], item.GetName())
+			result[key] = append(result[key], southObject{cluster: cluster, object: item})
+		}
+	}
+	return result, complete
+}
+
+// detectDrift compare the stage objects with the custom resources of the clusters. A stage object not yet
+// created or being deleted only keeps its custom resources from being reported orphaned, and missing is
+// only reported when every cluster could be listed
+func detectDrift(stage map[string]*stageObject, south map[string][]southObject, complete bool) []drift {
+	drifts := make([]drift, 0)
+	for key, desired := range stage {
+		status := specStatus(desired.south)
+		settled := status != common.INIT && status != common.DELETE
+		found := south[key]
+		if len(found) == 0 {
+			if complete && settled {
+				drifts = append(drifts, drift{
+					kind:      system.DriftMissing,
+					workspace: desired.object.GetWorkspace(),
+					name:      desired.object.GetName(),
+					stage:     desired,
+				})
+			}
+			continue
+		}
+		if !settled {
+			continue
+		}
+		for index := range found {
+			if fields := diffSpec(desired.south, found[index].object); len(fields) > 0 {
+				drifts = append(drifts, drift{
+					kind:      system.DriftDiffers,
+					workspace: desired.object.GetWorkspace(),
+					name:      desired.object.GetName(),
+					cluster:   found[index].cluster,
+					fields:    fields,
+					stage:     desired,
+					south:     &found[index],
+				})
+			}
+		}
+	}
+
+	for key, found := range south {
+		if _, exist := stage[key]; exist {
+			continue
+		}
+		for index := range found {
+			drifts = append(drifts, drift{
+				kind:      system.DriftOrphaned,
+				workspace: found[index].object.GetLabels()["workspace"],
+				name:      found[index].object.GetName(),
+				cluster:   found[index].cluster,
+				south:     &found[index],
+			})
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].name != drifts[j].name {
+			return drifts[i].name < drifts[j].name
+		}
+		return drifts[i].cluster < drifts[j].cluster
+	})
+	return drifts
+}
+
+// diffSpec the top level spec fields desired and current differ in, a field left empty on one side matches an absent one
+func diffSpec(desired, current *unstructured.Unstructured) []string {
+	desiredSpec, _ := desired.Object["spec"].(map[string]interface{})
+	currentSpec, _ := current.Object["spec"].(map[string]interface{})
+
+	fields := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, spec := range []map[string]interface{}{desiredSpec, currentSpec} {
+		for field := range spec {
+			if ignoredFields[field] || seen[field] {
+				continue
+			}
+			seen[field] = true
+			if !equalValue(desiredSpec[field], currentSpec[field]) {
+				fields = append(fields, field)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// equalValue compare through json so numbers decoded differently on both sides still match
+func equalValue(a, b interface{}) bool {
+	if isEmpty(a) || isEmpty(b) {
+		return isEmpty(a) && isEmpty(b)
+	}
+	aBytes, aErr := json.Marshal(a)
+	bBytes, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(aBytes) == string(bBytes)
+}
+
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// repair the drift from the side of policy, through the handler queues whenever the handler does the work
+func (bc *BackendController) repair(ctx context.Context, resyncer Resyncer, policy SourceOfTruth, d *drift) error {
+	switch policy {
+	case StageTruth:
+		switch d.kind {
+		case system.DriftMissing:
+			object := d.stage.object.Clone().(*core.DefaultObject)
+			spec, ok := object.Spec.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s %s has no spec", resyncer.ResyncTable(), d.name)
+			}
+			spec["status"] = common.INIT
+			return bc.enqueueNorth(ctx, core.Event{Type: core.ADDED, Object: object})
+		case system.DriftDiffers:
+			client, err := bc.clients.GetClient(d.cluster)
+			if err != nil {
+				return err
+			}
+			current := d.south.object
+			desired := d.stage.south.DeepCopy()
+			for field := range ignoredFields {
+				if value, exist := current.Object["spec"].(map[string]interface{})[field]; exist {
+					_ = unstructured.SetNestedField(desired.Object, value, "spec", field)
+				}
+			}
+			_, _, err = client.Apply(ctx, current.GetNamespace(), resyncer.ResyncGvr(), current.GetName(), desired, false)
+			return err
+		case system.DriftOrphaned:
+			client, err := bc.clients.GetClient(d.cluster)
+			if err != nil {
+				return err
+			}
+			err = client.Interface.Resource(resyncer.ResyncGvr()).Namespace(d.south.object.GetNamespace()).
+				Delete(ctx, d.south.object.GetName(), metav1.DeleteOptions{})
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+	case ClusterTruth:
+		switch d.kind {
+		case system.DriftMissing:
+			return bc.stage.Delete(common.DefaultDatabase, resyncer.ResyncTable(), d.name, d.stage.object.GetWorkspace())
+		case system.DriftDiffers, system.DriftOrphaned:
+			return bc.enqueueSouth(ctx, southEvent{cluster: d.cluster, event: watch.Event{Type: watch.Added, Object: d.south.object}})
+		}
+	}
+	return fmt.Errorf("unknown source of truth %s", policy)
+}
+
+func (bc *BackendController) enqueueNorth(ctx context.Context, event core.Event) error {
+	select {
+	case bc.northQueue <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (bc *BackendController) enqueueSouth(ctx context.Context, event southEvent) error {
+	select {
+	case bc.southQueue <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// recordDrift replace the drift records of the table with the drift found, an event is recorded
+// whenever a drift is first found and whenever it is repaired
+func (bc *BackendController) recordDrift(table string, policy SourceOfTruth, drifts []drift) error {
+	filter := map[string]interface{}{"spec.controller": bc.name, "spec.table": table}
+	existing := make([]system.Drift, 0)
+	if err := bc.stage.ListToObject(common.DefaultDatabase, common.DRIFT, filter, &existing, true); err != nil {
+		return err
+	}
+	known := make(map[string]system.Drift, len(existing))
+	for _, record := range existing {
+		known[driftKey(record.GetWorkspace(), record.GetName())] = record
+	}
+
+	now := time.Now().Unix()
+	current := make(map[string]bool, len(drifts))
+	for index := range drifts {
+		d := &drifts[index]
+		record := &system.Drift{
+			Metadata: core.Metadata{
+				Name:      d.recordName(table),
+				Kind:      system.DriftKind,
+				Workspace: d.workspace,
+			},
+			Spec: system.DriftSpec{
+				Controller: bc.name,
+				Table:      table,
+				Object:     d.name,
+				Cluster:    d.cluster,
+				Type:       d.kind,
+				Fields:     d.fields,
+				Policy:     policy,
+				Repaired:   d.repaired,
+				Message:    d.message,
+				DetectTime: now,
+			},
+		}
+		key := driftKey(record.GetWorkspace(), record.GetName())
+		current[key] = true
+
+		old, exist := known[key]
+		if exist {
+			record.Spec.DetectTime = old.Spec.DetectTime
+			if _, _, err := bc.stage.Apply(common.DefaultDatabase, common.DRIFT, record.GetName(), record, false); err != nil {
+				return err
+			}
+		} else {
+			if _, err := bc.stage.Create(common.DefaultDatabase, common.DRIFT, record); err != nil {
+				return err
+			}
+			bc.recordDriftEvent(record, "drift", event.CloudEventSuccess)
+		}
+
+		if policy == ReportOnly {
+			continue
+		}
+		if d.repaired {
+			bc.recordDriftEvent(record, "repair", event.CloudEventSuccess)
+		} else {
+			bc.recordDriftEvent(record, "repair", event.CloudEventFail)
+		}
+	}
+
+	for key, record := range known {
+		if current[key] {
+			continue
+		}
+		if err := bc.stage.Delete(common.DefaultDatabase, common.DRIFT, record.GetName(), record.GetWorkspace()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bc *BackendController) recordDriftEvent(record *system.Drift, action string, status event.OperatorStatusType) {
+	cloudEvent := &event.CloudEvent{
+		Metadata: core.Metadata{
+			Name:      record.GetName(),
+			Workspace: record.GetWorkspace(),
+		},
+		Spec: event.CloudEventSpec{
+			Source:          record.Spec.Table,
+			Name:            record.Spec.Object,
+			Action:          fmt.Sprintf("%s %s", action, record.Spec.Type),
+			Operator:        bc.name,
+			SourceWorkspace: record.GetWorkspace(),
+			OperatorStatus:  status,
+		},
+	}
+	cloudEvent.GenerateVersion()
+	if _, err := bc.stage.Create(common.DefaultDatabase, common.CLOUDEVENT, cloudEvent); err != nil {
+		log.G(context.Background()).Warnf("backend controller %s record %s event error: %v", bc.name, record.GetName(), err)
+	}
+}
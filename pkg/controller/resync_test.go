@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newSouth(name, workspace string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetName(name)
+	obj.SetLabels(map[string]string{"workspace": workspace})
+	return obj
+}
+
+func newStage(name, workspace string, spec map[string]interface{}) *stageObject {
+	object := &core.DefaultObject{Metadata: core.Metadata{Name: name, Workspace: workspace}, Spec: spec}
+	return &stageObject{object: object, south: newSouth(name, workspace, spec)}
+}
+
+func TestDetectDrift(t *testing.T) {
+	stage := map[string]*stageObject{
+		driftKey("ws", "same"):    newStage("same", "ws", map[string]interface{}{"ip": "10.0.0.0", "status": common.RUNNING}),
+		driftKey("ws", "changed"): newStage("changed", "ws", map[string]interface{}{"ip": "10.0.0.0", "mask": "16", "status": common.RUNNING}),
+		driftKey("ws", "missing"): newStage("missing", "ws", map[string]interface{}{"ip": "10.1.0.0", "status": common.RUNNING}),
+		driftKey("ws", "pending"): newStage("pending", "ws", map[string]interface{}{"ip": "10.2.0.0", "status": common.INIT}),
+	}
+	south := map[string][]southObject{
+		driftKey("ws", "same"): {{cluster: "a", object: newSouth("same", "ws",
+			map[string]interface{}{"ip": "10.0.0.0", "local_name": "", "status": common.SYNC, "message": "ok"})}},
+		driftKey("ws", "changed"): {{cluster: "a", object: newSouth("changed", "ws",
+			map[string]interface{}{"ip": "10.0.0.0", "mask": int64(24), "status": common.RUNNING})}},
+		driftKey("ws", "orphan"): {{cluster: "b", object: newSouth("orphan", "ws",
+			map[string]interface{}{"ip": "10.3.0.0"})}},
+	}
+
+	drifts := detectDrift(stage, south, true)
+	if len(drifts) != 3 {
+		t.Fatalf("expected 3 drifts, got %+v", drifts)
+	}
+	if drifts[0].name != "changed" || drifts[0].kind != system.DriftDiffers || drifts[0].cluster != "a" ||
+		len(drifts[0].fields) != 1 || drifts[0].fields[0] != "mask" {
+		t.Fatalf("unexpected differs drift %+v", drifts[0])
+	}
+	if drifts[1].name != "missing" || drifts[1].kind != system.DriftMissing {
+		t.Fatalf("unexpected missing drift %+v", drifts[1])
+	}
+	if drifts[2].name != "orphan" || drifts[2].kind != system.DriftOrphaned || drifts[2].cluster != "b" {
+		t.Fatalf("unexpected orphaned drift %+v", drifts[2])
+	}
+
+	// a cluster that could not be listed may hold the missing object
+	for _, d := range detectDrift(stage, south, false) {
+		if d.kind == system.DriftMissing {
+			t.Fatalf("missing reported with an incomplete listing: %+v", d)
+		}
+	}
+}
+
+func TestRecordDrift(t *testing.T) {
+	stage := memory.NewMemory()
+	bc := &BackendController{stage: stage, name: "vpcctrl.VPCCtrl", southQueue: make(chan southEvent, 1)}
+
+	orphan := southObject{cluster: "b", object: newSouth("orphan", "ws", map[string]interface{}{"ip": "10.3.0.0"})}
+	drifts := []drift{
+		{kind: system.DriftMissing, workspace: "ws", name: "missing", stage: newStage("missing", "ws", map[string]interface{}{})},
+		{kind: system.DriftOrphaned, workspace: "ws", name: "orphan", cluster: "b", south: &orphan},
+	}
+	if err := bc.recordDrift(common.VPC, ReportOnly, drifts); err != nil {
+		t.Fatal(err)
+	}
+
+	records := make([]system.Drift, 0)
+	if err := stage.ListToObject(common.DefaultDatabase, common.DRIFT, map[string]interface{}{}, &records, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].GetName() != "vpc-missing" || records[1].GetName() != "vpc-b-orphan" {
+		t.Fatalf("unexpected drift records %+v", records)
+	}
+	events := make([]event.CloudEvent, 0)
+	if err := stage.ListToObject(common.DefaultDatabase, common.CLOUDEVENT, map[string]interface{}{}, &events, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Spec.Action != "drift missing" {
+		t.Fatalf("unexpected drift events %+v", events)
+	}
+
+	// the cluster is the source of truth: the orphan is copied into the stage through the south queue
+	if err := bc.repair(context.Background(), nil, ClusterTruth, &drifts[1]); err != nil {
+		t.Fatal(err)
+	}
+	if queued := <-bc.southQueue; queued.cluster != "b" || queued.event.Object != orphan.object {
+		t.Fatalf("unexpected south event %+v", queued)
+	}
+
+	// the drift is gone on the next resync
+	if err := bc.recordDrift(common.VPC, ReportOnly, drifts[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := stage.ListToObject(common.DefaultDatabase, common.DRIFT, map[string]interface{}{}, &records, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].GetName() != "vpc-missing" {
+		t.Fatalf("unexpected drift records %+v", records)
+	}
+}
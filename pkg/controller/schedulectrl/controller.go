@@ -0,0 +1,304 @@
+package schedulectrl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/datasource/cache"
+	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/proc"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/schedule"
+	"github.com/ddx2x/oilmont/pkg/service"
+	computesvc "github.com/ddx2x/oilmont/pkg/service/compute"
+	networkingsvc "github.com/ddx2x/oilmont/pkg/service/networking"
+	"github.com/ddx2x/oilmont/pkg/utils/uuid"
+)
+
+const (
+	ReasonScheduled   = "Scheduled"
+	ReasonInvalidCron = "InvalidCron"
+	ReasonRunFailed   = "RunFailed"
+)
+
+// DefaultTickInterval how often the schedules are checked for a due run
+const DefaultTickInterval = 30 * time.Second
+
+// maxMessageErrors the errors of the targets kept in the message of the status
+const maxMessageErrors = 3
+
+var _ controller.Controller = &ScheduleController{}
+
+// ScheduleController run the actions of the schedules once they are due. Every replica checks every schedule, a run
+// is carried out by the replica which claimed it first in the schedulerun table
+type ScheduleController struct {
+	datasource.IStorage
+	service  service.IService
+	proc     *proc.Proc
+	interval time.Duration
+	flog     log.Logger
+	// replica the id a run is claimed with
+	replica string
+}
+
+func NewScheduleController(store datasource.IStorage) *ScheduleController {
+	hostname, _ := os.Hostname()
+	return &ScheduleController{
+		IStorage: store,
+		service:  service.NewBaseService(store, cache.NewCache(15*time.Minute, 20*time.Minute)),
+		proc:     proc.NewProc(),
+		interval: DefaultTickInterval,
+		flog:     log.GetLogger(context.Background()).WithField("controller", "schedulectrl"),
+		replica:  fmt.Sprintf("%s-%s", hostname, uuid.NewSUID().String()),
+	}
+}
+
+func (s *ScheduleController) Run() error {
+	s.proc.Add(s.TickLoop)
+	return <-s.proc.Start()
+}
+
+// TickLoop reconcile every schedule each interval
+func (s *ScheduleController) TickLoop(errC chan<- error) {
+	flog := s.flog.WithField("thread", "tick")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if err := s.Tick(now); err != nil {
+			flog.Infof("tick schedule error %s\n", err)
+		}
+	}
+}
+
+// Tick reconcile every schedule at now
+func (s *ScheduleController) Tick(now time.Time) error {
+	schedules := make([]system.Schedule, 0)
+	if err := s.ListToObject(common.DefaultDatabase, common.SCHEDULE, map[string]interface{}{}, &schedules, true); err != nil {
+		return err
+	}
+	for index := range schedules {
+		if err := s.Reconcile(&schedules[index], now); err != nil {
+			s.flog.Infof("reconcile schedule %s error %s\n", schedules[index].GetName(), err)
+		}
+	}
+	return nil
+}
+
+// Reconcile run the schedule when its next run is due at now and record the run in its status. A new, changed or
+// suspended schedule only gets its next run computed, a schedule is changed while its generation is not observed
+func (s *ScheduleController) Reconcile(sc *system.Schedule, now time.Time) error {
+	status := &sc.Status
+	cron, err := schedule.ParseCron(sc.Spec.Cron)
+	if err != nil {
+		return s.invalid(sc, err)
+	}
+	location, err := sc.Spec.Location()
+	if err != nil {
+		return s.invalid(sc, err)
+	}
+	local := now.In(location)
+
+	changed := sc.GetGeneration() > status.ObservedGeneration
+	if status.NextScheduleTime == 0 || changed || sc.Spec.Suspend {
+		next := nextRun(cron, local)
+		if next == status.NextScheduleTime && !changed {
+			return nil
+		}
+		status.NextScheduleTime = next
+		status.ObservedGeneration = sc.GetGeneration()
+		status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionTrue, ReasonScheduled, ""))
+		_, err := s.ApplyStatus(common.DefaultDatabase, common.SCHEDULE, sc.GetName(), sc)
+		return err
+	}
+	if now.Unix() < status.NextScheduleTime {
+		return nil
+	}
+
+	slot := cron.Due(time.Unix(status.NextScheduleTime-1, 0).In(location), local)
+	if !slot.IsZero() {
+		claimed, err := s.claim(sc, slot)
+		if err != nil {
+			return err
+		}
+		// the replica which claimed the run records it
+		if !claimed {
+			return nil
+		}
+		status.LastScheduleTime = slot.Unix()
+		status.LastResult, status.LastMessage = s.Execute(sc, slot)
+	}
+	status.NextScheduleTime = nextRun(cron, local)
+	if status.LastResult == system.ScheduleResultFail || status.LastResult == system.ScheduleResultPartial {
+		status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionFalse, ReasonRunFailed, status.LastMessage))
+	} else {
+		status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionTrue, ReasonScheduled, ""))
+	}
+
+	_, err = s.ApplyStatus(common.DefaultDatabase, common.SCHEDULE, sc.GetName(), sc)
+	return err
+}
+
+// nextRun the unix time of the first run of cron after local, 0 when it never runs again, e.g. on february 30
+func nextRun(cron *schedule.Cron, local time.Time) int64 {
+	next := cron.Next(local)
+	if next.IsZero() {
+		return 0
+	}
+	return next.Unix()
+}
+
+func (s *ScheduleController) invalid(sc *system.Schedule, reason error) error {
+	condition := sc.Status.GetCondition(core.ConditionReady)
+	if condition != nil && condition.Reason == ReasonInvalidCron && condition.Message == reason.Error() &&
+		sc.Status.ObservedGeneration == sc.GetGeneration() {
+		return nil
+	}
+	sc.Status.NextScheduleTime = 0
+	sc.Status.ObservedGeneration = sc.GetGeneration()
+	sc.Status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionFalse, ReasonInvalidCron, reason.Error()))
+	_, err := s.ApplyStatus(common.DefaultDatabase, common.SCHEDULE, sc.GetName(), sc)
+	return err
+}
+
+type scheduleRun struct {
+	Id   string `json:"_id" bson:"_id"`
+	Data string `json:"data" bson:"data"`
+}
+
+// claim whether this replica is the one carrying out the run of the schedule at slot. The first replica to insert the
+// run keeps it, the insert of the others is ignored
+func (s *ScheduleController) claim(sc *system.Schedule, slot time.Time) (bool, error) {
+	id := fmt.Sprintf("%s/%d", sc.GetUUID(), slot.Unix())
+	if err := s.InsertUnique(common.DefaultDatabase, common.SCHEDULERUN, id, s.replica); err != nil {
+		return false, err
+	}
+	run := &scheduleRun{}
+	if err := s.GetById(common.DefaultDatabase, common.SCHEDULERUN, id, run); err != nil {
+		return false, err
+	}
+	return run.Data == s.replica, nil
+}
+
+// Targets the names of the objects of the workspace of the schedule its target selects
+func (s *ScheduleController) Targets(sc *system.Schedule) ([]string, error) {
+	filter := map[string]interface{}{common.FilterWorkspace: sc.GetWorkspace()}
+	objects := make([]core.DefaultObject, 0)
+	if err := s.ListToObject(common.DefaultDatabase, string(sc.Spec.Target.Kind), filter, &objects, true); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, object := range objects {
+		if sc.Spec.Target.Matches(object.Metadata) {
+			names = append(names, object.GetName())
+		}
+	}
+	return names, nil
+}
+
+// Execute run the action of the schedule on each target through the services, each run of a target is recorded as
+// a cloud event. It returns the result of the run and a message of its errors
+func (s *ScheduleController) Execute(sc *system.Schedule, slot time.Time) (string, string) {
+	names, err := s.Targets(sc)
+	if err != nil {
+		return system.ScheduleResultFail, fmt.Sprintf("list targets error %v", err)
+	}
+	if len(names) == 0 {
+		return system.ScheduleResultSkipped, "no target matched"
+	}
+
+	errs := make([]string, 0)
+	for _, name := range names {
+		status := event.CloudEventSuccess
+		if err := s.execute(sc, name, slot); err != nil {
+			status = event.CloudEventFail
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+		s.record(sc, name, status)
+	}
+
+	switch {
+	case len(errs) == 0:
+		return system.ScheduleResultSuccess, ""
+	case len(errs) == len(names):
+		return system.ScheduleResultFail, message(errs, len(names))
+	}
+	return system.ScheduleResultPartial, message(errs, len(names))
+}
+
+func message(errs []string, total int) string {
+	shown := errs
+	if len(shown) > maxMessageErrors {
+		shown = shown[:maxMessageErrors]
+	}
+	return fmt.Sprintf("%d/%d failed: %s", len(errs), total, strings.Join(shown, "; "))
+}
+
+func (s *ScheduleController) execute(sc *system.Schedule, name string, slot time.Time) error {
+	workspace := sc.GetWorkspace()
+	switch sc.Spec.Action {
+	case system.ScheduleActionStart, system.ScheduleActionStop, system.ScheduleActionRestart,
+		system.ScheduleActionPause, system.ScheduleActionUnpause:
+		_, err := computesvc.NewVirtualMachineService(s.service).Action(workspace, name, sc.Spec.Action)
+		return err
+
+	case system.ScheduleActionSnapshot:
+		snapshot := &compute.VirtualMachineSnapshot{
+			Metadata: core.Metadata{
+				Name:      fmt.Sprintf("%s-%s", name, slot.UTC().Format("200601021504")),
+				Workspace: workspace,
+			},
+			Spec: compute.VirtualMachineSnapshotSpec{
+				VirtualMachine: name,
+				Retention:      sc.Spec.Retention,
+			},
+		}
+		_, err := computesvc.NewVirtualMachineSnapshotService(s.service).Create(snapshot)
+		return err
+
+	case system.ScheduleActionDelete:
+		var err error
+		switch sc.Spec.Target.Kind {
+		case compute.VirtualMachineSnapshotKind:
+			_, err = computesvc.NewVirtualMachineSnapshotService(s.service).Delete(workspace, name)
+		case networking.ElasticIPKind:
+			_, err = networkingsvc.NewElasticIPService(s.service).Delete(workspace, name)
+		case networking.LoadBalancerKind:
+			_, err = networkingsvc.NewLoadBalancerService(s.service).Delete(workspace, name)
+		default:
+			err = fmt.Errorf("not support action %s on %s", sc.Spec.Action, sc.Spec.Target.Kind)
+		}
+		return err
+	}
+	return fmt.Errorf("not support action %s", sc.Spec.Action)
+}
+
+// record the run of the action on a target, the operator is the schedule
+func (s *ScheduleController) record(sc *system.Schedule, name string, status event.OperatorStatusType) {
+	cloudEvent := &event.CloudEvent{
+		Metadata: core.Metadata{
+			Name:      name,
+			Workspace: sc.GetWorkspace(),
+		},
+		Spec: event.CloudEventSpec{
+			Source:          string(sc.Spec.Target.Kind),
+			Name:            name,
+			Action:          sc.Spec.Action,
+			Operator:        fmt.Sprintf("%s/%s", system.ScheduleKind, sc.GetName()),
+			SourceWorkspace: sc.GetWorkspace(),
+			OperatorStatus:  status,
+		},
+	}
+	cloudEvent.GenerateVersion()
+	s.service.Add(cloudEvent)
+}
@@ -0,0 +1,140 @@
+package schedulectrl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+func getSchedule(t *testing.T, stage *memory.Memory) *system.Schedule {
+	sc := &system.Schedule{}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.SCHEDULE, sc, map[string]interface{}{common.FilterName: "stop-dev"}, true); err != nil {
+		t.Fatal(err)
+	}
+	return sc
+}
+
+func TestReconcile(t *testing.T) {
+	stage := memory.NewMemory()
+	for name, env := range map[string]string{"vm1": "dev", "vm2": "prod"} {
+		vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: name, Workspace: "ws1"}}
+		vm.SetLabel("env", env)
+		vm.Spec.State = compute.Running
+		if _, err := stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sc := &system.Schedule{
+		Metadata: core.Metadata{Name: "stop-dev", Workspace: "ws1"},
+		Spec: system.ScheduleSpec{
+			Cron:   "0 22 * * *",
+			Target: system.ScheduleTarget{Kind: compute.VirtualMachineKind, Labels: map[string]string{"env": "dev"}},
+			Action: system.ScheduleActionStop,
+		},
+	}
+	sc.GenerateVersion()
+	if _, err := stage.Create(common.DefaultDatabase, common.SCHEDULE, sc); err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := NewScheduleController(stage)
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	if err := ctrl.Reconcile(getSchedule(t, stage), day.Add(12*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	scheduled := getSchedule(t, stage)
+	if scheduled.Status.NextScheduleTime != day.Add(22*time.Hour).Unix() {
+		t.Fatalf("expected the next run at 22:00, got %d", scheduled.Status.NextScheduleTime)
+	}
+
+	// both replicas see the run due, only the one claiming it first carries it out
+	if err := ctrl.Reconcile(getSchedule(t, stage), day.Add(22*time.Hour+30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewScheduleController(stage).Reconcile(scheduled, day.Add(22*time.Hour+45*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	vms := make([]compute.VirtualMachine, 0)
+	if err := stage.ListToObject(common.DefaultDatabase, common.VIRTUALMACHINE, map[string]interface{}{}, &vms, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, vm := range vms {
+		stopping := vm.Spec.State == compute.Stopping
+		if stopping != (vm.GetName() == "vm1") {
+			t.Fatalf("expected only the dev vm stopping, %s is %s", vm.GetName(), vm.Spec.State)
+		}
+	}
+
+	events := make([]event.CloudEvent, 0)
+	if err := stage.ListToObject(common.DefaultDatabase, common.CLOUDEVENT, map[string]interface{}{}, &events, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Spec.Operator != "schedule/stop-dev" || events[0].Spec.OperatorStatus != event.CloudEventSuccess {
+		t.Fatalf("expected a single successful run recorded, got %+v", events)
+	}
+
+	status := getSchedule(t, stage).Status
+	if status.LastScheduleTime != day.Add(22*time.Hour).Unix() || status.LastResult != system.ScheduleResultSuccess ||
+		status.NextScheduleTime != day.Add(46*time.Hour).Unix() {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
+
+func TestReconcileChanged(t *testing.T) {
+	stage := memory.NewMemory()
+	sc := &system.Schedule{
+		Metadata: core.Metadata{Name: "stop-dev", Workspace: "ws1"},
+		Spec: system.ScheduleSpec{
+			Cron:   "0 22 * * *",
+			Target: system.ScheduleTarget{Kind: compute.VirtualMachineKind},
+			Action: system.ScheduleActionStop,
+		},
+	}
+	sc.GenerateVersion()
+	if _, err := stage.Create(common.DefaultDatabase, common.SCHEDULE, sc); err != nil {
+		t.Fatal(err)
+	}
+
+	ctrl := NewScheduleController(stage)
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	if err := ctrl.Reconcile(getSchedule(t, stage), day.Add(12*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new cron is a new generation, its next run replaces the one of the old cron
+	sc = getSchedule(t, stage)
+	sc.Spec.Cron = "0 20 * * *"
+	if _, _, err := stage.Apply(common.DefaultDatabase, common.SCHEDULE, sc.GetName(), sc, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.Reconcile(getSchedule(t, stage), day.Add(13*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	status := getSchedule(t, stage).Status
+	if status.NextScheduleTime != day.Add(20*time.Hour).Unix() || status.ObservedGeneration != getSchedule(t, stage).GetGeneration() {
+		t.Fatalf("expected the next run at 20:00 with the generation observed, got %+v", status)
+	}
+
+	// a cron which never runs again is never due
+	sc = getSchedule(t, stage)
+	sc.Spec.Cron = "0 0 30 2 *"
+	if _, _, err := stage.Apply(common.DefaultDatabase, common.SCHEDULE, sc.GetName(), sc, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, now := range []time.Time{day.Add(14 * time.Hour), day.Add(15 * time.Hour)} {
+		if err := ctrl.Reconcile(getSchedule(t, stage), now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status = getSchedule(t, stage).Status
+	if status.NextScheduleTime != 0 || status.LastScheduleTime != 0 || status.GetCondition(core.ConditionReady).Reason != ReasonScheduled {
+		t.Fatalf("expected no run of a cron which never runs, got %+v", status)
+	}
+}
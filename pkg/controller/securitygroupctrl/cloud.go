@@ -0,0 +1,82 @@
+package securitygroupctrl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	_ "github.com/ddx2x/oilmont/pkg/cloudprovider/providers"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+// vendorSecurityGroup the securityGroup with the vpc it references given by vendor id
+func (V *SecurityGroupCtrl) vendorSecurityGroup(securityGroup *system.SecurityGroup) *system.SecurityGroup {
+	vendor := *securityGroup
+	vendor.Spec.VpcId = controller.CloudId(V.stage, common.VPC, securityGroup.GetWorkspace(), securityGroup.Spec.VpcId)
+	return &vendor
+}
+
+// createCloudSecurityGroup create the vendor security group with its rules and store its id
+func (V *SecurityGroupCtrl) createCloudSecurityGroup(securityGroup *system.SecurityGroup) {
+	flog := V.flog.WithField("func", "createCloudSecurityGroup")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if securityGroup.Spec.ID == "" {
+		provider, err := cloudprovider.ForProvider(V.stage, securityGroup.GetNamespace(), securityGroup.Spec.RegionId)
+		if err != nil {
+			V.fail(securityGroup, err)
+			return
+		}
+		vendor := V.vendorSecurityGroup(securityGroup)
+		if err := provider.SecurityGroups().Create(ctx, vendor); err != nil {
+			V.fail(securityGroup, fmt.Errorf("create security group error %v", err))
+			return
+		}
+		securityGroup.Spec.ID = vendor.Spec.ID
+		flog.Infof("create securityGroup %s id %s", securityGroup.GetName(), securityGroup.Spec.ID)
+	}
+	V.succeed(securityGroup)
+}
+
+// updateCloudSecurityGroup replace the rules of the vendor security group with the ones of the spec
+func (V *SecurityGroupCtrl) updateCloudSecurityGroup(securityGroup *system.SecurityGroup) {
+	flog := V.flog.WithField("func", "updateCloudSecurityGroup")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(V.stage, securityGroup.GetNamespace(), securityGroup.Spec.RegionId)
+	if err != nil {
+		V.fail(securityGroup, err)
+		return
+	}
+	if err := provider.SecurityGroups().Update(ctx, V.vendorSecurityGroup(securityGroup)); err != nil {
+		V.fail(securityGroup, fmt.Errorf("update security group %s error %v", securityGroup.Spec.ID, err))
+		return
+	}
+	flog.Infof("update securityGroup %s id %s", securityGroup.GetName(), securityGroup.Spec.ID)
+	V.succeed(securityGroup)
+}
+
+func (V *SecurityGroupCtrl) deleteCloudSecurityGroup(securityGroup *system.SecurityGroup) {
+	flog := V.flog.WithField("func", "deleteCloudSecurityGroup")
+	if securityGroup.Spec.ID == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(V.stage, securityGroup.GetNamespace(), securityGroup.Spec.RegionId)
+	if err != nil {
+		flog.Warnf("get cloud provider error %v", err)
+		return
+	}
+	err = provider.SecurityGroups().Delete(ctx, securityGroup.Spec.RegionId, securityGroup.Spec.ID)
+	if err != nil && err != cloudprovider.NotFound {
+		flog.Warnf("delete securityGroup %s id %s error %v", securityGroup.GetName(), securityGroup.Spec.ID, err)
+		return
+	}
+	flog.Infof("delete securityGroup %s id %s", securityGroup.GetName(), securityGroup.Spec.ID)
+}
@@ -0,0 +1,247 @@
+package securitygroupctrl
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// maxPolicyPorts network policies of this cluster version have no port range, a range is expanded up to this many ports
+const maxPolicyPorts = 64
+
+// isLocal the security group applies to the kubeVirt vms of the workspace rather than a cloud provider
+func isLocal(securityGroup *system.SecurityGroup) bool {
+	return !cloudprovider.IsRegistered(securityGroup.GetNamespace())
+}
+
+func networkPolicyName(securityGroup *system.SecurityGroup) string {
+	return fmt.Sprintf("securitygroup-%s", securityGroup.GetName())
+}
+
+// toNetworkPolicy the network policy of the security group selecting the kubeVirt vms labelled with it,
+// network policies only allow so a deny rule is rendered as an except of the allow rules it takes precedence over
+func toNetworkPolicy(securityGroup *system.SecurityGroup) (*networkingv1.NetworkPolicy, error) {
+	spec := securityGroup.Spec
+	spec.Normalize()
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      networkPolicyName(securityGroup),
+			Namespace: securityGroup.GetWorkspace(),
+			Labels:    map[string]string{system.SecurityGroupLabel(securityGroup.GetName()): "true"},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{system.SecurityGroupLabel(securityGroup.GetName()): "true"},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+
+	ingress, err := toPolicyRules(spec.Ingress)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range ingress {
+		policy.Spec.Ingress = append(policy.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{Ports: rule.ports, From: rule.peers})
+	}
+
+	// a security group without egress rule leaves egress open, as the cloud providers do
+	if len(spec.Egress) > 0 {
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		egress, err := toPolicyRules(spec.Egress)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range egress {
+			policy.Spec.Egress = append(policy.Spec.Egress, networkingv1.NetworkPolicyEgressRule{Ports: rule.ports, To: rule.peers})
+		}
+	}
+	return policy, nil
+}
+
+type policyRule struct {
+	ports []networkingv1.NetworkPolicyPort
+	peers []networkingv1.NetworkPolicyPeer
+}
+
+func toPolicyRules(rules []system.SecurityGroupRole) ([]policyRule, error) {
+	denies := make([]system.SecurityGroupRole, 0)
+	for _, rule := range rules {
+		if rule.Action != system.Deny {
+			continue
+		}
+		if rule.PeerGroup != "" {
+			return nil, fmt.Errorf("rule %s: a network policy can not deny a peer group", rule)
+		}
+		denies = append(denies, rule)
+	}
+
+	result := make([]policyRule, 0)
+	for _, rule := range rules {
+		if rule.Action != system.Allow {
+			continue
+		}
+		ports, err := toPolicyPorts(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		if rule.PeerGroup != "" {
+			result = append(result, policyRule{ports: ports, peers: []networkingv1.NetworkPolicyPeer{{
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{system.SecurityGroupLabel(rule.PeerGroup): "true"}},
+			}}})
+			continue
+		}
+
+		block, covered, err := exceptDenied(rule, denies)
+		if err != nil {
+			return nil, err
+		}
+		if covered {
+			continue
+		}
+		result = append(result, policyRule{ports: ports, peers: []networkingv1.NetworkPolicyPeer{{IPBlock: block}}})
+	}
+	return result, nil
+}
+
+// exceptDenied the ip block of the allow rule without the cidrs of the deny rules taking precedence over it,
+// covered when a deny rule takes the whole of it
+func exceptDenied(allow system.SecurityGroupRole, denies []system.SecurityGroupRole) (*networkingv1.IPBlock, bool, error) {
+	_, allowNetwork, _ := net.ParseCIDR(allow.SourceCidrIp)
+	block := &networkingv1.IPBlock{CIDR: allowNetwork.String()}
+
+	for _, deny := range denies {
+		if deny.Priority > allow.Priority {
+			continue
+		}
+		_, denyNetwork, _ := net.ParseCIDR(deny.SourceCidrIp)
+		if !denyNetwork.Contains(allowNetwork.IP) && !allowNetwork.Contains(denyNetwork.IP) {
+			continue
+		}
+		if !portsCovered(deny, allow) {
+			if portsOverlap(deny, allow) {
+				return nil, false, fmt.Errorf("rule %s: a network policy can not deny part of the ports of rule %s", deny, allow)
+			}
+			continue
+		}
+		denyPrefix, _ := denyNetwork.Mask.Size()
+		allowPrefix, _ := allowNetwork.Mask.Size()
+		if denyPrefix <= allowPrefix {
+			return nil, true, nil
+		}
+		block.Except = append(block.Except, denyNetwork.String())
+	}
+	return block, false, nil
+}
+
+// portsCovered whether the deny rule matches every protocol and port of the allow rule
+func portsCovered(deny, allow system.SecurityGroupRole) bool {
+	if deny.IpProtocol == system.ALL {
+		return true
+	}
+	if deny.IpProtocol != allow.IpProtocol {
+		return false
+	}
+	denyFrom, denyTo, _ := system.ParsePortRange(deny.PortRange)
+	if denyFrom == -1 {
+		return true
+	}
+	allowFrom, allowTo, _ := system.ParsePortRange(allow.PortRange)
+	return allowFrom != -1 && denyFrom <= allowFrom && allowTo <= denyTo
+}
+
+func portsOverlap(deny, allow system.SecurityGroupRole) bool {
+	if allow.IpProtocol != system.ALL && deny.IpProtocol != allow.IpProtocol {
+		return false
+	}
+	denyFrom, denyTo, _ := system.ParsePortRange(deny.PortRange)
+	allowFrom, allowTo, _ := system.ParsePortRange(allow.PortRange)
+	if denyFrom == -1 || allowFrom == -1 {
+		return true
+	}
+	return denyFrom <= allowTo && allowFrom <= denyTo
+}
+
+// toPolicyPorts the ports of the rule, none when it matches every port and protocol
+func toPolicyPorts(rule system.SecurityGroupRole) ([]networkingv1.NetworkPolicyPort, error) {
+	var protocol corev1.Protocol
+	switch rule.IpProtocol {
+	case system.ALL:
+		return nil, nil
+	case system.TCP:
+		protocol = corev1.ProtocolTCP
+	case system.UDP:
+		protocol = corev1.ProtocolUDP
+	default:
+		return nil, fmt.Errorf("rule %s: a network policy can not match protocol %s", rule, rule.IpProtocol)
+	}
+
+	from, to, err := system.ParsePortRange(rule.PortRange)
+	if err != nil {
+		return nil, err
+	}
+	if from == -1 {
+		return []networkingv1.NetworkPolicyPort{{Protocol: &protocol}}, nil
+	}
+	if to-from+1 > maxPolicyPorts {
+		return nil, fmt.Errorf("rule %s: a network policy takes at most %d ports", rule, maxPolicyPorts)
+	}
+	ports := make([]networkingv1.NetworkPolicyPort, 0, to-from+1)
+	for port := from; port <= to; port++ {
+		value := intstr.FromInt(port)
+		ports = append(ports, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &value})
+	}
+	return ports, nil
+}
+
+// applyNetworkPolicy create or replace the network policy of a local security group
+func (V *SecurityGroupCtrl) applyNetworkPolicy(securityGroup *system.SecurityGroup) error {
+	policy, err := toNetworkPolicy(securityGroup)
+	if err != nil {
+		return err
+	}
+	client, err := V.clientOf(securityGroup)
+	if err != nil {
+		return err
+	}
+
+	policies := client.KubevirtCli.NetworkingV1().NetworkPolicies(policy.Namespace)
+	current, err := policies.Get(context.Background(), policy.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = policies.Create(context.Background(), policy, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	current.Labels = policy.Labels
+	current.Spec = policy.Spec
+	_, err = policies.Update(context.Background(), current, metav1.UpdateOptions{})
+	return err
+}
+
+func (V *SecurityGroupCtrl) deleteNetworkPolicy(securityGroup *system.SecurityGroup) error {
+	client, err := V.clientOf(securityGroup)
+	if err != nil {
+		return err
+	}
+	err = client.KubevirtCli.NetworkingV1().NetworkPolicies(securityGroup.GetWorkspace()).
+		Delete(context.Background(), networkPolicyName(securityGroup), metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package securitygroupctrl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func newSecurityGroup(ingress, egress []system.SecurityGroupRole) *system.SecurityGroup {
+	return &system.SecurityGroup{
+		Metadata: core.Metadata{Name: "web", Workspace: "ws"},
+		Spec:     system.SecurityGroupSpec{Ingress: ingress, Egress: egress},
+	}
+}
+
+func TestToNetworkPolicy(t *testing.T) {
+	securityGroup := newSecurityGroup([]system.SecurityGroupRole{
+		{IpProtocol: system.TCP, PortRange: "80/81", SourceCidrIp: "10.0.0.0/8", Priority: 10},
+		{IpProtocol: system.ALL, SourceCidrIp: "10.1.0.0/16", Action: system.Deny, Priority: 5},
+		{IpProtocol: system.TCP, PortRange: "22/22", PeerGroup: "bastion"},
+	}, nil)
+
+	policy, err := toNetworkPolicy(securityGroup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Name != "securitygroup-web" || policy.Namespace != "ws" {
+		t.Fatalf("unexpected policy %s/%s", policy.Namespace, policy.Name)
+	}
+	if policy.Spec.PodSelector.MatchLabels[system.SecurityGroupLabel("web")] != "true" {
+		t.Fatalf("expected the policy to select the vms of the group, got %v", policy.Spec.PodSelector)
+	}
+	if !reflect.DeepEqual(policy.Spec.PolicyTypes, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}) {
+		t.Fatalf("expected ingress policy only, got %v", policy.Spec.PolicyTypes)
+	}
+	if len(policy.Spec.Ingress) != 2 {
+		t.Fatalf("expected 2 ingress rules, got %d", len(policy.Spec.Ingress))
+	}
+
+	web := policy.Spec.Ingress[0]
+	if len(web.Ports) != 2 || web.Ports[0].Port.IntValue() != 80 || web.Ports[1].Port.IntValue() != 81 {
+		t.Fatalf("expected ports 80 and 81, got %v", web.Ports)
+	}
+	if block := web.From[0].IPBlock; block.CIDR != "10.0.0.0/8" || !reflect.DeepEqual(block.Except, []string{"10.1.0.0/16"}) {
+		t.Fatalf("expected the denied cidr excepted, got %+v", block)
+	}
+	if selector := policy.Spec.Ingress[1].From[0].PodSelector; selector.MatchLabels[system.SecurityGroupLabel("bastion")] != "true" {
+		t.Fatalf("expected the peer group selected, got %v", selector)
+	}
+}
+
+func TestToNetworkPolicyDenyPrecedence(t *testing.T) {
+	// a deny rule of lower precedence leaves the allow rule whole
+	policy, err := toNetworkPolicy(newSecurityGroup([]system.SecurityGroupRole{
+		{IpProtocol: system.TCP, PortRange: "443/443", SourceCidrIp: "10.0.0.0/8", Priority: 1},
+		{IpProtocol: system.ALL, SourceCidrIp: "10.1.0.0/16", Action: system.Deny, Priority: 50},
+	}, []system.SecurityGroupRole{{IpProtocol: system.ALL, SourceCidrIp: "0.0.0.0/0"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if except := policy.Spec.Ingress[0].From[0].IPBlock.Except; len(except) != 0 {
+		t.Fatalf("expected no except, got %v", except)
+	}
+	if len(policy.Spec.PolicyTypes) != 2 || len(policy.Spec.Egress) != 1 || policy.Spec.Egress[0].Ports != nil {
+		t.Fatalf("expected egress open to every port, got %v %v", policy.Spec.PolicyTypes, policy.Spec.Egress)
+	}
+
+	// a deny rule covering the whole allow rule drops it
+	policy, err = toNetworkPolicy(newSecurityGroup([]system.SecurityGroupRole{
+		{IpProtocol: system.TCP, PortRange: "443/443", SourceCidrIp: "10.1.0.0/16"},
+		{IpProtocol: system.TCP, PortRange: "1/1024", SourceCidrIp: "10.0.0.0/8", Action: system.Deny},
+	}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policy.Spec.Ingress) != 0 {
+		t.Fatalf("expected the covered allow rule dropped, got %v", policy.Spec.Ingress)
+	}
+}
+
+func TestToNetworkPolicyUnsupported(t *testing.T) {
+	for name, rules := range map[string][]system.SecurityGroupRole{
+		"icmp":        {{IpProtocol: system.ICMP, SourceCidrIp: "0.0.0.0/0"}},
+		"deny group":  {{IpProtocol: system.ALL, PeerGroup: "db", Action: system.Deny}},
+		"wide range":  {{IpProtocol: system.TCP, PortRange: "1000/2000", SourceCidrIp: "0.0.0.0/0"}},
+		"partly deny": {{IpProtocol: system.TCP, PortRange: "80/90", SourceCidrIp: "10.0.0.0/8"}, {IpProtocol: system.TCP, PortRange: "85/85", SourceCidrIp: "10.1.0.0/16", Action: system.Deny}},
+		"invalid":     {{IpProtocol: system.TCP, PortRange: "0/70000", SourceCidrIp: "0.0.0.0/0"}},
+		"no peer":     {{IpProtocol: system.TCP, PortRange: "22/22"}},
+	} {
+		if _, err := toNetworkPolicy(newSecurityGroup(rules, nil)); err == nil {
+			t.Fatalf("expected %s rules to be refused", name)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
 	"github.com/ddx2x/oilmont/pkg/resource/system"
 	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,43 +22,29 @@ func (V *SecurityGroupCtrl) NorthOnAdd(obj core.IObject) {
 	if securityGroup.Spec.Status != common.INIT {
 		return
 	}
-
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
-	if err != nil {
-		flog.Infof("get client error %v", err)
+	if !isLocal(&securityGroup) {
+		V.createCloudSecurityGroup(&securityGroup)
 		return
 	}
-
-	labels := make([]securityGroupLabel, 0)
-	for k, v := range securityGroup.Labels {
-		label := securityGroupLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
+	if err := V.applyNetworkPolicy(&securityGroup); err != nil {
+		V.fail(&securityGroup, err)
+		return
 	}
 
-	sg := securityGroupParams{
-		Name:      securityGroup.GetName(),
-		Namespace: securityGroup.GetNamespace(),
-		Id:        securityGroup.Spec.ID,
-		VpcId:     securityGroup.Spec.VpcId,
-		Region:    securityGroup.Spec.RegionId,
-		Ingress:   securityGroup.Spec.Ingress,
-		Egress:    securityGroup.Spec.Egress,
-		LocalName: securityGroup.Spec.LocalName,
-		Status:    common.INIT,
-		Labels:    labels,
+	client, err := V.clientOf(&securityGroup)
+	if err != nil {
+		flog.Infof("get client error %v", err)
+		return
 	}
 
-	unstructuredObj, err := utilsObj.Render(sg, securityGroupTpl)
+	unstructuredObj, err := ddx2xv1.ToUnstructured(ddx2xv1.ToSecurityGroup(&securityGroup))
 	if err != nil {
-		flog.Warnf("render obj error %v", err)
+		flog.Warnf("convert obj error %v", err)
 		return
 	}
 	_, err = client.Interface.Resource(securityGroupGvr).Namespace(securityGroup.GetNamespace()).Create(
 		context.Background(), unstructuredObj, metav1.CreateOptions{})
-
+	V.reportSynced(&securityGroup, err)
 	if err != nil {
 		flog.Warnf("create securityGroup error %v", err)
 		return
@@ -80,43 +67,29 @@ func (V *SecurityGroupCtrl) NorthOnUpdate(obj core.IObject) {
 	if securityGroup.Spec.Status != common.UPDATE {
 		return
 	}
-
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
-	if err != nil {
-		flog.Infof("get client error %v", err)
+	if !isLocal(&securityGroup) {
+		V.updateCloudSecurityGroup(&securityGroup)
 		return
 	}
-
-	labels := make([]securityGroupLabel, 0)
-	for k, v := range securityGroup.Labels {
-		label := securityGroupLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
+	if err := V.applyNetworkPolicy(&securityGroup); err != nil {
+		V.fail(&securityGroup, err)
+		return
 	}
 
-	sg := securityGroupParams{
-		Name:      securityGroup.GetName(),
-		Namespace: securityGroup.GetNamespace(),
-		Id:        securityGroup.Spec.ID,
-		VpcId:     securityGroup.Spec.VpcId,
-		Region:    securityGroup.Spec.RegionId,
-		Ingress:   securityGroup.Spec.Ingress,
-		Egress:    securityGroup.Spec.Egress,
-		Status:    common.UPDATE,
-		Labels:    labels,
-		LocalName: securityGroup.Spec.LocalName,
+	client, err := V.clientOf(&securityGroup)
+	if err != nil {
+		flog.Infof("get client error %v", err)
+		return
 	}
 
-	unstructuredObj, err := utilsObj.Render(sg, securityGroupTpl)
+	unstructuredObj, err := ddx2xv1.ToUnstructured(ddx2xv1.ToSecurityGroup(&securityGroup))
 	if err != nil {
-		flog.Warnf("render obj error %v", err)
+		flog.Warnf("convert obj error %v", err)
 		return
 	}
 
 	_, _, err = client.Apply(context.Background(), securityGroup.GetNamespace(), securityGroupGvr, securityGroup.GetName(), unstructuredObj, false)
-
+	V.reportSynced(&securityGroup, err)
 	if err != nil {
 		flog.Infof("update securityGroup obj error %v", err)
 		return
@@ -134,45 +107,25 @@ func (V *SecurityGroupCtrl) NorthOnDelete(obj core.IObject) {
 		flog.Warnf("unstructured obj error %v", err)
 		return
 	}
+	if !isLocal(&securityGroup) {
+		V.deleteCloudSecurityGroup(&securityGroup)
+		return
+	}
 
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
+	client, err := V.clientOf(&securityGroup)
 	if err != nil {
 		flog.Infof("get client error %v", err)
 		return
 	}
 
-	labels := make([]securityGroupLabel, 0)
-	for k, v := range securityGroup.Labels {
-		label := securityGroupLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
-	}
-
-	if securityGroup.Spec.Ingress == nil {
-		securityGroup.Spec.Ingress = make([]system.SecurityGroupRole, 0)
-	}
-	if securityGroup.Spec.Egress == nil {
-		securityGroup.Spec.Egress = make([]system.SecurityGroupRole, 0)
-	}
-
-	sg := securityGroupParams{
-		Name:      securityGroup.GetName(),
-		Namespace: securityGroup.GetNamespace(),
-		Id:        securityGroup.Spec.ID,
-		VpcId:     securityGroup.Spec.VpcId,
-		Region:    securityGroup.Spec.RegionId,
-		Ingress:   securityGroup.Spec.Ingress,
-		Egress:    securityGroup.Spec.Egress,
-		Status:    common.DELETE,
-		Labels:    labels,
-		LocalName: securityGroup.Spec.LocalName,
+	if err := V.deleteNetworkPolicy(&securityGroup); err != nil {
+		flog.Warnf("delete network policy of securityGroup %s error %v", securityGroup.GetName(), err)
 	}
 
-	unstructuredObj, err := utilsObj.Render(sg, securityGroupTpl)
+	securityGroup.Spec.Status = common.DELETE
+	unstructuredObj, err := ddx2xv1.ToUnstructured(ddx2xv1.ToSecurityGroup(&securityGroup))
 	if err != nil {
-		flog.Warnf("render obj error %v", err)
+		flog.Warnf("convert obj error %v", err)
 		return
 	}
 
@@ -0,0 +1,59 @@
+package securitygroupctrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var networkPolicyGvr = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}
+
+var _ controller.Planner = &SecurityGroupCtrl{}
+
+// Plan the security group custom resource and the network policy the north event of a local group would apply.
+// Cloud groups change no object of the clusters
+func (V *SecurityGroupCtrl) Plan(eventType core.EventType, obj core.IObject) ([]controller.Rendered, error) {
+	securityGroup := &system.SecurityGroup{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, securityGroup); err != nil {
+		return nil, err
+	}
+	if !isLocal(securityGroup) {
+		return nil, nil
+	}
+	cluster, err := V.placement.Resolve(controller.Placement{Provider: securityGroup.GetNamespace(), Region: securityGroup.Spec.RegionId, Workspace: securityGroup.GetWorkspace()})
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: networkPolicyName(securityGroup), Namespace: securityGroup.GetWorkspace()},
+	}
+	if eventType != core.DELETED {
+		if policy, err = toNetworkPolicy(securityGroup); err != nil {
+			return nil, err
+		}
+	}
+	policy.Kind, policy.APIVersion = "NetworkPolicy", networkPolicyGvr.GroupVersion().String()
+	policyObject, err := ddx2xv1.ToUnstructured(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if eventType == core.DELETED {
+		securityGroup.Spec.Status = common.DELETE
+	}
+	object, err := ddx2xv1.ToUnstructured(ddx2xv1.ToSecurityGroup(securityGroup))
+	if err != nil {
+		return nil, err
+	}
+	return []controller.Rendered{
+		{Cluster: cluster, Gvr: networkPolicyGvr, Object: policyObject, Delete: eventType == core.DELETED},
+		{Cluster: cluster, Gvr: securityGroupGvr, Object: object},
+	}, nil
+}
@@ -0,0 +1,30 @@
+package securitygroupctrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ controller.Resyncer = &SecurityGroupCtrl{}
+
+func (V *SecurityGroupCtrl) ResyncTable() string { return common.SECURITYGROUP }
+
+func (V *SecurityGroupCtrl) ResyncGvr() schema.GroupVersionResource { return securityGroupGvr }
+
+// ToSouth the security group custom resource the stage object is pushed as, none for a cloud group
+func (V *SecurityGroupCtrl) ToSouth(obj core.IObject) (*unstructured.Unstructured, error) {
+	object := &system.SecurityGroup{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, object); err != nil {
+		return nil, err
+	}
+	if !isLocal(object) {
+		return nil, nil
+	}
+	return ddx2xv1.ToUnstructured(ddx2xv1.ToSecurityGroup(object))
+}
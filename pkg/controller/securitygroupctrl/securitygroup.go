@@ -3,10 +3,12 @@ package securitygroupctrl
 import (
 	"context"
 
+	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/controller"
 	"github.com/ddx2x/oilmont/pkg/controller/clients"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -17,16 +19,63 @@ var (
 var _ controller.Handler = &SecurityGroupCtrl{}
 
 type SecurityGroupCtrl struct {
-	stage datasource.IStorage
-	cs    *clients.Clients
-	flog  log.Logger
+	stage     datasource.IStorage
+	cs        *clients.Clients
+	placement *controller.PlacementResolver
+	flog      log.Logger
 }
 
 func (V *SecurityGroupCtrl) Set(cs *clients.Clients, stage datasource.IStorage) {
 	V.cs, V.stage = cs, stage
+	V.placement = controller.NewPlacementResolver(stage, cs)
 }
 
 func NewSecurityGroupCtrl(ctx context.Context) controller.Handler {
 	flog := log.GetLogger(ctx).WithField("controller", "securityGroupCtrl")
 	return &SecurityGroupCtrl{flog: flog}
 }
+
+func (V *SecurityGroupCtrl) reportSynced(securityGroup *system.SecurityGroup, err error) {
+	if reportErr := controller.ReportObserved(V.stage, common.SECURITYGROUP, securityGroup, controller.SyncedCondition(err)); reportErr != nil {
+		V.flog.Warnf("report securityGroup %s synced condition error %v", securityGroup.GetName(), reportErr)
+	}
+}
+
+func (V *SecurityGroupCtrl) reportReady(securityGroup *system.SecurityGroup) {
+	condition := controller.ReadyCondition(securityGroup.Spec.Status, securityGroup.Spec.Message)
+	if reportErr := controller.ReportCondition(V.stage, common.SECURITYGROUP, securityGroup, condition); reportErr != nil {
+		V.flog.Warnf("report securityGroup %s ready condition error %v", securityGroup.GetName(), reportErr)
+	}
+}
+
+// clientOf client of the cluster owning the securityGroup
+func (V *SecurityGroupCtrl) clientOf(securityGroup *system.SecurityGroup) (*clients.KubeClient, error) {
+	return V.placement.Client(controller.Placement{Provider: securityGroup.GetNamespace(), Region: securityGroup.Spec.RegionId, Workspace: securityGroup.GetWorkspace()})
+}
+
+// ownedPaths the vendor id and the progress of a securityGroup, written back by the controller without bumping the
+// generation
+var ownedPaths = append([]string{"spec.id"}, datasource.ProgressPaths...)
+
+// fail record err on the securityGroup when its rules can not be applied
+func (V *SecurityGroupCtrl) fail(securityGroup *system.SecurityGroup, err error) {
+	V.flog.Warnf("securityGroup %s error %v", securityGroup.GetName(), err)
+	securityGroup.Spec.Status = common.FAIL
+	securityGroup.Spec.Message = err.Error()
+	if _, applyErr := V.stage.ApplyStatus(common.DefaultDatabase, common.SECURITYGROUP, securityGroup.GetName(), securityGroup, ownedPaths...); applyErr != nil {
+		V.flog.Warnf("change securityGroup status error %v", applyErr)
+	}
+	V.reportSynced(securityGroup, err)
+	V.reportReady(securityGroup)
+}
+
+// succeed record the securityGroup running once the vendor applied it, a cloud securityGroup has no custom resource
+func (V *SecurityGroupCtrl) succeed(securityGroup *system.SecurityGroup) {
+	securityGroup.Spec.Status = common.RUNNING
+	securityGroup.Spec.Message = "success"
+	if _, applyErr := V.stage.ApplyStatus(common.DefaultDatabase, common.SECURITYGROUP, securityGroup.GetName(), securityGroup, ownedPaths...); applyErr != nil {
+		V.flog.Warnf("change securityGroup status error %v", applyErr)
+	}
+	V.reportSynced(securityGroup, nil)
+	V.reportReady(securityGroup)
+}
@@ -0,0 +1,166 @@
+package snapshotctrl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+var (
+	// cloudSnapshotPollInterval interval the provider is asked whether the disk snapshots are ready
+	cloudSnapshotPollInterval = 10 * time.Second
+	// cloudSnapshotDeadline a snapshot not ready by then is failed
+	cloudSnapshotDeadline = 30 * time.Minute
+)
+
+// createCloudSnapshot snapshot every disk attached to the instance of vm
+func (S *SnapshotCtrl) createCloudSnapshot(snapshot *compute.VirtualMachineSnapshot, vm *compute.VirtualMachine) {
+	flog := S.flog.WithField("func", "createCloudSnapshot")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if vm.Spec.InstanceId == "" {
+		S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, fmt.Errorf("vm %s has no instance", vm.GetName()))
+		return
+	}
+	provider, err := cloudprovider.ForProvider(S.stage, snapshot.Spec.Vendor, snapshot.Spec.RegionId)
+	if err != nil {
+		S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, err)
+		return
+	}
+
+	disks, err := provider.Disks().List(ctx, snapshot.Spec.RegionId)
+	if err != nil {
+		S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, fmt.Errorf("list disks error %v", err))
+		return
+	}
+	snapshot.Spec.Disks = make([]compute.DiskSnapshot, 0)
+	for _, disk := range disks {
+		if !attachedTo(disk, vm.Spec.InstanceId) {
+			continue
+		}
+		id, err := provider.Snapshots().Create(ctx, snapshot.Spec.RegionId, disk.Spec.StorageId, snapshot.GetName())
+		if err != nil {
+			S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, fmt.Errorf("snapshot disk %s error %v", disk.Spec.StorageId, err))
+			return
+		}
+		snapshot.Spec.Disks = append(snapshot.Spec.Disks, compute.DiskSnapshot{StorageId: disk.Spec.StorageId, SnapshotId: id})
+	}
+	if len(snapshot.Spec.Disks) == 0 {
+		S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, fmt.Errorf("instance %s has no disk", vm.Spec.InstanceId))
+		return
+	}
+	flog.Infof("snapshot vm %s disks %v", vm.GetName(), snapshot.Spec.Disks)
+
+	ready, err := cloudSnapshotReady(ctx, provider, snapshot)
+	switch {
+	case err != nil:
+		S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, err)
+	case ready:
+		S.changeSnapshotState(snapshot, compute.SnapshotStateReady, nil)
+	default:
+		S.changeSnapshotState(snapshot, compute.SnapshotStateCreating, nil)
+		go S.waitCloudSnapshot(provider, snapshot)
+	}
+}
+
+// waitCloudSnapshot poll the provider until the disk snapshots are ready, failed or the deadline passed
+func (S *SnapshotCtrl) waitCloudSnapshot(provider cloudprovider.Interface, snapshot *compute.VirtualMachineSnapshot) {
+	ctx, cancel := context.WithTimeout(context.Background(), cloudSnapshotDeadline)
+	defer cancel()
+
+	ticker := time.NewTicker(cloudSnapshotPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, fmt.Errorf("snapshot not ready in %s", cloudSnapshotDeadline))
+			return
+		case <-ticker.C:
+		}
+
+		ready, err := cloudSnapshotReady(ctx, provider, snapshot)
+		if err == nil && !ready {
+			continue
+		}
+		// the snapshot may have been deleted meanwhile
+		if _, getErr := getSnapshot(S.stage, snapshot.GetWorkspace(), snapshot.GetName()); getErr != nil {
+			return
+		}
+		if err != nil {
+			S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, err)
+			return
+		}
+		S.changeSnapshotState(snapshot, compute.SnapshotStateReady, nil)
+		return
+	}
+}
+
+func cloudSnapshotReady(ctx context.Context, provider cloudprovider.Interface, snapshot *compute.VirtualMachineSnapshot) (bool, error) {
+	for _, disk := range snapshot.Spec.Disks {
+		ready, err := provider.Snapshots().Ready(ctx, snapshot.Spec.RegionId, disk.SnapshotId)
+		if err != nil {
+			return false, fmt.Errorf("snapshot %s of disk %s error %v", disk.SnapshotId, disk.StorageId, err)
+		}
+		if !ready {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (S *SnapshotCtrl) deleteCloudSnapshot(snapshot *compute.VirtualMachineSnapshot) {
+	flog := S.flog.WithField("func", "deleteCloudSnapshot")
+	if len(snapshot.Spec.Disks) == 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(S.stage, snapshot.Spec.Vendor, snapshot.Spec.RegionId)
+	if err != nil {
+		flog.Warnf("get cloud provider error %v", err)
+		return
+	}
+	for _, disk := range snapshot.Spec.Disks {
+		err := provider.Snapshots().Delete(ctx, snapshot.Spec.RegionId, disk.SnapshotId)
+		if err != nil && err != cloudprovider.NotFound {
+			flog.Warnf("delete snapshot %s of disk %s error %v", disk.SnapshotId, disk.StorageId, err)
+			continue
+		}
+		flog.Infof("delete snapshot %s of disk %s", disk.SnapshotId, disk.StorageId)
+	}
+}
+
+// restoreCloudVM roll every disk of the snapshot back, the vm must be stopped
+func (R *RestoreCtrl) restoreCloudVM(restore *compute.VirtualMachineRestore, snapshot *compute.VirtualMachineSnapshot) {
+	flog := R.flog.WithField("func", "restoreCloudVM")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(R.stage, snapshot.Spec.Vendor, snapshot.Spec.RegionId)
+	if err != nil {
+		R.changeRestoreState(restore, compute.RestoreStateFailed, err)
+		return
+	}
+	for _, disk := range snapshot.Spec.Disks {
+		if err := provider.Snapshots().Restore(ctx, snapshot.Spec.RegionId, disk.StorageId, disk.SnapshotId); err != nil {
+			R.changeRestoreState(restore, compute.RestoreStateFailed, fmt.Errorf("restore disk %s from snapshot %s error %v", disk.StorageId, disk.SnapshotId, err))
+			return
+		}
+	}
+	flog.Infof("restore vm %s from snapshot %s", restore.Spec.VirtualMachine, snapshot.GetName())
+	R.changeRestoreState(restore, compute.RestoreStateSucceeded, nil)
+}
+
+func attachedTo(disk compute.Storage, instanceId string) bool {
+	for _, attachment := range disk.Spec.Attachments {
+		if attachment.InstanceId == instanceId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package snapshotctrl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	objUtils "github.com/ddx2x/oilmont/pkg/utils/obj"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	snapshotv1alpha1 "kubevirt.io/client-go/apis/snapshot/v1alpha1"
+)
+
+var kubeVirtGroup = "kubevirt.io"
+
+func vmReference(name string) corev1.TypedLocalObjectReference {
+	return corev1.TypedLocalObjectReference{APIGroup: &kubeVirtGroup, Kind: "VirtualMachine", Name: name}
+}
+
+func (S *SnapshotCtrl) createKubeVirtSnapshot(snapshot *compute.VirtualMachineSnapshot, vm *compute.VirtualMachine) {
+	flog := S.flog.WithField("func", "createKubeVirtSnapshot")
+
+	client, err := clientOf(S.placement, vm)
+	if err != nil {
+		S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, err)
+		return
+	}
+
+	kubeVirtSnapshot := &snapshotv1alpha1.VirtualMachineSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      snapshot.GetName(),
+			Namespace: vm.GetWorkspace(),
+			Labels:    map[string]string{"workspace": vm.GetWorkspace(), "virtualmachine": vm.GetName()},
+		},
+		Spec: snapshotv1alpha1.VirtualMachineSnapshotSpec{Source: vmReference(vm.GetName())},
+	}
+	_, err = client.KubevirtCli.VirtualMachineSnapshot(vm.GetWorkspace()).Create(context.Background(), kubeVirtSnapshot, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, fmt.Errorf("create kubeVirt snapshot error %v", err))
+		return
+	}
+
+	flog.Infof("create kubeVirt snapshot %s of vm %s", snapshot.GetName(), vm.GetName())
+	S.changeSnapshotState(snapshot, compute.SnapshotStateCreating, nil)
+}
+
+func (S *SnapshotCtrl) deleteKubeVirtSnapshot(snapshot *compute.VirtualMachineSnapshot) {
+	flog := S.flog.WithField("func", "deleteKubeVirtSnapshot")
+
+	vm, err := vmOf(S.stage, snapshot.GetWorkspace(), snapshot.Spec.VirtualMachine)
+	if err != nil {
+		flog.Warnf("get vm %s of snapshot %s error %v", snapshot.Spec.VirtualMachine, snapshot.GetName(), err)
+		return
+	}
+	client, err := clientOf(S.placement, vm)
+	if err != nil {
+		flog.Warnf("get client error %v", err)
+		return
+	}
+
+	err = client.KubevirtCli.VirtualMachineSnapshot(vm.GetWorkspace()).Delete(context.Background(), snapshot.GetName(), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		flog.Warnf("delete kubeVirt snapshot %s error %v", snapshot.GetName(), err)
+		return
+	}
+	flog.Infof("delete kubeVirt snapshot %s", snapshot.GetName())
+}
+
+// kubeVirtSnapshotToStage mirror the phase of the kubeVirt snapshot into the stage snapshot of the same name
+func (S *SnapshotCtrl) kubeVirtSnapshotToStage(cluster string, obj runtime.Object) {
+	flog := S.flog.WithField("func", "kubeVirtSnapshotToStage").WithField("cluster", cluster)
+
+	kubeVirtSnapshot := &snapshotv1alpha1.VirtualMachineSnapshot{}
+	if err := fromObject(obj, kubeVirtSnapshot); err != nil {
+		flog.Warnf("unmarshal kubeVirt snapshot error %v", err)
+		return
+	}
+	snapshot, err := getSnapshot(S.stage, kubeVirtSnapshot.GetNamespace(), kubeVirtSnapshot.GetName())
+	if err != nil {
+		return
+	}
+
+	state, err := kubeVirtSnapshotState(kubeVirtSnapshot)
+	if state == snapshot.Spec.State {
+		return
+	}
+	S.changeSnapshotState(snapshot, state, err)
+}
+
+func kubeVirtSnapshotState(snapshot *snapshotv1alpha1.VirtualMachineSnapshot) (string, error) {
+	status := snapshot.Status
+	if status == nil {
+		return compute.SnapshotStateCreating, nil
+	}
+	switch status.Phase {
+	case snapshotv1alpha1.Succeeded:
+		if status.ReadyToUse != nil && *status.ReadyToUse {
+			return compute.SnapshotStateReady, nil
+		}
+	case snapshotv1alpha1.Failed:
+		message := "kubeVirt snapshot failed"
+		if status.Error != nil && status.Error.Message != nil {
+			message = *status.Error.Message
+		}
+		return compute.SnapshotStateFailed, fmt.Errorf("%s", message)
+	}
+	return compute.SnapshotStateCreating, nil
+}
+
+func (R *RestoreCtrl) restoreKubeVirtVM(restore *compute.VirtualMachineRestore, vm *compute.VirtualMachine) {
+	flog := R.flog.WithField("func", "restoreKubeVirtVM")
+
+	client, err := clientOf(R.placement, vm)
+	if err != nil {
+		R.changeRestoreState(restore, compute.RestoreStateFailed, err)
+		return
+	}
+
+	kubeVirtRestore := &snapshotv1alpha1.VirtualMachineRestore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.GetName(),
+			Namespace: vm.GetWorkspace(),
+			Labels:    map[string]string{"workspace": vm.GetWorkspace(), "virtualmachine": vm.GetName()},
+		},
+		Spec: snapshotv1alpha1.VirtualMachineRestoreSpec{
+			Target:                     vmReference(vm.GetName()),
+			VirtualMachineSnapshotName: restore.Spec.Snapshot,
+		},
+	}
+	_, err = client.KubevirtCli.VirtualMachineRestore(vm.GetWorkspace()).Create(context.Background(), kubeVirtRestore, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		R.changeRestoreState(restore, compute.RestoreStateFailed, fmt.Errorf("create kubeVirt restore error %v", err))
+		return
+	}
+
+	flog.Infof("restore vm %s from kubeVirt snapshot %s", vm.GetName(), restore.Spec.Snapshot)
+	R.changeRestoreState(restore, compute.RestoreStateRestoring, nil)
+}
+
+func (R *RestoreCtrl) deleteKubeVirtRestore(restore *compute.VirtualMachineRestore) {
+	flog := R.flog.WithField("func", "deleteKubeVirtRestore")
+
+	vm, err := vmOf(R.stage, restore.GetWorkspace(), restore.Spec.VirtualMachine)
+	if err != nil {
+		flog.Warnf("get vm %s of restore %s error %v", restore.Spec.VirtualMachine, restore.GetName(), err)
+		return
+	}
+	client, err := clientOf(R.placement, vm)
+	if err != nil {
+		flog.Warnf("get client error %v", err)
+		return
+	}
+
+	err = client.KubevirtCli.VirtualMachineRestore(vm.GetWorkspace()).Delete(context.Background(), restore.GetName(), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		flog.Warnf("delete kubeVirt restore %s error %v", restore.GetName(), err)
+	}
+}
+
+// kubeVirtRestoreToStage a restore succeeds once kubeVirt reports it complete
+func (R *RestoreCtrl) kubeVirtRestoreToStage(cluster string, obj runtime.Object) {
+	flog := R.flog.WithField("func", "kubeVirtRestoreToStage").WithField("cluster", cluster)
+
+	kubeVirtRestore := &snapshotv1alpha1.VirtualMachineRestore{}
+	if err := fromObject(obj, kubeVirtRestore); err != nil {
+		flog.Warnf("unmarshal kubeVirt restore error %v", err)
+		return
+	}
+	if kubeVirtRestore.Status == nil || kubeVirtRestore.Status.Complete == nil || !*kubeVirtRestore.Status.Complete {
+		return
+	}
+
+	restore, err := getRestore(R.stage, kubeVirtRestore.GetNamespace(), kubeVirtRestore.GetName())
+	if err != nil {
+		return
+	}
+	if restore.Spec.State == compute.RestoreStateSucceeded {
+		return
+	}
+	R.changeRestoreState(restore, compute.RestoreStateSucceeded, nil)
+}
+
+func fromObject(obj runtime.Object, result interface{}) error {
+	object := &unstructured.Unstructured{}
+	if err := objUtils.Unmarshal(object, obj); err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, result)
+}
@@ -0,0 +1,171 @@
+package snapshotctrl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/controller/clients"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var _ controller.Handler = &RestoreCtrl{}
+
+// RestoreCtrl roll a vm back to one of its ready snapshots
+type RestoreCtrl struct {
+	stage     datasource.IStorage
+	cs        *clients.Clients
+	placement *controller.PlacementResolver
+	flog      log.Logger
+}
+
+func (R *RestoreCtrl) Set(cs *clients.Clients, stage datasource.IStorage) {
+	R.cs, R.stage = cs, stage
+	R.placement = controller.NewPlacementResolver(stage, cs)
+}
+
+func NewRestoreCtrl(ctx context.Context) controller.Handler {
+	flog := log.GetLogger(ctx).WithField("controller", "restorectrl")
+	return &RestoreCtrl{flog: flog}
+}
+
+func (R *RestoreCtrl) NorthOnAdd(obj core.IObject) {
+	flog := R.flog.WithField("func", "NorthOnAdd")
+
+	restore := &compute.VirtualMachineRestore{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, restore); err != nil {
+		flog.Warnf("unstructured obj error %v", err)
+		return
+	}
+	if restore.Spec.Status != common.INIT {
+		return
+	}
+
+	snapshot, err := getSnapshot(R.stage, restore.GetWorkspace(), restore.Spec.Snapshot)
+	if err != nil {
+		R.changeRestoreState(restore, compute.RestoreStateFailed, fmt.Errorf("get snapshot %s error %v", restore.Spec.Snapshot, err))
+		return
+	}
+	if snapshot.Spec.State != compute.SnapshotStateReady {
+		R.changeRestoreState(restore, compute.RestoreStateFailed, fmt.Errorf("snapshot %s is not ready", snapshot.GetName()))
+		return
+	}
+	vm, err := vmOf(R.stage, restore.GetWorkspace(), snapshot.Spec.VirtualMachine)
+	if err != nil {
+		R.changeRestoreState(restore, compute.RestoreStateFailed, err)
+		return
+	}
+	if vm.Spec.State != compute.Stopped {
+		R.changeRestoreState(restore, compute.RestoreStateFailed, fmt.Errorf("vm %s must be stopped before restore, it is %s", vm.GetName(), vm.Spec.State))
+		return
+	}
+
+	if cloudprovider.IsRegistered(snapshot.Spec.Vendor) {
+		R.restoreCloudVM(restore, snapshot)
+		return
+	}
+	R.restoreKubeVirtVM(restore, vm)
+}
+
+// NorthOnUpdate a restore is immutable once submitted
+func (R *RestoreCtrl) NorthOnUpdate(core.IObject) {}
+
+func (R *RestoreCtrl) NorthOnDelete(obj core.IObject) {
+	restore := &compute.VirtualMachineRestore{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, restore); err != nil {
+		return
+	}
+	if snapshot, err := getSnapshot(R.stage, restore.GetWorkspace(), restore.Spec.Snapshot); err == nil && cloudprovider.IsRegistered(snapshot.Spec.Vendor) {
+		return
+	}
+	R.deleteKubeVirtRestore(restore)
+}
+
+func (R *RestoreCtrl) NorthEventCh(ctx context.Context) (<-chan core.Event, error) {
+	return R.stage.WatchEvent(ctx, common.DefaultDatabase, common.VIRTUALMACHINERESTORE, "0")
+}
+
+func (R *RestoreCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
+	R.kubeVirtRestoreToStage(cluster, obj)
+}
+
+func (R *RestoreCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
+	R.kubeVirtRestoreToStage(cluster, obj)
+}
+
+func (R *RestoreCtrl) SouthOnDelete(string, runtime.Object) {}
+
+func (R *RestoreCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
+	client, err := R.cs.GetClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+	watchInterface, err := client.Interface.Resource(kubeVirtRestoreGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return []<-chan watch.Event{watchInterface.ResultChan()}, nil
+}
+
+// restorePaths the spec fields of a restore the controller writes back with the progress, they bump no generation
+var restorePaths = append([]string{"spec.state"}, datasource.ProgressPaths...)
+
+func (R *RestoreCtrl) changeRestoreState(restore *compute.VirtualMachineRestore, state string, err error) {
+	flog := R.flog.WithField("func", "changeRestoreState")
+
+	restore.Spec.State = state
+	switch {
+	case err != nil:
+		flog.Warnf("restore %s error %v", restore.GetName(), err)
+		restore.Spec.State = compute.RestoreStateFailed
+		restore.Spec.Status = common.FAIL
+		restore.Spec.Message = err.Error()
+	case state == compute.RestoreStateFailed:
+		restore.Spec.Status = common.FAIL
+	default:
+		restore.Spec.Status = common.RUNNING
+		restore.Spec.Message = "success"
+	}
+
+	if _, applyErr := R.stage.ApplyStatus(common.DefaultDatabase, common.VIRTUALMACHINERESTORE, restore.GetName(), restore, restorePaths...); applyErr != nil {
+		flog.Warnf("change restore %s state error %v", restore.GetName(), applyErr)
+		return
+	}
+	if reportErr := controller.ReportObserved(R.stage, common.VIRTUALMACHINERESTORE, restore, controller.SyncedCondition(err)); reportErr != nil {
+		flog.Warnf("report restore %s synced condition error %v", restore.GetName(), reportErr)
+	}
+	if reportErr := controller.ReportCondition(R.stage, common.VIRTUALMACHINERESTORE, restore, restoreReadyCondition(restore)); reportErr != nil {
+		flog.Warnf("report restore %s ready condition error %v", restore.GetName(), reportErr)
+	}
+}
+
+func restoreReadyCondition(restore *compute.VirtualMachineRestore) core.Condition {
+	switch restore.Spec.State {
+	case compute.RestoreStateSucceeded:
+		return core.NewCondition(core.ConditionReady, core.ConditionTrue, controller.ReasonRunning, restore.Spec.Message)
+	case compute.RestoreStateFailed:
+		return core.NewCondition(core.ConditionReady, core.ConditionFalse, controller.ReasonFailed, restore.Spec.Message)
+	}
+	return core.NewCondition(core.ConditionReady, core.ConditionUnknown, controller.ReasonPending, restore.Spec.Message)
+}
+
+func getRestore(stage datasource.IStorage, workspace, name string) (*compute.VirtualMachineRestore, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	restore := &compute.VirtualMachineRestore{}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINERESTORE, restore, filter, true); err != nil {
+		return nil, err
+	}
+	return restore, nil
+}
@@ -0,0 +1,65 @@
+package snapshotctrl
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+// prune remove the ready snapshots of the vm of snapshot beyond its retention,
+// the provider or kubeVirt snapshots go away with the delete events
+func (S *SnapshotCtrl) prune(snapshot *compute.VirtualMachineSnapshot) {
+	flog := S.flog.WithField("func", "prune")
+
+	retention := snapshot.Spec.Retention
+	if retention.Days <= 0 && retention.Count <= 0 {
+		return
+	}
+
+	filter := map[string]interface{}{
+		"spec.virtual_machine": snapshot.Spec.VirtualMachine,
+		"spec.state":           compute.SnapshotStateReady,
+	}
+	if snapshot.GetWorkspace() != "" {
+		filter[common.FilterWorkspace] = snapshot.GetWorkspace()
+	}
+	snapshots := make([]compute.VirtualMachineSnapshot, 0)
+	if err := S.stage.ListToObject(common.DefaultDatabase, common.VIRTUALMACHINESNAPSHOT, filter, &snapshots, true); err != nil {
+		flog.Warnf("list snapshots of vm %s error %v", snapshot.Spec.VirtualMachine, err)
+		return
+	}
+
+	for _, expired := range expiredSnapshots(snapshots, retention, time.Now()) {
+		if err := S.stage.Delete(common.DefaultDatabase, common.VIRTUALMACHINESNAPSHOT, expired.GetName(), expired.GetWorkspace()); err != nil {
+			flog.Warnf("delete expired snapshot %s error %v", expired.GetName(), err)
+			continue
+		}
+		flog.Infof("delete expired snapshot %s of vm %s", expired.GetName(), snapshot.Spec.VirtualMachine)
+	}
+}
+
+// expiredSnapshots the snapshots older than retention days or beyond the newest retention count
+func expiredSnapshots(snapshots []compute.VirtualMachineSnapshot, retention compute.SnapshotRetention, now time.Time) []compute.VirtualMachineSnapshot {
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Spec.CreateTime > snapshots[j].Spec.CreateTime })
+
+	expired := make([]compute.VirtualMachineSnapshot, 0)
+	for index, snapshot := range snapshots {
+		if retention.Count > 0 && index >= retention.Count {
+			expired = append(expired, snapshot)
+			continue
+		}
+		if retention.Days <= 0 {
+			continue
+		}
+		createTime, err := time.Parse(time.RFC3339, snapshot.Spec.CreateTime)
+		if err != nil {
+			continue
+		}
+		if now.Sub(createTime) > time.Duration(retention.Days)*24*time.Hour {
+			expired = append(expired, snapshot)
+		}
+	}
+	return expired
+}
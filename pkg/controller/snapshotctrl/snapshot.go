@@ -0,0 +1,189 @@
+package snapshotctrl
+
+import (
+	"context"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	_ "github.com/ddx2x/oilmont/pkg/cloudprovider/providers"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/controller/clients"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var _ controller.Handler = &SnapshotCtrl{}
+
+var (
+	kubeVirtSnapshotGvr = schema.GroupVersionResource{Group: "snapshot.kubevirt.io", Version: "v1alpha1", Resource: "virtualmachinesnapshots"}
+	kubeVirtRestoreGvr  = schema.GroupVersionResource{Group: "snapshot.kubevirt.io", Version: "v1alpha1", Resource: "virtualmachinerestores"}
+)
+
+// SnapshotCtrl take the snapshots of kubeVirt vms through snapshot.kubevirt.io and of third-party vms through the provider
+type SnapshotCtrl struct {
+	stage     datasource.IStorage
+	cs        *clients.Clients
+	placement *controller.PlacementResolver
+	flog      log.Logger
+}
+
+func (S *SnapshotCtrl) Set(cs *clients.Clients, stage datasource.IStorage) {
+	S.cs, S.stage = cs, stage
+	S.placement = controller.NewPlacementResolver(stage, cs)
+}
+
+func NewSnapshotCtrl(ctx context.Context) controller.Handler {
+	flog := log.GetLogger(ctx).WithField("controller", "snapshotctrl")
+	return &SnapshotCtrl{flog: flog}
+}
+
+func (S *SnapshotCtrl) NorthOnAdd(obj core.IObject) {
+	flog := S.flog.WithField("func", "NorthOnAdd")
+
+	snapshot := &compute.VirtualMachineSnapshot{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, snapshot); err != nil {
+		flog.Warnf("unstructured obj error %v", err)
+		return
+	}
+	if snapshot.Spec.Status != common.INIT {
+		return
+	}
+
+	vm, err := vmOf(S.stage, snapshot.GetWorkspace(), snapshot.Spec.VirtualMachine)
+	if err != nil {
+		S.changeSnapshotState(snapshot, compute.SnapshotStateFailed, err)
+		return
+	}
+
+	if cloudprovider.IsRegistered(snapshot.Spec.Vendor) {
+		S.createCloudSnapshot(snapshot, vm)
+		return
+	}
+	S.createKubeVirtSnapshot(snapshot, vm)
+}
+
+// NorthOnUpdate a snapshot is immutable once taken
+func (S *SnapshotCtrl) NorthOnUpdate(core.IObject) {}
+
+func (S *SnapshotCtrl) NorthOnDelete(obj core.IObject) {
+	snapshot := &compute.VirtualMachineSnapshot{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, snapshot); err != nil {
+		return
+	}
+
+	if cloudprovider.IsRegistered(snapshot.Spec.Vendor) {
+		S.deleteCloudSnapshot(snapshot)
+		return
+	}
+	S.deleteKubeVirtSnapshot(snapshot)
+}
+
+func (S *SnapshotCtrl) NorthEventCh(ctx context.Context) (<-chan core.Event, error) {
+	return S.stage.WatchEvent(ctx, common.DefaultDatabase, common.VIRTUALMACHINESNAPSHOT, "0")
+}
+
+func (S *SnapshotCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
+	S.kubeVirtSnapshotToStage(cluster, obj)
+}
+
+func (S *SnapshotCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
+	S.kubeVirtSnapshotToStage(cluster, obj)
+}
+
+// SouthOnDelete the stage owns the snapshot, removing it from the cluster does not remove the record
+func (S *SnapshotCtrl) SouthOnDelete(string, runtime.Object) {}
+
+func (S *SnapshotCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
+	client, err := S.cs.GetClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+	watchInterface, err := client.Interface.Resource(kubeVirtSnapshotGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return []<-chan watch.Event{watchInterface.ResultChan()}, nil
+}
+
+// snapshotPaths the spec fields of a snapshot the controller writes back with the progress, they bump no generation
+var snapshotPaths = append([]string{"spec.disks", "spec.state"}, datasource.ProgressPaths...)
+
+// changeSnapshotState record the state reached by the snapshot, the ready ones are subject to the retention policy
+func (S *SnapshotCtrl) changeSnapshotState(snapshot *compute.VirtualMachineSnapshot, state string, err error) {
+	flog := S.flog.WithField("func", "changeSnapshotState")
+
+	snapshot.Spec.State = state
+	switch {
+	case err != nil:
+		flog.Warnf("snapshot %s error %v", snapshot.GetName(), err)
+		snapshot.Spec.State = compute.SnapshotStateFailed
+		snapshot.Spec.Status = common.FAIL
+		snapshot.Spec.Message = err.Error()
+	case state == compute.SnapshotStateFailed:
+		snapshot.Spec.Status = common.FAIL
+	default:
+		snapshot.Spec.Status = common.RUNNING
+		snapshot.Spec.Message = "success"
+	}
+
+	if _, applyErr := S.stage.ApplyStatus(common.DefaultDatabase, common.VIRTUALMACHINESNAPSHOT, snapshot.GetName(), snapshot, snapshotPaths...); applyErr != nil {
+		flog.Warnf("change snapshot %s state error %v", snapshot.GetName(), applyErr)
+		return
+	}
+	if reportErr := controller.ReportObserved(S.stage, common.VIRTUALMACHINESNAPSHOT, snapshot, controller.SyncedCondition(err)); reportErr != nil {
+		flog.Warnf("report snapshot %s synced condition error %v", snapshot.GetName(), reportErr)
+	}
+	if reportErr := controller.ReportCondition(S.stage, common.VIRTUALMACHINESNAPSHOT, snapshot, snapshotReadyCondition(snapshot)); reportErr != nil {
+		flog.Warnf("report snapshot %s ready condition error %v", snapshot.GetName(), reportErr)
+	}
+
+	if snapshot.Spec.State == compute.SnapshotStateReady {
+		S.prune(snapshot)
+	}
+}
+
+func snapshotReadyCondition(snapshot *compute.VirtualMachineSnapshot) core.Condition {
+	switch snapshot.Spec.State {
+	case compute.SnapshotStateReady:
+		return core.NewCondition(core.ConditionReady, core.ConditionTrue, controller.ReasonRunning, snapshot.Spec.Message)
+	case compute.SnapshotStateFailed:
+		return core.NewCondition(core.ConditionReady, core.ConditionFalse, controller.ReasonFailed, snapshot.Spec.Message)
+	}
+	return core.NewCondition(core.ConditionReady, core.ConditionUnknown, controller.ReasonPending, snapshot.Spec.Message)
+}
+
+func getSnapshot(stage datasource.IStorage, workspace, name string) (*compute.VirtualMachineSnapshot, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	snapshot := &compute.VirtualMachineSnapshot{}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINESNAPSHOT, snapshot, filter, true); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func vmOf(stage datasource.IStorage, workspace, name string) (*compute.VirtualMachine, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	vm := &compute.VirtualMachine{}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, vm, filter, true); err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+// clientOf client of the cluster owning the vm
+func clientOf(placement *controller.PlacementResolver, vm *compute.VirtualMachine) (*clients.KubeClient, error) {
+	return placement.Client(controller.Placement{Provider: vm.Spec.Vendor, Region: vm.Spec.RegionId, Az: vm.Spec.Az, Workspace: vm.GetWorkspace()})
+}
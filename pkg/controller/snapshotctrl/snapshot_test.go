@@ -0,0 +1,146 @@
+package snapshotctrl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake/faketest"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+func newFakeCloudCtrls(t *testing.T) (*SnapshotCtrl, *RestoreCtrl, *fake.Cloud) {
+	stage, cloud := faketest.NewStage(t)
+	S := NewSnapshotCtrl(context.Background()).(*SnapshotCtrl)
+	S.Set(nil, stage)
+	R := NewRestoreCtrl(context.Background()).(*RestoreCtrl)
+	R.Set(nil, stage)
+	return S, R, cloud
+}
+
+// newFakeCloudVM a vm whose instance has a disk attached
+func newFakeCloudVM(t *testing.T, S *SnapshotCtrl, cloud *fake.Cloud) (*compute.VirtualMachine, string) {
+	ctx := context.Background()
+	vm := faketest.SeedVM(t, S.stage, cloud, "vm1")
+	disk := &compute.Storage{Spec: compute.StorageSpec{Region: faketest.Region, Size: 20}}
+	if err := cloud.Disks().Create(ctx, disk); err != nil {
+		t.Fatal(err)
+	}
+	if err := cloud.Disks().Attach(ctx, faketest.Region, disk.Spec.StorageId, vm.Spec.InstanceId); err != nil {
+		t.Fatal(err)
+	}
+	return vm, disk.Spec.StorageId
+}
+
+func newSnapshot(t *testing.T, S *SnapshotCtrl, name string, retention compute.SnapshotRetention) *compute.VirtualMachineSnapshot {
+	snapshot := &compute.VirtualMachineSnapshot{Metadata: core.Metadata{Name: name, Namespace: fake.Name, Workspace: "ws"}}
+	snapshot.Spec = compute.VirtualMachineSnapshotSpec{
+		VirtualMachine: "vm1",
+		Vendor:         fake.Name,
+		RegionId:       "region-1",
+		Retention:      retention,
+		State:          compute.SnapshotStateCreating,
+		Status:         common.INIT,
+		CreateTime:     time.Now().Format(time.RFC3339Nano),
+	}
+	if _, err := S.stage.Create(common.DefaultDatabase, common.VIRTUALMACHINESNAPSHOT, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
+
+func TestCloudSnapshotAndRestore(t *testing.T) {
+	S, R, cloud := newFakeCloudCtrls(t)
+	vm, diskId := newFakeCloudVM(t, S, cloud)
+	S.NorthOnAdd(newSnapshot(t, S, "snap1", compute.SnapshotRetention{}))
+
+	snapshot, err := getSnapshot(S.stage, "ws", "snap1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Spec.State != compute.SnapshotStateReady || len(snapshot.Spec.Disks) != 1 || snapshot.Spec.Disks[0].StorageId != diskId {
+		t.Fatalf("expected ready snapshot of disk %s, got state %s disks %v message %s", diskId, snapshot.Spec.State, snapshot.Spec.Disks, snapshot.Spec.Message)
+	}
+
+	restore := &compute.VirtualMachineRestore{Metadata: core.Metadata{Name: "restore1", Namespace: fake.Name, Workspace: "ws"}}
+	restore.Spec = compute.VirtualMachineRestoreSpec{VirtualMachine: "vm1", Snapshot: "snap1", Status: common.INIT}
+	if _, err := R.stage.Create(common.DefaultDatabase, common.VIRTUALMACHINERESTORE, restore); err != nil {
+		t.Fatal(err)
+	}
+	R.NorthOnAdd(restore)
+	if restore, _ = getRestore(R.stage, "ws", "restore1"); restore.Spec.State != compute.RestoreStateFailed {
+		t.Fatalf("expected restore of a running vm to fail, got %s", restore.Spec.State)
+	}
+
+	if err := cloud.Instances().Stop(context.Background(), "region-1", vm.Spec.InstanceId); err != nil {
+		t.Fatal(err)
+	}
+	vm.Spec.State = compute.Stopped
+	if _, _, err := S.stage.Apply(common.DefaultDatabase, common.VIRTUALMACHINE, vm.GetName(), vm, false); err != nil {
+		t.Fatal(err)
+	}
+	restore.Spec.Status = common.INIT
+	R.NorthOnAdd(restore)
+	if restore, _ = getRestore(R.stage, "ws", "restore1"); restore.Spec.State != compute.RestoreStateSucceeded {
+		t.Fatalf("expected restore succeeded, got %s %s", restore.Spec.State, restore.Spec.Message)
+	}
+	if restored := cloud.Restored(diskId); restored != snapshot.Spec.Disks[0].SnapshotId {
+		t.Fatalf("expected disk restored from %s, got %q", snapshot.Spec.Disks[0].SnapshotId, restored)
+	}
+
+	S.NorthOnDelete(snapshot)
+	if _, err := cloud.Snapshots().Ready(context.Background(), "region-1", snapshot.Spec.Disks[0].SnapshotId); err == nil {
+		t.Fatal("expected provider snapshot deleted")
+	}
+}
+
+func TestCloudSnapshotFail(t *testing.T) {
+	S, _, cloud := newFakeCloudCtrls(t)
+	newFakeCloudVM(t, S, cloud)
+	cloud.SetError(fmt.Errorf("quota exceeded"))
+	S.NorthOnAdd(newSnapshot(t, S, "snap1", compute.SnapshotRetention{}))
+
+	if snapshot, _ := getSnapshot(S.stage, "ws", "snap1"); snapshot.Spec.State != compute.SnapshotStateFailed || snapshot.Spec.Status != common.FAIL {
+		t.Fatalf("expected failed snapshot, got state %s status %s", snapshot.Spec.State, snapshot.Spec.Status)
+	}
+}
+
+func TestSnapshotRetentionCount(t *testing.T) {
+	S, _, cloud := newFakeCloudCtrls(t)
+	newFakeCloudVM(t, S, cloud)
+	for _, name := range []string{"snap1", "snap2", "snap3"} {
+		S.NorthOnAdd(newSnapshot(t, S, name, compute.SnapshotRetention{Count: 2}))
+	}
+
+	if _, err := getSnapshot(S.stage, "ws", "snap1"); err == nil {
+		t.Fatal("expected the oldest snapshot pruned")
+	}
+	for _, name := range []string{"snap2", "snap3"} {
+		if _, err := getSnapshot(S.stage, "ws", name); err != nil {
+			t.Fatalf("expected snapshot %s kept, got %v", name, err)
+		}
+	}
+}
+
+func TestExpiredSnapshots(t *testing.T) {
+	now := time.Now()
+	snapshot := func(name string, age time.Duration) compute.VirtualMachineSnapshot {
+		return compute.VirtualMachineSnapshot{
+			Metadata: core.Metadata{Name: name},
+			Spec:     compute.VirtualMachineSnapshotSpec{CreateTime: now.Add(-age).Format(time.RFC3339)},
+		}
+	}
+	snapshots := []compute.VirtualMachineSnapshot{snapshot("old", 72*time.Hour), snapshot("new", time.Hour), snapshot("mid", 30*time.Hour)}
+
+	expired := expiredSnapshots(snapshots, compute.SnapshotRetention{Days: 2}, now)
+	if len(expired) != 1 || expired[0].Name != "old" {
+		t.Fatalf("expected old expired, got %v", expired)
+	}
+	if expired = expiredSnapshots(snapshots, compute.SnapshotRetention{Days: 2, Count: 1}, now); len(expired) != 2 {
+		t.Fatalf("expected mid and old expired, got %v", expired)
+	}
+}
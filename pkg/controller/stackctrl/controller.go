@@ -0,0 +1,464 @@
+package stackctrl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/datasource/cache"
+	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/proc"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/stack"
+	"github.com/ddx2x/oilmont/pkg/utils/obj"
+)
+
+const (
+	ReasonProgressing = "Progressing"
+	ReasonCompleted   = "Completed"
+	ReasonRolledBack  = "RolledBack"
+)
+
+// DefaultResyncInterval how often the stacks in progress look at the objects they wait for, the objects are not watched
+const DefaultResyncInterval = 15 * time.Second
+
+var _ controller.Controller = &StackController{}
+
+// StackController bring the objects of the stacks to their template through the services. The resources are created
+// in the order of their dependencies, each one once the ones it depends on are ready. A changed resource is updated,
+// a failure rolls the operation back to the specs applied before it
+type StackController struct {
+	datasource.IStorage
+	service  service.IService
+	proc     *proc.Proc
+	interval time.Duration
+	flog     log.Logger
+
+	// mu the watch and the resync do not reconcile at the same time
+	mu sync.Mutex
+}
+
+func NewStackController(store datasource.IStorage) *StackController {
+	return &StackController{
+		IStorage: store,
+		service:  service.NewBaseService(store, cache.NewCache(15*time.Minute, 20*time.Minute)),
+		proc:     proc.NewProc(),
+		interval: DefaultResyncInterval,
+		flog:     log.GetLogger(context.Background()).WithField("controller", "stackctrl"),
+	}
+}
+
+func (s *StackController) Run() error {
+	s.proc.Add(s.WatchStack, s.Resync)
+	return <-s.proc.Start()
+}
+
+// WatchStack reconcile a stack as soon as it is created, changed or deleted
+func (s *StackController) WatchStack(errC chan<- error) {
+	s.flog.Info("StackController start watch stack")
+	flog := s.flog.WithField("thread", "stack")
+
+	stackCoder := datasource.GetCoder(string(system.StackKind))
+	if stackCoder == nil {
+		errC <- fmt.Errorf("(%s) %s", system.StackKind, "coder not exist")
+		return
+	}
+	stackWatchChan := datasource.NewWatch(stackCoder)
+
+	stacks := make([]system.Stack, 0)
+	if err := s.ListToObject(common.DefaultDatabase, common.STACK, map[string]interface{}{}, &stacks, false); err != nil {
+		errC <- err
+		return
+	}
+	var version = "0"
+	for index := range stacks {
+		if stacks[index].GetResourceVersion() > version {
+			version = stacks[index].GetResourceVersion()
+		}
+		if err := s.Reconcile(&stacks[index]); err != nil {
+			flog.Infof("reconcile stack %s error %s\n", stacks[index].GetName(), err)
+		}
+	}
+
+	s.Watch(common.DefaultDatabase, common.STACK, version, stackWatchChan)
+
+	for {
+		select {
+		case item, ok := <-stackWatchChan.ResultChan():
+			if !ok {
+				errC <- fmt.Errorf("stack watch closed")
+				return
+			}
+
+			st := &system.Stack{}
+			if err := obj.UnstructuredObjectToInstanceObj(&item, st); err != nil {
+				flog.Infof("watch stack unstruct error %s\n", err)
+				continue
+			}
+			// the status written back comes again through the watch, it is only written when something moved
+			if err := s.Reconcile(st); err != nil {
+				flog.Infof("watch stack %s reconcile error %s\n", st.GetName(), err)
+			}
+		}
+	}
+}
+
+// Resync reconcile the stacks each interval, this is what follows the objects created by the stacks becoming ready
+func (s *StackController) Resync(errC chan<- error) {
+	flog := s.flog.WithField("thread", "resync")
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		stacks := make([]system.Stack, 0)
+		if err := s.ListToObject(common.DefaultDatabase, common.STACK, map[string]interface{}{}, &stacks, false); err != nil {
+			flog.Infof("list stack error %s\n", err)
+			continue
+		}
+		for index := range stacks {
+			if err := s.Reconcile(&stacks[index]); err != nil {
+				flog.Infof("resync stack %s error %s\n", stacks[index].GetName(), err)
+			}
+		}
+	}
+}
+
+// Reconcile move the stack one step towards its template. A changed template starts an operation, an operation in
+// progress goes on until every resource is ready or one fails. A failed operation is not retried until the template
+// or the parameters change
+func (s *StackController) Reconcile(st *system.Stack) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st.IsDelete {
+		return s.delete(st)
+	}
+	normalize(st)
+	before := stack.Hash(st.Status)
+	err := s.reconcile(st)
+	if stack.Hash(st.Status) != before {
+		if _, writeErr := s.ApplyStatus(common.DefaultDatabase, common.STACK, st.GetName(), st); writeErr != nil && err == nil {
+			err = writeErr
+		}
+	}
+	return err
+}
+
+func (s *StackController) reconcile(st *system.Stack) error {
+	status := &st.Status
+	hash := stack.Hash(st.Spec)
+	switch {
+	case status.Phase == system.StackPhaseRollingBack:
+		return s.rollback(st)
+	case status.Hash != hash:
+		s.begin(st, hash)
+	case status.Phase == system.StackPhaseFailed:
+		return nil
+	case status.Phase == system.StackPhaseReady:
+		// the outputs follow the objects, e.g. an address allocated after the stack was ready
+		outputs, err := s.outputs(st)
+		if err == nil {
+			status.Outputs = outputs
+		}
+		return nil
+	}
+
+	if err := s.progress(st); err != nil {
+		return s.fail(st, err)
+	}
+	return nil
+}
+
+// begin an operation applying the spec of hash, the specs applied so far are the ones a rollback returns to
+func (s *StackController) begin(st *system.Stack, hash string) {
+	status := &st.Status
+	status.Hash = hash
+	status.Message = ""
+	status.Phase = system.StackPhaseCreating
+	if len(status.Resources) > 0 {
+		status.Phase = system.StackPhaseUpdating
+	}
+	for index := range status.Resources {
+		status.Resources[index].Stable = status.Resources[index].Applied
+	}
+	status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionFalse, ReasonProgressing, ""))
+}
+
+// progress apply the resources whose dependencies are ready and follow the objects applied. Once all are ready the
+// resources no longer in the template are deleted and the stack is ready
+func (s *StackController) progress(st *system.Stack) error {
+	status := &st.Status
+	template := &st.Spec.Template
+	order, err := stack.Order(template)
+	if err != nil {
+		return err
+	}
+	parameters, err := stack.Parameters(template, st.Spec.Parameters)
+	if err != nil {
+		return err
+	}
+	resources := make(map[string]*system.StackResource)
+	for index := range template.Resources {
+		resources[template.Resources[index].Name] = &template.Resources[index]
+	}
+
+	objects := make(map[string]map[string]interface{})
+	done := true
+	for _, name := range order {
+		resource := resources[name]
+		rs := status.Resource(name)
+		if rs == nil {
+			status.Resources = append(status.Resources, system.StackResourceStatus{
+				Name: name, Kind: resource.Kind, Object: st.ObjectName(name), Phase: system.StackResourcePending,
+			})
+			rs = &status.Resources[len(status.Resources)-1]
+		}
+		if rs.Kind != resource.Kind {
+			return fmt.Errorf("resource %s can not change its kind from %s to %s", name, rs.Kind, resource.Kind)
+		}
+
+		ready, err := s.apply(st, resource, rs, parameters, objects)
+		if err != nil {
+			return err
+		}
+		done = done && ready
+	}
+	if !done {
+		return nil
+	}
+
+	// dependents come after their dependencies in the status, they are deleted first
+	for index := len(status.Resources) - 1; index >= 0; index-- {
+		rs := status.Resources[index]
+		if _, exist := resources[rs.Name]; exist {
+			continue
+		}
+		if err := s.deleteObject(st, &rs); err != nil {
+			return err
+		}
+		status.Resources = append(status.Resources[:index], status.Resources[index+1:]...)
+	}
+
+	outputs, err := s.outputs(st)
+	if err != nil {
+		return err
+	}
+	for index := range status.Resources {
+		status.Resources[index].Stable = nil
+	}
+	status.Outputs = outputs
+	status.Phase = system.StackPhaseReady
+	status.Message = ""
+	status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionTrue, ReasonCompleted, ""))
+	return nil
+}
+
+// apply create or update the object of resource once its dependencies are ready and tell whether it is ready. The
+// object of a ready resource is added to objects, for the resources referencing it
+func (s *StackController) apply(st *system.Stack, resource *system.StackResource, rs *system.StackResourceStatus,
+	parameters map[string]string, objects map[string]map[string]interface{}) (bool, error) {
+	dependencies, err := stack.Dependencies(resource)
+	if err != nil {
+		return false, err
+	}
+	for _, dependency := range dependencies {
+		if _, ready := objects[dependency]; !ready {
+			return false, nil
+		}
+	}
+
+	rendered, err := stack.Render(resource.Spec, parameters, objects)
+	if err != nil {
+		return false, fmt.Errorf("resource %s: %v", resource.Name, err)
+	}
+	spec, _ := rendered.(map[string]interface{})
+	hash := stack.Hash(spec)
+	h := handlers[resource.Kind]
+
+	current, err := h.get(s.service, st.GetWorkspace(), rs.Object)
+	switch {
+	case err == datasource.NotFound:
+		namespace, err := stack.Render(resource.Namespace, parameters, nil)
+		if err != nil {
+			return false, fmt.Errorf("resource %s: %v", resource.Name, err)
+		}
+		rs.Namespace = fmt.Sprint(namespace)
+		object, err := build(st, rs, spec)
+		if err != nil {
+			return false, err
+		}
+		if err := h.create(s.service, object); err != nil {
+			return false, fmt.Errorf("create %s %s error %v", resource.Kind, rs.Object, err)
+		}
+		rs.Applied, rs.Hash, rs.Phase, rs.Message = spec, hash, system.StackResourceCreating, ""
+		return false, nil
+	case err != nil:
+		return false, err
+	case rs.Hash != hash:
+		if h.update == nil {
+			return false, fmt.Errorf("resource %s of kind %s can not be updated, rename it to replace it", resource.Name, resource.Kind)
+		}
+		object, err := build(st, rs, spec)
+		if err != nil {
+			return false, err
+		}
+		if err := h.update(s.service, object); err != nil {
+			return false, fmt.Errorf("update %s %s error %v", resource.Kind, rs.Object, err)
+		}
+		rs.Applied, rs.Hash, rs.Phase, rs.Message = spec, hash, system.StackResourceUpdating, ""
+		return false, nil
+	}
+
+	value, err := core.ToMap(current)
+	if err != nil {
+		return false, err
+	}
+	specStatus, _ := stack.Lookup(value, "spec.status")
+	message, _ := stack.Lookup(value, "spec.message")
+	condition := controller.ReadyCondition(fmt.Sprint(specStatus), fmt.Sprint(message))
+	switch condition.Status {
+	case core.ConditionTrue:
+		rs.Phase, rs.Message = system.StackResourceReady, ""
+		objects[resource.Name] = value
+		return true, nil
+	case core.ConditionFalse:
+		rs.Phase, rs.Message = system.StackResourceFailed, condition.Message
+		return false, fmt.Errorf("resource %s failed: %s", resource.Name, condition.Message)
+	}
+	return false, nil
+}
+
+// outputs render the outputs of the template from the objects of the stack
+func (s *StackController) outputs(st *system.Stack) (map[string]string, error) {
+	parameters, err := stack.Parameters(&st.Spec.Template, st.Spec.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	objects := make(map[string]map[string]interface{})
+	for _, rs := range st.Status.Resources {
+		current, err := handlers[rs.Kind].get(s.service, st.GetWorkspace(), rs.Object)
+		if err != nil {
+			return nil, err
+		}
+		if objects[rs.Name], err = core.ToMap(current); err != nil {
+			return nil, err
+		}
+	}
+
+	outputs := make(map[string]string)
+	for _, output := range st.Spec.Template.Outputs {
+		value, err := stack.Render(output.Value, parameters, objects)
+		if err != nil {
+			return nil, fmt.Errorf("output %s: %v", output.Name, err)
+		}
+		outputs[output.Name] = fmt.Sprint(value)
+	}
+	return outputs, nil
+}
+
+// fail the operation in progress and roll it back
+func (s *StackController) fail(st *system.Stack, reason error) error {
+	st.Status.Phase = system.StackPhaseRollingBack
+	st.Status.Message = reason.Error()
+	return s.rollback(st)
+}
+
+// rollback delete the objects the failed operation created and apply again the specs it changed, dependents first.
+// An error leaves the stack rolling back, the next reconcile carries on
+func (s *StackController) rollback(st *system.Stack) error {
+	status := &st.Status
+	for index := len(status.Resources) - 1; index >= 0; index-- {
+		rs := &status.Resources[index]
+		switch {
+		case rs.Stable == nil:
+			if rs.Hash != "" {
+				if err := s.deleteObject(st, rs); err != nil {
+					return err
+				}
+			}
+			status.Resources = append(status.Resources[:index], status.Resources[index+1:]...)
+			continue
+		case stack.Hash(rs.Stable) != rs.Hash && handlers[rs.Kind].update != nil:
+			object, err := build(st, rs, rs.Stable)
+			if err != nil {
+				return err
+			}
+			if err := handlers[rs.Kind].update(s.service, object); err != nil {
+				return fmt.Errorf("roll back %s %s error %v", rs.Kind, rs.Object, err)
+			}
+			rs.Applied, rs.Hash, rs.Phase = rs.Stable, stack.Hash(rs.Stable), system.StackResourceUpdating
+		}
+		rs.Stable = nil
+	}
+
+	status.Phase = system.StackPhaseFailed
+	status.SetCondition(core.NewCondition(core.ConditionReady, core.ConditionFalse, ReasonRolledBack, status.Message))
+	return nil
+}
+
+// delete the objects of a deleted stack, dependents first, then the stack itself. On error the objects left are
+// recorded and the next reconcile carries on
+func (s *StackController) delete(st *system.Stack) error {
+	status := &st.Status
+	status.Phase = system.StackPhaseDeleting
+	for index := len(status.Resources) - 1; index >= 0; index-- {
+		if err := s.deleteObject(st, &status.Resources[index]); err != nil {
+			if _, writeErr := s.ApplyStatus(common.DefaultDatabase, common.STACK, st.GetName(), st); writeErr != nil {
+				s.flog.Infof("write stack %s status error %s\n", st.GetName(), writeErr)
+			}
+			return err
+		}
+		status.Resources = status.Resources[:index]
+	}
+	return s.IStorage.Delete(common.DefaultDatabase, common.STACK, st.GetName(), st.GetWorkspace())
+}
+
+func (s *StackController) deleteObject(st *system.Stack, rs *system.StackResourceStatus) error {
+	err := handlers[rs.Kind].delete(s.service, st.GetWorkspace(), rs.Object)
+	if err != nil && err != datasource.NotFound {
+		return fmt.Errorf("delete %s %s error %v", rs.Kind, rs.Object, err)
+	}
+	return nil
+}
+
+// build the object of the resource of the stack from its spec, labelled with the stack name
+func build(st *system.Stack, rs *system.StackResourceStatus, spec map[string]interface{}) (core.IObject, error) {
+	object := handlers[rs.Kind].object()
+	data := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      rs.Object,
+			"namespace": rs.Namespace,
+			"workspace": st.GetWorkspace(),
+			"tenant":    st.GetTenant(),
+			"labels":    map[string]interface{}{system.StackLabel: st.GetName()},
+		},
+		"spec": spec,
+	}
+	if err := core.UnmarshalToIObject(data, object); err != nil {
+		return nil, fmt.Errorf("resource %s: %v", rs.Name, err)
+	}
+	return object, nil
+}
+
+// normalize the specs decoded from the stage, so that they hash as the ones rendered
+func normalize(st *system.Stack) {
+	for index := range st.Spec.Template.Resources {
+		resource := &st.Spec.Template.Resources[index]
+		resource.Spec, _ = stack.Normalize(resource.Spec).(map[string]interface{})
+	}
+	for index := range st.Status.Resources {
+		rs := &st.Status.Resources[index]
+		if rs.Applied != nil {
+			rs.Applied, _ = stack.Normalize(rs.Applied).(map[string]interface{})
+		}
+		if rs.Stable != nil {
+			rs.Stable, _ = stack.Normalize(rs.Stable).(map[string]interface{})
+		}
+	}
+}
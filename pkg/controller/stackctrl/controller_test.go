@@ -0,0 +1,186 @@
+package stackctrl
+
+import (
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+func getStack(t *testing.T, stage *memory.Memory) *system.Stack {
+	st := &system.Stack{}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.STACK, st, map[string]interface{}{common.FilterName: "s1"}, true); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func reconcile(t *testing.T, ctrl *StackController, stage *memory.Memory) *system.Stack {
+	if err := ctrl.Reconcile(getStack(t, stage)); err != nil {
+		t.Fatal(err)
+	}
+	return getStack(t, stage)
+}
+
+// setStatus report the sync status of an object as its controller would
+func setStatus(t *testing.T, stage *memory.Memory, kind core.Kind, name, status string) {
+	object := handlers[kind].object()
+	if err := stage.GetByFilter(common.DefaultDatabase, string(kind), object, map[string]interface{}{common.FilterName: name}, true); err != nil {
+		t.Fatal(err)
+	}
+	switch typed := object.(type) {
+	case *networking.VirtualPrivateCloud:
+		typed.Spec.Status = status
+	case *system.SecurityGroup:
+		typed.Spec.Status = status
+	case *compute.VirtualMachine:
+		typed.Spec.Status = status
+	case *compute.Storage:
+		typed.Spec.Status = status
+	}
+	if _, _, err := stage.Apply(common.DefaultDatabase, string(kind), name, object, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exist(stage *memory.Memory, kind core.Kind, name string) bool {
+	object := handlers[kind].object()
+	return stage.GetByFilter(common.DefaultDatabase, string(kind), object, map[string]interface{}{common.FilterName: name}, true) == nil
+}
+
+func TestReconcile(t *testing.T) {
+	stage := memory.NewMemory()
+	st := &system.Stack{
+		Metadata: core.Metadata{Name: "s1", Workspace: "ws1"},
+		Spec: system.StackSpec{
+			Template: system.StackTemplate{
+				Parameters: []system.StackParameter{{Name: "cidr", Default: "10.0.0.0/16"}, {Name: "cpu", Default: "2"}},
+				Resources: []system.StackResource{
+					{Name: "vm", Kind: compute.VirtualMachineKind, Spec: map[string]interface{}{
+						"cpu": "${param.cpu}", "memory": "4Gi", "vpc_id": "${resource.net.metadata.name}",
+					}, DependsOn: []string{"sg"}},
+					{Name: "sg", Kind: system.SecurityGroupKind, Spec: map[string]interface{}{"vpc_id": "${resource.net.metadata.name}"}},
+					{Name: "net", Kind: networking.VirtualPrivateCloudKind, Spec: map[string]interface{}{"ip": "${param.cidr}"}},
+				},
+				Outputs: []system.StackOutput{{Name: "vm", Value: "${resource.vm.metadata.name} (${resource.vm.spec.cpu} cpu)"}},
+			},
+		},
+	}
+	st.GenerateVersion()
+	if _, err := stage.Create(common.DefaultDatabase, common.STACK, st); err != nil {
+		t.Fatal(err)
+	}
+	ctrl := NewStackController(stage)
+
+	// the resources are created one after the other, each once the one it references is ready
+	st = reconcile(t, ctrl, stage)
+	if st.Status.Phase != system.StackPhaseCreating || !exist(stage, networking.VirtualPrivateCloudKind, "s1-net") || exist(stage, system.SecurityGroupKind, "s1-sg") {
+		t.Fatalf("expected only the vpc created, got phase %s", st.Status.Phase)
+	}
+	setStatus(t, stage, networking.VirtualPrivateCloudKind, "s1-net", common.RUNNING)
+	reconcile(t, ctrl, stage)
+	setStatus(t, stage, system.SecurityGroupKind, "s1-sg", common.RUNNING)
+	reconcile(t, ctrl, stage)
+	setStatus(t, stage, compute.VirtualMachineKind, "s1-vm", common.RUNNING)
+	st = reconcile(t, ctrl, stage)
+	if st.Status.Phase != system.StackPhaseReady || st.Status.Outputs["vm"] != "s1-vm (2 cpu)" {
+		t.Fatalf("expected the stack ready, got phase %s outputs %v", st.Status.Phase, st.Status.Outputs)
+	}
+
+	// a failed update deletes the objects it created and applies the changed specs again
+	st.Spec.Parameters = map[string]string{"cpu": "4"}
+	st.Spec.Template.Resources = append(st.Spec.Template.Resources, system.StackResource{Name: "web", Kind: system.SecurityGroupKind})
+	if _, _, err := stage.Apply(common.DefaultDatabase, common.STACK, st.GetName(), st, false); err != nil {
+		t.Fatal(err)
+	}
+	st = reconcile(t, ctrl, stage)
+	if st.Status.Phase != system.StackPhaseUpdating || !exist(stage, system.SecurityGroupKind, "s1-web") {
+		t.Fatalf("expected the stack updating, got phase %s", st.Status.Phase)
+	}
+	setStatus(t, stage, compute.VirtualMachineKind, "s1-vm", common.FAIL)
+	st = reconcile(t, ctrl, stage)
+	if st.Status.Phase != system.StackPhaseFailed || st.Status.Resource("web") != nil {
+		t.Fatalf("expected the update rolled back, got phase %s", st.Status.Phase)
+	}
+	vm := &compute.VirtualMachine{}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, vm, map[string]interface{}{common.FilterName: "s1-vm"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if vm.Spec.CPU != "2" {
+		t.Fatalf("expected the vm back to 2 cpu, got %s", vm.Spec.CPU)
+	}
+
+	// a failed stack waits for another template
+	if st = reconcile(t, ctrl, stage); st.Status.Phase != system.StackPhaseFailed {
+		t.Fatalf("expected the stack still failed, got phase %s", st.Status.Phase)
+	}
+
+	st.Delete()
+	if err := ctrl.Reconcile(st); err != nil {
+		t.Fatal(err)
+	}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.STACK, &system.Stack{}, map[string]interface{}{common.FilterName: "s1"}, false); err != datasource.NotFound {
+		t.Fatalf("expected the stack removed, got %v", err)
+	}
+}
+
+func TestReconcileStorage(t *testing.T) {
+	stage := memory.NewMemory()
+	st := &system.Stack{
+		Metadata: core.Metadata{Name: "s1", Workspace: "ws1"},
+		Spec: system.StackSpec{
+			Template: system.StackTemplate{
+				Resources: []system.StackResource{{Name: "disk", Kind: compute.StorageKind, Spec: map[string]interface{}{"size": 10}}},
+			},
+		},
+	}
+	st.GenerateVersion()
+	if _, err := stage.Create(common.DefaultDatabase, common.STACK, st); err != nil {
+		t.Fatal(err)
+	}
+	ctrl := NewStackController(stage)
+
+	getStorage := func() *compute.Storage {
+		storage := &compute.Storage{}
+		if err := stage.GetByFilter(common.DefaultDatabase, common.STORAGE, storage, map[string]interface{}{common.FilterName: "s1-disk"}, true); err != nil {
+			t.Fatal(err)
+		}
+		return storage
+	}
+
+	reconcile(t, ctrl, stage)
+	if storage := getStorage(); storage.Spec.Size != 10 || storage.Spec.Status != common.INIT {
+		t.Fatalf("expected a 10GiB disk to create, got %d %s", storage.Spec.Size, storage.Spec.Status)
+	}
+	setStatus(t, stage, compute.StorageKind, "s1-disk", common.RUNNING)
+	if st = reconcile(t, ctrl, stage); st.Status.Phase != system.StackPhaseReady {
+		t.Fatalf("expected the stack ready, got phase %s", st.Status.Phase)
+	}
+
+	// a larger size resizes the disk
+	st.Spec.Template.Resources[0].Spec = map[string]interface{}{"size": 20}
+	if _, _, err := stage.Apply(common.DefaultDatabase, common.STACK, st.GetName(), st, false); err != nil {
+		t.Fatal(err)
+	}
+	reconcile(t, ctrl, stage)
+	if storage := getStorage(); storage.Spec.Size != 20 || storage.Spec.State != compute.StorageStateResize {
+		t.Fatalf("expected the disk resized to 20GiB, got %d %s", storage.Spec.Size, storage.Spec.State)
+	}
+	setStatus(t, stage, compute.StorageKind, "s1-disk", common.RUNNING)
+	if st = reconcile(t, ctrl, stage); st.Status.Phase != system.StackPhaseReady {
+		t.Fatalf("expected the stack ready, got phase %s", st.Status.Phase)
+	}
+
+	st.Delete()
+	if err := ctrl.Reconcile(st); err != nil {
+		t.Fatal(err)
+	}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.STACK, &system.Stack{}, map[string]interface{}{common.FilterName: "s1"}, false); err != datasource.NotFound {
+		t.Fatalf("expected the stack removed, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package stackctrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/service"
+	computesvc "github.com/ddx2x/oilmont/pkg/service/compute"
+	networkingsvc "github.com/ddx2x/oilmont/pkg/service/networking"
+	systemsvc "github.com/ddx2x/oilmont/pkg/service/system"
+)
+
+// handler the service calls managing the objects of a kind
+type handler struct {
+	object func() core.IObject
+	get    func(i service.IService, workspace, name string) (core.IObject, error)
+	create func(i service.IService, object core.IObject) error
+	// update nil when the objects of the kind can not be updated in place
+	update func(i service.IService, object core.IObject) error
+	delete func(i service.IService, workspace, name string) error
+}
+
+var handlers = map[core.Kind]*handler{
+	networking.VirtualPrivateCloudKind: {
+		object: func() core.IObject { return &networking.VirtualPrivateCloud{} },
+		get: func(i service.IService, workspace, name string) (core.IObject, error) {
+			return networkingsvc.NewVirtualPrivateCloudService(i).GetByName(workspace, name)
+		},
+		create: func(i service.IService, object core.IObject) error {
+			_, err := networkingsvc.NewVirtualPrivateCloudService(i).Create(object.(*networking.VirtualPrivateCloud))
+			return err
+		},
+		delete: func(i service.IService, workspace, name string) error {
+			_, err := networkingsvc.NewVirtualPrivateCloudService(i).Delete(workspace, name)
+			return err
+		},
+	},
+	networking.VSwitchKind: {
+		object: func() core.IObject { return &networking.Vswitch{} },
+		get: func(i service.IService, workspace, name string) (core.IObject, error) {
+			return networkingsvc.NewVSwitchService(i).GetByName(workspace, name)
+		},
+		create: func(i service.IService, object core.IObject) error {
+			_, err := networkingsvc.NewVSwitchService(i).Create(object.(*networking.Vswitch))
+			return err
+		},
+		delete: func(i service.IService, workspace, name string) error {
+			_, err := networkingsvc.NewVSwitchService(i).Delete(workspace, name)
+			return err
+		},
+	},
+	system.SecurityGroupKind: {
+		object: func() core.IObject { return &system.SecurityGroup{} },
+		get: func(i service.IService, workspace, name string) (core.IObject, error) {
+			return systemsvc.NewSecurityGroupService(i).GetByName(workspace, name)
+		},
+		create: func(i service.IService, object core.IObject) error {
+			_, err := systemsvc.NewSecurityGroupService(i).Create(object.(*system.SecurityGroup))
+			return err
+		},
+		update: func(i service.IService, object core.IObject) error {
+			_, _, err := systemsvc.NewSecurityGroupService(i).Update(object.GetWorkspace(), object.GetName(), object.(*system.SecurityGroup))
+			return err
+		},
+		delete: func(i service.IService, workspace, name string) error {
+			_, err := systemsvc.NewSecurityGroupService(i).Delete(workspace, name)
+			return err
+		},
+	},
+	compute.VirtualMachineKind: {
+		object: func() core.IObject { return &compute.VirtualMachine{} },
+		get: func(i service.IService, workspace, name string) (core.IObject, error) {
+			return computesvc.NewVirtualMachineService(i).GetByName(workspace, name)
+		},
+		create: func(i service.IService, object core.IObject) error {
+			_, err := computesvc.NewVirtualMachineService(i).Create(object.(*compute.VirtualMachine))
+			return err
+		},
+		update: func(i service.IService, object core.IObject) error {
+			_, err := computesvc.NewVirtualMachineService(i).Update(object.GetWorkspace(), object.GetName(), object.(*compute.VirtualMachine))
+			return err
+		},
+		delete: func(i service.IService, workspace, name string) error {
+			_, err := computesvc.NewVirtualMachineService(i).Delete(workspace, name)
+			return err
+		},
+	},
+	compute.StorageKind: {
+		object: func() core.IObject { return &compute.Storage{} },
+		get: func(i service.IService, workspace, name string) (core.IObject, error) {
+			return computesvc.NewStorageService(i).GetByName(workspace, name)
+		},
+		create: func(i service.IService, object core.IObject) error {
+			_, err := computesvc.NewStorageService(i).Create(object.(*compute.Storage))
+			return err
+		},
+		update: func(i service.IService, object core.IObject) error {
+			_, err := computesvc.NewStorageService(i).Update(object.GetWorkspace(), object.GetName(), object.(*compute.Storage))
+			return err
+		},
+		delete: func(i service.IService, workspace, name string) error {
+			_, err := computesvc.NewStorageService(i).Delete(workspace, name)
+			return err
+		},
+	},
+	networking.ElasticIPKind: {
+		object: func() core.IObject { return &networking.ElasticIP{} },
+		get: func(i service.IService, workspace, name string) (core.IObject, error) {
+			return networkingsvc.NewElasticIPService(i).GetByName(workspace, name)
+		},
+		create: func(i service.IService, object core.IObject) error {
+			_, err := networkingsvc.NewElasticIPService(i).Create(object.(*networking.ElasticIP))
+			return err
+		},
+		update: func(i service.IService, object core.IObject) error {
+			_, err := networkingsvc.NewElasticIPService(i).Update(object.GetWorkspace(), object.GetName(), object.(*networking.ElasticIP))
+			return err
+		},
+		delete: func(i service.IService, workspace, name string) error {
+			_, err := networkingsvc.NewElasticIPService(i).Delete(workspace, name)
+			return err
+		},
+	},
+	networking.LoadBalancerKind: {
+		object: func() core.IObject { return &networking.LoadBalancer{} },
+		get: func(i service.IService, workspace, name string) (core.IObject, error) {
+			return networkingsvc.NewLoadBalancerService(i).GetByName(workspace, name)
+		},
+		create: func(i service.IService, object core.IObject) error {
+			_, err := networkingsvc.NewLoadBalancerService(i).Create(object.(*networking.LoadBalancer))
+			return err
+		},
+		update: func(i service.IService, object core.IObject) error {
+			_, err := networkingsvc.NewLoadBalancerService(i).Update(object.GetWorkspace(), object.GetName(), object.(*networking.LoadBalancer))
+			return err
+		},
+		delete: func(i service.IService, workspace, name string) error {
+			_, err := networkingsvc.NewLoadBalancerService(i).Delete(workspace, name)
+			return err
+		},
+	},
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	objUtils "github.com/ddx2x/oilmont/pkg/utils/obj"
+)
+
+const (
+	ReasonApplied     = "Applied"
+	ReasonApplyFailed = "ApplyFailed"
+	ReasonDeleting    = "Deleting"
+	ReasonRunning     = "Running"
+	ReasonFailed      = "Failed"
+	ReasonPending     = "Pending"
+	ReasonReachable   = "Reachable"
+	ReasonUnreachable = "Unreachable"
+)
+
+// ReportCondition merge condition into the stored status of obj
+func ReportCondition(stage datasource.IStorage, table string, obj core.IObject, condition core.Condition) error {
+	return reportCondition(stage, table, obj, condition, false)
+}
+
+// ReportObserved merge condition and record the generation of obj as observed,
+// used by north handlers after the spec has been pushed to the cluster
+func ReportObserved(stage datasource.IStorage, table string, obj core.IObject, condition core.Condition) error {
+	return reportCondition(stage, table, obj, condition, true)
+}
+
+func reportCondition(stage datasource.IStorage, table string, obj core.IObject, condition core.Condition, observed bool) error {
+	filter := map[string]interface{}{common.FilterName: obj.GetName()}
+	if obj.GetWorkspace() != "" {
+		filter[common.FilterWorkspace] = obj.GetWorkspace()
+	}
+
+	current := &core.StatusObject{}
+	if err := stage.GetByFilter(common.DefaultDatabase, table, current, filter, false); err != nil {
+		return err
+	}
+
+	if observed && obj.GetGeneration() > current.Status.ObservedGeneration {
+		current.Status.ObservedGeneration = obj.GetGeneration()
+	}
+	current.Status.SetCondition(condition)
+
+	_, err := stage.ApplyStatus(common.DefaultDatabase, table, current.GetName(), current)
+	return err
+}
+
+// Observed tell whether the generation of an event object has already been handled by its controller. Controllers write
+// back through ApplyStatus which bumps no generation, the change is then only theirs and the north handlers skip it so
+// that an action is not carried out twice
+func Observed(obj core.IObject) bool {
+	object, ok := obj.(*core.DefaultObject)
+	if !ok || object.Status == nil {
+		return false
+	}
+	status := core.Status{}
+	if err := objUtils.UnstructuredObjectToInstanceObj(object.Status, &status); err != nil {
+		return false
+	}
+	return status.ObservedGeneration > 0 && status.ObservedGeneration >= object.GetGeneration()
+}
+
+// SyncedCondition condition reported by north handlers
+func SyncedCondition(err error) core.Condition {
+	if err != nil {
+		return core.NewCondition(core.ConditionSynced, core.ConditionFalse, ReasonApplyFailed, err.Error())
+	}
+	return core.NewCondition(core.ConditionSynced, core.ConditionTrue, ReasonApplied, "")
+}
+
+// ReadyCondition translate the legacy spec status reported by the cluster into a Ready condition
+func ReadyCondition(status, message string) core.Condition {
+	switch status {
+	case common.RUNNING, common.SYNC:
+		return core.NewCondition(core.ConditionReady, core.ConditionTrue, ReasonRunning, message)
+	case common.FAIL:
+		return core.NewCondition(core.ConditionReady, core.ConditionFalse, ReasonFailed, message)
+	case common.DELETE:
+		return core.NewCondition(core.ConditionReady, core.ConditionFalse, ReasonDeleting, message)
+	}
+	return core.NewCondition(core.ConditionReady, core.ConditionUnknown, ReasonPending, message)
+}
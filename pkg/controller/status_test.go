@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+func nextEvent(t *testing.T, events <-chan core.Event) core.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("expected an event")
+	}
+	return core.Event{}
+}
+
+func TestObserved(t *testing.T) {
+	stage := memory.NewMemory()
+	vpc := &networking.VirtualPrivateCloud{Metadata: core.Metadata{Name: "vpc1", Workspace: "ws1"}}
+	vpc.Spec.Status = common.UPDATE
+	vpc.GenerateVersion()
+	if _, err := stage.Create(common.DefaultDatabase, common.VPC, vpc); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := stage.WatchEvent(ctx, common.DefaultDatabase, common.VPC, "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, events); Observed(event.Object) {
+		t.Fatal("expected a vpc never synced not observed")
+	}
+
+	// the status written back by the handler comes again as a modification
+	if err := ReportObserved(stage, common.VPC, vpc, SyncedCondition(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, events); event.Type != core.MODIFIED || !Observed(event.Object) {
+		t.Fatalf("expected the status write observed, got %s", event.Type)
+	}
+
+	// the progress and the spec fields a controller owns bump no generation
+	vpc.Spec.Status, vpc.Spec.ID = common.RUNNING, "vpc-1"
+	if _, err := stage.ApplyStatus(common.DefaultDatabase, common.VPC, vpc.GetName(), vpc, append(datasource.ProgressPaths, "spec.id")...); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, events); !Observed(event.Object) {
+		t.Fatal("expected the progress written by the controller observed")
+	}
+	vpc.Spec.Message = "synced from the cluster"
+	if _, _, err := stage.Apply(common.DefaultDatabase, common.VPC, vpc.GetName(), vpc, false); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, events); !Observed(event.Object) {
+		t.Fatal("expected a change of the progress alone observed")
+	}
+	stored := &networking.VirtualPrivateCloud{}
+	if err := stage.Get(common.DefaultDatabase, common.VPC, vpc.GetName(), stored, false); err != nil {
+		t.Fatal(err)
+	}
+	if stored.GetGeneration() != 1 || stored.Spec.ID != "vpc-1" || stored.Spec.Status != common.RUNNING {
+		t.Fatalf("expected the vpc running with its id at generation 1, got %d %s %s", stored.GetGeneration(), stored.Spec.ID, stored.Spec.Status)
+	}
+	for _, path := range []string{"spec", "metadata.name"} {
+		if _, err := stage.ApplyStatus(common.DefaultDatabase, common.VPC, vpc.GetName(), vpc, path); err != datasource.NotControllerPath {
+			t.Fatalf("expected %s not writable through the status subresource, got %v", path, err)
+		}
+	}
+
+	vpc.Spec.IP = "10.0.0.0/16"
+	if _, _, err := stage.Apply(common.DefaultDatabase, common.VPC, vpc.GetName(), vpc, false); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEvent(t, events); Observed(event.Object) {
+		t.Fatal("expected a spec change not observed")
+	}
+}
@@ -0,0 +1,206 @@
+package storagectrl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	_ "github.com/ddx2x/oilmont/pkg/cloudprovider/providers"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeVirtV1 "kubevirt.io/client-go/apis/core/v1"
+)
+
+func isAction(storage *compute.Storage) bool {
+	switch storage.Spec.State {
+	case compute.StorageStateAttach, compute.StorageStateDetach, compute.StorageStateResize:
+		return true
+	}
+	return false
+}
+
+// handleAction carry out the attach, detach or resize recorded in storage.Spec.State
+// and keep the storage of the vms in line with the attachments
+func (V *StorageCtrl) handleAction(storage *compute.Storage) {
+	flog := V.flog.WithField("func", "handleAction")
+
+	var err error
+	if cloudprovider.IsRegistered(storage.GetNamespace()) {
+		err = V.cloudAction(storage)
+	} else {
+		err = V.kubeVirtAction(storage)
+	}
+	if err != nil {
+		flog.Warnf("%s storage %s error %v", storage.Spec.State, storage.GetName(), err)
+		settle(storage)
+		if applyErr := V.changeStorageStatus(storage, common.FAIL, err.Error()); applyErr != nil {
+			flog.Warnf("change storage status error %v", applyErr)
+		}
+		V.reportSynced(storage, err)
+		V.reportReady(storage)
+		return
+	}
+
+	flog.Infof("storage %s %s to vm %s", storage.GetName(), storage.Spec.State, storage.Spec.VirtualMachine)
+	settle(storage)
+	if applyErr := V.changeStorageStatus(storage, common.RUNNING, "success"); applyErr != nil {
+		flog.Warnf("change storage status error %v", applyErr)
+	}
+	V.reportSynced(storage, nil)
+	V.reportReady(storage)
+
+	if syncErr := V.syncVirtualMachineStorage(storage); syncErr != nil {
+		flog.Warnf("sync vm storage of %s error %v", storage.GetName(), syncErr)
+	}
+}
+
+func (V *StorageCtrl) cloudAction(storage *compute.Storage) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(V.stage, storage.GetNamespace(), storage.Spec.Region)
+	if err != nil {
+		return err
+	}
+	disks := provider.Disks()
+
+	switch storage.Spec.State {
+	case compute.StorageStateAttach:
+		vm, err := V.vmOf(storage)
+		if err != nil {
+			return err
+		}
+		if vm.Spec.InstanceId == "" {
+			return fmt.Errorf("vm %s has no instance", vm.GetName())
+		}
+		err = disks.Attach(ctx, storage.Spec.Region, storage.Spec.StorageId, vm.Spec.InstanceId)
+	case compute.StorageStateDetach:
+		for _, attachment := range storage.Spec.Attachments {
+			if err := disks.Detach(ctx, storage.Spec.Region, storage.Spec.StorageId, attachment.InstanceId); err != nil {
+				return err
+			}
+		}
+	case compute.StorageStateResize:
+		err = disks.Resize(ctx, storage.Spec.Region, storage.Spec.StorageId, storage.Spec.Size)
+	}
+	if err != nil {
+		return err
+	}
+
+	disk, err := disks.Get(ctx, storage.Spec.Region, storage.Spec.StorageId)
+	if err != nil {
+		return fmt.Errorf("get disk %s error %v", storage.Spec.StorageId, err)
+	}
+	storage.Spec.Attachments = disk.Spec.Attachments
+	storage.Spec.Size = disk.Spec.Size
+	return nil
+}
+
+// kubeVirtAction hotplug the pvc of the storage into the kubeVirt vm, or expand the pvc
+func (V *StorageCtrl) kubeVirtAction(storage *compute.Storage) error {
+	client, err := V.clientOf(storage)
+	if err != nil {
+		return err
+	}
+	namespace := storage.GetWorkspace()
+
+	switch storage.Spec.State {
+	case compute.StorageStateAttach:
+		vm, err := V.vmOf(storage)
+		if err != nil {
+			return err
+		}
+		err = client.KubevirtCli.VirtualMachine(namespace).AddVolume(vm.GetName(), hotplugVolume(storage.GetName()))
+		if err != nil {
+			return err
+		}
+		storage.Spec.Attachments = []compute.Attachment{{
+			AttachedTime: time.Now().Format(time.RFC3339),
+			InstanceId:   vm.GetName(),
+			State:        compute.StorageStateAttached,
+		}}
+
+	case compute.StorageStateDetach:
+		for _, attachment := range storage.Spec.Attachments {
+			err := client.KubevirtCli.VirtualMachine(namespace).RemoveVolume(attachment.InstanceId, &kubeVirtV1.RemoveVolumeOptions{Name: storage.GetName()})
+			if err != nil {
+				return err
+			}
+		}
+		storage.Spec.Attachments = nil
+
+	case compute.StorageStateResize:
+		claims := client.KubevirtCli.CoreV1().PersistentVolumeClaims(namespace)
+		claim, err := claims.Get(context.Background(), storage.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		size, err := resource.ParseQuantity(fmt.Sprintf("%dGi", storage.Spec.Size))
+		if err != nil {
+			return err
+		}
+		if claim.Spec.Resources.Requests == nil {
+			claim.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+		claim.Spec.Resources.Requests[corev1.ResourceStorage] = size
+		if _, err := claims.Update(context.Background(), claim, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hotplugVolume hotplugged disks must sit on the scsi bus
+func hotplugVolume(name string) *kubeVirtV1.AddVolumeOptions {
+	return &kubeVirtV1.AddVolumeOptions{
+		Name: name,
+		Disk: &kubeVirtV1.Disk{
+			Name:       name,
+			DiskDevice: kubeVirtV1.DiskDevice{Disk: &kubeVirtV1.DiskTarget{Bus: "scsi"}},
+		},
+		VolumeSource: &kubeVirtV1.HotplugVolumeSource{
+			PersistentVolumeClaim: &kubeVirtV1.PersistentVolumeClaimVolumeSource{
+				PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: name},
+				Hotpluggable:                      true,
+			},
+		},
+	}
+}
+
+// settle the state of a storage once the action is over, a storage without attachment belongs to no vm
+func settle(storage *compute.Storage) {
+	if len(storage.Spec.Attachments) > 0 {
+		storage.Spec.State = compute.StorageStateInUse
+		return
+	}
+	storage.Spec.State = compute.StorageStateAvailable
+	storage.Spec.VirtualMachine = ""
+}
+
+// ownedPaths the spec fields the controller writes back with the progress: the vendor disk, its attachments and the
+// state an action settles in. They bump no generation
+var ownedPaths = append([]string{"spec.storage_id", "spec.attachments", "spec.size", "spec.state", "spec.virtual_machine"}, datasource.ProgressPaths...)
+
+func (V *StorageCtrl) changeStorageStatus(storage *compute.Storage, status string, message string) error {
+	storage.Spec.Status = status
+	storage.Spec.Message = message
+	_, err := V.stage.ApplyStatus(common.DefaultDatabase, common.STORAGE, storage.GetName(), storage, ownedPaths...)
+	return err
+}
+
+func (V *StorageCtrl) vmOf(storage *compute.Storage) (*compute.VirtualMachine, error) {
+	filter := map[string]interface{}{common.FilterName: storage.Spec.VirtualMachine}
+	if storage.GetWorkspace() != "" {
+		filter[common.FilterWorkspace] = storage.GetWorkspace()
+	}
+	vm := &compute.VirtualMachine{}
+	if err := V.stage.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, vm, filter, true); err != nil {
+		return nil, fmt.Errorf("get vm %s error %v", storage.Spec.VirtualMachine, err)
+	}
+	return vm, nil
+}
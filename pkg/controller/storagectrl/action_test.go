@@ -0,0 +1,96 @@
+package storagectrl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake/faketest"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+func newFakeCloudCtrl(t *testing.T) (*StorageCtrl, *fake.Cloud) {
+	stage, cloud := faketest.NewStage(t)
+	V := NewStorageCtrl(context.Background()).(*StorageCtrl)
+	V.Set(nil, stage)
+	return V, cloud
+}
+
+func getStorage(t *testing.T, V *StorageCtrl) *compute.Storage {
+	storage := &compute.Storage{}
+	if err := V.stage.Get(common.DefaultDatabase, common.STORAGE, "disk1", storage, false); err != nil {
+		t.Fatal(err)
+	}
+	return storage
+}
+
+func getVM(t *testing.T, V *StorageCtrl) *compute.VirtualMachine {
+	vm := &compute.VirtualMachine{}
+	if err := V.stage.Get(common.DefaultDatabase, common.VIRTUALMACHINE, "vm1", vm, false); err != nil {
+		t.Fatal(err)
+	}
+	return vm
+}
+
+func TestCloudStorageAttachResizeDetach(t *testing.T) {
+	V, cloud := newFakeCloudCtrl(t)
+	ctx := context.Background()
+
+	vm := faketest.SeedVM(t, V.stage, cloud, "vm1")
+	storage := &compute.Storage{Metadata: core.Metadata{Name: "disk1", Namespace: fake.Name, Workspace: "ws"}}
+	storage.Spec = compute.StorageSpec{Region: "region-1", Size: 20, Status: common.RUNNING}
+	if err := cloud.Disks().Create(ctx, storage); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := V.stage.Create(common.DefaultDatabase, common.STORAGE, storage); err != nil {
+		t.Fatal(err)
+	}
+
+	storage.Spec.State, storage.Spec.Status, storage.Spec.VirtualMachine = compute.StorageStateAttach, common.UPDATE, "vm1"
+	V.NorthOnUpdate(storage)
+	if storage = getStorage(t, V); storage.Spec.State != compute.StorageStateInUse || len(storage.Spec.Attachments) != 1 {
+		t.Fatalf("expected storage in use, got state %s attachments %v message %s", storage.Spec.State, storage.Spec.Attachments, storage.Spec.Message)
+	}
+	if vm = getVM(t, V); len(vm.Spec.Storage) != 1 || vm.Spec.Storage[0].VolumeId != storage.Spec.StorageId {
+		t.Fatalf("expected vm to list the attached storage, got %v", vm.Spec.Storage)
+	}
+
+	storage.Spec.State, storage.Spec.Status, storage.Spec.Size = compute.StorageStateResize, common.UPDATE, 40
+	V.NorthOnUpdate(storage)
+	if disk, _ := cloud.Disks().Get(ctx, "region-1", storage.Spec.StorageId); disk.Spec.Size != 40 {
+		t.Fatalf("expected disk resized to 40, got %d", disk.Spec.Size)
+	}
+	if vm = getVM(t, V); vm.Spec.Storage[0].VolumeSize != 40 {
+		t.Fatalf("expected vm storage size 40, got %d", vm.Spec.Storage[0].VolumeSize)
+	}
+
+	storage = getStorage(t, V)
+	storage.Spec.State, storage.Spec.Status = compute.StorageStateDetach, common.UPDATE
+	V.NorthOnUpdate(storage)
+	if storage = getStorage(t, V); storage.Spec.State != compute.StorageStateAvailable || storage.Spec.VirtualMachine != "" {
+		t.Fatalf("expected storage available, got state %s vm %q", storage.Spec.State, storage.Spec.VirtualMachine)
+	}
+	if vm = getVM(t, V); len(vm.Spec.Storage) != 0 {
+		t.Fatalf("expected detached storage removed from vm, got %v", vm.Spec.Storage)
+	}
+}
+
+func TestCloudStorageAttachFail(t *testing.T) {
+	V, cloud := newFakeCloudCtrl(t)
+	storage := &compute.Storage{Metadata: core.Metadata{Name: "disk1", Namespace: fake.Name, Workspace: "ws"}}
+	storage.Spec = compute.StorageSpec{Region: "region-1", Size: 20, Status: common.RUNNING}
+	if err := cloud.Disks().Create(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := V.stage.Create(common.DefaultDatabase, common.STORAGE, storage); err != nil {
+		t.Fatal(err)
+	}
+
+	storage.Spec.State, storage.Spec.Status, storage.Spec.VirtualMachine = compute.StorageStateAttach, common.UPDATE, "missing"
+	V.NorthOnUpdate(storage)
+	if storage = getStorage(t, V); storage.Spec.Status != common.FAIL || storage.Spec.State != compute.StorageStateAvailable || storage.Spec.VirtualMachine != "" {
+		t.Fatalf("expected failed attach to leave the storage available, got status %s state %s vm %q", storage.Spec.Status, storage.Spec.State, storage.Spec.VirtualMachine)
+	}
+}
@@ -0,0 +1,68 @@
+package storagectrl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+// cloudFail record err on the storage, a cloud storage has no custom resource to report it back
+func (V *StorageCtrl) cloudFail(storage *compute.Storage, err error) {
+	flog := V.flog.WithField("func", "cloudFail")
+	flog.Warnf("storage %s error %v", storage.GetName(), err)
+	if applyErr := V.changeStorageStatus(storage, common.FAIL, err.Error()); applyErr != nil {
+		flog.Warnf("change storage status error %v", applyErr)
+	}
+	V.reportSynced(storage, err)
+	V.reportReady(storage)
+}
+
+// createCloudStorage create the vendor disk and store its id, a storage with an id is already created
+func (V *StorageCtrl) createCloudStorage(storage *compute.Storage) {
+	flog := V.flog.WithField("func", "createCloudStorage")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if storage.Spec.StorageId == "" {
+		provider, err := cloudprovider.ForProvider(V.stage, storage.GetNamespace(), storage.Spec.Region)
+		if err != nil {
+			V.cloudFail(storage, err)
+			return
+		}
+		if err := provider.Disks().Create(ctx, storage); err != nil {
+			V.cloudFail(storage, fmt.Errorf("create disk error %v", err))
+			return
+		}
+		flog.Infof("create storage %s disk %s", storage.GetName(), storage.Spec.StorageId)
+	}
+
+	if applyErr := V.changeStorageStatus(storage, common.RUNNING, "success"); applyErr != nil {
+		flog.Warnf("change storage status error %v", applyErr)
+	}
+	V.reportSynced(storage, nil)
+	V.reportReady(storage)
+}
+
+func (V *StorageCtrl) deleteCloudStorage(storage *compute.Storage) {
+	flog := V.flog.WithField("func", "deleteCloudStorage")
+	if storage.Spec.StorageId == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(V.stage, storage.GetNamespace(), storage.Spec.Region)
+	if err != nil {
+		flog.Warnf("get cloud provider error %v", err)
+		return
+	}
+	err = provider.Disks().Delete(ctx, storage.Spec.Region, storage.Spec.StorageId)
+	if err != nil && err != cloudprovider.NotFound {
+		flog.Warnf("delete storage %s disk %s error %v", storage.GetName(), storage.Spec.StorageId, err)
+		return
+	}
+	flog.Infof("delete storage %s disk %s", storage.GetName(), storage.Spec.StorageId)
+}
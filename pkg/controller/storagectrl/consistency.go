@@ -0,0 +1,123 @@
+package storagectrl
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+// vmStoragePath the storage of a vm follows the attachments, it is written back by the controller without bumping
+// the generation of the vm
+const vmStoragePath = "spec.storage"
+
+// syncVirtualMachineStorage keep the storage of the vms in line with the attachments of storage,
+// the vm it is attached to lists it and no other vm does
+func (V *StorageCtrl) syncVirtualMachineStorage(storage *compute.Storage) error {
+	owner, err := V.attachedVM(storage)
+	if err != nil {
+		return err
+	}
+
+	vms, err := V.vmsListing(storage)
+	if err != nil {
+		return err
+	}
+	for index := range vms {
+		vm := &vms[index]
+		if owner != nil && vm.GetName() == owner.GetName() {
+			continue
+		}
+		vm.Spec.Storage = removeVMStorage(vm.Spec.Storage, storage)
+		if _, err := V.stage.ApplyStatus(common.DefaultDatabase, common.VIRTUALMACHINE, vm.GetName(), vm, vmStoragePath); err != nil {
+			return err
+		}
+	}
+
+	if owner == nil {
+		return nil
+	}
+	entry := V.vmStorageOf(storage)
+	owner.Spec.Storage = append(removeVMStorage(owner.Spec.Storage, storage), entry)
+	_, err = V.stage.ApplyStatus(common.DefaultDatabase, common.VIRTUALMACHINE, owner.GetName(), owner, vmStoragePath)
+	return err
+}
+
+// attachedVM the vm storage is attached to, nil when it is not attached
+func (V *StorageCtrl) attachedVM(storage *compute.Storage) (*compute.VirtualMachine, error) {
+	if len(storage.Spec.Attachments) == 0 {
+		return nil, nil
+	}
+	if storage.Spec.VirtualMachine != "" {
+		return V.vmOf(storage)
+	}
+
+	// attached outside of the api, the attachment names the vendor instance or the kubeVirt vm
+	filter := map[string]interface{}{"spec.instance_id": storage.Spec.Attachments[0].InstanceId}
+	if !cloudprovider.IsRegistered(storage.GetNamespace()) {
+		filter = map[string]interface{}{common.FilterName: storage.Spec.Attachments[0].InstanceId}
+	}
+	if storage.GetWorkspace() != "" {
+		filter[common.FilterWorkspace] = storage.GetWorkspace()
+	}
+	vm := &compute.VirtualMachine{}
+	if err := V.stage.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, vm, filter, true); err != nil {
+		return nil, fmt.Errorf("get vm of instance %s error %v", storage.Spec.Attachments[0].InstanceId, err)
+	}
+	return vm, nil
+}
+
+// vmsListing the vms whose storage lists storage by name or volume id
+func (V *StorageCtrl) vmsListing(storage *compute.Storage) ([]compute.VirtualMachine, error) {
+	filters := []map[string]interface{}{{"spec.storage.name": storage.GetName()}}
+	if storage.Spec.StorageId != "" {
+		filters = append(filters, map[string]interface{}{"spec.storage.volume_id": storage.Spec.StorageId})
+	}
+
+	result := make([]compute.VirtualMachine, 0)
+	seen := make(map[string]bool)
+	for _, filter := range filters {
+		if storage.GetWorkspace() != "" {
+			filter[common.FilterWorkspace] = storage.GetWorkspace()
+		}
+		vms := make([]compute.VirtualMachine, 0)
+		if err := V.stage.ListToObject(common.DefaultDatabase, common.VIRTUALMACHINE, filter, &vms, true); err != nil {
+			return nil, err
+		}
+		for _, vm := range vms {
+			if !seen[vm.GetName()] {
+				seen[vm.GetName()] = true
+				result = append(result, vm)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (V *StorageCtrl) vmStorageOf(storage *compute.Storage) compute.VmStorage {
+	entry := compute.VmStorage{
+		Name:       storage.GetName(),
+		Status:     storage.Spec.Status,
+		State:      storage.Spec.State,
+		VolumeId:   storage.Spec.StorageId,
+		VolumeSize: int64(storage.Spec.Size),
+		DiskType:   storage.Spec.DiskType,
+	}
+	if !cloudprovider.IsRegistered(storage.GetNamespace()) {
+		entry.Type = compute.VmStorageTypeHotplug
+		entry.Quantity = fmt.Sprintf("%dGi", storage.Spec.Size)
+	}
+	return entry
+}
+
+func removeVMStorage(storages []compute.VmStorage, storage *compute.Storage) []compute.VmStorage {
+	result := make([]compute.VmStorage, 0, len(storages))
+	for _, item := range storages {
+		if item.Name == storage.GetName() || (item.VolumeId != "" && item.VolumeId == storage.Spec.StorageId) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
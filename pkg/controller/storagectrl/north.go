@@ -2,8 +2,12 @@ package storagectrl
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
 	"github.com/ddx2x/oilmont/pkg/resource/compute"
 	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,17 +17,23 @@ import (
 func (V *StorageCtrl) NorthOnAdd(obj core.IObject) {
 	flog := V.flog.WithField("func", "NorthOnAdd")
 
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
-	if err != nil {
-		flog.Infof("get client error %v", err)
-		return
-	}
-
 	storage := &compute.Storage{}
 	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, storage); err != nil {
 		flog.Warnf("unstructured obj error %v", err)
 		return
 	}
+	if cloudprovider.IsRegistered(storage.GetNamespace()) {
+		if storage.Spec.Status == common.INIT {
+			V.createCloudStorage(storage)
+		}
+		return
+	}
+
+	client, err := V.clientOf(storage)
+	if err != nil {
+		flog.Infof("get client error %v", err)
+		return
+	}
 
 	unstructuredStorage, ok, err := V.checkObjStatusAndGetUnstructuredObj(storage, common.INIT)
 	if !ok {
@@ -35,6 +45,7 @@ func (V *StorageCtrl) NorthOnAdd(obj core.IObject) {
 
 	_, err = client.Interface.Resource(storageGvr).Namespace(unstructuredStorage.GetNamespace()).Create(
 		context.Background(), unstructuredStorage, metav1.CreateOptions{})
+	V.reportSynced(storage, err)
 	if err != nil {
 		flog.Infof("create storage error %v", err)
 		return
@@ -47,18 +58,30 @@ func (V *StorageCtrl) NorthOnAdd(obj core.IObject) {
 func (V *StorageCtrl) NorthOnUpdate(obj core.IObject) {
 	flog := V.flog.WithField("func", "NorthOnUpdate")
 
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
-	if err != nil {
-		flog.Infof("get client error %v", err)
-		return
-	}
-
 	storage := &compute.Storage{}
 	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, storage); err != nil {
 		flog.Warnf("unstructured obj error %v", err)
 		return
 	}
 
+	if storage.Spec.Status == common.UPDATE && isAction(storage) {
+		V.handleAction(storage)
+		return
+	}
+	if cloudprovider.IsRegistered(storage.GetNamespace()) {
+		if storage.Spec.Status == common.UPDATE {
+			// a vendor disk changes through the attach, detach and resize actions only
+			V.cloudFail(storage, fmt.Errorf("update storage %s error %v", storage.GetName(), cloudprovider.NotImplemented))
+		}
+		return
+	}
+
+	client, err := V.clientOf(storage)
+	if err != nil {
+		flog.Infof("get client error %v", err)
+		return
+	}
+
 	unstructuredStorage, ok, err := V.checkObjStatusAndGetUnstructuredObj(storage, common.UPDATE)
 	if !ok {
 		if err != nil {
@@ -67,7 +90,9 @@ func (V *StorageCtrl) NorthOnUpdate(obj core.IObject) {
 		return
 	}
 
-	if _, _, err := client.Apply(context.Background(), storage.GetNamespace(), storageGvr, storage.GetName(), unstructuredStorage, false); err != nil {
+	_, _, err = client.Apply(context.Background(), storage.GetNamespace(), storageGvr, storage.GetName(), unstructuredStorage, false)
+	V.reportSynced(storage, err)
+	if err != nil {
 		flog.Warnf("apply storage error %v", err)
 		return
 	}
@@ -77,6 +102,16 @@ func (V *StorageCtrl) NorthOnUpdate(obj core.IObject) {
 }
 
 func (V *StorageCtrl) NorthOnDelete(obj core.IObject) {
+	storage := &compute.Storage{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, storage); err != nil {
+		V.flog.WithField("func", "NorthOnDelete").Warnf("unstructured obj error %v", err)
+		return
+	}
+	if cloudprovider.IsRegistered(storage.GetNamespace()) {
+		V.deleteCloudStorage(storage)
+		return
+	}
+
 	//flog := V.flog.WithField("func", "NorthOnDelete")
 	//
 	//client, err := V.cs.GetClient(common.DefaultKubernetes)
@@ -121,38 +156,7 @@ func (V *StorageCtrl) checkObjStatusAndGetUnstructuredObj(storage *compute.Stora
 		return nil, false, nil
 	}
 
-	labels := make([]storageLabel, 0)
-	for k, v := range storage.Labels {
-		label := storageLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
-	}
-
-	storageParams := &StorageParams{
-		Name:               storage.Name,
-		Namespace:          storage.Namespace,
-		Labels:             labels,
-		Attachments:        storage.Spec.Attachments,
-		CategoryType:       storage.Spec.CategoryType,
-		DeleteWithInstance: storage.Spec.DeleteWithInstance,
-		Description:        storage.Spec.Description,
-		DiskChargeType:     storage.Spec.DiskChargeType,
-		DiskType:           storage.Spec.DiskType,
-		IOPS:               storage.Spec.IOPS,
-		LocalName:          storage.Spec.LocalName,
-		Message:            storage.Spec.Message,
-		Region:             storage.Spec.Region,
-		Zone:               storage.Spec.Zone,
-		Size:               storage.Spec.Size,
-		State:              storage.Spec.State,
-		Status:             storage.Spec.Status,
-		StorageId:          storage.Spec.StorageId,
-		Throughput:         storage.Spec.Throughput,
-	}
-
-	unstructuredStorage, err := utilsObj.Render(storageParams, storageTpl)
+	unstructuredStorage, err := ddx2xv1.ToUnstructured(ddx2xv1.ToStorage(storage))
 	if err != nil {
 		return nil, false, err
 	}
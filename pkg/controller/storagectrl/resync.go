@@ -0,0 +1,31 @@
+package storagectrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ controller.Resyncer = &StorageCtrl{}
+
+func (V *StorageCtrl) ResyncTable() string { return common.STORAGE }
+
+func (V *StorageCtrl) ResyncGvr() schema.GroupVersionResource { return storageGvr }
+
+// ToSouth the storage custom resource the stage object is pushed as, none for a cloud storage
+func (V *StorageCtrl) ToSouth(obj core.IObject) (*unstructured.Unstructured, error) {
+	object := &compute.Storage{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, object); err != nil {
+		return nil, err
+	}
+	if cloudprovider.IsRegistered(object.GetNamespace()) {
+		return nil, nil
+	}
+	return ddx2xv1.ToUnstructured(ddx2xv1.ToStorage(object))
+}
@@ -3,10 +3,9 @@ package storagectrl
 import (
 	"context"
 	"github.com/ddx2x/oilmont/pkg/common"
-	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/datasource"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
 	"github.com/ddx2x/oilmont/pkg/resource/compute"
-	"github.com/ddx2x/oilmont/pkg/resource/system"
 	objUtils "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -14,17 +13,13 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 )
 
-func (V *StorageCtrl) SouthOnAdd(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnAdd")
-	unstructuredStorage := &unstructured.Unstructured{}
-
-	err := objUtils.Unmarshal(unstructuredStorage, obj)
-	if err != nil {
-		flog.Warnf("unmarshal storage data error: %v", obj)
-	}
-
-	storage, ok := V.checkObjStatusAndGetObj(unstructuredStorage, common.INIT)
+func (V *StorageCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnAdd").WithField("cluster", cluster)
+	storage, ok, err := V.checkObjStatusAndGetObj(obj, common.INIT)
 	if !ok {
+		if err != nil {
+			flog.Warnf("unmarshal storage data error: %v", err)
+		}
 		return
 	}
 
@@ -36,6 +31,7 @@ func (V *StorageCtrl) SouthOnAdd(obj runtime.Object) {
 			return
 		}
 		flog.Infof("create storage %s,namespace: %s, workspace: %s", storage.GetName(), storage.GetNamespace(), storage.GetWorkspace())
+		V.reportReady(storage)
 		return
 	}
 	if err != nil {
@@ -51,20 +47,18 @@ func (V *StorageCtrl) SouthOnAdd(obj runtime.Object) {
 	if update {
 		flog.Infof("update storage %s, workspace: %s, namespace: %s", storage.GetName(), storage.GetWorkspace(), storage.GetNamespace())
 	}
+	V.reportReady(storage)
 	return
 }
 
-func (V *StorageCtrl) SouthOnUpdate(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnUpdate")
+func (V *StorageCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnUpdate").WithField("cluster", cluster)
 	var force = false
-	unstructuredStorage := &unstructured.Unstructured{}
-	err := objUtils.Unmarshal(unstructuredStorage, obj)
-	if err != nil {
-		flog.Warnf("unmarshal storage data error: %v", obj)
-	}
-
-	storage, ok := V.checkObjStatusAndGetObj(unstructuredStorage, common.DELETE, common.UPDATE)
+	storage, ok, err := V.checkObjStatusAndGetObj(obj, common.DELETE, common.UPDATE)
 	if !ok {
+		if err != nil {
+			flog.Warnf("unmarshal storage data error: %v", err)
+		}
 		return
 	}
 
@@ -79,12 +73,13 @@ func (V *StorageCtrl) SouthOnUpdate(obj runtime.Object) {
 		return
 	}
 
+	V.reportReady(storage)
 	flog.Infof("update a storage %s to stage", storage.GetName())
 	return
 }
 
-func (V *StorageCtrl) SouthOnDelete(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnDelete")
+func (V *StorageCtrl) SouthOnDelete(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnDelete").WithField("cluster", cluster)
 	unstructuredStorage := &unstructured.Unstructured{}
 
 	err := objUtils.Unmarshal(unstructuredStorage, obj)
@@ -104,117 +99,31 @@ func (V *StorageCtrl) SouthOnDelete(obj runtime.Object) {
 	return
 }
 
-func (V *StorageCtrl) SouthEventChs(ctx context.Context) ([]<-chan watch.Event, error) {
-	clusters := make([]system.Cluster, 0)
-	if err := V.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+func (V *StorageCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
 		return nil, err
 	}
 
 	channels := make([]<-chan watch.Event, 0)
-	for _, cluster := range clusters {
-		client, err := V.cs.GetClient(cluster.GetName())
-		if err != nil {
-			return nil, err
-		}
-
-		watchInterface, err := client.Interface.Resource(storageGvr).Watch(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		channels = append(channels, watchInterface.ResultChan())
+	watchInterface, err := client.Interface.Resource(storageGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	channels = append(channels, watchInterface.ResultChan())
 
 	return channels, nil
 }
 
-func (V *StorageCtrl) checkObjStatusAndGetObj(unstructuredStorage *unstructured.Unstructured, checkStatus ...string) (*compute.Storage, bool) {
-	status := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "status")
-	for _, s := range checkStatus {
-		if status == s {
-			return nil, false
-		}
-	}
-
-	categoryType := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "categoryType")
-	deleteWithInstance := objUtils.GetNestedBool(unstructuredStorage.Object,
-		"spec", "deleteWithInstance")
-	description := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "description")
-	diskChargeType := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "diskChargeType")
-	diskType := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "diskType")
-	iops := objUtils.GetNestedInt(unstructuredStorage.Object,
-		"spec", "iops")
-	localName := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "localName")
-	message := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "message")
-	region := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "region")
-	zone := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "zone")
-	size := objUtils.GetNestedInt(unstructuredStorage.Object,
-		"spec", "size")
-	throughput := objUtils.GetNestedInt(unstructuredStorage.Object,
-		"spec", "throughput")
-	state := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "state")
-	storageId := objUtils.GetNestedString(unstructuredStorage.Object,
-		"spec", "storageId")
-
-	spec := objUtils.GetNestedMap(unstructuredStorage.Object,
-		"spec")
-
-	labels := map[string]interface{}{}
-	getLabels := unstructuredStorage.GetLabels()
-	for k, v := range getLabels {
-		labels[k] = v
-	}
-
-	storage := &compute.Storage{
-		Metadata: core.Metadata{
-			Name:      unstructuredStorage.GetName(),
-			Namespace: unstructuredStorage.GetNamespace(),
-			Workspace: getLabels["workspace"],
-			Kind:      core.Kind(unstructuredStorage.GetKind()),
-			Labels:    labels,
-		}, Spec: compute.StorageSpec{
-			CategoryType:       categoryType,
-			DeleteWithInstance: deleteWithInstance,
-			Description:        description,
-			DiskChargeType:     diskChargeType,
-			IOPS:               iops,
-			LocalName:          localName,
-			Message:            message,
-			Region:             region,
-			DiskType:           diskType,
-			Zone:               zone,
-			Size:               size,
-			State:              state,
-			Throughput:         throughput,
-			Status:             status,
-			StorageId:          storageId,
-		},
+func (V *StorageCtrl) checkObjStatusAndGetObj(obj runtime.Object, checkStatus ...string) (*compute.Storage, bool, error) {
+	unstructuredStorage := &ddx2xv1.Storage{}
+	if err := ddx2xv1.FromUnstructured(obj, unstructuredStorage); err != nil {
+		return nil, false, err
 	}
-	attachments := spec["attachments"]
-	if attachments != nil {
-		for _, obj := range attachments.([]interface{}) {
-			_attachment := obj.(map[string]interface{})
-			attachment := compute.Attachment{
-				AttachedTime: _attachment["attachedTime"].(string),
-				Device:       _attachment["device"].(string),
-				InstanceId:   _attachment["instanceId"].(string),
-				State:        _attachment["state"].(string),
-			}
-			storage.Spec.Attachments = append(storage.Spec.Attachments, attachment)
+	for _, s := range checkStatus {
+		if unstructuredStorage.Spec.Status == s {
+			return nil, false, nil
 		}
 	}
-
-	if storage.Workspace == "" {
-		storage.Workspace = common.DefaultWorkspace
-	}
-	return storage, true
+	return unstructuredStorage.ToResource(), true, nil
 }
@@ -3,10 +3,12 @@ package storagectrl
 import (
 	"context"
 
+	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/controller"
 	"github.com/ddx2x/oilmont/pkg/controller/clients"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -17,16 +19,36 @@ var (
 var _ controller.Handler = &StorageCtrl{}
 
 type StorageCtrl struct {
-	stage datasource.IStorage
-	cs    *clients.Clients
-	flog  log.Logger
+	stage     datasource.IStorage
+	cs        *clients.Clients
+	placement *controller.PlacementResolver
+	flog      log.Logger
 }
 
 func (V *StorageCtrl) Set(cs *clients.Clients, stage datasource.IStorage) {
 	V.cs, V.stage = cs, stage
+	V.placement = controller.NewPlacementResolver(stage, cs)
 }
 
 func NewStorageCtrl(ctx context.Context) controller.Handler {
 	flog := log.GetLogger(ctx).WithField("controller", "storageCtrl")
 	return &StorageCtrl{flog: flog}
 }
+
+func (V *StorageCtrl) reportSynced(storage *compute.Storage, err error) {
+	if reportErr := controller.ReportObserved(V.stage, common.STORAGE, storage, controller.SyncedCondition(err)); reportErr != nil {
+		V.flog.Warnf("report storage %s synced condition error %v", storage.GetName(), reportErr)
+	}
+}
+
+func (V *StorageCtrl) reportReady(storage *compute.Storage) {
+	condition := controller.ReadyCondition(storage.Spec.Status, storage.Spec.Message)
+	if reportErr := controller.ReportCondition(V.stage, common.STORAGE, storage, condition); reportErr != nil {
+		V.flog.Warnf("report storage %s ready condition error %v", storage.GetName(), reportErr)
+	}
+}
+
+// clientOf client of the cluster owning the storage
+func (V *StorageCtrl) clientOf(storage *compute.Storage) (*clients.KubeClient, error) {
+	return V.placement.Client(controller.Placement{Provider: storage.GetNamespace(), Region: storage.Spec.Region, Az: storage.Spec.Zone, Workspace: storage.GetWorkspace()})
+}
@@ -0,0 +1,40 @@
+package vmctrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/ipam"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+// allocateAddress take the private address of the vm from its vswitch, the requested address when given,
+// a vswitch unknown to the stage is left to the provider
+func (V *VMCtrl) allocateAddress(vm *compute.VirtualMachine) error {
+	flog := V.flog.WithField("func", "allocateAddress")
+	if vm.Spec.VSwitchId == "" {
+		return nil
+	}
+
+	addresses := ipam.New(V.stage)
+	vswitch, err := addresses.VSwitchOf(vm.GetWorkspace(), vm.Spec.VSwitchId)
+	if err != nil {
+		flog.Infof("vm %s %v, address left to the provider", vm.GetName(), err)
+		return nil
+	}
+
+	requested := ""
+	if len(vm.Spec.PrivateIpAddress) > 0 {
+		requested = vm.Spec.PrivateIpAddress[0]
+	}
+	address, err := addresses.Allocate(vswitch, string(compute.VirtualMachineKind), vm.GetName(), requested)
+	if err != nil {
+		return err
+	}
+	vm.Spec.PrivateIpAddress = []string{address}
+	return nil
+}
+
+func (V *VMCtrl) releaseAddress(vm *compute.VirtualMachine) {
+	flog := V.flog.WithField("func", "releaseAddress")
+	if err := ipam.New(V.stage).ReleaseOwner(vm.GetWorkspace(), string(compute.VirtualMachineKind), vm.GetName()); err != nil {
+		flog.Warnf("release address of vm %s error %v", vm.GetName(), err)
+	}
+}
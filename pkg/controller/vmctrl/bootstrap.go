@@ -0,0 +1,69 @@
+package vmctrl
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudinit"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+// bootstrapOf render the cloud-init material of vm from its license, user data and network data
+func (V *VMCtrl) bootstrapOf(vm *compute.VirtualMachine) (*cloudprovider.Bootstrap, error) {
+	hostname := vm.Spec.LocalName
+	if hostname == "" {
+		hostname = vm.GetName()
+	}
+	config := cloudinit.Config{Hostname: hostname, UserData: vm.Spec.UserData}
+
+	if vm.Spec.License != "" {
+		license, err := V.licenseOf(vm)
+		if err != nil {
+			return nil, err
+		}
+		switch license.Spec.SshType {
+		case system.RSA:
+			config.SSHAuthorizedKeys = []string{license.Spec.Key}
+		case system.Password:
+			config.Password = license.Spec.Key
+		default:
+			return nil, fmt.Errorf("license %s ssh type %s not support", license.GetName(), license.Spec.SshType)
+		}
+	}
+
+	userData, err := cloudinit.Render(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := cloudinit.ValidateNetworkData(vm.Spec.NetworkData); err != nil {
+		return nil, err
+	}
+
+	return &cloudprovider.Bootstrap{
+		UserData:          userData,
+		NetworkData:       vm.Spec.NetworkData,
+		SSHAuthorizedKeys: config.SSHAuthorizedKeys,
+		Password:          config.Password,
+	}, nil
+}
+
+// licenseOf the license of vm, it must be issued for the vendor and region of the vm
+func (V *VMCtrl) licenseOf(vm *compute.VirtualMachine) (*system.License, error) {
+	filter := map[string]interface{}{common.FilterName: vm.Spec.License}
+	if vm.GetWorkspace() != "" {
+		filter[common.FilterWorkspace] = vm.GetWorkspace()
+	}
+	license := &system.License{}
+	if err := V.stage.GetByFilter(common.DefaultDatabase, common.LICENSE, license, filter, true); err != nil {
+		return nil, fmt.Errorf("get license %s error %v", vm.Spec.License, err)
+	}
+	if license.Spec.Vendor != "" && vm.Spec.Vendor != "" && license.Spec.Vendor != vm.Spec.Vendor {
+		return nil, fmt.Errorf("license %s is issued for vendor %s", license.GetName(), license.Spec.Vendor)
+	}
+	if license.Spec.Region != "" && vm.Spec.RegionId != "" && license.Spec.Region != vm.Spec.RegionId {
+		return nil, fmt.Errorf("license %s is issued for region %s", license.GetName(), license.Spec.Region)
+	}
+	return license, nil
+}
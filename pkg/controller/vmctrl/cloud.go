@@ -0,0 +1,204 @@
+package vmctrl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	_ "github.com/ddx2x/oilmont/pkg/cloudprovider/providers"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+var (
+	// cloudInstancePollInterval interval the provider is asked whether a pending instance settled
+	cloudInstancePollInterval = 10 * time.Second
+	// cloudInstanceDeadline a pending instance not settled by then is failed
+	cloudInstanceDeadline = 30 * time.Minute
+)
+
+// cloudFail record err on the vm the same way the kubeVirt path does
+func (V *VMCtrl) cloudFail(vm *compute.VirtualMachine, err error) {
+	flog := V.flog.WithField("func", "cloudFail")
+	flog.Warnf("vm %s error %v", vm.GetName(), err)
+	if applyErr := V.changeVMStatus(vm, common.FAIL, err.Error()); applyErr != nil {
+		flog.Warnf("change vm status error %v", applyErr)
+	}
+	V.reportSynced(vm, err)
+	V.reportReady(vm)
+}
+
+func (V *VMCtrl) createCloudVM(vm *compute.VirtualMachine) {
+	flog := V.flog.WithField("func", "createCloudVM")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(V.stage, vm.Spec.Vendor, vm.Spec.RegionId)
+	if err != nil {
+		V.cloudFail(vm, err)
+		return
+	}
+
+	if vm.Spec.InstanceId == "" {
+		bootstrap, err := V.bootstrapOf(vm)
+		if err != nil {
+			V.cloudFail(vm, err)
+			return
+		}
+		if err := V.allocateAddress(vm); err != nil {
+			V.cloudFail(vm, fmt.Errorf("allocate address error %v", err))
+			return
+		}
+		if err := provider.Instances().Create(ctx, vm, bootstrap); err != nil {
+			V.releaseAddress(vm)
+			V.cloudFail(vm, fmt.Errorf("create instance error %v", err))
+			return
+		}
+		flog.Infof("create vm %s instance %s", vm.GetName(), vm.Spec.InstanceId)
+	}
+	V.observeCloudVM(provider, vm)
+}
+
+func (V *VMCtrl) updateCloudVM(vm *compute.VirtualMachine) {
+	flog := V.flog.WithField("func", "updateCloudVM")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(V.stage, vm.Spec.Vendor, vm.Spec.RegionId)
+	if err != nil {
+		V.cloudFail(vm, err)
+		return
+	}
+	instances := provider.Instances()
+
+	switch vm.Spec.State {
+	case compute.Starting:
+		err = instances.Start(ctx, vm.Spec.RegionId, vm.Spec.InstanceId)
+	case compute.Stopping:
+		err = instances.Stop(ctx, vm.Spec.RegionId, vm.Spec.InstanceId)
+	case compute.Restarting:
+		err = instances.Reboot(ctx, vm.Spec.RegionId, vm.Spec.InstanceId)
+	case compute.Pausing, compute.Unpausing, compute.Migrating:
+		err = cloudprovider.NotImplemented
+	}
+	if err != nil {
+		V.cloudFail(vm, fmt.Errorf("%s instance %s error %v", vm.Spec.State, vm.Spec.InstanceId, err))
+		return
+	}
+
+	flog.Infof("%s vm %s instance %s", vm.Spec.State, vm.GetName(), vm.Spec.InstanceId)
+	V.observeCloudVM(provider, vm)
+}
+
+// transitional whether the vendor is still carrying out a change of the instance
+func transitional(state compute.VirtualMachineStateType) bool {
+	switch state {
+	case compute.Starting, compute.Stopping, compute.Restarting, compute.Deleting, compute.Updating,
+		compute.Provisioning, compute.Pausing, compute.Unpausing, compute.Migrating:
+		return true
+	}
+	return false
+}
+
+func observe(vm *compute.VirtualMachine, instance *compute.VirtualMachine) {
+	vm.Spec.State = instance.Spec.State
+	vm.Spec.PrivateIpAddress = instance.Spec.PrivateIpAddress
+	vm.Spec.PublicIpAddress = instance.Spec.PublicIpAddress
+}
+
+// observeCloudVM store the instance as the provider reports it. The vm is running once the instance
+// settled, pending until then while the provider is polled in the background
+func (V *VMCtrl) observeCloudVM(provider cloudprovider.Interface, vm *compute.VirtualMachine) {
+	flog := V.flog.WithField("func", "observeCloudVM")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	instance, err := provider.Instances().Get(ctx, vm.Spec.RegionId, vm.Spec.InstanceId)
+	if err != nil {
+		V.cloudFail(vm, fmt.Errorf("get instance %s error %v", vm.Spec.InstanceId, err))
+		return
+	}
+	observe(vm, instance)
+
+	status, message := common.RUNNING, "success"
+	if transitional(vm.Spec.State) {
+		status, message = common.PENDING, fmt.Sprintf("instance %s", vm.Spec.State)
+	}
+	if applyErr := V.changeVMStatus(vm, status, message); applyErr != nil {
+		flog.Warnf("change vm status error %v", applyErr)
+	}
+	V.reportSynced(vm, nil)
+	V.reportReady(vm)
+	if status == common.PENDING {
+		go V.waitCloudVM(provider, vm)
+	}
+}
+
+// waitCloudVM poll the provider until the instance of the pending vm settled, failed or the deadline passed
+func (V *VMCtrl) waitCloudVM(provider cloudprovider.Interface, vm *compute.VirtualMachine) {
+	flog := V.flog.WithField("func", "waitCloudVM")
+	ctx, cancel := context.WithTimeout(context.Background(), cloudInstanceDeadline)
+	defer cancel()
+
+	ticker := time.NewTicker(cloudInstancePollInterval)
+	defer ticker.Stop()
+	for {
+		var instance *compute.VirtualMachine
+		var err error
+		select {
+		case <-ctx.Done():
+			err = fmt.Errorf("instance %s not settled in %s", vm.Spec.InstanceId, cloudInstanceDeadline)
+		case <-ticker.C:
+			instance, err = provider.Instances().Get(ctx, vm.Spec.RegionId, vm.Spec.InstanceId)
+			if err == nil && transitional(instance.Spec.State) {
+				continue
+			}
+			if err != nil {
+				err = fmt.Errorf("get instance %s error %v", vm.Spec.InstanceId, err)
+			}
+		}
+
+		// the vm may have been deleted or changed again meanwhile
+		stored := &compute.VirtualMachine{}
+		if getErr := V.stage.Get(common.DefaultDatabase, common.VIRTUALMACHINE, vm.GetName(), stored, true); getErr != nil || stored.Spec.Status != common.PENDING {
+			return
+		}
+		if err != nil {
+			V.cloudFail(stored, err)
+			return
+		}
+		observe(stored, instance)
+		flog.Infof("vm %s instance %s settled %s", stored.GetName(), stored.Spec.InstanceId, stored.Spec.State)
+		if applyErr := V.changeVMStatus(stored, common.RUNNING, "success"); applyErr != nil {
+			flog.Warnf("change vm status error %v", applyErr)
+		}
+		V.reportSynced(stored, nil)
+		V.reportReady(stored)
+		return
+	}
+}
+
+func (V *VMCtrl) deleteCloudVM(vm *compute.VirtualMachine) {
+	flog := V.flog.WithField("func", "deleteCloudVM")
+	if vm.Spec.InstanceId == "" {
+		V.releaseAddress(vm)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(V.stage, vm.Spec.Vendor, vm.Spec.RegionId)
+	if err != nil {
+		flog.Warnf("get cloud provider error %v", err)
+		return
+	}
+	err = provider.Instances().Delete(ctx, vm.Spec.RegionId, vm.Spec.InstanceId)
+	if err != nil && err != cloudprovider.NotFound {
+		flog.Warnf("delete vm %s instance %s error %v", vm.GetName(), vm.Spec.InstanceId, err)
+		return
+	}
+	flog.Infof("delete vm %s instance %s", vm.GetName(), vm.Spec.InstanceId)
+	V.releaseStorages(vm, vm.Spec.InstanceId)
+	V.releaseAddress(vm)
+}
@@ -0,0 +1,256 @@
+package vmctrl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake/faketest"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/ipam"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+func newFakeCloudCtrl(t *testing.T) (*VMCtrl, *fake.Cloud) {
+	stage, cloud := faketest.NewStage(t)
+	V := NewVMCtrl(context.Background()).(*VMCtrl)
+	V.Set(nil, stage)
+	return V, cloud
+}
+
+func newFakeCloudVM(t *testing.T, V *VMCtrl) *compute.VirtualMachine {
+	vm := faketest.NewVM("vm1", common.INIT)
+	if _, err := V.stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+		t.Fatal(err)
+	}
+	return vm
+}
+
+func getVM(t *testing.T, V *VMCtrl) *compute.VirtualMachine {
+	vm := &compute.VirtualMachine{}
+	if err := V.stage.Get(common.DefaultDatabase, common.VIRTUALMACHINE, "vm1", vm, false); err != nil {
+		t.Fatal(err)
+	}
+	return vm
+}
+
+func TestCloudVMLifecycle(t *testing.T) {
+	V, cloud := newFakeCloudCtrl(t)
+	V.NorthOnAdd(newFakeCloudVM(t, V))
+
+	vm := getVM(t, V)
+	if vm.Spec.Status != common.RUNNING || vm.Spec.InstanceId == "" {
+		t.Fatalf("expected running vm with instance, got status %s instance %q", vm.Spec.Status, vm.Spec.InstanceId)
+	}
+	instance, err := cloud.Instances().Get(context.Background(), "region-1", vm.Spec.InstanceId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Spec.State != compute.Running {
+		t.Fatalf("expected instance running, got %s", instance.Spec.State)
+	}
+
+	vm.Spec.Status = common.UPDATE
+	vm.Spec.State = compute.Stopping
+	V.NorthOnUpdate(vm)
+	if vm = getVM(t, V); vm.Spec.State != compute.Stopped {
+		t.Fatalf("expected vm stopped, got %s", vm.Spec.State)
+	}
+
+	V.NorthOnDelete(vm)
+	if _, err := cloud.Instances().Get(context.Background(), "region-1", vm.Spec.InstanceId); err == nil {
+		t.Fatal("expected instance deleted")
+	}
+}
+
+// TestCloudVMVendor the provider of a vm is its vendor, its namespace is not looked at
+func TestCloudVMVendor(t *testing.T) {
+	V, cloud := newFakeCloudCtrl(t)
+	vm := faketest.NewVM("vm1", common.INIT)
+	vm.Namespace = common.DefaultKubernetes
+	if _, err := V.stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+		t.Fatal(err)
+	}
+	V.NorthOnAdd(vm)
+
+	vm = getVM(t, V)
+	if vm.Spec.Status != common.RUNNING || vm.Spec.InstanceId == "" {
+		t.Fatalf("expected running vm with instance, got status %s instance %q", vm.Spec.Status, vm.Spec.InstanceId)
+	}
+	V.NorthOnDelete(vm)
+	if _, err := cloud.Instances().Get(context.Background(), faketest.Region, vm.Spec.InstanceId); err == nil {
+		t.Fatal("expected instance deleted")
+	}
+}
+
+// waitVM the vm once it left the pending status
+func waitVM(t *testing.T, V *VMCtrl) *compute.VirtualMachine {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		vm := getVM(t, V)
+		if vm.Spec.Status != common.PENDING || time.Now().After(deadline) {
+			return vm
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCloudVMPending(t *testing.T) {
+	defer func(interval time.Duration) { cloudInstancePollInterval = interval }(cloudInstancePollInterval)
+	cloudInstancePollInterval = 10 * time.Millisecond
+	V, cloud := newFakeCloudCtrl(t)
+	cloud.SetDelay(100 * time.Millisecond)
+
+	// the instance is reported pending until the provider settled it
+	V.NorthOnAdd(newFakeCloudVM(t, V))
+	vm := getVM(t, V)
+	if vm.Spec.Status != common.PENDING || vm.Spec.State != compute.Starting {
+		t.Fatalf("expected vm pending while the instance starts, got status %s state %s", vm.Spec.Status, vm.Spec.State)
+	}
+	if ready := vm.Status.GetCondition(core.ConditionReady); ready == nil || ready.Status != core.ConditionUnknown {
+		t.Fatalf("expected the vm not ready yet, got %v", ready)
+	}
+	if vm = waitVM(t, V); vm.Spec.Status != common.RUNNING || vm.Spec.State != compute.Running {
+		t.Fatalf("expected vm running once the instance settled, got status %s state %s", vm.Spec.Status, vm.Spec.State)
+	}
+	if ready := vm.Status.GetCondition(core.ConditionReady); ready == nil || ready.Status != core.ConditionTrue {
+		t.Fatalf("expected the vm ready, got %v", ready)
+	}
+
+	vm.Spec.Status = common.UPDATE
+	vm.Spec.State = compute.Stopping
+	V.NorthOnUpdate(vm)
+	if vm = getVM(t, V); vm.Spec.Status != common.PENDING || vm.Spec.State != compute.Stopping {
+		t.Fatalf("expected vm pending while the instance stops, got status %s state %s", vm.Spec.Status, vm.Spec.State)
+	}
+	if vm = waitVM(t, V); vm.Spec.Status != common.RUNNING || vm.Spec.State != compute.Stopped {
+		t.Fatalf("expected vm stopped once the instance settled, got status %s state %s", vm.Spec.Status, vm.Spec.State)
+	}
+}
+
+func TestCloudVMCreateFail(t *testing.T) {
+	V, cloud := newFakeCloudCtrl(t)
+	cloud.SetError(fmt.Errorf("quota exceeded"))
+	V.NorthOnAdd(newFakeCloudVM(t, V))
+
+	if vm := getVM(t, V); vm.Spec.Status != common.FAIL || vm.Spec.InstanceId != "" {
+		t.Fatalf("expected failed vm without instance, got status %s instance %q", vm.Spec.Status, vm.Spec.InstanceId)
+	}
+}
+
+func TestCloudVMPauseNotImplemented(t *testing.T) {
+	V, _ := newFakeCloudCtrl(t)
+	V.NorthOnAdd(newFakeCloudVM(t, V))
+
+	vm := getVM(t, V)
+	vm.Spec.Status = common.UPDATE
+	vm.Spec.State = compute.Pausing
+	V.NorthOnUpdate(vm)
+	if vm = getVM(t, V); vm.Spec.Status != common.FAIL {
+		t.Fatalf("expected pause to fail on a cloud vm, got status %s", vm.Spec.Status)
+	}
+}
+
+func TestCloudVMLicenseBootstrap(t *testing.T) {
+	V, cloud := newFakeCloudCtrl(t)
+	license := &system.License{Metadata: core.Metadata{Name: "key", Workspace: "ws"}}
+	license.Spec = system.LicenseSpec{Vendor: fake.Name, SshType: system.RSA, Key: "ssh-rsa AAA"}
+	if _, err := V.stage.Create(common.DefaultDatabase, common.LICENSE, license); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Namespace: fake.Name, Workspace: "ws"}}
+	vm.Spec = compute.VirtualMachineSpec{Vendor: fake.Name, RegionId: "region-1", Status: common.INIT, License: "key", UserData: "#cloud-config\npackages: [nginx]\n"}
+	if _, err := V.stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+		t.Fatal(err)
+	}
+	V.NorthOnAdd(vm)
+
+	vm = getVM(t, V)
+	bootstrap, exist := cloud.Bootstrap(vm.Spec.InstanceId)
+	if !exist {
+		t.Fatalf("expected instance created with bootstrap, got status %s message %s", vm.Spec.Status, vm.Spec.Message)
+	}
+	if len(bootstrap.SSHAuthorizedKeys) != 1 || !strings.Contains(bootstrap.UserData, "ssh-rsa AAA") || !strings.Contains(bootstrap.UserData, "nginx") {
+		t.Fatalf("unexpected bootstrap %+v", bootstrap)
+	}
+}
+
+func TestCloudVMLicenseVendorMismatch(t *testing.T) {
+	V, _ := newFakeCloudCtrl(t)
+	license := &system.License{Metadata: core.Metadata{Name: "key", Workspace: "ws"}}
+	license.Spec = system.LicenseSpec{Vendor: common.AWS, SshType: system.Password, Key: "secret"}
+	if _, err := V.stage.Create(common.DefaultDatabase, common.LICENSE, license); err != nil {
+		t.Fatal(err)
+	}
+	vm := newFakeCloudVM(t, V)
+	vm.Spec.License = "key"
+	V.NorthOnAdd(vm)
+
+	if vm = getVM(t, V); vm.Spec.Status != common.FAIL {
+		t.Fatalf("expected license of another vendor to fail, got status %s", vm.Spec.Status)
+	}
+}
+
+func TestCloudVMDeleteReleaseStorage(t *testing.T) {
+	V, _ := newFakeCloudCtrl(t)
+	V.NorthOnAdd(newFakeCloudVM(t, V))
+	vm := getVM(t, V)
+
+	for _, name := range []string{"data", "scratch"} {
+		storage := &compute.Storage{Metadata: core.Metadata{Name: name, Namespace: fake.Name, Workspace: "ws"}}
+		storage.Spec = compute.StorageSpec{
+			State:              compute.StorageStateInUse,
+			Status:             common.RUNNING,
+			VirtualMachine:     vm.GetName(),
+			DeleteWithInstance: name == "scratch",
+			Attachments:        []compute.Attachment{{InstanceId: vm.Spec.InstanceId}},
+		}
+		if _, err := V.stage.Create(common.DefaultDatabase, common.STORAGE, storage); err != nil {
+			t.Fatal(err)
+		}
+	}
+	V.NorthOnDelete(vm)
+
+	storage := &compute.Storage{}
+	if err := V.stage.Get(common.DefaultDatabase, common.STORAGE, "data", storage, true); err != nil {
+		t.Fatal(err)
+	}
+	if storage.Spec.State != compute.StorageStateAvailable || len(storage.Spec.Attachments) != 0 || storage.Spec.VirtualMachine != "" {
+		t.Fatalf("expected storage released, got state %s attachments %v", storage.Spec.State, storage.Spec.Attachments)
+	}
+	if err := V.stage.Get(common.DefaultDatabase, common.STORAGE, "scratch", storage, true); err == nil {
+		t.Fatal("expected storage deleted with the instance")
+	}
+}
+
+func TestCloudVMAllocateAddress(t *testing.T) {
+	V, _ := newFakeCloudCtrl(t)
+	vswitch := &networking.Vswitch{Metadata: core.Metadata{Name: "vsw1", Workspace: "ws"}}
+	vswitch.Spec = networking.VSwitchSpec{IP: "10.0.1.0", Mask: "24", Id: "vsw-1"}
+	if _, err := V.stage.Create(common.DefaultDatabase, common.VSWITCH, vswitch); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := newFakeCloudVM(t, V)
+	vm.Spec.VSwitchId = "vsw-1"
+	V.NorthOnAdd(vm)
+	if vm = getVM(t, V); len(vm.Spec.PrivateIpAddress) != 1 || vm.Spec.PrivateIpAddress[0] != "10.0.1.2" {
+		t.Fatalf("expected the first free address 10.0.1.2, got %v message %s", vm.Spec.PrivateIpAddress, vm.Spec.Message)
+	}
+
+	V.NorthOnDelete(vm)
+	usage, err := ipam.New(V.stage).Usage(vswitch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Allocated != 0 {
+		t.Fatalf("expected the address released with the vm, got %d allocated", usage.Allocated)
+	}
+}
@@ -0,0 +1,63 @@
+package vmctrl
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+// imagesOf the catalogue images the storages of vm clone their disk from, by storage name.
+// An image must be visible to the vm and its golden volume imported into the cluster of the vm
+func (V *VMCtrl) imagesOf(vm *compute.VirtualMachine) (map[string]*compute.Image, error) {
+	images := make(map[string]*compute.Image)
+	cluster := ""
+	for _, storage := range vm.Spec.Storage {
+		if storage.Image == "" || storage.Type == compute.VmStorageTypeHotplug {
+			continue
+		}
+		image, err := V.imageOf(vm, storage.Image)
+		if err != nil {
+			return nil, err
+		}
+
+		if cluster == "" {
+			if cluster, err = V.placement.Resolve(placementOf(vm)); err != nil {
+				return nil, err
+			}
+		}
+		imported, exist := image.ImportOf(cluster)
+		if !exist || imported.Phase != string(v1beta1.Succeeded) || imported.Checksum != image.Spec.Checksum {
+			return nil, fmt.Errorf("image %s is not imported into cluster %s yet", image.GetName(), cluster)
+		}
+		images[storage.Name] = image
+	}
+	return images, nil
+}
+
+// imageOf the image of the vm workspace named name, otherwise the visible image of another workspace
+func (V *VMCtrl) imageOf(vm *compute.VirtualMachine, name string) (*compute.Image, error) {
+	images := make([]compute.Image, 0)
+	if err := V.stage.ListToObject(common.DefaultDatabase, common.IMAGE, map[string]interface{}{common.FilterName: name}, &images, true); err != nil {
+		return nil, err
+	}
+
+	var visible *compute.Image
+	for index := range images {
+		image := &images[index]
+		if !image.IsCatalogue() {
+			continue
+		}
+		if image.GetWorkspace() == vm.GetWorkspace() {
+			return image, nil
+		}
+		if visible == nil && image.VisibleTo(vm.Tenant, vm.GetWorkspace()) {
+			visible = image
+		}
+	}
+	if visible == nil {
+		return nil, fmt.Errorf("image %s not found", name)
+	}
+	return visible, nil
+}
@@ -0,0 +1,60 @@
+package vmctrl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+func TestImagesOf(t *testing.T) {
+	V := NewVMCtrl(context.Background()).(*VMCtrl)
+	V.Set(nil, memory.NewMemory())
+
+	image := &compute.Image{Metadata: core.Metadata{Name: "fedora", Workspace: "other", Tenant: "t1"}}
+	image.Spec = compute.ImageSpec{SourceType: compute.ImageSourceRegistry, Source: "docker://fedora", Size: "5Gi"}
+	if _, err := V.stage.Create(common.DefaultDatabase, common.IMAGE, image); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Workspace: "ws", Tenant: "t2"}}
+	vm.Spec.Storage = []compute.VmStorage{{Name: "root", Quantity: "10Gi", Image: "fedora"}}
+	if _, err := V.imagesOf(vm); err == nil {
+		t.Fatal("expected the private image of another tenant to be invisible")
+	}
+
+	vm.Tenant = "t1"
+	if _, err := V.imagesOf(vm); err == nil {
+		t.Fatal("expected an image not yet imported to be refused")
+	}
+
+	image.Spec.Imports = []compute.ImageImport{{Cluster: common.DefaultKubernetes, Phase: string(v1beta1.Succeeded)}}
+	if _, _, err := V.stage.Apply(common.DefaultDatabase, common.IMAGE, image.GetName(), image, false); err != nil {
+		t.Fatal(err)
+	}
+	images, err := V.imagesOf(vm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	virtualMachine, err := toKubeVirtVM(vm, images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := virtualMachine.Spec.DataVolumeTemplates[0]
+	if source := template.Spec.Source.PVC; source == nil || source.Namespace != compute.ImageNamespace || source.Name != "image-other-fedora" {
+		t.Fatalf("expected clone of the golden volume, got %+v", template.Spec.Source)
+	}
+	if template.Annotations[compute.ImageNameLabel] != "fedora" {
+		t.Fatalf("expected image annotation, got %v", template.Annotations)
+	}
+
+	vm.Spec.Storage[0].Quantity = "1Gi"
+	if _, err := toKubeVirtVM(vm, images); err == nil {
+		t.Fatal("expected a disk smaller than its image to be refused")
+	}
+}
@@ -0,0 +1,74 @@
+package vmctrl
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	corev1 "k8s.io/api/core/v1"
+	kubeVirtV1 "kubevirt.io/client-go/apis/core/v1"
+)
+
+// instanceTypeOf the custom instance type of a kubeVirt vm, none when the vm is sized by cpu and memory only
+func (V *VMCtrl) instanceTypeOf(vm *compute.VirtualMachine) (*system.InstanceType, error) {
+	if vm.Spec.InstanceType == "" {
+		return nil, nil
+	}
+	instanceTypes := make([]system.InstanceType, 0)
+	filter := map[string]interface{}{"spec.id": vm.Spec.InstanceType}
+	if err := V.stage.ListToObject(common.DefaultDatabase, common.INSTANCETYPE, filter, &instanceTypes, true); err != nil {
+		return nil, err
+	}
+	for index := range instanceTypes {
+		instanceType := &instanceTypes[index]
+		if instanceType.Spec.Custom && instanceType.VisibleTo(vm.Tenant) && instanceType.Offers(vm.Spec.RegionId, vm.Spec.Az) {
+			return instanceType, nil
+		}
+	}
+	return nil, fmt.Errorf("instance type %s is not offered in region %s zone %s", vm.Spec.InstanceType, vm.Spec.RegionId, vm.Spec.Az)
+}
+
+// applyInstanceType schedule the vm as its instance type declares, on the nodes of its affinity,
+// backed by huge pages and pinned to dedicated cpus
+func applyInstanceType(virtualMachine *kubeVirtV1.VirtualMachine, instanceType *system.InstanceType) {
+	if instanceType == nil {
+		return
+	}
+	template := &virtualMachine.Spec.Template.Spec
+	spec := instanceType.Spec
+
+	if len(spec.NodeAffinity) > 0 {
+		keys := make([]string, 0, len(spec.NodeAffinity))
+		for key := range spec.NodeAffinity {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		term := corev1.NodeSelectorTerm{}
+		for _, key := range keys {
+			term.MatchExpressions = append(term.MatchExpressions, corev1.NodeSelectorRequirement{
+				Key:      key,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   []string{spec.NodeAffinity[key]},
+			})
+		}
+		template.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{term}},
+		}}
+	}
+
+	if spec.HugePages != "" {
+		template.Domain.Memory = &kubeVirtV1.Memory{Hugepages: &kubeVirtV1.Hugepages{PageSize: spec.HugePages}}
+	}
+
+	if spec.DedicatedCPU {
+		if template.Domain.CPU == nil {
+			template.Domain.CPU = &kubeVirtV1.CPU{Cores: uint32(spec.Cores)}
+		}
+		template.Domain.CPU.DedicatedCPUPlacement = true
+		// dedicated cpus are only granted to vms of the guaranteed qos class
+		template.Domain.Resources.Limits = template.Domain.Resources.Requests.DeepCopy()
+	}
+}
@@ -0,0 +1,81 @@
+package vmctrl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestInstanceTypeOf(t *testing.T) {
+	V := NewVMCtrl(context.Background()).(*VMCtrl)
+	V.Set(nil, memory.NewMemory())
+
+	instanceType := &system.InstanceType{Metadata: core.Metadata{Name: "gpu", Workspace: "ws", Tenant: "t1"}}
+	instanceType.Spec = system.InstanceTypeSpec{ID: "gpu", Cores: 4, Memory: "8Gi", Region: "r1", Zone: "z1", Custom: true}
+	if _, err := V.stage.Create(common.DefaultDatabase, common.INSTANCETYPE, instanceType); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Workspace: "ws", Tenant: "t1"}}
+	if instanceType, err := V.instanceTypeOf(vm); err != nil || instanceType != nil {
+		t.Fatalf("expected no instance type for a vm sized by cpu and memory, got %v %v", instanceType, err)
+	}
+
+	vm.Spec.InstanceType, vm.Spec.RegionId, vm.Spec.Az = "gpu", "r1", "z2"
+	if _, err := V.instanceTypeOf(vm); err == nil {
+		t.Fatal("expected an instance type of another zone to be refused")
+	}
+	vm.Spec.Az = "z1"
+	if _, err := V.instanceTypeOf(vm); err != nil {
+		t.Fatal(err)
+	}
+	vm.Tenant = "t2"
+	if _, err := V.instanceTypeOf(vm); err == nil {
+		t.Fatal("expected the custom instance type of another tenant to be refused")
+	}
+}
+
+func TestApplyInstanceType(t *testing.T) {
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Workspace: "ws"}}
+	vm.Spec.CPU, vm.Spec.Memory = "4", "8Gi"
+	virtualMachine, err := toKubeVirtVM(vm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain := virtualMachine.Spec.Template.Spec.Domain
+	if domain.CPU == nil || domain.CPU.Cores != 4 || domain.Resources.Requests.Memory().String() != "8Gi" {
+		t.Fatalf("expected 4 cores and 8Gi, got cpu %+v resources %v", domain.CPU, domain.Resources.Requests)
+	}
+
+	instanceType := &system.InstanceType{Spec: system.InstanceTypeSpec{
+		Cores:        4,
+		Memory:       "8Gi",
+		NodeAffinity: map[string]string{"node.ddx2x.nip/gpu": "true"},
+		HugePages:    "1Gi",
+		DedicatedCPU: true,
+	}}
+	applyInstanceType(virtualMachine, instanceType)
+
+	template := virtualMachine.Spec.Template.Spec
+	terms := template.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || terms[0].MatchExpressions[0].Key != "node.ddx2x.nip/gpu" || terms[0].MatchExpressions[0].Operator != corev1.NodeSelectorOpIn {
+		t.Fatalf("unexpected node affinity %+v", terms)
+	}
+	if template.Domain.Memory == nil || template.Domain.Memory.Hugepages.PageSize != "1Gi" {
+		t.Fatalf("expected 1Gi huge pages, got %+v", template.Domain.Memory)
+	}
+	if !template.Domain.CPU.DedicatedCPUPlacement || template.Domain.Resources.Limits.Memory().String() != "8Gi" {
+		t.Fatalf("expected dedicated cpus with guaranteed memory, got cpu %+v limits %v", template.Domain.CPU, template.Domain.Resources.Limits)
+	}
+
+	vm.Spec.CPU = "four"
+	if _, err := toKubeVirtVM(vm, nil); err == nil {
+		t.Fatal("expected invalid cpu error")
+	}
+}
@@ -0,0 +1,423 @@
+package vmctrl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller/clients"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeVirtV1 "kubevirt.io/client-go/apis/core/v1"
+	"kubevirt.io/containerized-data-importer/pkg/apis/core/v1beta1"
+)
+
+// TODO: 自定义
+const defaultDataVolumeRegistry = "docker://laiks/fedora:cloud-base"
+
+// defaultMemory the memory of a vm sized neither by instance type nor by memory
+const defaultMemory = "1Gi"
+
+// vmLabels the labels of the vm, the network policies of its security groups select it by them
+func vmLabels(vm *compute.VirtualMachine) map[string]string {
+	labels := map[string]string{
+		"kubevirt.io/vm":                    vm.GetName(),
+		"cloud.ddx2x.nip/region":            vm.Spec.RegionId,
+		"cloud.ddx2x.nip/availability-zone": vm.Spec.Az,
+		"cloud.ddx2x.nip/vendor":            vm.Spec.Vendor,
+	}
+	for _, securityGroup := range vm.Spec.SecurityGroup {
+		labels[system.SecurityGroupLabel(securityGroup)] = "true"
+	}
+	return labels
+}
+
+// toKubeVirtVM the kubeVirt VirtualMachine of vm, every VmStorage becomes a dataVolumeTemplate owned by it
+// except the hotplugged ones which refer to the pvc of their storage. images holds the resolved catalogue image
+// of the storages cloned from one, by storage name
+func toKubeVirtVM(vm *compute.VirtualMachine, images map[string]*compute.Image) (*kubeVirtV1.VirtualMachine, error) {
+	cloudInitDisk := fmt.Sprintf("cloudinitdisk-%s", vm.Name)
+
+	memory := vm.Spec.Memory
+	if memory == "" {
+		memory = defaultMemory
+	}
+	memoryQuantity, err := resource.ParseQuantity(memory)
+	if err != nil {
+		return nil, fmt.Errorf("vm %s memory %q error %v", vm.GetName(), vm.Spec.Memory, err)
+	}
+	vmiResource := corev1.ResourceList{}
+	vmiResource[corev1.ResourceMemory] = memoryQuantity
+
+	var cpu *kubeVirtV1.CPU
+	if vm.Spec.CPU != "" {
+		cores, err := strconv.ParseUint(vm.Spec.CPU, 10, 32)
+		if err != nil || cores == 0 {
+			return nil, fmt.Errorf("vm %s cpu %q must be a number of cores", vm.GetName(), vm.Spec.CPU)
+		}
+		cpu = &kubeVirtV1.CPU{Cores: uint32(cores)}
+	}
+
+	runStrategy := kubeVirtV1.RunStrategyAlways
+	if vm.Spec.State == compute.Stopped {
+		runStrategy = kubeVirtV1.RunStrategyHalted
+	}
+
+	virtualMachine := &kubeVirtV1.VirtualMachine{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "VirtualMachine",
+			APIVersion: "kubevirt.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      vm.GetName(),
+			Namespace: vm.GetWorkspace(),
+			Labels:    vmLabels(vm),
+		},
+		Spec: kubeVirtV1.VirtualMachineSpec{
+			RunStrategy: &runStrategy,
+			Template: &kubeVirtV1.VirtualMachineInstanceTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: vmLabels(vm)},
+				Spec: kubeVirtV1.VirtualMachineInstanceSpec{
+					Domain: kubeVirtV1.DomainSpec{
+						CPU: cpu,
+						Devices: kubeVirtV1.Devices{
+							Disks: []kubeVirtV1.Disk{
+								{
+									Name:       cloudInitDisk,
+									DiskDevice: kubeVirtV1.DiskDevice{Disk: &kubeVirtV1.DiskTarget{Bus: "virtio"}},
+								},
+							},
+						},
+						Resources: kubeVirtV1.ResourceRequirements{
+							Requests: vmiResource,
+						},
+					},
+					Volumes: []kubeVirtV1.Volume{
+						{
+							Name: cloudInitDisk,
+							VolumeSource: kubeVirtV1.VolumeSource{
+								CloudInitNoCloud: cloudInitSource(vm),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	template := &virtualMachine.Spec.Template.Spec
+	for _, storage := range vm.Spec.Storage {
+		// attached by storagectrl, keep it plugged in when the vm is applied again
+		if storage.Type == compute.VmStorageTypeHotplug {
+			template.Domain.Devices.Disks = append(template.Domain.Devices.Disks, kubeVirtV1.Disk{Name: storage.Name, DiskDevice: kubeVirtV1.DiskDevice{Disk: &kubeVirtV1.DiskTarget{Bus: "scsi"}}})
+			template.Volumes = append(template.Volumes, kubeVirtV1.Volume{
+				Name: storage.Name,
+				VolumeSource: kubeVirtV1.VolumeSource{PersistentVolumeClaim: &kubeVirtV1.PersistentVolumeClaimVolumeSource{
+					PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{ClaimName: storage.Name},
+					Hotpluggable:                      true,
+				}}})
+			continue
+		}
+
+		quantity, err := resource.ParseQuantity(storage.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("storage %s quantity %q error %v", storage.Name, storage.Quantity, err)
+		}
+		source, annotations, err := dataVolumeSource(storage, images[storage.Name], quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		virtualMachine.Spec.DataVolumeTemplates = append(virtualMachine.Spec.DataVolumeTemplates, kubeVirtV1.DataVolumeTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Name: storage.Name, Annotations: annotations},
+			Spec: v1beta1.DataVolumeSpec{
+				Source: source,
+				PVC: &corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: quantity},
+					},
+				},
+			},
+		})
+
+		dataVolumeDisk := fmt.Sprintf("datavolumedisk-%s", storage.Name)
+		template.Domain.Devices.Disks = append(template.Domain.Devices.Disks, kubeVirtV1.Disk{Name: dataVolumeDisk, DiskDevice: kubeVirtV1.DiskDevice{Disk: &kubeVirtV1.DiskTarget{Bus: "virtio"}}})
+		template.Volumes = append(template.Volumes, kubeVirtV1.Volume{
+			Name:         dataVolumeDisk,
+			VolumeSource: kubeVirtV1.VolumeSource{DataVolume: &kubeVirtV1.DataVolumeSource{Name: storage.Name}}})
+	}
+
+	return virtualMachine, nil
+}
+
+// dataVolumeSource clone the golden volume of the image when the storage has one, otherwise import its registry
+func dataVolumeSource(storage compute.VmStorage, image *compute.Image, quantity resource.Quantity) (*v1beta1.DataVolumeSource, map[string]string, error) {
+	if storage.Image == "" {
+		registry := storage.Registry
+		if registry == "" {
+			registry = defaultDataVolumeRegistry
+		}
+		return &v1beta1.DataVolumeSource{Registry: &v1beta1.DataVolumeSourceRegistry{URL: registry}}, nil, nil
+	}
+
+	if image == nil {
+		return nil, nil, fmt.Errorf("storage %s image %s is not resolved", storage.Name, storage.Image)
+	}
+	if size, err := resource.ParseQuantity(image.Spec.Size); err == nil && quantity.Cmp(size) < 0 {
+		return nil, nil, fmt.Errorf("storage %s quantity %s is smaller than image %s size %s", storage.Name, storage.Quantity, image.GetName(), image.Spec.Size)
+	}
+	source := &v1beta1.DataVolumeSource{PVC: &v1beta1.DataVolumeSourcePVC{Namespace: compute.ImageNamespace, Name: image.VolumeName()}}
+	return source, map[string]string{compute.ImageNameLabel: image.GetName()}, nil
+}
+
+func cloudInitSecretName(vm *compute.VirtualMachine) string {
+	return fmt.Sprintf("%s-cloudinit", vm.GetName())
+}
+
+// cloudInitSource the cloud-init of the vmi is read from the secret rendered by applyCloudInitSecret
+func cloudInitSource(vm *compute.VirtualMachine) *kubeVirtV1.CloudInitNoCloudSource {
+	secret := &corev1.LocalObjectReference{Name: cloudInitSecretName(vm)}
+	source := &kubeVirtV1.CloudInitNoCloudSource{UserDataSecretRef: secret}
+	if vm.Spec.NetworkData != "" {
+		source.NetworkDataSecretRef = secret
+	}
+	return source
+}
+
+// cloudInitSecret the secret holding the cloud-init material of vm
+func (V *VMCtrl) cloudInitSecret(vm *compute.VirtualMachine) (*corev1.Secret, error) {
+	bootstrap, err := V.bootstrapOf(vm)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cloudInitSecretName(vm),
+			Namespace: vm.GetWorkspace(),
+			Labels:    vmLabels(vm),
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: map[string]string{"userdata": bootstrap.UserData},
+	}
+	if bootstrap.NetworkData != "" {
+		secret.StringData["networkdata"] = bootstrap.NetworkData
+	}
+	return secret, nil
+}
+
+func (V *VMCtrl) applyCloudInitSecret(client *clients.KubeClient, vm *compute.VirtualMachine) error {
+	secret, err := V.cloudInitSecret(vm)
+	if err != nil {
+		return err
+	}
+
+	secrets := client.KubevirtCli.CoreV1().Secrets(vm.GetWorkspace())
+	_, err = secrets.Create(context.Background(), secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = secrets.Update(context.Background(), secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("apply cloud-init secret of vm %s error %v", vm.GetName(), err)
+	}
+	return nil
+}
+
+// renderVirtualMachine the kubeVirt VirtualMachine of vm, its disks cloned from their images and sized by its instance type
+func (V *VMCtrl) renderVirtualMachine(vm *compute.VirtualMachine) (*kubeVirtV1.VirtualMachine, error) {
+	images, err := V.imagesOf(vm)
+	if err != nil {
+		return nil, err
+	}
+	virtualMachine, err := toKubeVirtVM(vm, images)
+	if err != nil {
+		return nil, err
+	}
+	instanceType, err := V.instanceTypeOf(vm)
+	if err != nil {
+		return nil, err
+	}
+	applyInstanceType(virtualMachine, instanceType)
+	return virtualMachine, nil
+}
+
+func (V *VMCtrl) CreateOrApplyVirtualMachine(client *clients.KubeClient, vm *compute.VirtualMachine) error {
+	virtualMachine, err := V.renderVirtualMachine(vm)
+	if err != nil {
+		return err
+	}
+	if err := V.applyCloudInitSecret(client, vm); err != nil {
+		return err
+	}
+	if _, _, err = client.ApplyVirtualMachine(virtualMachine, false); err != nil {
+		return fmt.Errorf("apply vm %s error %v", vm.GetName(), err)
+	}
+	return nil
+}
+
+func (V *VMCtrl) applyLiZiVmToK8s(vm *compute.VirtualMachine) {
+	flog := V.flog.WithField("func", "applyLiZiVmToK8s")
+
+	client, err := V.clientOf(vm)
+	if err != nil {
+		flog.Warnf("get client error %v", err)
+		return
+	}
+
+	if err = V.CreateOrApplyVirtualMachine(client, vm); err != nil {
+		flog.Warnf("%v", err)
+		if applyErr := V.changeVMStatus(vm, common.FAIL, err.Error()); applyErr != nil {
+			flog.Warnf("change vm status error %v", applyErr)
+		}
+		V.reportSynced(vm, err)
+		return
+	}
+
+	flog.Infof("create vm %s to k8s ", vm.GetName())
+	if applyErr := V.changeVMStatus(vm, common.RUNNING, "success"); applyErr != nil {
+		flog.Warnf("change vm status error %v", applyErr)
+	}
+	V.reportSynced(vm, nil)
+}
+
+// deleteLiZiVM the dataVolumes created from the templates are garbage collected with the vm, the cloud-init secret is not owned by it
+func (V *VMCtrl) deleteLiZiVM(vm *compute.VirtualMachine) {
+	flog := V.flog.WithField("func", "deleteLiZiVM")
+
+	client, err := V.clientOf(vm)
+	if err != nil {
+		return
+	}
+
+	err = client.KubevirtCli.VirtualMachine(vm.GetWorkspace()).Delete(vm.GetName(), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		flog.Warnf("delete vm %s error %v", vm.GetName(), err)
+		return
+	}
+
+	err = client.KubevirtCli.CoreV1().Secrets(vm.GetWorkspace()).Delete(context.Background(), cloudInitSecretName(vm), metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		flog.Warnf("delete cloud-init secret of vm %s error %v", vm.GetName(), err)
+	}
+	V.releaseStorages(vm, vm.GetName())
+}
+
+// applyLiZiVmToStage mirror the kubeVirt VirtualMachine and its vmi back into the stage
+func (V *VMCtrl) applyLiZiVmToStage(cluster, namespace, name string) {
+	flog := V.flog.WithField("func", "applyLiZiVmToStage").WithField("cluster", cluster)
+
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
+		flog.Warnf("get client error %v", err)
+		return
+	}
+
+	virtualMachine, err := client.KubevirtCli.VirtualMachine(namespace).Get(name, &metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return
+	}
+	if err != nil {
+		flog.Warnf("get vm %s error %v", name, err)
+		return
+	}
+
+	vm := &compute.VirtualMachine{
+		Metadata: core.Metadata{
+			Name:      virtualMachine.GetName(),
+			Kind:      compute.VirtualMachineKind,
+			Namespace: virtualMachine.GetNamespace(),
+			Workspace: virtualMachine.GetNamespace(),
+		},
+		Spec: compute.VirtualMachineSpec{
+			Vendor:   virtualMachine.Labels["cloud.ddx2x.nip/vendor"],
+			Az:       virtualMachine.Labels["cloud.ddx2x.nip/availability-zone"],
+			RegionId: virtualMachine.Labels["cloud.ddx2x.nip/region"],
+			State:    compute.VirtualMachineStateType(strings.ToLower(string(virtualMachine.Status.PrintableStatus))),
+			Status:   common.RUNNING,
+		},
+	}
+
+	vmi, err := client.KubevirtCli.VirtualMachineInstance(namespace).Get(name, &metav1.GetOptions{})
+	switch {
+	case err == nil:
+		if vm.Spec.State == "" || vm.Spec.State == compute.Running {
+			vm.Spec.State = vmiState(vmi)
+		}
+		vm.Spec.Message = vmi.Status.Reason
+		for _, networkInterface := range vmi.Status.Interfaces {
+			if networkInterface.IP != "" {
+				vm.Spec.PrivateIpAddress = append(vm.Spec.PrivateIpAddress, networkInterface.IP)
+			}
+		}
+	case !errors.IsNotFound(err):
+		flog.Warnf("get vmi %s error %v", name, err)
+		return
+	}
+
+	for _, template := range virtualMachine.Spec.DataVolumeTemplates {
+		dataVolume, err := client.KubevirtCli.CdiClient().CdiV1beta1().
+			DataVolumes(namespace).
+			Get(context.Background(), template.GetName(), metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			flog.Warnf("get dataVolume error: %v", err)
+			return
+		}
+
+		storage := compute.VmStorage{Name: template.GetName(), Type: "DataVolume"}
+		if template.Spec.PVC != nil {
+			storage.Quantity = template.Spec.PVC.Resources.Requests.Storage().String()
+		}
+		if template.Spec.Source != nil && template.Spec.Source.Registry != nil {
+			storage.Registry = template.Spec.Source.Registry.URL
+		}
+		storage.Image = template.Annotations[compute.ImageNameLabel]
+		if err == nil {
+			storage.Status = string(dataVolume.Status.Phase)
+		}
+		vm.Spec.Storage = append(vm.Spec.Storage, storage)
+	}
+	for _, volume := range virtualMachine.Spec.Template.Spec.Volumes {
+		if claim := volume.PersistentVolumeClaim; claim != nil && claim.Hotpluggable {
+			vm.Spec.Storage = append(vm.Spec.Storage, compute.VmStorage{Name: claim.ClaimName, Type: compute.VmStorageTypeHotplug})
+		}
+	}
+
+	if vm, err = V.saveLiZiVm(vm); err != nil {
+		flog.Warnf("virtualMachine %s save to stage error: %v", name, err)
+		return
+	}
+	V.reportReady(vm)
+}
+
+// saveLiZiVm store the vm observed in the cluster. The cluster only knows the state, addresses and disks
+// of a vm already in the stage, they are written back onto the stored vm without bumping its generation
+func (V *VMCtrl) saveLiZiVm(observed *compute.VirtualMachine) (*compute.VirtualMachine, error) {
+	filter := map[string]interface{}{common.FilterName: observed.GetName(), common.FilterWorkspace: observed.GetWorkspace()}
+	vm := &compute.VirtualMachine{}
+	err := V.stage.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, vm, filter, false)
+	if err == datasource.NotFound {
+		_, err = V.stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, observed)
+		return observed, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	vm.Spec.State = observed.Spec.State
+	vm.Spec.Status = observed.Spec.Status
+	vm.Spec.Message = observed.Spec.Message
+	vm.Spec.PrivateIpAddress = observed.Spec.PrivateIpAddress
+	vm.Spec.Storage = observed.Spec.Storage
+	_, err = V.stage.ApplyStatus(common.DefaultDatabase, common.VIRTUALMACHINE, vm.GetName(), vm,
+		"spec.state", "spec.status", "spec.message", "spec.private_ip_address", "spec.storage")
+	return vm, err
+}
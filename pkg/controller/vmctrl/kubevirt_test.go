@@ -0,0 +1,127 @@
+package vmctrl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	kubeVirtV1 "kubevirt.io/client-go/apis/core/v1"
+)
+
+func TestToKubeVirtVM(t *testing.T) {
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Workspace: "ws"}}
+	vm.Spec.Storage = []compute.VmStorage{{Name: "root", Quantity: "10Gi"}, {Name: "data", Quantity: "20Gi", Registry: "docker://data"}}
+
+	virtualMachine, err := toKubeVirtVM(vm, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if virtualMachine.Namespace != "ws" || *virtualMachine.Spec.RunStrategy != kubeVirtV1.RunStrategyAlways {
+		t.Fatalf("unexpected vm %s/%s run strategy %s", virtualMachine.Namespace, virtualMachine.Name, *virtualMachine.Spec.RunStrategy)
+	}
+	if len(virtualMachine.Spec.DataVolumeTemplates) != 2 {
+		t.Fatalf("expected 2 dataVolumeTemplates, got %d", len(virtualMachine.Spec.DataVolumeTemplates))
+	}
+	if url := virtualMachine.Spec.DataVolumeTemplates[0].Spec.Source.Registry.URL; url != defaultDataVolumeRegistry {
+		t.Fatalf("expected default registry, got %s", url)
+	}
+	if url := virtualMachine.Spec.DataVolumeTemplates[1].Spec.Source.Registry.URL; url != "docker://data" {
+		t.Fatalf("expected storage registry, got %s", url)
+	}
+	if len(virtualMachine.Spec.Template.Spec.Volumes) != 3 {
+		t.Fatalf("expected cloud-init and 2 dataVolume volumes, got %d", len(virtualMachine.Spec.Template.Spec.Volumes))
+	}
+
+	vm.Spec.Storage = append(vm.Spec.Storage, compute.VmStorage{Name: "hot", Type: compute.VmStorageTypeHotplug})
+	if virtualMachine, err = toKubeVirtVM(vm, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(virtualMachine.Spec.DataVolumeTemplates) != 2 {
+		t.Fatalf("expected hotplugged storage without dataVolumeTemplate, got %d", len(virtualMachine.Spec.DataVolumeTemplates))
+	}
+	if volume := virtualMachine.Spec.Template.Spec.Volumes[3]; volume.PersistentVolumeClaim == nil || !volume.PersistentVolumeClaim.Hotpluggable {
+		t.Fatalf("expected hotpluggable pvc volume, got %+v", volume)
+	}
+
+	vm.Spec.SecurityGroup = []string{"web"}
+	if virtualMachine, _ = toKubeVirtVM(vm, nil); virtualMachine.Spec.Template.ObjectMeta.Labels[system.SecurityGroupLabel("web")] != "true" {
+		t.Fatalf("expected the vm labelled with its security group, got %v", virtualMachine.Spec.Template.ObjectMeta.Labels)
+	}
+
+	vm.Spec.State = compute.Stopped
+	if virtualMachine, _ = toKubeVirtVM(vm, nil); *virtualMachine.Spec.RunStrategy != kubeVirtV1.RunStrategyHalted {
+		t.Fatalf("expected halted run strategy for a stopped vm, got %s", *virtualMachine.Spec.RunStrategy)
+	}
+
+	vm.Spec.Storage = []compute.VmStorage{{Name: "bad", Quantity: "ten"}}
+	if _, err := toKubeVirtVM(vm, nil); err == nil {
+		t.Fatal("expected invalid quantity error")
+	}
+}
+
+func TestCloudInitSource(t *testing.T) {
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1"}}
+	if source := cloudInitSource(vm); source.UserDataSecretRef.Name != "vm1-cloudinit" || source.NetworkDataSecretRef != nil {
+		t.Fatalf("unexpected cloud-init source %+v", source)
+	}
+	vm.Spec.NetworkData = "version: 2"
+	if source := cloudInitSource(vm); source.NetworkDataSecretRef == nil {
+		t.Fatal("expected network data secret reference")
+	}
+}
+
+func TestSaveLiZiVm(t *testing.T) {
+	stage := memory.NewMemory()
+	V := NewVMCtrl(context.Background()).(*VMCtrl)
+	V.Set(nil, stage)
+
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Namespace: "local", Workspace: "ws"}}
+	vm.Spec = compute.VirtualMachineSpec{
+		CPU: "2", Memory: "4Gi", InstanceType: "small", License: "key", UserData: "#cloud-config",
+		SecurityGroup: []string{"web"}, Status: common.INIT,
+	}
+	if _, err := stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+		t.Fatal(err)
+	}
+	// a vm of the same name in another workspace is left alone
+	other := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Namespace: "local", Workspace: "other"}}
+	other.Spec.CPU = "8"
+	if _, err := stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, other); err != nil {
+		t.Fatal(err)
+	}
+
+	observed := &compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Namespace: "ws", Workspace: "ws"}}
+	observed.Spec = compute.VirtualMachineSpec{
+		State: compute.Running, Status: common.RUNNING, PrivateIpAddress: []string{"10.0.0.2"},
+		Storage: []compute.VmStorage{{Name: "root", Type: "DataVolume", Status: "Succeeded"}},
+	}
+	if _, err := V.saveLiZiVm(observed); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := &compute.VirtualMachine{}
+	filter := map[string]interface{}{common.FilterName: "vm1", common.FilterWorkspace: "ws"}
+	if err := stage.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, stored, filter, false); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Spec.CPU != "2" || stored.Spec.Memory != "4Gi" || stored.Spec.InstanceType != "small" || stored.Spec.License != "key" ||
+		stored.Spec.UserData != "#cloud-config" || len(stored.Spec.SecurityGroup) != 1 || stored.GetNamespace() != "local" {
+		t.Fatalf("expected the stored spec kept, got %+v", stored.Spec)
+	}
+	if stored.Spec.State != compute.Running || stored.Spec.Status != common.RUNNING || len(stored.Spec.PrivateIpAddress) != 1 ||
+		len(stored.Spec.Storage) != 1 || stored.Spec.Storage[0].Status != "Succeeded" {
+		t.Fatalf("expected the observed fields stored, got %+v", stored.Spec)
+	}
+
+	filter[common.FilterWorkspace] = "other"
+	if err := stage.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, stored, filter, false); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Spec.CPU != "8" || stored.Spec.State != "" {
+		t.Fatalf("expected the vm of the other workspace untouched, got %+v", stored.Spec)
+	}
+}
@@ -2,6 +2,8 @@ package vmctrl
 
 import (
 	"context"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/resource/compute"
@@ -20,13 +22,11 @@ func (V *VMCtrl) NorthOnAdd(obj core.IObject) {
 		return
 	}
 
-	switch vm.GetNamespace() {
-	case common.AWS, common.ALIYUN:
-		V.addThirdPartyToK8s(vm)
-	default:
-		V.applyLiZiVmToK8s(vm)
+	if cloudprovider.IsRegistered(vm.Spec.Vendor) {
+		V.createCloudVM(vm)
+		return
 	}
-
+	V.applyLiZiVmToK8s(vm)
 }
 
 func (V *VMCtrl) NorthOnUpdate(obj core.IObject) {
@@ -41,12 +41,11 @@ func (V *VMCtrl) NorthOnUpdate(obj core.IObject) {
 		return
 	}
 
-	switch vm.Spec.Vendor {
-	case common.AWS, common.ALIYUN:
-		V.updateThirdPartyToK8s(vm)
-	default:
-		V.updateVMIToK8s(vm)
+	if cloudprovider.IsRegistered(vm.Spec.Vendor) {
+		V.updateCloudVM(vm)
+		return
 	}
+	V.updateVMIToK8s(vm)
 }
 
 func (V *VMCtrl) NorthOnDelete(obj core.IObject) {
@@ -55,13 +54,11 @@ func (V *VMCtrl) NorthOnDelete(obj core.IObject) {
 		return
 	}
 
-	switch vm.GetNamespace() {
-	case common.AWS, common.ALIYUN:
-		V.deleteK8sDataThirdPartyVM(vm)
-
-	default:
-		V.deleteLiZiVM(vm)
+	if cloudprovider.IsRegistered(vm.Spec.Vendor) {
+		V.deleteCloudVM(vm)
+		return
 	}
+	V.deleteLiZiVM(vm)
 }
 
 func (V *VMCtrl) NorthEventCh(ctx context.Context) (<-chan core.Event, error) {
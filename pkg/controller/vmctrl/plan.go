@@ -0,0 +1,67 @@
+package vmctrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubeVirtV1 "kubevirt.io/client-go/apis/core/v1"
+)
+
+var secretGvr = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
+
+var _ controller.Planner = &VMCtrl{}
+
+// Plan the kubeVirt VirtualMachine and cloud-init secret the north event of a local vm would apply.
+// Cloud vms and power actions change no object of the clusters
+func (V *VMCtrl) Plan(eventType core.EventType, obj core.IObject) ([]controller.Rendered, error) {
+	vm := &compute.VirtualMachine{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, vm); err != nil {
+		return nil, err
+	}
+	if cloudprovider.IsRegistered(vm.Spec.Vendor) {
+		return nil, nil
+	}
+	if eventType == core.MODIFIED && vm.Spec.State != compute.Updating {
+		return nil, nil
+	}
+	cluster, err := V.placement.Resolve(placementOf(vm))
+	if err != nil {
+		return nil, err
+	}
+
+	virtualMachine := &kubeVirtV1.VirtualMachine{ObjectMeta: metav1.ObjectMeta{Name: vm.GetName(), Namespace: vm.GetWorkspace()}}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: cloudInitSecretName(vm), Namespace: vm.GetWorkspace()}}
+	if eventType != core.DELETED {
+		if virtualMachine, err = V.renderVirtualMachine(vm); err != nil {
+			return nil, err
+		}
+		if secret, err = V.cloudInitSecret(vm); err != nil {
+			return nil, err
+		}
+		// the api server returns the data of a secret, never its string data
+		secret.Data = make(map[string][]byte, len(secret.StringData))
+		for key, value := range secret.StringData {
+			secret.Data[key] = []byte(value)
+		}
+		secret.StringData = nil
+	}
+
+	virtualMachineObject, err := ddx2xv1.ToUnstructured(virtualMachine)
+	if err != nil {
+		return nil, err
+	}
+	secretObject, err := ddx2xv1.ToUnstructured(secret)
+	if err != nil {
+		return nil, err
+	}
+	return []controller.Rendered{
+		{Cluster: cluster, Gvr: secretGvr, Object: secretObject, Delete: eventType == core.DELETED, Redact: true},
+		{Cluster: cluster, Gvr: kubeVirtVirtualMachineGvr, Object: virtualMachineObject, Delete: eventType == core.DELETED},
+	}, nil
+}
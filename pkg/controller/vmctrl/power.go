@@ -0,0 +1,95 @@
+package vmctrl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeVirtV1 "kubevirt.io/client-go/apis/core/v1"
+)
+
+// updateVMIToK8s carry out the action recorded in vm.Spec.State on the vmi and report the state reached
+func (V *VMCtrl) updateVMIToK8s(vm *compute.VirtualMachine) {
+	flog := V.flog.WithField("func", "updateVMIToK8s")
+
+	client, err := V.clientOf(vm)
+	if err != nil {
+		flog.Warnf("get client error %v", err)
+		return
+	}
+	vms := client.KubevirtCli.VirtualMachine(vm.GetWorkspace())
+	vmis := client.KubevirtCli.VirtualMachineInstance(vm.GetWorkspace())
+
+	state := vm.Spec.State
+	switch vm.Spec.State {
+	case compute.Updating:
+		err = V.CreateOrApplyVirtualMachine(client, vm)
+		state = compute.Running
+
+	case compute.Starting:
+		err = vms.Start(vm.GetName(), &kubeVirtV1.StartOptions{})
+		state = compute.Running
+
+	case compute.Stopping:
+		err = vms.Stop(vm.GetName())
+		state = compute.Stopped
+
+	case compute.Restarting:
+		err = vms.Restart(vm.GetName())
+		state = compute.Running
+
+	case compute.Pausing:
+		err = vmis.Pause(vm.GetName())
+		state = compute.Paused
+
+	case compute.Unpausing:
+		err = vmis.Unpause(vm.GetName())
+		state = compute.Running
+
+	case compute.Migrating:
+		migration := &kubeVirtV1.VirtualMachineInstanceMigration{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: fmt.Sprintf("%s-migration-", vm.GetName()),
+				Namespace:    vm.GetWorkspace(),
+			},
+			Spec: kubeVirtV1.VirtualMachineInstanceMigrationSpec{VMIName: vm.GetName()},
+		}
+		_, err = client.KubevirtCli.VirtualMachineInstanceMigration(vm.GetWorkspace()).Create(migration)
+
+	default:
+		return
+	}
+
+	if err != nil {
+		flog.Warnf("%s vmi %s error %v", vm.Spec.State, vm.GetName(), err)
+		if applyErr := V.changeVMStatus(vm, common.FAIL, err.Error()); applyErr != nil {
+			flog.Warnf("change vm status error %v", applyErr)
+		}
+		V.reportSynced(vm, err)
+		V.reportReady(vm)
+		return
+	}
+
+	flog.Infof("vm %s %s to %s", vm.GetName(), vm.Spec.State, state)
+	vm.Spec.State = state
+	if applyErr := V.changeVMStatus(vm, common.RUNNING, "success"); applyErr != nil {
+		flog.Warnf("change vm status error %v", applyErr)
+	}
+	V.reportSynced(vm, nil)
+	V.reportReady(vm)
+}
+
+// vmiState the power state of a vmi, a paused or migrating vmi still reports the Running phase
+func vmiState(vmi *kubeVirtV1.VirtualMachineInstance) compute.VirtualMachineStateType {
+	for _, condition := range vmi.Status.Conditions {
+		if condition.Type == kubeVirtV1.VirtualMachineInstancePaused && condition.Status == "True" {
+			return compute.Paused
+		}
+	}
+	if vmi.Status.MigrationState != nil && !vmi.Status.MigrationState.Completed && !vmi.Status.MigrationState.Failed {
+		return compute.Migrating
+	}
+	return compute.VirtualMachineStateType(strings.ToLower(string(vmi.Status.Phase)))
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/log"
-	"github.com/ddx2x/oilmont/pkg/resource/system"
 	objUtils "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -13,35 +12,35 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 )
 
-func (V *VMCtrl) SouthOnAdd(obj runtime.Object) {
+func (V *VMCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
 	groupKind := obj.GetObjectKind().GroupVersionKind().GroupKind()
 
 	switch groupKind.Group {
 	case "github.com/ddx2x":
 		V.addThirdVmToStage(obj)
 	case "kubevirt.io":
-		V.applyLiZiVmToStage(obj)
+		V.kubeVirtToStage(cluster, obj)
 	}
 }
 
-func (V *VMCtrl) SouthOnUpdate(obj runtime.Object) {
+func (V *VMCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
 	groupKind := obj.GetObjectKind().GroupVersionKind().GroupKind()
 
 	switch groupKind.Group {
 	case "github.com/ddx2x":
 		V.updateThirdVmToStage(obj)
 	case "kubevirt.io":
-		V.applyLiZiVmToStage(obj)
+		V.kubeVirtToStage(cluster, obj)
 	}
 }
 
-func (V *VMCtrl) SouthOnDelete(obj runtime.Object) {
+func (V *VMCtrl) SouthOnDelete(cluster string, obj runtime.Object) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer func() {
 		cancel()
 	}()
 
-	flog := log.G(ctx).WithField("controller", "SouthOnDelete")
+	flog := log.G(ctx).WithField("controller", "SouthOnDelete").WithField("cluster", cluster)
 	virtualMachine := &unstructured.Unstructured{}
 
 	err := objUtils.Unmarshal(virtualMachine, obj)
@@ -49,6 +48,11 @@ func (V *VMCtrl) SouthOnDelete(obj runtime.Object) {
 		flog.Warnf("unmarshal virtualMachine data error: %v", obj)
 	}
 
+	// a vmi goes away whenever the vm stops, the vm itself is mirrored from its own events
+	if virtualMachine.GroupVersionKind().Group == "kubevirt.io" && virtualMachine.GetKind() != "VirtualMachine" {
+		return
+	}
+
 	labels := virtualMachine.GetLabels()
 
 	err = V.stage.Delete(common.DefaultDatabase, common.VIRTUALMACHINE, virtualMachine.GetName(), labels["workspace"])
@@ -58,35 +62,44 @@ func (V *VMCtrl) SouthOnDelete(obj runtime.Object) {
 	flog.Infof("delete a virtualMachine %s from stage", virtualMachine.GetName())
 }
 
-func (V *VMCtrl) SouthEventChs(ctx context.Context) ([]<-chan watch.Event, error) {
+func (V *VMCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
 	flog := V.flog.WithField("func", "SouthEventChs")
 	flog.Infof("vm start watch south event")
 
-	clusters := make([]system.Cluster, 0)
-	if err := V.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
 		return nil, err
 	}
 
 	channels := make([]<-chan watch.Event, 0)
-	for _, cluster := range clusters {
-		client, err := V.cs.GetClient(cluster.GetName())
-		if err != nil {
-			return nil, err
-		}
-
-		watchInterface, err := client.Interface.Resource(virtualMachineGvr).Watch(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		channels = append(channels, watchInterface.ResultChan())
-
-		// 增加对 kubeVirt 的 watch
-		virtWatchInterface, err := client.Interface.Resource(virtualMachineInstanceGvr).Watch(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		channels = append(channels, virtWatchInterface.ResultChan())
+	watchInterface, err := client.Interface.Resource(virtualMachineGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	channels = append(channels, watchInterface.ResultChan())
+
+	// 增加对 kubeVirt 的 watch
+	kubeVirtVMWatchInterface, err := client.Interface.Resource(kubeVirtVirtualMachineGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	channels = append(channels, kubeVirtVMWatchInterface.ResultChan())
+
+	virtWatchInterface, err := client.Interface.Resource(virtualMachineInstanceGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	channels = append(channels, virtWatchInterface.ResultChan())
 
 	return channels, nil
 }
+
+// kubeVirtToStage both the VirtualMachine and its vmi events refresh the vm on the stage
+func (V *VMCtrl) kubeVirtToStage(cluster string, obj runtime.Object) {
+	object := &unstructured.Unstructured{}
+	if err := objUtils.Unmarshal(object, obj); err != nil {
+		V.flog.Warnf("unmarshal kubeVirt object error: %v", err)
+		return
+	}
+	V.applyLiZiVmToStage(cluster, object.GetNamespace(), object.GetName())
+}
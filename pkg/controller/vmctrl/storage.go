@@ -0,0 +1,41 @@
+package vmctrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+)
+
+// releaseStorages the storages attached to a deleted vm are detached, or deleted with it when asked to
+func (V *VMCtrl) releaseStorages(vm *compute.VirtualMachine, instanceId string) {
+	flog := V.flog.WithField("func", "releaseStorages")
+
+	filter := map[string]interface{}{"spec.attachments.instance_id": instanceId}
+	if vm.GetWorkspace() != "" {
+		filter[common.FilterWorkspace] = vm.GetWorkspace()
+	}
+	storages := make([]compute.Storage, 0)
+	if err := V.stage.ListToObject(common.DefaultDatabase, common.STORAGE, filter, &storages, true); err != nil {
+		flog.Warnf("list storages of vm %s error %v", vm.GetName(), err)
+		return
+	}
+
+	for index := range storages {
+		storage := &storages[index]
+		if storage.Spec.DeleteWithInstance {
+			if err := V.stage.Delete(common.DefaultDatabase, common.STORAGE, storage.GetName(), storage.GetWorkspace()); err != nil {
+				flog.Warnf("delete storage %s error %v", storage.GetName(), err)
+			}
+			continue
+		}
+		storage.Spec.Attachments = nil
+		storage.Spec.VirtualMachine = ""
+		storage.Spec.State = compute.StorageStateAvailable
+		_, err := V.stage.ApplyStatus(common.DefaultDatabase, common.STORAGE, storage.GetName(), storage,
+			"spec.attachments", "spec.virtual_machine", "spec.state")
+		if err != nil {
+			flog.Warnf("release storage %s error %v", storage.GetName(), err)
+			continue
+		}
+		flog.Infof("release storage %s of vm %s", storage.GetName(), vm.GetName())
+	}
+}
@@ -2,11 +2,15 @@ package vmctrl
 
 import (
 	"context"
+	"strings"
 
+	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/controller"
 	"github.com/ddx2x/oilmont/pkg/controller/clients"
+	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -16,20 +20,58 @@ var (
 	podGvr                    = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
 	dataVolumeGvr             = schema.GroupVersionResource{Group: "cdi.kubevirt.io", Version: "v1beta1", Resource: "datavolume"}
 	virtualMachineGvr         = schema.GroupVersionResource{Group: "github.com/ddx2x", Version: "v1", Resource: "virtualmachines"}
+	kubeVirtVirtualMachineGvr = schema.GroupVersionResource{Group: "kubevirt.io", Version: "v1", Resource: "virtualmachines"}
 	virtualMachineInstanceGvr = schema.GroupVersionResource{Group: "kubevirt.io", Version: "v1", Resource: "virtualmachineinstances"}
 )
 
 type VMCtrl struct {
-	stage datasource.IStorage
-	cs    *clients.Clients
-	flog  log.Logger
+	stage     datasource.IStorage
+	cs        *clients.Clients
+	placement *controller.PlacementResolver
+	flog      log.Logger
 }
 
 func (V *VMCtrl) Set(cs *clients.Clients, stage datasource.IStorage) {
 	V.cs, V.stage = cs, stage
+	V.placement = controller.NewPlacementResolver(stage, cs)
 }
 
 func NewVMCtrl(ctx context.Context) controller.Handler {
 	flog := log.GetLogger(ctx).WithField("controller", "vmctrl")
 	return &VMCtrl{flog: flog}
 }
+
+func (V *VMCtrl) reportSynced(vm *compute.VirtualMachine, err error) {
+	if reportErr := controller.ReportObserved(V.stage, common.VIRTUALMACHINE, vm, controller.SyncedCondition(err)); reportErr != nil {
+		V.flog.Warnf("report vm %s synced condition error %v", vm.GetName(), reportErr)
+	}
+}
+
+func (V *VMCtrl) reportReady(vm *compute.VirtualMachine) {
+	if reportErr := controller.ReportCondition(V.stage, common.VIRTUALMACHINE, vm, vmReadyCondition(vm)); reportErr != nil {
+		V.flog.Warnf("report vm %s ready condition error %v", vm.GetName(), reportErr)
+	}
+}
+
+// vmReadyCondition prefer the power state reported by kubeVirt or the vendor over the sync status
+func vmReadyCondition(vm *compute.VirtualMachine) core.Condition {
+	state := strings.ToLower(string(vm.Spec.State))
+	switch state {
+	case "":
+		return controller.ReadyCondition(vm.Spec.Status, vm.Spec.Message)
+	case string(compute.Running):
+		return core.NewCondition(core.ConditionReady, core.ConditionTrue, controller.ReasonRunning, vm.Spec.Message)
+	case string(compute.Stopped), "failed", "succeeded":
+		return core.NewCondition(core.ConditionReady, core.ConditionFalse, string(vm.Spec.State), vm.Spec.Message)
+	}
+	return core.NewCondition(core.ConditionReady, core.ConditionUnknown, string(vm.Spec.State), vm.Spec.Message)
+}
+
+func placementOf(vm *compute.VirtualMachine) controller.Placement {
+	return controller.Placement{Provider: vm.Spec.Vendor, Region: vm.Spec.RegionId, Az: vm.Spec.Az, Workspace: vm.GetWorkspace()}
+}
+
+// clientOf client of the cluster owning the vm
+func (V *VMCtrl) clientOf(vm *compute.VirtualMachine) (*clients.KubeClient, error) {
+	return V.placement.Client(placementOf(vm))
+}
@@ -0,0 +1,82 @@
+package vpcctrl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	_ "github.com/ddx2x/oilmont/pkg/cloudprovider/providers"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+func isCloud(vpc *networking.VirtualPrivateCloud) bool {
+	return cloudprovider.IsRegistered(vpc.GetNamespace())
+}
+
+// changeVPCStatus write the progress and the vendor id back, both belong to the controller and bump no generation
+func (V *VPCCtrl) changeVPCStatus(vpc *networking.VirtualPrivateCloud, status string, message string) error {
+	vpc.Spec.Status = status
+	vpc.Spec.Message = message
+	_, err := V.stage.ApplyStatus(common.DefaultDatabase, common.VPC, vpc.GetName(), vpc, append(datasource.ProgressPaths, "spec.id")...)
+	return err
+}
+
+// cloudFail record err on the vpc, a cloud vpc has no custom resource to report it back
+func (V *VPCCtrl) cloudFail(vpc *networking.VirtualPrivateCloud, err error) {
+	flog := V.flog.WithField("func", "cloudFail")
+	flog.Warnf("vpc %s error %v", vpc.GetName(), err)
+	if applyErr := V.changeVPCStatus(vpc, common.FAIL, err.Error()); applyErr != nil {
+		flog.Warnf("change vpc status error %v", applyErr)
+	}
+	V.reportSynced(vpc, err)
+	V.reportReady(vpc)
+}
+
+// createCloudVPC create the vendor vpc and store its id, a vpc with an id is already created
+func (V *VPCCtrl) createCloudVPC(vpc *networking.VirtualPrivateCloud) {
+	flog := V.flog.WithField("func", "createCloudVPC")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if vpc.Spec.ID == "" {
+		provider, err := cloudprovider.ForProvider(V.stage, vpc.GetNamespace(), vpc.Spec.Region)
+		if err != nil {
+			V.cloudFail(vpc, err)
+			return
+		}
+		if err := provider.VPCs().Create(ctx, vpc); err != nil {
+			V.cloudFail(vpc, fmt.Errorf("create vpc error %v", err))
+			return
+		}
+		flog.Infof("create vpc %s id %s", vpc.GetName(), vpc.Spec.ID)
+	}
+
+	if applyErr := V.changeVPCStatus(vpc, common.RUNNING, "success"); applyErr != nil {
+		flog.Warnf("change vpc status error %v", applyErr)
+	}
+	V.reportSynced(vpc, nil)
+	V.reportReady(vpc)
+}
+
+func (V *VPCCtrl) deleteCloudVPC(vpc *networking.VirtualPrivateCloud) {
+	flog := V.flog.WithField("func", "deleteCloudVPC")
+	if vpc.Spec.ID == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(V.stage, vpc.GetNamespace(), vpc.Spec.Region)
+	if err != nil {
+		flog.Warnf("get cloud provider error %v", err)
+		return
+	}
+	err = provider.VPCs().Delete(ctx, vpc.Spec.Region, vpc.Spec.ID)
+	if err != nil && err != cloudprovider.NotFound {
+		flog.Warnf("delete vpc %s id %s error %v", vpc.GetName(), vpc.Spec.ID, err)
+		return
+	}
+	flog.Infof("delete vpc %s id %s", vpc.GetName(), vpc.Spec.ID)
+}
@@ -2,8 +2,12 @@ package vpcctrl
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
 	"github.com/ddx2x/oilmont/pkg/resource/networking"
 	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,40 +25,25 @@ func (V *VPCCtrl) NorthOnAdd(obj core.IObject) {
 	if vpc.Spec.Status != common.INIT {
 		return
 	}
+	if isCloud(&vpc) {
+		V.createCloudVPC(&vpc)
+		return
+	}
 
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
+	client, err := V.clientOf(&vpc)
 	if err != nil {
 		flog.Infof("get client error %v", err)
 		return
 	}
 
-	labels := make([]vpcLabel, 0)
-	for k, v := range vpc.Labels {
-		label := vpcLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
-	}
-
-	virtualPrivateCloud := &virtualPrivateCloudParams{
-		Name:      vpc.Name,
-		Namespace: vpc.Namespace,
-		Ip:        vpc.Spec.IP,
-		Mask:      vpc.Spec.Mask,
-		Region:    vpc.Spec.Region,
-		Status:    vpc.Spec.Status,
-		Labels:    labels,
-		LocalName: vpc.Spec.LocalName,
-	}
-
-	unstructuredVirtualPrivateCloud, err := utilsObj.Render(virtualPrivateCloud, virtualPrivateCloudTpl)
+	unstructuredVirtualPrivateCloud, err := ddx2xv1.ToUnstructured(ddx2xv1.ToVPC(&vpc))
 	if err != nil {
-		flog.Infof("render vpc obj error %v", err)
+		flog.Infof("convert vpc obj error %v", err)
 		return
 	}
 	_, err = client.Interface.Resource(virtualPrivateCloudGvr).Namespace(vpc.GetNamespace()).Create(
 		context.Background(), unstructuredVirtualPrivateCloud, metav1.CreateOptions{})
+	V.reportSynced(&vpc, err)
 	if err != nil {
 		flog.Infof("create vpc error %v", err)
 		return
@@ -76,41 +65,26 @@ func (V *VPCCtrl) NorthOnUpdate(obj core.IObject) {
 	if vpc.Spec.Status != common.UPDATE {
 		return
 	}
+	if isCloud(&vpc) {
+		// a vendor vpc is not changed once created
+		V.cloudFail(&vpc, fmt.Errorf("update vpc %s error %v", vpc.GetName(), cloudprovider.NotImplemented))
+		return
+	}
 
-	client, err := V.cs.GetClient(vpc.GetNamespace())
+	client, err := V.clientOf(&vpc)
 	if err != nil {
 		flog.Infof("get client error %v", err)
 		return
 	}
 
-	labels := make([]vpcLabel, 0)
-	for k, v := range vpc.Labels {
-		label := vpcLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
-	}
-
-	virtualPrivateCloud := &virtualPrivateCloudParams{
-		Name:      vpc.Name,
-		Namespace: vpc.Namespace,
-		Ip:        vpc.Spec.IP,
-		Mask:      vpc.Spec.Mask,
-		Region:    vpc.Spec.Region,
-		Status:    common.UPDATE,
-		Id:        vpc.Spec.ID,
-		Labels:    labels,
-		LocalName: vpc.Spec.LocalName,
-	}
-	unstructuredVirtualPrivateCloud, err := utilsObj.Render(virtualPrivateCloud, virtualPrivateCloudTpl)
+	unstructuredVirtualPrivateCloud, err := ddx2xv1.ToUnstructured(ddx2xv1.ToVPC(&vpc))
 	if err != nil {
-		flog.Infof("render vpc obj error %v", err)
+		flog.Infof("convert vpc obj error %v", err)
 		return
 	}
 	_, err = client.Interface.Resource(virtualPrivateCloudGvr).Namespace(vpc.GetNamespace()).Update(
 		context.Background(), unstructuredVirtualPrivateCloud, metav1.UpdateOptions{})
-
+	V.reportSynced(&vpc, err)
 	if err != nil {
 		flog.Infof("update vpc obj error %v", err)
 		return
@@ -128,35 +102,20 @@ func (V *VPCCtrl) NorthOnDelete(obj core.IObject) {
 		flog.Infof("unstructured obj error %v", err)
 		return
 	}
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
+	if isCloud(&vpc) {
+		V.deleteCloudVPC(&vpc)
+		return
+	}
+	client, err := V.clientOf(&vpc)
 	if err != nil {
 		flog.Infof("get client error %v", err)
 		return
 	}
 
-	labels := make([]vpcLabel, 0)
-	for k, v := range vpc.Labels {
-		label := vpcLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
-	}
-
-	virtualPrivateCloud := &virtualPrivateCloudParams{
-		Name:      vpc.Name,
-		Namespace: vpc.Namespace,
-		Ip:        vpc.Spec.IP,
-		Mask:      vpc.Spec.Mask,
-		Region:    vpc.Spec.Region,
-		Status:    common.DELETE,
-		Id:        vpc.Spec.ID,
-		Labels:    labels,
-		LocalName: vpc.Spec.LocalName,
-	}
-	unstructuredVirtualPrivateCloud, err := utilsObj.Render(virtualPrivateCloud, virtualPrivateCloudTpl)
+	vpc.Spec.Status = common.DELETE
+	unstructuredVirtualPrivateCloud, err := ddx2xv1.ToUnstructured(ddx2xv1.ToVPC(&vpc))
 	if err != nil {
-		flog.Infof("render vpc obj error %v", err)
+		flog.Infof("convert vpc obj error %v", err)
 		return
 	}
 
@@ -0,0 +1,36 @@
+package vpcctrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+)
+
+var _ controller.Planner = &VPCCtrl{}
+
+// Plan the vpc custom resource the north event would apply, a deleted vpc is applied with the delete status.
+// Cloud vpcs change no object of the clusters
+func (V *VPCCtrl) Plan(eventType core.EventType, obj core.IObject) ([]controller.Rendered, error) {
+	vpc := &networking.VirtualPrivateCloud{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, vpc); err != nil {
+		return nil, err
+	}
+	if isCloud(vpc) {
+		return nil, nil
+	}
+	cluster, err := V.placement.Resolve(controller.Placement{Provider: vpc.GetNamespace(), Region: vpc.Spec.Region, Workspace: vpc.GetWorkspace()})
+	if err != nil {
+		return nil, err
+	}
+	if eventType == core.DELETED {
+		vpc.Spec.Status = common.DELETE
+	}
+	object, err := ddx2xv1.ToUnstructured(ddx2xv1.ToVPC(vpc))
+	if err != nil {
+		return nil, err
+	}
+	return []controller.Rendered{{Cluster: cluster, Gvr: virtualPrivateCloudGvr, Object: object}}, nil
+}
@@ -0,0 +1,30 @@
+package vpcctrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ controller.Resyncer = &VPCCtrl{}
+
+func (V *VPCCtrl) ResyncTable() string { return common.VPC }
+
+func (V *VPCCtrl) ResyncGvr() schema.GroupVersionResource { return virtualPrivateCloudGvr }
+
+// ToSouth the vpc custom resource the stage object is pushed as, none for a cloud vpc
+func (V *VPCCtrl) ToSouth(obj core.IObject) (*unstructured.Unstructured, error) {
+	object := &networking.VirtualPrivateCloud{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, object); err != nil {
+		return nil, err
+	}
+	if isCloud(object) {
+		return nil, nil
+	}
+	return ddx2xv1.ToUnstructured(ddx2xv1.ToVPC(object))
+}
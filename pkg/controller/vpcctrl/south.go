@@ -3,10 +3,9 @@ package vpcctrl
 import (
 	"context"
 	"github.com/ddx2x/oilmont/pkg/common"
-	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/datasource"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
 	"github.com/ddx2x/oilmont/pkg/resource/networking"
-	"github.com/ddx2x/oilmont/pkg/resource/system"
 	objUtils "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -14,63 +13,19 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 )
 
-func (V *VPCCtrl) SouthOnAdd(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnAdd")
-	vpc := &unstructured.Unstructured{}
-
-	err := objUtils.Unmarshal(vpc, obj)
-	if err != nil {
-		flog.Warnf("unmarshal vpc data error: %v", obj)
-	}
-
-	status := objUtils.GetNestedString(vpc.Object,
-		"spec", "status")
-	if status == common.INIT {
+func (V *VPCCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnAdd").WithField("cluster", cluster)
+	vpc := &ddx2xv1.VPC{}
+	if err := ddx2xv1.FromUnstructured(obj, vpc); err != nil {
+		flog.Warnf("unmarshal vpc data error: %v", err)
 		return
 	}
-
-	ip := objUtils.GetNestedString(vpc.Object,
-		"spec", "ip")
-	mask := objUtils.GetNestedString(vpc.Object,
-		"spec", "mask")
-	regionId := objUtils.GetNestedString(vpc.Object,
-		"spec", "regionId")
-	vpcId := objUtils.GetNestedString(vpc.Object,
-		"spec", "vpcId")
-	message := objUtils.GetNestedString(vpc.Object,
-		"spec", "message")
-	localName := objUtils.GetNestedString(vpc.Object,
-		"spec", "localName")
-
-	labels := map[string]interface{}{}
-	getLabels := vpc.GetLabels()
-	for k, v := range getLabels {
-		labels[k] = v
-	}
-
-	vpcObj := &networking.VirtualPrivateCloud{
-		Metadata: core.Metadata{
-			Name:      vpc.GetName(),
-			Namespace: vpc.GetNamespace(),
-			Workspace: getLabels["workspace"],
-			Kind:      core.Kind(vpc.GetKind()),
-			Labels:    labels,
-		}, Spec: networking.VirtualPrivateCloudSpec{
-			IP:        ip,
-			Mask:      mask,
-			Region:    regionId,
-			Status:    status,
-			ID:        vpcId,
-			Message:   message,
-			LocalName: localName,
-		},
-	}
-
-	if vpcObj.Workspace == "" {
-		vpcObj.Workspace = common.DefaultWorkspace
+	if vpc.Spec.Status == common.INIT {
+		return
 	}
+	vpcObj := vpc.ToResource()
 
-	err = V.stage.Get(common.DefaultDatabase, common.VPC, vpcObj.Name, &networking.VirtualPrivateCloud{}, false)
+	err := V.stage.Get(common.DefaultDatabase, common.VPC, vpcObj.Name, &networking.VirtualPrivateCloud{}, false)
 	if err == datasource.NotFound {
 		_, createErr := V.stage.Create(common.DefaultDatabase, common.VPC, vpcObj)
 		if createErr != nil {
@@ -78,6 +33,7 @@ func (V *VPCCtrl) SouthOnAdd(obj runtime.Object) {
 			return
 		}
 		flog.Infof("create vpc %s,namespace: %s, workspace: %s", vpcObj.GetName(), vpcObj.GetNamespace(), vpcObj.GetWorkspace())
+		V.reportReady(vpcObj)
 		return
 	}
 	if err != nil {
@@ -93,81 +49,41 @@ func (V *VPCCtrl) SouthOnAdd(obj runtime.Object) {
 	if update {
 		flog.Infof("update vpc %s, workspace: %s, namespace: %s", vpcObj.GetName(), vpcObj.GetWorkspace(), vpcObj.GetNamespace())
 	}
+	V.reportReady(vpcObj)
 	return
 }
 
-func (V *VPCCtrl) SouthOnUpdate(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnUpdate")
+func (V *VPCCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnUpdate").WithField("cluster", cluster)
 	var force = false
-	vpc := &unstructured.Unstructured{}
-	err := objUtils.Unmarshal(vpc, obj)
-	if err != nil {
-		flog.Warnf("unmarshal vpc data error: %v", obj)
-	}
-	status := objUtils.GetNestedString(vpc.Object,
-		"spec", "status")
-	if status == common.DELETE || status == common.UPDATE {
+	vpc := &ddx2xv1.VPC{}
+	if err := ddx2xv1.FromUnstructured(obj, vpc); err != nil {
+		flog.Warnf("unmarshal vpc data error: %v", err)
 		return
 	}
-
-	ip := objUtils.GetNestedString(vpc.Object,
-		"spec", "ip")
-	mask := objUtils.GetNestedString(vpc.Object,
-		"spec", "mask")
-	regionId := objUtils.GetNestedString(vpc.Object,
-		"spec", "regionId")
-	vpcId := objUtils.GetNestedString(vpc.Object,
-		"spec", "vpcId")
-	message := objUtils.GetNestedString(vpc.Object,
-		"spec", "message")
-	localName := objUtils.GetNestedString(vpc.Object,
-		"spec", "localName")
-
-	labels := map[string]interface{}{}
-	getLabels := vpc.GetLabels()
-	for k, v := range getLabels {
-		labels[k] = v
-	}
-
-	vpcObj := &networking.VirtualPrivateCloud{
-		Metadata: core.Metadata{
-			Name:      vpc.GetName(),
-			Namespace: vpc.GetNamespace(),
-			Workspace: getLabels["workspace"],
-			Kind:      core.Kind(vpc.GetKind()),
-			Labels:    labels,
-		}, Spec: networking.VirtualPrivateCloudSpec{
-			IP:        ip,
-			Mask:      mask,
-			Region:    regionId,
-			Status:    status,
-			ID:        vpcId,
-			Message:   message,
-			LocalName: localName,
-		},
+	if vpc.Spec.Status == common.DELETE || vpc.Spec.Status == common.UPDATE {
+		return
 	}
+	vpcObj := vpc.ToResource()
 
 	if vpcObj.Spec.Status == common.FAIL {
 		force = true
 		vpcObj.Metadata.IsDelete = false
 	}
 
-	if vpcObj.Workspace == "" {
-		vpcObj.Workspace = common.DefaultWorkspace
-	}
-
-	_, _, err = V.stage.Apply(common.DefaultDatabase, common.VPC, vpcObj.Name, vpcObj, force)
+	_, _, err := V.stage.Apply(common.DefaultDatabase, common.VPC, vpcObj.Name, vpcObj, force)
 	if err != nil {
 		flog.Warnf("update vpc error: %v", obj)
 		return
 	}
+	V.reportReady(vpcObj)
 
 	flog.Infof("update a vpc %s to stage", vpcObj.GetName())
 	return
 }
 
-func (V *VPCCtrl) SouthOnDelete(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnDelete")
+func (V *VPCCtrl) SouthOnDelete(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnDelete").WithField("cluster", cluster)
 	vpc := &unstructured.Unstructured{}
 
 	err := objUtils.Unmarshal(vpc, obj)
@@ -187,25 +103,18 @@ func (V *VPCCtrl) SouthOnDelete(obj runtime.Object) {
 	return
 }
 
-func (V *VPCCtrl) SouthEventChs(ctx context.Context) ([]<-chan watch.Event, error) {
-	clusters := make([]system.Cluster, 0)
-	if err := V.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+func (V *VPCCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
 		return nil, err
 	}
 
 	channels := make([]<-chan watch.Event, 0)
-	for _, cluster := range clusters {
-		client, err := V.cs.GetClient(cluster.GetName())
-		if err != nil {
-			return nil, err
-		}
-
-		watchInterface, err := client.Interface.Resource(virtualPrivateCloudGvr).Watch(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		channels = append(channels, watchInterface.ResultChan())
+	watchInterface, err := client.Interface.Resource(virtualPrivateCloudGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	channels = append(channels, watchInterface.ResultChan())
 
 	return channels, nil
 }
@@ -3,10 +3,12 @@ package vpcctrl
 import (
 	"context"
 
+	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/controller"
 	"github.com/ddx2x/oilmont/pkg/controller/clients"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -17,16 +19,36 @@ var (
 var _ controller.Handler = &VPCCtrl{}
 
 type VPCCtrl struct {
-	stage datasource.IStorage
-	cs    *clients.Clients
-	flog  log.Logger
+	stage     datasource.IStorage
+	cs        *clients.Clients
+	placement *controller.PlacementResolver
+	flog      log.Logger
 }
 
 func (V *VPCCtrl) Set(cs *clients.Clients, stage datasource.IStorage) {
 	V.cs, V.stage = cs, stage
+	V.placement = controller.NewPlacementResolver(stage, cs)
 }
 
 func NewVPCtrl(ctx context.Context) controller.Handler {
 	flog := log.GetLogger(ctx).WithField("controller", "vpcCtrl")
 	return &VPCCtrl{flog: flog}
 }
+
+func (V *VPCCtrl) reportSynced(vpc *networking.VirtualPrivateCloud, err error) {
+	if reportErr := controller.ReportObserved(V.stage, common.VPC, vpc, controller.SyncedCondition(err)); reportErr != nil {
+		V.flog.Warnf("report vpc %s synced condition error %v", vpc.GetName(), reportErr)
+	}
+}
+
+func (V *VPCCtrl) reportReady(vpc *networking.VirtualPrivateCloud) {
+	condition := controller.ReadyCondition(vpc.Spec.Status, vpc.Spec.Message)
+	if reportErr := controller.ReportCondition(V.stage, common.VPC, vpc, condition); reportErr != nil {
+		V.flog.Warnf("report vpc %s ready condition error %v", vpc.GetName(), reportErr)
+	}
+}
+
+// clientOf client of the cluster owning the vpc
+func (V *VPCCtrl) clientOf(vpc *networking.VirtualPrivateCloud) (*clients.KubeClient, error) {
+	return V.placement.Client(controller.Placement{Provider: vpc.GetNamespace(), Region: vpc.Spec.Region, Workspace: vpc.GetWorkspace()})
+}
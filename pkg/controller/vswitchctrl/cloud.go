@@ -0,0 +1,86 @@
+package vswitchctrl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	_ "github.com/ddx2x/oilmont/pkg/cloudprovider/providers"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+func isCloud(vSwitch *networking.Vswitch) bool {
+	return cloudprovider.IsRegistered(vSwitch.GetNamespace())
+}
+
+// changeVSwitchStatus write the progress and the vendor id back, both belong to the controller and bump no generation
+func (V *VSwitchCtrl) changeVSwitchStatus(vSwitch *networking.Vswitch, status string, message string) error {
+	vSwitch.Spec.Status = status
+	vSwitch.Spec.Message = message
+	_, err := V.stage.ApplyStatus(common.DefaultDatabase, common.VSWITCH, vSwitch.GetName(), vSwitch, append(datasource.ProgressPaths, "spec.id")...)
+	return err
+}
+
+// cloudFail record err on the vSwitch, a cloud vSwitch has no custom resource to report it back
+func (V *VSwitchCtrl) cloudFail(vSwitch *networking.Vswitch, err error) {
+	flog := V.flog.WithField("func", "cloudFail")
+	flog.Warnf("vSwitch %s error %v", vSwitch.GetName(), err)
+	if applyErr := V.changeVSwitchStatus(vSwitch, common.FAIL, err.Error()); applyErr != nil {
+		flog.Warnf("change vSwitch status error %v", applyErr)
+	}
+	V.reportSynced(vSwitch, err)
+	V.reportReady(vSwitch)
+}
+
+// createCloudVSwitch create the vendor subnet in the vendor vpc the vSwitch references and store its id
+func (V *VSwitchCtrl) createCloudVSwitch(vSwitch *networking.Vswitch) {
+	flog := V.flog.WithField("func", "createCloudVSwitch")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if vSwitch.Spec.Id == "" {
+		provider, err := cloudprovider.ForProvider(V.stage, vSwitch.GetNamespace(), vSwitch.Spec.Region)
+		if err != nil {
+			V.cloudFail(vSwitch, err)
+			return
+		}
+		subnet := *vSwitch
+		subnet.Spec.VpcId = controller.CloudId(V.stage, common.VPC, vSwitch.GetWorkspace(), vSwitch.Spec.VpcId)
+		if err := provider.Subnets().Create(ctx, &subnet); err != nil {
+			V.cloudFail(vSwitch, fmt.Errorf("create subnet error %v", err))
+			return
+		}
+		vSwitch.Spec.Id = subnet.Spec.Id
+		flog.Infof("create vSwitch %s subnet %s", vSwitch.GetName(), vSwitch.Spec.Id)
+	}
+
+	if applyErr := V.changeVSwitchStatus(vSwitch, common.RUNNING, "success"); applyErr != nil {
+		flog.Warnf("change vSwitch status error %v", applyErr)
+	}
+	V.reportSynced(vSwitch, nil)
+	V.reportReady(vSwitch)
+}
+
+func (V *VSwitchCtrl) deleteCloudVSwitch(vSwitch *networking.Vswitch) {
+	flog := V.flog.WithField("func", "deleteCloudVSwitch")
+	if vSwitch.Spec.Id == "" {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider, err := cloudprovider.ForProvider(V.stage, vSwitch.GetNamespace(), vSwitch.Spec.Region)
+	if err != nil {
+		flog.Warnf("get cloud provider error %v", err)
+		return
+	}
+	err = provider.Subnets().Delete(ctx, vSwitch.Spec.Region, vSwitch.Spec.Id)
+	if err != nil && err != cloudprovider.NotFound {
+		flog.Warnf("delete vSwitch %s subnet %s error %v", vSwitch.GetName(), vSwitch.Spec.Id, err)
+		return
+	}
+	flog.Infof("delete vSwitch %s subnet %s", vSwitch.GetName(), vSwitch.Spec.Id)
+}
@@ -0,0 +1,60 @@
+package vswitchctrl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake"
+	"github.com/ddx2x/oilmont/pkg/cloudprovider/fake/faketest"
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+func TestCloudVSwitch(t *testing.T) {
+	stage, cloud := faketest.NewStage(t)
+	V := NewVSwitchCtrl(context.Background()).(*VSwitchCtrl)
+	V.Set(nil, stage)
+	ctx := context.Background()
+
+	vpc := &networking.VirtualPrivateCloud{Metadata: core.Metadata{Name: "vpc1", Namespace: fake.Name, Workspace: "ws"}}
+	vpc.Spec = networking.VirtualPrivateCloudSpec{Region: "region-1", IP: "10.0.0.0", Mask: "16", Status: common.RUNNING}
+	if err := cloud.VPCs().Create(ctx, vpc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stage.Create(common.DefaultDatabase, common.VPC, vpc); err != nil {
+		t.Fatal(err)
+	}
+
+	// the vSwitch references its vpc by name, the subnet is created in the vendor vpc
+	vSwitch := &networking.Vswitch{Metadata: core.Metadata{Name: "vsw1", Namespace: fake.Name, Workspace: "ws"}}
+	vSwitch.Spec = networking.VSwitchSpec{Region: "region-1", IP: "10.0.1.0", Mask: "24", VpcId: "vpc1", Status: common.INIT}
+	if _, err := stage.Create(common.DefaultDatabase, common.VSWITCH, vSwitch); err != nil {
+		t.Fatal(err)
+	}
+	if rendered, err := V.Plan(core.ADDED, vSwitch); err != nil || len(rendered) != 0 {
+		t.Fatalf("expected a cloud vSwitch to change no cluster, got %v %v", rendered, err)
+	}
+	V.NorthOnAdd(vSwitch)
+
+	stored := &networking.Vswitch{}
+	if err := stage.Get(common.DefaultDatabase, common.VSWITCH, "vsw1", stored, false); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Spec.Status != common.RUNNING || stored.Spec.Id == "" || stored.Spec.VpcId != "vpc1" {
+		t.Fatalf("expected the vSwitch running with a subnet id, got %+v", stored.Spec)
+	}
+	subnet, err := cloud.Subnets().Get(ctx, "region-1", stored.Spec.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnet.Spec.VpcId != vpc.Spec.ID {
+		t.Fatalf("expected the subnet in vpc %s, got %s", vpc.Spec.ID, subnet.Spec.VpcId)
+	}
+
+	V.NorthOnDelete(stored)
+	if _, err := cloud.Subnets().Get(ctx, "region-1", stored.Spec.Id); err != cloudprovider.NotFound {
+		t.Fatalf("expected the subnet deleted, got %v", err)
+	}
+}
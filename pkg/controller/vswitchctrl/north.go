@@ -2,8 +2,12 @@ package vswitchctrl
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/cloudprovider"
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
 	"github.com/ddx2x/oilmont/pkg/resource/networking"
 	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,43 +25,25 @@ func (V *VSwitchCtrl) NorthOnAdd(obj core.IObject) {
 	if vSwitch.Spec.Status != common.INIT {
 		return
 	}
+	if isCloud(&vSwitch) {
+		V.createCloudVSwitch(&vSwitch)
+		return
+	}
 
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
+	client, err := V.clientOf(&vSwitch)
 	if err != nil {
 		flog.Infof("get client error %v", err)
 		return
 	}
 
-	labels := make([]vpcLabel, 0)
-	for k, v := range vSwitch.Labels {
-		label := vpcLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
-	}
-
-	virtualPrivateCloud := &virtualPrivateCloudParams{
-		Name:      vSwitch.Name,
-		Namespace: vSwitch.Namespace,
-		Ip:        vSwitch.Spec.IP,
-		Mask:      vSwitch.Spec.Mask,
-		Region:    vSwitch.Spec.Region,
-		Status:    common.INIT,
-		VpcId:     vSwitch.Spec.VpcId,
-		Zone:      vSwitch.Spec.Zone,
-		Labels:    labels,
-		LocalName: vSwitch.Spec.LocalName,
-	}
-
-	unstructuredVirtualPrivateCloud, err := utilsObj.Render(virtualPrivateCloud, vSwitchTpl)
+	unstructuredVSwitch, err := ddx2xv1.ToUnstructured(ddx2xv1.ToVSwitch(&vSwitch))
 	if err != nil {
-		flog.Warnf("render obj error %v", err)
+		flog.Warnf("convert vSwitch obj error %v", err)
 		return
 	}
 	_, err = client.Interface.Resource(vSwitchGvr).Namespace(vSwitch.GetNamespace()).Create(
-		context.Background(), unstructuredVirtualPrivateCloud, metav1.CreateOptions{})
-
+		context.Background(), unstructuredVSwitch, metav1.CreateOptions{})
+	V.reportSynced(&vSwitch, err)
 	if err != nil {
 		flog.Warnf("create vSwitch error %v", err)
 		return
@@ -79,44 +65,26 @@ func (V *VSwitchCtrl) NorthOnUpdate(obj core.IObject) {
 	if vSwitch.Spec.Status != common.UPDATE {
 		return
 	}
+	if isCloud(&vSwitch) {
+		// a vendor subnet is not changed once created
+		V.cloudFail(&vSwitch, fmt.Errorf("update vSwitch %s error %v", vSwitch.GetName(), cloudprovider.NotImplemented))
+		return
+	}
 
-	client, err := V.cs.GetClient(vSwitch.GetNamespace())
+	client, err := V.clientOf(&vSwitch)
 	if err != nil {
 		flog.Infof("get client error %v", err)
 		return
 	}
 
-	labels := make([]vpcLabel, 0)
-	for k, v := range vSwitch.Labels {
-		label := vpcLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
-	}
-
-	virtualPrivateCloud := &virtualPrivateCloudParams{
-		Name:      vSwitch.Name,
-		Namespace: vSwitch.Namespace,
-		Ip:        vSwitch.Spec.IP,
-		Mask:      vSwitch.Spec.Mask,
-		Region:    vSwitch.Spec.Region,
-		Status:    common.UPDATE,
-		VpcId:     vSwitch.Spec.VpcId,
-		Zone:      vSwitch.Spec.Zone,
-		Id:        vSwitch.Spec.Id,
-		Labels:    labels,
-		LocalName: vSwitch.Spec.LocalName,
-	}
-
-	unstructuredVirtualPrivateCloud, err := utilsObj.Render(virtualPrivateCloud, vSwitchTpl)
+	unstructuredVSwitch, err := ddx2xv1.ToUnstructured(ddx2xv1.ToVSwitch(&vSwitch))
 	if err != nil {
-		flog.Infof("render vSwitch obj error %v", err)
+		flog.Infof("convert vSwitch obj error %v", err)
 		return
 	}
 
-	_, _, err = client.Apply(context.Background(), vSwitch.GetNamespace(), vSwitchGvr, vSwitch.GetName(), unstructuredVirtualPrivateCloud, false)
-
+	_, _, err = client.Apply(context.Background(), vSwitch.GetNamespace(), vSwitchGvr, vSwitch.GetName(), unstructuredVSwitch, false)
+	V.reportSynced(&vSwitch, err)
 	if err != nil {
 		flog.Infof("update vSwitch obj error %v", err)
 		return
@@ -134,42 +102,24 @@ func (V *VSwitchCtrl) NorthOnDelete(obj core.IObject) {
 		flog.Infof("unstructured obj error %v", err)
 		return
 	}
-	client, err := V.cs.GetClient(common.DefaultKubernetes)
+	if isCloud(&vSwitch) {
+		V.deleteCloudVSwitch(&vSwitch)
+		return
+	}
+	client, err := V.clientOf(&vSwitch)
 	if err != nil {
 		flog.Infof("get client error %v", err)
 		return
 	}
 
-	labels := make([]vpcLabel, 0)
-	for k, v := range vSwitch.Labels {
-		label := vpcLabel{
-			Key:   k,
-			Value: v,
-		}
-		labels = append(labels, label)
-	}
-
-	virtualPrivateCloud := &virtualPrivateCloudParams{
-		Name:      vSwitch.Name,
-		Namespace: vSwitch.Namespace,
-		Id:        vSwitch.Spec.Id,
-		Ip:        vSwitch.Spec.IP,
-		Mask:      vSwitch.Spec.Mask,
-		Region:    vSwitch.Spec.Region,
-		Status:    common.DELETE,
-		VpcId:     vSwitch.Spec.VpcId,
-		Zone:      vSwitch.Spec.Zone,
-		Labels:    labels,
-		LocalName: vSwitch.Spec.LocalName,
-	}
-
-	unstructuredVirtualPrivateCloud, err := utilsObj.Render(virtualPrivateCloud, vSwitchTpl)
+	vSwitch.Spec.Status = common.DELETE
+	unstructuredVSwitch, err := ddx2xv1.ToUnstructured(ddx2xv1.ToVSwitch(&vSwitch))
 	if err != nil {
-		flog.Infof("render vSwitch obj error %v", err)
+		flog.Infof("convert vSwitch obj error %v", err)
 		return
 	}
 
-	_, _, err = client.Apply(context.Background(), vSwitch.GetNamespace(), vSwitchGvr, vSwitch.GetName(), unstructuredVirtualPrivateCloud, false)
+	_, _, err = client.Apply(context.Background(), vSwitch.GetNamespace(), vSwitchGvr, vSwitch.GetName(), unstructuredVSwitch, false)
 
 	if err != nil {
 		flog.Infof("delete vSwitch obj error %v", err)
@@ -0,0 +1,36 @@
+package vswitchctrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+)
+
+var _ controller.Planner = &VSwitchCtrl{}
+
+// Plan the vSwitch custom resource the north event would apply, a deleted vSwitch is applied with the delete status.
+// Cloud vSwitches change no object of the clusters
+func (V *VSwitchCtrl) Plan(eventType core.EventType, obj core.IObject) ([]controller.Rendered, error) {
+	vSwitch := &networking.Vswitch{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, vSwitch); err != nil {
+		return nil, err
+	}
+	if isCloud(vSwitch) {
+		return nil, nil
+	}
+	cluster, err := V.placement.Resolve(controller.Placement{Provider: vSwitch.GetNamespace(), Region: vSwitch.Spec.Region, Az: vSwitch.Spec.Zone, Workspace: vSwitch.GetWorkspace()})
+	if err != nil {
+		return nil, err
+	}
+	if eventType == core.DELETED {
+		vSwitch.Spec.Status = common.DELETE
+	}
+	object, err := ddx2xv1.ToUnstructured(ddx2xv1.ToVSwitch(vSwitch))
+	if err != nil {
+		return nil, err
+	}
+	return []controller.Rendered{{Cluster: cluster, Gvr: vSwitchGvr, Object: object}}, nil
+}
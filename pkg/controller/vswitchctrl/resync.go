@@ -0,0 +1,30 @@
+package vswitchctrl
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/controller"
+	"github.com/ddx2x/oilmont/pkg/core"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	utilsObj "github.com/ddx2x/oilmont/pkg/utils/obj"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ controller.Resyncer = &VSwitchCtrl{}
+
+func (V *VSwitchCtrl) ResyncTable() string { return common.VSWITCH }
+
+func (V *VSwitchCtrl) ResyncGvr() schema.GroupVersionResource { return vSwitchGvr }
+
+// ToSouth the vswitch custom resource the stage object is pushed as, none for a cloud vswitch
+func (V *VSwitchCtrl) ToSouth(obj core.IObject) (*unstructured.Unstructured, error) {
+	object := &networking.Vswitch{}
+	if err := utilsObj.UnstructuredObjectToInstanceObj(obj, object); err != nil {
+		return nil, err
+	}
+	if isCloud(object) {
+		return nil, nil
+	}
+	return ddx2xv1.ToUnstructured(ddx2xv1.ToVSwitch(object))
+}
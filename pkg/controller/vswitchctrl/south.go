@@ -3,10 +3,9 @@ package vswitchctrl
 import (
 	"context"
 	"github.com/ddx2x/oilmont/pkg/common"
-	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/datasource"
+	ddx2xv1 "github.com/ddx2x/oilmont/pkg/k8s/apis/ddx2x/v1"
 	"github.com/ddx2x/oilmont/pkg/resource/networking"
-	"github.com/ddx2x/oilmont/pkg/resource/system"
 	objUtils "github.com/ddx2x/oilmont/pkg/utils/obj"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -14,69 +13,20 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 )
 
-func (V *VSwitchCtrl) SouthOnAdd(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnAdd")
+func (V *VSwitchCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnAdd").WithField("cluster", cluster)
 
-	vSwitch := &unstructured.Unstructured{}
-	err := objUtils.Unmarshal(vSwitch, obj)
-	if err != nil {
-		flog.Warnf("unmarshal vSwitch data error: %v", obj)
-	}
-
-	status := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "status")
-	if status == common.INIT {
+	vSwitch := &ddx2xv1.VSwitch{}
+	if err := ddx2xv1.FromUnstructured(obj, vSwitch); err != nil {
+		flog.Warnf("unmarshal vSwitch data error: %v", err)
 		return
 	}
-
-	ip := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "ip")
-	mask := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "mask")
-	regionId := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "regionId")
-	vpcId := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "vpcId")
-	zone := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "zone")
-	id := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "id")
-	message := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "message")
-	localName := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "localName")
-
-	labels := map[string]interface{}{}
-	getLabels := vSwitch.GetLabels()
-	for k, v := range getLabels {
-		labels[k] = v
-	}
-
-	vSwitchObj := &networking.Vswitch{
-		Metadata: core.Metadata{
-			Name:      vSwitch.GetName(),
-			Namespace: vSwitch.GetNamespace(),
-			Workspace: getLabels["workspace"],
-			Kind:      core.Kind(vSwitch.GetKind()),
-			Labels:    labels,
-		}, Spec: networking.VSwitchSpec{
-			IP:        ip,
-			Mask:      mask,
-			Region:    regionId,
-			Zone:      zone,
-			Status:    status,
-			VpcId:     vpcId,
-			Id:        id,
-			Message:   message,
-			LocalName: localName,
-		},
-	}
-
-	if vSwitchObj.Workspace == "" {
-		vSwitchObj.Workspace = common.DefaultWorkspace
+	if vSwitch.Spec.Status == common.INIT {
+		return
 	}
+	vSwitchObj := vSwitch.ToResource()
 
-	err = V.stage.Get(common.DefaultDatabase, common.VSWITCH, vSwitchObj.Name, &networking.Vswitch{}, false)
+	err := V.stage.Get(common.DefaultDatabase, common.VSWITCH, vSwitchObj.Name, &networking.Vswitch{}, false)
 	if err == datasource.NotFound {
 		_, createErr := V.stage.Create(common.DefaultDatabase, common.VSWITCH, vSwitchObj)
 		if createErr != nil {
@@ -84,6 +34,7 @@ func (V *VSwitchCtrl) SouthOnAdd(obj runtime.Object) {
 			return
 		}
 		flog.Infof("create vswitch %s, workspace: %s, namespace: %s", vSwitchObj.GetName(), vSwitchObj.GetWorkspace(), vSwitchObj.GetNamespace())
+		V.reportReady(vSwitchObj)
 		return
 	}
 	if err != nil {
@@ -99,91 +50,42 @@ func (V *VSwitchCtrl) SouthOnAdd(obj runtime.Object) {
 	if update {
 		flog.Infof("update vSwitch %s ,workspace: %s, namespace: %s", vSwitchObj.GetName(), vSwitchObj.GetWorkspace(), vSwitchObj.GetNamespace())
 	}
+	V.reportReady(vSwitchObj)
 
 	return
 }
 
-func (V *VSwitchCtrl) SouthOnUpdate(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnUpdate")
+func (V *VSwitchCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnUpdate").WithField("cluster", cluster)
 	var force = false
 
-	vSwitch := &unstructured.Unstructured{}
-
-	err := objUtils.Unmarshal(vSwitch, obj)
-	if err != nil {
-		flog.Warnf("unmarshal vSwitch data error: %v", obj)
+	vSwitch := &ddx2xv1.VSwitch{}
+	if err := ddx2xv1.FromUnstructured(obj, vSwitch); err != nil {
+		flog.Warnf("unmarshal vSwitch data error: %v", err)
 		return
 	}
-	status := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "status")
-
-	if status == common.DELETE || status == common.UPDATE {
+	if vSwitch.Spec.Status == common.DELETE || vSwitch.Spec.Status == common.UPDATE {
 		return
 	}
-
-	ip := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "ip")
-	mask := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "mask")
-	regionId := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "regionId")
-	vpcId := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "vpcId")
-	zone := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "zone")
-	localName := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "localName")
-	id := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "id")
-	message := objUtils.GetNestedString(vSwitch.Object,
-		"spec", "message")
-
-	labels := map[string]interface{}{}
-	getLabels := vSwitch.GetLabels()
-	for k, v := range getLabels {
-		labels[k] = v
-	}
-
-	vSwitchObj := &networking.Vswitch{
-		Metadata: core.Metadata{
-			Name:      vSwitch.GetName(),
-			Namespace: vSwitch.GetNamespace(),
-			Workspace: getLabels["workspace"],
-			Kind:      core.Kind(vSwitch.GetKind()),
-			Labels:    labels,
-		}, Spec: networking.VSwitchSpec{
-			IP:        ip,
-			Mask:      mask,
-			Region:    regionId,
-			Zone:      zone,
-			Status:    status,
-			VpcId:     vpcId,
-			Id:        id,
-			Message:   message,
-			LocalName: localName,
-		},
-	}
+	vSwitchObj := vSwitch.ToResource()
 
 	if vSwitchObj.Spec.Status == common.FAIL {
 		force = true
 		vSwitchObj.Metadata.IsDelete = false
 	}
 
-	if vSwitchObj.Workspace == "" {
-		vSwitchObj.Workspace = common.DefaultWorkspace
-	}
-
-	_, _, err = V.stage.Apply(common.DefaultDatabase, common.VSWITCH, vSwitchObj.Name, vSwitchObj, force)
+	_, _, err := V.stage.Apply(common.DefaultDatabase, common.VSWITCH, vSwitchObj.Name, vSwitchObj, force)
 	if err != nil {
 		flog.Warnf("update vSwitch error: %v", obj)
 		return
 	}
+	V.reportReady(vSwitchObj)
 	flog.Infof("Apply a new vSwitch %s to stage", vSwitchObj.GetName())
 	return
 }
 
-func (V *VSwitchCtrl) SouthOnDelete(obj runtime.Object) {
-	flog := V.flog.WithField("func", "SouthOnDelete")
+func (V *VSwitchCtrl) SouthOnDelete(cluster string, obj runtime.Object) {
+	flog := V.flog.WithField("func", "SouthOnDelete").WithField("cluster", cluster)
 
 	vSwitch := &unstructured.Unstructured{}
 
@@ -205,25 +107,18 @@ func (V *VSwitchCtrl) SouthOnDelete(obj runtime.Object) {
 	return
 }
 
-func (V *VSwitchCtrl) SouthEventChs(ctx context.Context) ([]<-chan watch.Event, error) {
-	clusters := make([]system.Cluster, 0)
-	if err := V.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+func (V *VSwitchCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
 		return nil, err
 	}
 
 	channels := make([]<-chan watch.Event, 0)
-	for _, cluster := range clusters {
-		client, err := V.cs.GetClient(cluster.GetName())
-		if err != nil {
-			return nil, err
-		}
-
-		watchInterface, err := client.Interface.Resource(vSwitchGvr).Watch(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		channels = append(channels, watchInterface.ResultChan())
+	watchInterface, err := client.Interface.Resource(vSwitchGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	channels = append(channels, watchInterface.ResultChan())
 
 	return channels, nil
 }
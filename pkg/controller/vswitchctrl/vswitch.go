@@ -3,10 +3,12 @@ package vswitchctrl
 import (
 	"context"
 
+	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/controller"
 	"github.com/ddx2x/oilmont/pkg/controller/clients"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/log"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -17,16 +19,36 @@ var (
 var _ controller.Handler = &VSwitchCtrl{}
 
 type VSwitchCtrl struct {
-	stage datasource.IStorage
-	cs    *clients.Clients
-	flog  log.Logger
+	stage     datasource.IStorage
+	cs        *clients.Clients
+	placement *controller.PlacementResolver
+	flog      log.Logger
 }
 
 func (V *VSwitchCtrl) Set(cs *clients.Clients, stage datasource.IStorage) {
 	V.cs, V.stage = cs, stage
+	V.placement = controller.NewPlacementResolver(stage, cs)
 }
 
 func NewVSwitchCtrl(ctx context.Context) controller.Handler {
 	flog := log.GetLogger(ctx).WithField("controller", "vSwitchCtrl")
 	return &VSwitchCtrl{flog: flog}
 }
+
+func (V *VSwitchCtrl) reportSynced(vSwitch *networking.Vswitch, err error) {
+	if reportErr := controller.ReportObserved(V.stage, common.VSWITCH, vSwitch, controller.SyncedCondition(err)); reportErr != nil {
+		V.flog.Warnf("report vSwitch %s synced condition error %v", vSwitch.GetName(), reportErr)
+	}
+}
+
+func (V *VSwitchCtrl) reportReady(vSwitch *networking.Vswitch) {
+	condition := controller.ReadyCondition(vSwitch.Spec.Status, vSwitch.Spec.Message)
+	if reportErr := controller.ReportCondition(V.stage, common.VSWITCH, vSwitch, condition); reportErr != nil {
+		V.flog.Warnf("report vSwitch %s ready condition error %v", vSwitch.GetName(), reportErr)
+	}
+}
+
+// clientOf client of the cluster owning the vSwitch
+func (V *VSwitchCtrl) clientOf(vSwitch *networking.Vswitch) (*clients.KubeClient, error) {
+	return V.placement.Client(controller.Placement{Provider: vSwitch.GetNamespace(), Region: vSwitch.Spec.Region, Az: vSwitch.Spec.Zone, Workspace: vSwitch.GetWorkspace()})
+}
@@ -14,41 +14,34 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 )
 
-func (V *ZoneCtrl) SouthOnAdd(obj runtime.Object) {
+func (V *ZoneCtrl) SouthOnAdd(cluster string, obj runtime.Object) {
 	V.applyZoneToStage(obj)
 
 }
 
-func (V *ZoneCtrl) SouthOnUpdate(obj runtime.Object) {
+func (V *ZoneCtrl) SouthOnUpdate(cluster string, obj runtime.Object) {
 	V.applyZoneToStage(obj)
 }
 
-func (V *ZoneCtrl) SouthOnDelete(obj runtime.Object) {
+func (V *ZoneCtrl) SouthOnDelete(cluster string, obj runtime.Object) {
 	panic("implement me")
 }
 
-func (V *ZoneCtrl) SouthEventChs(ctx context.Context) ([]<-chan watch.Event, error) {
+func (V *ZoneCtrl) SouthEventChs(ctx context.Context, cluster string) ([]<-chan watch.Event, error) {
 	flog := V.flog.WithField("func", "SouthEventChs")
 	flog.Info("zone start watch South Event")
 
-	clusters := make([]system.Cluster, 0)
-	if err := V.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+	client, err := V.cs.GetClient(cluster)
+	if err != nil {
 		return nil, err
 	}
 
 	channels := make([]<-chan watch.Event, 0)
-	for _, cluster := range clusters {
-		client, err := V.cs.GetClient(cluster.GetName())
-		if err != nil {
-			return nil, err
-		}
-
-		watchInterface, err := client.Interface.Resource(zoneGvr).Watch(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		channels = append(channels, watchInterface.ResultChan())
+	watchInterface, err := client.Interface.Resource(zoneGvr).Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	channels = append(channels, watchInterface.ResultChan())
 
 	return channels, nil
 }
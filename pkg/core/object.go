@@ -14,16 +14,17 @@ type Kind string
 type AreaType = uint32
 
 type Metadata struct {
-	Name      string                 `json:"name" bson:"name"`
-	Kind      Kind                   `json:"kind"  bson:"kind"`
-	Version   string                 `json:"version" bson:"version"`
-	UUID      string                 `json:"uuid" bson:"uuid"`
-	IsDelete  bool                   `json:"is_delete" bson:"is_delete"`
-	Tenant    string                 `json:"tenant" bson:"tenant"`
-	Namespace string                 `json:"namespace" bson:"namespace"`
-	Workspace string                 `json:"workspace" bson:"workspace"`
-	Labels    map[string]interface{} `json:"labels" bson:"labels"`
-	Area      AreaType               `json:"area" bson:"area"`
+	Name       string                 `json:"name" bson:"name"`
+	Kind       Kind                   `json:"kind"  bson:"kind"`
+	Version    string                 `json:"version" bson:"version"`
+	UUID       string                 `json:"uuid" bson:"uuid"`
+	IsDelete   bool                   `json:"is_delete" bson:"is_delete"`
+	Tenant     string                 `json:"tenant" bson:"tenant"`
+	Namespace  string                 `json:"namespace" bson:"namespace"`
+	Workspace  string                 `json:"workspace" bson:"workspace"`
+	Labels     map[string]interface{} `json:"labels" bson:"labels"`
+	Area       AreaType               `json:"area" bson:"area"`
+	Generation int64                  `json:"generation" bson:"generation"`
 }
 
 func (m *Metadata) GetMateData() Metadata {
@@ -49,6 +50,10 @@ func (m *Metadata) GetResourceVersion() string {
 	return m.Version
 }
 
+func (m *Metadata) GetGeneration() int64 {
+	return m.Generation
+}
+
 func (m *Metadata) GetName() string {
 	return m.Name
 }
@@ -78,6 +83,9 @@ func (m *Metadata) GenerateVersion() IObject {
 	if m.UUID == "" {
 		m.UUID = uuid.NewSUID().String()
 	}
+	if m.Generation == 0 {
+		m.Generation = 1
+	}
 	return m
 }
 
@@ -119,6 +127,7 @@ type IObject interface {
 	Clone() IObject
 	GenerateVersion() IObject
 	GetResourceVersion() string
+	GetGeneration() int64
 	GetUUID() string
 	GetMateData() Metadata
 	Delete()
@@ -180,6 +189,8 @@ var _ IObject = &DefaultObject{}
 type DefaultObject struct {
 	Metadata `json:"metadata"`
 	Spec     interface{} `json:"spec"`
+	// Status the status subresource as stored, absent for the resources without one
+	Status interface{} `json:"status,omitempty"`
 }
 
 func (i *DefaultObject) GetMateData() Metadata {
@@ -0,0 +1,92 @@
+package core
+
+import "time"
+
+type ConditionType string
+
+type ConditionStatus string
+
+const (
+	// ConditionSynced the desired spec has been pushed to the backend cluster
+	ConditionSynced ConditionType = "Synced"
+	// ConditionReady the backend reports the resource as usable
+	ConditionReady ConditionType = "Ready"
+
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+type Condition struct {
+	Type               ConditionType   `json:"type" bson:"type"`
+	Status             ConditionStatus `json:"status" bson:"status"`
+	Reason             string          `json:"reason" bson:"reason"`
+	Message            string          `json:"message" bson:"message"`
+	LastTransitionTime string          `json:"last_transition_time" bson:"last_transition_time"`
+}
+
+func NewCondition(t ConditionType, status ConditionStatus, reason, message string) Condition {
+	return Condition{Type: t, Status: status, Reason: reason, Message: message}
+}
+
+// Status observed state of a resource, only written by controllers
+type Status struct {
+	ObservedGeneration int64       `json:"observed_generation" bson:"observed_generation"`
+	Conditions         []Condition `json:"conditions" bson:"conditions"`
+}
+
+func (s *Status) GetCondition(t ConditionType) *Condition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == t {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition add or replace the condition of the same type,
+// LastTransitionTime only moves when the condition status changes.
+func (s *Status) SetCondition(condition Condition) {
+	now := time.Now().Format(time.RFC3339)
+	exist := s.GetCondition(condition.Type)
+	if exist == nil {
+		if condition.LastTransitionTime == "" {
+			condition.LastTransitionTime = now
+		}
+		s.Conditions = append(s.Conditions, condition)
+		return
+	}
+	if exist.Status != condition.Status {
+		exist.LastTransitionTime = now
+	}
+	exist.Status = condition.Status
+	exist.Reason = condition.Reason
+	exist.Message = condition.Message
+}
+
+func (s *Status) IsTrue(t ConditionType) bool {
+	condition := s.GetCondition(t)
+	return condition != nil && condition.Status == ConditionTrue
+}
+
+// IStatusObject resource carry a status subresource
+type IStatusObject interface {
+	IObject
+	GetStatus() *Status
+}
+
+var _ IStatusObject = &StatusObject{}
+
+// StatusObject read or write only the status subresource of any resource
+type StatusObject struct {
+	Metadata `json:"metadata"`
+	Status   Status `json:"status"`
+}
+
+func (s *StatusObject) Clone() IObject {
+	result := &StatusObject{}
+	Clone(s, result)
+	return result
+}
+
+func (s *StatusObject) GetStatus() *Status { return &s.Status }
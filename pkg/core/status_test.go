@@ -0,0 +1,30 @@
+package core
+
+import "testing"
+
+func TestStatus_SetCondition(t *testing.T) {
+	status := &Status{}
+	status.SetCondition(NewCondition(ConditionReady, ConditionFalse, "Pending", ""))
+	if len(status.Conditions) != 1 || status.IsTrue(ConditionReady) {
+		t.Fatal("expected a single false ready condition")
+	}
+
+	transition := "2006-01-02T15:04:05Z"
+	status.Conditions[0].LastTransitionTime = transition
+
+	status.SetCondition(NewCondition(ConditionReady, ConditionFalse, "Failed", "boom"))
+	condition := status.GetCondition(ConditionReady)
+	if condition.LastTransitionTime != transition || condition.Reason != "Failed" || condition.Message != "boom" {
+		t.Fatal("expected reason updated without transition")
+	}
+
+	status.SetCondition(NewCondition(ConditionReady, ConditionTrue, "Running", ""))
+	if !status.IsTrue(ConditionReady) || status.GetCondition(ConditionReady).LastTransitionTime == transition {
+		t.Fatal("expected transition time moved")
+	}
+
+	status.SetCondition(NewCondition(ConditionSynced, ConditionTrue, "Applied", ""))
+	if len(status.Conditions) != 2 {
+		t.Fatal("expected two conditions")
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/dict"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"reflect"
+	"strings"
 	"time"
 )
 
@@ -14,6 +17,41 @@ type ErrorType error
 
 var NotFound ErrorType = fmt.Errorf("notFound")
 
+var StatusNotWritable ErrorType = fmt.Errorf("status is only writable through the status subresource")
+
+var NotControllerPath ErrorType = fmt.Errorf("only the status and the spec fields of a controller are writable through the status subresource")
+
+// ProgressPaths the legacy progress reported in the spec. It belongs to the controllers, a change of it alone is not
+// an edit of the spec and does not bump the generation
+var ProgressPaths = []string{"spec.status", "spec.message"}
+
+// CheckControllerPaths the paths given to ApplyStatus are the status or fields of the spec, never the whole spec
+func CheckControllerPaths(paths ...string) error {
+	for _, path := range paths {
+		if path != "status" && !strings.HasPrefix(path, "status.") && !strings.HasPrefix(path, "spec.") {
+			return NotControllerPath
+		}
+	}
+	return nil
+}
+
+// SpecEdited tell whether the spec of after differs from the one of before by more than the progress
+func SpecEdited(before, after map[string]interface{}) bool {
+	return !reflect.DeepEqual(withoutProgress(before), withoutProgress(after))
+}
+
+func withoutProgress(object map[string]interface{}) map[string]interface{} {
+	spec, _ := dict.Get(object, "spec").(map[string]interface{})
+	result := make(map[string]interface{}, len(spec))
+	for key, value := range spec {
+		result[key] = value
+	}
+	for _, path := range ProgressPaths {
+		delete(result, strings.TrimPrefix(path, "spec."))
+	}
+	return result
+}
+
 var coderList = make(map[string]Coder)
 
 func RegistryCoder(res string, coder Coder) { coderList[res] = coder }
@@ -77,6 +115,9 @@ type IStorage interface {
 	Delete(db, table, name, workspace string) error
 	DeleteByIObject(db, table string, object core.IObject) error
 	Apply(db, table, name string, object core.IObject, forceApply bool, paths ...string) (core.IObject, bool, error)
+	// ApplyStatus write only the status subresource, reserved for controllers. Given paths, only those are written,
+	// a controller names there the spec fields it owns. It never bumps the generation
+	ApplyStatus(db, table, name string, object core.IStatusObject, paths ...string) (core.IObject, error)
 	List(db, table, labels string, filterDelete bool) ([]interface{}, error)
 	Get(db, table, name string, result interface{}, filterDelete bool) error
 
@@ -0,0 +1,648 @@
+// Package memory an in-process datasource.IStorage with the semantics of the mongo stage,
+// documents are kept in their bson form so filters use the same keys as on mongo
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/datasource/dict"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	metadataName      = "metadata.name"
+	metadataWorkspace = "metadata.workspace"
+	metadataUUID      = "metadata.uuid"
+	metadataDelete    = "metadata.is_delete"
+	metadataGen       = "metadata.generation"
+	specPath          = "spec"
+	statusPath        = "status"
+)
+
+var _ datasource.IStorage = &Memory{}
+
+type Memory struct {
+	mu       sync.RWMutex
+	tables   map[string][]bson.M
+	watchers map[string][]*watcher
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		tables:   make(map[string][]bson.M),
+		watchers: make(map[string][]*watcher),
+	}
+}
+
+func ns(db, table string) string { return fmt.Sprintf("%s.%s", db, table) }
+
+func toDocument(object interface{}) (bson.M, error) {
+	bs, err := bson.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.M{}
+	if err := bson.Unmarshal(bs, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func decode(doc bson.M, result interface{}) error {
+	bs, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(bs, result)
+}
+
+// lookup the values at the dotted path, arrays on the way are flattened as mongo does
+func lookup(value interface{}, path []string) []interface{} {
+	if len(path) == 0 {
+		if array, ok := value.(primitive.A); ok {
+			return append([]interface{}{value}, array...)
+		}
+		return []interface{}{value}
+	}
+	switch current := value.(type) {
+	case bson.M:
+		next, exist := current[path[0]]
+		if !exist {
+			return nil
+		}
+		return lookup(next, path[1:])
+	case primitive.A:
+		values := make([]interface{}, 0)
+		for _, item := range current {
+			values = append(values, lookup(item, path)...)
+		}
+		return values
+	}
+	return nil
+}
+
+// normalize pass the filter value through bson so it compare with the stored types
+func normalize(value interface{}) interface{} {
+	doc, err := toDocument(bson.M{"v": value})
+	if err != nil {
+		return value
+	}
+	return doc["v"]
+}
+
+func match(doc bson.M, filter map[string]interface{}) bool {
+	for key, value := range filter {
+		expected := normalize(value)
+		values := lookup(doc, strings.Split(key, "."))
+		if len(values) == 0 {
+			if expected == nil {
+				continue
+			}
+			return false
+		}
+		matched := false
+		for _, v := range values {
+			if reflect.DeepEqual(v, expected) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Memory) find(db, table string, filter map[string]interface{}) (int, bson.M) {
+	for index, doc := range m.tables[ns(db, table)] {
+		if match(doc, filter) {
+			return index, doc
+		}
+	}
+	return -1, nil
+}
+
+func (m *Memory) findAll(db, table string, filter map[string]interface{}) []bson.M {
+	result := make([]bson.M, 0)
+	for _, doc := range m.tables[ns(db, table)] {
+		if match(doc, filter) {
+			result = append(result, doc)
+		}
+	}
+	return result
+}
+
+func nameQuery(name string, object core.IObject) map[string]interface{} {
+	query := map[string]interface{}{metadataName: name}
+	if object.GetWorkspace() != "" {
+		query[metadataWorkspace] = object.GetWorkspace()
+	}
+	return query
+}
+
+func (m *Memory) insert(db, table string, doc bson.M) {
+	m.tables[ns(db, table)] = append(m.tables[ns(db, table)], doc)
+	m.notify(db, table, core.ADDED, doc)
+}
+
+func (m *Memory) replace(db, table string, index int, doc bson.M) {
+	m.tables[ns(db, table)][index] = doc
+	m.notify(db, table, core.MODIFIED, doc)
+}
+
+func (m *Memory) remove(db, table string, index int) {
+	docs := m.tables[ns(db, table)]
+	doc := docs[index]
+	m.tables[ns(db, table)] = append(docs[:index:index], docs[index+1:]...)
+	doc = copyDocument(doc)
+	if metadata, ok := doc["metadata"].(bson.M); ok {
+		metadata["is_delete"] = true
+	}
+	m.notify(db, table, core.DELETED, doc)
+}
+
+func copyDocument(doc bson.M) bson.M {
+	copied := bson.M{}
+	_ = decode(doc, &copied)
+	return copied
+}
+
+func (m *Memory) Create(db, table string, object core.IObject) (core.IObject, error) {
+	if datasource.GetCoder(table) == nil {
+		return nil, fmt.Errorf("not register code table %s", table)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	object.SetKind(core.Kind(table))
+	object.GenerateVersion()
+	if index, _ := m.find(db, table, nameQuery(object.GetName(), object)); index >= 0 {
+		return nil, fmt.Errorf("duplicate key %s", object.GetName())
+	}
+	doc, err := toDocument(object)
+	if err != nil {
+		return nil, err
+	}
+	m.insert(db, table, doc)
+	return object, nil
+}
+
+func (m *Memory) Delete(db, table, name, workspace string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	query := map[string]interface{}{metadataName: name}
+	if workspace != "" {
+		query[metadataWorkspace] = workspace
+	}
+	index, _ := m.find(db, table, query)
+	if index < 0 {
+		return nil
+	}
+	m.remove(db, table, index)
+	return nil
+}
+
+func (m *Memory) DeleteByIObject(db, table string, object core.IObject) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	query := nameQuery(object.GetName(), object)
+	if object.GetWorkspace() != "" {
+		query[metadataUUID] = object.GetUUID()
+	}
+	object.Delete()
+	if index, _ := m.find(db, table, query); index >= 0 {
+		m.remove(db, table, index)
+	}
+	return nil
+}
+
+func (m *Memory) Apply(db, table, name string, newObject core.IObject, forceApply bool, paths ...string) (core.IObject, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	query := nameQuery(name, newObject)
+	if newObject.GetUUID() != "" {
+		query[metadataUUID] = newObject.GetUUID()
+	}
+	index, doc := m.find(db, table, query)
+	if index < 0 {
+		newObject.GenerateVersion()
+		newDoc, err := toDocument(newObject)
+		if err != nil {
+			return nil, false, err
+		}
+		m.insert(db, table, newDoc)
+		return newObject, false, nil
+	}
+
+	old := newObject.Clone()
+	if err := decode(doc, old); err != nil {
+		return nil, false, err
+	}
+	before, err := core.ToMap(old)
+	if err != nil {
+		return nil, false, err
+	}
+	oldMap, err := core.ToMap(old)
+	if err != nil {
+		return nil, false, err
+	}
+	newMap, err := core.ToMap(newObject)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(paths) == 0 {
+		paths = []string{specPath}
+	}
+
+	update := false
+	for _, path := range paths {
+		if path == statusPath || strings.HasPrefix(path, statusPath+".") {
+			return nil, false, datasource.StatusNotWritable
+		}
+		if dict.CompareMergeObject(oldMap, newMap, path) {
+			update = true
+		}
+	}
+	if !update && !forceApply {
+		return old, false, nil
+	}
+
+	generation := old.GetGeneration()
+	if datasource.SpecEdited(before, oldMap) {
+		generation++
+	}
+	dict.Set(oldMap, metadataGen, generation)
+
+	if err := core.EncodeFromMap(newObject, oldMap); err != nil {
+		return old, false, err
+	}
+	newObject.GenerateVersion()
+	newDoc, err := toDocument(newObject)
+	if err != nil {
+		return old, true, err
+	}
+	m.replace(db, table, index, newDoc)
+	return newObject, true, nil
+}
+
+func (m *Memory) ApplyStatus(db, table, name string, object core.IStatusObject, paths ...string) (core.IObject, error) {
+	if err := datasource.CheckControllerPaths(paths...); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index, doc := m.find(db, table, nameQuery(name, object))
+	if index < 0 {
+		return nil, datasource.NotFound
+	}
+	if len(paths) > 0 {
+		return m.applyPaths(db, table, index, doc, object, paths)
+	}
+	objectDoc, err := toDocument(object)
+	if err != nil {
+		return nil, err
+	}
+	doc = copyDocument(doc)
+	doc[statusPath] = objectDoc[statusPath]
+	if metadata, ok := doc["metadata"].(bson.M); ok {
+		metadata["version"] = fmt.Sprintf("%d", time.Now().Unix())
+	}
+	m.replace(db, table, index, doc)
+
+	result := object.Clone()
+	if err := decode(doc, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyPaths merge the paths of object into the stored doc, the generation is kept as stored
+func (m *Memory) applyPaths(db, table string, index int, doc bson.M, object core.IStatusObject, paths []string) (core.IObject, error) {
+	current := object.Clone()
+	if err := decode(doc, current); err != nil {
+		return nil, err
+	}
+	currentMap, err := core.ToMap(current)
+	if err != nil {
+		return nil, err
+	}
+	objectMap, err := core.ToMap(object)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		dict.Set(currentMap, path, dict.Get(objectMap, path))
+	}
+	if err := core.EncodeFromMap(current, currentMap); err != nil {
+		return nil, err
+	}
+	current.GenerateVersion()
+	newDoc, err := toDocument(current)
+	if err != nil {
+		return nil, err
+	}
+	m.replace(db, table, index, newDoc)
+	return current, nil
+}
+
+func filterWithDelete(filter map[string]interface{}, filterDelete bool) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range filter {
+		result[key] = value
+	}
+	if filterDelete {
+		result[metadataDelete] = false
+	}
+	return result
+}
+
+func (m *Memory) List(db, table, labels string, filterDelete bool) ([]interface{}, error) {
+	filter := make(map[string]interface{})
+	for _, item := range strings.Split(labels, ",") {
+		keyValue := strings.FieldsFunc(item, func(r rune) bool { return r == ':' || r == '=' })
+		if len(keyValue) == 2 {
+			filter[keyValue[0]] = keyValue[1]
+		}
+	}
+	return m.ListByFilter(db, table, filter, filterDelete)
+}
+
+func (m *Memory) Get(db, table, name string, result interface{}, filterDelete bool) error {
+	return m.GetByFilter(db, table, result, map[string]interface{}{metadataName: name}, filterDelete)
+}
+
+func (m *Memory) GetByMetadataUUID(db, table, uuid string, result interface{}, filterDelete bool) error {
+	return m.GetByFilter(db, table, result, map[string]interface{}{metadataUUID: uuid}, filterDelete)
+}
+
+func (m *Memory) GetByFilter(db, table string, result interface{}, filter map[string]interface{}, filterDelete bool) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	index, doc := m.find(db, table, filterWithDelete(filter, filterDelete))
+	if index < 0 {
+		return datasource.NotFound
+	}
+	return decode(doc, result)
+}
+
+func (m *Memory) DeleteByUUID(db, table, uuid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if index, _ := m.find(db, table, map[string]interface{}{metadataUUID: uuid}); index >= 0 {
+		m.remove(db, table, index)
+	}
+	return nil
+}
+
+func (m *Memory) ListToObject(db, table string, filter map[string]interface{}, result interface{}, filterDelete bool) error {
+	m.mu.RLock()
+	docs := m.findAll(db, table, filterWithDelete(filter, filterDelete))
+	m.mu.RUnlock()
+
+	resultValue := reflect.ValueOf(result)
+	if resultValue.Kind() != reflect.Ptr || resultValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("result argument must be a slice address")
+	}
+	sliceValue := resultValue.Elem()
+	sliceValue.Set(sliceValue.Slice(0, 0))
+	for _, doc := range docs {
+		item := reflect.New(sliceValue.Type().Elem())
+		if err := decode(doc, item.Interface()); err != nil {
+			return err
+		}
+		sliceValue.Set(reflect.Append(sliceValue, item.Elem()))
+	}
+	return nil
+}
+
+func (m *Memory) ListByFilter(db, table string, filter map[string]interface{}, filterDelete bool) ([]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := make([]interface{}, 0)
+	for _, doc := range m.findAll(db, table, filterWithDelete(filter, filterDelete)) {
+		results = append(results, copyDocument(doc))
+	}
+	return results, nil
+}
+
+func (m *Memory) InsertUnique(db, table string, id interface{}, data interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if index, _ := m.find(db, table, map[string]interface{}{"_id": id}); index >= 0 {
+		return nil
+	}
+	doc, err := toDocument(bson.M{"_id": id, "data": data})
+	if err != nil {
+		return err
+	}
+	m.insert(db, table, doc)
+	return nil
+}
+
+func (m *Memory) GetById(db, table, id string, result interface{}) error {
+	return m.GetByFilter(db, table, result, map[string]interface{}{"_id": id}, false)
+}
+
+func (m *Memory) Bulk(db, table string, objects []core.IObject) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, object := range objects {
+		doc, err := toDocument(object)
+		if err != nil {
+			return err
+		}
+		m.insert(db, table, doc)
+	}
+	return nil
+}
+
+func (m *Memory) RemoveTable(db, table string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tables, ns(db, table))
+	return nil
+}
+
+func versionMatch(doc bson.M, resourceVersion string) bool {
+	if resourceVersion == "" {
+		return false
+	}
+	values := lookup(doc, []string{"metadata", "version"})
+	if len(values) == 0 {
+		return false
+	}
+	version, ok := values[0].(string)
+	return ok && version > resourceVersion
+}
+
+func fieldMatch(doc bson.M, filters []datasource.Filter) bool {
+	for _, filter := range filters {
+		if !match(doc, map[string]interface{}{filter.Key: filter.Value}) {
+			return false
+		}
+	}
+	return true
+}
+
+// WatchEvent replay the documents newer than resourceVersion then follow the changes of the table
+func (m *Memory) WatchEvent(ctx context.Context, db, table string, resourceVersion string, filters ...datasource.Filter) (<-chan core.Event, error) {
+	m.mu.Lock()
+	w := newWatcher(filters)
+	for _, doc := range m.tables[ns(db, table)] {
+		if versionMatch(doc, resourceVersion) && fieldMatch(doc, filters) {
+			w.push(core.ADDED, doc)
+		}
+	}
+	m.watchers[ns(db, table)] = append(m.watchers[ns(db, table)], w)
+	m.mu.Unlock()
+
+	result := make(chan core.Event)
+	go func() {
+		defer close(result)
+		defer m.unwatch(db, table, w)
+		for {
+			event, ok := w.pop(ctx)
+			if !ok {
+				return
+			}
+			select {
+			case result <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return result, nil
+}
+
+func (m *Memory) Watch(db, table string, resourceVersion string, watch datasource.WatchInterface, filters ...datasource.Filter) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, _ := m.WatchEvent(ctx, db, table, resourceVersion, filters...)
+	go func() {
+		defer cancel()
+		for {
+			select {
+			case <-watch.CloseStop():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				data, err := core.ToMap(event.Object)
+				if err != nil {
+					watch.ErrorStop() <- err
+					return
+				}
+				if err := watch.Handle(data); err != nil {
+					watch.ErrorStop() <- err
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (m *Memory) unwatch(db, table string, w *watcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	watchers := m.watchers[ns(db, table)]
+	for index := range watchers {
+		if watchers[index] == w {
+			m.watchers[ns(db, table)] = append(watchers[:index:index], watchers[index+1:]...)
+			return
+		}
+	}
+}
+
+// notify must be called with the lock held
+func (m *Memory) notify(db, table string, eventType core.EventType, doc bson.M) {
+	for _, w := range m.watchers[ns(db, table)] {
+		if fieldMatch(doc, w.filters) {
+			w.push(eventType, doc)
+		}
+	}
+}
+
+// watcher an unbounded queue so writers never block on slow readers
+type watcher struct {
+	filters []datasource.Filter
+	mu      sync.Mutex
+	queue   []core.Event
+	signal  chan struct{}
+}
+
+func newWatcher(filters []datasource.Filter) *watcher {
+	return &watcher{filters: filters, signal: make(chan struct{}, 1)}
+}
+
+func (w *watcher) push(eventType core.EventType, doc bson.M) {
+	if eventType == core.MODIFIED {
+		if isDelete, ok := lookupBool(doc, "metadata", "is_delete"); ok && isDelete {
+			eventType = core.DELETED
+		}
+	}
+	if eventType == core.ADDED {
+		if isDelete, ok := lookupBool(doc, "metadata", "is_delete"); ok && isDelete {
+			return
+		}
+	}
+	object := &core.DefaultObject{}
+	if err := decodeDefault(doc, object); err != nil {
+		return
+	}
+	w.mu.Lock()
+	w.queue = append(w.queue, core.Event{Type: eventType, Object: object})
+	w.mu.Unlock()
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) pop(ctx context.Context) (core.Event, bool) {
+	for {
+		w.mu.Lock()
+		if len(w.queue) > 0 {
+			event := w.queue[0]
+			w.queue = w.queue[1:]
+			w.mu.Unlock()
+			return event, true
+		}
+		w.mu.Unlock()
+		select {
+		case <-w.signal:
+		case <-ctx.Done():
+			return core.Event{}, false
+		}
+	}
+}
+
+func lookupBool(doc bson.M, path ...string) (bool, bool) {
+	values := lookup(doc, path)
+	if len(values) == 0 {
+		return false, false
+	}
+	value, ok := values[0].(bool)
+	return value, ok
+}
+
+// decodeDefault decode as the mongo change stream does, through the json form of the document
+func decodeDefault(doc bson.M, object *core.DefaultObject) error {
+	data, err := core.ToMap(doc)
+	if err != nil {
+		return err
+	}
+	return core.UnmarshalToIObject(data, object)
+}
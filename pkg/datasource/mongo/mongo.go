@@ -26,6 +26,10 @@ const (
 	metadataWorkspace = "metadata.workspace"
 	metadataUUID      = "metadata.uuid"
 	metadataDelete    = "metadata.is_delete"
+	metadataVersion   = "metadata.version"
+	metadataGen       = "metadata.generation"
+	specPath          = "spec"
+	statusPath        = "status"
 )
 
 var _ datasource.IStorage = &Mongo{}
@@ -497,6 +501,11 @@ func (m *Mongo) Apply(db, table, name string, newObject core.IObject, forceApply
 		return nil, false, err
 	}
 
+	before, err := core.ToMap(old)
+	if err != nil {
+		return nil, false, err
+	}
+
 	oldMap, err := core.ToMap(old)
 	if err != nil {
 		return nil, false, err
@@ -508,10 +517,13 @@ func (m *Mongo) Apply(db, table, name string, newObject core.IObject, forceApply
 	}
 
 	if len(paths) == 0 {
-		paths = []string{"spec"}
+		paths = []string{specPath}
 	}
 
 	for _, path := range paths {
+		if path == statusPath || strings.HasPrefix(path, statusPath+".") {
+			return nil, false, datasource.StatusNotWritable
+		}
 		if dict.CompareMergeObject(oldMap, newMap, path) {
 			update = true
 		}
@@ -521,6 +533,12 @@ func (m *Mongo) Apply(db, table, name string, newObject core.IObject, forceApply
 		return old, false, nil
 	}
 
+	generation := old.GetGeneration()
+	if datasource.SpecEdited(before, oldMap) {
+		generation++
+	}
+	dict.Set(oldMap, metadataGen, generation)
+
 	if err := core.EncodeFromMap(newObject, oldMap); err != nil {
 		return old, false, err
 	}
@@ -540,6 +558,79 @@ func (m *Mongo) Apply(db, table, name string, newObject core.IObject, forceApply
 	return newObject, true, nil
 }
 
+func (m *Mongo) ApplyStatus(db, table, name string, object core.IStatusObject, paths ...string) (core.IObject, error) {
+	if err := datasource.CheckControllerPaths(paths...); err != nil {
+		return nil, err
+	}
+	var query = bson.M{metadataName: name}
+	if object.GetWorkspace() != "" {
+		query[metadataWorkspace] = object.GetWorkspace()
+	}
+	if len(paths) > 0 {
+		return m.applyPaths(db, table, query, object, paths)
+	}
+
+	objectMap, err := core.ToMap(object)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			statusPath:      objectMap[statusPath],
+			metadataVersion: fmt.Sprintf("%d", time.Now().Unix()),
+		},
+	}
+	after := options.After
+	singleResult := m.client.Database(db).Collection(table).FindOneAndUpdate(m.ctx, query, update,
+		&options.FindOneAndUpdateOptions{ReturnDocument: &after},
+	)
+	if singleResult.Err() == mongo.ErrNoDocuments {
+		return nil, datasource.NotFound
+	}
+
+	result := object.Clone()
+	if err := singleResult.Decode(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyPaths merge the paths of object into the stored document, the field names of the spec are the json ones so
+// the document is replaced rather than set field by field. The generation is kept as stored
+func (m *Mongo) applyPaths(db, table string, query bson.M, object core.IStatusObject, paths []string) (core.IObject, error) {
+	singleResult := m.client.Database(db).Collection(table).FindOne(m.ctx, query)
+	if singleResult.Err() == mongo.ErrNoDocuments {
+		return nil, datasource.NotFound
+	}
+	current := object.Clone()
+	if err := singleResult.Decode(current); err != nil {
+		return nil, err
+	}
+
+	currentMap, err := core.ToMap(current)
+	if err != nil {
+		return nil, err
+	}
+	objectMap, err := core.ToMap(object)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		dict.Set(currentMap, path, dict.Get(objectMap, path))
+	}
+	if err := core.EncodeFromMap(current, currentMap); err != nil {
+		return nil, err
+	}
+
+	current.GenerateVersion()
+	query[metadataUUID] = current.GetUUID()
+	if _, err := m.client.Database(db).Collection(table).ReplaceOne(m.ctx, query, current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
 func (m *Mongo) DeleteByIObject(db, table string, object core.IObject) error {
 	query := bson.M{metadataName: object.GetName()}
 	if object.GetWorkspace() != "" {
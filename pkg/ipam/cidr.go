@@ -0,0 +1,117 @@
+// Package ipam validate the cidr of vpcs and vswitches and hand out their private addresses
+package ipam
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// MaxVSwitchPrefix a vswitch smaller than /29 has no room left once the reserved addresses are taken
+const MaxVSwitchPrefix = 29
+
+// ParseCIDR the ipv4 network of the ip and mask stored on vpcs and vswitches,
+// mask is a prefix length or a dotted mask, ip may carry the prefix itself
+func ParseCIDR(ip, mask string) (*net.IPNet, error) {
+	cidr := ip
+	if !strings.Contains(ip, "/") {
+		prefix, err := prefixOf(mask)
+		if err != nil {
+			return nil, err
+		}
+		cidr = fmt.Sprintf("%s/%d", ip, prefix)
+	}
+
+	address, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cidr %s", cidr)
+	}
+	if address.To4() == nil {
+		return nil, fmt.Errorf("cidr %s is not ipv4", cidr)
+	}
+	if !address.Equal(network.IP) {
+		return nil, fmt.Errorf("ip %s is not the network address of %s", ip, network)
+	}
+	network.IP = network.IP.To4()
+	return network, nil
+}
+
+func prefixOf(mask string) (int, error) {
+	if mask == "" {
+		return 0, fmt.Errorf("mask is required")
+	}
+	if prefix, err := strconv.Atoi(strings.TrimPrefix(mask, "/")); err == nil {
+		if prefix < 0 || prefix > 32 {
+			return 0, fmt.Errorf("invalid mask %s", mask)
+		}
+		return prefix, nil
+	}
+	dotted := net.ParseIP(mask).To4()
+	if dotted == nil {
+		return 0, fmt.Errorf("invalid mask %s", mask)
+	}
+	prefix, bits := net.IPMask(dotted).Size()
+	if bits == 0 {
+		return 0, fmt.Errorf("non contiguous mask %s", mask)
+	}
+	return prefix, nil
+}
+
+// Overlap whether the two networks share any address
+func Overlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// Contains whether inner lies entirely inside outer
+func Contains(outer, inner *net.IPNet) bool {
+	outerPrefix, _ := outer.Mask.Size()
+	innerPrefix, _ := inner.Mask.Size()
+	return outerPrefix <= innerPrefix && outer.Contains(inner.IP)
+}
+
+// Reserved the network, gateway and broadcast addresses of network, never handed out
+func Reserved(network *net.IPNet) []net.IP {
+	first, last := bounds(network)
+	return []net.IP{toIP(first), toIP(first + 1), toIP(last)}
+}
+
+func isReserved(network *net.IPNet, ip net.IP) bool {
+	for _, reserved := range Reserved(network) {
+		if reserved.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Size the number of addresses of network
+func Size(network *net.IPNet) int {
+	first, last := bounds(network)
+	return int(last-first) + 1
+}
+
+// hosts the addresses of network that can be handed out, in order
+func hosts(network *net.IPNet) []net.IP {
+	first, last := bounds(network)
+	if last-first < 3 {
+		return nil
+	}
+	result := make([]net.IP, 0, last-first-2)
+	for value := first + 2; value < last; value++ {
+		result = append(result, toIP(value))
+	}
+	return result
+}
+
+func bounds(network *net.IPNet) (uint32, uint32) {
+	first := binary.BigEndian.Uint32(network.IP.To4())
+	return first, first | ^binary.BigEndian.Uint32(net.IP(network.Mask).To4())
+}
+
+func toIP(value uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, value)
+	return ip
+}
@@ -0,0 +1,303 @@
+package ipam
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+// Storage the part of the datasource ipam works on, satisfied by both the stage and the services
+type Storage interface {
+	Create(db, table string, object core.IObject) (core.IObject, error)
+	Delete(db, table, name, workspace string) error
+	GetByFilter(db, table string, result interface{}, filter map[string]interface{}, filterDelete bool) error
+	ListToObject(db, table string, filter map[string]interface{}, result interface{}, filterDelete bool) error
+}
+
+type IPAM struct {
+	stage Storage
+}
+
+func New(stage Storage) *IPAM {
+	return &IPAM{stage: stage}
+}
+
+// Usage the addresses of a vswitch
+type Usage struct {
+	VSwitch     string                    `json:"vswitch"`
+	CIDR        string                    `json:"cidr"`
+	Total       int                       `json:"total"`
+	Reserved    []string                  `json:"reserved"`
+	Allocated   int                       `json:"allocated"`
+	Available   int                       `json:"available"`
+	Allocations []networking.IPAllocation `json:"allocations"`
+}
+
+// ValidateVPC the cidr of vpc must not overlap another vpc of the same tenant
+func (i *IPAM) ValidateVPC(vpc *networking.VirtualPrivateCloud) error {
+	network, err := ParseCIDR(vpc.Spec.IP, vpc.Spec.Mask)
+	if err != nil {
+		return fmt.Errorf("vpc %s %v", vpc.GetName(), err)
+	}
+
+	vpcs := make([]networking.VirtualPrivateCloud, 0)
+	if err := i.stage.ListToObject(common.DefaultDatabase, common.VPC, scopeOf(vpc.Metadata), &vpcs, true); err != nil {
+		return err
+	}
+	for _, other := range vpcs {
+		if other.GetName() == vpc.GetName() {
+			continue
+		}
+		otherNetwork, err := ParseCIDR(other.Spec.IP, other.Spec.Mask)
+		if err != nil {
+			continue
+		}
+		if Overlap(network, otherNetwork) {
+			return fmt.Errorf("vpc %s cidr %s overlaps vpc %s cidr %s", vpc.GetName(), network, other.GetName(), otherNetwork)
+		}
+	}
+	return nil
+}
+
+// ValidateVSwitch the cidr of vswitch must lie inside its vpc and not overlap the other vswitches of the vpc
+func (i *IPAM) ValidateVSwitch(vswitch *networking.Vswitch) error {
+	network, err := ParseCIDR(vswitch.Spec.IP, vswitch.Spec.Mask)
+	if err != nil {
+		return fmt.Errorf("vswitch %s %v", vswitch.GetName(), err)
+	}
+	if prefix, _ := network.Mask.Size(); prefix > MaxVSwitchPrefix {
+		return fmt.Errorf("vswitch %s cidr %s is smaller than /%d", vswitch.GetName(), network, MaxVSwitchPrefix)
+	}
+
+	vpc, err := i.VPCOf(vswitch.GetWorkspace(), vswitch.Spec.VpcId)
+	if err != nil {
+		return err
+	}
+	vpcNetwork, err := ParseCIDR(vpc.Spec.IP, vpc.Spec.Mask)
+	if err != nil {
+		return fmt.Errorf("vpc %s %v", vpc.GetName(), err)
+	}
+	if !Contains(vpcNetwork, network) {
+		return fmt.Errorf("vswitch %s cidr %s is outside vpc %s cidr %s", vswitch.GetName(), network, vpc.GetName(), vpcNetwork)
+	}
+
+	siblings, err := i.vswitchesOf(vswitch.GetWorkspace(), vpc)
+	if err != nil {
+		return err
+	}
+	for _, other := range siblings {
+		if other.GetName() == vswitch.GetName() {
+			continue
+		}
+		otherNetwork, err := ParseCIDR(other.Spec.IP, other.Spec.Mask)
+		if err != nil {
+			continue
+		}
+		if Overlap(network, otherNetwork) {
+			return fmt.Errorf("vswitch %s cidr %s overlaps vswitch %s cidr %s", vswitch.GetName(), network, other.GetName(), otherNetwork)
+		}
+	}
+	return nil
+}
+
+// VPCOf the vpc of workspace whose vendor id or name is id
+func (i *IPAM) VPCOf(workspace, id string) (*networking.VirtualPrivateCloud, error) {
+	vpc := &networking.VirtualPrivateCloud{}
+	for _, key := range []string{"spec.id", common.FilterName} {
+		filter := map[string]interface{}{key: id}
+		if workspace != "" {
+			filter[common.FilterWorkspace] = workspace
+		}
+		if err := i.stage.GetByFilter(common.DefaultDatabase, common.VPC, vpc, filter, true); err == nil {
+			return vpc, nil
+		}
+	}
+	return nil, fmt.Errorf("vpc %s not found", id)
+}
+
+// VSwitchOf the vswitch of workspace whose vendor id or name is id
+func (i *IPAM) VSwitchOf(workspace, id string) (*networking.Vswitch, error) {
+	vswitch := &networking.Vswitch{}
+	for _, key := range []string{"spec.id", common.FilterName} {
+		filter := map[string]interface{}{key: id}
+		if workspace != "" {
+			filter[common.FilterWorkspace] = workspace
+		}
+		if err := i.stage.GetByFilter(common.DefaultDatabase, common.VSWITCH, vswitch, filter, true); err == nil {
+			return vswitch, nil
+		}
+	}
+	return nil, fmt.Errorf("vswitch %s not found", id)
+}
+
+func (i *IPAM) vswitchesOf(workspace string, vpc *networking.VirtualPrivateCloud) ([]networking.Vswitch, error) {
+	ids := []string{vpc.GetName()}
+	if vpc.Spec.ID != "" && vpc.Spec.ID != vpc.GetName() {
+		ids = append(ids, vpc.Spec.ID)
+	}
+
+	result := make([]networking.Vswitch, 0)
+	for _, id := range ids {
+		filter := map[string]interface{}{"spec.vpc_id": id}
+		if workspace != "" {
+			filter[common.FilterWorkspace] = workspace
+		}
+		vswitches := make([]networking.Vswitch, 0)
+		if err := i.stage.ListToObject(common.DefaultDatabase, common.VSWITCH, filter, &vswitches, true); err != nil {
+			return nil, err
+		}
+		result = append(result, vswitches...)
+	}
+	return result, nil
+}
+
+// Allocate an address of vswitch to the owner, address is claimed when given,
+// otherwise the first free one is; an owner already holding an address of vswitch keeps it
+func (i *IPAM) Allocate(vswitch *networking.Vswitch, ownerKind, owner, address string) (string, error) {
+	network, err := ParseCIDR(vswitch.Spec.IP, vswitch.Spec.Mask)
+	if err != nil {
+		return "", fmt.Errorf("vswitch %s %v", vswitch.GetName(), err)
+	}
+
+	allocations, err := i.allocationsOf(vswitch)
+	if err != nil {
+		return "", err
+	}
+	used := make(map[string]bool)
+	for _, allocation := range allocations {
+		if allocation.Spec.OwnerKind == ownerKind && allocation.Spec.Owner == owner &&
+			(address == "" || address == allocation.Spec.Address) {
+			return allocation.Spec.Address, nil
+		}
+		used[allocation.Spec.Address] = true
+	}
+
+	if address != "" {
+		ip := net.ParseIP(address).To4()
+		if ip == nil || !network.Contains(ip) {
+			return "", fmt.Errorf("address %s is outside vswitch %s cidr %s", address, vswitch.GetName(), network)
+		}
+		if isReserved(network, ip) {
+			return "", fmt.Errorf("address %s is reserved in vswitch %s", address, vswitch.GetName())
+		}
+		if used[address] {
+			return "", fmt.Errorf("address %s of vswitch %s is already allocated", address, vswitch.GetName())
+		}
+		if err := i.claim(vswitch, ownerKind, owner, address); err != nil {
+			return "", fmt.Errorf("claim address %s of vswitch %s error %v", address, vswitch.GetName(), err)
+		}
+		return address, nil
+	}
+
+	// another allocation may claim the same address between the listing and the claim, move on to the next
+	for _, ip := range hosts(network) {
+		if used[ip.String()] {
+			continue
+		}
+		if err := i.claim(vswitch, ownerKind, owner, ip.String()); err != nil {
+			continue
+		}
+		return ip.String(), nil
+	}
+	return "", fmt.Errorf("vswitch %s has no free address", vswitch.GetName())
+}
+
+// claim create the allocation of address, storage refuses a second allocation of the same name
+func (i *IPAM) claim(vswitch *networking.Vswitch, ownerKind, owner, address string) error {
+	allocation := &networking.IPAllocation{
+		Metadata: core.Metadata{
+			Name:      allocationName(vswitch.GetName(), address),
+			Kind:      networking.IPAllocationKind,
+			Tenant:    vswitch.Tenant,
+			Workspace: vswitch.GetWorkspace(),
+		},
+		Spec: networking.IPAllocationSpec{
+			VSwitch:   vswitch.GetName(),
+			VpcId:     vswitch.Spec.VpcId,
+			Address:   address,
+			OwnerKind: ownerKind,
+			Owner:     owner,
+		},
+	}
+	_, err := i.stage.Create(common.DefaultDatabase, common.IPALLOCATION, allocation)
+	return err
+}
+
+// Release the address of vswitch
+func (i *IPAM) Release(vswitch *networking.Vswitch, address string) error {
+	return i.stage.Delete(common.DefaultDatabase, common.IPALLOCATION, allocationName(vswitch.GetName(), address), vswitch.GetWorkspace())
+}
+
+// ReleaseOwner release every address held by the owner in workspace
+func (i *IPAM) ReleaseOwner(workspace, ownerKind, owner string) error {
+	filter := map[string]interface{}{"spec.owner_kind": ownerKind, "spec.owner": owner}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	allocations := make([]networking.IPAllocation, 0)
+	if err := i.stage.ListToObject(common.DefaultDatabase, common.IPALLOCATION, filter, &allocations, true); err != nil {
+		return err
+	}
+	for _, allocation := range allocations {
+		if err := i.stage.Delete(common.DefaultDatabase, common.IPALLOCATION, allocation.GetName(), allocation.GetWorkspace()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Usage the total, reserved and allocated addresses of vswitch
+func (i *IPAM) Usage(vswitch *networking.Vswitch) (*Usage, error) {
+	network, err := ParseCIDR(vswitch.Spec.IP, vswitch.Spec.Mask)
+	if err != nil {
+		return nil, fmt.Errorf("vswitch %s %v", vswitch.GetName(), err)
+	}
+	allocations, err := i.allocationsOf(vswitch)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{
+		VSwitch:     vswitch.GetName(),
+		CIDR:        network.String(),
+		Total:       Size(network),
+		Allocated:   len(allocations),
+		Allocations: allocations,
+	}
+	for _, ip := range Reserved(network) {
+		usage.Reserved = append(usage.Reserved, ip.String())
+	}
+	usage.Available = len(hosts(network)) - usage.Allocated
+	return usage, nil
+}
+
+func (i *IPAM) allocationsOf(vswitch *networking.Vswitch) ([]networking.IPAllocation, error) {
+	filter := map[string]interface{}{"spec.vswitch": vswitch.GetName()}
+	if vswitch.GetWorkspace() != "" {
+		filter[common.FilterWorkspace] = vswitch.GetWorkspace()
+	}
+	allocations := make([]networking.IPAllocation, 0)
+	if err := i.stage.ListToObject(common.DefaultDatabase, common.IPALLOCATION, filter, &allocations, true); err != nil {
+		return nil, err
+	}
+	return allocations, nil
+}
+
+func allocationName(vswitch, address string) string {
+	return fmt.Sprintf("%s-%s", vswitch, strings.ReplaceAll(address, ".", "-"))
+}
+
+// scopeOf the tenant the cidr of a vpc must be unique in, the workspace when no tenant is recorded
+func scopeOf(metadata core.Metadata) map[string]interface{} {
+	if metadata.Tenant != "" {
+		return map[string]interface{}{"metadata.tenant": metadata.Tenant}
+	}
+	if metadata.Workspace != "" {
+		return map[string]interface{}{common.FilterWorkspace: metadata.Workspace}
+	}
+	return map[string]interface{}{}
+}
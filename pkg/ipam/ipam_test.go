@@ -0,0 +1,145 @@
+package ipam
+
+import (
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+func TestParseCIDR(t *testing.T) {
+	for _, mask := range []string{"16", "/16", "255.255.0.0"} {
+		network, err := ParseCIDR("10.0.0.0", mask)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if network.String() != "10.0.0.0/16" {
+			t.Fatalf("expected 10.0.0.0/16 of mask %s, got %s", mask, network)
+		}
+	}
+	if network, err := ParseCIDR("10.0.0.0/24", ""); err != nil || network.String() != "10.0.0.0/24" {
+		t.Fatalf("expected 10.0.0.0/24, got %v %v", network, err)
+	}
+	for _, item := range [][2]string{{"10.0.0.1", "24"}, {"10.0.0.0", "255.0.255.0"}, {"10.0.0.0", ""}, {"fd00::", "64"}} {
+		if _, err := ParseCIDR(item[0], item[1]); err == nil {
+			t.Fatalf("expected %s mask %q to be refused", item[0], item[1])
+		}
+	}
+}
+
+func TestReserved(t *testing.T) {
+	network, _ := ParseCIDR("192.168.1.0", "24")
+	reserved := Reserved(network)
+	if reserved[0].String() != "192.168.1.0" || reserved[1].String() != "192.168.1.1" || reserved[2].String() != "192.168.1.255" {
+		t.Fatalf("unexpected reserved addresses %v", reserved)
+	}
+	if Size(network) != 256 || len(hosts(network)) != 253 {
+		t.Fatalf("expected 256 addresses and 253 hosts, got %d %d", Size(network), len(hosts(network)))
+	}
+}
+
+func newIPAM(t *testing.T) *IPAM {
+	stage := memory.NewMemory()
+	vpc := &networking.VirtualPrivateCloud{Metadata: core.Metadata{Name: "vpc1", Workspace: "ws"}}
+	vpc.Spec = networking.VirtualPrivateCloudSpec{IP: "10.0.0.0", Mask: "16", ID: "vpc-1"}
+	if _, err := stage.Create(common.DefaultDatabase, common.VPC, vpc); err != nil {
+		t.Fatal(err)
+	}
+	vswitch := &networking.Vswitch{Metadata: core.Metadata{Name: "vsw1", Workspace: "ws"}}
+	vswitch.Spec = networking.VSwitchSpec{IP: "10.0.1.0", Mask: "29", VpcId: "vpc-1", Id: "vsw-1"}
+	if _, err := stage.Create(common.DefaultDatabase, common.VSWITCH, vswitch); err != nil {
+		t.Fatal(err)
+	}
+	return New(stage)
+}
+
+func TestValidate(t *testing.T) {
+	i := newIPAM(t)
+
+	vpc := &networking.VirtualPrivateCloud{Metadata: core.Metadata{Name: "vpc2", Workspace: "ws"}}
+	vpc.Spec = networking.VirtualPrivateCloudSpec{IP: "10.0.128.0", Mask: "17"}
+	if err := i.ValidateVPC(vpc); err == nil {
+		t.Fatal("expected overlapping vpc to be refused")
+	}
+	vpc.Workspace = "other"
+	if err := i.ValidateVPC(vpc); err != nil {
+		t.Fatalf("expected vpc of another tenant to be accepted, got %v", err)
+	}
+
+	vswitch := &networking.Vswitch{Metadata: core.Metadata{Name: "vsw2", Workspace: "ws"}}
+	vswitch.Spec = networking.VSwitchSpec{IP: "10.1.0.0", Mask: "24", VpcId: "vpc1"}
+	if err := i.ValidateVSwitch(vswitch); err == nil {
+		t.Fatal("expected vswitch outside its vpc to be refused")
+	}
+	vswitch.Spec.IP, vswitch.Spec.Mask = "10.0.0.0", "23"
+	if err := i.ValidateVSwitch(vswitch); err == nil {
+		t.Fatal("expected vswitch overlapping vsw1 to be refused")
+	}
+	vswitch.Spec.IP, vswitch.Spec.Mask = "10.0.2.0", "30"
+	if err := i.ValidateVSwitch(vswitch); err == nil {
+		t.Fatal("expected vswitch smaller than /29 to be refused")
+	}
+	vswitch.Spec.Mask = "24"
+	if err := i.ValidateVSwitch(vswitch); err != nil {
+		t.Fatalf("expected vswitch to be accepted, got %v", err)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	i := newIPAM(t)
+	vswitch, err := i.VSwitchOf("ws", "vsw-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := i.Allocate(vswitch, "virtualmachine", "vm0", "10.0.1.1"); err == nil {
+		t.Fatal("expected the gateway address to be refused")
+	}
+	if _, err := i.Allocate(vswitch, "virtualmachine", "vm0", "10.0.2.4"); err == nil {
+		t.Fatal("expected an address outside the vswitch to be refused")
+	}
+
+	address, err := i.Allocate(vswitch, "virtualmachine", "vm0", "10.0.1.4")
+	if err != nil || address != "10.0.1.4" {
+		t.Fatalf("expected 10.0.1.4, got %s %v", address, err)
+	}
+	if _, err := i.Allocate(vswitch, "networkinterface", "eni0", "10.0.1.4"); err == nil {
+		t.Fatal("expected an allocated address to be refused")
+	}
+	if again, _ := i.Allocate(vswitch, "virtualmachine", "vm0", ""); again != "10.0.1.4" {
+		t.Fatalf("expected vm0 to keep 10.0.1.4, got %s", again)
+	}
+
+	// a /29 has 5 usable addresses once the network, gateway and broadcast are reserved
+	allocated := map[string]bool{address: true}
+	for _, owner := range []string{"vm1", "vm2", "vm3", "vm4"} {
+		address, err := i.Allocate(vswitch, "virtualmachine", owner, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allocated[address] {
+			t.Fatalf("address %s allocated twice", address)
+		}
+		allocated[address] = true
+	}
+	if _, err := i.Allocate(vswitch, "virtualmachine", "vm5", ""); err == nil {
+		t.Fatal("expected a full vswitch to be refused")
+	}
+
+	usage, err := i.Usage(vswitch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Total != 8 || usage.Allocated != 5 || usage.Available != 0 || len(usage.Reserved) != 3 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+
+	if err := i.ReleaseOwner("ws", "virtualmachine", "vm0"); err != nil {
+		t.Fatal(err)
+	}
+	if address, err := i.Allocate(vswitch, "virtualmachine", "vm5", ""); err != nil || address != "10.0.1.4" {
+		t.Fatalf("expected the released 10.0.1.4, got %s %v", address, err)
+	}
+}
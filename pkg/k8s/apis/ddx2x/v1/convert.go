@@ -0,0 +1,412 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sjson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// ToUnstructured convert a typed object for the dynamic client
+func ToUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: data}, nil
+}
+
+// FromUnstructured decode the object of a south event into out
+func FromUnstructured(obj runtime.Object, out runtime.Object) error {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, out)
+	}
+	bs, err := k8sjson.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return k8sjson.Unmarshal(bs, out)
+}
+
+func objectMeta(metadata core.Metadata) metav1.ObjectMeta {
+	var labels map[string]string
+	if len(metadata.Labels) > 0 {
+		labels = make(map[string]string, len(metadata.Labels))
+		for k, v := range metadata.Labels {
+			labels[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	return metav1.ObjectMeta{
+		Name:      metadata.Name,
+		Namespace: metadata.Namespace,
+		Labels:    labels,
+	}
+}
+
+// metadata the workspace of a south object is carried by its workspace label
+func metadata(kind string, meta metav1.ObjectMeta) core.Metadata {
+	labels := make(map[string]interface{}, len(meta.Labels))
+	for k, v := range meta.Labels {
+		labels[k] = v
+	}
+	workspace := meta.Labels["workspace"]
+	if workspace == "" {
+		workspace = common.DefaultWorkspace
+	}
+	return core.Metadata{
+		Name:      meta.Name,
+		Namespace: meta.Namespace,
+		Workspace: workspace,
+		Kind:      core.Kind(kind),
+		Labels:    labels,
+	}
+}
+
+func ToVPC(vpc *networking.VirtualPrivateCloud) *VPC {
+	return &VPC{
+		TypeMeta:   typeMeta(VPCKind),
+		ObjectMeta: objectMeta(vpc.Metadata),
+		Spec: VPCSpec{
+			LocalName: vpc.Spec.LocalName,
+			IP:        vpc.Spec.IP,
+			Mask:      vpc.Spec.Mask,
+			RegionId:  vpc.Spec.Region,
+			VpcId:     vpc.Spec.ID,
+			Status:    vpc.Spec.Status,
+			Message:   vpc.Spec.Message,
+		},
+	}
+}
+
+func (in *VPC) ToResource() *networking.VirtualPrivateCloud {
+	return &networking.VirtualPrivateCloud{
+		Metadata: metadata(in.Kind, in.ObjectMeta),
+		Spec: networking.VirtualPrivateCloudSpec{
+			LocalName: in.Spec.LocalName,
+			IP:        in.Spec.IP,
+			Mask:      in.Spec.Mask,
+			Region:    in.Spec.RegionId,
+			ID:        in.Spec.VpcId,
+			Status:    in.Spec.Status,
+			Message:   in.Spec.Message,
+		},
+	}
+}
+
+func ToVSwitch(vSwitch *networking.Vswitch) *VSwitch {
+	return &VSwitch{
+		TypeMeta:   typeMeta(VSwitchKind),
+		ObjectMeta: objectMeta(vSwitch.Metadata),
+		Spec: VSwitchSpec{
+			LocalName: vSwitch.Spec.LocalName,
+			IP:        vSwitch.Spec.IP,
+			Id:        vSwitch.Spec.Id,
+			Mask:      vSwitch.Spec.Mask,
+			RegionId:  vSwitch.Spec.Region,
+			Zone:      vSwitch.Spec.Zone,
+			VpcId:     vSwitch.Spec.VpcId,
+			Status:    vSwitch.Spec.Status,
+			Message:   vSwitch.Spec.Message,
+		},
+	}
+}
+
+func (in *VSwitch) ToResource() *networking.Vswitch {
+	return &networking.Vswitch{
+		Metadata: metadata(in.Kind, in.ObjectMeta),
+		Spec: networking.VSwitchSpec{
+			LocalName: in.Spec.LocalName,
+			IP:        in.Spec.IP,
+			Id:        in.Spec.Id,
+			Mask:      in.Spec.Mask,
+			Region:    in.Spec.RegionId,
+			Zone:      in.Spec.Zone,
+			VpcId:     in.Spec.VpcId,
+			Status:    in.Spec.Status,
+			Message:   in.Spec.Message,
+		},
+	}
+}
+
+func toSecurityGroupRules(roles []system.SecurityGroupRole) []SecurityGroupRule {
+	if roles == nil {
+		return nil
+	}
+	rules := make([]SecurityGroupRule, 0, len(roles))
+	for _, role := range roles {
+		rules = append(rules, SecurityGroupRule{
+			Direction:    string(role.Direction),
+			Action:       string(role.Action),
+			Priority:     role.Priority,
+			IpProtocol:   string(role.IpProtocol),
+			PortRange:    role.PortRange,
+			SourceCidrIp: role.SourceCidrIp,
+			PeerGroup:    role.PeerGroup,
+			Description:  role.Description,
+		})
+	}
+	return rules
+}
+
+func toSecurityGroupRoles(rules []SecurityGroupRule) []system.SecurityGroupRole {
+	if rules == nil {
+		return nil
+	}
+	roles := make([]system.SecurityGroupRole, 0, len(rules))
+	for _, rule := range rules {
+		roles = append(roles, system.SecurityGroupRole{
+			Direction:    system.RuleDirection(rule.Direction),
+			Action:       system.RuleAction(rule.Action),
+			Priority:     rule.Priority,
+			IpProtocol:   system.IpProtocolType(rule.IpProtocol),
+			PortRange:    rule.PortRange,
+			SourceCidrIp: rule.SourceCidrIp,
+			PeerGroup:    rule.PeerGroup,
+			Description:  rule.Description,
+		})
+	}
+	return roles
+}
+
+func ToSecurityGroup(securityGroup *system.SecurityGroup) *SecurityGroup {
+	return &SecurityGroup{
+		TypeMeta:   typeMeta(SecurityGroupKind),
+		ObjectMeta: objectMeta(securityGroup.Metadata),
+		Spec: SecurityGroupSpec{
+			LocalName: securityGroup.Spec.LocalName,
+			Id:        securityGroup.Spec.ID,
+			RegionId:  securityGroup.Spec.RegionId,
+			VpcId:     securityGroup.Spec.VpcId,
+			Ingress:   toSecurityGroupRules(securityGroup.Spec.Ingress),
+			Egress:    toSecurityGroupRules(securityGroup.Spec.Egress),
+			Status:    securityGroup.Spec.Status,
+			Message:   securityGroup.Spec.Message,
+		},
+	}
+}
+
+func (in *SecurityGroup) ToResource() *system.SecurityGroup {
+	return &system.SecurityGroup{
+		Metadata: metadata(in.Kind, in.ObjectMeta),
+		Spec: system.SecurityGroupSpec{
+			LocalName: in.Spec.LocalName,
+			ID:        in.Spec.Id,
+			RegionId:  in.Spec.RegionId,
+			VpcId:     in.Spec.VpcId,
+			Ingress:   toSecurityGroupRoles(in.Spec.Ingress),
+			Egress:    toSecurityGroupRoles(in.Spec.Egress),
+			Status:    in.Spec.Status,
+			Message:   in.Spec.Message,
+		},
+	}
+}
+
+func ToStorage(storage *compute.Storage) *Storage {
+	result := &Storage{
+		TypeMeta:   typeMeta(StorageKind),
+		ObjectMeta: objectMeta(storage.Metadata),
+		Spec: StorageSpec{
+			LocalName:          storage.Spec.LocalName,
+			CategoryType:       storage.Spec.CategoryType,
+			DeleteWithInstance: storage.Spec.DeleteWithInstance,
+			Description:        storage.Spec.Description,
+			DiskChargeType:     storage.Spec.DiskChargeType,
+			DiskType:           storage.Spec.DiskType,
+			IOPS:               int64(storage.Spec.IOPS),
+			Throughput:         int64(storage.Spec.Throughput),
+			Size:               int64(storage.Spec.Size),
+			Region:             storage.Spec.Region,
+			Zone:               storage.Spec.Zone,
+			StorageId:          storage.Spec.StorageId,
+			State:              storage.Spec.State,
+			Status:             storage.Spec.Status,
+			Message:            storage.Spec.Message,
+		},
+	}
+	for _, attachment := range storage.Spec.Attachments {
+		result.Spec.Attachments = append(result.Spec.Attachments, StorageAttachment{
+			AttachedTime: attachment.AttachedTime,
+			Device:       attachment.Device,
+			InstanceId:   attachment.InstanceId,
+			State:        attachment.State,
+		})
+	}
+	return result
+}
+
+func (in *Storage) ToResource() *compute.Storage {
+	result := &compute.Storage{
+		Metadata: metadata(in.Kind, in.ObjectMeta),
+		Spec: compute.StorageSpec{
+			LocalName:          in.Spec.LocalName,
+			CategoryType:       in.Spec.CategoryType,
+			DeleteWithInstance: in.Spec.DeleteWithInstance,
+			Description:        in.Spec.Description,
+			DiskChargeType:     in.Spec.DiskChargeType,
+			DiskType:           in.Spec.DiskType,
+			IOPS:               int(in.Spec.IOPS),
+			Throughput:         int(in.Spec.Throughput),
+			Size:               int(in.Spec.Size),
+			Region:             in.Spec.Region,
+			Zone:               in.Spec.Zone,
+			StorageId:          in.Spec.StorageId,
+			State:              in.Spec.State,
+			Status:             in.Spec.Status,
+			Message:            in.Spec.Message,
+		},
+	}
+	for _, attachment := range in.Spec.Attachments {
+		result.Spec.Attachments = append(result.Spec.Attachments, compute.Attachment{
+			AttachedTime: attachment.AttachedTime,
+			Device:       attachment.Device,
+			InstanceId:   attachment.InstanceId,
+			State:        attachment.State,
+		})
+	}
+	return result
+}
+
+func ToNetworkInterface(networkInterface *networking.NetworkInterface) *NetworkInterface {
+	result := &NetworkInterface{
+		TypeMeta:   typeMeta(NetworkInterfaceKind),
+		ObjectMeta: objectMeta(networkInterface.Metadata),
+		Spec: NetworkInterfaceSpec{
+			LocalName:          networkInterface.Spec.LocalName,
+			NetworkInterfaceId: networkInterface.Spec.ID,
+			Description:        networkInterface.Spec.Description,
+			Attachment: NetworkInterfaceAttachment{
+				AttachTime: networkInterface.Spec.Attachment.AttachTime,
+				InstanceId: networkInterface.Spec.Attachment.InstanceId,
+				Status:     networkInterface.Spec.Attachment.Status,
+			},
+			MacAddress:       networkInterface.Spec.MacAddress,
+			PrivateDnsName:   networkInterface.Spec.PrivateDnsName,
+			PrivateIpAddress: networkInterface.Spec.PrivateIpAddress,
+			PublicDnsName:    networkInterface.Spec.PublicDnsName,
+			PublicIpAddress:  networkInterface.Spec.PublicIpAddress,
+			SecurityGroupIds: networkInterface.Spec.SecurityGroupIds,
+			Ipv6:             networkInterface.Spec.Ipv6,
+			Region:           networkInterface.Spec.Region,
+			Zone:             networkInterface.Spec.Zone,
+			VpcId:            networkInterface.Spec.VPCId,
+			SubnetId:         networkInterface.Spec.SubnetId,
+			Type:             networkInterface.Spec.Type,
+			State:            networkInterface.Spec.State,
+			Status:           networkInterface.Spec.Status,
+			Message:          networkInterface.Spec.Message,
+		},
+	}
+	for _, set := range networkInterface.Spec.PrivateIpSets {
+		result.Spec.PrivateIpSets = append(result.Spec.PrivateIpSets, NetworkInterfacePrivateIpSet{
+			Primary:          set.Primary,
+			PrivateDnsName:   set.PrivateDnsName,
+			PrivateIpAddress: set.PrivateIpAddress,
+			PublicIpAddress:  set.PublicIpAddress,
+		})
+	}
+	return result.DeepCopy()
+}
+
+func (in *NetworkInterface) ToResource() *networking.NetworkInterface {
+	spec := in.Spec.DeepCopy()
+	result := &networking.NetworkInterface{
+		Metadata: metadata(in.Kind, in.ObjectMeta),
+		Spec: networking.NetworkInterfaceSpec{
+			LocalName:   spec.LocalName,
+			ID:          spec.NetworkInterfaceId,
+			Description: spec.Description,
+			Attachment: networking.ENIAttachment{
+				AttachTime: spec.Attachment.AttachTime,
+				InstanceId: spec.Attachment.InstanceId,
+				Status:     spec.Attachment.Status,
+			},
+			MacAddress:       spec.MacAddress,
+			PrivateDnsName:   spec.PrivateDnsName,
+			PrivateIpAddress: spec.PrivateIpAddress,
+			PublicDnsName:    spec.PublicDnsName,
+			PublicIpAddress:  spec.PublicIpAddress,
+			SecurityGroupIds: spec.SecurityGroupIds,
+			Ipv6:             spec.Ipv6,
+			Region:           spec.Region,
+			Zone:             spec.Zone,
+			VPCId:            spec.VpcId,
+			SubnetId:         spec.SubnetId,
+			Type:             spec.Type,
+			State:            spec.State,
+			Status:           spec.Status,
+			Message:          spec.Message,
+		},
+	}
+	for _, set := range spec.PrivateIpSets {
+		result.Spec.PrivateIpSets = append(result.Spec.PrivateIpSets, networking.ENIPrivateIpSet{
+			Primary:          set.Primary,
+			PrivateDnsName:   set.PrivateDnsName,
+			PrivateIpAddress: set.PrivateIpAddress,
+			PublicIpAddress:  set.PublicIpAddress,
+		})
+	}
+	return result
+}
+
+// ToVirtualMachine the storages and network interfaces of vm are left out, they are resources of their own
+func ToVirtualMachine(vm *compute.VirtualMachine) *VirtualMachine {
+	result := &VirtualMachine{
+		TypeMeta:   typeMeta(VirtualMachineKind),
+		ObjectMeta: objectMeta(vm.Metadata),
+		Spec: VirtualMachineSpec{
+			LocalName:     vm.Spec.LocalName,
+			InstanceId:    vm.Spec.InstanceId,
+			CPU:           vm.Spec.CPU,
+			Memory:        vm.Spec.Memory,
+			RegionId:      vm.Spec.RegionId,
+			Az:            vm.Spec.Az,
+			Image:         vm.Spec.ImageId,
+			InstanceType:  vm.Spec.InstanceType,
+			RootDevice:    vm.Spec.RootDevice,
+			VpcId:         vm.Spec.VPCId,
+			VSwitchId:     vm.Spec.VSwitchId,
+			SecurityGroup: vm.Spec.SecurityGroup,
+			Os:            vm.Spec.Os,
+			CreateTime:    vm.Spec.CreateTime,
+			State:         string(vm.Spec.State),
+			Status:        vm.Spec.Status,
+			Message:       vm.Spec.Message,
+		},
+	}
+	return result.DeepCopy()
+}
+
+// ToResource the vendor of a cloud machine is the namespace it lives in
+func (in *VirtualMachine) ToResource() *compute.VirtualMachine {
+	spec := in.Spec.DeepCopy()
+	return &compute.VirtualMachine{
+		Metadata: metadata(in.Kind, in.ObjectMeta),
+		Spec: compute.VirtualMachineSpec{
+			Vendor:        in.Namespace,
+			LocalName:     spec.LocalName,
+			InstanceId:    spec.InstanceId,
+			CPU:           spec.CPU,
+			Memory:        spec.Memory,
+			RegionId:      spec.RegionId,
+			Az:            spec.Az,
+			ImageId:       spec.Image,
+			InstanceType:  spec.InstanceType,
+			RootDevice:    spec.RootDevice,
+			VPCId:         spec.VpcId,
+			VSwitchId:     spec.VSwitchId,
+			SecurityGroup: spec.SecurityGroup,
+			Os:            spec.Os,
+			CreateTime:    spec.CreateTime,
+			State:         compute.VirtualMachineStateType(spec.State),
+			Status:        spec.Status,
+			Message:       spec.Message,
+		},
+	}
+}
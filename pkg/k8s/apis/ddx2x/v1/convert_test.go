@@ -0,0 +1,157 @@
+package v1
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func testMetadata(kind string) core.Metadata {
+	return core.Metadata{
+		Name:      "test",
+		Namespace: "aws",
+		Workspace: "dev",
+		Kind:      core.Kind(kind),
+		Labels:    map[string]interface{}{"workspace": "dev", "note": "a: b"},
+	}
+}
+
+// roundTrip push obj through the dynamic client representation as a south event would
+func roundTrip(t *testing.T, obj runtime.Object, out runtime.Object) {
+	u, err := ToUnstructured(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := FromUnstructured(u, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVPCRoundTrip(t *testing.T) {
+	vpc := &networking.VirtualPrivateCloud{
+		Metadata: testMetadata(VPCKind),
+		Spec: networking.VirtualPrivateCloudSpec{
+			LocalName: "local", IP: "10.0.0.0", Mask: "16", Region: "cn-south", ID: "vpc-1",
+			Status: "init", Message: "multi\nline: message",
+		},
+	}
+	out := &VPC{}
+	roundTrip(t, ToVPC(vpc), out)
+	if got := out.ToResource(); !reflect.DeepEqual(got, vpc) {
+		t.Fatalf("expected %+v, got %+v", vpc, got)
+	}
+}
+
+func TestVSwitchRoundTrip(t *testing.T) {
+	vSwitch := &networking.Vswitch{
+		Metadata: testMetadata(VSwitchKind),
+		Spec: networking.VSwitchSpec{
+			LocalName: "local", IP: "10.0.1.0", Mask: "24", Region: "cn-south", Zone: "cn-south-a",
+			Id: "vsw-1", VpcId: "vpc-1", Status: "update", Message: "ok",
+		},
+	}
+	out := &VSwitch{}
+	roundTrip(t, ToVSwitch(vSwitch), out)
+	if got := out.ToResource(); !reflect.DeepEqual(got, vSwitch) {
+		t.Fatalf("expected %+v, got %+v", vSwitch, got)
+	}
+}
+
+func TestSecurityGroupRoundTrip(t *testing.T) {
+	securityGroup := &system.SecurityGroup{
+		Metadata: testMetadata(SecurityGroupKind),
+		Spec: system.SecurityGroupSpec{
+			LocalName: "local", RegionId: "cn-south", VpcId: "vpc-1", ID: "sg-1", Status: "sync",
+			Ingress: []system.SecurityGroupRole{
+				{Direction: system.Ingress, Action: system.Allow, Priority: 1, PortRange: "22/22", IpProtocol: system.TCP, SourceCidrIp: "0.0.0.0/0", Description: "ssh"},
+				{Direction: system.Ingress, Action: system.Deny, Priority: 2, PortRange: "-1/-1", IpProtocol: system.ALL, PeerGroup: "sg-2"},
+			},
+			Egress: []system.SecurityGroupRole{{PortRange: "-1/-1", IpProtocol: system.ALL, SourceCidrIp: "0.0.0.0/0"}},
+		},
+	}
+	out := &SecurityGroup{}
+	roundTrip(t, ToSecurityGroup(securityGroup), out)
+	if got := out.ToResource(); !reflect.DeepEqual(got, securityGroup) {
+		t.Fatalf("expected %+v, got %+v", securityGroup, got)
+	}
+}
+
+func TestStorageRoundTrip(t *testing.T) {
+	storage := &compute.Storage{
+		Metadata: testMetadata(StorageKind),
+		Spec: compute.StorageSpec{
+			LocalName: "local", CategoryType: "gp2", DeleteWithInstance: true, Description: "data: disk",
+			DiskType: "data", IOPS: 100, Throughput: 125, Size: 40, Region: "cn-south", Zone: "cn-south-a",
+			StorageId: "vol-1", State: "in-use", Status: "running",
+			Attachments: []compute.Attachment{{AttachedTime: "2021-09-01T00:00:00Z", Device: "/dev/sdb", InstanceId: "i-1", State: "attached"}},
+		},
+	}
+	out := &Storage{}
+	roundTrip(t, ToStorage(storage), out)
+	if got := out.ToResource(); !reflect.DeepEqual(got, storage) {
+		t.Fatalf("expected %+v, got %+v", storage, got)
+	}
+}
+
+func TestNetworkInterfaceRoundTrip(t *testing.T) {
+	networkInterface := &networking.NetworkInterface{
+		Metadata: testMetadata(NetworkInterfaceKind),
+		Spec: networking.NetworkInterfaceSpec{
+			LocalName: "local", ID: "eni-1", Description: "primary",
+			Attachment: networking.ENIAttachment{AttachTime: "2021-09-01T00:00:00Z", InstanceId: "i-1", Status: "attached"},
+			MacAddress: "00:16:3e:00:00:01", PrivateIpAddress: "10.0.1.2", PublicIpAddress: "1.2.3.4",
+			PrivateIpSets:    []networking.ENIPrivateIpSet{{Primary: true, PrivateIpAddress: "10.0.1.2"}, {PrivateIpAddress: "10.0.1.3"}},
+			SecurityGroupIds: []string{"sg-1"}, Ipv6: []string{"fe80::1"},
+			Region: "cn-south", Zone: "cn-south-a", VPCId: "vpc-1", SubnetId: "vsw-1", Type: "Primary",
+			State: "InUse", Status: "running",
+		},
+	}
+	out := &NetworkInterface{}
+	roundTrip(t, ToNetworkInterface(networkInterface), out)
+	if got := out.ToResource(); !reflect.DeepEqual(got, networkInterface) {
+		t.Fatalf("expected %+v, got %+v", networkInterface, got)
+	}
+}
+
+func TestVirtualMachineRoundTrip(t *testing.T) {
+	vm := &compute.VirtualMachine{
+		Metadata: testMetadata(VirtualMachineKind),
+		Spec: compute.VirtualMachineSpec{
+			Vendor: "aws", LocalName: "local", InstanceId: "i-1", CPU: "2", Memory: "4Gi", RegionId: "cn-south",
+			Az: "cn-south-a", ImageId: "ami-1", InstanceType: "t3.medium", RootDevice: "/dev/xvda",
+			VPCId: "vpc-1", VSwitchId: "vsw-1", SecurityGroup: []string{"sg-1", "sg-2"}, Os: "linux",
+			CreateTime: "2021-09-01T00:00:00Z", State: compute.Running, Status: "running", Message: "ok",
+		},
+	}
+	out := &VirtualMachine{}
+	roundTrip(t, ToVirtualMachine(vm), out)
+	if got := out.ToResource(); !reflect.DeepEqual(got, vm) {
+		t.Fatalf("expected %+v, got %+v", vm, got)
+	}
+}
+
+func TestMissingWorkspace(t *testing.T) {
+	vpc := &VPC{TypeMeta: typeMeta(VPCKind)}
+	vpc.Name = "test"
+	if workspace := vpc.ToResource().GetWorkspace(); workspace != "default" {
+		t.Fatalf("expected default workspace, got %s", workspace)
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	sg := ToSecurityGroup(&system.SecurityGroup{
+		Metadata: testMetadata(SecurityGroupKind),
+		Spec:     system.SecurityGroupSpec{Ingress: []system.SecurityGroupRole{{PortRange: "22/22"}}},
+	})
+	copied := sg.DeepCopyObject().(*SecurityGroup)
+	copied.Spec.Ingress[0].PortRange = "80/80"
+	copied.Labels["workspace"] = "prod"
+	if sg.Spec.Ingress[0].PortRange != "22/22" || sg.Labels["workspace"] != "dev" {
+		t.Fatalf("deep copy shares memory with the original: %+v", sg)
+	}
+}
@@ -0,0 +1,7 @@
+// Package v1 contains the typed objects of the github.com/ddx2x/v1 custom resources
+// the infra controllers write to and watch from the clusters.
+// +kubebuilder:object:generate=true
+// +groupName=github.com/ddx2x
+package v1
+
+//go:generate controller-gen object paths=.
@@ -0,0 +1,27 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	GroupName = "github.com/ddx2x"
+	Version   = "v1"
+)
+
+const (
+	VPCKind              = "VPC"
+	VSwitchKind          = "VSwitch"
+	SecurityGroupKind    = "SecurityGroup"
+	StorageKind          = "Storage"
+	NetworkInterfaceKind = "NetworkInterface"
+	VirtualMachineKind   = "VirtualMachine"
+)
+
+// SchemeGroupVersion group version of the ddx2x custom resources
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: Version}
+
+func typeMeta(kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{APIVersion: SchemeGroupVersion.String(), Kind: kind}
+}
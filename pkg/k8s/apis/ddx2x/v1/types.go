@@ -0,0 +1,185 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+
+type VPC struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VPCSpec `json:"spec"`
+}
+
+type VPCSpec struct {
+	LocalName string `json:"localName,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	Mask      string `json:"mask,omitempty"`
+	RegionId  string `json:"regionId,omitempty"`
+	VpcId     string `json:"vpcId,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type VSwitch struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VSwitchSpec `json:"spec"`
+}
+
+type VSwitchSpec struct {
+	LocalName string `json:"localName,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	Id        string `json:"id,omitempty"`
+	Mask      string `json:"mask,omitempty"`
+	RegionId  string `json:"regionId,omitempty"`
+	Zone      string `json:"zone,omitempty"`
+	VpcId     string `json:"vpcId,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type SecurityGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SecurityGroupSpec `json:"spec"`
+}
+
+type SecurityGroupRule struct {
+	Direction    string `json:"direction,omitempty"`
+	Action       string `json:"action,omitempty"`
+	Priority     int    `json:"priority,omitempty"`
+	IpProtocol   string `json:"ipProtocol,omitempty"`
+	PortRange    string `json:"portRange,omitempty"`
+	SourceCidrIp string `json:"sourceCidrIp,omitempty"`
+	PeerGroup    string `json:"peerGroup,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type SecurityGroupSpec struct {
+	LocalName string              `json:"localName,omitempty"`
+	Id        string              `json:"id,omitempty"`
+	RegionId  string              `json:"regionId,omitempty"`
+	VpcId     string              `json:"vpcId,omitempty"`
+	Ingress   []SecurityGroupRule `json:"ingress,omitempty"`
+	Egress    []SecurityGroupRule `json:"egress,omitempty"`
+	Status    string              `json:"status,omitempty"`
+	Message   string              `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type Storage struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec StorageSpec `json:"spec"`
+}
+
+type StorageAttachment struct {
+	AttachedTime string `json:"attachedTime,omitempty"`
+	Device       string `json:"device,omitempty"`
+	InstanceId   string `json:"instanceId,omitempty"`
+	State        string `json:"state,omitempty"`
+}
+
+type StorageSpec struct {
+	LocalName          string              `json:"localName,omitempty"`
+	CategoryType       string              `json:"categoryType,omitempty"`
+	DeleteWithInstance bool                `json:"deleteWithInstance"`
+	Description        string              `json:"description,omitempty"`
+	DiskChargeType     string              `json:"diskChargeType,omitempty"`
+	DiskType           string              `json:"diskType,omitempty"`
+	IOPS               int64               `json:"iops"`
+	Throughput         int64               `json:"throughput"`
+	Size               int64               `json:"size"`
+	Region             string              `json:"region,omitempty"`
+	Zone               string              `json:"zone,omitempty"`
+	StorageId          string              `json:"storageId,omitempty"`
+	Attachments        []StorageAttachment `json:"attachments,omitempty"`
+	State              string              `json:"state,omitempty"`
+	Status             string              `json:"status,omitempty"`
+	Message            string              `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type NetworkInterface struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NetworkInterfaceSpec `json:"spec"`
+}
+
+type NetworkInterfaceAttachment struct {
+	AttachTime string `json:"attachTime,omitempty"`
+	InstanceId string `json:"instanceId,omitempty"`
+	Status     string `json:"status,omitempty"`
+}
+
+type NetworkInterfacePrivateIpSet struct {
+	Primary          bool   `json:"primary"`
+	PrivateDnsName   string `json:"privateDnsName,omitempty"`
+	PrivateIpAddress string `json:"privateIpAddress,omitempty"`
+	PublicIpAddress  string `json:"publicIpAddress,omitempty"`
+}
+
+type NetworkInterfaceSpec struct {
+	LocalName          string                         `json:"localName,omitempty"`
+	NetworkInterfaceId string                         `json:"networkInterfaceId,omitempty"`
+	Description        string                         `json:"description,omitempty"`
+	Attachment         NetworkInterfaceAttachment     `json:"attachment,omitempty"`
+	MacAddress         string                         `json:"macAddress,omitempty"`
+	PrivateDnsName     string                         `json:"privateDnsName,omitempty"`
+	PrivateIpAddress   string                         `json:"privateIpAddress,omitempty"`
+	PrivateIpSets      []NetworkInterfacePrivateIpSet `json:"privateIpSets,omitempty"`
+	PublicDnsName      string                         `json:"publicDnsName,omitempty"`
+	PublicIpAddress    string                         `json:"publicIpAddress,omitempty"`
+	SecurityGroupIds   []string                       `json:"securityGroupIds,omitempty"`
+	Ipv6               []string                       `json:"ipv6,omitempty"`
+	Region             string                         `json:"region,omitempty"`
+	Zone               string                         `json:"zone,omitempty"`
+	VpcId              string                         `json:"vpcId,omitempty"`
+	SubnetId           string                         `json:"subnetId,omitempty"`
+	Type               string                         `json:"type,omitempty"`
+	State              string                         `json:"state,omitempty"`
+	Status             string                         `json:"status,omitempty"`
+	Message            string                         `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+type VirtualMachine struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VirtualMachineSpec `json:"spec"`
+}
+
+type VirtualMachineSpec struct {
+	LocalName     string   `json:"localName,omitempty"`
+	InstanceId    string   `json:"instanceId,omitempty"`
+	CPU           string   `json:"cpu,omitempty"`
+	Memory        string   `json:"memory,omitempty"`
+	RegionId      string   `json:"regionId,omitempty"`
+	Az            string   `json:"az,omitempty"`
+	Image         string   `json:"image,omitempty"`
+	InstanceType  string   `json:"instanceType,omitempty"`
+	RootDevice    string   `json:"rootDevice,omitempty"`
+	VpcId         string   `json:"vpcId,omitempty"`
+	VSwitchId     string   `json:"vSwitchId,omitempty"`
+	SecurityGroup []string `json:"securityGroup,omitempty"`
+	Os            string   `json:"os,omitempty"`
+	CreateTime    string   `json:"createTime,omitempty"`
+	State         string   `json:"state,omitempty"`
+	Status        string   `json:"status,omitempty"`
+	Message       string   `json:"message,omitempty"`
+}
@@ -0,0 +1,146 @@
+package metering
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+// Records the usage records of tenant and workspace starting from from until to, in unix seconds. An empty tenant or
+// workspace does not filter, a zero to is now
+func (m *Metering) Records(tenant, workspace string, from, to int64) ([]system.UsageRecord, error) {
+	filter := map[string]interface{}{}
+	if tenant != "" {
+		filter["metadata.tenant"] = tenant
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	if to == 0 {
+		to = time.Now().Unix()
+	}
+
+	records := make([]system.UsageRecord, 0)
+	if err := m.stage.ListToObject(common.DefaultDatabase, common.USAGERECORD, filter, &records, true); err != nil {
+		return nil, err
+	}
+	result := make([]system.UsageRecord, 0, len(records))
+	for _, record := range records {
+		if record.Spec.Start >= from && record.Spec.Start < to {
+			result = append(result, record)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Spec.Start < result[j].Spec.Start })
+	return result, nil
+}
+
+// InvoiceLine the usage of a meter of a table in a workspace over the period of the invoice
+type InvoiceLine struct {
+	Workspace string `json:"workspace"`
+	Table     string `json:"table"`
+	Meter     string `json:"meter"`
+	// Objects the objects billed
+	Objects int `json:"objects"`
+	// Usage the units used multiplied by the hours they were used
+	Usage float64 `json:"usage"`
+	Cost  float64 `json:"cost"`
+}
+
+type Invoice struct {
+	Tenant    string         `json:"tenant"`
+	Workspace string         `json:"workspace"`
+	From      int64          `json:"from"`
+	To        int64          `json:"to"`
+	Lines     []*InvoiceLine `json:"lines"`
+	Total     float64        `json:"total"`
+}
+
+// Invoice sum the usage records of tenant and workspace over the period by workspace, table and meter
+func (m *Metering) Invoice(tenant, workspace string, from, to int64) (*Invoice, error) {
+	if to == 0 {
+		to = time.Now().Unix()
+	}
+	records, err := m.Records(tenant, workspace, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := &Invoice{Tenant: tenant, Workspace: workspace, From: from, To: to, Lines: make([]*InvoiceLine, 0)}
+	lines := make(map[[3]string]*InvoiceLine)
+	objects := make(map[[3]string]map[string]bool)
+	for _, record := range records {
+		key := [3]string{record.GetWorkspace(), record.Spec.Table, record.Spec.Meter}
+		line, exist := lines[key]
+		if !exist {
+			line = &InvoiceLine{Workspace: key[0], Table: key[1], Meter: key[2]}
+			lines[key] = line
+			objects[key] = make(map[string]bool)
+			invoice.Lines = append(invoice.Lines, line)
+		}
+		objects[key][record.Spec.Object] = true
+		line.Objects = len(objects[key])
+		line.Usage += record.Spec.Quantity * record.Spec.Hours()
+		line.Cost += record.Spec.Cost
+	}
+
+	sort.Slice(invoice.Lines, func(i, j int) bool {
+		a, b := invoice.Lines[i], invoice.Lines[j]
+		if a.Workspace != b.Workspace {
+			return a.Workspace < b.Workspace
+		}
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		return a.Meter < b.Meter
+	})
+	for _, line := range invoice.Lines {
+		line.Usage, line.Cost = round(line.Usage), round(line.Cost)
+		invoice.Total += line.Cost
+	}
+	invoice.Total = round(invoice.Total)
+	return invoice, nil
+}
+
+// WriteCSV the lines of the invoice followed by its total
+func (i *Invoice) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{{"workspace", "table", "meter", "objects", "usage", "cost"}}
+	for _, line := range i.Lines {
+		rows = append(rows, []string{line.Workspace, line.Table, line.Meter, strconv.Itoa(line.Objects), formatFloat(line.Usage), formatFloat(line.Cost)})
+	}
+	rows = append(rows, []string{"total", "", "", "", "", formatFloat(i.Total)})
+	return writer.WriteAll(rows)
+}
+
+// WriteRecordsCSV one row by usage record, the times in RFC3339
+func WriteRecordsCSV(w io.Writer, records []system.UsageRecord) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{{"tenant", "workspace", "table", "object", "meter", "quantity", "instance_type", "start", "end", "hours", "unit_price", "cost", "source"}}
+	for _, record := range records {
+		rows = append(rows, []string{
+			record.GetTenant(),
+			record.GetWorkspace(),
+			record.Spec.Table,
+			record.Spec.Object,
+			record.Spec.Meter,
+			formatFloat(record.Spec.Quantity),
+			record.Spec.InstanceType,
+			time.Unix(record.Spec.Start, 0).UTC().Format(time.RFC3339),
+			time.Unix(record.Spec.End, 0).UTC().Format(time.RFC3339),
+			formatFloat(record.Spec.Hours()),
+			formatFloat(record.Spec.UnitPrice),
+			formatFloat(record.Spec.Cost),
+			record.Spec.Source,
+		})
+	}
+	return writer.WriteAll(rows)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,296 @@
+package metering
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/cr"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Storage the part of the datasource metering reads, satisfied by both the stage and the services
+type Storage interface {
+	GetByFilter(db, table string, result interface{}, filter map[string]interface{}, filterDelete bool) error
+	ListToObject(db, table string, filter map[string]interface{}, result interface{}, filterDelete bool) error
+}
+
+type Metering struct {
+	stage Storage
+	// tenants the tenant of each workspace looked up
+	tenants map[string]string
+}
+
+func New(stage Storage) *Metering {
+	return &Metering{stage: stage, tenants: make(map[string]string)}
+}
+
+// Usage what an object uses while it is billed, priced by the hour
+type Usage struct {
+	Table        string
+	Workspace    string
+	Object       string
+	Meter        string
+	Quantity     float64
+	InstanceType string
+	// Namespace the provider of a vm, the instance types are looked up in it first
+	Namespace string
+	// Cores the cores of a vm, it is billed by them when its instance type has no price
+	Cores float64
+}
+
+// Key identify the usage of an object across samples
+func (u *Usage) Key() string {
+	return fmt.Sprintf("%s/%s/%s/%s", u.Table, u.Workspace, u.Object, u.Meter)
+}
+
+// Running a vm is billed for its cores from started until stopped
+func Running(vm *compute.VirtualMachine) bool {
+	if vm.IsDelete {
+		return false
+	}
+	switch vm.Spec.State {
+	case compute.Running, compute.Restarting, compute.Migrating, compute.Stopping, compute.Pausing:
+		return true
+	}
+	return false
+}
+
+// RunUsage the usage of a running vm, the vm as a whole when it has an instance type, its cores otherwise
+func RunUsage(vm *compute.VirtualMachine) Usage {
+	usage := Usage{
+		Table:        common.VIRTUALMACHINE,
+		Workspace:    vm.GetWorkspace(),
+		Object:       vm.GetName(),
+		Meter:        system.MeterCore,
+		InstanceType: vm.Spec.InstanceType,
+		Namespace:    vm.GetNamespace(),
+	}
+	if cpu, err := resource.ParseQuantity(vm.Spec.CPU); err == nil {
+		usage.Cores = float64(cpu.MilliValue()) / 1000
+	}
+	usage.Quantity = usage.Cores
+	if vm.Spec.InstanceType != "" {
+		usage.Meter, usage.Quantity = system.MeterInstance, 1
+	}
+	return usage
+}
+
+// volumeUsage the GiB of the volumes a vm is created with, billed while the vm exists
+func volumeUsage(vm *compute.VirtualMachine) (Usage, bool) {
+	storage := resource.Quantity{}
+	for _, volume := range vm.Spec.Storage {
+		if volume.Type == compute.VmStorageTypeHotplug {
+			continue
+		}
+		if quantity, err := resource.ParseQuantity(volume.Quantity); err == nil {
+			storage.Add(quantity)
+			continue
+		}
+		if volume.VolumeSize > 0 {
+			storage.Add(*resource.NewQuantity(volume.VolumeSize<<30, resource.BinarySI))
+		}
+	}
+	if storage.IsZero() {
+		return Usage{}, false
+	}
+	return Usage{
+		Table:     common.VIRTUALMACHINE,
+		Workspace: vm.GetWorkspace(),
+		Object:    vm.GetName(),
+		Meter:     system.MeterStorage,
+		Quantity:  float64(storage.Value()) / (1 << 30),
+	}, true
+}
+
+// Sample the usage of every object billed now: the running vms, the volumes of the vms, the disks, the elastic ips,
+// the load balancers and the custom data
+func (m *Metering) Sample() ([]Usage, error) {
+	result := make([]Usage, 0)
+
+	vms := make([]compute.VirtualMachine, 0)
+	if err := m.stage.ListToObject(common.DefaultDatabase, common.VIRTUALMACHINE, map[string]interface{}{}, &vms, true); err != nil {
+		return nil, err
+	}
+	for index := range vms {
+		vm := &vms[index]
+		if Running(vm) {
+			result = append(result, RunUsage(vm))
+		}
+		if usage, ok := volumeUsage(vm); ok {
+			result = append(result, usage)
+		}
+	}
+
+	storages := make([]compute.Storage, 0)
+	if err := m.stage.ListToObject(common.DefaultDatabase, common.STORAGE, map[string]interface{}{}, &storages, true); err != nil {
+		return nil, err
+	}
+	for _, storage := range storages {
+		result = append(result, Usage{
+			Table:     common.STORAGE,
+			Workspace: storage.GetWorkspace(),
+			Object:    storage.GetName(),
+			Meter:     system.MeterStorage,
+			Quantity:  float64(storage.Spec.Size),
+		})
+	}
+
+	elasticIPs := make([]networking.ElasticIP, 0)
+	if err := m.stage.ListToObject(common.DefaultDatabase, common.ELASTICIP, map[string]interface{}{}, &elasticIPs, true); err != nil {
+		return nil, err
+	}
+	for _, elasticIP := range elasticIPs {
+		result = append(result, unitUsage(common.ELASTICIP, &elasticIP))
+	}
+
+	loadBalancers := make([]networking.LoadBalancer, 0)
+	if err := m.stage.ListToObject(common.DefaultDatabase, common.LOADBALANCER, map[string]interface{}{}, &loadBalancers, true); err != nil {
+		return nil, err
+	}
+	for _, loadBalancer := range loadBalancers {
+		result = append(result, unitUsage(common.LOADBALANCER, &loadBalancer))
+	}
+
+	// the custom data of a custom resource is kept in a table of its name and priced as the resource of that name
+	customResources := make([]cr.CustomResource, 0)
+	if err := m.stage.ListToObject(common.DefaultDatabase, common.CUSTOMRESOURCE, map[string]interface{}{}, &customResources, true); err != nil {
+		return nil, err
+	}
+	for _, customResource := range customResources {
+		data := make([]core.DefaultObject, 0)
+		if err := m.stage.ListToObject(common.CustomDatabase, customResource.GetName(), map[string]interface{}{}, &data, true); err != nil {
+			return nil, err
+		}
+		for index := range data {
+			result = append(result, unitUsage(customResource.GetName(), &data[index]))
+		}
+	}
+
+	return result, nil
+}
+
+func unitUsage(table string, object core.IObject) Usage {
+	return Usage{
+		Table:     table,
+		Workspace: object.GetWorkspace(),
+		Object:    object.GetName(),
+		Meter:     system.MeterUnit,
+		Quantity:  1,
+	}
+}
+
+// Prices the price of a unit for an hour
+type Prices struct {
+	// resources by resource name, which is the table the resource is kept in
+	resources     map[string]float64
+	instanceTypes map[string][]system.InstanceType
+}
+
+// Prices the prices of the resources and of the instance types as they are now
+func (m *Metering) Prices() (*Prices, error) {
+	resources := make([]system.Resource, 0)
+	if err := m.stage.ListToObject(common.DefaultDatabase, common.RESOURCE, map[string]interface{}{}, &resources, true); err != nil {
+		return nil, err
+	}
+	instanceTypes := make([]system.InstanceType, 0)
+	if err := m.stage.ListToObject(common.DefaultDatabase, common.INSTANCETYPE, map[string]interface{}{}, &instanceTypes, true); err != nil {
+		return nil, err
+	}
+
+	prices := &Prices{
+		resources:     make(map[string]float64),
+		instanceTypes: make(map[string][]system.InstanceType),
+	}
+	for _, item := range resources {
+		if item.Spec.ResourceName != "" && item.Spec.Info.Price > 0 {
+			prices.resources[item.Spec.ResourceName] = float64(item.Spec.Info.Price)
+		}
+	}
+	for _, instanceType := range instanceTypes {
+		prices.instanceTypes[instanceType.Spec.ID] = append(prices.instanceTypes[instanceType.Spec.ID], instanceType)
+	}
+	return prices, nil
+}
+
+// Price the usage as it is billed and the price of a unit of it for an hour. A vm whose instance type has no price is
+// billed by its cores at the price of the virtualmachine resource, the volumes of a vm are priced as disks
+func (p *Prices) Price(usage Usage) (Usage, float64) {
+	switch usage.Meter {
+	case system.MeterInstance:
+		if price := p.instanceType(usage.InstanceType, usage.Namespace); price > 0 {
+			return usage, price
+		}
+		usage.Meter, usage.Quantity = system.MeterCore, usage.Cores
+		return usage, p.resources[common.VIRTUALMACHINE]
+	case system.MeterStorage:
+		return usage, p.resources[common.STORAGE]
+	}
+	return usage, p.resources[usage.Table]
+}
+
+// instanceType the price of the instance type id, the one of namespace is preferred when several share the id
+func (p *Prices) instanceType(id, namespace string) float64 {
+	var price float64
+	for _, instanceType := range p.instanceTypes[id] {
+		if instanceType.Spec.Price <= 0 {
+			continue
+		}
+		if instanceType.GetNamespace() == namespace {
+			return instanceType.Spec.Price
+		}
+		if price == 0 {
+			price = instanceType.Spec.Price
+		}
+	}
+	return price
+}
+
+// Record the usage record of usage over the window from start to end, in unix seconds, priced with prices
+func (m *Metering) Record(usage Usage, prices *Prices, start, end int64, source string) *system.UsageRecord {
+	usage, unitPrice := prices.Price(usage)
+	record := &system.UsageRecord{
+		Metadata: core.Metadata{
+			Name:      fmt.Sprintf("%s-%s-%s-%d", usage.Table, usage.Object, usage.Meter, start),
+			Kind:      system.UsageRecordKind,
+			Workspace: usage.Workspace,
+			Tenant:    m.tenantOf(usage.Workspace),
+		},
+		Spec: system.UsageRecordSpec{
+			Table:        usage.Table,
+			Object:       usage.Object,
+			Meter:        usage.Meter,
+			Quantity:     usage.Quantity,
+			InstanceType: usage.InstanceType,
+			Start:        start,
+			End:          end,
+			UnitPrice:    unitPrice,
+			Source:       source,
+		},
+	}
+	record.Spec.Cost = round(usage.Quantity * record.Spec.Hours() * unitPrice)
+	record.GenerateVersion()
+	return record
+}
+
+func (m *Metering) tenantOf(workspace string) string {
+	if tenant, exist := m.tenants[workspace]; exist {
+		return tenant
+	}
+	ws := &system.Workspace{}
+	if err := m.stage.GetByFilter(common.DefaultDatabase, common.WORKSPACE, ws, map[string]interface{}{common.FilterName: workspace}, true); err != nil {
+		return ""
+	}
+	m.tenants[workspace] = ws.Spec.Tenant
+	return ws.Spec.Tenant
+}
+
+// round a cost to the cent fraction kept, 1/10000
+func round(cost float64) float64 {
+	value, _ := strconv.ParseFloat(strconv.FormatFloat(cost, 'f', 4, 64), 64)
+	return value
+}
@@ -0,0 +1,127 @@
+package metering
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+func newMetering(t *testing.T) (*Metering, *memory.Memory) {
+	stage := memory.NewMemory()
+	objects := map[string][]core.IObject{
+		common.WORKSPACE: {
+			&system.Workspace{Metadata: core.Metadata{Name: "ws1"}, Spec: system.WorkspaceSpec{Tenant: "tenant1"}},
+		},
+		common.RESOURCE: {
+			&system.Resource{Metadata: core.Metadata{Name: "vm"}, Spec: system.ResourceSpec{ResourceName: common.VIRTUALMACHINE, Info: system.Info{Price: 0.5}}},
+			&system.Resource{Metadata: core.Metadata{Name: "disk"}, Spec: system.ResourceSpec{ResourceName: common.STORAGE, Info: system.Info{Price: 0.01}}},
+			&system.Resource{Metadata: core.Metadata{Name: "eip"}, Spec: system.ResourceSpec{ResourceName: common.ELASTICIP, Info: system.Info{Price: 0.25}}},
+		},
+		common.INSTANCETYPE: {
+			&system.InstanceType{Metadata: core.Metadata{Name: "large"}, Spec: system.InstanceTypeSpec{ID: "large", Cores: 4, Price: 3}},
+		},
+		common.VIRTUALMACHINE: {
+			&compute.VirtualMachine{Metadata: core.Metadata{Name: "vm1", Workspace: "ws1"},
+				Spec: compute.VirtualMachineSpec{CPU: "2", State: compute.Running, Storage: []compute.VmStorage{{Quantity: "10Gi"}}}},
+			&compute.VirtualMachine{Metadata: core.Metadata{Name: "vm2", Workspace: "ws1"},
+				Spec: compute.VirtualMachineSpec{CPU: "4", InstanceType: "large", State: compute.Running}},
+			&compute.VirtualMachine{Metadata: core.Metadata{Name: "vm3", Workspace: "ws1"},
+				Spec: compute.VirtualMachineSpec{CPU: "8", State: compute.Stopped}},
+		},
+		common.ELASTICIP: {
+			&networking.ElasticIP{Metadata: core.Metadata{Name: "eip1", Workspace: "ws1"}},
+		},
+	}
+	for table, items := range objects {
+		for _, item := range items {
+			if _, err := stage.Create(common.DefaultDatabase, table, item); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return New(stage), stage
+}
+
+func TestSample(t *testing.T) {
+	m, _ := newMetering(t)
+	usages, err := m.Sample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices, err := m.Prices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	costs := make(map[string]float64)
+	for _, usage := range usages {
+		record := m.Record(usage, prices, 0, 7200, system.UsageSourceSample)
+		if record.GetTenant() != "tenant1" {
+			t.Fatalf("expected the tenant of ws1 on the records, got %q", record.GetTenant())
+		}
+		costs[record.Spec.Object+"/"+record.Spec.Meter] = record.Spec.Cost
+	}
+
+	expected := map[string]float64{
+		// 2 cores at 0.5 for 2 hours
+		"vm1/" + system.MeterCore: 2,
+		// 10 GiB at 0.01 for 2 hours
+		"vm1/" + system.MeterStorage: 0.2,
+		// the instance type price for 2 hours
+		"vm2/" + system.MeterInstance: 6,
+		"eip1/" + system.MeterUnit:    0.5,
+	}
+	if len(costs) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, costs)
+	}
+	for key, cost := range expected {
+		if costs[key] != cost {
+			t.Fatalf("expected %s to cost %v, got %v", key, cost, costs[key])
+		}
+	}
+}
+
+func TestInvoice(t *testing.T) {
+	m, stage := newMetering(t)
+	usages, _ := m.Sample()
+	prices, _ := m.Prices()
+	for _, window := range [][2]int64{{0, 3600}, {3600, 7200}, {7200, 10800}} {
+		for _, usage := range usages {
+			if _, err := stage.Create(common.DefaultDatabase, common.USAGERECORD, m.Record(usage, prices, window[0], window[1], system.UsageSourceSample)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	invoice, err := m.Invoice("tenant1", "", 0, 7200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invoice.Lines) != 4 || invoice.Total != 8.7 {
+		t.Fatalf("expected 4 lines totalling 8.7 over the first 2 hours, got %d %v", len(invoice.Lines), invoice.Total)
+	}
+	for _, line := range invoice.Lines {
+		if line.Table == common.VIRTUALMACHINE && line.Meter == system.MeterCore && (line.Objects != 1 || line.Usage != 4) {
+			t.Fatalf("expected 4 core hours of 1 vm, got %+v", line)
+		}
+	}
+	if other, _ := m.Invoice("tenant2", "", 0, 7200); len(other.Lines) != 0 {
+		t.Fatalf("expected nothing billed to tenant2, got %v", other.Lines)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := invoice.WriteCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 || lines[len(lines)-1] != "total,,,,,8.7" {
+		t.Fatalf("unexpected csv %q", buf.String())
+	}
+}
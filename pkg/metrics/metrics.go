@@ -0,0 +1,176 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "oilmont"
+
+	// Path the metrics endpoint of every service
+	Path = "/metrics"
+)
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latencies by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	storageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operation_duration_seconds",
+		Help:      "Storage operation latencies by operation and table.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "table"})
+
+	storageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operation_errors_total",
+		Help:      "Storage operation errors by operation and table, not found is not counted.",
+	}, []string{"operation", "table"})
+
+	watchStreams = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "watch_streams",
+		Help:      "Open storage watch streams by table.",
+	}, []string{"table"})
+
+	watchLag = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "watch_lag_seconds",
+		Help:      "Delay between an object version and the delivery of its watch event.",
+		Buckets:   []float64{.1, .5, 1, 2, 5, 10, 30, 60, 300},
+	}, []string{"table"})
+
+	controllerQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "queue_depth",
+		Help:      "Events waiting in the controller channels by direction.",
+	}, []string{"controller", "direction"})
+
+	controllerRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "watch_retries_total",
+		Help:      "South watches established again after they ended, by cluster.",
+	}, []string{"controller", "cluster"})
+
+	controllerReconcile = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "controller",
+		Name:      "reconcile_duration_seconds",
+		Help:      "Event handling latencies by direction and event type.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"controller", "direction", "event"})
+
+	sessions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sessions",
+		Help:      "Open long lived client sessions by kind.",
+	}, []string{"kind"})
+)
+
+const (
+	SessionSSE     = "sse"
+	SessionShell   = "shell"
+	SessionConsole = "console"
+
+	North = "north"
+	South = "south"
+)
+
+func init() {
+	prometheus.MustRegister(
+		httpRequests, httpDuration,
+		storageDuration, storageErrors, watchStreams, watchLag,
+		controllerQueueDepth, controllerRetries, controllerReconcile,
+		sessions,
+	)
+}
+
+// Handler serve the registered metrics
+func Handler() http.Handler { return promhttp.Handler() }
+
+// Register expose the metrics endpoint on engine
+func Register(engine *gin.Engine) {
+	engine.GET(Path, gin.WrapH(Handler()))
+}
+
+// Serve expose the metrics endpoint on addr for processes without web server, e.g. controllers
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(Path, Handler())
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Middleware count requests and latencies by matched route so path parameters do not explode the label set
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		if route == Path {
+			return
+		}
+		httpRequests.WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// SessionOpened track a long lived session, the returned func must be called once it is closed
+func SessionOpened(kind string) func() {
+	sessions.WithLabelValues(kind).Inc()
+	return func() { sessions.WithLabelValues(kind).Dec() }
+}
+
+// AddSessions adjust the open sessions of kind by delta, for sessions tracked by a registry
+func AddSessions(kind string, delta float64) {
+	sessions.WithLabelValues(kind).Add(delta)
+}
+
+func ObserveReconcile(controller, direction, event string, start time.Time) {
+	controllerReconcile.WithLabelValues(controller, direction, event).Observe(time.Since(start).Seconds())
+}
+
+func SetQueueDepth(controller, direction string, depth int) {
+	controllerQueueDepth.WithLabelValues(controller, direction).Set(float64(depth))
+}
+
+func IncWatchRetry(controller, cluster string) {
+	controllerRetries.WithLabelValues(controller, cluster).Inc()
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware())
+	Register(engine)
+	engine.GET("/cluster/:name", func(g *gin.Context) { g.Status(http.StatusOK) })
+
+	for _, path := range []string{"/cluster/a", "/cluster/b", Path} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if count := testutil.ToFloat64(httpRequests.WithLabelValues("/cluster/:name", http.MethodGet, "200")); count != 2 {
+		t.Fatalf("expected 2 requests on the route, got %v", count)
+	}
+	if count := testutil.ToFloat64(httpRequests.WithLabelValues(Path, http.MethodGet, "200")); count != 0 {
+		t.Fatalf("expected metrics endpoint not counted, got %v", count)
+	}
+}
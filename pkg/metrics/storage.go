@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/datasource/dict"
+)
+
+var _ datasource.IStorage = &storage{}
+
+// storage record latency and errors of every operation of the wrapped IStorage
+type storage struct {
+	datasource.IStorage
+}
+
+// InstrumentStorage wrap s so its operations are reported per table
+func InstrumentStorage(s datasource.IStorage) datasource.IStorage {
+	return &storage{s}
+}
+
+func observe(operation, table string, start time.Time, err error) {
+	storageDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	if err != nil && err != datasource.NotFound {
+		storageErrors.WithLabelValues(operation, table).Inc()
+	}
+}
+
+// observeLag version is the unix timestamp written by the storage on every change
+func observeLag(table, version string) {
+	seconds, err := strconv.ParseInt(version, 10, 64)
+	if err != nil || seconds <= 0 {
+		return
+	}
+	watchLag.WithLabelValues(table).Observe(time.Since(time.Unix(seconds, 0)).Seconds())
+}
+
+func (s *storage) Create(db, table string, object core.IObject) (core.IObject, error) {
+	start := time.Now()
+	result, err := s.IStorage.Create(db, table, object)
+	observe("create", table, start, err)
+	return result, err
+}
+
+func (s *storage) Delete(db, table, name, workspace string) error {
+	start := time.Now()
+	err := s.IStorage.Delete(db, table, name, workspace)
+	observe("delete", table, start, err)
+	return err
+}
+
+func (s *storage) DeleteByIObject(db, table string, object core.IObject) error {
+	start := time.Now()
+	err := s.IStorage.DeleteByIObject(db, table, object)
+	observe("delete", table, start, err)
+	return err
+}
+
+func (s *storage) Apply(db, table, name string, object core.IObject, forceApply bool, paths ...string) (core.IObject, bool, error) {
+	start := time.Now()
+	result, update, err := s.IStorage.Apply(db, table, name, object, forceApply, paths...)
+	observe("apply", table, start, err)
+	return result, update, err
+}
+
+func (s *storage) ApplyStatus(db, table, name string, object core.IStatusObject, paths ...string) (core.IObject, error) {
+	start := time.Now()
+	result, err := s.IStorage.ApplyStatus(db, table, name, object, paths...)
+	observe("apply_status", table, start, err)
+	return result, err
+}
+
+func (s *storage) List(db, table, labels string, filterDelete bool) ([]interface{}, error) {
+	start := time.Now()
+	result, err := s.IStorage.List(db, table, labels, filterDelete)
+	observe("list", table, start, err)
+	return result, err
+}
+
+func (s *storage) Get(db, table, name string, result interface{}, filterDelete bool) error {
+	start := time.Now()
+	err := s.IStorage.Get(db, table, name, result, filterDelete)
+	observe("get", table, start, err)
+	return err
+}
+
+func (s *storage) GetByMetadataUUID(db, table, uuid string, result interface{}, filterDelete bool) error {
+	start := time.Now()
+	err := s.IStorage.GetByMetadataUUID(db, table, uuid, result, filterDelete)
+	observe("get", table, start, err)
+	return err
+}
+
+func (s *storage) GetByFilter(db, table string, result interface{}, filter map[string]interface{}, filterDelete bool) error {
+	start := time.Now()
+	err := s.IStorage.GetByFilter(db, table, result, filter, filterDelete)
+	observe("get", table, start, err)
+	return err
+}
+
+func (s *storage) DeleteByUUID(db, table, uuid string) error {
+	start := time.Now()
+	err := s.IStorage.DeleteByUUID(db, table, uuid)
+	observe("delete", table, start, err)
+	return err
+}
+
+func (s *storage) ListToObject(db, table string, filter map[string]interface{}, result interface{}, filterDelete bool) error {
+	start := time.Now()
+	err := s.IStorage.ListToObject(db, table, filter, result, filterDelete)
+	observe("list", table, start, err)
+	return err
+}
+
+func (s *storage) ListByFilter(db, table string, filter map[string]interface{}, filterDelete bool) ([]interface{}, error) {
+	start := time.Now()
+	result, err := s.IStorage.ListByFilter(db, table, filter, filterDelete)
+	observe("list", table, start, err)
+	return result, err
+}
+
+func (s *storage) InsertUnique(db, table string, id interface{}, data interface{}) error {
+	start := time.Now()
+	err := s.IStorage.InsertUnique(db, table, id, data)
+	observe("create", table, start, err)
+	return err
+}
+
+func (s *storage) GetById(db, table, id string, result interface{}) error {
+	start := time.Now()
+	err := s.IStorage.GetById(db, table, id, result)
+	observe("get", table, start, err)
+	return err
+}
+
+func (s *storage) Bulk(db, table string, objects []core.IObject) error {
+	start := time.Now()
+	err := s.IStorage.Bulk(db, table, objects)
+	observe("bulk", table, start, err)
+	return err
+}
+
+func (s *storage) RemoveTable(db, table string) error {
+	start := time.Now()
+	err := s.IStorage.RemoveTable(db, table)
+	observe("remove_table", table, start, err)
+	return err
+}
+
+func (s *storage) Watch(db, table string, resourceVersion string, watch datasource.WatchInterface, filters ...datasource.Filter) {
+	s.IStorage.Watch(db, table, resourceVersion, &lagWatch{WatchInterface: watch, table: table}, filters...)
+}
+
+func (s *storage) WatchEvent(ctx context.Context, db, table string, resourceVersion string, filters ...datasource.Filter) (<-chan core.Event, error) {
+	events, err := s.IStorage.WatchEvent(ctx, db, table, resourceVersion, filters...)
+	if err != nil {
+		storageErrors.WithLabelValues("watch", table).Inc()
+		return nil, err
+	}
+
+	result := make(chan core.Event)
+	watchStreams.WithLabelValues(table).Inc()
+	go func() {
+		defer func() {
+			watchStreams.WithLabelValues(table).Dec()
+			close(result)
+		}()
+		for event := range events {
+			if event.Object != nil {
+				observeLag(table, event.Object.GetResourceVersion())
+			}
+			select {
+			case result <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return result, nil
+}
+
+type lagWatch struct {
+	datasource.WatchInterface
+	table string
+}
+
+func (w *lagWatch) Handle(opData map[string]interface{}) error {
+	if version, ok := dict.Get(opData, "metadata.version").(string); ok {
+		observeLag(w.table, version)
+	}
+	return w.WatchInterface.Handle(opData)
+}
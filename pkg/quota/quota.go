@@ -0,0 +1,402 @@
+package quota
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/cr"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/utils/uuid"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Storage the part of the datasource quota reads and keeps its reservations in, satisfied by both the stage and the
+// services
+type Storage interface {
+	Create(db, table string, object core.IObject) (core.IObject, error)
+	DeleteByUUID(db, table, uuid string) error
+	GetByFilter(db, table string, result interface{}, filter map[string]interface{}, filterDelete bool) error
+	ListToObject(db, table string, filter map[string]interface{}, result interface{}, filterDelete bool) error
+}
+
+// reservationTimeout how long the reservation of an admission counts, it outlives any write so only the reservations of
+// a replica that died while admitting expire
+var reservationTimeout = time.Minute
+
+type Quota struct {
+	stage Storage
+}
+
+func New(stage Storage) *Quota {
+	return &Quota{stage: stage}
+}
+
+// Usage the quantities by quota name
+type Usage map[string]resource.Quantity
+
+func (u Usage) add(other Usage) {
+	for name, quantity := range other {
+		current := u[name]
+		current.Add(quantity)
+		u[name] = current
+	}
+}
+
+// Strings the usage formatted the way the limits are given
+func (u Usage) Strings() map[string]string {
+	result := make(map[string]string, len(u))
+	for name, quantity := range u {
+		result[name] = quantity.String()
+	}
+	return result
+}
+
+// Admit check the quotas of the workspace of object and of its tenant allow object, then write it. old is the stored
+// object on update, nil on create
+func (q *Quota) Admit(table string, object, old core.IObject, write func() error) error {
+	demand := Demand(table, object)
+	if old != nil {
+		for name, quantity := range Demand(table, old) {
+			current := demand[name]
+			current.Sub(quantity)
+			demand[name] = current
+		}
+	}
+	return q.admit(map[core.IObject]Usage{object: demand}, write)
+}
+
+// AdmitAll the quotas must allow every object created at once, e.g. by a batch load
+func (q *Quota) AdmitAll(table string, objects []core.IObject, write func() error) error {
+	demands := make(map[core.IObject]Usage, len(objects))
+	for _, object := range objects {
+		demands[object] = Demand(table, object)
+	}
+	return q.admit(demands, write)
+}
+
+type quotaDemand struct {
+	quota  system.ResourceQuota
+	demand Usage
+	// reservation the uuid of the reservation of the demand
+	reservation string
+}
+
+// admit reserve the demands on the quotas they fall under before checking them, and release the reservations once
+// written. Every admission counts the reservations of the others, so of two replicas admitting at once at least one
+// sees the demand of the other and the last of a quota is taken once, at worst both are refused
+func (q *Quota) admit(demands map[core.IObject]Usage, write func() error) error {
+	byQuota, err := q.byQuota(demands)
+	if err != nil {
+		return err
+	}
+	defer q.release(byQuota)
+
+	if err := q.reserve(byQuota); err != nil {
+		return err
+	}
+	if err := q.check(byQuota); err != nil {
+		return err
+	}
+	return write()
+}
+
+// byQuota sum the demands of the objects by the quotas they fall under, keyed by the tenant, workspace and name of the
+// quota. Only the names the quota limits and the object takes more of are kept
+func (q *Quota) byQuota(demands map[core.IObject]Usage) (map[string]*quotaDemand, error) {
+	byQuota := make(map[string]*quotaDemand)
+	for object, demand := range demands {
+		quotas, err := q.QuotasOf(object.GetWorkspace(), object.GetTenant())
+		if err != nil {
+			return nil, err
+		}
+		for _, quota := range quotas {
+			key := fmt.Sprintf("%s/%s/%s", quota.GetTenant(), quota.GetWorkspace(), quota.GetName())
+			if byQuota[key] == nil {
+				byQuota[key] = &quotaDemand{quota: quota, demand: Usage{}}
+			}
+			byQuota[key].demand.add(demand)
+		}
+	}
+
+	for key, quotaDemand := range byQuota {
+		for name, quantity := range quotaDemand.demand {
+			if _, exist := quotaDemand.quota.Spec.Hard[name]; !exist || quantity.Sign() <= 0 {
+				delete(quotaDemand.demand, name)
+			}
+		}
+		if len(quotaDemand.demand) == 0 {
+			delete(byQuota, key)
+		}
+	}
+	return byQuota, nil
+}
+
+func (q *Quota) reserve(byQuota map[string]*quotaDemand) error {
+	expire := time.Now().Add(reservationTimeout).Unix()
+	for key, quotaDemand := range byQuota {
+		reservation := &system.QuotaReservation{
+			Metadata: core.Metadata{Name: uuid.NewSUID().String()},
+			Spec:     system.QuotaReservationSpec{Quota: key, Demand: quotaDemand.demand.Strings(), Expire: expire},
+		}
+		if _, err := q.stage.Create(common.DefaultDatabase, common.QUOTARESERVATION, reservation); err != nil {
+			return fmt.Errorf("reserve quota %s: %v", quotaDemand.quota.GetName(), err)
+		}
+		quotaDemand.reservation = reservation.GetUUID()
+	}
+	return nil
+}
+
+func (q *Quota) release(byQuota map[string]*quotaDemand) {
+	for _, quotaDemand := range byQuota {
+		if quotaDemand.reservation == "" {
+			continue
+		}
+		_ = q.stage.DeleteByUUID(common.DefaultDatabase, common.QUOTARESERVATION, quotaDemand.reservation)
+	}
+}
+
+// reserved the demands the other admissions in progress reserved on the quota of key
+func (q *Quota) reserved(key string, quotaDemand *quotaDemand) (Usage, error) {
+	reservations := make([]system.QuotaReservation, 0)
+	filter := map[string]interface{}{"spec.quota": key}
+	if err := q.stage.ListToObject(common.DefaultDatabase, common.QUOTARESERVATION, filter, &reservations, true); err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	result := Usage{}
+	for _, reservation := range reservations {
+		if reservation.GetUUID() == quotaDemand.reservation || reservation.Spec.Expire < now {
+			continue
+		}
+		for name, value := range reservation.Spec.Demand {
+			if quantity, err := resource.ParseQuantity(value); err == nil {
+				result.add(Usage{name: quantity})
+			}
+		}
+	}
+	return result, nil
+}
+
+// check compare the demand on each quota with what neither the stored objects nor the other reservations take
+func (q *Quota) check(byQuota map[string]*quotaDemand) error {
+	keys := make([]string, 0, len(byQuota))
+	for key := range byQuota {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		quota, demand := &byQuota[key].quota, byQuota[key].demand
+		names := make([]string, 0, len(demand))
+		for name := range demand {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		used, err := q.Usage(quota, names)
+		if err != nil {
+			return err
+		}
+		reserved, err := q.reserved(key, byQuota[key])
+		if err != nil {
+			return err
+		}
+		used.add(reserved)
+		for _, name := range names {
+			hard, err := resource.ParseQuantity(quota.Spec.Hard[name])
+			if err != nil {
+				return fmt.Errorf("quota %s %s: %v", quota.GetName(), name, err)
+			}
+			usedQuantity, demandQuantity := used[name], demand[name]
+			requested := usedQuantity.DeepCopy()
+			requested.Add(demandQuantity)
+			if requested.Cmp(hard) > 0 {
+				return fmt.Errorf("exceeded quota %s of %s: %s used %s, requested %s, limited to %s",
+					quota.GetName(), scopeOf(quota), name, usedQuantity.String(), demandQuantity.String(), hard.String())
+			}
+		}
+	}
+	return nil
+}
+
+// QuotasOf the quotas of workspace and the ones of its tenant. The tenant of a workspace is taken from the workspace,
+// tenant is the fallback of the objects whose workspace is not recorded
+func (q *Quota) QuotasOf(workspace, tenant string) ([]system.ResourceQuota, error) {
+	if workspace != "" {
+		ws := &system.Workspace{}
+		if err := q.stage.GetByFilter(common.DefaultDatabase, common.WORKSPACE, ws, map[string]interface{}{common.FilterName: workspace}, true); err == nil && ws.Spec.Tenant != "" {
+			tenant = ws.Spec.Tenant
+		}
+	}
+
+	quotas := make([]system.ResourceQuota, 0)
+	if err := q.stage.ListToObject(common.DefaultDatabase, common.RESOURCEQUOTA, map[string]interface{}{}, &quotas, true); err != nil {
+		return nil, err
+	}
+	result := make([]system.ResourceQuota, 0)
+	for _, quota := range quotas {
+		switch {
+		case quota.GetWorkspace() != "" && quota.GetWorkspace() == workspace:
+			result = append(result, quota)
+		case quota.GetWorkspace() == "" && quota.GetTenant() != "" && quota.GetTenant() == tenant:
+			result = append(result, quota)
+		}
+	}
+	return result, nil
+}
+
+// Usage the usage of names in the scope of quota, every name of the quota when names is empty
+func (q *Quota) Usage(quota *system.ResourceQuota, names []string) (Usage, error) {
+	if len(names) == 0 {
+		for name := range quota.Spec.Hard {
+			names = append(names, name)
+		}
+	}
+	workspaces, err := q.workspacesOf(quota)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]bool)
+	for _, name := range names {
+		switch {
+		case name == system.QuotaCPU, name == system.QuotaMemory:
+			tables[common.VIRTUALMACHINE] = true
+		case name == system.QuotaStorage:
+			tables[common.VIRTUALMACHINE] = true
+			tables[common.STORAGE] = true
+		case strings.HasPrefix(name, system.QuotaCountPrefix):
+			tables[strings.TrimPrefix(name, system.QuotaCountPrefix)] = true
+		}
+	}
+
+	total := Usage{}
+	for _, workspace := range workspaces {
+		for table := range tables {
+			objects, err := q.objectsOf(table, workspace)
+			if err != nil {
+				return nil, err
+			}
+			for _, object := range objects {
+				total.add(Demand(table, object))
+			}
+		}
+	}
+
+	result := Usage{}
+	for _, name := range names {
+		result[name] = total[name]
+	}
+	return result, nil
+}
+
+// workspacesOf the workspaces a quota applies to, every workspace of the tenant for a tenant quota
+func (q *Quota) workspacesOf(quota *system.ResourceQuota) ([]string, error) {
+	if quota.GetWorkspace() != "" {
+		return []string{quota.GetWorkspace()}, nil
+	}
+	workspaces := make([]system.Workspace, 0)
+	if err := q.stage.ListToObject(common.DefaultDatabase, common.WORKSPACE, map[string]interface{}{}, &workspaces, true); err != nil {
+		return nil, err
+	}
+	result := make([]string, 0)
+	for _, workspace := range workspaces {
+		if workspace.Spec.Tenant == quota.GetTenant() {
+			result = append(result, workspace.GetName())
+		}
+	}
+	return result, nil
+}
+
+func (q *Quota) objectsOf(table, workspace string) ([]core.IObject, error) {
+	filter := map[string]interface{}{common.FilterWorkspace: workspace}
+	result := make([]core.IObject, 0)
+
+	switch table {
+	case common.VIRTUALMACHINE:
+		vms := make([]compute.VirtualMachine, 0)
+		if err := q.stage.ListToObject(common.DefaultDatabase, table, filter, &vms, true); err != nil {
+			return nil, err
+		}
+		for index := range vms {
+			result = append(result, &vms[index])
+		}
+	case common.STORAGE:
+		storages := make([]compute.Storage, 0)
+		if err := q.stage.ListToObject(common.DefaultDatabase, table, filter, &storages, true); err != nil {
+			return nil, err
+		}
+		for index := range storages {
+			result = append(result, &storages[index])
+		}
+	case system.QuotaCustomData:
+		// the custom data of a custom resource is kept in a table of its name
+		customResources := make([]cr.CustomResource, 0)
+		if err := q.stage.ListToObject(common.DefaultDatabase, common.CUSTOMRESOURCE, map[string]interface{}{}, &customResources, true); err != nil {
+			return nil, err
+		}
+		for _, customResource := range customResources {
+			data := make([]core.DefaultObject, 0)
+			if err := q.stage.ListToObject(common.CustomDatabase, customResource.GetName(), filter, &data, true); err != nil {
+				return nil, err
+			}
+			for index := range data {
+				result = append(result, &data[index])
+			}
+		}
+	default:
+		objects := make([]core.DefaultObject, 0)
+		if err := q.stage.ListToObject(common.DefaultDatabase, table, filter, &objects, true); err != nil {
+			return nil, err
+		}
+		for index := range objects {
+			result = append(result, &objects[index])
+		}
+	}
+	return result, nil
+}
+
+// Demand what a single object of table takes from a quota: one of its count, the cores and memory of a vm, the capacity
+// of a disk or of the volumes a vm is created with. A hotplugged vm volume is a disk counted on its own
+func Demand(table string, object core.IObject) Usage {
+	usage := Usage{system.QuotaCount(table): resource.MustParse("1")}
+
+	switch obj := object.(type) {
+	case *compute.VirtualMachine:
+		if cpu, err := resource.ParseQuantity(obj.Spec.CPU); err == nil {
+			usage[system.QuotaCPU] = cpu
+		}
+		if memory, err := resource.ParseQuantity(obj.Spec.Memory); err == nil {
+			usage[system.QuotaMemory] = memory
+		}
+		storage := resource.Quantity{}
+		for _, volume := range obj.Spec.Storage {
+			if volume.Type == compute.VmStorageTypeHotplug {
+				continue
+			}
+			if quantity, err := resource.ParseQuantity(volume.Quantity); err == nil {
+				storage.Add(quantity)
+				continue
+			}
+			if volume.VolumeSize > 0 {
+				storage.Add(*resource.NewQuantity(volume.VolumeSize<<30, resource.BinarySI))
+			}
+		}
+		usage[system.QuotaStorage] = storage
+	case *compute.Storage:
+		usage[system.QuotaStorage] = *resource.NewQuantity(int64(obj.Spec.Size)<<30, resource.BinarySI)
+	}
+	return usage
+}
+
+func scopeOf(quota *system.ResourceQuota) string {
+	if quota.GetWorkspace() != "" {
+		return fmt.Sprintf("workspace %s", quota.GetWorkspace())
+	}
+	return fmt.Sprintf("tenant %s", quota.GetTenant())
+}
@@ -0,0 +1,163 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+func newQuota(t *testing.T) (*Quota, *memory.Memory) {
+	stage := memory.NewMemory()
+	for _, name := range []string{"ws1", "ws2"} {
+		workspace := &system.Workspace{Metadata: core.Metadata{Name: name, Workspace: "tenant1"}}
+		workspace.Spec.Tenant = "tenant1"
+		if _, err := stage.Create(common.DefaultDatabase, common.WORKSPACE, workspace); err != nil {
+			t.Fatal(err)
+		}
+	}
+	quotas := []*system.ResourceQuota{
+		{Metadata: core.Metadata{Name: "ws1-quota", Workspace: "ws1", Tenant: "tenant1"},
+			Spec: system.ResourceQuotaSpec{Hard: map[string]string{system.QuotaCount(common.VIRTUALMACHINE): "2"}}},
+		{Metadata: core.Metadata{Name: "tenant1-quota", Tenant: "tenant1"},
+			Spec: system.ResourceQuotaSpec{Hard: map[string]string{system.QuotaCPU: "6", system.QuotaStorage: "100Gi"}}},
+	}
+	for _, quota := range quotas {
+		if _, err := stage.Create(common.DefaultDatabase, common.RESOURCEQUOTA, quota); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return New(stage), stage
+}
+
+func newVM(name, workspace, cpu string) *compute.VirtualMachine {
+	vm := &compute.VirtualMachine{Metadata: core.Metadata{Name: name, Workspace: workspace}}
+	vm.Spec.CPU, vm.Spec.Memory = cpu, "4Gi"
+	vm.Spec.Storage = []compute.VmStorage{{Quantity: "20Gi"}, {VolumeSize: 100, Type: compute.VmStorageTypeHotplug}}
+	return vm
+}
+
+func TestAdmit(t *testing.T) {
+	q, stage := newQuota(t)
+	create := func(vm *compute.VirtualMachine) error {
+		return q.Admit(common.VIRTUALMACHINE, vm, nil, func() error {
+			_, err := stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm)
+			return err
+		})
+	}
+
+	if err := create(newVM("vm1", "ws1", "2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := create(newVM("vm2", "ws2", "2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := create(newVM("vm3", "ws2", "4")); err == nil {
+		t.Fatal("expected the cpu quota of the tenant to refuse vm3")
+	}
+	if err := create(newVM("vm3", "ws1", "1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := create(newVM("vm4", "ws1", "1")); err == nil {
+		t.Fatal("expected the vm count quota of ws1 to refuse vm4")
+	}
+	if err := create(newVM("vm4", "ws3", "8")); err != nil {
+		t.Fatalf("expected a workspace without quota to be admitted, got %v", err)
+	}
+
+	old := newVM("vm1", "ws1", "2")
+	if err := q.Admit(common.VIRTUALMACHINE, newVM("vm1", "ws1", "3"), old, func() error { return nil }); err != nil {
+		t.Fatalf("expected resizing vm1 to 3 cores to be admitted, got %v", err)
+	}
+	if err := q.Admit(common.VIRTUALMACHINE, newVM("vm1", "ws1", "4"), old, func() error { return nil }); err == nil {
+		t.Fatal("expected resizing vm1 to 4 cores to be refused")
+	}
+
+	disks := []core.IObject{
+		&compute.Storage{Metadata: core.Metadata{Name: "disk1", Workspace: "ws1"}, Spec: compute.StorageSpec{Size: 25}},
+		&compute.Storage{Metadata: core.Metadata{Name: "disk2", Workspace: "ws2"}, Spec: compute.StorageSpec{Size: 25}},
+	}
+	if err := q.AdmitAll(common.STORAGE, disks, func() error { return nil }); err == nil {
+		t.Fatal("expected the disks to exceed the storage quota of the tenant together")
+	}
+	if err := q.AdmitAll(common.STORAGE, disks[:1], func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAdmitReservation two replicas admitting at once against the same stage
+func TestAdmitReservation(t *testing.T) {
+	first, stage := newQuota(t)
+	second := New(stage)
+	create := func(vm *compute.VirtualMachine) func() error {
+		return func() error {
+			_, err := stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm)
+			return err
+		}
+	}
+	if err := first.Admit(common.VIRTUALMACHINE, newVM("vm1", "ws1", "1"), nil, create(newVM("vm1", "ws1", "1"))); err != nil {
+		t.Fatal(err)
+	}
+
+	// the second replica admits the last vm of ws1 while the first one is about to write it
+	err := first.Admit(common.VIRTUALMACHINE, newVM("vm2", "ws1", "1"), nil, func() error {
+		if err := second.Admit(common.VIRTUALMACHINE, newVM("vm3", "ws1", "1"), nil, create(newVM("vm3", "ws1", "1"))); err == nil {
+			t.Error("expected the reservation of vm2 to refuse vm3")
+		}
+		return create(newVM("vm2", "ws1", "1"))()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reservations := make([]system.QuotaReservation, 0)
+	if err := stage.ListToObject(common.DefaultDatabase, common.QUOTARESERVATION, map[string]interface{}{}, &reservations, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(reservations) != 0 {
+		t.Fatalf("expected the reservations to be released, got %v", reservations)
+	}
+
+	// the reservation of a replica that died no longer counts once expired
+	expired := &system.QuotaReservation{Metadata: core.Metadata{Name: "expired"}}
+	expired.Spec = system.QuotaReservationSpec{Quota: "tenant1//tenant1-quota", Demand: map[string]string{system.QuotaCPU: "4"}}
+	if _, err := stage.Create(common.DefaultDatabase, common.QUOTARESERVATION, expired); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Admit(common.VIRTUALMACHINE, newVM("vm1", "ws2", "4"), nil, func() error { return nil }); err != nil {
+		t.Fatalf("expected an expired reservation not to count, got %v", err)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	q, stage := newQuota(t)
+	for _, vm := range []*compute.VirtualMachine{newVM("vm1", "ws1", "2"), newVM("vm2", "ws2", "1"), newVM("vm3", "other", "8")} {
+		if _, err := stage.Create(common.DefaultDatabase, common.VIRTUALMACHINE, vm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	disk := &compute.Storage{Metadata: core.Metadata{Name: "disk1", Workspace: "ws2"}, Spec: compute.StorageSpec{Size: 10}}
+	if _, err := stage.Create(common.DefaultDatabase, common.STORAGE, disk); err != nil {
+		t.Fatal(err)
+	}
+
+	quotas, err := q.QuotasOf("ws2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotas) != 1 || quotas[0].GetName() != "tenant1-quota" {
+		t.Fatalf("expected the tenant quota to apply to ws2, got %v", quotas)
+	}
+
+	usage, err := q.Usage(&quotas[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := usage.Strings()
+	if used[system.QuotaCPU] != "3" || used[system.QuotaStorage] != "50Gi" {
+		t.Fatalf("expected 3 cores and 50Gi used by tenant1, got %v", used)
+	}
+}
@@ -1,22 +1,93 @@
 package compute
 
 import (
+	"fmt"
+
 	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 )
 
 const ImageKind core.Kind = "image"
 
+const (
+	ImageSourceRegistry = "registry"
+	ImageSourceHTTP     = "http"
+
+	ImageStateImporting = "importing"
+	ImageStateReady     = "ready"
+	ImageStateFailed    = "failed"
+
+	// ImageNamespace the namespace of every cluster holding the golden volumes the vms clone their disks from
+	ImageNamespace = "oilmont-images"
+
+	ImageNameLabel          = "image.ddx2x.nip/name"
+	ImageWorkspaceLabel     = "image.ddx2x.nip/workspace"
+	ImageChecksumAnnotation = "image.ddx2x.nip/checksum"
+)
+
+// ImageImport the golden volume of the image in a cluster
+type ImageImport struct {
+	Cluster  string `json:"cluster" bson:"cluster"`
+	Phase    string `json:"phase" bson:"phase"`
+	Progress string `json:"progress" bson:"progress"`
+	// Checksum the checksum of the image when the volume was imported, a changed checksum imports the image again
+	Checksum string `json:"checksum" bson:"checksum"`
+	Message  string `json:"message" bson:"message"`
+}
+
 type ImageSpec struct {
-	Version string `json:"version"`
-	Os      string `json:"os"`
-	Region  string `json:"region"`
-	ID      string `json:"id"`
+	Version string `json:"version" bson:"version"`
+	Os      string `json:"os" bson:"os"`
+	Region  string `json:"region" bson:"region"`
+	ID      string `json:"id" bson:"id"`
+
+	// catalogue, images registered from a container registry or a http url and imported into every cluster
+	SourceType string        `json:"source_type" bson:"source_type"`
+	Source     string        `json:"source" bson:"source"`
+	Checksum   string        `json:"checksum" bson:"checksum"`
+	Size       string        `json:"size" bson:"size"`
+	Public     bool          `json:"public" bson:"public"`
+	Imports    []ImageImport `json:"imports" bson:"imports"`
+	State      string        `json:"state" bson:"state"`
+	Status     string        `json:"status" bson:"status"`
+	Message    string        `json:"message" bson:"message"`
 }
 
 type Image struct {
 	core.Metadata `json:"metadata"`
-	Spec          ImageSpec `json:"spec"`
+	Spec          ImageSpec   `json:"spec"`
+	Status        core.Status `json:"status"`
+}
+
+func (i *Image) GetStatus() *core.Status { return &i.Status }
+
+// IsCatalogue the image was registered in the catalogue rather than discovered from a cloud provider
+func (i *Image) IsCatalogue() bool { return i.Spec.Source != "" }
+
+// VisibleTo a public image is visible to every tenant, a private one to its tenant and workspace only
+func (i *Image) VisibleTo(tenant, workspace string) bool {
+	if i.Spec.Public {
+		return true
+	}
+	if i.Tenant != "" && i.Tenant == tenant {
+		return true
+	}
+	return i.GetWorkspace() == workspace
+}
+
+// VolumeName the golden volume of the image, unique across the workspaces sharing ImageNamespace
+func (i *Image) VolumeName() string {
+	return fmt.Sprintf("image-%s-%s", i.GetWorkspace(), i.GetName())
+}
+
+// ImportOf the import of the image into cluster
+func (i *Image) ImportOf(cluster string) (*ImageImport, bool) {
+	for index := range i.Spec.Imports {
+		if i.Spec.Imports[index].Cluster == cluster {
+			return &i.Spec.Imports[index], true
+		}
+	}
+	return nil, false
 }
 
 func (i *Image) Clone() core.IObject {
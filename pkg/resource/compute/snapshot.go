@@ -0,0 +1,138 @@
+package compute
+
+import (
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+)
+
+const (
+	VirtualMachineSnapshotKind core.Kind = "virtualmachinesnapshot"
+	VirtualMachineRestoreKind  core.Kind = "virtualmachinerestore"
+)
+
+const (
+	SnapshotStateCreating = "creating"
+	SnapshotStateReady    = "ready"
+	SnapshotStateFailed   = "failed"
+
+	RestoreStateRestoring = "restoring"
+	RestoreStateSucceeded = "succeeded"
+	RestoreStateFailed    = "failed"
+)
+
+// SnapshotRetention ready snapshots of a vm older than Days or beyond the newest Count are removed, zero means unlimited
+type SnapshotRetention struct {
+	Days  int `json:"days" bson:"days"`
+	Count int `json:"count" bson:"count"`
+}
+
+// DiskSnapshot the provider snapshot taken from a disk of a third-party vm
+type DiskSnapshot struct {
+	StorageId  string `json:"storage_id" bson:"storage_id"`
+	SnapshotId string `json:"snapshot_id" bson:"snapshot_id"`
+}
+
+type VirtualMachineSnapshotSpec struct {
+	VirtualMachine string            `json:"virtual_machine" bson:"virtual_machine"`
+	Vendor         string            `json:"vendor" bson:"vendor"`
+	RegionId       string            `json:"region_id" bson:"region_id"`
+	Retention      SnapshotRetention `json:"retention" bson:"retention"`
+	Disks          []DiskSnapshot    `json:"disks" bson:"disks"`
+	State          string            `json:"state" bson:"state"`
+	Status         string            `json:"status" bson:"status"`
+	Message        string            `json:"message" bson:"message"`
+	CreateTime     string            `json:"create_time" bson:"create_time"`
+}
+
+type VirtualMachineSnapshot struct {
+	core.Metadata `json:"metadata"`
+	Spec          VirtualMachineSnapshotSpec `json:"spec"`
+	Status        core.Status                `json:"status"`
+}
+
+func (v *VirtualMachineSnapshot) GetStatus() *core.Status { return &v.Status }
+
+func (v *VirtualMachineSnapshot) Clone() core.IObject {
+	result := &VirtualMachineSnapshot{}
+	core.Clone(v, result)
+	return result
+}
+
+func (*VirtualMachineSnapshot) Decode(opData map[string]interface{}) (core.IObject, error) {
+	snapshot := &VirtualMachineSnapshot{}
+	if err := core.UnmarshalToIObject(opData, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+type VirtualMachineSnapshotList struct {
+	core.Metadata `json:"metadata"`
+	Items         []VirtualMachineSnapshot `json:"items"`
+}
+
+func (a *VirtualMachineSnapshotList) GenerateListVersion() {
+	var maxVersion string
+	for _, item := range a.Items {
+		if item.Version > maxVersion {
+			maxVersion = item.Version
+		}
+	}
+	a.Metadata = core.Metadata{
+		Kind:    "virtualMachineSnapshotList",
+		Version: maxVersion,
+	}
+}
+
+type VirtualMachineRestoreSpec struct {
+	VirtualMachine string `json:"virtual_machine" bson:"virtual_machine"`
+	Snapshot       string `json:"snapshot" bson:"snapshot"`
+	State          string `json:"state" bson:"state"`
+	Status         string `json:"status" bson:"status"`
+	Message        string `json:"message" bson:"message"`
+}
+
+type VirtualMachineRestore struct {
+	core.Metadata `json:"metadata"`
+	Spec          VirtualMachineRestoreSpec `json:"spec"`
+	Status        core.Status               `json:"status"`
+}
+
+func (v *VirtualMachineRestore) GetStatus() *core.Status { return &v.Status }
+
+func (v *VirtualMachineRestore) Clone() core.IObject {
+	result := &VirtualMachineRestore{}
+	core.Clone(v, result)
+	return result
+}
+
+func (*VirtualMachineRestore) Decode(opData map[string]interface{}) (core.IObject, error) {
+	restore := &VirtualMachineRestore{}
+	if err := core.UnmarshalToIObject(opData, restore); err != nil {
+		return nil, err
+	}
+	return restore, nil
+}
+
+type VirtualMachineRestoreList struct {
+	core.Metadata `json:"metadata"`
+	Items         []VirtualMachineRestore `json:"items"`
+}
+
+func (a *VirtualMachineRestoreList) GenerateListVersion() {
+	var maxVersion string
+	for _, item := range a.Items {
+		if item.Version > maxVersion {
+			maxVersion = item.Version
+		}
+	}
+	a.Metadata = core.Metadata{
+		Kind:    "virtualMachineRestoreList",
+		Version: maxVersion,
+	}
+}
+
+func init() {
+	datasource.RegistryCoder(string(VirtualMachineSnapshotKind), &VirtualMachineSnapshot{})
+	datasource.RegistryCoder(string(VirtualMachineRestoreKind), &VirtualMachineRestore{})
+}
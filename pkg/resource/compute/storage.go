@@ -32,8 +32,16 @@ const (
 	StorageStateAttach = "attach"
 	StorageStateDetach = "detach"
 	StorageStateDelete = "delete"
+	StorageStateResize = "resize"
 )
 
+// StorageActions actions accepted on a storage and the state recorded as their intent
+var StorageActions = map[string]string{
+	"attach": StorageStateAttach,
+	"detach": StorageStateDetach,
+	"resize": StorageStateResize,
+}
+
 type Attachment struct {
 	AttachedTime string `json:"attached_time" bson:"attached_time"`
 	Device       string `json:"device" bson:"device"`
@@ -58,13 +66,18 @@ type StorageSpec struct {
 	State              string       `json:"state" bson:"state"`
 	Status             string       `json:"status" bson:"status"`
 	StorageId          string       `json:"storage_id" bson:"storage_id"`
+	// VirtualMachine the vm the storage is attached to, or is to be attached to by an attach action
+	VirtualMachine string `json:"virtual_machine" bson:"virtual_machine"`
 }
 
 type Storage struct {
 	core.Metadata `json:"metadata"`
 	Spec          StorageSpec `json:"spec"`
+	Status        core.Status `json:"status"`
 }
 
+func (s *Storage) GetStatus() *core.Status { return &s.Status }
+
 func (s *Storage) Clone() core.IObject {
 	result := &Storage{}
 	core.Clone(s, result)
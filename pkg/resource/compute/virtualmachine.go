@@ -22,8 +22,25 @@ const (
 	Ready        VirtualMachineStateType = "ready"
 	Unknown      VirtualMachineStateType = "unknown"
 	Provisioning VirtualMachineStateType = "Provisioning"
+	Paused       VirtualMachineStateType = "paused"
+	Pausing      VirtualMachineStateType = "pausing"
+	Unpausing    VirtualMachineStateType = "unpausing"
+	Migrating    VirtualMachineStateType = "migrating"
 )
 
+// VirtualMachineActions power actions accepted on a vm and the state recorded as their intent
+var VirtualMachineActions = map[string]VirtualMachineStateType{
+	"start":   Starting,
+	"stop":    Stopping,
+	"restart": Restarting,
+	"pause":   Pausing,
+	"unpause": Unpausing,
+	"migrate": Migrating,
+}
+
+// VmStorageTypeHotplug a storage attached to the vm after it was created
+const VmStorageTypeHotplug = "Hotplug"
+
 type VmStorage struct {
 	Name   string `json:"name" bson:"name"`
 	Status string `json:"status" bson:"status"`
@@ -32,6 +49,8 @@ type VmStorage struct {
 	Quantity string `json:"quantity" bson:"quantity"`
 	Registry string `json:"registry" bson:"registry"`
 	Type     string `json:"type" bson:"type"`
+	// Image the catalogue image the disk is cloned from, takes precedence over Registry
+	Image string `json:"image" bson:"image"`
 
 	// thirdProvider
 	DeleteOnTermination bool   `json:"delete_on_termination" bson:"delete_on_termination"`
@@ -83,13 +102,20 @@ type VirtualMachineSpec struct {
 	NetWorkInterface []NetWorkInterface      `json:"network_interface" bson:"network_interface"`
 	CreateTime       string                  `json:"create_time" bson:"create_time"`
 	Os               string                  `json:"os" bson:"os"`
+	// License name of the system license injected as ssh key or password
+	License     string `json:"license" bson:"license"`
+	UserData    string `json:"user_data" bson:"user_data"`
+	NetworkData string `json:"network_data" bson:"network_data"`
 }
 
 type VirtualMachine struct {
 	core.Metadata `json:"metadata"`
 	Spec          VirtualMachineSpec `json:"spec"`
+	Status        core.Status        `json:"status"`
 }
 
+func (v *VirtualMachine) GetStatus() *core.Status { return &v.Status }
+
 func (v *VirtualMachine) Clone() core.IObject {
 	result := &VirtualMachine{}
 	core.Clone(v, result)
@@ -0,0 +1,79 @@
+package networking
+
+import (
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+)
+
+const ElasticIPKind core.Kind = "elasticip"
+
+const (
+	ElasticIPAvailable  = "available"
+	ElasticIPAssociated = "associated"
+	ElasticIPFailed     = "failed"
+)
+
+// ElasticIPSpec a public address allocated by the provider, or taken from a MetalLB pool of a local cluster,
+// and bound to a vm
+type ElasticIPSpec struct {
+	Vendor string `json:"vendor" bson:"vendor"`
+	Region string `json:"region" bson:"region"`
+	Az     string `json:"az" bson:"az"`
+	// Address the address requested, filled by the provider when left empty. A local address must be set
+	Address      string `json:"address" bson:"address"`
+	AllocationId string `json:"allocation_id" bson:"allocation_id"`
+	// Bandwidth Mbps, the provider default when zero
+	Bandwidth int `json:"bandwidth" bson:"bandwidth"`
+	// VirtualMachine the vm of the workspace the address is bound to, unbound when empty
+	VirtualMachine string `json:"virtual_machine" bson:"virtual_machine"`
+	InstanceId     string `json:"instance_id" bson:"instance_id"`
+	// Ports the tcp ports a local cluster forwards to the vm, a Service can not forward every port
+	Ports   []int  `json:"ports" bson:"ports"`
+	State   string `json:"state" bson:"state"`
+	Status  string `json:"status" bson:"status"`
+	Message string `json:"message" bson:"message"`
+}
+
+type ElasticIP struct {
+	core.Metadata `json:"metadata"`
+	Spec          ElasticIPSpec `json:"spec"`
+	Status        core.Status   `json:"status"`
+}
+
+func (e *ElasticIP) GetStatus() *core.Status { return &e.Status }
+
+func (e *ElasticIP) Clone() core.IObject {
+	result := &ElasticIP{}
+	core.Clone(e, result)
+	return result
+}
+
+func (*ElasticIP) Decode(opData map[string]interface{}) (core.IObject, error) {
+	elasticIP := &ElasticIP{}
+	if err := core.UnmarshalToIObject(opData, elasticIP); err != nil {
+		return nil, err
+	}
+	return elasticIP, nil
+}
+
+type ElasticIPList struct {
+	core.Metadata `json:"metadata"`
+	Items         []ElasticIP `json:"items"`
+}
+
+func (e *ElasticIPList) GenerateListVersion() {
+	var maxVersion string
+	for _, item := range e.Items {
+		if item.Version > maxVersion {
+			maxVersion = item.Version
+		}
+	}
+	e.Metadata = core.Metadata{
+		Kind:    "elasticIPList",
+		Version: maxVersion,
+	}
+}
+
+func init() {
+	datasource.RegistryCoder(string(ElasticIPKind), &ElasticIP{})
+}
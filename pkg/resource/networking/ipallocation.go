@@ -0,0 +1,62 @@
+package networking
+
+import (
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+)
+
+const IPAllocationKind core.Kind = "ipallocation"
+
+// IPAllocationSpec a private address of a vswitch held by a vm or a network interface,
+// the name is derived from the vswitch and the address so an address is only held once
+type IPAllocationSpec struct {
+	VSwitch   string `json:"vswitch" bson:"vswitch"`
+	VpcId     string `json:"vpc_id" bson:"vpc_id"`
+	Address   string `json:"address" bson:"address"`
+	OwnerKind string `json:"owner_kind" bson:"owner_kind"`
+	Owner     string `json:"owner" bson:"owner"`
+}
+
+type IPAllocation struct {
+	core.Metadata `json:"metadata"`
+	Spec          IPAllocationSpec `json:"spec"`
+	Status        core.Status      `json:"status"`
+}
+
+func (i *IPAllocation) GetStatus() *core.Status { return &i.Status }
+
+func (i *IPAllocation) Clone() core.IObject {
+	result := &IPAllocation{}
+	core.Clone(i, result)
+	return result
+}
+
+func (*IPAllocation) Decode(opData map[string]interface{}) (core.IObject, error) {
+	action := &IPAllocation{}
+	if err := core.UnmarshalToIObject(opData, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+type IPAllocationList struct {
+	core.Metadata `json:"metadata"`
+	Items         []IPAllocation `json:"items"`
+}
+
+func (i *IPAllocationList) GenerateListVersion() {
+	var maxVersion string
+	for _, item := range i.Items {
+		if item.Version > maxVersion {
+			maxVersion = item.Version
+		}
+	}
+	i.Metadata = core.Metadata{
+		Kind:    "ipAllocationList",
+		Version: maxVersion,
+	}
+}
+
+func init() {
+	datasource.RegistryCoder(string(IPAllocationKind), &IPAllocation{})
+}
@@ -0,0 +1,197 @@
+package networking
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+)
+
+const LoadBalancerKind core.Kind = "loadbalancer"
+
+type ListenerProtocol = string
+
+const (
+	ListenerTCP  ListenerProtocol = "TCP"
+	ListenerUDP  ListenerProtocol = "UDP"
+	ListenerHTTP ListenerProtocol = "HTTP"
+)
+
+const (
+	LoadBalancerActive  = "active"
+	LoadBalancerPending = "pending"
+	LoadBalancerFailed  = "failed"
+)
+
+// Listener a port of the load balancer forwarded to BackendPort of every backend vm
+type Listener struct {
+	Protocol    ListenerProtocol `json:"protocol" bson:"protocol"`
+	Port        int              `json:"port" bson:"port"`
+	BackendPort int              `json:"backend_port" bson:"backend_port"`
+}
+
+// HealthCheck the probe the provider takes the backends out of rotation by, Path is only used by http listeners.
+// Local clusters only forward to the vms kubeVirt reports ready
+type HealthCheck struct {
+	Protocol           ListenerProtocol `json:"protocol" bson:"protocol"`
+	Port               int              `json:"port" bson:"port"`
+	Path               string           `json:"path" bson:"path"`
+	Interval           int              `json:"interval" bson:"interval"`
+	Timeout            int              `json:"timeout" bson:"timeout"`
+	HealthyThreshold   int              `json:"healthy_threshold" bson:"healthy_threshold"`
+	UnhealthyThreshold int              `json:"unhealthy_threshold" bson:"unhealthy_threshold"`
+}
+
+type LoadBalancerSpec struct {
+	Vendor  string   `json:"vendor" bson:"vendor"`
+	Region  string   `json:"region" bson:"region"`
+	Az      string   `json:"az" bson:"az"`
+	VPCId   string   `json:"vpc_id" bson:"vpc_id"`
+	Subnets []string `json:"subnets" bson:"subnets"`
+	// Internal the load balancer is only reachable from the vpc
+	Internal  bool       `json:"internal" bson:"internal"`
+	Listeners []Listener `json:"listeners" bson:"listeners"`
+	// Backends the vms of the workspace the listeners forward to
+	Backends    []string    `json:"backends" bson:"backends"`
+	HealthCheck HealthCheck `json:"health_check" bson:"health_check"`
+	// ElasticIP the elastic ip of the workspace the load balancer is exposed on, a local cluster picks an address
+	// of its MetalLB pools when empty
+	ElasticIP      string `json:"elastic_ip" bson:"elastic_ip"`
+	LoadBalancerId string `json:"load_balancer_id" bson:"load_balancer_id"`
+	Address        string `json:"address" bson:"address"`
+	State          string `json:"state" bson:"state"`
+	Status         string `json:"status" bson:"status"`
+	Message        string `json:"message" bson:"message"`
+}
+
+// Normalize fill the defaults of the listeners and of the health check
+func (l *LoadBalancerSpec) Normalize() {
+	for index := range l.Listeners {
+		listener := &l.Listeners[index]
+		listener.Protocol = strings.ToUpper(listener.Protocol)
+		if listener.Protocol == "" {
+			listener.Protocol = ListenerTCP
+		}
+		if listener.BackendPort == 0 {
+			listener.BackendPort = listener.Port
+		}
+	}
+
+	check := &l.HealthCheck
+	check.Protocol = strings.ToUpper(check.Protocol)
+	if check.Protocol == "" {
+		check.Protocol = ListenerTCP
+	}
+	if check.Port == 0 && len(l.Listeners) > 0 {
+		check.Port = l.Listeners[0].BackendPort
+	}
+	if check.Protocol == ListenerHTTP && check.Path == "" {
+		check.Path = "/"
+	}
+	if check.Interval == 0 {
+		check.Interval = 10
+	}
+	if check.Timeout == 0 {
+		check.Timeout = 5
+	}
+	if check.HealthyThreshold == 0 {
+		check.HealthyThreshold = 3
+	}
+	if check.UnhealthyThreshold == 0 {
+		check.UnhealthyThreshold = 3
+	}
+}
+
+// Validate the listeners and the health check, a port may only be listened on once
+func (l *LoadBalancerSpec) Validate() error {
+	if len(l.Listeners) == 0 {
+		return fmt.Errorf("load balancer requires a listener")
+	}
+	ports := make(map[int]bool)
+	for _, listener := range l.Listeners {
+		switch listener.Protocol {
+		case ListenerTCP, ListenerUDP, ListenerHTTP:
+		default:
+			return fmt.Errorf("listener %d: invalid protocol %s", listener.Port, listener.Protocol)
+		}
+		if !validPort(listener.Port) || !validPort(listener.BackendPort) {
+			return fmt.Errorf("listener %d: ports must be within 1-65535", listener.Port)
+		}
+		if ports[listener.Port] {
+			return fmt.Errorf("listener %d: port listened on twice", listener.Port)
+		}
+		ports[listener.Port] = true
+	}
+
+	check := l.HealthCheck
+	switch check.Protocol {
+	case ListenerTCP, ListenerHTTP:
+	default:
+		return fmt.Errorf("health check: invalid protocol %s", check.Protocol)
+	}
+	if !validPort(check.Port) {
+		return fmt.Errorf("health check: port must be within 1-65535")
+	}
+	if check.Timeout >= check.Interval {
+		return fmt.Errorf("health check: timeout must be shorter than the interval")
+	}
+	if check.HealthyThreshold < 2 || check.HealthyThreshold > 10 || check.UnhealthyThreshold < 2 || check.UnhealthyThreshold > 10 {
+		return fmt.Errorf("health check: thresholds must be within 2-10")
+	}
+
+	backends := make(map[string]bool)
+	for _, backend := range l.Backends {
+		if backends[backend] {
+			return fmt.Errorf("backend %s listed twice", backend)
+		}
+		backends[backend] = true
+	}
+	return nil
+}
+
+func validPort(port int) bool { return port >= 1 && port <= 65535 }
+
+type LoadBalancer struct {
+	core.Metadata `json:"metadata"`
+	Spec          LoadBalancerSpec `json:"spec"`
+	Status        core.Status      `json:"status"`
+}
+
+func (l *LoadBalancer) GetStatus() *core.Status { return &l.Status }
+
+func (l *LoadBalancer) Clone() core.IObject {
+	result := &LoadBalancer{}
+	core.Clone(l, result)
+	return result
+}
+
+func (*LoadBalancer) Decode(opData map[string]interface{}) (core.IObject, error) {
+	loadBalancer := &LoadBalancer{}
+	if err := core.UnmarshalToIObject(opData, loadBalancer); err != nil {
+		return nil, err
+	}
+	return loadBalancer, nil
+}
+
+type LoadBalancerList struct {
+	core.Metadata `json:"metadata"`
+	Items         []LoadBalancer `json:"items"`
+}
+
+func (l *LoadBalancerList) GenerateListVersion() {
+	var maxVersion string
+	for _, item := range l.Items {
+		if item.Version > maxVersion {
+			maxVersion = item.Version
+		}
+	}
+	l.Metadata = core.Metadata{
+		Kind:    "loadBalancerList",
+		Version: maxVersion,
+	}
+}
+
+func init() {
+	datasource.RegistryCoder(string(LoadBalancerKind), &LoadBalancer{})
+}
@@ -46,8 +46,11 @@ type NetworkInterfaceSpec struct {
 type NetworkInterface struct {
 	core.Metadata `json:"metadata"`
 	Spec          NetworkInterfaceSpec `json:"spec"`
+	Status        core.Status          `json:"status"`
 }
 
+func (v *NetworkInterface) GetStatus() *core.Status { return &v.Status }
+
 func (v *NetworkInterface) Clone() core.IObject {
 	result := &NetworkInterface{}
 	core.Clone(v, result)
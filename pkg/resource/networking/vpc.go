@@ -28,8 +28,11 @@ type VirtualPrivateCloudSpec struct {
 type VirtualPrivateCloud struct {
 	core.Metadata `json:"metadata"`
 	Spec          VirtualPrivateCloudSpec `json:"spec"`
+	Status        core.Status             `json:"status"`
 }
 
+func (v *VirtualPrivateCloud) GetStatus() *core.Status { return &v.Status }
+
 func (v *VirtualPrivateCloud) Clone() core.IObject {
 	result := &VirtualPrivateCloud{}
 	core.Clone(v, result)
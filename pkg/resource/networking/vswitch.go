@@ -23,8 +23,11 @@ type VSwitchSpec struct {
 type Vswitch struct {
 	core.Metadata `json:"metadata"`
 	Spec          VSwitchSpec `json:"spec"`
+	Status        core.Status `json:"status"`
 }
 
+func (v *Vswitch) GetStatus() *core.Status { return &v.Status }
+
 func (v *Vswitch) Clone() core.IObject {
 	result := &Vswitch{}
 	core.Clone(v, result)
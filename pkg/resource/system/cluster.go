@@ -19,11 +19,24 @@ type ClusterSpec struct {
 	Nodes      []string               `json:"nodes" bson:"nodes"`
 }
 
+// ClusterStatus health of the cluster api server, reported by the backend controllers
+type ClusterStatus struct {
+	core.Status   `json:",inline" bson:",inline"`
+	Reachable     bool   `json:"reachable" bson:"reachable"`
+	Version       string `json:"version" bson:"version"`
+	LastError     string `json:"last_error" bson:"last_error"`
+	Latency       int64  `json:"latency" bson:"latency"`
+	LastProbeTime string `json:"last_probe_time" bson:"last_probe_time"`
+}
+
 type Cluster struct {
 	core.Metadata `json:"metadata"`
-	Spec          ClusterSpec `json:"spec"`
+	Spec          ClusterSpec   `json:"spec"`
+	Status        ClusterStatus `json:"status"`
 }
 
+func (i *Cluster) GetStatus() *core.Status { return &i.Status.Status }
+
 func (i *Cluster) Clone() core.IObject {
 	result := &Cluster{}
 	core.Clone(i, result)
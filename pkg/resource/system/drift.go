@@ -0,0 +1,79 @@
+package system
+
+import (
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+)
+
+const (
+	DriftKind     core.Kind = "drift"
+	DriftListKind core.Kind = "driftList"
+)
+
+type DriftType = string
+
+const (
+	// DriftMissing the stage object has no custom resource in any cluster
+	DriftMissing DriftType = "missing"
+	// DriftOrphaned the custom resource of a cluster has no stage object
+	DriftOrphaned DriftType = "orphaned"
+	// DriftDiffers the custom resource of a cluster differs from the stage object
+	DriftDiffers DriftType = "differs"
+)
+
+// DriftSpec a difference between a stage table and the custom resources of the clusters found by a resync
+type DriftSpec struct {
+	Controller string    `json:"controller" bson:"controller"`
+	Table      string    `json:"table" bson:"table"`
+	Object     string    `json:"object" bson:"object"`
+	Cluster    string    `json:"cluster" bson:"cluster"`
+	Type       DriftType `json:"type" bson:"type"`
+	// Fields the spec fields the custom resource differs in
+	Fields []string `json:"fields" bson:"fields"`
+	// Policy the side the drift is repaired from, reported only when empty
+	Policy     string `json:"policy" bson:"policy"`
+	Repaired   bool   `json:"repaired" bson:"repaired"`
+	Message    string `json:"message" bson:"message"`
+	DetectTime int64  `json:"detect_time" bson:"detect_time"`
+}
+
+type Drift struct {
+	core.Metadata `json:"metadata"`
+	Spec          DriftSpec `json:"spec"`
+}
+
+func (d *Drift) Clone() core.IObject {
+	result := &Drift{}
+	core.Clone(d, result)
+	return result
+}
+
+func (*Drift) Decode(opData map[string]interface{}) (core.IObject, error) {
+	action := &Drift{}
+	if err := core.UnmarshalToIObject(opData, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+type DriftList struct {
+	core.Metadata `json:"metadata"`
+	Items         []Drift `json:"items"`
+}
+
+func (d *DriftList) GenerateListVersion() {
+	var maxVersion string
+	for _, item := range d.Items {
+		if item.Version > maxVersion {
+			maxVersion = item.Version
+		}
+	}
+	d.Metadata = core.Metadata{
+		Kind:    DriftListKind,
+		Version: maxVersion,
+	}
+}
+
+func init() {
+	datasource.RegistryCoder(string(DriftKind), &Drift{})
+}
@@ -1,6 +1,8 @@
 package system
 
 import (
+	"strconv"
+
 	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/datasource"
 )
@@ -13,6 +15,17 @@ type InstanceTypeSpec struct {
 	Region string `json:"region" bson:"region"`
 	ID     string `json:"id" bson:"id"`
 	Zone   string `json:"zone" bson:"zone"`
+
+	// Custom defined by a tenant for the kubeVirt vms of the local clusters, the others are synced from the providers
+	Custom bool `json:"custom" bson:"custom"`
+	// NodeAffinity node labels the vms of the instance type are required to run on
+	NodeAffinity map[string]string `json:"node_affinity" bson:"node_affinity"`
+	// HugePages the page size backing the memory of the vms, 2Mi or 1Gi, regular memory when empty
+	HugePages    string `json:"huge_pages" bson:"huge_pages"`
+	DedicatedCPU bool   `json:"dedicated_cpu" bson:"dedicated_cpu"`
+	// Price the price of a vm of the instance type for an hour, the core price of the virtualmachine resource
+	// applies when zero
+	Price float64 `json:"price" bson:"price"`
 }
 
 type InstanceType struct {
@@ -20,6 +33,24 @@ type InstanceType struct {
 	Spec          InstanceTypeSpec `json:"spec"`
 }
 
+// Offers whether the instance type can be used in region and zone, an instance type without zone serves its whole region
+func (r *InstanceType) Offers(region, zone string) bool {
+	if r.Spec.Region != "" && region != "" && r.Spec.Region != region {
+		return false
+	}
+	return r.Spec.Zone == "" || zone == "" || r.Spec.Zone == zone
+}
+
+// VisibleTo a custom instance type is visible to its tenant only
+func (r *InstanceType) VisibleTo(tenant string) bool {
+	return !r.Spec.Custom || r.Tenant == "" || r.Tenant == tenant
+}
+
+// CPU the cores of the instance type in the form VirtualMachineSpec.CPU holds them
+func (r *InstanceType) CPU() string {
+	return strconv.FormatInt(r.Spec.Cores, 10)
+}
+
 func (r *InstanceType) Clone() core.IObject {
 	result := &InstanceType{}
 	core.Clone(r, result)
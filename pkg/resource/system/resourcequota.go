@@ -0,0 +1,151 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	ResourceQuotaKind     core.Kind = "resourcequota"
+	ResourceQuotaListKind core.Kind = "resourcequotaList"
+)
+
+// the totals a quota limits, the other names count the objects of a table, e.g. count/virtualmachine
+const (
+	// QuotaCPU the cores of the vms
+	QuotaCPU = "cpu"
+	// QuotaMemory the memory of the vms
+	QuotaMemory = "memory"
+	// QuotaStorage the capacity of the disks and of the vm volumes
+	QuotaStorage = "storage"
+
+	// QuotaCountPrefix prefix of the names counting the objects of a table
+	QuotaCountPrefix = "count/"
+	// QuotaCustomData table name counting the custom data of every custom resource
+	QuotaCustomData = "customdata"
+)
+
+// QuotaCount the quota name counting the objects of table
+func QuotaCount(table string) string { return QuotaCountPrefix + table }
+
+// ResourceQuotaSpec limits of a workspace, or of the whole tenant when the quota has no workspace.
+// Hard holds quantities by quota name, e.g. {"count/virtualmachine": "10", "cpu": "32", "memory": "64Gi", "storage": "1Ti"}
+type ResourceQuotaSpec struct {
+	Hard map[string]string `json:"hard" bson:"hard"`
+}
+
+// Validate every limit must be a non negative quantity of a known name
+func (s *ResourceQuotaSpec) Validate() error {
+	if len(s.Hard) == 0 {
+		return fmt.Errorf("quota has no limit")
+	}
+	for name, value := range s.Hard {
+		switch {
+		case name == QuotaCPU, name == QuotaMemory, name == QuotaStorage:
+		case strings.HasPrefix(name, QuotaCountPrefix) && len(name) > len(QuotaCountPrefix):
+		default:
+			return fmt.Errorf("unknown quota %s", name)
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("quota %s: %v", name, err)
+		}
+		if quantity.Sign() < 0 {
+			return fmt.Errorf("quota %s must not be negative", name)
+		}
+	}
+	return nil
+}
+
+// ResourceQuotaStatus the usage of the scope as last reconciled by quotactrl
+type ResourceQuotaStatus struct {
+	core.Status `json:",inline" bson:",inline"`
+	Used        map[string]string `json:"used" bson:"used"`
+	// Exceeded the quota names whose usage is above the limit, e.g. once the limit was lowered
+	Exceeded      []string `json:"exceeded" bson:"exceeded"`
+	ReconcileTime int64    `json:"reconcile_time" bson:"reconcile_time"`
+}
+
+type ResourceQuota struct {
+	core.Metadata `json:"metadata"`
+	Spec          ResourceQuotaSpec   `json:"spec"`
+	Status        ResourceQuotaStatus `json:"status"`
+}
+
+func (r *ResourceQuota) GetStatus() *core.Status { return &r.Status.Status }
+
+func (r *ResourceQuota) Clone() core.IObject {
+	result := &ResourceQuota{}
+	core.Clone(r, result)
+	return result
+}
+
+func (*ResourceQuota) Decode(opData map[string]interface{}) (core.IObject, error) {
+	action := &ResourceQuota{}
+	if err := core.UnmarshalToIObject(opData, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+type ResourceQuotaList struct {
+	core.Metadata `json:"metadata"`
+	Items         []ResourceQuota `json:"items"`
+}
+
+func (r *ResourceQuotaList) GenerateListVersion() {
+	var maxVersion string
+	for _, item := range r.Items {
+		if item.Version > maxVersion {
+			maxVersion = item.Version
+		}
+	}
+	r.Metadata = core.Metadata{
+		Kind:    ResourceQuotaListKind,
+		Version: maxVersion,
+	}
+}
+
+func init() {
+	datasource.RegistryCoder(string(ResourceQuotaKind), &ResourceQuota{})
+}
+
+const QuotaReservationKind core.Kind = "quotareservation"
+
+// QuotaReservationSpec what an admission in progress takes from a quota, counted by the other admissions along with
+// the stored objects until the admitted object is written. A reservation left by a replica that died counts until Expire
+type QuotaReservationSpec struct {
+	Quota  string            `json:"quota" bson:"quota"`
+	Demand map[string]string `json:"demand" bson:"demand"`
+	Expire int64             `json:"expire" bson:"expire"`
+}
+
+type QuotaReservation struct {
+	core.Metadata `json:"metadata"`
+	Spec          QuotaReservationSpec `json:"spec"`
+	Status        core.Status          `json:"status"`
+}
+
+func (r *QuotaReservation) GetStatus() *core.Status { return &r.Status }
+
+func (r *QuotaReservation) Clone() core.IObject {
+	result := &QuotaReservation{}
+	core.Clone(r, result)
+	return result
+}
+
+func (*QuotaReservation) Decode(opData map[string]interface{}) (core.IObject, error) {
+	action := &QuotaReservation{}
+	if err := core.UnmarshalToIObject(opData, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+func init() {
+	datasource.RegistryCoder(string(QuotaReservationKind), &QuotaReservation{})
+}
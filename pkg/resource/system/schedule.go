@@ -0,0 +1,181 @@
+package system
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+const (
+	ScheduleKind     core.Kind = "schedule"
+	ScheduleListKind core.Kind = "scheduleList"
+)
+
+// the actions a schedule runs on its targets
+const (
+	ScheduleActionStart    = "start"
+	ScheduleActionStop     = "stop"
+	ScheduleActionRestart  = "restart"
+	ScheduleActionPause    = "pause"
+	ScheduleActionUnpause  = "unpause"
+	ScheduleActionSnapshot = "snapshot"
+	ScheduleActionDelete   = "delete"
+)
+
+// ScheduleActions the actions supported by kind of target, the kind of a resource is also its table name
+var ScheduleActions = map[core.Kind][]string{
+	compute.VirtualMachineKind: {
+		ScheduleActionStart, ScheduleActionStop, ScheduleActionRestart,
+		ScheduleActionPause, ScheduleActionUnpause, ScheduleActionSnapshot,
+	},
+	compute.VirtualMachineSnapshotKind: {ScheduleActionDelete},
+	networking.ElasticIPKind:           {ScheduleActionDelete},
+	networking.LoadBalancerKind:        {ScheduleActionDelete},
+}
+
+// the results of the last run of a schedule
+const (
+	ScheduleResultSuccess = "success"
+	// ScheduleResultPartial some targets failed
+	ScheduleResultPartial = "partial"
+	ScheduleResultFail    = "fail"
+	// ScheduleResultSkipped no target matched the selector
+	ScheduleResultSkipped = "skipped"
+)
+
+// ScheduleTarget the objects of the workspace of the schedule an action runs on. Kind is the table of the objects,
+// an object is selected when its name is in Names, if any, and its labels hold every one of Labels
+type ScheduleTarget struct {
+	Kind   core.Kind         `json:"kind" bson:"kind"`
+	Names  []string          `json:"names" bson:"names"`
+	Labels map[string]string `json:"labels" bson:"labels"`
+}
+
+// Matches whether the object of metadata is selected by the target
+func (t *ScheduleTarget) Matches(metadata core.Metadata) bool {
+	if len(t.Names) > 0 {
+		found := false
+		for _, name := range t.Names {
+			if name == metadata.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for key, value := range t.Labels {
+		label, exist := metadata.Labels[key]
+		if !exist || fmt.Sprint(label) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ScheduleSpec run Action on the targets each time Cron, a five fields expression, matches in Timezone
+type ScheduleSpec struct {
+	Cron string `json:"cron" bson:"cron"`
+	// Timezone an IANA name e.g. Asia/Shanghai, UTC when empty
+	Timezone string         `json:"timezone" bson:"timezone"`
+	Target   ScheduleTarget `json:"target" bson:"target"`
+	Action   string         `json:"action" bson:"action"`
+	// Retention of the snapshots taken by a snapshot action
+	Retention compute.SnapshotRetention `json:"retention" bson:"retention"`
+	// Suspend no run happens while set, the runs missed meanwhile are dropped
+	Suspend bool `json:"suspend" bson:"suspend"`
+}
+
+// Location the timezone of the schedule
+func (s *ScheduleSpec) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// Validate the target kind must support the action, the cron expression is checked by the scheduler
+func (s *ScheduleSpec) Validate() error {
+	if s.Cron == "" {
+		return fmt.Errorf("cron is empty")
+	}
+	if _, err := s.Location(); err != nil {
+		return fmt.Errorf("invalid timezone %s", s.Timezone)
+	}
+	actions, exist := ScheduleActions[s.Target.Kind]
+	if !exist {
+		return fmt.Errorf("not support target kind %s", s.Target.Kind)
+	}
+	supported := false
+	for _, action := range actions {
+		if action == s.Action {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("not support action %s on %s", s.Action, s.Target.Kind)
+	}
+	if s.Retention.Days < 0 || s.Retention.Count < 0 {
+		return fmt.Errorf("retention must not be negative")
+	}
+	return nil
+}
+
+// ScheduleStatus the runs of the schedule as recorded by schedulectrl, the times in unix seconds
+type ScheduleStatus struct {
+	core.Status      `json:",inline" bson:",inline"`
+	LastScheduleTime int64  `json:"last_schedule_time" bson:"last_schedule_time"`
+	NextScheduleTime int64  `json:"next_schedule_time" bson:"next_schedule_time"`
+	LastResult       string `json:"last_result" bson:"last_result"`
+	LastMessage      string `json:"last_message" bson:"last_message"`
+}
+
+type Schedule struct {
+	core.Metadata `json:"metadata"`
+	Spec          ScheduleSpec   `json:"spec"`
+	Status        ScheduleStatus `json:"status"`
+}
+
+func (s *Schedule) GetStatus() *core.Status { return &s.Status.Status }
+
+func (s *Schedule) Clone() core.IObject {
+	result := &Schedule{}
+	core.Clone(s, result)
+	return result
+}
+
+func (*Schedule) Decode(opData map[string]interface{}) (core.IObject, error) {
+	action := &Schedule{}
+	if err := core.UnmarshalToIObject(opData, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+type ScheduleList struct {
+	core.Metadata `json:"metadata"`
+	Items         []Schedule `json:"items"`
+}
+
+func (s *ScheduleList) GenerateListVersion() {
+	var maxVersion string
+	for _, item := range s.Items {
+		if item.Version > maxVersion {
+			maxVersion = item.Version
+		}
+	}
+	s.Metadata = core.Metadata{
+		Kind:    ScheduleListKind,
+		Version: maxVersion,
+	}
+}
+
+func init() {
+	datasource.RegistryCoder(string(ScheduleKind), &Schedule{})
+}
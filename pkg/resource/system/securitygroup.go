@@ -17,10 +17,31 @@ const (
 	ALL  IpProtocolType = "ALL"
 )
 
+type RuleDirection string
+
+const (
+	Ingress RuleDirection = "ingress"
+	Egress  RuleDirection = "egress"
+)
+
+type RuleAction string
+
+const (
+	Allow RuleAction = "allow"
+	Deny  RuleAction = "deny"
+)
+
+// SecurityGroupRole a rule of the security group, the peer is either SourceCidrIp or PeerGroup,
+// the source of ingress and the destination of egress
 type SecurityGroupRole struct {
+	Direction    RuleDirection  `json:"direction" bson:"direction"`
+	Action       RuleAction     `json:"action" bson:"action"`
+	Priority     int            `json:"priority" bson:"priority"`
 	PortRange    string         `json:"port_range" bson:"port_range"`
 	IpProtocol   IpProtocolType `json:"ip_protocol" bson:"ip_protocol"`
 	SourceCidrIp string         `json:"source_cidr_ip" bson:"source_cidr_ip"`
+	PeerGroup    string         `json:"peer_group" bson:"peer_group"`
+	Description  string         `json:"description" bson:"description"`
 }
 
 type SecurityGroupSpec struct {
@@ -37,8 +58,11 @@ type SecurityGroupSpec struct {
 type SecurityGroup struct {
 	core.Metadata `json:"metadata"`
 	Spec          SecurityGroupSpec `json:"spec"`
+	Status        core.Status       `json:"status"`
 }
 
+func (v *SecurityGroup) GetStatus() *core.Status { return &v.Status }
+
 func (v *SecurityGroup) Clone() core.IObject {
 	result := &SecurityGroup{}
 	core.Clone(v, result)
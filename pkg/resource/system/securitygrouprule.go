@@ -0,0 +1,167 @@
+package system
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	MinRulePriority = 1
+	MaxRulePriority = 100
+
+	// AllPorts the port range of the protocols without ports and of the rules covering every port
+	AllPorts = "-1/-1"
+
+	securityGroupLabelPrefix = "securitygroup.ddx2x.nip/"
+)
+
+// SecurityGroupLabel the label carried by the kubeVirt vms of the security group, the network policy of the group selects it
+func SecurityGroupLabel(name string) string {
+	return securityGroupLabelPrefix + name
+}
+
+// ParsePortRange the "from/to" port range of a rule, -1 -1 for every port
+func ParsePortRange(portRange string) (int, int, error) {
+	parts := strings.SplitN(portRange, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid port range %s", portRange)
+	}
+	from, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %s", portRange)
+	}
+	to, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port range %s", portRange)
+	}
+	return from, to, nil
+}
+
+// Normalize fill the defaults of the rules, the direction follows the list a rule sits in
+func (s *SecurityGroupSpec) Normalize() {
+	normalize := func(rules []SecurityGroupRole, direction RuleDirection) {
+		for index := range rules {
+			rule := &rules[index]
+			rule.Direction = direction
+			rule.IpProtocol = IpProtocolType(strings.ToUpper(string(rule.IpProtocol)))
+			if rule.Action == "" {
+				rule.Action = Allow
+			}
+			if rule.Priority == 0 {
+				rule.Priority = MinRulePriority
+			}
+			if rule.PortRange == "" && rule.IpProtocol != TCP && rule.IpProtocol != UDP {
+				rule.PortRange = AllPorts
+			}
+		}
+	}
+	normalize(s.Ingress, Ingress)
+	normalize(s.Egress, Egress)
+}
+
+// Validate the rules of the security group, a rule may not appear twice
+func (s *SecurityGroupSpec) Validate() error {
+	seen := make(map[string]bool)
+	for _, rules := range [][]SecurityGroupRole{s.Ingress, s.Egress} {
+		for _, rule := range rules {
+			if err := rule.Validate(); err != nil {
+				return err
+			}
+			if seen[rule.Key()] {
+				return fmt.Errorf("duplicate %s rule %s", rule.Direction, rule)
+			}
+			seen[rule.Key()] = true
+		}
+	}
+	return nil
+}
+
+func (r SecurityGroupRole) Validate() error {
+	switch r.Direction {
+	case Ingress, Egress:
+	default:
+		return fmt.Errorf("rule %s: invalid direction %s", r, r.Direction)
+	}
+	switch r.Action {
+	case Allow, Deny:
+	default:
+		return fmt.Errorf("rule %s: invalid action %s", r, r.Action)
+	}
+	if r.Priority < MinRulePriority || r.Priority > MaxRulePriority {
+		return fmt.Errorf("rule %s: priority must be between %d and %d", r, MinRulePriority, MaxRulePriority)
+	}
+
+	from, to, err := ParsePortRange(r.PortRange)
+	if err != nil {
+		return fmt.Errorf("rule %s: %v", r, err)
+	}
+	switch r.IpProtocol {
+	case TCP, UDP:
+		if !(from == -1 && to == -1) && (from < 1 || to > 65535 || from > to) {
+			return fmt.Errorf("rule %s: port range must be within 1/65535", r)
+		}
+	case ICMP, GRE, ALL:
+		if from != -1 || to != -1 {
+			return fmt.Errorf("rule %s: protocol %s takes port range %s", r, r.IpProtocol, AllPorts)
+		}
+	default:
+		return fmt.Errorf("rule %s: invalid ip protocol %s", r, r.IpProtocol)
+	}
+
+	if (r.SourceCidrIp == "") == (r.PeerGroup == "") {
+		return fmt.Errorf("rule %s: either a cidr or a peer group is required", r)
+	}
+	if r.SourceCidrIp != "" {
+		if _, _, err := net.ParseCIDR(r.SourceCidrIp); err != nil {
+			return fmt.Errorf("rule %s: invalid cidr %s", r, r.SourceCidrIp)
+		}
+	}
+	return nil
+}
+
+// Key identify the rule when rule sets are compared, the description is left out
+func (r SecurityGroupRole) Key() string {
+	return fmt.Sprintf("%s/%s/%d/%s/%s/%s/%s", r.Direction, r.Action, r.Priority, r.IpProtocol, r.PortRange, r.SourceCidrIp, r.PeerGroup)
+}
+
+func (r SecurityGroupRole) String() string {
+	peer := r.SourceCidrIp
+	if r.PeerGroup != "" {
+		peer = "group " + r.PeerGroup
+	}
+	return fmt.Sprintf("%s %s %s %s %s", r.Direction, r.Action, r.IpProtocol, r.PortRange, peer)
+}
+
+// DiffRules the rules to add and to remove to turn current into desired
+func DiffRules(current, desired []SecurityGroupRole) ([]SecurityGroupRole, []SecurityGroupRole) {
+	currentKeys := make(map[string]bool)
+	for _, rule := range current {
+		currentKeys[rule.Key()] = true
+	}
+	desiredKeys := make(map[string]bool)
+	for _, rule := range desired {
+		desiredKeys[rule.Key()] = true
+	}
+
+	added, removed := make([]SecurityGroupRole, 0), make([]SecurityGroupRole, 0)
+	for _, rule := range desired {
+		if !currentKeys[rule.Key()] {
+			added = append(added, rule)
+		}
+	}
+	for _, rule := range current {
+		if !desiredKeys[rule.Key()] {
+			removed = append(removed, rule)
+		}
+	}
+	return added, removed
+}
+
+// Rules the ingress and egress rules of the security group
+func (s *SecurityGroupSpec) Rules() []SecurityGroupRole {
+	rules := make([]SecurityGroupRole, 0, len(s.Ingress)+len(s.Egress))
+	rules = append(rules, s.Ingress...)
+	return append(rules, s.Egress...)
+}
@@ -0,0 +1,222 @@
+package system
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+)
+
+const (
+	StackKind     core.Kind = "stack"
+	StackListKind core.Kind = "stackList"
+)
+
+// StackResourceKinds the kinds a stack template can hold, the kind of a resource is also its table name
+var StackResourceKinds = []core.Kind{
+	networking.VirtualPrivateCloudKind,
+	networking.VSwitchKind,
+	SecurityGroupKind,
+	compute.VirtualMachineKind,
+	compute.StorageKind,
+	networking.ElasticIPKind,
+	networking.LoadBalancerKind,
+}
+
+// the phases of a stack
+const (
+	StackPhaseCreating    = "Creating"
+	StackPhaseUpdating    = "Updating"
+	StackPhaseReady       = "Ready"
+	StackPhaseRollingBack = "RollingBack"
+	// StackPhaseFailed the last operation was rolled back, the stack waits for another template
+	StackPhaseFailed   = "Failed"
+	StackPhaseDeleting = "Deleting"
+)
+
+// the phases of a resource of a stack
+const (
+	StackResourcePending  = "Pending"
+	StackResourceCreating = "Creating"
+	StackResourceUpdating = "Updating"
+	StackResourceReady    = "Ready"
+	StackResourceFailed   = "Failed"
+)
+
+// StackLabel the label holding the name of the stack on the objects it created
+const StackLabel = "stack"
+
+var stackNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// StackParameter a value of the template given when the stack is created, Default is used when it is not given
+type StackParameter struct {
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description" bson:"description"`
+	Default     string `json:"default" bson:"default"`
+	Required    bool   `json:"required" bson:"required"`
+}
+
+// StackResource an object of the stack, named <stack>-<name> in the workspace of the stack. The strings of Spec may
+// reference ${param.<name>} or ${resource.<name>.<path>}, path being the json path in the object e.g. spec.id.
+// A resource is created once the resources it depends on, explicitly or by reference, are ready
+type StackResource struct {
+	Name string    `json:"name" bson:"name"`
+	Kind core.Kind `json:"kind" bson:"kind"`
+	// Namespace the namespace of the object e.g. its provider, it may reference the parameters
+	Namespace string                 `json:"namespace" bson:"namespace"`
+	DependsOn []string               `json:"depends_on" bson:"depends_on"`
+	Spec      map[string]interface{} `json:"spec" bson:"spec"`
+}
+
+// StackOutput a value of the stack once ready, Value may reference parameters and resources as a spec does
+type StackOutput struct {
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description" bson:"description"`
+	Value       string `json:"value" bson:"value"`
+}
+
+type StackTemplate struct {
+	Parameters []StackParameter `json:"parameters" bson:"parameters"`
+	Resources  []StackResource  `json:"resources" bson:"resources"`
+	Outputs    []StackOutput    `json:"outputs" bson:"outputs"`
+}
+
+// Validate the names and kinds of the template, the references and dependencies are checked by the stack package
+func (t *StackTemplate) Validate() error {
+	if len(t.Resources) == 0 {
+		return fmt.Errorf("template has no resource")
+	}
+	parameters := make(map[string]bool)
+	for _, parameter := range t.Parameters {
+		if parameter.Name == "" || parameters[parameter.Name] {
+			return fmt.Errorf("parameter name %q is empty or duplicated", parameter.Name)
+		}
+		parameters[parameter.Name] = true
+	}
+	resources := make(map[string]bool)
+	for _, resource := range t.Resources {
+		if !stackNamePattern.MatchString(resource.Name) || resources[resource.Name] {
+			return fmt.Errorf("resource name %q is invalid or duplicated", resource.Name)
+		}
+		resources[resource.Name] = true
+		supported := false
+		for _, kind := range StackResourceKinds {
+			if kind == resource.Kind {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("resource %s: not support kind %s", resource.Name, resource.Kind)
+		}
+	}
+	outputs := make(map[string]bool)
+	for _, output := range t.Outputs {
+		if output.Name == "" || outputs[output.Name] {
+			return fmt.Errorf("output name %q is empty or duplicated", output.Name)
+		}
+		outputs[output.Name] = true
+	}
+	return nil
+}
+
+type StackSpec struct {
+	Template StackTemplate `json:"template" bson:"template"`
+	// Parameters the values of the parameters of the template
+	Parameters map[string]string `json:"parameters" bson:"parameters"`
+}
+
+// ValidateStackName the stack name prefixes the names of its objects
+func ValidateStackName(name string) error {
+	if !stackNamePattern.MatchString(name) {
+		return fmt.Errorf("stack name %q must be lower case alphanumeric or '-'", name)
+	}
+	return nil
+}
+
+// StackResourceStatus the object of a resource of the stack
+type StackResourceStatus struct {
+	Name      string    `json:"name" bson:"name"`
+	Kind      core.Kind `json:"kind" bson:"kind"`
+	Namespace string    `json:"namespace" bson:"namespace"`
+	Object    string    `json:"object" bson:"object"`
+	Phase     string    `json:"phase" bson:"phase"`
+	Message   string    `json:"message" bson:"message"`
+	// Hash of the spec last applied to the object
+	Hash    string                 `json:"hash" bson:"hash"`
+	Applied map[string]interface{} `json:"applied" bson:"applied"`
+	// Stable the spec applied when the operation in progress started, a rollback applies it again. Nil when the
+	// operation created the object, a rollback deletes it
+	Stable map[string]interface{} `json:"stable" bson:"stable"`
+}
+
+// StackStatus the progress of the stack as recorded by stackctrl
+type StackStatus struct {
+	core.Status `json:",inline" bson:",inline"`
+	Phase       string `json:"phase" bson:"phase"`
+	Message     string `json:"message" bson:"message"`
+	// Hash of the spec the last operation applied
+	Hash      string                `json:"hash" bson:"hash"`
+	Resources []StackResourceStatus `json:"resources" bson:"resources"`
+	Outputs   map[string]string     `json:"outputs" bson:"outputs"`
+}
+
+// Resource the status of the resource name, nil when it has none yet
+func (s *StackStatus) Resource(name string) *StackResourceStatus {
+	for index := range s.Resources {
+		if s.Resources[index].Name == name {
+			return &s.Resources[index]
+		}
+	}
+	return nil
+}
+
+type Stack struct {
+	core.Metadata `json:"metadata"`
+	Spec          StackSpec   `json:"spec"`
+	Status        StackStatus `json:"status"`
+}
+
+// ObjectName the name of the object of the resource of the stack
+func (s *Stack) ObjectName(resource string) string { return fmt.Sprintf("%s-%s", s.Name, resource) }
+
+func (s *Stack) GetStatus() *core.Status { return &s.Status.Status }
+
+func (s *Stack) Clone() core.IObject {
+	result := &Stack{}
+	core.Clone(s, result)
+	return result
+}
+
+func (*Stack) Decode(opData map[string]interface{}) (core.IObject, error) {
+	action := &Stack{}
+	if err := core.UnmarshalToIObject(opData, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+type StackList struct {
+	core.Metadata `json:"metadata"`
+	Items         []Stack `json:"items"`
+}
+
+func (s *StackList) GenerateListVersion() {
+	var maxVersion string
+	for _, item := range s.Items {
+		if item.Version > maxVersion {
+			maxVersion = item.Version
+		}
+	}
+	s.Metadata = core.Metadata{
+		Kind:    StackListKind,
+		Version: maxVersion,
+	}
+}
+
+func init() {
+	datasource.RegistryCoder(string(StackKind), &Stack{})
+}
@@ -0,0 +1,124 @@
+package system
+
+import (
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+)
+
+const (
+	UsageRecordKind     core.Kind = "usagerecord"
+	UsageRecordListKind core.Kind = "usagerecordList"
+)
+
+// the units a usage record is metered in, every unit is priced by the hour
+const (
+	// MeterInstance a running vm priced by its instance type
+	MeterInstance = "instance"
+	// MeterCore the cores of a running vm whose instance type has no price
+	MeterCore = "core"
+	// MeterStorage the GiB of a disk or of the volumes of a vm
+	MeterStorage = "gib"
+	// MeterUnit an object priced as a whole, e.g. an elastic ip or a custom data
+	MeterUnit = "unit"
+)
+
+const (
+	// UsageSourceSample the usage of an object found by the periodic sample
+	UsageSourceSample = "sample"
+	// UsageSourceEvent the usage of a vm closed by its state change between two samples
+	UsageSourceEvent = "event"
+)
+
+// UsageRecordSpec what an object of a workspace used over a window and what it cost
+type UsageRecordSpec struct {
+	// Table the table of the object, the custom resource for custom data
+	Table  string `json:"table" bson:"table"`
+	Object string `json:"object" bson:"object"`
+	Meter  string `json:"meter" bson:"meter"`
+	// Quantity the units used over the window, e.g. the cores of a vm
+	Quantity     float64 `json:"quantity" bson:"quantity"`
+	InstanceType string  `json:"instance_type" bson:"instance_type"`
+	// Start End unix seconds of the window
+	Start int64 `json:"start" bson:"start"`
+	End   int64 `json:"end" bson:"end"`
+	// UnitPrice the price of a unit for an hour when the usage was metered
+	UnitPrice float64 `json:"unit_price" bson:"unit_price"`
+	Cost      float64 `json:"cost" bson:"cost"`
+	Source    string  `json:"source" bson:"source"`
+}
+
+// Hours the length of the window
+func (s *UsageRecordSpec) Hours() float64 {
+	return float64(s.End-s.Start) / 3600
+}
+
+type UsageRecord struct {
+	core.Metadata `json:"metadata"`
+	Spec          UsageRecordSpec `json:"spec"`
+}
+
+func (u *UsageRecord) Clone() core.IObject {
+	result := &UsageRecord{}
+	core.Clone(u, result)
+	return result
+}
+
+func (*UsageRecord) Decode(opData map[string]interface{}) (core.IObject, error) {
+	action := &UsageRecord{}
+	if err := core.UnmarshalToIObject(opData, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+type UsageRecordList struct {
+	core.Metadata `json:"metadata"`
+	Items         []UsageRecord `json:"items"`
+}
+
+func (u *UsageRecordList) GenerateListVersion() {
+	var maxVersion string
+	for _, item := range u.Items {
+		if item.Version > maxVersion {
+			maxVersion = item.Version
+		}
+	}
+	u.Metadata = core.Metadata{
+		Kind:    UsageRecordListKind,
+		Version: maxVersion,
+	}
+}
+
+func init() {
+	datasource.RegistryCoder(string(UsageRecordKind), &UsageRecord{})
+}
+
+const UsageMeterKind core.Kind = "usagemeter"
+
+// UsageMeterSpec how far a usage is metered, the meter is named by the key of the usage and its next window starts at End
+type UsageMeterSpec struct {
+	End int64 `json:"end" bson:"end"`
+}
+
+type UsageMeter struct {
+	core.Metadata `json:"metadata"`
+	Spec          UsageMeterSpec `json:"spec"`
+}
+
+func (u *UsageMeter) Clone() core.IObject {
+	result := &UsageMeter{}
+	core.Clone(u, result)
+	return result
+}
+
+func (*UsageMeter) Decode(opData map[string]interface{}) (core.IObject, error) {
+	action := &UsageMeter{}
+	if err := core.UnmarshalToIObject(opData, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+func init() {
+	datasource.RegistryCoder(string(UsageMeterKind), &UsageMeter{})
+}
@@ -46,6 +46,15 @@ func (p *Provider) GetRegion(localName string) *Region {
 	return nil
 }
 
+// Quota the limits of the tenant or of a workspace of the user, with the usage last reconciled
+type Quota struct {
+	Name      string            `json:"name"`
+	Workspace string            `json:"workspace"`
+	Hard      map[string]string `json:"hard"`
+	Used      map[string]string `json:"used"`
+	Exceeded  []string          `json:"exceeded"`
+}
+
 type UserMenu struct {
 	Name     string      `json:"name"`
 	Link     string      `json:"link"`
@@ -97,6 +106,7 @@ type Config struct {
 	Roles             []string               `json:"roles"`
 	IsTenantOwner     bool                   `json:"isTenantOwner"`
 	OwnerBiz          []string               `json:"ownerBiz"`
+	Quotas            []*Quota               `json:"quotas"`
 }
 
 func (cfg *Config) IsAdmin() bool {
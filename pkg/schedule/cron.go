@@ -0,0 +1,192 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// macros the shorthands of the usual expressions
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+// Cron a parsed five fields expression: minute hour day-of-month month day-of-week
+type Cron struct {
+	minute, hour, day, month, weekday uint64
+	// dayAny weekdayAny the field was *, a day matches either restricted field as in the classic cron
+	dayAny, weekdayAny bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	{name: "day of week", min: 0, max: 7, names: weekdayNames},
+}
+
+// ParseCron parse a five fields expression, each field is *, a value, a range a-b, a step */n or a-b/n, or a list of
+// them. Months and weekdays also take their three letters names, 7 is sunday as 0
+func ParseCron(expression string) (*Cron, error) {
+	expression = strings.TrimSpace(expression)
+	if macro, exist := macros[strings.ToLower(expression)]; exist {
+		expression = macro
+	}
+	parts := strings.Fields(expression)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron %q must have %d fields", expression, len(fields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for index, part := range parts {
+		value, err := parseField(part, fields[index])
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %v", expression, err)
+		}
+		bits[index] = value
+	}
+	// sunday is both 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &Cron{
+		minute:     bits[0],
+		hour:       bits[1],
+		day:        bits[2],
+		month:      bits[3],
+		weekday:    bits[4],
+		dayAny:     parts[2] == "*",
+		weekdayAny: parts[4] == "*",
+	}, nil
+}
+
+func parseField(part string, f field) (uint64, error) {
+	var result uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, step := item, 1
+		if index := strings.Index(item, "/"); index >= 0 {
+			value, err := strconv.Atoi(item[index+1:])
+			if err != nil || value <= 0 {
+				return 0, fmt.Errorf("invalid step %q of %s", item, f.name)
+			}
+			rangePart, step = item[:index], value
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if high, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q of %s", rangePart, f.name)
+			}
+		default:
+			value, err := f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			// a value with a step runs from the value to the end of the field
+			if step == 1 {
+				high = value
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			result |= 1 << uint(value)
+		}
+	}
+	return result, nil
+}
+
+func (f field) value(raw string) (int, error) {
+	if value, exist := f.names[strings.ToLower(raw)]; exist {
+		return value, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d-%d", f.name, raw, f.min, f.max)
+	}
+	return value, nil
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	day := c.day&(1<<uint(t.Day())) != 0
+	weekday := c.weekday&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.dayAny && c.weekdayAny:
+		return true
+	case c.dayAny:
+		return weekday
+	case c.weekdayAny:
+		return day
+	}
+	return day || weekday
+}
+
+// Next the first time strictly after after the expression matches, in the location of after. The zero time when
+// nothing matches within five years, e.g. for the 30th of february
+func (c *Cron) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Due the latest time in (last, now] the expression matches, the zero time when it does not. The runs missed before
+// it, e.g. while the scheduler was down, are collapsed into it
+func (c *Cron) Due(last, now time.Time) time.Time {
+	var due time.Time
+	for next := c.Next(last); !next.IsZero() && !next.After(now); next = c.Next(next) {
+		due = next
+	}
+	return due
+}
@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// 2026-10-19 is a monday
+	after := time.Date(2026, 10, 19, 10, 7, 0, 0, time.UTC)
+	cases := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 10, 19, 10, 15, 0, 0, time.UTC)},
+		{"0 22 * * 1-5", time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)},
+		{"0 22 * * sat,sun", time.Date(2026, 10, 24, 22, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"30 1 * * 7", time.Date(2026, 10, 25, 1, 30, 0, 0, time.UTC)},
+		// day of month or day of week when both are restricted
+		{"0 0 1,15 * sun", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"5/20 9-10 * * *", time.Date(2026, 10, 19, 10, 25, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, c := range cases {
+		cron, err := ParseCron(c.expression)
+		if err != nil {
+			t.Fatalf("parse %q: %v", c.expression, err)
+		}
+		if next := cron.Next(after); !next.Equal(c.expected) {
+			t.Fatalf("expected %q next at %s, got %s", c.expression, c.expected, next)
+		}
+	}
+}
+
+func TestCronTimezone(t *testing.T) {
+	location, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("no timezone database")
+	}
+	cron, _ := ParseCron("0 3 * * *")
+	next := cron.Next(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC).In(location))
+	if expected := time.Date(2026, 10, 19, 19, 0, 0, 0, time.UTC); !next.Equal(expected) {
+		t.Fatalf("expected 03:00 in shanghai at %s, got %s", expected, next.UTC())
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, expression := range []string{"", "* * *", "60 * * * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *"} {
+		if _, err := ParseCron(expression); err == nil {
+			t.Fatalf("expected %q to be invalid", expression)
+		}
+	}
+}
+
+func TestCronDue(t *testing.T) {
+	cron, _ := ParseCron("0 * * * *")
+	last := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	if due := cron.Due(last, time.Date(2026, 10, 19, 13, 30, 0, 0, time.UTC)); !due.Equal(time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the missed runs collapsed into 13:00, got %s", due)
+	}
+	if due := cron.Due(last, last.Add(30*time.Minute)); !due.IsZero() {
+		t.Fatalf("expected nothing due, got %s", due)
+	}
+}
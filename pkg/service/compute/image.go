@@ -0,0 +1,151 @@
+package compute
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// checksumLength the hex length of the digests an image checksum may use
+var checksumLength = map[string]int{"md5": 32, "sha1": 40, "sha256": 64, "sha512": 128}
+
+type ImageService struct {
+	service.IService
+}
+
+func NewImageService(i service.IService) *ImageService {
+	return &ImageService{i}
+}
+
+// List the images visible from workspace, the public ones and the private ones of tenant or workspace
+func (is *ImageService) List(name, workspace, tenant string) (*compute.ImageList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+
+	data := make([]compute.Image, 0)
+	err := is.IService.ListToObject(common.DefaultDatabase, common.IMAGE, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]compute.Image, 0, len(data))
+	for _, image := range data {
+		if workspace == "" || !image.IsCatalogue() || image.VisibleTo(tenant, workspace) {
+			visible = append(visible, image)
+		}
+	}
+
+	imageList := &compute.ImageList{Items: visible}
+	imageList.GenerateListVersion()
+
+	return imageList, nil
+}
+
+func (is *ImageService) GetByName(workspace, name string) (*compute.Image, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	image := &compute.Image{}
+	err := is.IService.GetByFilter(common.DefaultDatabase, common.IMAGE, image, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// Create register an image in the catalogue, imagectrl imports it into every cluster
+func (is *ImageService) Create(reqImage *compute.Image) (core.IObject, error) {
+	if reqImage.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := is.GetByName(reqImage.Workspace, reqImage.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("image exists")
+	}
+	if err := validateImage(&reqImage.Spec); err != nil {
+		return nil, err
+	}
+
+	reqImage.Kind = compute.ImageKind
+	reqImage.Spec.Imports = nil
+	reqImage.Spec.State = compute.ImageStateImporting
+	reqImage.Spec.Status = common.INIT
+	reqImage.Spec.Message = ""
+	reqImage.GenerateVersion()
+
+	_, err := is.IService.Create(common.DefaultDatabase, common.IMAGE, reqImage)
+	if err != nil {
+		return nil, err
+	}
+	return reqImage, nil
+}
+
+// Delete an image still cloned by a vm of the workspace is refused
+func (is *ImageService) Delete(workspace, name string) (core.IObject, error) {
+	image, err := is.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	vms := make([]compute.VirtualMachine, 0)
+	filter := map[string]interface{}{"spec.storage.image": name}
+	if err := is.IService.ListToObject(common.DefaultDatabase, common.VIRTUALMACHINE, filter, &vms, true); err != nil {
+		return nil, err
+	}
+	for _, vm := range vms {
+		if vm.GetWorkspace() == workspace || image.Spec.Public {
+			return nil, fmt.Errorf("image %s is used by vm %s", name, vm.GetName())
+		}
+	}
+
+	image.Delete()
+	_, _, err = is.Apply(common.DefaultDatabase, common.IMAGE, image.Name, image, true)
+	return image, err
+}
+
+func validateImage(spec *compute.ImageSpec) error {
+	source, err := url.Parse(spec.Source)
+	if err != nil || spec.Source == "" {
+		return fmt.Errorf("invalid image source %q", spec.Source)
+	}
+	switch spec.SourceType {
+	case compute.ImageSourceRegistry:
+		if source.Scheme != "docker" && source.Scheme != "oci-archive" {
+			return fmt.Errorf("registry source %s must be a docker:// or oci-archive:// url", spec.Source)
+		}
+	case compute.ImageSourceHTTP:
+		if source.Scheme != "http" && source.Scheme != "https" {
+			return fmt.Errorf("http source %s must be a http:// or https:// url", spec.Source)
+		}
+	default:
+		return fmt.Errorf("invalid image source type %q", spec.SourceType)
+	}
+
+	size, err := resource.ParseQuantity(spec.Size)
+	if err != nil || size.Sign() <= 0 {
+		return fmt.Errorf("invalid image size %q", spec.Size)
+	}
+
+	if spec.Checksum != "" {
+		parts := strings.SplitN(spec.Checksum, ":", 2)
+		length, ok := checksumLength[parts[0]]
+		if len(parts) != 2 || !ok {
+			return fmt.Errorf("checksum %s must be <md5|sha1|sha256|sha512>:<hex>", spec.Checksum)
+		}
+		if _, err := hex.DecodeString(parts[1]); err != nil || len(parts[1]) != length {
+			return fmt.Errorf("invalid %s checksum %s", parts[0], parts[1])
+		}
+	}
+	return nil
+}
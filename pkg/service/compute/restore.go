@@ -0,0 +1,102 @@
+package compute
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/service"
+)
+
+type VirtualMachineRestoreService struct {
+	service.IService
+}
+
+func NewVirtualMachineRestoreService(i service.IService) *VirtualMachineRestoreService {
+	return &VirtualMachineRestoreService{i}
+}
+
+func (rs *VirtualMachineRestoreService) List(name, workspace string) (*compute.VirtualMachineRestoreList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]compute.VirtualMachineRestore, 0)
+	err := rs.IService.ListToObject(common.DefaultDatabase, common.VIRTUALMACHINERESTORE, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreList := &compute.VirtualMachineRestoreList{Items: data}
+	restoreList.GenerateListVersion()
+
+	return restoreList, nil
+}
+
+func (rs *VirtualMachineRestoreService) GetByName(workspace, name string) (*compute.VirtualMachineRestore, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	restore := &compute.VirtualMachineRestore{}
+	err := rs.IService.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINERESTORE, restore, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return restore, nil
+}
+
+// Create roll the vm back to a ready snapshot of it, snapshotctrl requires the vm to be stopped
+func (rs *VirtualMachineRestoreService) Create(reqRestore *compute.VirtualMachineRestore) (core.IObject, error) {
+	if reqRestore.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := rs.GetByName(reqRestore.Workspace, reqRestore.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("restore exists")
+	}
+
+	snapshot, err := NewVirtualMachineSnapshotService(rs.IService).GetByName(reqRestore.Workspace, reqRestore.Spec.Snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot %s error %v", reqRestore.Spec.Snapshot, err)
+	}
+	if snapshot.Spec.State != compute.SnapshotStateReady {
+		return nil, fmt.Errorf("snapshot %s is not ready", snapshot.Name)
+	}
+	if reqRestore.Spec.VirtualMachine == "" {
+		reqRestore.Spec.VirtualMachine = snapshot.Spec.VirtualMachine
+	}
+	if reqRestore.Spec.VirtualMachine != snapshot.Spec.VirtualMachine {
+		return nil, fmt.Errorf("snapshot %s is taken from vm %s", snapshot.Name, snapshot.Spec.VirtualMachine)
+	}
+
+	reqRestore.Kind = compute.VirtualMachineRestoreKind
+	reqRestore.Namespace = snapshot.Namespace
+	reqRestore.Spec.State = compute.RestoreStateRestoring
+	reqRestore.Spec.Status = common.INIT
+	reqRestore.Spec.Message = ""
+	reqRestore.GenerateVersion()
+
+	_, err = rs.IService.Create(common.DefaultDatabase, common.VIRTUALMACHINERESTORE, reqRestore)
+	if err != nil {
+		return nil, err
+	}
+	return reqRestore, nil
+}
+
+func (rs *VirtualMachineRestoreService) Delete(workspace, name string) (core.IObject, error) {
+	restore, err := rs.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	restore.Delete()
+	_, _, err = rs.Apply(common.DefaultDatabase, common.VIRTUALMACHINERESTORE, restore.Name, restore, true)
+	return restore, err
+}
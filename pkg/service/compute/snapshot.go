@@ -0,0 +1,101 @@
+package compute
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/service"
+)
+
+type VirtualMachineSnapshotService struct {
+	service.IService
+}
+
+func NewVirtualMachineSnapshotService(i service.IService) *VirtualMachineSnapshotService {
+	return &VirtualMachineSnapshotService{i}
+}
+
+func (ss *VirtualMachineSnapshotService) List(name, workspace string) (*compute.VirtualMachineSnapshotList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]compute.VirtualMachineSnapshot, 0)
+	err := ss.IService.ListToObject(common.DefaultDatabase, common.VIRTUALMACHINESNAPSHOT, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotList := &compute.VirtualMachineSnapshotList{Items: data}
+	snapshotList.GenerateListVersion()
+
+	return snapshotList, nil
+}
+
+func (ss *VirtualMachineSnapshotService) GetByName(workspace, name string) (*compute.VirtualMachineSnapshot, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	snapshot := &compute.VirtualMachineSnapshot{}
+	err := ss.IService.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINESNAPSHOT, snapshot, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Create record a snapshot of the vm, snapshotctrl takes it on the kubevirt cluster or the provider of the vm
+func (ss *VirtualMachineSnapshotService) Create(reqSnapshot *compute.VirtualMachineSnapshot) (core.IObject, error) {
+	if reqSnapshot.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := ss.GetByName(reqSnapshot.Workspace, reqSnapshot.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("snapshot exists")
+	}
+	if reqSnapshot.Spec.Retention.Days < 0 || reqSnapshot.Spec.Retention.Count < 0 {
+		return nil, fmt.Errorf("retention must not be negative")
+	}
+
+	vm, err := NewVirtualMachineService(ss.IService).GetByName(reqSnapshot.Workspace, reqSnapshot.Spec.VirtualMachine)
+	if err != nil {
+		return nil, fmt.Errorf("get vm %s error %v", reqSnapshot.Spec.VirtualMachine, err)
+	}
+
+	reqSnapshot.Kind = compute.VirtualMachineSnapshotKind
+	reqSnapshot.Namespace = vm.Namespace
+	reqSnapshot.Spec.Vendor = vm.Spec.Vendor
+	reqSnapshot.Spec.RegionId = vm.Spec.RegionId
+	reqSnapshot.Spec.Disks = nil
+	reqSnapshot.Spec.State = compute.SnapshotStateCreating
+	reqSnapshot.Spec.Status = common.INIT
+	reqSnapshot.Spec.Message = ""
+	reqSnapshot.Spec.CreateTime = time.Now().Format(time.RFC3339)
+	reqSnapshot.GenerateVersion()
+
+	_, err = ss.IService.Create(common.DefaultDatabase, common.VIRTUALMACHINESNAPSHOT, reqSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	return reqSnapshot, nil
+}
+
+func (ss *VirtualMachineSnapshotService) Delete(workspace, name string) (core.IObject, error) {
+	snapshot, err := ss.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Delete()
+	_, _, err = ss.Apply(common.DefaultDatabase, common.VIRTUALMACHINESNAPSHOT, snapshot.Name, snapshot, true)
+	return snapshot, err
+}
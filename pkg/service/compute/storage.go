@@ -0,0 +1,180 @@
+package compute
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/quota"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/topology"
+)
+
+type StorageService struct {
+	service.IService
+}
+
+func NewStorageService(i service.IService) *StorageService {
+	return &StorageService{i}
+}
+
+func (ss *StorageService) List(name, workspace string) (*compute.StorageList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]compute.Storage, 0)
+	err := ss.IService.ListToObject(common.DefaultDatabase, common.STORAGE, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	storageList := &compute.StorageList{Items: data}
+	storageList.GenerateListVersion()
+
+	return storageList, nil
+}
+
+func (ss *StorageService) GetByName(workspace, name string) (*compute.Storage, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	storage := &compute.Storage{}
+	err := ss.IService.GetByFilter(common.DefaultDatabase, common.STORAGE, storage, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return storage, nil
+}
+
+// Create record a disk of the provider in the namespace, storagectrl creates it. A disk is attached to a vm once
+// created, through the attach action
+func (ss *StorageService) Create(reqStorage *compute.Storage) (core.IObject, error) {
+	if reqStorage.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := ss.GetByName(reqStorage.Workspace, reqStorage.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("storage exists")
+	}
+	if reqStorage.Spec.Size <= 0 {
+		return nil, fmt.Errorf("storage %s requires a size", reqStorage.Name)
+	}
+	if err := topology.New(ss.IService).Validate(reqStorage.GetNamespace(), reqStorage.Spec.Region, reqStorage.Spec.Zone); err != nil {
+		return nil, err
+	}
+
+	reqStorage.Kind = compute.StorageKind
+	reqStorage.Spec.StorageId = ""
+	reqStorage.Spec.Attachments = nil
+	reqStorage.Spec.VirtualMachine = ""
+	reqStorage.Spec.State = compute.StorageStateCreate
+	reqStorage.Spec.Status = common.INIT
+	reqStorage.Spec.Message = ""
+	reqStorage.GenerateVersion()
+
+	err := quota.New(ss.IService).Admit(common.STORAGE, reqStorage, nil, func() error {
+		_, err := ss.IService.Create(common.DefaultDatabase, common.STORAGE, reqStorage)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reqStorage, nil
+}
+
+// Update expand the storage to reqStorage.Spec.Size through the resize action, the other fields of a disk are fixed
+// once it is created. A disk is never shrunk, a smaller size, e.g. applied again by a roll back, keeps its capacity
+func (ss *StorageService) Update(workspace, name string, reqStorage *compute.Storage) (core.IObject, error) {
+	storage, err := ss.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	if reqStorage.Spec.Size <= storage.Spec.Size {
+		return storage, nil
+	}
+	return ss.Action(workspace, name, "resize", reqStorage)
+}
+
+// Delete a storage attached to a vm is refused, it is detached first
+func (ss *StorageService) Delete(workspace, name string) (core.IObject, error) {
+	storage, err := ss.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(storage.Spec.Attachments) > 0 || storage.Spec.VirtualMachine != "" {
+		return nil, fmt.Errorf("storage %s is attached to vm %s", name, storage.Spec.VirtualMachine)
+	}
+
+	storage.Delete()
+	_, _, err = ss.IService.Apply(common.DefaultDatabase, common.STORAGE, storage.Name, storage, true)
+	return storage, err
+}
+
+// Action record the intent to attach the storage to request.Spec.VirtualMachine, detach it
+// or expand it to request.Spec.Size, storagectrl carries it out
+func (ss *StorageService) Action(workspace, name, action string, request *compute.Storage) (core.IObject, error) {
+	state, exist := compute.StorageActions[action]
+	if !exist {
+		return nil, fmt.Errorf("not support action %s", action)
+	}
+
+	storage, err := ss.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	if storage.Spec.Status == common.INIT || storage.Spec.Status == common.UPDATE {
+		return nil, fmt.Errorf("storage %s is being synced, retry later", name)
+	}
+
+	old := storage.Clone()
+	switch state {
+	case compute.StorageStateAttach:
+		if len(storage.Spec.Attachments) > 0 || storage.Spec.VirtualMachine != "" {
+			return nil, fmt.Errorf("storage %s is attached to vm %s", name, storage.Spec.VirtualMachine)
+		}
+		vm, err := NewVirtualMachineService(ss.IService).GetByName(workspace, request.Spec.VirtualMachine)
+		if err != nil {
+			return nil, fmt.Errorf("get vm %s error %v", request.Spec.VirtualMachine, err)
+		}
+		if vm.Spec.RegionId != "" && storage.Spec.Region != "" && vm.Spec.RegionId != storage.Spec.Region {
+			return nil, fmt.Errorf("storage %s in region %s can not attach to vm in region %s", name, storage.Spec.Region, vm.Spec.RegionId)
+		}
+		if vm.Spec.Az != "" && storage.Spec.Zone != "" && vm.Spec.Az != storage.Spec.Zone {
+			return nil, fmt.Errorf("storage %s in zone %s can not attach to vm in zone %s", name, storage.Spec.Zone, vm.Spec.Az)
+		}
+		storage.Spec.VirtualMachine = vm.GetName()
+
+	case compute.StorageStateDetach:
+		if len(storage.Spec.Attachments) == 0 && storage.Spec.VirtualMachine == "" {
+			return nil, fmt.Errorf("storage %s is not attached", name)
+		}
+
+	case compute.StorageStateResize:
+		if request.Spec.Size <= storage.Spec.Size {
+			return nil, fmt.Errorf("storage %s can only be expanded, size must be larger than %d", name, storage.Spec.Size)
+		}
+		storage.Spec.Size = request.Spec.Size
+	}
+
+	storage.Spec.State = state
+	storage.Spec.Status = common.UPDATE
+	storage.Spec.Message = ""
+
+	// an expansion takes the added capacity from the storage quota
+	err = quota.New(ss.IService).Admit(common.STORAGE, storage, old, func() error {
+		_, _, err := ss.IService.Apply(common.DefaultDatabase, common.STORAGE, storage.Name, storage, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storage, nil
+}
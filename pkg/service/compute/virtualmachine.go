@@ -0,0 +1,181 @@
+package compute
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/quota"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/service/system"
+	"github.com/ddx2x/oilmont/pkg/topology"
+)
+
+type VirtualMachineService struct {
+	service.IService
+}
+
+func NewVirtualMachineService(i service.IService) *VirtualMachineService {
+	return &VirtualMachineService{i}
+}
+
+func (vs *VirtualMachineService) List(name, workspace string) (*compute.VirtualMachineList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]compute.VirtualMachine, 0)
+	err := vs.IService.ListToObject(common.DefaultDatabase, common.VIRTUALMACHINE, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	vmList := &compute.VirtualMachineList{Items: data}
+	vmList.GenerateListVersion()
+
+	return vmList, nil
+}
+
+func (vs *VirtualMachineService) GetByName(workspace, name string) (*compute.VirtualMachine, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	vm := &compute.VirtualMachine{}
+	err := vs.IService.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, vm, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+// Create record a vm sized by its instance type, vmctrl creates it on the kubevirt cluster or the provider
+func (vs *VirtualMachineService) Create(reqVM *compute.VirtualMachine) (core.IObject, error) {
+	if reqVM.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := vs.GetByName(reqVM.Workspace, reqVM.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("vm exists")
+	}
+	if err := topology.New(vs.IService).Validate(reqVM.Spec.Vendor, reqVM.Spec.RegionId, reqVM.Spec.Az); err != nil {
+		return nil, err
+	}
+	if err := vs.size(reqVM); err != nil {
+		return nil, err
+	}
+
+	reqVM.Kind = compute.VirtualMachineKind
+	reqVM.Spec.InstanceId = ""
+	reqVM.Spec.State = ""
+	reqVM.Spec.Status = common.INIT
+	reqVM.Spec.Message = ""
+	reqVM.Spec.CreateTime = time.Now().Format(time.RFC3339)
+	reqVM.GenerateVersion()
+
+	err := quota.New(vs.IService).Admit(common.VIRTUALMACHINE, reqVM, nil, func() error {
+		_, err := vs.IService.Create(common.DefaultDatabase, common.VIRTUALMACHINE, reqVM)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reqVM, nil
+}
+
+// Update resize the vm to another instance type, a kubeVirt vm is applied again with the new sizing
+func (vs *VirtualMachineService) Update(workspace, name string, reqVM *compute.VirtualMachine) (core.IObject, error) {
+	vm, err := vs.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	if vm.Spec.Status == common.INIT || vm.Spec.Status == common.UPDATE {
+		return nil, fmt.Errorf("vm %s is being synced, retry later", name)
+	}
+	if reqVM.Spec.InstanceType == vm.Spec.InstanceType && reqVM.Spec.CPU == vm.Spec.CPU && reqVM.Spec.Memory == vm.Spec.Memory {
+		return vm, nil
+	}
+	if vm.Spec.InstanceId != "" {
+		return nil, fmt.Errorf("resizing vm %s of provider %s is not supported", name, vm.Spec.Vendor)
+	}
+
+	old := vm.Clone()
+	vm.Spec.InstanceType, vm.Spec.CPU, vm.Spec.Memory = reqVM.Spec.InstanceType, reqVM.Spec.CPU, reqVM.Spec.Memory
+	if err := vs.size(vm); err != nil {
+		return nil, err
+	}
+	vm.Spec.State = compute.Updating
+	vm.Spec.Status = common.UPDATE
+	vm.Spec.Message = ""
+
+	err = quota.New(vs.IService).Admit(common.VIRTUALMACHINE, vm, old, func() error {
+		_, _, err := vs.IService.Apply(common.DefaultDatabase, common.VIRTUALMACHINE, vm.Name, vm, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+// Delete record the intent to delete the vm, vmctrl removes it from the kubevirt cluster or the provider
+func (vs *VirtualMachineService) Delete(workspace, name string) (core.IObject, error) {
+	vm, err := vs.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	vm.Delete()
+	_, _, err = vs.IService.Apply(common.DefaultDatabase, common.VIRTUALMACHINE, vm.Name, vm, true)
+	return vm, err
+}
+
+// size take the cpu and memory of the vm from its instance type, a vm without instance type keeps the ones given
+func (vs *VirtualMachineService) size(vm *compute.VirtualMachine) error {
+	if vm.Spec.InstanceType == "" {
+		if vm.Spec.CPU == "" || vm.Spec.Memory == "" {
+			return fmt.Errorf("either an instance type or cpu and memory are required")
+		}
+		return nil
+	}
+	instanceType, err := system.NewInstanceType(vs.IService).Resolve(vm)
+	if err != nil {
+		return err
+	}
+	vm.Spec.CPU = instanceType.CPU()
+	vm.Spec.Memory = instanceType.Spec.Memory
+	return nil
+}
+
+// Action record the intent of a power action on the vm, vmctrl carries it out and reports the state reached
+func (vs *VirtualMachineService) Action(workspace, name, action string) (core.IObject, error) {
+	state, exist := compute.VirtualMachineActions[action]
+	if !exist {
+		return nil, fmt.Errorf("not support action %s", action)
+	}
+
+	vm, err := vs.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	if vm.Spec.Status == common.INIT || vm.Spec.Status == common.UPDATE {
+		return nil, fmt.Errorf("vm %s is being synced, retry later", name)
+	}
+
+	vm.Spec.State = state
+	vm.Spec.Status = common.UPDATE
+	vm.Spec.Message = ""
+
+	_, _, err = vs.IService.Apply(common.DefaultDatabase, common.VIRTUALMACHINE, vm.Name, vm, false)
+	if err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
@@ -5,7 +5,9 @@ import (
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/quota"
 	"github.com/ddx2x/oilmont/pkg/resource/cr"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
 	"github.com/ddx2x/oilmont/pkg/service"
 	"strings"
 )
@@ -65,7 +67,10 @@ func (ss *CustomDataService) Create(resource string, reqCustomData *cr.CustomDat
 
 	reqCustomData.GenerateVersion()
 
-	_, err := ss.IService.Create(common.CustomDatabase, resource, reqCustomData)
+	err := quota.New(ss.IService).Admit(system.QuotaCustomData, reqCustomData, nil, func() error {
+		_, err := ss.IService.Create(common.CustomDatabase, resource, reqCustomData)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +82,9 @@ func (ss *CustomDataService) Upload(resource string, reqCustomData []cr.CustomDa
 	for index := range reqCustomData {
 		objects[index] = &reqCustomData[index]
 	}
-	err := ss.IService.BatchLoad(common.CustomDatabase, resource, objects, false)
+	err := quota.New(ss.IService).AdmitAll(system.QuotaCustomData, objects, func() error {
+		return ss.IService.BatchLoad(common.CustomDatabase, resource, objects, false)
+	})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/resource/event"
+)
+
+var _ IService = &dryRunService{}
+
+// dryRunService read through the wrapped service and drop every write, so a service run on it
+// validates and builds the objects of a change without persisting them
+type dryRunService struct {
+	IService
+}
+
+// DryRun wrap i so nothing is persisted
+func DryRun(i IService) IService {
+	return &dryRunService{i}
+}
+
+func (d *dryRunService) Create(db, resource string, object core.IObject) (core.IObject, error) {
+	return object, nil
+}
+
+func (d *dryRunService) DeleteObject(db, resource, name string, object core.IObject, purge bool) error {
+	return nil
+}
+
+func (d *dryRunService) Delete(db, resource, name, workspace string) error { return nil }
+
+func (d *dryRunService) Apply(db, resource, name string, object core.IObject, forceApply bool, paths ...string) (core.IObject, bool, error) {
+	return object, true, nil
+}
+
+func (d *dryRunService) DeleteByUUID(db, resource, uuid string) error { return nil }
+
+func (d *dryRunService) BatchLoad(db, resource string, objects []core.IObject, forceApply bool) error {
+	return nil
+}
+
+func (d *dryRunService) RemoveTable(db, table string) error { return nil }
+
+func (d *dryRunService) Add(*event.CloudEvent) {}
+
+func (d *dryRunService) GetSelf() IService { return d }
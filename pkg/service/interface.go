@@ -48,6 +48,14 @@ func (bs *BaseService) Add(event *event.CloudEvent) {
 	bs.Create(common.DefaultDatabase, common.CLOUDEVENT, event)
 }
 
+// Create the status of an object is only written by the controllers, the one given with a new object is dropped
+func (bs *BaseService) Create(db, resource string, object core.IObject) (core.IObject, error) {
+	if statusObject, ok := object.(core.IStatusObject); ok {
+		*statusObject.GetStatus() = core.Status{}
+	}
+	return bs.IStorage.Create(db, resource, object)
+}
+
 func (bs *BaseService) DeleteObject(db, resource, name string, object core.IObject, purge bool) error {
 	if err := bs.DeleteByIObject(db, resource, object); err != nil {
 		return err
@@ -0,0 +1,207 @@
+package networking
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/quota"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/topology"
+)
+
+type ElasticIPService struct {
+	service.IService
+}
+
+func NewElasticIPService(i service.IService) *ElasticIPService {
+	return &ElasticIPService{i}
+}
+
+func (es *ElasticIPService) List(name, workspace string) (*networking.ElasticIPList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]networking.ElasticIP, 0)
+	err := es.IService.ListToObject(common.DefaultDatabase, common.ELASTICIP, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	elasticIPList := &networking.ElasticIPList{Items: data}
+	elasticIPList.GenerateListVersion()
+
+	return elasticIPList, nil
+}
+
+func (es *ElasticIPService) GetByName(workspace, name string) (*networking.ElasticIP, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	elasticIP := &networking.ElasticIP{}
+	err := es.IService.GetByFilter(common.DefaultDatabase, common.ELASTICIP, elasticIP, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return elasticIP, nil
+}
+
+// Create record an elastic ip, bound to VirtualMachine if set. The provider allocates the address of the cloud ones,
+// a local one takes the address requested from MetalLB
+func (es *ElasticIPService) Create(reqElasticIP *networking.ElasticIP) (core.IObject, error) {
+	if reqElasticIP.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := es.GetByName(reqElasticIP.Workspace, reqElasticIP.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("elastic ip exists")
+	}
+	if err := es.validate(reqElasticIP); err != nil {
+		return nil, err
+	}
+
+	reqElasticIP.Kind = networking.ElasticIPKind
+	reqElasticIP.Spec.AllocationId = ""
+	reqElasticIP.Spec.InstanceId = ""
+	reqElasticIP.Spec.State = ""
+	reqElasticIP.Spec.Status = common.INIT
+	reqElasticIP.Spec.Message = ""
+	reqElasticIP.GenerateVersion()
+
+	err := quota.New(es.IService).Admit(common.ELASTICIP, reqElasticIP, nil, func() error {
+		_, err := es.IService.Create(common.DefaultDatabase, common.ELASTICIP, reqElasticIP)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reqElasticIP, nil
+}
+
+// Update bind the elastic ip to another vm, or unbind it when VirtualMachine is empty
+func (es *ElasticIPService) Update(workspace, name string, reqElasticIP *networking.ElasticIP) (core.IObject, error) {
+	elasticIP, err := es.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	if elasticIP.Spec.Status == common.INIT || elasticIP.Spec.Status == common.UPDATE {
+		return nil, fmt.Errorf("elastic ip %s is being synced, retry later", name)
+	}
+	if reqElasticIP.Spec.VirtualMachine == elasticIP.Spec.VirtualMachine && intsEqual(reqElasticIP.Spec.Ports, elasticIP.Spec.Ports) {
+		return elasticIP, nil
+	}
+
+	elasticIP.Spec.VirtualMachine = reqElasticIP.Spec.VirtualMachine
+	elasticIP.Spec.Ports = reqElasticIP.Spec.Ports
+	if err := es.validate(elasticIP); err != nil {
+		return nil, err
+	}
+	elasticIP.Spec.Status = common.UPDATE
+	elasticIP.Spec.Message = ""
+
+	_, _, err = es.IService.Apply(common.DefaultDatabase, common.ELASTICIP, elasticIP.Name, elasticIP, false)
+	if err != nil {
+		return nil, err
+	}
+	return elasticIP, nil
+}
+
+// Delete an elastic ip a load balancer is exposed on is refused
+func (es *ElasticIPService) Delete(workspace, name string) (core.IObject, error) {
+	elasticIP, err := es.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	if loadBalancer, err := es.loadBalancerOf(elasticIP); err == nil {
+		return nil, fmt.Errorf("elastic ip %s is used by load balancer %s", name, loadBalancer.GetName())
+	}
+
+	elasticIP.Delete()
+	_, _, err = es.Apply(common.DefaultDatabase, common.ELASTICIP, elasticIP.Name, elasticIP, true)
+	return elasticIP, err
+}
+
+// validate a local elastic ip requires its address and the ports to forward once bound, the vm it is bound to must
+// exist in the workspace and the elastic ip must not front a load balancer
+func (es *ElasticIPService) validate(elasticIP *networking.ElasticIP) error {
+	if elasticIP.Spec.Address != "" && net.ParseIP(elasticIP.Spec.Address).To4() == nil {
+		return fmt.Errorf("invalid address %s", elasticIP.Spec.Address)
+	}
+	if elasticIP.Spec.Bandwidth < 0 {
+		return fmt.Errorf("invalid bandwidth %d", elasticIP.Spec.Bandwidth)
+	}
+	if err := topology.New(es.IService).Validate(elasticIP.Spec.Vendor, elasticIP.Spec.Region, elasticIP.Spec.Az); err != nil {
+		return err
+	}
+	for _, port := range elasticIP.Spec.Ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("port %d must be within 1-65535", port)
+		}
+	}
+	if !isCloud(elasticIP.Spec.Vendor) {
+		if elasticIP.Spec.Address == "" {
+			return fmt.Errorf("local elastic ip requires an address")
+		}
+		if elasticIP.Spec.VirtualMachine != "" && len(elasticIP.Spec.Ports) == 0 {
+			return fmt.Errorf("local elastic ip requires the ports forwarded to vm %s", elasticIP.Spec.VirtualMachine)
+		}
+	}
+	if elasticIP.Spec.VirtualMachine == "" {
+		return nil
+	}
+
+	filter := map[string]interface{}{common.FilterName: elasticIP.Spec.VirtualMachine}
+	if elasticIP.Workspace != "" {
+		filter[common.FilterWorkspace] = elasticIP.Workspace
+	}
+	vm := &compute.VirtualMachine{}
+	if err := es.IService.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, vm, filter, true); err != nil {
+		return fmt.Errorf("vm %s: %v", elasticIP.Spec.VirtualMachine, err)
+	}
+	if vm.Spec.Vendor != elasticIP.Spec.Vendor {
+		return fmt.Errorf("vm %s is not of provider %s", vm.GetName(), elasticIP.Spec.Vendor)
+	}
+	if loadBalancer, err := es.loadBalancerOf(elasticIP); err == nil {
+		return fmt.Errorf("elastic ip %s is used by load balancer %s", elasticIP.GetName(), loadBalancer.GetName())
+	}
+	return nil
+}
+
+func (es *ElasticIPService) loadBalancerOf(elasticIP *networking.ElasticIP) (*networking.LoadBalancer, error) {
+	filter := map[string]interface{}{"spec.elastic_ip": elasticIP.GetName()}
+	if elasticIP.Workspace != "" {
+		filter[common.FilterWorkspace] = elasticIP.Workspace
+	}
+	loadBalancer := &networking.LoadBalancer{}
+	if err := es.IService.GetByFilter(common.DefaultDatabase, common.LOADBALANCER, loadBalancer, filter, true); err != nil {
+		return nil, err
+	}
+	return loadBalancer, nil
+}
+
+// isCloud the vendor is realised through the provider api rather than a local cluster
+func isCloud(vendor string) bool {
+	return vendor == common.AWS || vendor == common.ALIYUN
+}
+
+func intsEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
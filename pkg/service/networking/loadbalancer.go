@@ -0,0 +1,187 @@
+package networking
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/quota"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/topology"
+)
+
+type LoadBalancerService struct {
+	service.IService
+}
+
+func NewLoadBalancerService(i service.IService) *LoadBalancerService {
+	return &LoadBalancerService{i}
+}
+
+func (ls *LoadBalancerService) List(name, workspace string) (*networking.LoadBalancerList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]networking.LoadBalancer, 0)
+	err := ls.IService.ListToObject(common.DefaultDatabase, common.LOADBALANCER, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	loadBalancerList := &networking.LoadBalancerList{Items: data}
+	loadBalancerList.GenerateListVersion()
+
+	return loadBalancerList, nil
+}
+
+func (ls *LoadBalancerService) GetByName(workspace, name string) (*networking.LoadBalancer, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	loadBalancer := &networking.LoadBalancer{}
+	err := ls.IService.GetByFilter(common.DefaultDatabase, common.LOADBALANCER, loadBalancer, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return loadBalancer, nil
+}
+
+// Create record a load balancer forwarding its listeners to the backend vms, loadbalancerctrl realises it
+func (ls *LoadBalancerService) Create(reqLoadBalancer *networking.LoadBalancer) (core.IObject, error) {
+	if reqLoadBalancer.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := ls.GetByName(reqLoadBalancer.Workspace, reqLoadBalancer.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("load balancer exists")
+	}
+	reqLoadBalancer.Spec.Normalize()
+	if err := ls.validate(reqLoadBalancer); err != nil {
+		return nil, err
+	}
+
+	reqLoadBalancer.Kind = networking.LoadBalancerKind
+	reqLoadBalancer.Spec.LoadBalancerId = ""
+	reqLoadBalancer.Spec.Address = ""
+	reqLoadBalancer.Spec.State = networking.LoadBalancerPending
+	reqLoadBalancer.Spec.Status = common.INIT
+	reqLoadBalancer.Spec.Message = ""
+	reqLoadBalancer.GenerateVersion()
+
+	err := quota.New(ls.IService).Admit(common.LOADBALANCER, reqLoadBalancer, nil, func() error {
+		_, err := ls.IService.Create(common.DefaultDatabase, common.LOADBALANCER, reqLoadBalancer)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reqLoadBalancer, nil
+}
+
+// Update replace the listeners, the backends and the health check. The network placement and the elastic ip of a
+// load balancer are fixed once created
+func (ls *LoadBalancerService) Update(workspace, name string, reqLoadBalancer *networking.LoadBalancer) (core.IObject, error) {
+	loadBalancer, err := ls.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	if loadBalancer.Spec.Status == common.INIT || loadBalancer.Spec.Status == common.UPDATE {
+		return nil, fmt.Errorf("load balancer %s is being synced, retry later", name)
+	}
+	reqLoadBalancer.Spec.Normalize()
+	if reflect.DeepEqual(reqLoadBalancer.Spec.Listeners, loadBalancer.Spec.Listeners) &&
+		reflect.DeepEqual(reqLoadBalancer.Spec.Backends, loadBalancer.Spec.Backends) &&
+		reqLoadBalancer.Spec.HealthCheck == loadBalancer.Spec.HealthCheck {
+		return loadBalancer, nil
+	}
+
+	loadBalancer.Spec.Listeners = reqLoadBalancer.Spec.Listeners
+	loadBalancer.Spec.Backends = reqLoadBalancer.Spec.Backends
+	loadBalancer.Spec.HealthCheck = reqLoadBalancer.Spec.HealthCheck
+	if err := ls.validate(loadBalancer); err != nil {
+		return nil, err
+	}
+	loadBalancer.Spec.Status = common.UPDATE
+	loadBalancer.Spec.Message = ""
+
+	_, _, err = ls.IService.Apply(common.DefaultDatabase, common.LOADBALANCER, loadBalancer.Name, loadBalancer, false)
+	if err != nil {
+		return nil, err
+	}
+	return loadBalancer, nil
+}
+
+func (ls *LoadBalancerService) Delete(workspace, name string) (core.IObject, error) {
+	loadBalancer, err := ls.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	loadBalancer.Delete()
+	_, _, err = ls.Apply(common.DefaultDatabase, common.LOADBALANCER, loadBalancer.Name, loadBalancer, true)
+	return loadBalancer, err
+}
+
+// validate the backends must be vms of the workspace and of the same provider, the elastic ip an unbound one.
+// A cloud load balancer is placed in the subnets of a vpc
+func (ls *LoadBalancerService) validate(loadBalancer *networking.LoadBalancer) error {
+	if err := loadBalancer.Spec.Validate(); err != nil {
+		return err
+	}
+	if err := topology.New(ls.IService).Validate(loadBalancer.Spec.Vendor, loadBalancer.Spec.Region, loadBalancer.Spec.Az); err != nil {
+		return err
+	}
+	if isCloud(loadBalancer.Spec.Vendor) && len(loadBalancer.Spec.Subnets) == 0 {
+		return fmt.Errorf("load balancer of provider %s requires a subnet", loadBalancer.Spec.Vendor)
+	}
+
+	for _, backend := range loadBalancer.Spec.Backends {
+		filter := map[string]interface{}{common.FilterName: backend}
+		if loadBalancer.Workspace != "" {
+			filter[common.FilterWorkspace] = loadBalancer.Workspace
+		}
+		vm := &compute.VirtualMachine{}
+		if err := ls.IService.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, vm, filter, true); err != nil {
+			return fmt.Errorf("backend vm %s: %v", backend, err)
+		}
+		if vm.Spec.Vendor != loadBalancer.Spec.Vendor {
+			return fmt.Errorf("backend vm %s is not of provider %s", backend, loadBalancer.Spec.Vendor)
+		}
+	}
+
+	if loadBalancer.Spec.ElasticIP == "" {
+		return nil
+	}
+	if loadBalancer.Spec.Internal {
+		return fmt.Errorf("an internal load balancer can not be exposed on an elastic ip")
+	}
+	elasticIP, err := NewElasticIPService(ls.IService).GetByName(loadBalancer.Workspace, loadBalancer.Spec.ElasticIP)
+	if err != nil {
+		return fmt.Errorf("elastic ip %s: %v", loadBalancer.Spec.ElasticIP, err)
+	}
+	if elasticIP.Spec.Vendor != loadBalancer.Spec.Vendor {
+		return fmt.Errorf("elastic ip %s is not of provider %s", elasticIP.GetName(), loadBalancer.Spec.Vendor)
+	}
+	if elasticIP.Spec.VirtualMachine != "" {
+		return fmt.Errorf("elastic ip %s is bound to vm %s", elasticIP.GetName(), elasticIP.Spec.VirtualMachine)
+	}
+	filter := map[string]interface{}{"spec.elastic_ip": elasticIP.GetName()}
+	if loadBalancer.Workspace != "" {
+		filter[common.FilterWorkspace] = loadBalancer.Workspace
+	}
+	other := &networking.LoadBalancer{}
+	if err := ls.IService.GetByFilter(common.DefaultDatabase, common.LOADBALANCER, other, filter, true); err == nil && other.GetName() != loadBalancer.GetName() {
+		return fmt.Errorf("elastic ip %s is used by load balancer %s", elasticIP.GetName(), other.GetName())
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package networking
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/ipam"
+	"github.com/ddx2x/oilmont/pkg/quota"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/topology"
+)
+
+type VirtualPrivateCloudService struct {
+	service.IService
+}
+
+func NewVirtualPrivateCloudService(i service.IService) *VirtualPrivateCloudService {
+	return &VirtualPrivateCloudService{i}
+}
+
+func (vs *VirtualPrivateCloudService) List(name, workspace string) (*networking.VirtualPrivateCloudList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]networking.VirtualPrivateCloud, 0)
+	err := vs.IService.ListToObject(common.DefaultDatabase, common.VPC, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	vpcList := &networking.VirtualPrivateCloudList{Items: data}
+	vpcList.GenerateListVersion()
+
+	return vpcList, nil
+}
+
+func (vs *VirtualPrivateCloudService) GetByName(workspace, name string) (*networking.VirtualPrivateCloud, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	vpc := &networking.VirtualPrivateCloud{}
+	err := vs.IService.GetByFilter(common.DefaultDatabase, common.VPC, vpc, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return vpc, nil
+}
+
+// Create record a vpc whose cidr does not overlap another vpc of the tenant, vpcctrl takes it on
+func (vs *VirtualPrivateCloudService) Create(reqVpc *networking.VirtualPrivateCloud) (core.IObject, error) {
+	if reqVpc.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := vs.GetByName(reqVpc.Workspace, reqVpc.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("vpc exists")
+	}
+	if err := topology.New(vs.IService).Validate(reqVpc.GetNamespace(), reqVpc.Spec.Region, ""); err != nil {
+		return nil, err
+	}
+	if err := ipam.New(vs.IService).ValidateVPC(reqVpc); err != nil {
+		return nil, err
+	}
+
+	reqVpc.Kind = networking.VirtualPrivateCloudKind
+	reqVpc.Spec.Status = common.INIT
+	reqVpc.Spec.Message = ""
+	reqVpc.GenerateVersion()
+
+	err := quota.New(vs.IService).Admit(common.VPC, reqVpc, nil, func() error {
+		_, err := vs.IService.Create(common.DefaultDatabase, common.VPC, reqVpc)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reqVpc, nil
+}
+
+// Delete a vpc still holding vswitches is refused
+func (vs *VirtualPrivateCloudService) Delete(workspace, name string) (core.IObject, error) {
+	vpc, err := vs.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	vswitches, err := NewVSwitchService(vs.IService).ListOfVPC(workspace, vpc)
+	if err != nil {
+		return nil, err
+	}
+	if len(vswitches) > 0 {
+		return nil, fmt.Errorf("vpc %s still has vswitch %s", name, vswitches[0].GetName())
+	}
+
+	vpc.Delete()
+	_, _, err = vs.Apply(common.DefaultDatabase, common.VPC, vpc.Name, vpc, true)
+	return vpc, err
+}
@@ -0,0 +1,135 @@
+package networking
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/ipam"
+	"github.com/ddx2x/oilmont/pkg/quota"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/topology"
+)
+
+type VSwitchService struct {
+	service.IService
+}
+
+func NewVSwitchService(i service.IService) *VSwitchService {
+	return &VSwitchService{i}
+}
+
+func (vs *VSwitchService) List(name, workspace string) (*networking.VSwitchList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]networking.Vswitch, 0)
+	err := vs.IService.ListToObject(common.DefaultDatabase, common.VSWITCH, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	vswitchList := &networking.VSwitchList{Items: data}
+	vswitchList.GenerateListVersion()
+
+	return vswitchList, nil
+}
+
+// ListOfVPC the vswitches naming vpc by name or vendor id
+func (vs *VSwitchService) ListOfVPC(workspace string, vpc *networking.VirtualPrivateCloud) ([]networking.Vswitch, error) {
+	ids := []string{vpc.GetName()}
+	if vpc.Spec.ID != "" && vpc.Spec.ID != vpc.GetName() {
+		ids = append(ids, vpc.Spec.ID)
+	}
+
+	result := make([]networking.Vswitch, 0)
+	for _, id := range ids {
+		filter := map[string]interface{}{"spec.vpc_id": id}
+		if workspace != "" {
+			filter[common.FilterWorkspace] = workspace
+		}
+		data := make([]networking.Vswitch, 0)
+		if err := vs.IService.ListToObject(common.DefaultDatabase, common.VSWITCH, filter, &data, true); err != nil {
+			return nil, err
+		}
+		result = append(result, data...)
+	}
+	return result, nil
+}
+
+func (vs *VSwitchService) GetByName(workspace, name string) (*networking.Vswitch, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	vswitch := &networking.Vswitch{}
+	err := vs.IService.GetByFilter(common.DefaultDatabase, common.VSWITCH, vswitch, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return vswitch, nil
+}
+
+// Create record a vswitch whose cidr lies inside its vpc and clear of the other vswitches, vswitchctrl takes it on
+func (vs *VSwitchService) Create(reqVswitch *networking.Vswitch) (core.IObject, error) {
+	if reqVswitch.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := vs.GetByName(reqVswitch.Workspace, reqVswitch.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("vswitch exists")
+	}
+	if err := topology.New(vs.IService).Validate(reqVswitch.GetNamespace(), reqVswitch.Spec.Region, reqVswitch.Spec.Zone); err != nil {
+		return nil, err
+	}
+	if err := ipam.New(vs.IService).ValidateVSwitch(reqVswitch); err != nil {
+		return nil, err
+	}
+
+	reqVswitch.Kind = networking.VSwitchKind
+	reqVswitch.Spec.Status = common.INIT
+	reqVswitch.Spec.Message = ""
+	reqVswitch.GenerateVersion()
+
+	err := quota.New(vs.IService).Admit(common.VSWITCH, reqVswitch, nil, func() error {
+		_, err := vs.IService.Create(common.DefaultDatabase, common.VSWITCH, reqVswitch)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reqVswitch, nil
+}
+
+// Delete a vswitch with allocated addresses is refused
+func (vs *VSwitchService) Delete(workspace, name string) (core.IObject, error) {
+	vswitch, err := vs.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := ipam.New(vs.IService).Usage(vswitch)
+	if err == nil && usage.Allocated > 0 {
+		return nil, fmt.Errorf("vswitch %s still has %d allocated address", name, usage.Allocated)
+	}
+
+	vswitch.Delete()
+	_, _, err = vs.Apply(common.DefaultDatabase, common.VSWITCH, vswitch.Name, vswitch, true)
+	return vswitch, err
+}
+
+// Usage the total, reserved and allocated addresses of the vswitch
+func (vs *VSwitchService) Usage(workspace, name string) (*ipam.Usage, error) {
+	vswitch, err := vs.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	return ipam.New(vs.IService).Usage(vswitch)
+}
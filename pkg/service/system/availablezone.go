@@ -7,6 +7,7 @@ import (
 	"github.com/ddx2x/oilmont/pkg/datasource"
 	"github.com/ddx2x/oilmont/pkg/resource/system"
 	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/topology"
 )
 
 type AvailableZoneService struct {
@@ -59,6 +60,12 @@ func (as *AvailableZoneService) Create(reqAvailableZone *system.AvailableZone) (
 	if reqAvailableZone.Name == "" {
 		return nil, fmt.Errorf("name is empty")
 	}
+	if reqAvailableZone.Spec.Region == "" {
+		return nil, fmt.Errorf("availableZone %s requires a region", reqAvailableZone.Name)
+	}
+	if err := topology.New(as.IService).Validate(reqAvailableZone.GetNamespace(), reqAvailableZone.Spec.Region, ""); err != nil {
+		return nil, err
+	}
 
 	reqAvailableZone.Kind = system.AvailableZoneKind
 	reqAvailableZone.GenerateVersion()
@@ -1,10 +1,13 @@
 package system
 
 import (
+	"fmt"
+
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/core"
 	"github.com/ddx2x/oilmont/pkg/resource/system"
 	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/topology"
 )
 
 type ClusterService struct {
@@ -47,7 +50,29 @@ func (s *ClusterService) GetByName(name string) (*system.Cluster, error) {
 	return object, nil
 }
 
+func (s *ClusterService) GetStatus(name string) (*system.ClusterStatus, error) {
+	object, err := s.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return &object.Status, nil
+}
+
+// Create record a cluster, the region and az it is placed in must exist
+func (s *ClusterService) Create(request *system.Cluster) (core.IObject, error) {
+	if request.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if err := topology.New(s.IService).Validate(request.Spec.Provider, request.Spec.Region, request.Spec.Az); err != nil {
+		return nil, err
+	}
+	return s.IService.Create(common.DefaultDatabase, common.CLUSTER, request)
+}
+
 func (s *ClusterService) Update(name string, request *system.Cluster) (core.IObject, bool, error) {
+	if err := topology.New(s.IService).Validate(request.Spec.Provider, request.Spec.Region, request.Spec.Az); err != nil {
+		return nil, false, err
+	}
 	new, update, err := s.IService.Apply(common.DefaultDatabase, common.CLUSTER, name, request, false)
 	if err != nil {
 		return nil, false, err
@@ -0,0 +1,42 @@
+package system
+
+import (
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/service"
+)
+
+type DriftService struct {
+	service.IService
+}
+
+func NewDriftService(i service.IService) *DriftService {
+	return &DriftService{i}
+}
+
+// List the drift found by the last resync of the backend controllers
+func (ds *DriftService) List(name, workspace, table, driftType string) (*system.DriftList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	if table != "" {
+		filter["spec.table"] = table
+	}
+	if driftType != "" {
+		filter["spec.type"] = driftType
+	}
+
+	data := make([]system.Drift, 0)
+	if err := ds.IService.ListToObject(common.DefaultDatabase, common.DRIFT, filter, &data, true); err != nil {
+		return nil, err
+	}
+
+	driftList := &system.DriftList{Items: data}
+	driftList.GenerateListVersion()
+
+	return driftList, nil
+}
@@ -1,12 +1,21 @@
 package system
 
 import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
 	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/ddx2x/oilmont/pkg/common"
 	"github.com/ddx2x/oilmont/pkg/service"
 )
 
+// hugePageSizes the page sizes kubeVirt backs the memory of a vm with
+var hugePageSizes = map[string]bool{"2Mi": true, "1Gi": true}
+
 type InstanceTypeService struct {
 	service.IService
 }
@@ -15,7 +24,8 @@ func NewInstanceType(i service.IService) *InstanceTypeService {
 	return &InstanceTypeService{i}
 }
 
-func (is *InstanceTypeService) List(name, namespace, provider, region, zone string) (*system.InstanceTypeList, error) {
+// List the instance types synced from the providers and the custom ones of tenant
+func (is *InstanceTypeService) List(name, namespace, provider, region, zone, tenant string) (*system.InstanceTypeList, error) {
 	filter := map[string]interface{}{}
 	if name != "" {
 		filter[common.FilterName] = name
@@ -37,7 +47,14 @@ func (is *InstanceTypeService) List(name, namespace, provider, region, zone stri
 		return nil, err
 	}
 
-	instanceTypeList := &system.InstanceTypeList{Items: data}
+	visible := make([]system.InstanceType, 0, len(data))
+	for _, instanceType := range data {
+		if tenant == "" || instanceType.VisibleTo(tenant) {
+			visible = append(visible, instanceType)
+		}
+	}
+
+	instanceTypeList := &system.InstanceTypeList{Items: visible}
 	instanceTypeList.GenerateListVersion()
 
 	return instanceTypeList, nil
@@ -56,3 +73,102 @@ func (is *InstanceTypeService) GetByName(namespace, name string) (*system.Instan
 	}
 	return instanceType, nil
 }
+
+// Create define a custom instance type of the tenant for the kubeVirt vms of the local clusters
+func (is *InstanceTypeService) Create(reqInstanceType *system.InstanceType) (core.IObject, error) {
+	if reqInstanceType.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if reqInstanceType.Workspace == "" {
+		reqInstanceType.Workspace = common.DefaultWorkspace
+	}
+	filter := map[string]interface{}{common.FilterName: reqInstanceType.Name, common.FilterWorkspace: reqInstanceType.Workspace}
+	if err := is.IService.GetByFilter(common.DefaultDatabase, common.INSTANCETYPE, &system.InstanceType{}, filter, true); err != datasource.NotFound {
+		return nil, fmt.Errorf("instance type exists")
+	}
+
+	spec := &reqInstanceType.Spec
+	if spec.Cores <= 0 {
+		return nil, fmt.Errorf("cores must be positive")
+	}
+	if memory, err := resource.ParseQuantity(spec.Memory); err != nil || memory.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid memory %q", spec.Memory)
+	}
+	if spec.HugePages != "" && !hugePageSizes[spec.HugePages] {
+		return nil, fmt.Errorf("huge pages must be 2Mi or 1Gi")
+	}
+	if spec.ID == "" {
+		spec.ID = reqInstanceType.Name
+	}
+	spec.Custom = true
+
+	reqInstanceType.Kind = system.InstanceTypeKind
+	reqInstanceType.Namespace = ""
+	reqInstanceType.GenerateVersion()
+
+	_, err := is.IService.Create(common.DefaultDatabase, common.INSTANCETYPE, reqInstanceType)
+	if err != nil {
+		return nil, err
+	}
+	return reqInstanceType, nil
+}
+
+// Delete a custom instance type, refused while a vm of the tenant uses it
+func (is *InstanceTypeService) Delete(workspace, name string) (core.IObject, error) {
+	if workspace == "" {
+		workspace = common.DefaultWorkspace
+	}
+	instanceType := &system.InstanceType{}
+	filter := map[string]interface{}{common.FilterName: name, common.FilterWorkspace: workspace}
+	if err := is.IService.GetByFilter(common.DefaultDatabase, common.INSTANCETYPE, instanceType, filter, true); err != nil {
+		return nil, err
+	}
+	if !instanceType.Spec.Custom {
+		return nil, fmt.Errorf("instance type %s is synced from provider %s", name, instanceType.GetNamespace())
+	}
+
+	vms := make([]compute.VirtualMachine, 0)
+	if err := is.IService.ListToObject(common.DefaultDatabase, common.VIRTUALMACHINE, map[string]interface{}{"spec.instance_type": instanceType.Spec.ID}, &vms, true); err != nil {
+		return nil, err
+	}
+	for _, vm := range vms {
+		if instanceType.Tenant == "" || vm.Tenant == instanceType.Tenant {
+			return nil, fmt.Errorf("instance type %s is used by vm %s", name, vm.GetName())
+		}
+	}
+
+	instanceType.Delete()
+	_, _, err := is.Apply(common.DefaultDatabase, common.INSTANCETYPE, instanceType.Name, instanceType, true)
+	return instanceType, err
+}
+
+// Resolve the instance type of vm, a cloud vm takes the instance types of its provider,
+// a vm of the local clusters the custom ones visible to its tenant. The type must be offered in the zone of the vm
+func (is *InstanceTypeService) Resolve(vm *compute.VirtualMachine) (*system.InstanceType, error) {
+	provider := &system.Provider{}
+	cloud := is.IService.GetByFilter(common.DefaultDatabase, common.PROVIDER, provider, map[string]interface{}{common.FilterName: vm.GetNamespace()}, true) == nil
+
+	data := make([]system.InstanceType, 0)
+	if err := is.IService.ListToObject(common.DefaultDatabase, common.INSTANCETYPE, map[string]interface{}{"spec.id": vm.Spec.InstanceType}, &data, true); err != nil {
+		return nil, err
+	}
+
+	known := false
+	for index := range data {
+		instanceType := &data[index]
+		if cloud && (instanceType.Spec.Custom || instanceType.GetNamespace() != vm.GetNamespace()) {
+			continue
+		}
+		if !cloud && (!instanceType.Spec.Custom || !instanceType.VisibleTo(vm.Tenant)) {
+			continue
+		}
+		known = true
+		if instanceType.Offers(vm.Spec.RegionId, vm.Spec.Az) {
+			return instanceType, nil
+		}
+	}
+	if known {
+		return nil, fmt.Errorf("instance type %s is not offered in region %s zone %s", vm.Spec.InstanceType, vm.Spec.RegionId, vm.Spec.Az)
+	}
+	return nil, fmt.Errorf("instance type %s not found", vm.Spec.InstanceType)
+}
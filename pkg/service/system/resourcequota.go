@@ -0,0 +1,136 @@
+package system
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/quota"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/service"
+)
+
+type ResourceQuotaService struct {
+	service.IService
+}
+
+func NewResourceQuotaService(i service.IService) *ResourceQuotaService {
+	return &ResourceQuotaService{i}
+}
+
+// List the quotas of workspace, the ones of tenant, or every quota when neither is given
+func (rs *ResourceQuotaService) List(name, workspace, tenant string) (*system.ResourceQuotaList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+	if tenant != "" {
+		filter["metadata.tenant"] = tenant
+	}
+
+	data := make([]system.ResourceQuota, 0)
+	err := rs.IService.ListToObject(common.DefaultDatabase, common.RESOURCEQUOTA, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceQuotaList := &system.ResourceQuotaList{Items: data}
+	resourceQuotaList.GenerateListVersion()
+
+	return resourceQuotaList, nil
+}
+
+func (rs *ResourceQuotaService) GetByName(workspace, name string) (*system.ResourceQuota, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	resourceQuota := &system.ResourceQuota{}
+	err := rs.IService.GetByFilter(common.DefaultDatabase, common.RESOURCEQUOTA, resourceQuota, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return resourceQuota, nil
+}
+
+// Create record a quota of a workspace, or of a tenant when no workspace is given. The tenant of a workspace quota is
+// the one of the workspace. Quota names are unique, a tenant quota is looked up by its name alone
+func (rs *ResourceQuotaService) Create(reqResourceQuota *system.ResourceQuota) (core.IObject, error) {
+	if reqResourceQuota.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := rs.GetByName("", reqResourceQuota.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("resource quota %s exists", reqResourceQuota.Name)
+	}
+	if err := reqResourceQuota.Spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case reqResourceQuota.Workspace != "":
+		workspace, err := NewWorkspaceService(rs.IService).GetByName(reqResourceQuota.Workspace)
+		if err != nil {
+			return nil, fmt.Errorf("get workspace %s error %v", reqResourceQuota.Workspace, err)
+		}
+		reqResourceQuota.Tenant = workspace.Spec.Tenant
+	case reqResourceQuota.Tenant == "":
+		return nil, fmt.Errorf("either a workspace or a tenant is required")
+	}
+
+	reqResourceQuota.Kind = system.ResourceQuotaKind
+	reqResourceQuota.Status = system.ResourceQuotaStatus{}
+	reqResourceQuota.GenerateVersion()
+
+	_, err := rs.IService.Create(common.DefaultDatabase, common.RESOURCEQUOTA, reqResourceQuota)
+	if err != nil {
+		return nil, err
+	}
+	return reqResourceQuota, nil
+}
+
+// Update replace the limits of the quota. A limit lowered below the usage stops the admissions without touching the
+// existing objects, quotactrl reports it exceeded
+func (rs *ResourceQuotaService) Update(workspace, name string, reqResourceQuota *system.ResourceQuota) (core.IObject, bool, error) {
+	resourceQuota, err := rs.GetByName(workspace, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := reqResourceQuota.Spec.Validate(); err != nil {
+		return nil, false, err
+	}
+
+	resourceQuota.Spec.Hard = reqResourceQuota.Spec.Hard
+	_, update, err := rs.IService.Apply(common.DefaultDatabase, common.RESOURCEQUOTA, resourceQuota.Name, resourceQuota, false)
+	if err != nil {
+		return nil, false, err
+	}
+	return resourceQuota, update, nil
+}
+
+func (rs *ResourceQuotaService) Delete(workspace, name string) (core.IObject, error) {
+	object, err := rs.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = rs.DeleteObject(common.DefaultDatabase, common.RESOURCEQUOTA, name, object, true)
+	return object, err
+}
+
+// Usage the live usage of the quota, it may be ahead of the status last reconciled
+func (rs *ResourceQuotaService) Usage(workspace, name string) (map[string]string, error) {
+	resourceQuota, err := rs.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := quota.New(rs.IService).Usage(resourceQuota, nil)
+	if err != nil {
+		return nil, err
+	}
+	return usage.Strings(), nil
+}
@@ -0,0 +1,122 @@
+package system
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/schedule"
+	"github.com/ddx2x/oilmont/pkg/service"
+)
+
+type ScheduleService struct {
+	service.IService
+}
+
+func NewScheduleService(i service.IService) *ScheduleService {
+	return &ScheduleService{i}
+}
+
+func (ss *ScheduleService) List(name, workspace string) (*system.ScheduleList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]system.Schedule, 0)
+	err := ss.IService.ListToObject(common.DefaultDatabase, common.SCHEDULE, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduleList := &system.ScheduleList{Items: data}
+	scheduleList.GenerateListVersion()
+
+	return scheduleList, nil
+}
+
+func (ss *ScheduleService) GetByName(workspace, name string) (*system.Schedule, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	sc := &system.Schedule{}
+	err := ss.IService.GetByFilter(common.DefaultDatabase, common.SCHEDULE, sc, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+func validateSchedule(spec *system.ScheduleSpec) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	_, err := schedule.ParseCron(spec.Cron)
+	return err
+}
+
+// Create record a schedule of the workspace, schedulectrl computes its next run and carries it out
+func (ss *ScheduleService) Create(reqSchedule *system.Schedule) (core.IObject, error) {
+	if reqSchedule.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if reqSchedule.Workspace == "" {
+		return nil, fmt.Errorf("workspace is empty")
+	}
+	if _, err := ss.GetByName(reqSchedule.Workspace, reqSchedule.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("schedule exists")
+	}
+	if err := validateSchedule(&reqSchedule.Spec); err != nil {
+		return nil, err
+	}
+	workspace, err := NewWorkspaceService(ss.IService).GetByName(reqSchedule.Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("get workspace %s error %v", reqSchedule.Workspace, err)
+	}
+
+	reqSchedule.Kind = system.ScheduleKind
+	reqSchedule.Tenant = workspace.Spec.Tenant
+	reqSchedule.Status = system.ScheduleStatus{}
+	reqSchedule.GenerateVersion()
+
+	_, err = ss.IService.Create(common.DefaultDatabase, common.SCHEDULE, reqSchedule)
+	if err != nil {
+		return nil, err
+	}
+	return reqSchedule, nil
+}
+
+// Update replace the spec of the schedule, schedulectrl computes its next run again once it observes the new generation
+func (ss *ScheduleService) Update(workspace, name string, reqSchedule *system.Schedule) (core.IObject, bool, error) {
+	sc, err := ss.GetByName(workspace, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := validateSchedule(&reqSchedule.Spec); err != nil {
+		return nil, false, err
+	}
+
+	sc.Spec = reqSchedule.Spec
+	_, update, err := ss.IService.Apply(common.DefaultDatabase, common.SCHEDULE, sc.Name, sc, false)
+	if err != nil {
+		return nil, false, err
+	}
+	return sc, update, nil
+}
+
+func (ss *ScheduleService) Delete(workspace, name string) (core.IObject, error) {
+	object, err := ss.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ss.DeleteObject(common.DefaultDatabase, common.SCHEDULE, name, object, true)
+	return object, err
+}
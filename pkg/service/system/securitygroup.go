@@ -0,0 +1,186 @@
+package system
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/topology"
+)
+
+type SecurityGroupService struct {
+	service.IService
+}
+
+func NewSecurityGroupService(i service.IService) *SecurityGroupService {
+	return &SecurityGroupService{i}
+}
+
+func (ss *SecurityGroupService) List(name, workspace string) (*system.SecurityGroupList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]system.SecurityGroup, 0)
+	err := ss.IService.ListToObject(common.DefaultDatabase, common.SECURITYGROUP, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	securityGroupList := &system.SecurityGroupList{Items: data}
+	securityGroupList.GenerateListVersion()
+
+	return securityGroupList, nil
+}
+
+func (ss *SecurityGroupService) GetByName(workspace, name string) (*system.SecurityGroup, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	securityGroup := &system.SecurityGroup{}
+	err := ss.IService.GetByFilter(common.DefaultDatabase, common.SECURITYGROUP, securityGroup, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return securityGroup, nil
+}
+
+func (ss *SecurityGroupService) Create(reqSecurityGroup *system.SecurityGroup) (core.IObject, error) {
+	if reqSecurityGroup.Name == "" {
+		return nil, fmt.Errorf("name is empty")
+	}
+	if _, err := ss.GetByName(reqSecurityGroup.Workspace, reqSecurityGroup.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("security group exists")
+	}
+	if err := ss.validate(reqSecurityGroup); err != nil {
+		return nil, err
+	}
+
+	reqSecurityGroup.Kind = system.SecurityGroupKind
+	reqSecurityGroup.Spec.Status = common.INIT
+	reqSecurityGroup.Spec.Message = ""
+	reqSecurityGroup.GenerateVersion()
+
+	_, err := ss.IService.Create(common.DefaultDatabase, common.SECURITYGROUP, reqSecurityGroup)
+	if err != nil {
+		return nil, err
+	}
+	return reqSecurityGroup, nil
+}
+
+// Update replace the rules of the security group, nothing is synced when the rule sets are the same
+func (ss *SecurityGroupService) Update(workspace, name string, reqSecurityGroup *system.SecurityGroup) (core.IObject, bool, error) {
+	securityGroup, err := ss.GetByName(workspace, name)
+	if err != nil {
+		return nil, false, err
+	}
+	reqSecurityGroup.Metadata = securityGroup.Metadata
+	reqSecurityGroup.Spec.ID = securityGroup.Spec.ID
+	if err := ss.validate(reqSecurityGroup); err != nil {
+		return nil, false, err
+	}
+
+	added, removed := system.DiffRules(securityGroup.Spec.Rules(), reqSecurityGroup.Spec.Rules())
+	if len(added) == 0 && len(removed) == 0 && reflect.DeepEqual(securityGroup.Spec.Rules(), reqSecurityGroup.Spec.Rules()) {
+		return securityGroup, false, nil
+	}
+
+	securityGroup.Spec.Ingress = reqSecurityGroup.Spec.Ingress
+	securityGroup.Spec.Egress = reqSecurityGroup.Spec.Egress
+	securityGroup.Spec.Status = common.UPDATE
+	securityGroup.Spec.Message = fmt.Sprintf("%d rules added, %d rules removed", len(added), len(removed))
+
+	_, update, err := ss.IService.Apply(common.DefaultDatabase, common.SECURITYGROUP, securityGroup.Name, securityGroup, false)
+	if err != nil {
+		return nil, false, err
+	}
+	return securityGroup, update, nil
+}
+
+// Delete a security group still used by a vm or referred to by the rules of another group is refused
+func (ss *SecurityGroupService) Delete(workspace, name string) (core.IObject, error) {
+	securityGroup, err := ss.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range referencesOf(securityGroup) {
+		filter := map[string]interface{}{"spec.security_group": id}
+		if workspace != "" {
+			filter[common.FilterWorkspace] = workspace
+		}
+		vm := &compute.VirtualMachine{}
+		if err := ss.IService.GetByFilter(common.DefaultDatabase, common.VIRTUALMACHINE, vm, filter, true); err == nil {
+			return nil, fmt.Errorf("security group %s is used by vm %s", name, vm.GetName())
+		}
+		for _, key := range []string{"spec.ingress.peer_group", "spec.egress.peer_group"} {
+			filter := map[string]interface{}{key: id}
+			if workspace != "" {
+				filter[common.FilterWorkspace] = workspace
+			}
+			peer := &system.SecurityGroup{}
+			err := ss.IService.GetByFilter(common.DefaultDatabase, common.SECURITYGROUP, peer, filter, true)
+			if err == nil && peer.GetName() != name {
+				return nil, fmt.Errorf("security group %s is referred to by security group %s", name, peer.GetName())
+			}
+		}
+	}
+
+	securityGroup.Delete()
+	_, _, err = ss.Apply(common.DefaultDatabase, common.SECURITYGROUP, securityGroup.Name, securityGroup, true)
+	return securityGroup, err
+}
+
+// validate the rules of securityGroup, the peer groups must be security groups of the same workspace
+func (ss *SecurityGroupService) validate(securityGroup *system.SecurityGroup) error {
+	securityGroup.Spec.Normalize()
+	if err := securityGroup.Spec.Validate(); err != nil {
+		return err
+	}
+	if err := topology.New(ss.IService).Validate(securityGroup.GetNamespace(), securityGroup.Spec.RegionId, ""); err != nil {
+		return err
+	}
+
+	for _, rule := range securityGroup.Spec.Rules() {
+		if rule.PeerGroup == "" || rule.PeerGroup == securityGroup.GetName() || rule.PeerGroup == securityGroup.Spec.ID {
+			continue
+		}
+		if _, err := ss.peerOf(securityGroup.GetWorkspace(), rule.PeerGroup); err != nil {
+			return fmt.Errorf("rule %s: peer group %s not found", rule, rule.PeerGroup)
+		}
+	}
+	return nil
+}
+
+// peerOf the security group of workspace whose name or vendor id is id
+func (ss *SecurityGroupService) peerOf(workspace, id string) (*system.SecurityGroup, error) {
+	securityGroup := &system.SecurityGroup{}
+	for _, key := range []string{common.FilterName, "spec.id"} {
+		filter := map[string]interface{}{key: id}
+		if workspace != "" {
+			filter[common.FilterWorkspace] = workspace
+		}
+		if err := ss.IService.GetByFilter(common.DefaultDatabase, common.SECURITYGROUP, securityGroup, filter, true); err == nil {
+			return securityGroup, nil
+		}
+	}
+	return nil, datasource.NotFound
+}
+
+func referencesOf(securityGroup *system.SecurityGroup) []string {
+	if securityGroup.Spec.ID == "" || securityGroup.Spec.ID == securityGroup.GetName() {
+		return []string{securityGroup.GetName()}
+	}
+	return []string{securityGroup.GetName(), securityGroup.Spec.ID}
+}
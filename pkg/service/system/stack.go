@@ -0,0 +1,125 @@
+package system
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/service"
+	"github.com/ddx2x/oilmont/pkg/stack"
+)
+
+type StackService struct {
+	service.IService
+}
+
+func NewStackService(i service.IService) *StackService {
+	return &StackService{i}
+}
+
+func (ss *StackService) List(name, workspace string) (*system.StackList, error) {
+	filter := map[string]interface{}{}
+	if name != "" {
+		filter[common.FilterName] = name
+	}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	data := make([]system.Stack, 0)
+	err := ss.IService.ListToObject(common.DefaultDatabase, common.STACK, filter, &data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	stackList := &system.StackList{Items: data}
+	stackList.GenerateListVersion()
+
+	return stackList, nil
+}
+
+func (ss *StackService) GetByName(workspace, name string) (*system.Stack, error) {
+	filter := map[string]interface{}{common.FilterName: name}
+	if workspace != "" {
+		filter[common.FilterWorkspace] = workspace
+	}
+
+	st := &system.Stack{}
+	err := ss.IService.GetByFilter(common.DefaultDatabase, common.STACK, st, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// validateStack the template must be ordered and every required parameter given
+func validateStack(spec *system.StackSpec) error {
+	if _, err := stack.Order(&spec.Template); err != nil {
+		return err
+	}
+	_, err := stack.Parameters(&spec.Template, spec.Parameters)
+	return err
+}
+
+// Create record a stack of the workspace, stackctrl creates its resources
+func (ss *StackService) Create(reqStack *system.Stack) (core.IObject, error) {
+	if err := system.ValidateStackName(reqStack.Name); err != nil {
+		return nil, err
+	}
+	if reqStack.Workspace == "" {
+		return nil, fmt.Errorf("workspace is empty")
+	}
+	if _, err := ss.GetByName(reqStack.Workspace, reqStack.Name); err != datasource.NotFound {
+		return nil, fmt.Errorf("stack exists")
+	}
+	if err := validateStack(&reqStack.Spec); err != nil {
+		return nil, err
+	}
+	workspace, err := NewWorkspaceService(ss.IService).GetByName(reqStack.Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("get workspace %s error %v", reqStack.Workspace, err)
+	}
+
+	reqStack.Kind = system.StackKind
+	reqStack.Tenant = workspace.Spec.Tenant
+	reqStack.Status = system.StackStatus{}
+	reqStack.GenerateVersion()
+
+	_, err = ss.IService.Create(common.DefaultDatabase, common.STACK, reqStack)
+	if err != nil {
+		return nil, err
+	}
+	return reqStack, nil
+}
+
+// Update replace the template and the parameters of the stack, stackctrl applies the resources which changed
+func (ss *StackService) Update(workspace, name string, reqStack *system.Stack) (core.IObject, bool, error) {
+	st, err := ss.GetByName(workspace, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := validateStack(&reqStack.Spec); err != nil {
+		return nil, false, err
+	}
+
+	st.Spec = reqStack.Spec
+	_, update, err := ss.IService.Apply(common.DefaultDatabase, common.STACK, st.Name, st, false)
+	if err != nil {
+		return nil, false, err
+	}
+	return st, update, nil
+}
+
+// Delete record the intent to delete the stack, stackctrl deletes its resources then the stack
+func (ss *StackService) Delete(workspace, name string) (core.IObject, error) {
+	st, err := ss.GetByName(workspace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	st.Delete()
+	_, _, err = ss.IService.Apply(common.DefaultDatabase, common.STACK, st.Name, st, true)
+	return st, err
+}
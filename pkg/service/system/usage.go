@@ -0,0 +1,33 @@
+package system
+
+import (
+	"github.com/ddx2x/oilmont/pkg/metering"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"github.com/ddx2x/oilmont/pkg/service"
+)
+
+type UsageService struct {
+	service.IService
+}
+
+func NewUsageService(i service.IService) *UsageService {
+	return &UsageService{i}
+}
+
+// List the usage records meterctrl recorded for tenant and workspace from from until to, in unix seconds
+func (us *UsageService) List(tenant, workspace string, from, to int64) (*system.UsageRecordList, error) {
+	data, err := metering.New(us.IService).Records(tenant, workspace, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	usageRecordList := &system.UsageRecordList{Items: data}
+	usageRecordList.GenerateListVersion()
+
+	return usageRecordList, nil
+}
+
+// Invoice the cost of tenant and workspace from from until to, summed by workspace, table and meter
+func (us *UsageService) Invoice(tenant, workspace string, from, to int64) (*metering.Invoice, error) {
+	return metering.New(us.IService).Invoice(tenant, workspace, from, to)
+}
@@ -0,0 +1,340 @@
+package stack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the sources of a reference, ${param.<name>} and ${resource.<name>.<path>}
+const (
+	SourceParam    = "param"
+	SourceResource = "resource"
+)
+
+var referencePattern = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Reference a value of a template taken from a parameter or from the object of a resource
+type Reference struct {
+	Source string
+	Name   string
+	// Path the json path in the object of a resource, e.g. spec.id
+	Path string
+}
+
+func parseReference(expression string) (Reference, error) {
+	parts := strings.SplitN(strings.TrimSpace(expression), ".", 3)
+	switch {
+	case len(parts) == 2 && parts[0] == SourceParam && parts[1] != "":
+		return Reference{Source: SourceParam, Name: parts[1]}, nil
+	case len(parts) == 3 && parts[0] == SourceResource && parts[1] != "" && parts[2] != "":
+		return Reference{Source: SourceResource, Name: parts[1], Path: parts[2]}, nil
+	}
+	return Reference{}, fmt.Errorf("invalid reference ${%s}, expected ${param.<name>} or ${resource.<name>.<path>}", expression)
+}
+
+// References every reference held by the strings of value
+func References(value interface{}) ([]Reference, error) {
+	references := make([]Reference, 0)
+	var walk func(value interface{}) error
+	walk = func(value interface{}) error {
+		switch typed := value.(type) {
+		case string:
+			for _, match := range referencePattern.FindAllStringSubmatch(typed, -1) {
+				reference, err := parseReference(match[1])
+				if err != nil {
+					return err
+				}
+				references = append(references, reference)
+			}
+		case map[string]interface{}:
+			for _, item := range typed {
+				if err := walk(item); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for _, item := range typed {
+				if err := walk(item); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	err := walk(Normalize(value))
+	return references, err
+}
+
+// Dependencies the resources resource depends on, explicitly or by reference, in the order they first appear
+func Dependencies(resource *system.StackResource) ([]string, error) {
+	references, err := References(resource.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("resource %s: %v", resource.Name, err)
+	}
+	seen := make(map[string]bool)
+	dependencies := make([]string, 0)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			dependencies = append(dependencies, name)
+		}
+	}
+	for _, name := range resource.DependsOn {
+		add(name)
+	}
+	for _, reference := range references {
+		if reference.Source == SourceResource {
+			add(reference.Name)
+		}
+	}
+	return dependencies, nil
+}
+
+// Order validate the template and sort its resources so that each one comes after the ones it depends on, the
+// resources without dependency between them keep the order of the template
+func Order(template *system.StackTemplate) ([]string, error) {
+	if err := template.Validate(); err != nil {
+		return nil, err
+	}
+	parameters := make(map[string]bool)
+	for _, parameter := range template.Parameters {
+		parameters[parameter.Name] = true
+	}
+	resources := make(map[string]bool)
+	for _, resource := range template.Resources {
+		resources[resource.Name] = true
+	}
+	checkReferences := func(owner string, references []Reference) error {
+		for _, reference := range references {
+			if reference.Source == SourceParam && !parameters[reference.Name] {
+				return fmt.Errorf("%s references unknown parameter %s", owner, reference.Name)
+			}
+			if reference.Source == SourceResource && !resources[reference.Name] {
+				return fmt.Errorf("%s references unknown resource %s", owner, reference.Name)
+			}
+		}
+		return nil
+	}
+
+	dependencies := make(map[string][]string)
+	for index := range template.Resources {
+		resource := &template.Resources[index]
+		references, err := References(resource.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %v", resource.Name, err)
+		}
+		if err := checkReferences("resource "+resource.Name, references); err != nil {
+			return nil, err
+		}
+		namespaceReferences, err := References(resource.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %v", resource.Name, err)
+		}
+		for _, reference := range namespaceReferences {
+			if reference.Source != SourceParam || !parameters[reference.Name] {
+				return nil, fmt.Errorf("the namespace of resource %s may only reference the parameters", resource.Name)
+			}
+		}
+		if dependencies[resource.Name], err = Dependencies(resource); err != nil {
+			return nil, err
+		}
+		for _, dependency := range dependencies[resource.Name] {
+			if !resources[dependency] {
+				return nil, fmt.Errorf("resource %s depends on unknown resource %s", resource.Name, dependency)
+			}
+		}
+	}
+	for _, output := range template.Outputs {
+		references, err := References(output.Value)
+		if err != nil {
+			return nil, fmt.Errorf("output %s: %v", output.Name, err)
+		}
+		if err := checkReferences("output "+output.Name, references); err != nil {
+			return nil, err
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make(map[string]int)
+	order := make([]string, 0, len(template.Resources))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch states[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle %s", strings.Join(append(path, name), " -> "))
+		}
+		states[name] = visiting
+		for _, dependency := range dependencies[name] {
+			if err := visit(dependency, append(path, name)); err != nil {
+				return err
+			}
+		}
+		states[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, resource := range template.Resources {
+		if err := visit(resource.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// Parameters the values of the parameters of template, the given ones or the defaults. A required parameter must be
+// given and no unknown parameter is accepted
+func Parameters(template *system.StackTemplate, values map[string]string) (map[string]string, error) {
+	result := make(map[string]string)
+	declared := make(map[string]bool)
+	for _, parameter := range template.Parameters {
+		declared[parameter.Name] = true
+		value, exist := values[parameter.Name]
+		if !exist {
+			if parameter.Required {
+				return nil, fmt.Errorf("parameter %s is required", parameter.Name)
+			}
+			value = parameter.Default
+		}
+		result[parameter.Name] = value
+	}
+	for name := range values {
+		if !declared[name] {
+			return nil, fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+	return result, nil
+}
+
+// Render replace the references held by the strings of value. A string made of a single reference takes the value
+// referenced as is, e.g. a number, otherwise the values are formatted into the string. Objects holds the objects of
+// the resources referenced as json maps
+func Render(value interface{}, parameters map[string]string, objects map[string]map[string]interface{}) (interface{}, error) {
+	resolve := func(expression string) (interface{}, error) {
+		reference, err := parseReference(expression)
+		if err != nil {
+			return nil, err
+		}
+		if reference.Source == SourceParam {
+			value, exist := parameters[reference.Name]
+			if !exist {
+				return nil, fmt.Errorf("unknown parameter %s", reference.Name)
+			}
+			return value, nil
+		}
+		object, exist := objects[reference.Name]
+		if !exist {
+			return nil, fmt.Errorf("resource %s is not ready", reference.Name)
+		}
+		value, exist := Lookup(object, reference.Path)
+		if !exist {
+			return nil, fmt.Errorf("resource %s has no %s", reference.Name, reference.Path)
+		}
+		return value, nil
+	}
+
+	switch typed := Normalize(value).(type) {
+	case string:
+		matches := referencePattern.FindAllStringSubmatchIndex(typed, -1)
+		if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(typed) {
+			return resolve(typed[matches[0][2]:matches[0][3]])
+		}
+		var err error
+		rendered := referencePattern.ReplaceAllStringFunc(typed, func(match string) string {
+			value, resolveErr := resolve(match[2 : len(match)-1])
+			if resolveErr != nil && err == nil {
+				err = resolveErr
+			}
+			if value == nil {
+				return ""
+			}
+			return fmt.Sprint(value)
+		})
+		return rendered, err
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			rendered, err := Render(item, parameters, objects)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = rendered
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, 0, len(typed))
+		for _, item := range typed {
+			rendered, err := Render(item, parameters, objects)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, rendered)
+		}
+		return result, nil
+	default:
+		return typed, nil
+	}
+}
+
+// Lookup the value at the dotted path of object
+func Lookup(object map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = object
+	for _, key := range strings.Split(path, ".") {
+		values, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = values[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// Normalize turn the documents and arrays decoded from the stage into plain maps and slices
+func Normalize(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case primitive.D:
+		result := make(map[string]interface{}, len(typed))
+		for _, element := range typed {
+			result[element.Key] = Normalize(element.Value)
+		}
+		return result
+	case primitive.M:
+		return Normalize(map[string]interface{}(typed))
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			result[key] = Normalize(item)
+		}
+		return result
+	case primitive.A:
+		return Normalize([]interface{}(typed))
+	case []interface{}:
+		result := make([]interface{}, 0, len(typed))
+		for _, item := range typed {
+			result = append(result, Normalize(item))
+		}
+		return result
+	}
+	return value
+}
+
+// Hash a digest of value, equal for values equal once normalized
+func Hash(value interface{}) string {
+	bs, _ := json.Marshal(Normalize(value))
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:8])
+}
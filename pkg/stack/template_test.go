@@ -0,0 +1,105 @@
+package stack
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/resource/compute"
+	"github.com/ddx2x/oilmont/pkg/resource/networking"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTemplate() *system.StackTemplate {
+	return &system.StackTemplate{
+		Parameters: []system.StackParameter{{Name: "cidr", Required: true}, {Name: "cpu", Default: "2"}},
+		Resources: []system.StackResource{
+			{Name: "vm", Kind: compute.VirtualMachineKind, DependsOn: []string{"subnet"},
+				Spec: map[string]interface{}{"cpu": "${param.cpu}", "vpc": "${resource.net.metadata.name}"}},
+			{Name: "subnet", Kind: networking.VSwitchKind,
+				Spec: map[string]interface{}{"vpc_id": "${resource.net.spec.id}"}},
+			{Name: "net", Kind: networking.VirtualPrivateCloudKind,
+				Spec: map[string]interface{}{"cidr_block": "${param.cidr}"}},
+		},
+		Outputs: []system.StackOutput{{Name: "vpc", Value: "vpc ${resource.net.spec.id}"}},
+	}
+}
+
+func TestOrder(t *testing.T) {
+	template := newTemplate()
+	order, err := Order(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(order, []string{"net", "subnet", "vm"}) {
+		t.Fatalf("expected the dependencies first, got %v", order)
+	}
+
+	template.Resources[2].DependsOn = []string{"vm"}
+	if _, err := Order(template); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("expected a dependency cycle, got %v", err)
+	}
+
+	template = newTemplate()
+	template.Resources[0].Spec["image"] = "${resource.disk.spec.id}"
+	if _, err := Order(template); err == nil {
+		t.Fatal("expected the reference to an unknown resource refused")
+	}
+	template = newTemplate()
+	template.Outputs[0].Value = "${param.region}"
+	if _, err := Order(template); err == nil {
+		t.Fatal("expected the reference to an unknown parameter refused")
+	}
+}
+
+func TestParameters(t *testing.T) {
+	template := newTemplate()
+	if _, err := Parameters(template, nil); err == nil {
+		t.Fatal("expected the required cidr")
+	}
+	if _, err := Parameters(template, map[string]string{"cidr": "10.0.0.0/16", "zone": "a"}); err == nil {
+		t.Fatal("expected the unknown zone refused")
+	}
+	parameters, err := Parameters(template, map[string]string{"cidr": "10.0.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parameters["cpu"] != "2" {
+		t.Fatalf("expected the default cpu, got %v", parameters)
+	}
+}
+
+func TestRender(t *testing.T) {
+	objects := map[string]map[string]interface{}{
+		"net": {"metadata": map[string]interface{}{"name": "s1-net"}, "spec": map[string]interface{}{"id": "vpc-1", "mtu": float64(1500)}},
+	}
+	value := primitive.D{
+		{Key: "cidr", Value: "${param.cidr}"},
+		{Key: "mtu", Value: "${resource.net.spec.mtu}"},
+		{Key: "tags", Value: primitive.A{"vpc=${resource.net.spec.id}", "name=${resource.net.metadata.name}"}},
+	}
+	rendered, err := Render(value, map[string]string{"cidr": "10.0.0.0/16"}, objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"cidr": "10.0.0.0/16",
+		// a whole reference keeps the type of the value
+		"mtu":  float64(1500),
+		"tags": []interface{}{"vpc=vpc-1", "name=s1-net"},
+	}
+	if !reflect.DeepEqual(rendered, expected) {
+		t.Fatalf("expected %v, got %v", expected, rendered)
+	}
+	if Hash(value) == Hash(rendered) || Hash(primitive.D{{Key: "cidr", Value: "a"}}) != Hash(map[string]interface{}{"cidr": "a"}) {
+		t.Fatal("expected the hash to follow the normalized value")
+	}
+
+	if _, err := Render("${resource.vm.spec.ip}", nil, objects); err == nil {
+		t.Fatal("expected a resource not ready refused")
+	}
+	if _, err := Render("${resource.net.spec.ip}", nil, objects); err == nil {
+		t.Fatal("expected a missing path refused")
+	}
+}
@@ -0,0 +1,250 @@
+package topology
+
+import (
+	"fmt"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+// Storage the part of the datasource topology reads, satisfied by both the stage and the services
+type Storage interface {
+	ListToObject(db, table string, filter map[string]interface{}, result interface{}, filterDelete bool) error
+}
+
+// Cluster a kubernetes cluster placed in a region or an az
+type Cluster struct {
+	Name       string   `json:"name"`
+	Namespaces []string `json:"namespaces"`
+}
+
+type Zone struct {
+	Name      string     `json:"name"`
+	ID        string     `json:"id"`
+	LocalName string     `json:"localName"`
+	Clusters  []*Cluster `json:"clusters"`
+}
+
+// is the zone referred to by name, either its name, its provider id or its local name
+func (z *Zone) is(name string) bool {
+	return name != "" && (z.Name == name || z.ID == name || z.LocalName == name)
+}
+
+type Region struct {
+	Name      string  `json:"name"`
+	ID        string  `json:"id"`
+	LocalName string  `json:"localName"`
+	Zones     []*Zone `json:"zones"`
+	// Clusters the clusters without az, they serve every az of the region
+	Clusters []*Cluster `json:"clusters"`
+}
+
+func (r *Region) is(name string) bool {
+	return name != "" && (r.Name == name || r.ID == name || r.LocalName == name)
+}
+
+// Zone the az of the region referred to by name
+func (r *Region) Zone(name string) *Zone {
+	for _, zone := range r.Zones {
+		if zone.is(name) {
+			return zone
+		}
+	}
+	return nil
+}
+
+type Provider struct {
+	Name       string    `json:"name"`
+	LocalName  string    `json:"localName"`
+	ThirdParty bool      `json:"thirdParty"`
+	Regions    []*Region `json:"regions"`
+}
+
+func (p *Provider) is(name string) bool {
+	return name != "" && (p.Name == name || p.LocalName == name)
+}
+
+// Region the region of the provider referred to by name
+func (p *Provider) Region(name string) *Region {
+	for _, region := range p.Regions {
+		if region.is(name) {
+			return region
+		}
+	}
+	return nil
+}
+
+// Graph the provider -> region -> az -> cluster topology
+type Graph struct {
+	Providers []*Provider `json:"providers"`
+	// Clusters the clusters without region, the legacy placement goes to them
+	Clusters []*Cluster `json:"clusters"`
+}
+
+// Provider the provider referred to by name, either its name or its local name
+func (g *Graph) Provider(name string) *Provider {
+	for _, provider := range g.Providers {
+		if provider.is(name) {
+			return provider
+		}
+	}
+	return nil
+}
+
+// providersOf the providers a reference of provider is looked up in, all of them when provider is empty
+func (g *Graph) providersOf(provider string) []*Provider {
+	if provider == "" {
+		return g.Providers
+	}
+	if p := g.Provider(provider); p != nil {
+		return []*Provider{p}
+	}
+	return nil
+}
+
+// Locate the region and the az referred to by a resource of provider. The az must belong to the region, a resource
+// giving only the az is located in the region of the az
+func (g *Graph) Locate(provider, region, az string) (*Region, *Zone, error) {
+	providers := g.providersOf(provider)
+
+	var foundRegion *Region
+	if region != "" {
+		for _, p := range providers {
+			if foundRegion = p.Region(region); foundRegion != nil {
+				break
+			}
+		}
+		if foundRegion == nil {
+			return nil, nil, fmt.Errorf("region %s%s does not exist", region, ofProvider(provider))
+		}
+	}
+	if az == "" {
+		return foundRegion, nil, nil
+	}
+
+	if foundRegion != nil {
+		if zone := foundRegion.Zone(az); zone != nil {
+			return foundRegion, zone, nil
+		}
+	}
+	for _, p := range providers {
+		for _, r := range p.Regions {
+			zone := r.Zone(az)
+			if zone == nil {
+				continue
+			}
+			if foundRegion != nil {
+				return nil, nil, fmt.Errorf("az %s belongs to region %s, not to region %s", az, r.Name, region)
+			}
+			return r, zone, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("az %s%s does not exist", az, ofProvider(provider))
+}
+
+func ofProvider(provider string) string {
+	if provider == "" {
+		return ""
+	}
+	return fmt.Sprintf(" of provider %s", provider)
+}
+
+type Topology struct {
+	stage Storage
+}
+
+func New(stage Storage) *Topology {
+	return &Topology{stage: stage}
+}
+
+// Graph build the topology from the provider, region, availablezone and cluster tables.
+// Regions and azs are namespaced by their provider, an az refers to its region by name or id
+func (t *Topology) Graph() (*Graph, error) {
+	providers := make([]system.Provider, 0)
+	if err := t.stage.ListToObject(common.DefaultDatabase, common.PROVIDER, map[string]interface{}{}, &providers, true); err != nil {
+		return nil, err
+	}
+	regions := make([]system.Region, 0)
+	if err := t.stage.ListToObject(common.DefaultDatabase, common.REGION, map[string]interface{}{}, &regions, true); err != nil {
+		return nil, err
+	}
+	zones := make([]system.AvailableZone, 0)
+	if err := t.stage.ListToObject(common.DefaultDatabase, common.AVAILABLEZONE, map[string]interface{}{}, &zones, true); err != nil {
+		return nil, err
+	}
+	clusters := make([]system.Cluster, 0)
+	if err := t.stage.ListToObject(common.DefaultDatabase, common.CLUSTER, map[string]interface{}{}, &clusters, true); err != nil {
+		return nil, err
+	}
+
+	graph := &Graph{Providers: make([]*Provider, 0), Clusters: make([]*Cluster, 0)}
+	for _, provider := range providers {
+		graph.Providers = append(graph.Providers, &Provider{
+			Name:       provider.GetName(),
+			LocalName:  provider.Spec.LocalName,
+			ThirdParty: provider.Spec.ThirdParty,
+			Regions:    make([]*Region, 0),
+		})
+	}
+	// regions mirrored from a cluster may come before their provider is recorded
+	providerOf := func(name string) *Provider {
+		if provider := graph.Provider(name); provider != nil {
+			return provider
+		}
+		provider := &Provider{Name: name, Regions: make([]*Region, 0)}
+		graph.Providers = append(graph.Providers, provider)
+		return provider
+	}
+
+	for _, region := range regions {
+		provider := providerOf(region.GetNamespace())
+		provider.Regions = append(provider.Regions, &Region{
+			Name:      region.GetName(),
+			ID:        region.Spec.ID,
+			LocalName: region.Spec.LocalName,
+			Zones:     make([]*Zone, 0),
+			Clusters:  make([]*Cluster, 0),
+		})
+	}
+	for _, zone := range zones {
+		region := providerOf(zone.GetNamespace()).Region(zone.Spec.Region)
+		if region == nil {
+			continue
+		}
+		region.Zones = append(region.Zones, &Zone{
+			Name:      zone.GetName(),
+			ID:        zone.Spec.ID,
+			LocalName: zone.Spec.LocalName,
+			Clusters:  make([]*Cluster, 0),
+		})
+	}
+
+	for _, cluster := range clusters {
+		node := &Cluster{Name: cluster.GetName(), Namespaces: cluster.Spec.Namespaces}
+		region, zone, err := graph.Locate(cluster.Spec.Provider, cluster.Spec.Region, cluster.Spec.Az)
+		switch {
+		case err != nil || region == nil:
+			graph.Clusters = append(graph.Clusters, node)
+		case zone != nil:
+			zone.Clusters = append(zone.Clusters, node)
+		default:
+			region.Clusters = append(region.Clusters, node)
+		}
+	}
+
+	return graph, nil
+}
+
+// Validate the region and az a resource of provider refers to exist and the az belongs to the region.
+// A resource without region nor az keeps the legacy placement on the default cluster
+func (t *Topology) Validate(provider, region, az string) error {
+	if region == "" && az == "" {
+		return nil
+	}
+	graph, err := t.Graph()
+	if err != nil {
+		return err
+	}
+	_, _, err = graph.Locate(provider, region, az)
+	return err
+}
@@ -0,0 +1,92 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/ddx2x/oilmont/pkg/common"
+	"github.com/ddx2x/oilmont/pkg/core"
+	"github.com/ddx2x/oilmont/pkg/datasource/memory"
+	"github.com/ddx2x/oilmont/pkg/resource/system"
+)
+
+func newTopology(t *testing.T) *Topology {
+	stage := memory.NewMemory()
+	objects := map[string][]core.IObject{
+		common.PROVIDER: {
+			&system.Provider{Metadata: core.Metadata{Name: "aws"}, Spec: system.ProviderSpec{LocalName: "Amazon"}},
+		},
+		common.REGION: {
+			&system.Region{Metadata: core.Metadata{Name: "aws-tokyo", Namespace: "aws"}, Spec: system.RegionSpec{ID: "ap-northeast-1", LocalName: "tokyo"}},
+			&system.Region{Metadata: core.Metadata{Name: "aws-seoul", Namespace: "aws"}, Spec: system.RegionSpec{ID: "ap-northeast-2", LocalName: "seoul"}},
+		},
+		common.AVAILABLEZONE: {
+			&system.AvailableZone{Metadata: core.Metadata{Name: "aws-tokyo-a", Namespace: "aws"}, Spec: system.AvailableZoneSpec{Region: "ap-northeast-1", ID: "ap-northeast-1a"}},
+			&system.AvailableZone{Metadata: core.Metadata{Name: "aws-seoul-a", Namespace: "aws"}, Spec: system.AvailableZoneSpec{Region: "aws-seoul", ID: "ap-northeast-2a"}},
+		},
+		common.CLUSTER: {
+			&system.Cluster{Metadata: core.Metadata{Name: "default"}},
+			&system.Cluster{Metadata: core.Metadata{Name: "tokyo-a"}, Spec: system.ClusterSpec{Provider: "aws", Region: "ap-northeast-1", Az: "ap-northeast-1a"}},
+			&system.Cluster{Metadata: core.Metadata{Name: "seoul"}, Spec: system.ClusterSpec{Provider: "aws", Region: "aws-seoul"}},
+		},
+	}
+	for table, items := range objects {
+		for _, item := range items {
+			if _, err := stage.Create(common.DefaultDatabase, table, item); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return New(stage)
+}
+
+func TestGraph(t *testing.T) {
+	graph, err := newTopology(t).Graph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := graph.Provider("Amazon")
+	if provider == nil || len(provider.Regions) != 2 {
+		t.Fatalf("expected provider aws with 2 regions, got %+v", provider)
+	}
+	tokyo := provider.Region("tokyo")
+	if tokyo == nil || len(tokyo.Zones) != 1 || len(tokyo.Zones[0].Clusters) != 1 || tokyo.Zones[0].Clusters[0].Name != "tokyo-a" {
+		t.Fatalf("expected tokyo with az a on cluster tokyo-a, got %+v", tokyo)
+	}
+	seoul := provider.Region("ap-northeast-2")
+	if seoul == nil || len(seoul.Zones) != 1 || len(seoul.Clusters) != 1 || seoul.Clusters[0].Name != "seoul" {
+		t.Fatalf("expected seoul with az a and the region wide cluster seoul, got %+v", seoul)
+	}
+	if len(graph.Clusters) != 1 || graph.Clusters[0].Name != "default" {
+		t.Fatalf("expected the default cluster without region, got %+v", graph.Clusters)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	topology := newTopology(t)
+	for _, item := range [][3]string{
+		{"", "", ""},
+		{"aws", "ap-northeast-1", "ap-northeast-1a"},
+		{"aws", "tokyo", "aws-tokyo-a"},
+		{"aws", "", "ap-northeast-2a"},
+		{"", "aws-seoul", ""},
+	} {
+		if err := topology.Validate(item[0], item[1], item[2]); err != nil {
+			t.Fatalf("expected provider %q region %q az %q to be valid, got %v", item[0], item[1], item[2], err)
+		}
+	}
+	for _, item := range [][3]string{
+		{"aws", "ap-northeast-1", "ap-northeast-2a"},
+		{"aws", "us-east-1", ""},
+		{"aws", "", "us-east-1a"},
+		{"aliyun", "ap-northeast-1", ""},
+	} {
+		if err := topology.Validate(item[0], item[1], item[2]); err == nil {
+			t.Fatalf("expected provider %q region %q az %q to be refused", item[0], item[1], item[2])
+		}
+	}
+
+	graph, _ := topology.Graph()
+	if region, zone, err := graph.Locate("aws", "", "ap-northeast-2a"); err != nil || region.Name != "aws-seoul" || zone.Name != "aws-seoul-a" {
+		t.Fatalf("expected az a located in seoul, got %v %v %v", region, zone, err)
+	}
+}